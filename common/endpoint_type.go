@@ -95,6 +95,14 @@ func ChannelSupportsEndpointType(channelType int, endpointType constant.Endpoint
 			constant.ChannelTypeCodex:
 			return true
 		}
+	case constant.EndpointTypeOpenAIBatch:
+		switch channelType {
+		case constant.ChannelTypeOpenAI,
+			constant.ChannelTypeAzure:
+			return true
+		}
+	case constant.EndpointTypeAnthropicBatch:
+		return channelType == constant.ChannelTypeAnthropic
 	}
 	return false
 }
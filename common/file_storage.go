@@ -0,0 +1,135 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStoragePath 持久化存储根目录，可通过 FILE_STORAGE_PATH 环境变量修改。
+// 默认位于工作目录下（Docker 镜像中为 /data/storage），不使用临时目录，避免文件被系统清理
+var FileStoragePath = "storage"
+
+// 上传文件与批处理结果的存储目录名
+const fileStorageDir = "files"
+
+// 任务结果本地存储目录名
+const assetStorageDir = "assets"
+
+var ErrStoredFileTooLarge = errors.New("file exceeds maximum allowed size")
+
+// GetFileStorageDir 获取持久化文件存储目录
+func GetFileStorageDir() string {
+	return filepath.Join(FileStoragePath, fileStorageDir)
+}
+
+// GetAssetStorageDir 获取任务结果（视频、图片）本地存储目录
+func GetAssetStorageDir() string {
+	return filepath.Join(FileStoragePath, assetStorageDir)
+}
+
+// storedFilePath 根据文件 ID 生成存储路径，拒绝包含路径分隔符的 ID
+func storedFilePath(fileId string) (string, error) {
+	if fileId == "" || strings.ContainsAny(fileId, `/\`) || strings.Contains(fileId, "..") {
+		return "", fmt.Errorf("invalid file id: %q", fileId)
+	}
+	return filepath.Join(GetFileStorageDir(), fileId), nil
+}
+
+// SaveStoredFile 将数据流写入持久化存储，maxBytes <= 0 表示不限制大小，返回写入字节数。
+// 文件以 ID 命名，读取时根据当前存储目录解析路径，因此存储目录变更后只需迁移目录即可
+func SaveStoredFile(fileId string, r io.Reader, maxBytes int64) (int64, error) {
+	if err := os.MkdirAll(GetFileStorageDir(), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create file storage directory: %w", err)
+	}
+	filePath, err := storedFilePath(fileId)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create stored file: %w", err)
+	}
+
+	reader := r
+	if maxBytes > 0 {
+		// 多读一个字节用于判断是否超限
+		reader = io.LimitReader(r, maxBytes+1)
+	}
+	size, err := io.Copy(file, reader)
+	closeErr := file.Close()
+	if err == nil && maxBytes > 0 && size > maxBytes {
+		err = ErrStoredFileTooLarge
+	}
+	if err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+		return 0, err
+	}
+	return size, nil
+}
+
+// AppendStoredFile 向持久化存储中的文件追加数据，文件不存在时创建，返回追加后的文件大小
+func AppendStoredFile(fileId string, data []byte) (int64, error) {
+	if err := os.MkdirAll(GetFileStorageDir(), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create file storage directory: %w", err)
+	}
+	filePath, err := storedFilePath(fileId)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open stored file: %w", err)
+	}
+	_, err = file.Write(data)
+	var size int64
+	if err == nil {
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			size = info.Size()
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// TruncateStoredFile 将持久化存储中的文件截断到指定大小
+func TruncateStoredFile(fileId string, size int64) error {
+	filePath, err := storedFilePath(fileId)
+	if err != nil {
+		return err
+	}
+	return os.Truncate(filePath, size)
+}
+
+// OpenStoredFile 打开持久化存储中的文件
+func OpenStoredFile(fileId string) (*os.File, error) {
+	filePath, err := storedFilePath(fileId)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filePath)
+}
+
+// RemoveStoredFile 删除持久化存储中的文件，文件不存在时不返回错误
+func RemoveStoredFile(fileId string) error {
+	filePath, err := storedFilePath(fileId)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
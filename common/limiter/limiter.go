@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/go-redis/redis/v8"
@@ -13,9 +14,21 @@ import (
 //go:embed lua/rate_limit.lua
 var rateLimitScript string
 
+//go:embed lua/consume.lua
+var consumeScript string
+
+//go:embed lua/concurrency.lua
+var concurrencyScript string
+
+//go:embed lua/concurrency_release.lua
+var concurrencyReleaseScript string
+
 type RedisLimiter struct {
-	client         *redis.Client
-	limitScriptSHA string
+	client                *redis.Client
+	limitScriptSHA        string
+	consumeScriptSHA      string
+	concurrencyScriptSHA  string
+	concurrencyReleaseSHA string
 }
 
 var (
@@ -30,9 +43,24 @@ func New(ctx context.Context, r *redis.Client) *RedisLimiter {
 		if err != nil {
 			common.SysLog(fmt.Sprintf("Failed to load rate limit script: %v", err))
 		}
+		consumeSHA, err := r.ScriptLoad(ctx, consumeScript).Result()
+		if err != nil {
+			common.SysLog(fmt.Sprintf("Failed to load consume script: %v", err))
+		}
+		concurrencySHA, err := r.ScriptLoad(ctx, concurrencyScript).Result()
+		if err != nil {
+			common.SysLog(fmt.Sprintf("Failed to load concurrency script: %v", err))
+		}
+		concurrencyReleaseSHA, err := r.ScriptLoad(ctx, concurrencyReleaseScript).Result()
+		if err != nil {
+			common.SysLog(fmt.Sprintf("Failed to load concurrency release script: %v", err))
+		}
 		instance = &RedisLimiter{
-			client:         r,
-			limitScriptSHA: limitSHA,
+			client:                r,
+			limitScriptSHA:        limitSHA,
+			consumeScriptSHA:      consumeSHA,
+			concurrencyScriptSHA:  concurrencySHA,
+			concurrencyReleaseSHA: concurrencyReleaseSHA,
 		}
 	})
 
@@ -68,6 +96,52 @@ func (rl *RedisLimiter) Allow(ctx context.Context, key string, opts ...Option) (
 	return result == 1, nil
 }
 
+// Consume 按实际用量从令牌桶中扣减 Requested 个令牌（余量不足时也会扣减，余量可为负），
+// 返回扣减后的余量。Requested 为 0 时仅查询当前余量。
+func (rl *RedisLimiter) Consume(ctx context.Context, key string, opts ...Option) (int64, error) {
+	config := &Config{
+		Capacity:  10,
+		Rate:      1,
+		Requested: 0,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	result, err := rl.client.EvalSha(
+		ctx,
+		rl.consumeScriptSHA,
+		[]string{key},
+		config.Requested,
+		config.Rate,
+		config.Capacity,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("rate limit consume failed: %w", err)
+	}
+	return result, nil
+}
+
+// Acquire 获取一个并发名额，已达 limit 时返回 false；ttl 用于进程异常退出后自动释放
+func (rl *RedisLimiter) Acquire(ctx context.Context, key string, limit int64, ttl time.Duration) (bool, error) {
+	result, err := rl.client.EvalSha(
+		ctx,
+		rl.concurrencyScriptSHA,
+		[]string{key},
+		limit,
+		int64(ttl.Seconds()),
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("concurrency limit failed: %w", err)
+	}
+	return result == 1, nil
+}
+
+// Release 释放 Acquire 获取的并发名额
+func (rl *RedisLimiter) Release(ctx context.Context, key string) error {
+	return rl.client.EvalSha(ctx, rl.concurrencyReleaseSHA, []string{key}).Err()
+}
+
 // Config 配置选项模式
 type Config struct {
 	Capacity  int64
@@ -0,0 +1,18 @@
+-- 并发计数器
+-- KEYS[1]: 计数器唯一标识
+-- ARGV[1]: 最大并发数
+-- ARGV[2]: 过期时间（秒），防止进程异常退出后计数无法释放
+-- 返回 1 表示获取成功，0 表示已达上限
+
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+
+local current = tonumber(redis.call('GET', key) or '0')
+if current >= limit then
+    return 0
+end
+
+redis.call('INCR', key)
+redis.call('EXPIRE', key, ttl)
+return 1
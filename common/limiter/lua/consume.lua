@@ -0,0 +1,37 @@
+-- 令牌桶扣减（按实际用量结算）
+-- 与 rate_limit.lua 使用相同的桶格式，但无论余量是否充足都会扣减，余量可以为负，
+-- 负数部分需要按速率补齐后才能再次放行。
+-- KEYS[1]: 限流器唯一标识
+-- ARGV[1]: 扣减令牌数 (为0时仅查询余量)
+-- ARGV[2]: 令牌生成速率 (每秒)
+-- ARGV[3]: 桶容量
+-- 返回扣减后的余量
+
+local key = KEYS[1]
+local requested = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+
+local now = redis.call('TIME')
+local nowInSeconds = tonumber(now[1])
+
+local bucket = redis.call('HMGET', key, 'tokens', 'last_time')
+local tokens = tonumber(bucket[1])
+local last_time = tonumber(bucket[2])
+
+if not tokens or not last_time then
+    tokens = capacity
+    last_time = nowInSeconds
+else
+    local elapsed = nowInSeconds - last_time
+    tokens = math.min(capacity, tokens + elapsed * rate)
+    last_time = nowInSeconds
+end
+
+tokens = tokens - requested
+
+redis.call('HMSET', key, 'tokens', tokens, 'last_time', last_time)
+-- 桶补满所需时间之后状态与新建时一致，可以安全过期
+redis.call('EXPIRE', key, math.ceil((capacity - math.min(tokens, 0)) / rate) + 60)
+
+return tokens
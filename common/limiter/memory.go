@@ -0,0 +1,124 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryLimiter 未启用 Redis 时的单机实现，语义与 RedisLimiter 的 Lua 脚本一致
+type MemoryLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*memoryBucket
+	counts  map[string]int64
+	now     func() time.Time
+}
+
+type memoryBucket struct {
+	tokens   int64
+	lastTime int64
+	expireAt int64
+}
+
+var (
+	memoryInstance *MemoryLimiter
+	memoryOnce     sync.Once
+)
+
+// NewMemory 获取进程内共享的 MemoryLimiter
+func NewMemory() *MemoryLimiter {
+	memoryOnce.Do(func() {
+		memoryInstance = newMemoryLimiter(time.Now)
+		go memoryInstance.clearExpiredBuckets()
+	})
+	return memoryInstance
+}
+
+func newMemoryLimiter(now func() time.Time) *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*memoryBucket),
+		counts:  make(map[string]int64),
+		now:     now,
+	}
+}
+
+func (ml *MemoryLimiter) clearExpiredBuckets() {
+	for {
+		time.Sleep(time.Minute)
+		now := ml.now().Unix()
+		ml.mutex.Lock()
+		for key, bucket := range ml.buckets {
+			if now > bucket.expireAt {
+				delete(ml.buckets, key)
+			}
+		}
+		ml.mutex.Unlock()
+	}
+}
+
+// refill 按经过的秒数补充令牌，调用方需持有锁
+func (ml *MemoryLimiter) refill(key string, config *Config) *memoryBucket {
+	now := ml.now().Unix()
+	bucket, ok := ml.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: config.Capacity, lastTime: now}
+		ml.buckets[key] = bucket
+	} else {
+		bucket.tokens = min(config.Capacity, bucket.tokens+(now-bucket.lastTime)*config.Rate)
+		bucket.lastTime = now
+	}
+	if config.Rate > 0 {
+		bucket.expireAt = now + (config.Capacity-min(bucket.tokens, 0))/config.Rate + 60
+	}
+	return bucket
+}
+
+// Allow 与 RedisLimiter.Allow 相同：余量充足时扣减 Requested 个令牌并放行
+func (ml *MemoryLimiter) Allow(key string, opts ...Option) bool {
+	config := &Config{Capacity: 10, Rate: 1, Requested: 1}
+	for _, opt := range opts {
+		opt(config)
+	}
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+	bucket := ml.refill(key, config)
+	if bucket.tokens < config.Requested {
+		return false
+	}
+	bucket.tokens -= config.Requested
+	return true
+}
+
+// Consume 与 RedisLimiter.Consume 相同：无条件扣减 Requested 个令牌，返回扣减后的余量
+func (ml *MemoryLimiter) Consume(key string, opts ...Option) int64 {
+	config := &Config{Capacity: 10, Rate: 1, Requested: 0}
+	for _, opt := range opts {
+		opt(config)
+	}
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+	bucket := ml.refill(key, config)
+	bucket.tokens -= config.Requested
+	return bucket.tokens
+}
+
+// Acquire 获取一个并发名额，已达 limit 时返回 false
+func (ml *MemoryLimiter) Acquire(key string, limit int64) bool {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+	if ml.counts[key] >= limit {
+		return false
+	}
+	ml.counts[key]++
+	return true
+}
+
+// Release 释放 Acquire 获取的并发名额
+func (ml *MemoryLimiter) Release(key string) {
+	ml.mutex.Lock()
+	defer ml.mutex.Unlock()
+	if ml.counts[key] <= 1 {
+		delete(ml.counts, key)
+		return
+	}
+	ml.counts[key]--
+}
@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	ml := newMemoryLimiter(clock.Now)
+	// 每分钟 2 次：容量 120，每秒补充 2，每次请求 60
+	opts := []Option{WithCapacity(120), WithRate(2), WithRequested(60)}
+
+	require.True(t, ml.Allow("k", opts...))
+	require.True(t, ml.Allow("k", opts...))
+	require.False(t, ml.Allow("k", opts...))
+
+	clock.now = clock.now.Add(29 * time.Second)
+	require.False(t, ml.Allow("k", opts...))
+	clock.now = clock.now.Add(time.Second)
+	require.True(t, ml.Allow("k", opts...))
+	require.True(t, ml.Allow("other", opts...))
+}
+
+func TestMemoryLimiter_ConsumeAllowsNegativeBalance(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	ml := newMemoryLimiter(clock.Now)
+	// 每分钟 100 tokens：容量 6000，每秒补充 100
+	bucket := func(requested int64) []Option {
+		return []Option{WithCapacity(6000), WithRate(100), WithRequested(requested)}
+	}
+
+	require.Equal(t, int64(6000), ml.Consume("k", bucket(0)...))
+	require.Equal(t, int64(-3000), ml.Consume("k", bucket(150*60)...))
+
+	clock.now = clock.now.Add(30 * time.Second)
+	require.Equal(t, int64(0), ml.Consume("k", bucket(0)...))
+	clock.now = clock.now.Add(2 * time.Minute)
+	require.Equal(t, int64(6000), ml.Consume("k", bucket(0)...))
+}
+
+func TestMemoryLimiter_Concurrency(t *testing.T) {
+	ml := newMemoryLimiter(time.Now)
+
+	require.True(t, ml.Acquire("k", 2))
+	require.True(t, ml.Acquire("k", 2))
+	require.False(t, ml.Acquire("k", 2))
+
+	ml.Release("k")
+	require.True(t, ml.Acquire("k", 2))
+
+	ml.Release("k")
+	ml.Release("k")
+	ml.Release("k")
+	require.Empty(t, ml.counts)
+}
@@ -68,3 +68,26 @@ func (l *InMemoryRateLimiter) Request(key string, maxRequestNum int, duration in
 	}
 	return true
 }
+
+// Status returns the number of requests recorded for key within the last duration seconds
+// and the seconds until the oldest of them leaves the window. It does not record a request.
+func (l *InMemoryRateLimiter) Status(key string, duration int64) (count int, reset int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	queue, ok := l.store[key]
+	if !ok {
+		return 0, 0
+	}
+	now := time.Now().Unix()
+	// [old <-- new]
+	for _, t := range *queue {
+		if now-t >= duration {
+			continue
+		}
+		if count == 0 {
+			reset = t + duration - now
+		}
+		count++
+	}
+	return count, reset
+}
@@ -11,14 +11,24 @@ const (
 	ContextKeyRequestStartTime ContextKey = "request_start_time"
 
 	/* token related keys */
-	ContextKeyTokenUnlimited         ContextKey = "token_unlimited_quota"
-	ContextKeyTokenKey               ContextKey = "token_key"
-	ContextKeyTokenId                ContextKey = "token_id"
-	ContextKeyTokenGroup             ContextKey = "token_group"
-	ContextKeyTokenSpecificChannelId ContextKey = "specific_channel_id"
-	ContextKeyTokenModelLimitEnabled ContextKey = "token_model_limit_enabled"
-	ContextKeyTokenModelLimit        ContextKey = "token_model_limit"
-	ContextKeyTokenCrossGroupRetry   ContextKey = "token_cross_group_retry"
+	ContextKeyTokenUnlimited             ContextKey = "token_unlimited_quota"
+	ContextKeyTokenKey                   ContextKey = "token_key"
+	ContextKeyTokenId                    ContextKey = "token_id"
+	ContextKeyTokenGroup                 ContextKey = "token_group"
+	ContextKeyTokenSpecificChannelId     ContextKey = "specific_channel_id"
+	ContextKeyTokenModelLimitEnabled     ContextKey = "token_model_limit_enabled"
+	ContextKeyTokenModelLimit            ContextKey = "token_model_limit"
+	ContextKeyTokenCrossGroupRetry       ContextKey = "token_cross_group_retry"
+	ContextKeyTokenRpmLimit              ContextKey = "token_rpm_limit"
+	ContextKeyTokenTpmLimit              ContextKey = "token_tpm_limit"
+	ContextKeyTokenConcurrencyLimit      ContextKey = "token_concurrency_limit"
+	ContextKeyTokenSemanticCacheDisabled ContextKey = "token_semantic_cache_disabled"
+	ContextKeyTokenModelFallbacks        ContextKey = "token_model_fallbacks"
+	ContextKeyTokenTaskCallbackUrl       ContextKey = "token_task_callback_url"
+	ContextKeyTokenOrganizationId        ContextKey = "token_organization_id"
+
+	// ContextKeyBillingOrganizationId holds the organization whose wallet actually paid for the request, 0 otherwise
+	ContextKeyBillingOrganizationId ContextKey = "billing_organization_id"
 
 	/* channel related keys */
 	ContextKeyChannelId                ContextKey = "channel_id"
@@ -37,25 +47,47 @@ const (
 	ContextKeyChannelIsMultiKey        ContextKey = "channel_is_multi_key"
 	ContextKeyChannelMultiKeyIndex     ContextKey = "channel_multi_key_index"
 	ContextKeyChannelKey               ContextKey = "channel_key"
+	ContextKeyRoutingStrategy          ContextKey = "routing_strategy"
+	ContextKeyHedgeAttempt             ContextKey = "hedge_attempt"
+	ContextKeyStreamFailover           ContextKey = "stream_failover"
+	ContextKeyModelFallbackFrom        ContextKey = "model_fallback_from"
 
 	ContextKeyAutoGroup           ContextKey = "auto_group"
 	ContextKeyAutoGroupIndex      ContextKey = "auto_group_index"
 	ContextKeyAutoGroupRetryIndex ContextKey = "auto_group_retry_index"
 
 	/* user related keys */
-	ContextKeyUserId      ContextKey = "id"
-	ContextKeyUserSetting ContextKey = "user_setting"
-	ContextKeyUserQuota   ContextKey = "user_quota"
-	ContextKeyUserStatus  ContextKey = "user_status"
-	ContextKeyUserEmail   ContextKey = "user_email"
-	ContextKeyUserGroup   ContextKey = "user_group"
-	ContextKeyUsingGroup  ContextKey = "group"
-	ContextKeyUserName    ContextKey = "username"
+	ContextKeyUserId             ContextKey = "id"
+	ContextKeyUserSetting        ContextKey = "user_setting"
+	ContextKeyUserQuota          ContextKey = "user_quota"
+	ContextKeyUserStatus         ContextKey = "user_status"
+	ContextKeyUserEmail          ContextKey = "user_email"
+	ContextKeyUserGroup          ContextKey = "user_group"
+	ContextKeyUsingGroup         ContextKey = "group"
+	ContextKeyUserName           ContextKey = "username"
+	ContextKeyUserOrganizationId ContextKey = "user_organization_id"
 
 	ContextKeyLocalCountTokens ContextKey = "local_count_tokens"
 
 	ContextKeySystemPromptOverride ContextKey = "system_prompt_override"
 
+	// ContextKeyModelTokenRateLimit holds the group × model TPM/TPD reservation of the current request
+	ContextKeyModelTokenRateLimit ContextKey = "model_token_rate_limit"
+	// ContextKeyRateLimitHeaders holds the merged rate limit state already written to the response headers
+	ContextKeyRateLimitHeaders ContextKey = "rate_limit_headers"
+
+	// ContextKeyBatchId marks requests executed by the local batch executor
+	ContextKeyBatchId ContextKey = "batch_id"
+
+	// ContextKeyModerationResult holds the flagged moderation result of the prompt, recorded into the consume log
+	ContextKeyModerationResult ContextKey = "moderation_result"
+	// ContextKeySensitiveOutputBlocked marks a non-stream response withheld because of sensitive words, so it is not billed
+	ContextKeySensitiveOutputBlocked ContextKey = "sensitive_output_blocked"
+	// ContextKeyResponseCacheCapture holds the response cache writer recording the response of a cache miss
+	ContextKeyResponseCacheCapture ContextKey = "response_cache_capture"
+	// ContextKeyResponseCacheLookup holds the response cache lookup done before channel selection
+	ContextKeyResponseCacheLookup ContextKey = "response_cache_lookup"
+
 	// ContextKeyFileSourcesToCleanup stores file sources that need cleanup when request ends
 	ContextKeyFileSourcesToCleanup ContextKey = "file_sources_to_cleanup"
 
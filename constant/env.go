@@ -18,6 +18,9 @@ var ErrorLogEnabled bool
 var TaskQueryLimit int
 var TaskTimeoutMinutes int
 
+// MetricsToken 访问 /metrics 所需的 Bearer Token，为空时不开放 /metrics
+var MetricsToken string
+
 // temporary variable for sora patch, will be removed in future
 var TaskPricePatches []string
 
@@ -3,6 +3,8 @@ package constant
 type MultiKeyMode string
 
 const (
-	MultiKeyModeRandom  MultiKeyMode = "random"  // 随机
-	MultiKeyModePolling MultiKeyMode = "polling" // 轮询
+	MultiKeyModeRandom    MultiKeyMode = "random"     // 随机
+	MultiKeyModePolling   MultiKeyMode = "polling"    // 轮询
+	MultiKeyModeLeastUsed MultiKeyMode = "least-used" // 最近一分钟请求最少优先
+	MultiKeyModeWeighted  MultiKeyMode = "weighted"   // 按 Key 权重随机
 )
@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/billing_setting"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	batchListDefaultLimit = 20
+	batchListMaxLimit     = 100
+)
+
+// errNoBatchChannel 没有支持原生批处理接口的渠道，此时可以由本地执行器执行
+var errNoBatchChannel = errors.New("no channel supports batch api")
+
+// getBatchChannel 为批处理选择支持原生批处理接口的渠道，并设置渠道上下文
+func getBatchChannel(c *gin.Context, info *relaycommon.RelayInfo, retryParam *service.RetryParam, endpoint string) (*model.Channel, error) {
+	var channel *model.Channel
+	if specificChannelId := common.GetContextKeyString(c, constant.ContextKeyTokenSpecificChannelId); specificChannelId != "" {
+		id, err := strconv.Atoi(specificChannelId)
+		if err != nil {
+			return nil, errors.New("invalid channel id")
+		}
+		channel, err = model.GetChannelById(id, true)
+		if err != nil {
+			return nil, errors.New("invalid channel id")
+		}
+		if channel.Status != common.ChannelStatusEnabled {
+			return nil, fmt.Errorf("channel #%d is disabled", channel.Id)
+		}
+		if !service.IsBatchChannelSupported(channel.Type, endpoint) {
+			return nil, fmt.Errorf("channel #%d does not support batch api for endpoint %s: %w", channel.Id, endpoint, errNoBatchChannel)
+		}
+	} else {
+		var selectGroup string
+		var err error
+		channel, selectGroup, err = service.CacheGetRandomSatisfiedChannel(retryParam)
+		if err != nil {
+			return nil, fmt.Errorf("获取分组 %s 下模型 %s 的可用渠道失败: %s", selectGroup, info.OriginModelName, err.Error())
+		}
+		if channel == nil {
+			return nil, fmt.Errorf("分组 %s 下模型 %s 没有支持批处理的可用渠道: %w", selectGroup, info.OriginModelName, errNoBatchChannel)
+		}
+	}
+	if apiErr := middleware.SetupContextForSelectedChannel(c, channel, info.OriginModelName); apiErr != nil {
+		return nil, apiErr.Err
+	}
+	return channel, nil
+}
+
+func getTokenBatchOrAbort(c *gin.Context) *model.Batch {
+	batchId := c.Param("id")
+	batch, exists, err := model.GetTokenBatch(c.GetInt("id"), c.GetInt("token_id"), batchId)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to query batch %s: %s", batchId, err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to query batch")
+		return nil
+	}
+	if !exists {
+		fileApiError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such Batch object: %s", batchId))
+		return nil
+	}
+	return batch
+}
+
+// CreateBatch POST /v1/batches
+func CreateBatch(c *gin.Context) {
+	setting := operation_setting.GetBatchSetting()
+	if !setting.Enabled {
+		RelayNotImplemented(c)
+		return
+	}
+	var req dto.OpenAIBatchRequest
+	if err := common.UnmarshalBodyReusable(c, &req); err != nil {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", "invalid request body")
+		return
+	}
+	if req.InputFileID == "" {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", "input_file_id is required")
+		return
+	}
+	if !service.IsBatchEndpointSupported(req.Endpoint) {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("unsupported endpoint: %s", req.Endpoint))
+		return
+	}
+	if req.CompletionWindow != service.BatchCompletionWindow {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("completion_window must be %s", service.BatchCompletionWindow))
+		return
+	}
+
+	userId := c.GetInt("id")
+	tokenId := c.GetInt("token_id")
+	file, exists, err := model.GetTokenFile(userId, tokenId, req.InputFileID)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to query file %s: %s", req.InputFileID, err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to query file")
+		return
+	}
+	if !exists {
+		fileApiError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such File object: %s", req.InputFileID))
+		return
+	}
+	if file.Purpose != service.BatchFilePurpose {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file purpose must be %s", service.BatchFilePurpose))
+		return
+	}
+
+	reader, err := service.OpenFileContent(c.Request.Context(), file)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to open file %s: %s", file.FileId, err.Error()))
+		fileApiError(c, http.StatusBadGateway, "upstream_error", "failed to read input file")
+		return
+	}
+	input, err := service.ParseBatchInput(reader, req.Endpoint, setting.MaxRequestsPerBatch)
+	_ = reader.Close()
+	if err != nil {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if !middleware.CheckTokenModelLimit(c, input.Model) {
+		return
+	}
+
+	relayInfo, err := relaycommon.GenRelayInfo(c, types.RelayFormatTask, nil, nil)
+	if err != nil {
+		fileApiError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	relayInfo.OriginModelName = input.Model
+	usingGroup := relayInfo.UsingGroup
+
+	now := common.GetTimestamp()
+	batch := &model.Batch{
+		BatchId:          model.GenerateBatchID(),
+		UserId:           userId,
+		TokenId:          tokenId,
+		Endpoint:         req.Endpoint,
+		ModelName:        input.Model,
+		CompletionWindow: req.CompletionWindow,
+		InputFileId:      file.FileId,
+		Status:           dto.BatchStatusValidating,
+		RequestTotal:     len(input.Lines),
+		CreatedAt:        now,
+		ExpiresAt:        now + 24*3600,
+	}
+	if len(req.Metadata) > 0 {
+		metadata, _ := common.Marshal(req.Metadata)
+		batch.Metadata = string(metadata)
+	}
+
+	var bc *model.BatchBillingContext
+	var quota int
+	var submitErr error
+	var errStatus int
+	defer func() {
+		if submitErr != nil && relayInfo.Billing != nil {
+			relayInfo.Billing.Refund(c)
+		}
+	}()
+
+	retryParam := &service.RetryParam{
+		Ctx:          c,
+		TokenGroup:   relayInfo.TokenGroup,
+		ModelName:    input.Model,
+		EndpointType: service.BatchEndpointType(req.Endpoint),
+		Retry:        common.GetPointer(0),
+	}
+	for ; retryParam.GetRetry() <= common.RetryTimes; retryParam.IncreaseRetry() {
+		var channel *model.Channel
+		channel, submitErr = getBatchChannel(c, relayInfo, retryParam, req.Endpoint)
+		if submitErr != nil {
+			// 没有支持原生批处理的渠道时交给本地执行器
+			if bc == nil && errors.Is(submitErr, errNoBatchChannel) && setting.LocalEnabled {
+				createLocalBatch(c, batch, usingGroup)
+				submitErr = nil
+				return
+			}
+			errStatus = http.StatusServiceUnavailable
+			break
+		}
+		addUsedChannel(c, channel.Id)
+		relayInfo.InitChannelMeta(c)
+		relayInfo.UpstreamModelName = input.Model
+		relayInfo.IsModelMapped = false
+		if submitErr = helper.ModelMappedHelper(c, relayInfo, nil); submitErr != nil {
+			errStatus = http.StatusBadRequest
+			break
+		}
+
+		// 预扣费（仅首次 — 重试时 relayInfo.Billing 已存在，跳过）
+		if bc == nil {
+			// 表达式计费依赖完整的请求上下文，无法在原生批处理中逐行结算
+			if billing_setting.GetBillingMode(input.Model) == billing_setting.BillingModeTieredExpr {
+				submitErr = fmt.Errorf("model %s does not support batch api", input.Model)
+				errStatus = http.StatusBadRequest
+				break
+			}
+			priceData, err := helper.ModelPriceHelper(c, relayInfo, input.EstimatedTokens, &types.TokenCountMeta{})
+			if err != nil {
+				submitErr = err
+				errStatus = http.StatusBadRequest
+				break
+			}
+			bc = &model.BatchBillingContext{
+				ModelPrice:         priceData.ModelPrice,
+				ModelRatio:         priceData.ModelRatio,
+				CompletionRatio:    priceData.CompletionRatio,
+				CacheRatio:         priceData.CacheRatio,
+				CacheCreationRatio: priceData.CacheCreationRatio,
+				GroupRatio:         priceData.GroupRatioInfo.GroupRatio,
+				BatchRatio:         ratio_setting.GetBatchRatio(input.Model),
+				UsePrice:           priceData.UsePrice,
+			}
+			if !priceData.FreeModel {
+				quota = service.EstimateBatchQuota(bc, input)
+				relayInfo.ForcePreConsume = true
+				if apiErr := service.PreConsumeBilling(c, quota, relayInfo); apiErr != nil {
+					submitErr = apiErr.Err
+					errStatus = apiErr.StatusCode
+					if errStatus == 0 {
+						errStatus = http.StatusForbidden
+					}
+					break
+				}
+			}
+		}
+		bc.UpstreamModelName = relayInfo.UpstreamModelName
+		batch.PrivateData.BillingContext = bc
+		batch.ChannelId = channel.Id
+
+		submitErr = service.SubmitBatch(c.Request.Context(), channel, batch, file, input)
+		if submitErr == nil {
+			break
+		}
+		errStatus = http.StatusBadGateway
+		logger.LogError(c, fmt.Sprintf("submit batch to channel #%d failed: %s", channel.Id, submitErr.Error()))
+		if common.GetContextKeyString(c, constant.ContextKeyTokenSpecificChannelId) != "" {
+			break
+		}
+	}
+
+	if submitErr != nil {
+		if errStatus == http.StatusBadGateway {
+			fileApiError(c, errStatus, "upstream_error", "failed to submit batch")
+			return
+		}
+		fileApiError(c, errStatus, "new_api_error", submitErr.Error())
+		return
+	}
+
+	batch.Group = relayInfo.UsingGroup
+	batch.Quota = quota
+	batch.PrivateData.BillingSource = relayInfo.BillingSource
+	batch.PrivateData.SubscriptionId = relayInfo.SubscriptionId
+	batch.PrivateData.OrganizationId = relayInfo.BillingOrganizationId()
+	if err := batch.Insert(); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to insert batch: %s", err.Error()))
+		if abortErr := service.AbortSubmittedBatch(c.Request.Context(), batch); abortErr != nil {
+			logger.LogError(c, fmt.Sprintf("failed to cancel upstream batch %s on channel #%d: %s", batch.UpstreamBatchId, batch.ChannelId, abortErr.Error()))
+		}
+		// 由 defer 退还预扣费
+		submitErr = err
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to create batch")
+		return
+	}
+	if settleErr := service.SettleBilling(c, relayInfo, quota); settleErr != nil {
+		common.SysError("settle batch billing error: " + settleErr.Error())
+	}
+	service.LogBatchConsumption(c, relayInfo, batch)
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+// createLocalBatch 创建由本地执行器逐行执行的批处理，每行按普通请求计费，因此不预扣费
+func createLocalBatch(c *gin.Context, batch *model.Batch, usingGroup string) {
+	batch.IsLocal = true
+	batch.Group = usingGroup
+	batch.PrivateData.SpecificChannelId = common.GetContextKeyString(c, constant.ContextKeyTokenSpecificChannelId)
+	if err := batch.Insert(); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to insert batch: %s", err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to create batch")
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+// ListBatches GET /v1/batches
+func ListBatches(c *gin.Context) {
+	limit := batchListDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			fileApiError(c, http.StatusBadRequest, "invalid_request_error", "invalid limit")
+			return
+		}
+		limit = min(parsed, batchListMaxLimit)
+	}
+	batches, err := model.GetTokenBatches(c.GetInt("id"), c.GetInt("token_id"), c.Query("after"), limit)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to list batches: %s", err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to list batches")
+		return
+	}
+	list := dto.OpenAIBatchList{
+		Object: "list",
+		Data:   make([]dto.OpenAIBatch, 0, len(batches)),
+	}
+	if len(batches) > limit {
+		list.HasMore = true
+		batches = batches[:limit]
+	}
+	for _, batch := range batches {
+		list.Data = append(list.Data, batch.ToOpenAIBatch())
+	}
+	if len(list.Data) > 0 {
+		list.FirstID = list.Data[0].ID
+		list.LastID = list.Data[len(list.Data)-1].ID
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RetrieveBatch GET /v1/batches/:id
+func RetrieveBatch(c *gin.Context) {
+	batch := getTokenBatchOrAbort(c)
+	if batch == nil {
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
+
+// CancelBatch POST /v1/batches/:id/cancel
+func CancelBatch(c *gin.Context) {
+	batch := getTokenBatchOrAbort(c)
+	if batch == nil {
+		return
+	}
+	if batch.Status == dto.BatchStatusCancelling || model.IsBatchStatusFinished(batch.Status) {
+		c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+		return
+	}
+	if err := service.CancelBatch(c.Request.Context(), batch); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to cancel batch %s: %s", batch.BatchId, err.Error()))
+		fileApiError(c, http.StatusBadGateway, "upstream_error", "failed to cancel batch")
+		return
+	}
+	c.JSON(http.StatusOK, batch.ToOpenAIBatch())
+}
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	batchLocalTickInterval = 30 * time.Second
+	batchLocalQueryLimit   = 20
+)
+
+var batchLocalExecutorOnce sync.Once
+
+// batchLocalRelayFormats 本地执行时各 endpoint 对应的中继格式，与 relay-router 保持一致
+var batchLocalRelayFormats = map[string]types.RelayFormat{
+	"/v1/chat/completions": types.RelayFormatOpenAI,
+	"/v1/completions":      types.RelayFormatOpenAI,
+	"/v1/embeddings":       types.RelayFormatEmbedding,
+	"/v1/responses":        types.RelayFormatOpenAIResponses,
+	"/v1/messages":         types.RelayFormatClaude,
+}
+
+// StartBatchLocalExecutor 启动本地批处理执行器：在配置的时间窗口内按并发数逐行执行本地批处理
+func StartBatchLocalExecutor() {
+	batchLocalExecutorOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("batch local executor started: tick=%s", batchLocalTickInterval))
+			ticker := time.NewTicker(batchLocalTickInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runBatchLocalExecutorOnce()
+			}
+		})
+	})
+}
+
+func runBatchLocalExecutorOnce() {
+	ctx := context.Background()
+	batches, err := model.GetUnfinishedLocalBatches(batchLocalQueryLimit)
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("get unfinished local batches failed: %s", err.Error()))
+		return
+	}
+	for _, batch := range batches {
+		if err := processLocalBatch(ctx, batch); err != nil {
+			logger.LogError(ctx, fmt.Sprintf("process local batch %s failed: %s", batch.BatchId, err.Error()))
+		}
+	}
+}
+
+// processLocalBatch 执行一个本地批处理，直到完成、离开时间窗口或被取消
+func processLocalBatch(ctx context.Context, batch *model.Batch) error {
+	// 取消与过期不受时间窗口限制
+	if batch.Status == dto.BatchStatusCancelling {
+		_, err := service.FinalizeLocalBatch(ctx, batch, dto.BatchStatusCancelling, dto.BatchStatusCancelled)
+		return err
+	}
+	if batch.ExpiresAt > 0 && common.GetTimestamp() > batch.ExpiresAt {
+		return service.ExpireLocalBatch(ctx, batch)
+	}
+	setting := operation_setting.GetBatchSetting()
+	if !setting.Enabled || !setting.LocalEnabled || !operation_setting.IsInBatchLocalWindow(time.Now()) {
+		return nil
+	}
+
+	input, err := service.LoadBatchInput(ctx, batch)
+	if err != nil {
+		return service.FailLocalBatch(ctx, batch, "invalid_input_file", err.Error())
+	}
+	token, err := model.GetTokenById(batch.TokenId)
+	if err != nil || token.Status != common.TokenStatusEnabled {
+		return service.FailLocalBatch(ctx, batch, "invalid_token", "the token that created this batch is no longer available")
+	}
+	userCache, err := model.GetUserCache(batch.UserId)
+	if err != nil {
+		return err
+	}
+	if userCache.Status != common.UserStatusEnabled {
+		return service.FailLocalBatch(ctx, batch, "user_disabled", "the user that created this batch has been disabled")
+	}
+
+	if batch.Status == dto.BatchStatusValidating {
+		batch.Status = dto.BatchStatusInProgress
+		batch.InProgressAt = common.GetTimestamp()
+		won, err := batch.UpdateWithStatus(dto.BatchStatusValidating)
+		if err != nil || !won {
+			return err
+		}
+	}
+
+	if err := service.PrepareBatchLocalFiles(batch); err != nil {
+		return err
+	}
+	// 上次执行可能在写入结果后、保存进度前中断，跳过已有结果的行
+	done, err := service.RecoverBatchLocalResults(batch)
+	if err != nil {
+		return err
+	}
+
+	concurrency := operation_setting.GetBatchLocalConcurrency()
+	if token.ConcurrencyLimit > 0 {
+		concurrency = min(concurrency, token.ConcurrencyLimit)
+	}
+	for batch.PrivateData.LocalCursor < len(input.Lines) {
+		if !operation_setting.IsInBatchLocalWindow(time.Now()) || common.GetTimestamp() > batch.ExpiresAt {
+			return nil
+		}
+		// 取消请求由下一轮处理
+		if status, err := model.GetBatchStatus(batch.Id); err != nil || status != dto.BatchStatusInProgress {
+			return err
+		}
+		end := min(batch.PrivateData.LocalCursor+concurrency, len(input.Lines))
+		lines := input.Lines[batch.PrivateData.LocalCursor:end]
+		// 逐行执行同样受令牌 RPM/TPM 限制，超出时留待下一轮继续
+		if lines, err = throttleLocalBatchLines(ctx, token, lines); err != nil || len(lines) == 0 {
+			return err
+		}
+		pending := make([]*dto.OpenAIBatchInputLine, 0, len(lines))
+		for i := range lines {
+			if _, ok := done[lines[i].CustomID]; !ok {
+				pending = append(pending, &lines[i])
+			}
+		}
+		results := make([]service.BatchLocalLineResult, len(pending))
+		var wg sync.WaitGroup
+		for i, line := range pending {
+			wg.Add(1)
+			gopool.Go(func() {
+				defer wg.Done()
+				results[i] = executeBatchLocalLine(batch, token, userCache, line)
+			})
+		}
+		wg.Wait()
+		if err := service.AppendBatchLocalResults(batch, len(lines), results); err != nil {
+			return err
+		}
+		if err := batch.UpdateLocalProgress(); err != nil {
+			return err
+		}
+	}
+	_, err = service.FinalizeLocalBatch(ctx, batch, dto.BatchStatusInProgress, dto.BatchStatusCompleted)
+	return err
+}
+
+// throttleLocalBatchLines 按令牌的 TPM 余量与 RPM 限制截取本轮可以执行的行
+func throttleLocalBatchLines(ctx context.Context, token *model.Token, lines []dto.OpenAIBatchInputLine) ([]dto.OpenAIBatchInputLine, error) {
+	available, err := service.IsTokenTpmAvailable(ctx, token.Id, token.TpmLimit)
+	if err != nil || !available {
+		return nil, err
+	}
+	for i := range lines {
+		allowed, err := service.AllowTokenRequest(ctx, token.Id, token.RpmLimit)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return lines[:i], nil
+		}
+	}
+	return lines, nil
+}
+
+// executeBatchLocalLine 以创建批处理的令牌身份，通过普通中继流程（Distribute → Relay）执行一行请求
+func executeBatchLocalLine(batch *model.Batch, token *model.Token, userCache *model.UserBase, line *dto.OpenAIBatchInputLine) service.BatchLocalLineResult {
+	result := service.BatchLocalLineResult{CustomID: line.CustomID}
+	relayFormat, ok := batchLocalRelayFormats[line.URL]
+	if !ok {
+		result.Error = &dto.OpenAIBatchError{Code: "invalid_url", Message: fmt.Sprintf("unsupported url: %s", line.URL)}
+		return result
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	requestId := common.GetTimeString() + common.GetRandomString(8)
+	req, err := http.NewRequestWithContext(context.WithValue(context.Background(), common.RequestIdKey, requestId), http.MethodPost, line.URL, bytes.NewReader(line.Body))
+	if err != nil {
+		result.Error = &dto.OpenAIBatchError{Code: "invalid_request", Message: err.Error()}
+		return result
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+	c.Set(common.RequestIdKey, requestId)
+	defer func() {
+		common.CleanupBodyStorage(c)
+		service.CleanupFileSources(c)
+	}()
+
+	userCache.WriteContext(c)
+	common.SetContextKey(c, constant.ContextKeyUsingGroup, batch.Group)
+	_ = middleware.SetupContextForToken(c, token)
+	if batch.PrivateData.SpecificChannelId != "" {
+		common.SetContextKey(c, constant.ContextKeyTokenSpecificChannelId, batch.PrivateData.SpecificChannelId)
+	}
+	common.SetContextKey(c, constant.ContextKeyBatchId, batch.BatchId)
+
+	middleware.ResponseCache()(c)
+	middleware.Distribute()(c)
+	if !c.IsAborted() {
+		Relay(c, relayFormat)
+	}
+	result.StatusCode = w.Code
+	result.RequestID = requestId
+	result.Body = w.Body.Bytes()
+	return result
+}
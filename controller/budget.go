@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BudgetRequest struct {
+	UserId      int    `json:"user_id"` // 仅管理员接口使用
+	TokenId     int    `json:"token_id"`
+	PeriodType  string `json:"period_type"`
+	QuotaLimit  int    `json:"quota_limit"`
+	SoftPercent int    `json:"soft_percent"`
+	Status      int    `json:"status"`
+}
+
+func validateBudgetRequest(req *BudgetRequest) string {
+	if !model.IsValidBudgetPeriod(req.PeriodType) {
+		return "无效的预算周期"
+	}
+	if req.QuotaLimit <= 0 {
+		return "预算上限必须大于 0"
+	}
+	if req.SoftPercent < 0 || req.SoftPercent > 100 {
+		return "提醒阈值必须在 0-100 之间"
+	}
+	if req.Status == 0 {
+		req.Status = model.BudgetStatusEnabled
+	}
+	if req.Status != model.BudgetStatusEnabled && req.Status != model.BudgetStatusDisabled {
+		return "无效的预算状态"
+	}
+	return ""
+}
+
+func saveBudget(c *gin.Context, userId int, req *BudgetRequest, createdBy string) {
+	budget := &model.Budget{
+		UserId:      userId,
+		TokenId:     req.TokenId,
+		PeriodType:  req.PeriodType,
+		QuotaLimit:  req.QuotaLimit,
+		SoftPercent: req.SoftPercent,
+		Status:      req.Status,
+		CreatedBy:   createdBy,
+	}
+	if err := model.UpsertBudget(budget); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, budget)
+}
+
+// parseBudgetTokenId 解析 token_id 查询参数，未提供时返回 -1 表示不过滤
+func parseBudgetTokenId(c *gin.Context) int {
+	tokenIdStr := c.Query("token_id")
+	if tokenIdStr == "" {
+		return -1
+	}
+	tokenId, err := strconv.Atoi(tokenIdStr)
+	if err != nil {
+		return -1
+	}
+	return tokenId
+}
+
+// ---- Admin APIs ----
+
+func GetBudgets(c *gin.Context) {
+	userId, _ := strconv.Atoi(c.Query("user_id"))
+	if userId <= 0 {
+		common.ApiErrorMsg(c, "无效的用户ID")
+		return
+	}
+	budgets, err := model.GetBudgets(userId, parseBudgetTokenId(c))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, budgets)
+}
+
+func SaveBudget(c *gin.Context) {
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserId <= 0 || req.TokenId < 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if msg := validateBudgetRequest(&req); msg != "" {
+		common.ApiErrorMsg(c, msg)
+		return
+	}
+	if req.TokenId > 0 {
+		if _, err := model.GetTokenByIds(req.TokenId, req.UserId); err != nil {
+			common.ApiErrorMsg(c, "令牌不存在或不属于该用户")
+			return
+		}
+	}
+	saveBudget(c, req.UserId, &req, model.BudgetCreatorAdmin)
+}
+
+func DeleteBudget(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		common.ApiErrorMsg(c, "无效的预算ID")
+		return
+	}
+	if err := model.DeleteBudgetById(id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+// ---- Self APIs ----
+// 用户可以查看自己的全部预算，但只能管理自己令牌上的预算，用户级预算由管理员设置。
+// 管理员在令牌上设置的预算用户不能删除或停用，只能调低上限。
+
+func GetSelfBudgets(c *gin.Context) {
+	budgets, err := model.GetBudgets(c.GetInt("id"), parseBudgetTokenId(c))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, budgets)
+}
+
+func SaveSelfBudget(c *gin.Context) {
+	userId := c.GetInt("id")
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.TokenId <= 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if msg := validateBudgetRequest(&req); msg != "" {
+		common.ApiErrorMsg(c, msg)
+		return
+	}
+	if _, err := model.GetTokenByIds(req.TokenId, userId); err != nil {
+		common.ApiErrorMsg(c, "令牌不存在")
+		return
+	}
+	existing, err := model.GetBudgetByScope(userId, req.TokenId, req.PeriodType)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if existing != nil && existing.CreatedBy != model.BudgetCreatorSelf {
+		if existing.Status == model.BudgetStatusEnabled && req.Status != model.BudgetStatusEnabled {
+			common.ApiErrorMsg(c, "不能停用管理员设置的预算")
+			return
+		}
+		if req.QuotaLimit > existing.QuotaLimit {
+			common.ApiErrorMsg(c, "管理员设置的预算只能调低上限")
+			return
+		}
+		saveBudget(c, userId, &req, existing.CreatedBy)
+		return
+	}
+	saveBudget(c, userId, &req, model.BudgetCreatorSelf)
+}
+
+func DeleteSelfBudget(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	budget, err := model.GetBudgetById(id)
+	if err != nil || budget.UserId != c.GetInt("id") || budget.TokenId <= 0 {
+		common.ApiErrorMsg(c, "预算不存在")
+		return
+	}
+	if budget.CreatedBy != model.BudgetCreatorSelf {
+		common.ApiErrorMsg(c, "不能删除管理员设置的预算")
+		return
+	}
+	if err := model.DeleteBudgetById(budget.Id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
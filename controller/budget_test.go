@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/QuantumNous/new-api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfBudgetCannotLoosenAdminBudget(t *testing.T) {
+	db := setupTokenControllerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Budget{}, &model.BudgetUsage{}))
+
+	const userID = 1
+	token := seedToken(t, db, userID, "budget", "sk-budget-key")
+
+	adminBudget := &model.Budget{UserId: userID, TokenId: token.Id, PeriodType: model.BudgetPeriodDaily, QuotaLimit: 1000}
+	require.NoError(t, model.UpsertBudget(adminBudget))
+	assert.Equal(t, model.BudgetCreatorAdmin, adminBudget.CreatedBy)
+
+	saveSelf := func(req BudgetRequest) tokenAPIResponse {
+		ctx, recorder := newAuthenticatedContext(t, http.MethodPost, "/api/budget/self", req, userID)
+		SaveSelfBudget(ctx)
+		return decodeAPIResponse(t, recorder)
+	}
+
+	// 不能调高或停用管理员设置的预算
+	assert.False(t, saveSelf(BudgetRequest{TokenId: token.Id, PeriodType: model.BudgetPeriodDaily, QuotaLimit: 5000}).Success)
+	assert.False(t, saveSelf(BudgetRequest{TokenId: token.Id, PeriodType: model.BudgetPeriodDaily, QuotaLimit: 500, Status: model.BudgetStatusDisabled}).Success)
+
+	// 调低上限后预算仍归管理员所有
+	assert.True(t, saveSelf(BudgetRequest{TokenId: token.Id, PeriodType: model.BudgetPeriodDaily, QuotaLimit: 500}).Success)
+	budget, err := model.GetBudgetById(adminBudget.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 500, budget.QuotaLimit)
+	assert.Equal(t, model.BudgetCreatorAdmin, budget.CreatedBy)
+
+	deleteSelf := func(id int) tokenAPIResponse {
+		ctx, recorder := newAuthenticatedContext(t, http.MethodDelete, "/api/budget/self/"+strconv.Itoa(id), nil, userID)
+		ctx.Params = gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+		DeleteSelfBudget(ctx)
+		return decodeAPIResponse(t, recorder)
+	}
+	assert.False(t, deleteSelf(adminBudget.Id).Success)
+	_, err = model.GetBudgetById(adminBudget.Id)
+	require.NoError(t, err)
+
+	// 用户自己创建的预算可以自由修改和删除
+	assert.True(t, saveSelf(BudgetRequest{TokenId: token.Id, PeriodType: model.BudgetPeriodMonthly, QuotaLimit: 3000}).Success)
+	selfBudget, err := model.GetBudgetByScope(userID, token.Id, model.BudgetPeriodMonthly)
+	require.NoError(t, err)
+	require.NotNil(t, selfBudget)
+	assert.Equal(t, model.BudgetCreatorSelf, selfBudget.CreatedBy)
+	assert.True(t, saveSelf(BudgetRequest{TokenId: token.Id, PeriodType: model.BudgetPeriodMonthly, QuotaLimit: 6000}).Success)
+	assert.True(t, deleteSelf(selfBudget.Id).Success)
+}
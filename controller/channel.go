@@ -13,6 +13,7 @@ import (
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/dto"
 	"github.com/QuantumNous/new-api/model"
+	keyusage "github.com/QuantumNous/new-api/pkg/key_usage"
 	relaychannel "github.com/QuantumNous/new-api/relay/channel"
 	"github.com/QuantumNous/new-api/relay/channel/gemini"
 	"github.com/QuantumNous/new-api/relay/channel/ollama"
@@ -1231,8 +1232,9 @@ func CopyChannel(c *gin.Context) {
 // MultiKeyManageRequest represents the request for multi-key management operations
 type MultiKeyManageRequest struct {
 	ChannelId int    `json:"channel_id"`
-	Action    string `json:"action"`              // "disable_key", "enable_key", "delete_key", "delete_disabled_keys", "get_key_status"
-	KeyIndex  *int   `json:"key_index,omitempty"` // for disable_key, enable_key, and delete_key actions
+	Action    string `json:"action"`              // "disable_key", "enable_key", "delete_key", "delete_disabled_keys", "get_key_status", "set_key_weight"
+	KeyIndex  *int   `json:"key_index,omitempty"` // for disable_key, enable_key, delete_key and set_key_weight actions
+	Weight    *int   `json:"weight,omitempty"`    // for set_key_weight action
 	Page      int    `json:"page,omitempty"`      // for get_key_status pagination
 	PageSize  int    `json:"page_size,omitempty"` // for get_key_status pagination
 	Status    *int   `json:"status,omitempty"`    // for get_key_status filtering: 1=enabled, 2=manual_disabled, 3=auto_disabled, nil=all
@@ -1257,6 +1259,13 @@ type KeyStatus struct {
 	DisabledTime int64  `json:"disabled_time,omitempty"`
 	Reason       string `json:"reason,omitempty"`
 	KeyPreview   string `json:"key_preview"` // first 10 chars of key for identification
+	Weight       int    `json:"weight"`
+	// 累计用量（定期落库）与当前实例最近一分钟的用量
+	RequestCount   int64 `json:"request_count"`
+	TokenCount     int64 `json:"token_count"`
+	LastUsedTime   int64 `json:"last_used_time,omitempty"`
+	MinuteRequests int64 `json:"minute_requests"`
+	MinuteTokens   int64 `json:"minute_tokens"`
 }
 
 // ManageMultiKeys handles multi-key management operations
@@ -1292,6 +1301,11 @@ func ManageMultiKeys(c *gin.Context) {
 	switch request.Action {
 	case "get_key_status":
 		keys := channel.GetKeys()
+		keyUsage, err := model.GetChannelKeyUsage(channel.Id)
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
 
 		// Default pagination parameters
 		page := request.Page
@@ -1344,12 +1358,20 @@ func ManageMultiKeys(c *gin.Context) {
 				keyPreview = key[:10] + "..."
 			}
 
+			usage := keyUsage[i]
+			minuteRequests, minuteTokens := keyusage.Minute(channel.Id, i)
 			allKeyStatusList = append(allKeyStatusList, KeyStatus{
-				Index:        i,
-				Status:       status,
-				DisabledTime: disabledTime,
-				Reason:       reason,
-				KeyPreview:   keyPreview,
+				Index:          i,
+				Status:         status,
+				DisabledTime:   disabledTime,
+				Reason:         reason,
+				KeyPreview:     keyPreview,
+				Weight:         channel.ChannelInfo.GetKeyWeight(i),
+				RequestCount:   usage.RequestCount,
+				TokenCount:     usage.TokenCount,
+				LastUsedTime:   max(usage.LastUsedTime, keyusage.LastUsed(channel.Id, i)),
+				MinuteRequests: minuteRequests,
+				MinuteTokens:   minuteTokens,
 			})
 		}
 
@@ -1580,6 +1602,7 @@ func ManageMultiKeys(c *gin.Context) {
 
 		keys := channel.GetKeys()
 		var remainingKeys []string
+		var remainingIndexes []int
 		var newStatusList = make(map[int]int)
 		var newDisabledTime = make(map[int]int64)
 		var newDisabledReason = make(map[int]string)
@@ -1592,6 +1615,7 @@ func ManageMultiKeys(c *gin.Context) {
 			}
 
 			remainingKeys = append(remainingKeys, key)
+			remainingIndexes = append(remainingIndexes, i)
 
 			// 保留其他密钥的状态信息，重新索引
 			if channel.ChannelInfo.MultiKeyStatusList != nil {
@@ -1626,6 +1650,12 @@ func ManageMultiKeys(c *gin.Context) {
 		channel.ChannelInfo.MultiKeyStatusList = newStatusList
 		channel.ChannelInfo.MultiKeyDisabledTime = newDisabledTime
 		channel.ChannelInfo.MultiKeyDisabledReason = newDisabledReason
+		channel.ChannelInfo.RemapMultiKeyStats(remainingIndexes)
+		// 先重排用量，channel.Update 会清理超出新 Key 数量的用量记录
+		if err := model.RemapChannelKeyUsage(channel.Id, remainingIndexes); err != nil {
+			common.ApiError(c, err)
+			return
+		}
 
 		err = channel.Update()
 		if err != nil {
@@ -1633,6 +1663,8 @@ func ManageMultiKeys(c *gin.Context) {
 			return
 		}
 
+		// 删除后 Key 索引发生变化，内存中的用量计数不再对应
+		keyusage.Reset(channel.Id)
 		model.InitChannelCache()
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -1643,6 +1675,7 @@ func ManageMultiKeys(c *gin.Context) {
 	case "delete_disabled_keys":
 		keys := channel.GetKeys()
 		var remainingKeys []string
+		var remainingIndexes []int
 		var deletedCount int
 		var newStatusList = make(map[int]int)
 		var newDisabledTime = make(map[int]int64)
@@ -1662,6 +1695,7 @@ func ManageMultiKeys(c *gin.Context) {
 				deletedCount++
 			} else {
 				remainingKeys = append(remainingKeys, key)
+				remainingIndexes = append(remainingIndexes, i)
 				// 保留非自动禁用密钥的状态信息，重新索引
 				if status != 1 {
 					newStatusList[newIndex] = status
@@ -1694,6 +1728,12 @@ func ManageMultiKeys(c *gin.Context) {
 		channel.ChannelInfo.MultiKeyStatusList = newStatusList
 		channel.ChannelInfo.MultiKeyDisabledTime = newDisabledTime
 		channel.ChannelInfo.MultiKeyDisabledReason = newDisabledReason
+		channel.ChannelInfo.RemapMultiKeyStats(remainingIndexes)
+		// 先重排用量，channel.Update 会清理超出新 Key 数量的用量记录
+		if err := model.RemapChannelKeyUsage(channel.Id, remainingIndexes); err != nil {
+			common.ApiError(c, err)
+			return
+		}
 
 		err = channel.Update()
 		if err != nil {
@@ -1701,6 +1741,8 @@ func ManageMultiKeys(c *gin.Context) {
 			return
 		}
 
+		// 删除后 Key 索引发生变化，内存中的用量计数不再对应
+		keyusage.Reset(channel.Id)
 		model.InitChannelCache()
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -1709,6 +1751,54 @@ func ManageMultiKeys(c *gin.Context) {
 		})
 		return
 
+	case "set_key_weight":
+		if request.KeyIndex == nil || request.Weight == nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "未指定密钥索引或权重",
+			})
+			return
+		}
+
+		keyIndex := *request.KeyIndex
+		if keyIndex < 0 || keyIndex >= channel.ChannelInfo.MultiKeySize {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "密钥索引超出范围",
+			})
+			return
+		}
+		if *request.Weight < 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "权重不能为负数",
+			})
+			return
+		}
+
+		// 权重为 1 时使用默认值，不单独记录
+		if *request.Weight == 1 {
+			delete(channel.ChannelInfo.MultiKeyWeights, keyIndex)
+		} else {
+			if channel.ChannelInfo.MultiKeyWeights == nil {
+				channel.ChannelInfo.MultiKeyWeights = make(map[int]int)
+			}
+			channel.ChannelInfo.MultiKeyWeights[keyIndex] = *request.Weight
+		}
+
+		err = channel.Update()
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+
+		model.InitChannelCache()
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "密钥权重已更新",
+		})
+		return
+
 	default:
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChannelHealthResponse struct {
+	circuitbreaker.Status
+	ChannelName string `json:"channel_name"`
+}
+
+// GetChannelHealth 返回当前节点上各渠道（及多 Key 渠道各 Key）的熔断状态与健康分
+func GetChannelHealth(c *gin.Context) {
+	statuses := circuitbreaker.Snapshot()
+	channelNames := make(map[int]string)
+	items := make([]ChannelHealthResponse, 0, len(statuses))
+	for _, status := range statuses {
+		name, ok := channelNames[status.ChannelId]
+		if !ok {
+			if channel, err := model.CacheGetChannel(status.ChannelId); err == nil {
+				name = channel.Name
+			}
+			channelNames[status.ChannelId] = name
+		}
+		items = append(items, ChannelHealthResponse{Status: status, ChannelName: name})
+	}
+	common.ApiSuccess(c, items)
+}
+
+// ResetChannelHealth 手动清除渠道的熔断状态，渠道立即恢复正常权重
+func ResetChannelHealth(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		common.ApiErrorMsg(c, "无效的渠道ID")
+		return
+	}
+	circuitbreaker.Reset(id)
+	common.ApiSuccess(c, nil)
+}
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	fileListDefaultLimit = 100
+	fileListMaxLimit     = 10000
+)
+
+// fileApiError returns an OpenAI-style error response for the files api.
+func fileApiError(c *gin.Context, status int, errType string, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"message": common.MessageWithRequestId(message, c.GetString(common.RequestIdKey)),
+			"type":    errType,
+		},
+	})
+}
+
+// resolveFileUploadChannel 决定上传文件时使用的上游渠道，返回 nil 表示保存到本地
+func resolveFileUploadChannel(c *gin.Context) (*model.Channel, error) {
+	var channel *model.Channel
+	if specificChannelId := common.GetContextKeyString(c, constant.ContextKeyTokenSpecificChannelId); specificChannelId != "" {
+		id, err := strconv.Atoi(specificChannelId)
+		if err != nil {
+			return nil, errors.New("invalid channel id")
+		}
+		channel, err = model.GetChannelById(id, true)
+		if err != nil {
+			return nil, errors.New("invalid channel id")
+		}
+		// 令牌指定的渠道不支持 Files API（非 OpenAI/Azure）时回退到本地存储
+		if !service.IsFileChannelSupported(channel.Type) {
+			return nil, nil
+		}
+	} else {
+		setting := operation_setting.GetFileSetting()
+		if setting.StorageMode != operation_setting.FileStorageModeUpstream || setting.UpstreamChannelId <= 0 {
+			return nil, nil
+		}
+		var err error
+		channel, err = model.CacheGetChannel(setting.UpstreamChannelId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get file channel #%d: %w", setting.UpstreamChannelId, err)
+		}
+	}
+	if channel.Status != common.ChannelStatusEnabled {
+		return nil, fmt.Errorf("file channel #%d is disabled", channel.Id)
+	}
+	if !service.IsFileChannelSupported(channel.Type) {
+		return nil, fmt.Errorf("channel #%d does not support files api", channel.Id)
+	}
+	return channel, nil
+}
+
+func getTokenFileOrAbort(c *gin.Context) *model.File {
+	fileId := c.Param("id")
+	file, exists, err := model.GetTokenFile(c.GetInt("id"), c.GetInt("token_id"), fileId)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to query file %s: %s", fileId, err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to query file")
+		return nil
+	}
+	if !exists {
+		fileApiError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such File object: %s", fileId))
+		return nil
+	}
+	return file
+}
+
+// UploadFile POST /v1/files
+func UploadFile(c *gin.Context) {
+	setting := operation_setting.GetFileSetting()
+	if !setting.Enabled {
+		RelayNotImplemented(c)
+		return
+	}
+	maxBytes := operation_setting.GetMaxFileSizeBytes()
+	// 额外预留 1MB 给 multipart 边界和其他字段
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+(1<<20))
+
+	purpose := strings.TrimSpace(c.PostForm("purpose"))
+	if purpose == "" {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", "purpose is required")
+		return
+	}
+	if !operation_setting.IsFilePurposeAllowed(purpose) {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid purpose: %s", purpose))
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if common.IsRequestBodyTooLargeError(err) {
+			fileApiError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("file exceeds maximum size of %d MB", maxBytes>>20))
+			return
+		}
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", "file is required")
+		return
+	}
+	if fileHeader.Size > maxBytes {
+		fileApiError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("file exceeds maximum size of %d MB", maxBytes>>20))
+		return
+	}
+
+	userId := c.GetInt("id")
+	tokenId := c.GetInt("token_id")
+	if setting.MaxFilesPerUser > 0 {
+		count, err := model.CountUserFiles(userId)
+		if err != nil {
+			fileApiError(c, http.StatusInternalServerError, "server_error", "failed to count files")
+			return
+		}
+		if count >= int64(setting.MaxFilesPerUser) {
+			fileApiError(c, http.StatusForbidden, "invalid_request_error", fmt.Sprintf("file limit reached: at most %d files per user", setting.MaxFilesPerUser))
+			return
+		}
+	}
+
+	channel, err := resolveFileUploadChannel(c)
+	if err != nil {
+		fileApiError(c, http.StatusServiceUnavailable, "new_api_error", err.Error())
+		return
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		fileApiError(c, http.StatusBadRequest, "invalid_request_error", "failed to read uploaded file")
+		return
+	}
+	defer src.Close()
+
+	var file *model.File
+	if channel != nil {
+		file, err = service.CreateUpstreamFile(c.Request.Context(), channel, userId, tokenId, purpose, fileHeader.Filename, fileHeader.Size, src)
+	} else {
+		file, err = service.CreateLocalFile(userId, tokenId, purpose, fileHeader.Filename, src)
+	}
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to save file: %s", err.Error()))
+		if errors.Is(err, common.ErrStoredFileTooLarge) {
+			fileApiError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("file exceeds maximum size of %d MB", maxBytes>>20))
+			return
+		}
+		fileApiError(c, http.StatusBadGateway, "upstream_error", "failed to save file")
+		return
+	}
+	c.JSON(http.StatusOK, file.ToOpenAIFile())
+}
+
+// ListFiles GET /v1/files
+func ListFiles(c *gin.Context) {
+	limit := fileListDefaultLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			fileApiError(c, http.StatusBadRequest, "invalid_request_error", "invalid limit")
+			return
+		}
+		limit = min(parsed, fileListMaxLimit)
+	}
+	params := model.FileListParams{
+		Purpose: c.Query("purpose"),
+		After:   c.Query("after"),
+		Limit:   limit,
+		Order:   c.DefaultQuery("order", "desc"),
+	}
+	files, err := model.GetTokenFiles(c.GetInt("id"), c.GetInt("token_id"), params)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to list files: %s", err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to list files")
+		return
+	}
+	list := dto.OpenAIFileList{
+		Object: "list",
+		Data:   make([]dto.OpenAIFile, 0, len(files)),
+	}
+	if len(files) > limit {
+		list.HasMore = true
+		files = files[:limit]
+	}
+	for _, file := range files {
+		list.Data = append(list.Data, file.ToOpenAIFile())
+	}
+	if len(list.Data) > 0 {
+		list.FirstID = list.Data[0].ID
+		list.LastID = list.Data[len(list.Data)-1].ID
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RetrieveFile GET /v1/files/:id
+func RetrieveFile(c *gin.Context) {
+	file := getTokenFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	c.JSON(http.StatusOK, file.ToOpenAIFile())
+}
+
+// DeleteFile DELETE /v1/files/:id
+func DeleteFile(c *gin.Context) {
+	file := getTokenFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	if err := service.DeleteFile(c.Request.Context(), file); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to delete file %s: %s", file.FileId, err.Error()))
+		fileApiError(c, http.StatusInternalServerError, "server_error", "failed to delete file")
+		return
+	}
+	c.JSON(http.StatusOK, dto.OpenAIFileDeleted{
+		ID:      file.FileId,
+		Object:  "file",
+		Deleted: true,
+	})
+}
+
+// RetrieveFileContent GET /v1/files/:id/content
+func RetrieveFileContent(c *gin.Context) {
+	file := getTokenFileOrAbort(c)
+	if file == nil {
+		return
+	}
+	reader, err := service.OpenFileContent(c.Request.Context(), file)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to open file %s: %s", file.FileId, err.Error()))
+		fileApiError(c, http.StatusBadGateway, "server_error", "failed to fetch file content")
+		return
+	}
+	defer reader.Close()
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	if file.Bytes > 0 {
+		c.Header("Content-Length", strconv.FormatInt(file.Bytes, 10))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to stream file %s: %s", file.FileId, err.Error()))
+	}
+}
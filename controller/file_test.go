@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFileUploadChannel_SpecificChannel(t *testing.T) {
+	db := setupTokenControllerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.Channel{}))
+	require.NoError(t, db.Create(&model.Channel{Id: 1, Type: constant.ChannelTypeOpenAI, Key: "sk-openai", Status: common.ChannelStatusEnabled, Name: "openai"}).Error)
+	require.NoError(t, db.Create(&model.Channel{Id: 2, Type: constant.ChannelTypeAnthropic, Key: "sk-ant", Status: common.ChannelStatusEnabled, Name: "anthropic"}).Error)
+
+	newContext := func(channelId string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/files", nil)
+		common.SetContextKey(c, constant.ContextKeyTokenSpecificChannelId, channelId)
+		return c
+	}
+
+	// 指定 OpenAI 渠道时上传到该渠道
+	channel, err := resolveFileUploadChannel(newContext("1"))
+	require.NoError(t, err)
+	require.NotNil(t, channel)
+	require.Equal(t, 1, channel.Id)
+
+	// 指定的渠道不支持 Files API 时回退到本地存储
+	channel, err = resolveFileUploadChannel(newContext("2"))
+	require.NoError(t, err)
+	require.Nil(t, channel)
+
+	_, err = resolveFileUploadChannel(newContext("3"))
+	require.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateBillingProfileRequest struct {
+	CompanyName string `json:"company_name"`
+	TaxId       string `json:"tax_id"`
+}
+
+// UpdateBillingProfile 更新当前用户的发票抬头（公司名称与税号）
+func UpdateBillingProfile(c *gin.Context) {
+	var req UpdateBillingProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	req.CompanyName = strings.TrimSpace(req.CompanyName)
+	req.TaxId = strings.TrimSpace(req.TaxId)
+	if len(req.CompanyName) > 128 || len(req.TaxId) > 64 {
+		common.ApiErrorMsg(c, "公司名称或税号过长")
+		return
+	}
+	if err := model.UpdateUserBillingProfile(c.GetInt("id"), req.CompanyName, req.TaxId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, req)
+}
+
+// GetTopUpInvoice 下载已完成充值/订阅订单的发票（可打印 HTML），管理员可查看任意用户的发票
+func GetTopUpInvoice(c *gin.Context) {
+	isAdmin := c.GetInt("role") >= common.RoleAdminUser
+	if !isAdmin && !operation_setting.GetInvoiceSetting().Enabled {
+		common.ApiErrorMsg(c, "发票功能未启用")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	topUp := model.GetTopUpById(id)
+	if topUp == nil || (!isAdmin && topUp.UserId != c.GetInt("id")) {
+		common.ApiErrorMsg(c, "订单不存在")
+		return
+	}
+	invoice, err := model.GetOrCreateInvoice(topUp)
+	if err != nil {
+		if errors.Is(err, model.ErrInvoiceNotAvailable) {
+			common.ApiErrorMsg(c, "仅已完成的订单可以开具发票")
+			return
+		}
+		common.ApiError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := service.RenderInvoiceHTML(&buf, invoice); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoice.InvoiceNo+".html"))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// GetSelfStatement 下载当前用户的月度账单，?month=2026-09&format=html|csv，month 默认为当月
+func GetSelfStatement(c *gin.Context) {
+	if !operation_setting.GetInvoiceSetting().Enabled {
+		common.ApiErrorMsg(c, "发票功能未启用")
+		return
+	}
+	writeStatement(c, c.GetInt("id"))
+}
+
+// GetUserStatement 管理员下载指定用户的月度账单
+func GetUserStatement(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	writeStatement(c, userId)
+}
+
+func writeStatement(c *gin.Context, userId int) {
+	month := c.Query("month")
+	if month == "" {
+		month = time.Now().In(operation_setting.GetInvoiceLocation()).Format("2006-01")
+	}
+	statement, err := service.BuildMonthlyStatement(userId, month)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	switch c.DefaultQuery("format", "html") {
+	case "csv":
+		if err := service.WriteStatementCSV(&buf, statement); err != nil {
+			common.ApiError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", service.StatementFileName(statement, "csv")))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+	case "html":
+		if err := service.RenderStatementHTML(&buf, statement); err != nil {
+			common.ApiError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", service.StatementFileName(statement, "html")))
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	default:
+		common.ApiErrorMsg(c, "不支持的账单格式")
+	}
+}
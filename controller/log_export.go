@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLogExportFilter 解析与日志列表接口相同的筛选参数，admin 为 false 时忽略用户名、渠道与组织
+func parseLogExportFilter(c *gin.Context, admin bool) model.LogExportFilter {
+	logType, _ := strconv.Atoi(c.Query("type"))
+	startTimestamp, _ := strconv.ParseInt(c.Query("start_timestamp"), 10, 64)
+	endTimestamp, _ := strconv.ParseInt(c.Query("end_timestamp"), 10, 64)
+	filter := model.LogExportFilter{
+		LogType:        logType,
+		StartTimestamp: startTimestamp,
+		EndTimestamp:   endTimestamp,
+		ModelName:      c.Query("model_name"),
+		TokenName:      c.Query("token_name"),
+		Group:          c.Query("group"),
+	}
+	if admin {
+		filter.Username = c.Query("username")
+		filter.Channel, _ = strconv.Atoi(c.Query("channel"))
+		filter.OrganizationId, _ = strconv.Atoi(c.Query("organization_id"))
+	} else {
+		filter.UserId = c.GetInt("id")
+	}
+	return filter
+}
+
+// ExportAllLogs 管理员导出日志，?format=csv，其余参数同 GetAllLogs
+func ExportAllLogs(c *gin.Context) {
+	writeUsageExport(c, "logs", parseLogExportFilter(c, true), service.ExportLogs)
+}
+
+// ExportUserLogs 用户导出自己的日志
+func ExportUserLogs(c *gin.Context) {
+	writeUsageExport(c, "logs", parseLogExportFilter(c, false), service.ExportLogs)
+}
+
+// ExportAllUsageData 管理员导出按小时汇总的用量
+func ExportAllUsageData(c *gin.Context) {
+	writeUsageExport(c, "usage", parseLogExportFilter(c, true), service.ExportUsageData)
+}
+
+// ExportUserUsageData 用户导出自己按小时汇总的用量
+func ExportUserUsageData(c *gin.Context) {
+	writeUsageExport(c, "usage", parseLogExportFilter(c, false), service.ExportUsageData)
+}
+
+func writeUsageExport(c *gin.Context, kind string, filter model.LogExportFilter, export func(io.Writer, string, model.LogExportFilter) error) {
+	format := c.DefaultQuery("format", service.UsageExportFormatCSV)
+	contentType, ok := service.UsageExportContentType(format)
+	if !ok {
+		common.ApiErrorMsg(c, "不支持的导出格式")
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", service.UsageExportFileName(kind, format)))
+	c.Status(http.StatusOK)
+	if err := export(c.Writer, format, filter); err != nil {
+		logger.LogError(c, fmt.Sprintf("failed to export %s: %v", kind, err))
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Del("Content-Type")
+			common.ApiError(c, err)
+			return
+		}
+		// 已开始输出文件内容，只能中断响应
+		c.Abort()
+	}
+}
@@ -119,6 +119,8 @@ func GetStatus(c *gin.Context) {
 		"user_agreement_enabled":      legalSetting.UserAgreement != "",
 		"privacy_policy_enabled":      legalSetting.PrivacyPolicy != "",
 		"checkin_enabled":             operation_setting.GetCheckinSetting().Enabled,
+		"invoice_enabled":             operation_setting.GetInvoiceSetting().Enabled,
+		"usage_report_enabled":        operation_setting.GetUsageReportSetting().Enabled,
 	}
 
 	// 根据启用状态注入可选内容
@@ -277,6 +277,104 @@ func UpdateOption(c *gin.Context) {
 			})
 			return
 		}
+	case "ModelTokenRateLimitGroup":
+		err = setting.CheckModelTokenRateLimitGroup(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "moderation_setting.group_actions":
+		err = operation_setting.CheckModerationGroupActions(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "routing_setting.default_strategy":
+		if !operation_setting.IsValidRoutingStrategy(option.Value.(string)) {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无效的路由策略",
+			})
+			return
+		}
+	case "routing_setting.group_strategies", "routing_setting.model_strategies":
+		err = operation_setting.CheckRoutingStrategies(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "hedge_setting.group_delays", "hedge_setting.model_delays":
+		err = operation_setting.CheckHedgeDelays(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "stream_failover_setting.models":
+		err = operation_setting.CheckStreamFailoverModels(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "model_fallback_setting.chains":
+		err = operation_setting.CheckModelFallbackChains(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "budget_setting.timezone", "invoice_setting.timezone", "usage_report_setting.timezone":
+		err = operation_setting.CheckBudgetTimezone(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "asset_setting.storage_mode":
+		err = operation_setting.CheckAssetStorageMode(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "asset_setting.s3_endpoint":
+		err = operation_setting.CheckAssetS3Endpoint(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	case "semantic_cache_setting.model_thresholds":
+		err = operation_setting.CheckSemanticCacheModelThresholds(option.Value.(string))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
 	case "AutomaticDisableStatusCodes":
 		_, err = operation_setting.ParseHTTPStatusCodeRanges(option.Value.(string))
 		if err != nil {
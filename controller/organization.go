@@ -0,0 +1,512 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrganizationRequest struct {
+	Id            int    `json:"id"`
+	Name          string `json:"name"`
+	Status        int    `json:"status"`
+	Remark        string `json:"remark"`
+	OwnerUsername string `json:"owner_username"` // 仅创建时使用
+	Quota         int    `json:"quota"`          // 仅创建时使用，初始钱包额度
+}
+
+type OrganizationQuotaRequest struct {
+	Mode  string `json:"mode"` // add / subtract / override
+	Value int    `json:"value"`
+}
+
+type OrganizationMemberRequest struct {
+	UserId     int    `json:"user_id"`
+	Username   string `json:"username"` // 添加成员时可使用用户名代替 user_id
+	Role       string `json:"role"`
+	QuotaLimit int    `json:"quota_limit"`
+	ResetUsed  bool   `json:"reset_used"` // 清零成员已用额度
+}
+
+// canManageOrganizationRole 判断操作者能否授予或管理目标角色，actorRole 为空表示系统管理员
+func canManageOrganizationRole(actorRole string, targetRole string) bool {
+	switch actorRole {
+	case "":
+		return true
+	case model.OrganizationRoleOwner:
+		return targetRole != model.OrganizationRoleOwner
+	case model.OrganizationRoleAdmin:
+		return targetRole == model.OrganizationRoleMember
+	default:
+		return false
+	}
+}
+
+func validateOrganizationName(name string) bool {
+	length := utf8.RuneCountInString(name)
+	return length > 0 && length <= 64
+}
+
+// ---- Admin APIs ----
+
+func GetAllOrganizations(c *gin.Context) {
+	pageInfo := common.GetPageQuery(c)
+	orgs, total, err := model.GetAllOrganizations(strings.TrimSpace(c.Query("keyword")), pageInfo.GetStartIdx(), pageInfo.GetPageSize())
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	pageInfo.SetTotal(int(total))
+	pageInfo.SetItems(orgs)
+	common.ApiSuccess(c, pageInfo)
+}
+
+func GetOrganization(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	org, err := model.GetOrganizationById(id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	members, err := model.GetOrganizationMembers(org.Id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, gin.H{
+		"organization": org,
+		"members":      members,
+	})
+}
+
+func CreateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if !validateOrganizationName(req.Name) {
+		common.ApiErrorMsg(c, "组织名称长度必须在 1-64 之间")
+		return
+	}
+	if req.Quota < 0 {
+		common.ApiErrorMsg(c, "额度不能为负数")
+		return
+	}
+	ownerId, err := model.GetUserIdByUsername(strings.TrimSpace(req.OwnerUsername))
+	if err != nil {
+		common.ApiErrorMsg(c, "所有者用户不存在")
+		return
+	}
+	org := &model.Organization{
+		Name:   req.Name,
+		Status: model.OrganizationStatusEnabled,
+		Quota:  req.Quota,
+		Remark: req.Remark,
+	}
+	if err := model.CreateOrganization(org, ownerId); err != nil {
+		if errors.Is(err, model.ErrOrganizationMemberExists) {
+			common.ApiErrorMsg(c, "该用户已属于其他组织")
+			return
+		}
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, org)
+}
+
+func UpdateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Id <= 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	org, err := model.GetOrganizationById(req.Id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if !validateOrganizationName(req.Name) {
+		common.ApiErrorMsg(c, "组织名称长度必须在 1-64 之间")
+		return
+	}
+	if req.Status != model.OrganizationStatusEnabled && req.Status != model.OrganizationStatusDisabled {
+		common.ApiErrorMsg(c, "无效的组织状态")
+		return
+	}
+	org.Name = req.Name
+	org.Status = req.Status
+	org.Remark = req.Remark
+	if err := org.Update(); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, org)
+}
+
+func AdjustOrganizationQuota(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	org, err := model.GetOrganizationById(id)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	var req OrganizationQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	adminInfo := map[string]interface{}{
+		"admin_id":          c.GetInt("id"),
+		"admin_username":    c.GetString("username"),
+		"organization_id":   org.Id,
+		"organization_name": org.Name,
+	}
+	var content string
+	switch req.Mode {
+	case "add":
+		if req.Value <= 0 {
+			common.ApiErrorMsg(c, "额度变更值必须大于 0")
+			return
+		}
+		err = model.IncreaseOrganizationQuota(org.Id, req.Value)
+		content = fmt.Sprintf("管理员增加组织 %s 额度 %s", org.Name, logger.LogQuota(req.Value))
+	case "subtract":
+		if req.Value <= 0 {
+			common.ApiErrorMsg(c, "额度变更值必须大于 0")
+			return
+		}
+		err = model.DecreaseOrganizationQuota(org.Id, req.Value)
+		content = fmt.Sprintf("管理员减少组织 %s 额度 %s", org.Name, logger.LogQuota(req.Value))
+	case "override":
+		err = model.SetOrganizationQuota(org.Id, req.Value)
+		content = fmt.Sprintf("管理员覆盖组织 %s 额度从 %s 为 %s", org.Name, logger.LogQuota(org.Quota), logger.LogQuota(req.Value))
+	default:
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	model.RecordLogWithAdminInfo(c.GetInt("id"), model.LogTypeManage, content, adminInfo)
+	common.ApiSuccess(c, nil)
+}
+
+func DeleteOrganization(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if id <= 0 {
+		common.ApiErrorMsg(c, "无效的组织ID")
+		return
+	}
+	if err := model.DeleteOrganizationById(id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+func AdminAddOrganizationMember(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if _, err := model.GetOrganizationById(id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	addOrganizationMember(c, id, "")
+}
+
+func AdminUpdateOrganizationMember(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	updateOrganizationMember(c, id, "")
+}
+
+func AdminRemoveOrganizationMember(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	removeOrganizationMember(c, id, "")
+}
+
+// ---- Member APIs ----
+
+// getSelfOrganizationMember 获取当前用户的组织成员信息，用户不属于任何组织时返回错误响应
+func getSelfOrganizationMember(c *gin.Context) (*model.OrganizationMember, bool) {
+	member, err := model.GetOrganizationMemberByUserId(c.GetInt("id"))
+	if err != nil {
+		if errors.Is(err, model.ErrOrganizationMemberNotFound) {
+			common.ApiErrorMsg(c, "您不属于任何组织")
+			return nil, false
+		}
+		common.ApiError(c, err)
+		return nil, false
+	}
+	return member, true
+}
+
+func GetSelfOrganization(c *gin.Context) {
+	member, err := model.GetOrganizationMemberByUserId(c.GetInt("id"))
+	if err != nil {
+		if errors.Is(err, model.ErrOrganizationMemberNotFound) {
+			common.ApiSuccess(c, nil)
+			return
+		}
+		common.ApiError(c, err)
+		return
+	}
+	org, err := model.GetOrganizationById(member.OrganizationId)
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	data := gin.H{
+		"organization": org,
+		"member":       member,
+	}
+	// 所有者和管理员可以查看全部成员的消费情况以及未处理的邀请
+	if member.Role != model.OrganizationRoleMember {
+		members, err := model.GetOrganizationMembers(org.Id)
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+		data["members"] = members
+		invitations, err := model.GetOrganizationInvitations(org.Id)
+		if err != nil {
+			common.ApiError(c, err)
+			return
+		}
+		data["invitations"] = invitations
+	}
+	common.ApiSuccess(c, data)
+}
+
+func AddSelfOrganizationMember(c *gin.Context) {
+	member, ok := getSelfOrganizationMember(c)
+	if !ok {
+		return
+	}
+	addOrganizationMember(c, member.OrganizationId, member.Role)
+}
+
+func UpdateSelfOrganizationMember(c *gin.Context) {
+	member, ok := getSelfOrganizationMember(c)
+	if !ok {
+		return
+	}
+	updateOrganizationMember(c, member.OrganizationId, member.Role)
+}
+
+func RemoveSelfOrganizationMember(c *gin.Context) {
+	member, ok := getSelfOrganizationMember(c)
+	if !ok {
+		return
+	}
+	removeOrganizationMember(c, member.OrganizationId, member.Role)
+}
+
+func LeaveSelfOrganization(c *gin.Context) {
+	if err := model.LeaveOrganization(c.GetInt("id")); err != nil {
+		switch {
+		case errors.Is(err, model.ErrOrganizationMemberNotFound):
+			common.ApiErrorMsg(c, "您不属于任何组织")
+		case errors.Is(err, model.ErrOrganizationOwnerCannotLeave):
+			common.ApiErrorMsg(c, "组织所有者不能退出组织")
+		default:
+			common.ApiError(c, err)
+		}
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+// GetSelfOrganizationInvitations 获取当前用户收到的组织邀请
+func GetSelfOrganizationInvitations(c *gin.Context) {
+	invitations, err := model.GetUserOrganizationInvitations(c.GetInt("id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, invitations)
+}
+
+func AcceptSelfOrganizationInvitation(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	member, err := model.AcceptOrganizationInvitation(id, c.GetInt("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrOrganizationInvitationMissing):
+			common.ApiErrorMsg(c, "组织邀请不存在")
+		case errors.Is(err, model.ErrOrganizationMemberExists):
+			common.ApiErrorMsg(c, "您已属于其他组织，请先退出")
+		case errors.Is(err, model.ErrOrganizationDisabled):
+			common.ApiErrorMsg(c, "该组织已被禁用")
+		default:
+			common.ApiError(c, err)
+		}
+		return
+	}
+	common.ApiSuccess(c, member)
+}
+
+// DeleteSelfOrganizationInvitation 被邀请用户拒绝邀请，或组织所有者/管理员撤回本组织发出的邀请
+func DeleteSelfOrganizationInvitation(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	userId := c.GetInt("id")
+	invitation, err := model.GetOrganizationInvitationById(id)
+	if err != nil {
+		if errors.Is(err, model.ErrOrganizationInvitationMissing) {
+			common.ApiErrorMsg(c, "组织邀请不存在")
+			return
+		}
+		common.ApiError(c, err)
+		return
+	}
+	if invitation.UserId != userId {
+		member, err := model.GetOrganizationMemberByUserId(userId)
+		if err != nil || member.OrganizationId != invitation.OrganizationId ||
+			!canManageOrganizationRole(member.Role, invitation.Role) {
+			common.ApiErrorMsg(c, "组织邀请不存在")
+			return
+		}
+	}
+	if err := model.DeleteOrganizationInvitation(invitation.Id); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
+
+// ---- Shared member operations ----
+
+func addOrganizationMember(c *gin.Context, orgId int, actorRole string) {
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if req.Role == "" {
+		req.Role = model.OrganizationRoleMember
+	}
+	if !model.IsValidOrganizationRole(req.Role) || req.QuotaLimit < 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if !canManageOrganizationRole(actorRole, req.Role) {
+		common.ApiErrorMsg(c, "无权授予该组织角色")
+		return
+	}
+	userId := req.UserId
+	if req.Username != "" {
+		var err error
+		userId, err = model.GetUserIdByUsername(strings.TrimSpace(req.Username))
+		if err != nil {
+			common.ApiErrorMsg(c, "用户不存在")
+			return
+		}
+	}
+	if userId <= 0 {
+		common.ApiErrorMsg(c, "用户不存在")
+		return
+	}
+	// 组织所有者和管理员只能发出邀请，用户接受后才会加入组织；系统管理员可以直接添加成员
+	if actorRole != "" {
+		invitation := &model.OrganizationInvitation{
+			OrganizationId: orgId,
+			UserId:         userId,
+			Role:           req.Role,
+			QuotaLimit:     req.QuotaLimit,
+			InviterId:      c.GetInt("id"),
+		}
+		if err := model.CreateOrganizationInvitation(invitation); err != nil {
+			if errors.Is(err, model.ErrOrganizationMemberExists) {
+				common.ApiErrorMsg(c, "该用户已是组织成员")
+				return
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				common.ApiErrorMsg(c, "用户不存在")
+				return
+			}
+			common.ApiError(c, err)
+			return
+		}
+		common.ApiSuccess(c, invitation)
+		return
+	}
+	member := &model.OrganizationMember{
+		OrganizationId: orgId,
+		UserId:         userId,
+		Role:           req.Role,
+		QuotaLimit:     req.QuotaLimit,
+	}
+	if err := model.AddOrganizationMember(member); err != nil {
+		if errors.Is(err, model.ErrOrganizationMemberExists) {
+			common.ApiErrorMsg(c, "该用户已属于其他组织")
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ApiErrorMsg(c, "用户不存在")
+			return
+		}
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, member)
+}
+
+func updateOrganizationMember(c *gin.Context, orgId int, actorRole string) {
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserId <= 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	if !model.IsValidOrganizationRole(req.Role) || req.QuotaLimit < 0 {
+		common.ApiErrorMsg(c, "参数错误")
+		return
+	}
+	target, err := model.GetOrganizationMemberByUserId(req.UserId)
+	if err != nil || target.OrganizationId != orgId {
+		common.ApiErrorMsg(c, "组织成员不存在")
+		return
+	}
+	if !canManageOrganizationRole(actorRole, target.Role) || !canManageOrganizationRole(actorRole, req.Role) {
+		common.ApiErrorMsg(c, "无权修改该组织成员")
+		return
+	}
+	target.Role = req.Role
+	target.QuotaLimit = req.QuotaLimit
+	if err := model.UpdateOrganizationMember(target, req.ResetUsed); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if req.ResetUsed {
+		target.UsedQuota = 0
+	}
+	common.ApiSuccess(c, target)
+}
+
+func removeOrganizationMember(c *gin.Context, orgId int, actorRole string) {
+	userId, _ := strconv.Atoi(c.Param("user_id"))
+	target, err := model.GetOrganizationMemberByUserId(userId)
+	if err != nil || target.OrganizationId != orgId {
+		common.ApiErrorMsg(c, "组织成员不存在")
+		return
+	}
+	if !canManageOrganizationRole(actorRole, target.Role) {
+		common.ApiErrorMsg(c, "无权移除该组织成员")
+		return
+	}
+	if err := model.RemoveOrganizationMember(orgId, userId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, nil)
+}
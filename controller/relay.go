@@ -16,6 +16,8 @@ import (
 	"github.com/QuantumNous/new-api/middleware"
 	"github.com/QuantumNous/new-api/model"
 	perfmetrics "github.com/QuantumNous/new-api/pkg/perf_metrics"
+	prommetrics "github.com/QuantumNous/new-api/pkg/prom_metrics"
+	"github.com/QuantumNous/new-api/pkg/routing"
 	"github.com/QuantumNous/new-api/relay"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
 	relayconstant "github.com/QuantumNous/new-api/relay/constant"
@@ -98,6 +100,17 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 					"type":  "error",
 					"error": newAPIError.ToClaudeError(),
 				})
+			case types.RelayFormatGemini:
+				// 仅限流等明确构造为 Gemini 格式的错误按 Gemini 格式返回，其余错误保持原有的 OpenAI 格式
+				if newAPIError.GetErrorType() == types.ErrorTypeGeminiError {
+					c.JSON(newAPIError.StatusCode, gin.H{
+						"error": newAPIError.ToGeminiError(),
+					})
+				} else {
+					c.JSON(newAPIError.StatusCode, gin.H{
+						"error": newAPIError.ToOpenAIError(),
+					})
+				}
 			default:
 				c.JSON(newAPIError.StatusCode, gin.H{
 					"error": newAPIError.ToOpenAIError(),
@@ -122,12 +135,18 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		newAPIError = types.NewError(err, types.ErrorCodeGenRelayInfoFailed)
 		return
 	}
+	defer func() {
+		prommetrics.ObserveRelay(relayInfo, newAPIError)
+	}()
 
 	needSensitiveCheck := setting.ShouldCheckPromptSensitive()
 	needCountToken := constant.CountToken
+	// 分组 × 模型的 token 限流需要按文本预估输入 token
+	needTokenRateLimit := service.HasModelTokenRateLimit(relayInfo)
+	needModeration := service.NeedModeration(relayInfo.UsingGroup)
 	// Avoid building huge CombineText (strings.Join) when token counting and sensitive check are both disabled.
 	var meta *types.TokenCountMeta
-	if needSensitiveCheck || needCountToken {
+	if needSensitiveCheck || needCountToken || needTokenRateLimit || needModeration {
 		meta = request.GetTokenCountMeta()
 	} else {
 		meta = fastTokenCountMetaForPricing(request)
@@ -142,6 +161,13 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		}
 	}
 
+	if needModeration && meta != nil {
+		newAPIError = service.ModerateRequest(c, relayInfo.UsingGroup, meta.CombineText)
+		if newAPIError != nil {
+			return
+		}
+	}
+
 	tokens, err := service.EstimateRequestToken(c, meta, relayInfo)
 	if err != nil {
 		newAPIError = types.NewError(err, types.ErrorCodeCountTokenFailed)
@@ -177,20 +203,48 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 			if relayInfo.Billing != nil {
 				relayInfo.Billing.Refund(c)
 			}
+			service.ReleaseModelTokenRateLimit(c)
 			service.ChargeViolationFeeIfNeeded(c, relayInfo, newAPIError)
 		}
 	}()
 
+	if !isCountTokens {
+		newAPIError = service.ReserveModelTokenRateLimit(c, relayInfo, tokens, meta)
+		if newAPIError != nil {
+			return
+		}
+	}
+
+	if !isCountTokens {
+		// 缓存已在选择渠道前由 middleware.ResponseCache 查询
+		if entry := service.GetResponseCacheHit(c); entry != nil {
+			serveResponseCache(c, relayInfo, entry)
+			return
+		}
+		service.StartResponseCacheCapture(c)
+		defer func() {
+			service.FinishResponseCacheCapture(c, relayInfo, newAPIError == nil)
+		}()
+	}
+
+	// 流式续写需在响应缓存之后接管写入器，使缓存记录拼接后的完整响应
+	streamFailover, restoreWriter := setupStreamFailover(c, relayFormat, relayInfo)
+	if streamFailover != nil {
+		defer restoreWriter()
+	}
+
 	requiredEndpoint, _ := common.GetRequiredEndpointTypeByRequestPath(c.Request.URL.Path)
 	retryParam := &service.RetryParam{
-		Ctx:          c,
-		TokenGroup:   relayInfo.TokenGroup,
-		ModelName:    relayInfo.OriginModelName,
-		EndpointType: requiredEndpoint,
-		Retry:        common.GetPointer(0),
+		Ctx:           c,
+		TokenGroup:    relayInfo.TokenGroup,
+		ModelName:     relayInfo.OriginModelName,
+		EndpointType:  requiredEndpoint,
+		Retry:         common.GetPointer(0),
+		ModelFallback: true,
 	}
 	relayInfo.RetryIndex = 0
 	relayInfo.LastError = nil
+	pricedModel := relayInfo.OriginModelName
 
 	for ; retryParam.GetRetry() <= common.RetryTimes; retryParam.IncreaseRetry() {
 		relayInfo.RetryIndex = retryParam.GetRetry()
@@ -198,8 +252,20 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		if channelErr != nil {
 			logger.LogError(c, channelErr.Error())
 			newAPIError = channelErr
+			// 渠道的 Key 全部达到单 Key 限额时换一个渠道重试
+			if channelErr.GetErrorCode() == types.ErrorCodeChannelKeyRateLimited && retryParam.GetRetry() < common.RetryTimes {
+				continue
+			}
 			break
 		}
+		if relayInfo.OriginModelName != pricedModel && !isCountTokens {
+			// 已切换到降级模型，按降级模型重新计价
+			newAPIError = applyModelFallbackPrice(c, relayInfo, tokens, meta)
+			if newAPIError != nil {
+				break
+			}
+			pricedModel = relayInfo.OriginModelName
+		}
 
 		addUsedChannel(c, channel.Id)
 		bodyStorage, bodyErr := common.GetBodyStorage(c)
@@ -214,15 +280,14 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		}
 		c.Request.Body = io.NopCloser(bodyStorage)
 
-		switch relayFormat {
-		case types.RelayFormatOpenAIRealtime:
-			newAPIError = relay.WssHelper(c, relayInfo)
-		case types.RelayFormatClaude:
-			newAPIError = relay.ClaudeHelper(c, relayInfo)
-		case types.RelayFormatGemini:
-			newAPIError = geminiRelayHandler(c, relayInfo)
-		default:
-			newAPIError = relayHandler(c, relayInfo)
+		// 开启对冲时，各渠道的调用结果与错误在 relayWithHedge 中处理
+		hedgeDelay, hedged := getHedgeDelay(c, relayFormat, relayInfo)
+		if hedged {
+			newAPIError = relayWithHedge(c, relayFormat, relayInfo, channel, requiredEndpoint, hedgeDelay)
+		} else {
+			attemptStart := time.Now()
+			newAPIError = relayWithChannel(c, relayFormat, relayInfo, channel.Id)
+			service.RecordChannelResult(c, relayInfo, channel.Id, attemptStart, newAPIError)
 		}
 
 		if newAPIError == nil {
@@ -233,13 +298,33 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 		newAPIError = service.NormalizeViolationFeeError(newAPIError)
 		relayInfo.LastError = newAPIError
 
-		processChannelError(c, *types.NewChannelError(channel.Id, channel.Type, channel.Name, channel.ChannelInfo.IsMultiKey, common.GetContextKeyString(c, constant.ContextKeyChannelKey), channel.GetAutoBan()), newAPIError)
+		if !hedged {
+			processChannelError(c, *types.NewChannelError(channel.Id, channel.Type, channel.Name, channel.ChannelInfo.IsMultiKey, common.GetContextKeyString(c, constant.ContextKeyChannelKey), channel.GetAutoBan()), newAPIError)
+		}
+
+		if streamFailover != nil && newAPIError.GetErrorCode() == types.ErrorCodeStreamInterrupted {
+			if !continueStreamFailover(c, relayInfo, streamFailover, common.RetryTimes-retryParam.GetRetry()) {
+				break
+			}
+			continue
+		}
+
+		// 当前模型的重试次数已用完时切换到降级模型，从其最高优先级的渠道重新开始
+		if retryParam.GetRetry() >= common.RetryTimes && shouldRetry(c, newAPIError, 1) && retryParam.NextFallbackModel() {
+			retryParam.ResetRetryNextTry()
+			continue
+		}
 
 		if !shouldRetry(c, newAPIError, common.RetryTimes-retryParam.GetRetry()) {
 			break
 		}
 	}
 
+	if streamFailover != nil && streamFailover.Pending() {
+		abandonStreamFailover(c, relayInfo, streamFailover)
+		newAPIError = nil
+	}
+
 	useChannel := c.GetStringSlice("use_channel")
 	if len(useChannel) > 1 {
 		retryLogStr := fmt.Sprintf("重试：%s", strings.Trim(strings.Join(strings.Fields(fmt.Sprint(useChannel)), "->"), "[]"))
@@ -252,6 +337,21 @@ func Relay(c *gin.Context, relayFormat types.RelayFormat) {
 	}
 }
 
+// relayWithChannel 向选中的渠道发起一次调用，调用期间计入该渠道的在途请求数
+func relayWithChannel(c *gin.Context, relayFormat types.RelayFormat, relayInfo *relaycommon.RelayInfo, channelId int) *types.NewAPIError {
+	defer routing.Acquire(channelId)()
+	switch relayFormat {
+	case types.RelayFormatOpenAIRealtime:
+		return relay.WssHelper(c, relayInfo)
+	case types.RelayFormatClaude:
+		return relay.ClaudeHelper(c, relayInfo)
+	case types.RelayFormatGemini:
+		return geminiRelayHandler(c, relayInfo)
+	default:
+		return relayHandler(c, relayInfo)
+	}
+}
+
 var upgrader = websocket.Upgrader{
 	Subprotocols: []string{"realtime"}, // WS 握手支持的协议，如果有使用 Sec-WebSocket-Protocol，则必须在此声明对应的 Protocol TODO add other protocol
 	CheckOrigin: func(r *http.Request) bool {
@@ -265,6 +365,19 @@ func addUsedChannel(c *gin.Context, channelId int) {
 	c.Set("use_channel", useChannel)
 }
 
+// serveResponseCache 重放缓存的响应并按命中倍率计费，流式请求按 SSE 原文返回
+func serveResponseCache(c *gin.Context, info *relaycommon.RelayInfo, entry *service.ResponseCacheEntry) {
+	if entry.IsStream {
+		helper.SetEventStreamHeaders(c)
+	} else if entry.ContentType != "" {
+		c.Header("Content-Type", entry.ContentType)
+	}
+	c.Status(http.StatusOK)
+	_, _ = c.Writer.WriteString(entry.Body)
+	c.Writer.Flush()
+	service.SettleResponseCacheHit(c, info, entry)
+}
+
 func fastTokenCountMetaForPricing(request dto.Request) *types.TokenCountMeta {
 	if request == nil {
 		return &types.TokenCountMeta{}
@@ -309,6 +422,9 @@ func getChannel(c *gin.Context, info *relaycommon.RelayInfo, retryParam *service
 		}, nil
 	}
 	channel, selectGroup, err := service.CacheGetRandomSatisfiedChannel(retryParam)
+	if channel != nil && retryParam.ModelName != info.OriginModelName {
+		switchFallbackModel(c, info, retryParam.ModelName)
+	}
 
 	info.PriceData.GroupRatioInfo = helper.HandleGroupRatio(c, info)
 
@@ -393,6 +509,14 @@ func processChannelError(c *gin.Context, channelError types.ChannelError, err *t
 			adminInfo["is_multi_key"] = true
 			adminInfo["multi_key_index"] = common.GetContextKeyInt(c, constant.ContextKeyChannelMultiKeyIndex)
 		}
+		if routingStrategy := common.GetContextKeyString(c, constant.ContextKeyRoutingStrategy); routingStrategy != "" {
+			adminInfo["routing_strategy"] = routingStrategy
+		}
+		if hedgeAttempt := relaycommon.GetHedgeAttempt(c); hedgeAttempt != nil {
+			if hedgeInfo := hedgeAttempt.Race().LogInfo(); hedgeInfo != nil {
+				adminInfo["hedge"] = hedgeInfo
+			}
+		}
 		service.AppendChannelAffinityAdminInfo(c, adminInfo)
 		other["admin_info"] = adminInfo
 		startTime := common.GetContextKeyTime(c, constant.ContextKeyRequestStartTime)
@@ -487,6 +611,18 @@ func RelayTaskFetch(c *gin.Context) {
 	}
 }
 
+func RelayTaskCancel(c *gin.Context) {
+	if taskErr := relay.RelayTaskCancel(c); taskErr != nil {
+		respondTaskError(c, taskErr)
+	}
+}
+
+func RelayVideoList(c *gin.Context) {
+	if taskErr := relay.RelayVideoList(c); taskErr != nil {
+		respondTaskError(c, taskErr)
+	}
+}
+
 func RelayTask(c *gin.Context) {
 	relayInfo, err := relaycommon.GenRelayInfo(c, types.RelayFormatTask, nil, nil)
 	if err != nil {
@@ -502,6 +638,11 @@ func RelayTask(c *gin.Context) {
 		respondTaskError(c, taskErr)
 		return
 	}
+	callbackURL, callbackErr := relay.ResolveTaskCallbackURL(c)
+	if callbackErr != nil {
+		respondTaskError(c, callbackErr)
+		return
+	}
 
 	var result *relay.TaskSubmitResult
 	var taskErr *dto.TaskError
@@ -553,16 +694,20 @@ func RelayTask(c *gin.Context) {
 		}
 		c.Request.Body = io.NopCloser(bodyStorage)
 
+		attemptStart := time.Now()
 		result, taskErr = relay.RelayTaskSubmit(c, relayInfo)
 		if taskErr == nil {
+			service.RecordChannelResult(c, relayInfo, channel.Id, attemptStart, nil)
 			break
 		}
 
 		if !taskErr.LocalError {
+			channelErr := types.NewOpenAIError(taskErr.Error, types.ErrorCodeBadResponseStatusCode, taskErr.StatusCode)
+			service.RecordChannelResult(c, relayInfo, channel.Id, attemptStart, channelErr)
 			processChannelError(c,
 				*types.NewChannelError(channel.Id, channel.Type, channel.Name, channel.ChannelInfo.IsMultiKey,
 					common.GetContextKeyString(c, constant.ContextKeyChannelKey), channel.GetAutoBan()),
-				types.NewOpenAIError(taskErr.Error, types.ErrorCodeBadResponseStatusCode, taskErr.StatusCode))
+				channelErr)
 		}
 
 		if !shouldRetryTaskRelay(c, channel.Id, taskErr, common.RetryTimes-retryParam.GetRetry()) {
@@ -587,7 +732,9 @@ func RelayTask(c *gin.Context) {
 		task.PrivateData.UpstreamTaskID = result.UpstreamTaskID
 		task.PrivateData.BillingSource = relayInfo.BillingSource
 		task.PrivateData.SubscriptionId = relayInfo.SubscriptionId
+		task.PrivateData.OrganizationId = relayInfo.BillingOrganizationId()
 		task.PrivateData.TokenId = relayInfo.TokenId
+		task.PrivateData.CallbackURL = callbackURL
 		task.PrivateData.BillingContext = &model.TaskBillingContext{
 			ModelPrice:      relayInfo.PriceData.ModelPrice,
 			GroupRatio:      relayInfo.PriceData.GroupRatioInfo.GroupRatio,
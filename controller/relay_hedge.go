@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hedgeChannelDraws 每个优先级内为对冲请求抽取与首个渠道不同的渠道的次数
+const hedgeChannelDraws = 3
+
+// hedgeLeg 对冲竞争中一个渠道的调用
+type hedgeLeg struct {
+	ctx     *gin.Context
+	info    *relaycommon.RelayInfo
+	channel *model.Channel
+	attempt *relaycommon.HedgeAttempt
+	start   time.Time
+	err     *types.NewAPIError
+}
+
+// getHedgeDelay 返回本次请求触发对冲的等待时间。Realtime 长连接与指定渠道的请求不对冲。
+func getHedgeDelay(c *gin.Context, relayFormat types.RelayFormat, relayInfo *relaycommon.RelayInfo) (time.Duration, bool) {
+	if relayFormat == types.RelayFormatOpenAIRealtime {
+		return 0, false
+	}
+	if _, ok := c.Get("specific_channel_id"); ok {
+		return 0, false
+	}
+	return operation_setting.GetHedgeDelay(relayInfo.UsingGroup, relayInfo.OriginModelName)
+}
+
+// relayWithHedge 向选中的渠道发起调用，若在 delay 内未收到上游首字节，则向另一个渠道发起相同请求，
+// 采用先返回首字节的结果并取消另一个。各渠道的错误在此处理，返回最终采用的调用的错误。
+func relayWithHedge(c *gin.Context, relayFormat types.RelayFormat, relayInfo *relaycommon.RelayInfo, channel *model.Channel, endpointType constant.EndpointType, delay time.Duration) *types.NewAPIError {
+	// 对冲请求使用独立的上下文副本，必须在首个渠道开始调用前复制
+	hedgeCtx, cleanup, err := copyHedgeContext(c)
+	if err != nil {
+		logger.LogError(c, fmt.Sprintf("prepare hedged request failed: %s", err.Error()))
+		leg := &hedgeLeg{ctx: c, info: relayInfo, channel: channel, start: time.Now()}
+		leg.err = relayWithChannel(c, relayFormat, relayInfo, channel.Id)
+		return finishHedgeLegs(c, []*hedgeLeg{leg}, nil)
+	}
+	defer cleanup()
+	hedgeInfo := relayInfo.Clone()
+
+	originalWriter := c.Writer
+	originalBilling := relayInfo.Billing
+	billLoser := operation_setting.GetHedgeSetting().BillLoser
+	race := relaycommon.NewHedgeRace(originalWriter, delay, billLoser)
+	primary := &hedgeLeg{
+		ctx:     c,
+		info:    relayInfo,
+		channel: channel,
+		attempt: race.Join(c.Request.Context(), channel.Id, service.HedgeLoserQuota(relayInfo)),
+	}
+	c.Writer = primary.attempt.Writer()
+	common.SetContextKey(c, constant.ContextKeyHedgeAttempt, primary.attempt)
+	relayInfo.Billing = primary.attempt.WrapBilling(originalBilling)
+	defer func() {
+		c.Writer = originalWriter
+		common.SetContextKey(c, constant.ContextKeyHedgeAttempt, nil)
+		relayInfo.Billing = originalBilling
+		race.Close()
+	}()
+
+	var (
+		launchMu    sync.Mutex
+		primaryDone bool
+		hedge       *hedgeLeg
+		wg          sync.WaitGroup
+	)
+	timer := time.AfterFunc(delay, func() {
+		launchMu.Lock()
+		defer launchMu.Unlock()
+		if primaryDone || race.Winner() != nil {
+			return
+		}
+		hedgeChannel := selectHedgeChannel(hedgeCtx, hedgeInfo, channel.Id, endpointType)
+		if hedgeChannel == nil {
+			return
+		}
+		attempt := race.Join(hedgeCtx.Request.Context(), hedgeChannel.Id, service.HedgeLoserQuota(hedgeInfo))
+		if attempt == nil {
+			return
+		}
+		hedgeCtx.Writer = attempt.Writer()
+		common.SetContextKey(hedgeCtx, constant.ContextKeyHedgeAttempt, attempt)
+		hedgeInfo.Billing = attempt.WrapBilling(originalBilling)
+		addUsedChannel(c, hedgeChannel.Id)
+		addUsedChannel(hedgeCtx, hedgeChannel.Id)
+		logger.LogInfo(c, fmt.Sprintf("channel #%d has no response after %dms, hedging to channel #%d", channel.Id, delay.Milliseconds(), hedgeChannel.Id))
+
+		hedge = &hedgeLeg{ctx: hedgeCtx, info: hedgeInfo, channel: hedgeChannel, attempt: attempt, start: time.Now()}
+		leg := hedge
+		wg.Add(1)
+		gopool.Go(func() {
+			defer wg.Done()
+			defer leg.attempt.Finish()
+			defer func() {
+				if r := recover(); r != nil {
+					leg.err = types.NewError(fmt.Errorf("hedged request panic: %v", r), types.ErrorCodeDoRequestFailed)
+				}
+			}()
+			leg.err = relayWithChannel(leg.ctx, relayFormat, leg.info, leg.channel.Id)
+		})
+	})
+
+	primary.start = time.Now()
+	primary.err = relayWithChannel(c, relayFormat, relayInfo, channel.Id)
+	primary.attempt.Finish()
+	launchMu.Lock()
+	primaryDone = true
+	launchMu.Unlock()
+	timer.Stop()
+	wg.Wait()
+
+	legs := []*hedgeLeg{primary}
+	if hedge != nil {
+		legs = append(legs, hedge)
+	}
+	newAPIError := finishHedgeLegs(c, legs, race)
+
+	if winner := race.Winner(); winner != nil && newAPIError == nil && race.ChargedLoserQuota() > 0 {
+		hedgeLogInfo := race.LogInfo()
+		for _, leg := range legs {
+			if leg.attempt != winner {
+				service.RecordHedgeLoserConsume(leg.ctx, leg.info, leg.channel.Id, leg.attempt.LoserQuota(), hedgeLogInfo)
+			}
+		}
+	}
+	return newAPIError
+}
+
+// finishHedgeLegs 记录各渠道的调用结果并处理渠道错误，因其他渠道获胜而被取消的调用不计入。
+// 返回获胜调用的错误，没有获胜者时返回首个渠道的错误。
+func finishHedgeLegs(c *gin.Context, legs []*hedgeLeg, race *relaycommon.HedgeRace) *types.NewAPIError {
+	result := legs[0]
+	if race != nil {
+		for _, leg := range legs {
+			if leg.attempt == race.Winner() {
+				result = leg
+			}
+		}
+	}
+	for _, leg := range legs {
+		if leg.attempt != nil && leg.attempt.Cancelled() {
+			continue
+		}
+		service.RecordChannelResult(leg.ctx, leg.info, leg.channel.Id, leg.start, leg.err)
+		if leg.err == nil {
+			continue
+		}
+		leg.err = service.NormalizeViolationFeeError(leg.err)
+		processChannelError(leg.ctx, *types.NewChannelError(leg.channel.Id, leg.channel.Type, leg.channel.Name, leg.channel.ChannelInfo.IsMultiKey, common.GetContextKeyString(leg.ctx, constant.ContextKeyChannelKey), leg.channel.GetAutoBan()), leg.err)
+	}
+	if result != legs[0] && result.err != nil {
+		// 对冲渠道获胜后失败时，首个渠道已被取消，错误以获胜渠道为准
+		logger.LogError(c, fmt.Sprintf("hedged channel #%d failed after winning: %s", result.channel.Id, result.err.Error()))
+	}
+	return result.err
+}
+
+// copyHedgeContext 复制请求上下文供对冲请求使用，请求体使用独立的存储以便两个渠道并发读取
+func copyHedgeContext(c *gin.Context) (*gin.Context, func(), error) {
+	bodyStorage, err := common.GetBodyStorage(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := bodyStorage.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hedgeBodyStorage, err := common.CreateBodyStorage(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	hedgeCtx := c.Copy()
+	hedgeCtx.Request = c.Request.Clone(c.Request.Context())
+	hedgeCtx.Request.Body = io.NopCloser(hedgeBodyStorage)
+	hedgeCtx.Set(common.KeyBodyStorage, hedgeBodyStorage)
+	hedgeCtx.Set("use_channel", append([]string(nil), c.GetStringSlice("use_channel")...))
+	cleanup := func() {
+		common.CleanupBodyStorage(hedgeCtx)
+	}
+	return hedgeCtx, cleanup, nil
+}
+
+// selectHedgeChannel 按重试顺序选择一个与首个渠道不同的渠道，没有可用渠道时返回 nil
+func selectHedgeChannel(hedgeCtx *gin.Context, hedgeInfo *relaycommon.RelayInfo, primaryChannelId int, endpointType constant.EndpointType) *model.Channel {
+	for retry := 0; retry <= common.RetryTimes; retry++ {
+		for draw := 0; draw < hedgeChannelDraws; draw++ {
+			retryParam := &service.RetryParam{
+				Ctx:          hedgeCtx,
+				TokenGroup:   hedgeInfo.TokenGroup,
+				ModelName:    hedgeInfo.OriginModelName,
+				EndpointType: endpointType,
+				Retry:        common.GetPointer(retry),
+			}
+			channel, _, err := service.CacheGetRandomSatisfiedChannel(retryParam)
+			if err != nil || channel == nil {
+				break
+			}
+			if channel.Id == primaryChannelId {
+				continue
+			}
+			hedgeInfo.PriceData.GroupRatioInfo = helper.HandleGroupRatio(hedgeCtx, hedgeInfo)
+			if middleware.SetupContextForSelectedChannel(hedgeCtx, channel, hedgeInfo.OriginModelName) != nil {
+				return nil
+			}
+			return channel
+		}
+	}
+	return nil
+}
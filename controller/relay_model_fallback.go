@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// switchFallbackModel 将请求切换到降级模型，上游请求、计费与日志均使用降级模型，并通过响应头告知客户端实际使用的模型。
+// 跨格式的请求转换（如 Claude/Gemini 格式请求降级到 OpenAI 渠道）由各渠道适配器完成。
+func switchFallbackModel(c *gin.Context, info *relaycommon.RelayInfo, modelName string) {
+	logger.LogInfo(c, fmt.Sprintf("model fallback: %s -> %s", info.OriginModelName, modelName))
+	info.OriginModelName = modelName
+	c.Header("X-New-Api-Fallback-Model", modelName)
+}
+
+// applyModelFallbackPrice 按降级模型重新计价，结算时按新价格多退少补；原模型免费未预扣费时补充预扣费
+func applyModelFallbackPrice(c *gin.Context, info *relaycommon.RelayInfo, promptTokens int, meta *types.TokenCountMeta) *types.NewAPIError {
+	priceData, err := helper.ModelPriceHelper(c, info, promptTokens, meta)
+	if err != nil {
+		return types.NewError(err, types.ErrorCodeModelPriceError, types.ErrOptionWithSkipRetry())
+	}
+	if info.Billing == nil && !priceData.FreeModel {
+		return service.PreConsumeBilling(c, priceData.QuotaToPreConsume, info)
+	}
+	return nil
+}
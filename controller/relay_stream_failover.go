@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupStreamFailover 为开启续写的 OpenAI Chat Completions 流式请求接管响应写入器，
+// 返回的函数输出剩余内容并恢复原写入器。不满足条件时返回 nil。
+func setupStreamFailover(c *gin.Context, relayFormat types.RelayFormat, relayInfo *relaycommon.RelayInfo) (*relaycommon.StreamFailover, func()) {
+	if relayFormat != types.RelayFormatOpenAI || relayInfo.RelayMode != relayconstant.RelayModeChatCompletions || !relayInfo.IsStream {
+		return nil, nil
+	}
+	if _, ok := c.Get("specific_channel_id"); ok {
+		return nil, nil
+	}
+	request, ok := relayInfo.Request.(*dto.GeneralOpenAIRequest)
+	if !ok || !operation_setting.IsStreamFailoverEnabled(relayInfo.OriginModelName) {
+		return nil, nil
+	}
+	originalWriter := c.Writer
+	failover := relaycommon.NewStreamFailover(originalWriter, request, operation_setting.GetStreamFailoverSetting().MaxFailovers)
+	c.Writer = failover.Writer()
+	common.SetContextKey(c, constant.ContextKeyStreamFailover, failover)
+	return failover, func() {
+		failover.Close()
+		c.Writer = originalWriter
+	}
+}
+
+// continueStreamFailover 上游中途断开时以已输出的内容作为预填充换渠道续写，返回是否继续重试
+func continueStreamFailover(c *gin.Context, relayInfo *relaycommon.RelayInfo, failover *relaycommon.StreamFailover, retryTimes int) bool {
+	if retryTimes <= 0 || !failover.CanContinue() {
+		return false
+	}
+	relayInfo.Request = failover.Continue()
+	logger.LogInfo(c, fmt.Sprintf("stream interrupted on channel #%d, continuing on another channel", relayInfo.ChannelId))
+	return true
+}
+
+// abandonStreamFailover 无法续写时输出中断前暂缓的末尾内容，并按中断尝试的用量结算。
+// 客户端已收到部分响应，不再返回错误。
+func abandonStreamFailover(c *gin.Context, relayInfo *relaycommon.RelayInfo, failover *relaycommon.StreamFailover) {
+	usage, channelMeta := failover.Abandon()
+	if channelMeta != nil {
+		relayInfo.ChannelMeta = channelMeta
+	}
+	logger.LogWarn(c, fmt.Sprintf("stream failover abandoned, settling the interrupted response on channel #%d", relayInfo.ChannelId))
+	service.PostTextConsumeQuota(c, relayInfo, usage, nil)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RelaySemanticCacheEmbedding 以 c 中请求的用户与令牌身份，通过普通中继流程向量化语义缓存的提示词：
+// 与普通 /v1/embeddings 请求一样依次经过 ModelRequestRateLimit → TokenRateLimit → Distribute → Relay，
+// 计入令牌与用户的限流，经由渠道适配器转发，按 embedding 模型计费并记录消费日志
+func RelaySemanticCacheEmbedding(ctx context.Context, c *gin.Context, group string, request *dto.EmbeddingRequest) ([]byte, error) {
+	token, err := model.GetTokenById(common.GetContextKeyInt(c, constant.ContextKeyTokenId))
+	if err != nil {
+		return nil, err
+	}
+	userCache, err := model.GetUserCache(token.UserId)
+	if err != nil {
+		return nil, err
+	}
+	body, err := common.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	requestId := common.GetTimeString() + common.GetRandomString(8)
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, common.RequestIdKey, requestId), http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// 代替 TokenAuth 写入用户与令牌信息，其余中间件与 /v1/embeddings 路由一致
+	var setupErr error
+	engine := gin.New()
+	engine.POST("/v1/embeddings", func(sub *gin.Context) {
+		sub.Set(common.RequestIdKey, requestId)
+		defer func() {
+			common.CleanupBodyStorage(sub)
+			service.CleanupFileSources(sub)
+		}()
+		userCache.WriteContext(sub)
+		common.SetContextKey(sub, constant.ContextKeyUsingGroup, group)
+		if setupErr = middleware.SetupContextForToken(sub, token); setupErr != nil {
+			sub.Abort()
+			return
+		}
+		sub.Next()
+	}, middleware.ModelRequestRateLimit(), middleware.TokenRateLimit(), middleware.Distribute(), func(sub *gin.Context) {
+		Relay(sub, types.RelayFormatEmbedding)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if setupErr != nil {
+		return nil, setupErr
+	}
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed: status %d, body: %s", w.Code, w.Body.String())
+	}
+	return w.Body.Bytes(), nil
+}
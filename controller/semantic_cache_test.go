@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelaySemanticCacheEmbedding_TokenRateLimit(t *testing.T) {
+	db := setupTokenControllerTestDB(t)
+	require.NoError(t, db.AutoMigrate(&model.User{}))
+	require.NoError(t, db.Create(&model.User{Id: 1, Username: "semantic_user", AffCode: "semantic", Group: "default", Status: common.UserStatusEnabled}).Error)
+	token := seedToken(t, db, 1, "semantic", "semantic-cache-key")
+	require.NoError(t, db.Model(token).Update("rpm_limit", 1).Error)
+
+	// 对话请求已用掉令牌的 RPM 额度，向量化请求同样受令牌限流
+	allowed, err := service.AllowTokenRequest(context.Background(), token.Id, 1)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	common.SetContextKey(c, constant.ContextKeyTokenId, token.Id)
+	_, err = RelaySemanticCacheEmbedding(context.Background(), c, "default", &dto.EmbeddingRequest{Model: "text-embedding-3-small", Input: "hello"})
+	require.ErrorContains(t, err, "status 429")
+}
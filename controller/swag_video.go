@@ -40,6 +40,51 @@ func VideoGenerations(c *gin.Context) {
 func VideoGenerationsTaskId(c *gin.Context) {
 }
 
+// VideoGenerationsCancel
+// @Summary 取消视频任务
+// @Description 取消尚未完成的视频生成任务并退还预扣额度，仅支持上游提供取消接口的平台（Sora、豆包、Vidu）
+// @Description 上游已取消但任务同时被轮询推进到终态时，返回任务的实际状态。
+// @Tags Video
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} dto.VideoTaskResponse "取消后（或实际）的任务状态"
+// @Failure 400 {object} dto.OpenAIError "任务不存在、已完成或平台不支持取消"
+// @Failure 502 {object} dto.OpenAIError "上游取消失败"
+// @Router /v1/video/generations/{task_id}/cancel [post]
+func VideoGenerationsCancel(c *gin.Context) {
+}
+
+// VideoList
+// @Summary 视频任务列表
+// @Description 以 OpenAI 列表格式返回当前用户的视频任务，按 after 游标分页
+// @Tags Video
+// @Produce json
+// @Security BearerAuth
+// @Param after query string false "上一页最后一个任务 ID"
+// @Param limit query int false "每页数量，默认 20，最大 100"
+// @Param order query string false "排序：asc 或 desc（默认）"
+// @Success 200 {object} dto.OpenAIVideoList "视频任务列表"
+// @Failure 400 {object} dto.OpenAIError "请求参数错误"
+// @Router /v1/videos [get]
+func VideoList(c *gin.Context) {
+}
+
+// VideoAssetDownload
+// @Summary 下载已转存的任务结果
+// @Description 任务结果转存后，结果地址改写为本接口的签名地址，签名过期前无需鉴权即可下载
+// @Tags Video
+// @Produce octet-stream
+// @Param asset_id path string true "转存结果 ID"
+// @Param expires query int true "过期时间（Unix 秒）"
+// @Param signature query string true "签名"
+// @Success 200 {file} binary "结果文件"
+// @Failure 403 {object} dto.OpenAIError "签名无效或已过期"
+// @Failure 404 {object} dto.OpenAIError "结果不存在"
+// @Router /v1/assets/{asset_id} [get]
+func VideoAssetDownload(c *gin.Context) {
+}
+
 // KlingText2VideoGenerations
 // @Summary 可灵文生视频
 // @Description 调用可灵AI文生视频接口，生成视频内容
@@ -66,6 +66,16 @@ func GetUserTask(c *gin.Context) {
 	common.ApiSuccess(c, pageInfo)
 }
 
+// GetUserTaskWebhookDeliveries 返回当前用户某个任务的完成回调投递记录
+func GetUserTaskWebhookDeliveries(c *gin.Context) {
+	deliveries, err := model.GetTaskWebhookDeliveries(c.GetInt("id"), c.Param("task_id"))
+	if err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	common.ApiSuccess(c, deliveries)
+}
+
 func tasksToDto(tasks []*model.Task, fillUser bool) []*dto.TaskDto {
 	var userIdMap map[int]*model.UserBase
 	if fillUser {
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	assetstorage "github.com/QuantumNous/new-api/pkg/asset_storage"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskAssetDownload 通过签名地址下载已转存的任务结果，无需登录或令牌
+func TaskAssetDownload(c *gin.Context) {
+	assetId := c.Param("asset_id")
+	if !model.VerifyTaskAssetSignature(assetId, c.Query("expires"), c.Query("signature")) {
+		videoProxyError(c, http.StatusForbidden, "invalid_request_error", "Invalid or expired signature")
+		return
+	}
+	asset, err := model.GetTaskAssetByAssetId(assetId)
+	if err != nil {
+		videoProxyError(c, http.StatusNotFound, "invalid_request_error", "Asset not found")
+		return
+	}
+	serveTaskAsset(c, asset)
+}
+
+// serveTaskAsset 输出转存结果，本地存储支持 Range 请求
+func serveTaskAsset(c *gin.Context, asset *model.TaskAsset) {
+	reader, err := service.OpenTaskAsset(c.Request.Context(), asset)
+	if err != nil {
+		if errors.Is(err, assetstorage.ErrNotFound) {
+			videoProxyError(c, http.StatusNotFound, "invalid_request_error", "Asset not found")
+			return
+		}
+		logger.LogError(c.Request.Context(), fmt.Sprintf("Failed to open asset %s: %s", asset.AssetId, err.Error()))
+		videoProxyError(c, http.StatusBadGateway, "server_error", "Failed to read asset")
+		return
+	}
+	defer reader.Close()
+
+	c.Writer.Header().Set("Content-Type", asset.ContentType)
+	c.Writer.Header().Set("Cache-Control", "private, max-age=3600")
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, "", time.Unix(asset.CreatedAt, 0), seeker)
+		return
+	}
+	c.Writer.Header().Set("Content-Length", strconv.FormatInt(asset.Bytes, 10))
+	c.Writer.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		logger.LogError(c.Request.Context(), fmt.Sprintf("Failed to stream asset %s: %s", asset.AssetId, err.Error()))
+	}
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/i18n"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting/operation_setting"
 
 	"github.com/gin-gonic/gin"
@@ -187,6 +189,22 @@ func AddToken(c *gin.Context) {
 			return
 		}
 	}
+	if token.RpmLimit < 0 || token.TpmLimit < 0 || token.ConcurrencyLimit < 0 {
+		common.ApiErrorI18n(c, i18n.MsgTokenRateLimitNegative)
+		return
+	}
+	if err := operation_setting.CheckModelFallbackChains(token.ModelFallbacks); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := service.CheckTaskCallbackURL(token.TaskCallbackUrl); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := checkTokenOrganization(c.GetInt("id"), token.OrganizationId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
 	// 检查用户令牌数量是否已达上限
 	maxTokens := operation_setting.GetMaxUserTokens()
 	count, err := model.CountUserTokens(c.GetInt("id"))
@@ -208,19 +226,26 @@ func AddToken(c *gin.Context) {
 		return
 	}
 	cleanToken := model.Token{
-		UserId:             c.GetInt("id"),
-		Name:               token.Name,
-		Key:                key,
-		CreatedTime:        common.GetTimestamp(),
-		AccessedTime:       common.GetTimestamp(),
-		ExpiredTime:        token.ExpiredTime,
-		RemainQuota:        token.RemainQuota,
-		UnlimitedQuota:     token.UnlimitedQuota,
-		ModelLimitsEnabled: token.ModelLimitsEnabled,
-		ModelLimits:        token.ModelLimits,
-		AllowIps:           token.AllowIps,
-		Group:              token.Group,
-		CrossGroupRetry:    token.CrossGroupRetry,
+		UserId:                c.GetInt("id"),
+		Name:                  token.Name,
+		Key:                   key,
+		CreatedTime:           common.GetTimestamp(),
+		AccessedTime:          common.GetTimestamp(),
+		ExpiredTime:           token.ExpiredTime,
+		RemainQuota:           token.RemainQuota,
+		UnlimitedQuota:        token.UnlimitedQuota,
+		ModelLimitsEnabled:    token.ModelLimitsEnabled,
+		ModelLimits:           token.ModelLimits,
+		AllowIps:              token.AllowIps,
+		Group:                 token.Group,
+		CrossGroupRetry:       token.CrossGroupRetry,
+		RpmLimit:              token.RpmLimit,
+		TpmLimit:              token.TpmLimit,
+		ConcurrencyLimit:      token.ConcurrencyLimit,
+		SemanticCacheDisabled: token.SemanticCacheDisabled,
+		ModelFallbacks:        token.ModelFallbacks,
+		TaskCallbackUrl:       token.TaskCallbackUrl,
+		OrganizationId:        token.OrganizationId,
 	}
 	err = cleanToken.Insert()
 	if err != nil {
@@ -233,6 +258,24 @@ func AddToken(c *gin.Context) {
 	})
 }
 
+// checkTokenOrganization 令牌只能绑定用户当前所属的组织，绑定后该令牌的钱包计费从组织钱包扣除
+func checkTokenOrganization(userId int, organizationId int) error {
+	if organizationId == 0 {
+		return nil
+	}
+	member, err := model.GetOrganizationMemberByUserId(userId)
+	if err != nil {
+		if errors.Is(err, model.ErrOrganizationMemberNotFound) {
+			return errors.New("您不属于任何组织，无法绑定组织")
+		}
+		return err
+	}
+	if member.OrganizationId != organizationId {
+		return errors.New("只能绑定您当前所属的组织")
+	}
+	return nil
+}
+
 func DeleteToken(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	userId := c.GetInt("id")
@@ -271,6 +314,22 @@ func UpdateToken(c *gin.Context) {
 			return
 		}
 	}
+	if token.RpmLimit < 0 || token.TpmLimit < 0 || token.ConcurrencyLimit < 0 {
+		common.ApiErrorI18n(c, i18n.MsgTokenRateLimitNegative)
+		return
+	}
+	if err := operation_setting.CheckModelFallbackChains(token.ModelFallbacks); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := service.CheckTaskCallbackURL(token.TaskCallbackUrl); err != nil {
+		common.ApiError(c, err)
+		return
+	}
+	if err := checkTokenOrganization(c.GetInt("id"), token.OrganizationId); err != nil {
+		common.ApiError(c, err)
+		return
+	}
 	cleanToken, err := model.GetTokenByIds(token.Id, userId)
 	if err != nil {
 		common.ApiError(c, err)
@@ -299,6 +358,13 @@ func UpdateToken(c *gin.Context) {
 		cleanToken.AllowIps = token.AllowIps
 		cleanToken.Group = token.Group
 		cleanToken.CrossGroupRetry = token.CrossGroupRetry
+		cleanToken.RpmLimit = token.RpmLimit
+		cleanToken.TpmLimit = token.TpmLimit
+		cleanToken.ConcurrencyLimit = token.ConcurrencyLimit
+		cleanToken.SemanticCacheDisabled = token.SemanticCacheDisabled
+		cleanToken.ModelFallbacks = token.ModelFallbacks
+		cleanToken.TaskCallbackUrl = token.TaskCallbackUrl
+		cleanToken.OrganizationId = token.OrganizationId
 	}
 	err = cleanToken.Update()
 	if err != nil {
@@ -422,6 +422,8 @@ func GetSelf(c *gin.Context) {
 		"linux_do_id":       user.LinuxDOId,
 		"setting":           user.Setting,
 		"stripe_customer":   user.StripeCustomer,
+		"company_name":      user.CompanyName,
+		"tax_id":            user.TaxId,
 		"sidebar_modules":   userSetting.SidebarModules, // 正确提取sidebar_modules字段
 		"permissions":       permissions,                // 新增权限字段
 	}
@@ -1139,6 +1141,7 @@ type UpdateUserSettingRequest struct {
 	UpstreamModelUpdateNotifyEnabled *bool   `json:"upstream_model_update_notify_enabled,omitempty"`
 	AcceptUnsetModelRatioModel       bool    `json:"accept_unset_model_ratio_model"`
 	RecordIpLog                      bool    `json:"record_ip_log"`
+	UsageReportFrequency             string  `json:"usage_report_frequency"`
 }
 
 func UpdateUserSetting(c *gin.Context) {
@@ -1222,6 +1225,12 @@ func UpdateUserSetting(c *gin.Context) {
 		}
 	}
 
+	// 验证用量报告频率
+	if req.UsageReportFrequency != "" && req.UsageReportFrequency != dto.UsageReportWeekly && req.UsageReportFrequency != dto.UsageReportMonthly {
+		common.ApiErrorI18n(c, i18n.MsgSettingReportFrequency)
+		return
+	}
+
 	userId := c.GetInt("id")
 	user, err := model.GetUserById(userId, true)
 	if err != nil {
@@ -1241,6 +1250,7 @@ func UpdateUserSetting(c *gin.Context) {
 		UpstreamModelUpdateNotifyEnabled: upstreamModelUpdateNotifyEnabled,
 		AcceptUnsetRatioModel:            req.AcceptUnsetModelRatioModel,
 		RecordIpLog:                      req.RecordIpLog,
+		UsageReportFrequency:             req.UsageReportFrequency,
 	}
 
 	// 如果是webhook类型,添加webhook相关设置
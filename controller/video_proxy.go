@@ -55,6 +55,14 @@ func VideoProxy(c *gin.Context) {
 		return
 	}
 
+	// 结果已转存时直接从存储后端读取，不再依赖上游地址
+	if task.PrivateData.AssetId != "" {
+		if asset, err := model.GetTaskAssetByAssetId(task.PrivateData.AssetId); err == nil {
+			serveTaskAsset(c, asset)
+			return
+		}
+	}
+
 	channel, err := model.CacheGetChannel(task.ChannelId)
 	if err != nil {
 		logger.LogError(c.Request.Context(), fmt.Sprintf("Failed to get channel for task %s: %s", taskID, err.Error()))
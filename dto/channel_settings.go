@@ -41,6 +41,9 @@ type ChannelOtherSettings struct {
 	UpstreamModelUpdateLastDetectedModels []string      `json:"upstream_model_update_last_detected_models,omitempty"` // 上次检测到的可加入模型
 	UpstreamModelUpdateLastRemovedModels  []string      `json:"upstream_model_update_last_removed_models,omitempty"`  // 上次检测到的可删除模型
 	UpstreamModelUpdateIgnoredModels      []string      `json:"upstream_model_update_ignored_models,omitempty"`       // 手动忽略的模型
+	MultiKeyRPMLimit                      int           `json:"multi_key_rpm_limit,omitempty"`                        // 多 Key 模式下单个 Key 每分钟请求数上限，0 为不限制
+	MultiKeyTPMLimit                      int           `json:"multi_key_tpm_limit,omitempty"`                        // 多 Key 模式下单个 Key 每分钟 token 数上限，0 为不限制
+	MultiKeyCooldownSeconds               int           `json:"multi_key_cooldown_seconds,omitempty"`                 // 自动禁用的 Key 冷却多少秒后自动恢复，0 为不恢复
 }
 
 func (s *ChannelOtherSettings) IsOpenRouterEnterprise() bool {
@@ -0,0 +1,134 @@
+package dto
+
+import "encoding/json"
+
+const (
+	BatchStatusValidating = "validating"
+	BatchStatusFailed     = "failed"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// OpenAIBatchRequest https://platform.openai.com/docs/api-reference/batch/create
+type OpenAIBatchRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+type OpenAIBatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+type OpenAIBatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+type OpenAIBatchErrors struct {
+	Object string             `json:"object"`
+	Data   []OpenAIBatchError `json:"data"`
+}
+
+// OpenAIBatch https://platform.openai.com/docs/api-reference/batch/object
+type OpenAIBatch struct {
+	ID               string                   `json:"id"`
+	Object           string                   `json:"object"`
+	Endpoint         string                   `json:"endpoint"`
+	Errors           *OpenAIBatchErrors       `json:"errors,omitempty"`
+	InputFileID      string                   `json:"input_file_id"`
+	CompletionWindow string                   `json:"completion_window"`
+	Status           string                   `json:"status"`
+	OutputFileID     string                   `json:"output_file_id,omitempty"`
+	ErrorFileID      string                   `json:"error_file_id,omitempty"`
+	CreatedAt        int64                    `json:"created_at"`
+	InProgressAt     int64                    `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64                    `json:"expires_at,omitempty"`
+	FinalizingAt     int64                    `json:"finalizing_at,omitempty"`
+	CompletedAt      int64                    `json:"completed_at,omitempty"`
+	FailedAt         int64                    `json:"failed_at,omitempty"`
+	ExpiredAt        int64                    `json:"expired_at,omitempty"`
+	CancellingAt     int64                    `json:"cancelling_at,omitempty"`
+	CancelledAt      int64                    `json:"cancelled_at,omitempty"`
+	RequestCounts    OpenAIBatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string        `json:"metadata,omitempty"`
+}
+
+type OpenAIBatchList struct {
+	Object  string        `json:"object"`
+	Data    []OpenAIBatch `json:"data"`
+	FirstID string        `json:"first_id,omitempty"`
+	LastID  string        `json:"last_id,omitempty"`
+	HasMore bool          `json:"has_more"`
+}
+
+// OpenAIBatchInputLine 批处理输入文件中的一行
+type OpenAIBatchInputLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// OpenAIBatchOutputLine 批处理输出文件中的一行
+type OpenAIBatchOutputLine struct {
+	ID       string                     `json:"id"`
+	CustomID string                     `json:"custom_id"`
+	Response *OpenAIBatchOutputResponse `json:"response"`
+	Error    *OpenAIBatchError          `json:"error"`
+}
+
+type OpenAIBatchOutputResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// ClaudeMessageBatchRequest https://docs.anthropic.com/en/api/creating-message-batches
+type ClaudeMessageBatchRequest struct {
+	Requests []ClaudeMessageBatchItem `json:"requests"`
+}
+
+type ClaudeMessageBatchItem struct {
+	CustomID string          `json:"custom_id"`
+	Params   json.RawMessage `json:"params"`
+}
+
+type ClaudeMessageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// ClaudeMessageBatch https://docs.anthropic.com/en/api/retrieving-message-batches
+type ClaudeMessageBatch struct {
+	ID                string                          `json:"id"`
+	Type              string                          `json:"type"`
+	ProcessingStatus  string                          `json:"processing_status"` // in_progress, canceling, ended
+	RequestCounts     ClaudeMessageBatchRequestCounts `json:"request_counts"`
+	CreatedAt         string                          `json:"created_at"`
+	EndedAt           string                          `json:"ended_at,omitempty"`
+	ExpiresAt         string                          `json:"expires_at,omitempty"`
+	CancelInitiatedAt string                          `json:"cancel_initiated_at,omitempty"`
+	ResultsURL        string                          `json:"results_url,omitempty"`
+}
+
+// ClaudeMessageBatchResult 结果文件中的一行
+type ClaudeMessageBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string          `json:"type"` // succeeded, errored, canceled, expired
+		Message json.RawMessage `json:"message,omitempty"`
+	} `json:"result"`
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 )
@@ -51,3 +52,19 @@ type OpenAIVideoError struct {
 	Message string `json:"message"`
 	Code    string `json:"code"`
 }
+
+// OpenAIVideoList GET /v1/videos 的游标分页列表
+type OpenAIVideoList struct {
+	Object  string            `json:"object"`
+	Data    []json.RawMessage `json:"data"`
+	FirstID string            `json:"first_id,omitempty"`
+	LastID  string            `json:"last_id,omitempty"`
+	HasMore bool              `json:"has_more"`
+}
+
+// OpenAIVideoDeleted DELETE /v1/videos/:id 的响应
+type OpenAIVideoDeleted struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
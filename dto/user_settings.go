@@ -16,6 +16,7 @@ type UserSetting struct {
 	SidebarModules                   string  `json:"sidebar_modules,omitempty"`                      // SidebarModules 左侧边栏模块配置
 	BillingPreference                string  `json:"billing_preference,omitempty"`                   // BillingPreference 扣费策略（订阅/钱包）
 	Language                         string  `json:"language,omitempty"`                             // Language 用户语言偏好 (zh, en)
+	UsageReportFrequency             string  `json:"usage_report_frequency,omitempty"`               // UsageReportFrequency 用量报告邮件频率（weekly/monthly），留空不发送
 }
 
 var (
@@ -24,3 +25,8 @@ var (
 	NotifyTypeBark    = "bark"    // Bark 推送
 	NotifyTypeGotify  = "gotify"  // Gotify 推送
 )
+
+const (
+	UsageReportWeekly  = "weekly"  // 每周一发送上一自然周的用量报告
+	UsageReportMonthly = "monthly" // 每月 1 日发送上一自然月的用量报告
+)
@@ -20,6 +20,7 @@ import (
 	"github.com/QuantumNous/new-api/model"
 	"github.com/QuantumNous/new-api/oauth"
 	perfmetrics "github.com/QuantumNous/new-api/pkg/perf_metrics"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/relay"
 	"github.com/QuantumNous/new-api/router"
 	"github.com/QuantumNous/new-api/service"
@@ -119,6 +120,16 @@ func main() {
 	// Subscription quota reset task (daily/weekly/monthly/custom)
 	service.StartSubscriptionQuotaResetTask()
 
+	// Weekly/monthly usage report emails
+	service.StartUsageReportTask()
+
+	// Expired files cleanup task (/v1/files)
+	service.StartFileCleanupTask()
+	service.StartAssetCleanupTask()
+
+	// Multi-key usage sync and cooled down key re-enable task
+	service.StartMultiKeyMaintenanceTask()
+
 	// Wire task polling adaptor factory (breaks service -> relay import cycle)
 	service.GetTaskAdaptorFunc = func(platform constant.TaskPlatform) service.TaskPollingAdaptor {
 		a := relay.GetTaskAdaptor(platform)
@@ -128,9 +139,16 @@ func main() {
 		return a
 	}
 
+	// Wire moderation requests and semantic cache embeddings through relay adaptors
+	service.ChannelRequestFunc = relay.DoChannelRequest
+	service.SemanticCacheEmbeddingFunc = controller.RelaySemanticCacheEmbedding
+
 	// Channel upstream model update check task
 	controller.StartChannelUpstreamModelUpdateTask()
 
+	// Local batch executor (/v1/batches without native batch channels)
+	controller.StartBatchLocalExecutor()
+
 	if common.IsMasterNode && constant.UpdateTask {
 		gopool.Go(func() {
 			controller.UpdateMidjourneyTaskBulk()
@@ -158,6 +176,11 @@ func main() {
 		common.SysError(fmt.Sprintf("start pyroscope error : %v", err))
 	}
 
+	err = tracing.Init()
+	if err != nil {
+		common.SysError(fmt.Sprintf("init tracing error : %v", err))
+	}
+
 	// Initialize HTTP server
 	server := gin.New()
 	server.Use(gin.CustomRecovery(func(c *gin.Context, err any) {
@@ -172,6 +195,7 @@ func main() {
 	// This will cause SSE not to work!!!
 	//server.Use(gzip.Gzip(gzip.DefaultCompression))
 	server.Use(middleware.RequestId())
+	server.Use(middleware.Tracing())
 	server.Use(middleware.PoweredBy())
 	server.Use(middleware.I18n())
 	middleware.SetUpLogger(server)
@@ -308,6 +332,7 @@ func InitResources() error {
 	}
 
 	perfmetrics.Init()
+	service.InitPrometheusMetrics()
 
 	// 启动系统监控
 	common.StartSystemMonitor()
@@ -275,6 +275,8 @@ func TokenAuthReadOnly() func(c *gin.Context) {
 
 func TokenAuth() func(c *gin.Context) {
 	return func(c *gin.Context) {
+		endSpan := startStageSpan(c, "auth.token")
+		defer endSpan()
 		// 先检测是否为ws
 		if c.Request.Header.Get("Sec-WebSocket-Protocol") != "" {
 			// Sec-WebSocket-Protocol: realtime, openai-insecure-api-key.sk-xxx, openai-beta.realtime-v1
@@ -379,6 +381,12 @@ func TokenAuth() func(c *gin.Context) {
 
 		userCache.WriteContext(c)
 
+		// 组织令牌只能在用户仍属于该组织时使用，避免退出组织后改用个人钱包计费
+		if token.OrganizationId != 0 && token.OrganizationId != userCache.OrganizationId {
+			abortWithOpenAiMessage(c, http.StatusForbidden, "令牌绑定的组织已不包含当前用户")
+			return
+		}
+
 		userGroup := userCache.Group
 		tokenGroup := token.Group
 		if tokenGroup != "" {
@@ -402,6 +410,7 @@ func TokenAuth() func(c *gin.Context) {
 		if err != nil {
 			return
 		}
+		endSpan()
 		c.Next()
 	}
 }
@@ -426,6 +435,13 @@ func SetupContextForToken(c *gin.Context, token *model.Token, parts ...string) e
 	}
 	common.SetContextKey(c, constant.ContextKeyTokenGroup, token.Group)
 	common.SetContextKey(c, constant.ContextKeyTokenCrossGroupRetry, token.CrossGroupRetry)
+	common.SetContextKey(c, constant.ContextKeyTokenRpmLimit, token.RpmLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenTpmLimit, token.TpmLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenConcurrencyLimit, token.ConcurrencyLimit)
+	common.SetContextKey(c, constant.ContextKeyTokenSemanticCacheDisabled, token.SemanticCacheDisabled)
+	common.SetContextKey(c, constant.ContextKeyTokenModelFallbacks, token.GetModelFallbacks())
+	common.SetContextKey(c, constant.ContextKeyTokenTaskCallbackUrl, token.TaskCallbackUrl)
+	common.SetContextKey(c, constant.ContextKeyTokenOrganizationId, token.OrganizationId)
 	if len(parts) > 1 {
 		if model.IsAdmin(token.UserId) {
 			c.Set("specific_channel_id", parts[1])
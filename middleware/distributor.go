@@ -29,8 +29,35 @@ type ModelRequest struct {
 	Group string `json:"group,omitempty"`
 }
 
+// CheckTokenModelLimit 检查令牌是否允许使用该模型，不允许时返回错误响应并返回 false
+func CheckTokenModelLimit(c *gin.Context, modelName string) bool {
+	modelLimitEnable := common.GetContextKeyBool(c, constant.ContextKeyTokenModelLimitEnabled)
+	if !modelLimitEnable {
+		return true
+	}
+	s, ok := common.GetContextKey(c, constant.ContextKeyTokenModelLimit)
+	if !ok {
+		// token model limit is empty, all models are not allowed
+		abortWithOpenAiMessage(c, http.StatusForbidden, i18n.T(c, i18n.MsgDistributorTokenNoModelAccess))
+		return false
+	}
+	var tokenModelLimit map[string]bool
+	tokenModelLimit, ok = s.(map[string]bool)
+	if !ok {
+		tokenModelLimit = map[string]bool{}
+	}
+	matchName := ratio_setting.FormatMatchingModelName(modelName) // match gpts & thinking-*
+	if _, ok := tokenModelLimit[matchName]; !ok {
+		abortWithOpenAiMessage(c, http.StatusForbidden, i18n.T(c, i18n.MsgDistributorTokenModelForbidden, map[string]any{"Model": modelName}))
+		return false
+	}
+	return true
+}
+
 func Distribute() func(c *gin.Context) {
 	return func(c *gin.Context) {
+		endSpan := startStageSpan(c, "relay.distribute")
+		defer endSpan()
 		var channel *model.Channel
 		channelId, ok := common.GetContextKey(c, constant.ContextKeyTokenSpecificChannelId)
 		modelRequest, shouldSelectChannel, err := getModelRequest(c)
@@ -61,27 +88,12 @@ func Distribute() func(c *gin.Context) {
 		} else {
 			// Select a channel for the user
 			// check token model mapping
-			modelLimitEnable := common.GetContextKeyBool(c, constant.ContextKeyTokenModelLimitEnabled)
-			if modelLimitEnable {
-				s, ok := common.GetContextKey(c, constant.ContextKeyTokenModelLimit)
-				if !ok {
-					// token model limit is empty, all models are not allowed
-					abortWithOpenAiMessage(c, http.StatusForbidden, i18n.T(c, i18n.MsgDistributorTokenNoModelAccess))
-					return
-				}
-				var tokenModelLimit map[string]bool
-				tokenModelLimit, ok = s.(map[string]bool)
-				if !ok {
-					tokenModelLimit = map[string]bool{}
-				}
-				matchName := ratio_setting.FormatMatchingModelName(modelRequest.Model) // match gpts & thinking-*
-				if _, ok := tokenModelLimit[matchName]; !ok {
-					abortWithOpenAiMessage(c, http.StatusForbidden, i18n.T(c, i18n.MsgDistributorTokenModelForbidden, map[string]any{"Model": modelRequest.Model}))
-					return
-				}
+			if !CheckTokenModelLimit(c, modelRequest.Model) {
+				return
 			}
 
-			if shouldSelectChannel {
+			// 命中响应缓存时不需要渠道
+			if shouldSelectChannel && service.GetResponseCacheHit(c) == nil {
 				if modelRequest.Model == "" {
 					abortWithOpenAiMessage(c, http.StatusBadRequest, i18n.T(c, i18n.MsgDistributorModelNameRequired))
 					return
@@ -137,13 +149,15 @@ func Distribute() func(c *gin.Context) {
 				}
 
 				if channel == nil {
-					channel, selectGroup, err = service.CacheGetRandomSatisfiedChannel(&service.RetryParam{
-						Ctx:          c,
-						ModelName:    modelRequest.Model,
-						TokenGroup:   usingGroup,
-						EndpointType: requiredEndpoint,
-						Retry:        common.GetPointer(0),
-					})
+					retryParam := &service.RetryParam{
+						Ctx:           c,
+						ModelName:     modelRequest.Model,
+						TokenGroup:    usingGroup,
+						EndpointType:  requiredEndpoint,
+						Retry:         common.GetPointer(0),
+						ModelFallback: true,
+					}
+					channel, selectGroup, err = service.CacheGetRandomSatisfiedChannel(retryParam)
 					if err != nil {
 						showGroup := usingGroup
 						if usingGroup == "auto" {
@@ -162,11 +176,25 @@ func Distribute() func(c *gin.Context) {
 						abortWithOpenAiMessage(c, http.StatusServiceUnavailable, i18n.T(c, i18n.MsgDistributorNoAvailableChannel, map[string]any{"Group": usingGroup, "Model": modelRequest.Model}), types.ErrorCodeModelNotFound)
 						return
 					}
+					// 原模型没有可用渠道时已切换到降级模型，后续转发与计费均使用降级模型
+					if retryParam.ModelName != modelRequest.Model {
+						modelRequest.Model = retryParam.ModelName
+						c.Header("X-New-Api-Fallback-Model", modelRequest.Model)
+					}
 				}
 			}
 		}
 		common.SetContextKey(c, constant.ContextKeyRequestStartTime, time.Now())
-		SetupContextForSelectedChannel(c, channel, modelRequest.Model)
+		if service.GetResponseCacheHit(c) != nil {
+			// 命中响应缓存时不使用渠道，也不占用指定渠道 Key 的限额
+			channel = nil
+		}
+		if newAPIError := SetupContextForSelectedChannel(c, channel, modelRequest.Model); newAPIError != nil && newAPIError.GetErrorCode() == types.ErrorCodeChannelKeyRateLimited {
+			// 多 Key 渠道的 Key 全部达到单 Key RPM/TPM 上限
+			abortWithOpenAiMessage(c, newAPIError.StatusCode, newAPIError.Error(), newAPIError.GetErrorCode())
+			return
+		}
+		endSpan()
 		c.Next()
 		if channel != nil && c.Writer != nil && c.Writer.Status() < http.StatusBadRequest {
 			service.RecordChannelAffinity(c, channel.Id)
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/QuantumNous/new-api/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuth 校验 /metrics 的 Bearer Token，未配置 METRICS_TOKEN 时视为未开放
+func MetricsAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if constant.MetricsToken == "" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(constant.MetricsToken)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+}
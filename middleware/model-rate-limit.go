@@ -10,6 +10,7 @@ import (
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/common/limiter"
 	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting"
 
 	"github.com/gin-gonic/gin"
@@ -74,6 +75,29 @@ func recordRedisRequest(ctx context.Context, rdb *redis.Client, key string, maxC
 	rdb.Expire(ctx, key, time.Duration(setting.ModelRequestRateLimitDurationMinutes)*time.Minute)
 }
 
+// setBucketRateLimitHeaders 查询总请求数令牌桶的余量并写入响应头，桶以 1/duration 为单位计数
+func setBucketRateLimitHeaders(c *gin.Context, ctx context.Context, tb *limiter.RedisLimiter, key string, maxCount int, duration int64, rejected bool) {
+	remaining, err := tb.Consume(
+		ctx,
+		key,
+		limiter.WithCapacity(int64(maxCount)*duration),
+		limiter.WithRate(int64(maxCount)),
+		limiter.WithRequested(0),
+	)
+	if err != nil {
+		return
+	}
+	info := service.RateLimitHeaderInfo{
+		RequestsLimit:     maxCount,
+		RequestsRemaining: int(remaining / duration),
+		RequestsReset:     time.Duration((int64(maxCount)*duration-remaining)/int64(maxCount)) * time.Second,
+	}
+	if rejected {
+		info.RetryAfter = time.Duration((duration-remaining+int64(maxCount)-1)/int64(maxCount)) * time.Second
+	}
+	service.SetRateLimitHeaders(c, service.InboundRelayFormat(c), info)
+}
+
 // Redis限流处理器
 func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -90,6 +114,7 @@ func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) g
 			return
 		}
 		if !allowed {
+			setRedisRateLimitHeaders(c, ctx, rdb, successKey, successMaxCount, duration, 0, true)
 			abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("您已达到请求数限制：%d分钟内最多请求%d次", setting.ModelRequestRateLimitDurationMinutes, successMaxCount))
 			return
 		}
@@ -113,10 +138,14 @@ func redisRateLimitHandler(duration int64, totalMaxCount, successMaxCount int) g
 				return
 			}
 
+			setBucketRateLimitHeaders(c, ctx, tb, totalKey, totalMaxCount, duration, !allowed)
 			if !allowed {
 				abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("您已达到总请求数限制：%d分钟内最多请求%d次，包括失败次数，请检查您的请求是否正确", setting.ModelRequestRateLimitDurationMinutes, totalMaxCount))
+				return
 			}
 		}
+		// 成功请求数在请求结束后才记录，本次请求按已计入处理
+		setRedisRateLimitHeaders(c, ctx, rdb, successKey, successMaxCount, duration, 1, false)
 
 		// 4. 处理请求
 		c.Next()
@@ -138,20 +167,26 @@ func memoryRateLimitHandler(duration int64, totalMaxCount, successMaxCount int)
 		successKey := ModelRequestRateLimitSuccessCountMark + userId
 
 		// 1. 检查总请求数限制（当totalMaxCount为0时跳过）
-		if totalMaxCount > 0 && !inMemoryRateLimiter.Request(totalKey, totalMaxCount, duration) {
-			c.Status(http.StatusTooManyRequests)
-			c.Abort()
-			return
+		if totalMaxCount > 0 {
+			if !inMemoryRateLimiter.Request(totalKey, totalMaxCount, duration) {
+				setMemoryRateLimitHeaders(c, totalKey, totalMaxCount, duration, true)
+				c.Status(http.StatusTooManyRequests)
+				c.Abort()
+				return
+			}
+			setMemoryRateLimitHeaders(c, totalKey, totalMaxCount, duration, false)
 		}
 
 		// 2. 检查成功请求数限制
 		// 使用一个临时key来检查限制，这样可以避免实际记录
 		checkKey := successKey + "_check"
 		if !inMemoryRateLimiter.Request(checkKey, successMaxCount, duration) {
+			setMemoryRateLimitHeaders(c, checkKey, successMaxCount, duration, true)
 			c.Status(http.StatusTooManyRequests)
 			c.Abort()
 			return
 		}
+		setMemoryRateLimitHeaders(c, checkKey, successMaxCount, duration, false)
 
 		// 3. 处理请求
 		c.Next()
@@ -7,7 +7,10 @@ import (
 	"time"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/service"
+
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 )
 
 var timeFormat = "2006-01-02T15:04:05.000Z"
@@ -53,6 +56,7 @@ func redisRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark st
 		// See: https://stackoverflow.com/questions/50970900/why-is-time-since-returning-negative-durations-on-windows
 		if int64(nowTime.Sub(oldTime).Seconds()) < duration {
 			rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
+			setRedisRateLimitHeaders(c, ctx, rdb, key, maxRequestNum, duration, 0, true)
 			c.Status(http.StatusTooManyRequests)
 			c.Abort()
 			return
@@ -62,15 +66,70 @@ func redisRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark st
 			rdb.Expire(ctx, key, common.RateLimitKeyExpirationDuration)
 		}
 	}
+	setRedisRateLimitHeaders(c, ctx, rdb, key, maxRequestNum, duration, 0, false)
 }
 
 func memoryRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark string) {
 	key := mark + c.ClientIP()
 	if !inMemoryRateLimiter.Request(key, maxRequestNum, duration) {
+		setMemoryRateLimitHeaders(c, key, maxRequestNum, duration, true)
 		c.Status(http.StatusTooManyRequests)
 		c.Abort()
 		return
 	}
+	setMemoryRateLimitHeaders(c, key, maxRequestNum, duration, false)
+}
+
+// setRequestRateLimitHeaders 按入站格式写入请求数限流响应头，count 为窗口内已计入的请求数（含本次），
+// reset 为最早一条记录离开窗口的时间
+func setRequestRateLimitHeaders(c *gin.Context, maxRequestNum int, count int, reset time.Duration, rejected bool) {
+	info := service.RateLimitHeaderInfo{
+		RequestsLimit:     maxRequestNum,
+		RequestsRemaining: maxRequestNum - count,
+		RequestsReset:     reset,
+	}
+	if rejected {
+		info.RetryAfter = reset
+	}
+	service.SetRateLimitHeaders(c, service.InboundRelayFormat(c), info)
+}
+
+// setRedisRateLimitHeaders 统计 Redis 列表 [new --> old] 中仍在窗口内的记录并写入响应头。
+// pending 为本次请求尚未写入列表的计数（例如成功请求数在请求结束后才记录）。
+func setRedisRateLimitHeaders(c *gin.Context, ctx context.Context, rdb *redis.Client, key string, maxRequestNum int, duration int64, pending int, rejected bool) {
+	records, err := rdb.LRange(ctx, key, 0, int64(maxRequestNum-1)).Result()
+	if err != nil {
+		return
+	}
+	nowTime, err := time.Parse(timeFormat, time.Now().Format(timeFormat))
+	if err != nil {
+		return
+	}
+	window := time.Duration(duration) * time.Second
+	count := 0
+	var reset time.Duration
+	for _, record := range records {
+		recordTime, err := time.Parse(timeFormat, record)
+		if err != nil {
+			return
+		}
+		elapsed := nowTime.Sub(recordTime)
+		if elapsed >= window {
+			break
+		}
+		count++
+		reset = window - elapsed
+	}
+	if pending > 0 && count == 0 {
+		reset = window
+	}
+	setRequestRateLimitHeaders(c, maxRequestNum, count+pending, reset, rejected)
+}
+
+// setMemoryRateLimitHeaders 与 setRedisRateLimitHeaders 相同，数据来自 inMemoryRateLimiter（本次请求已记录）
+func setMemoryRateLimitHeaders(c *gin.Context, key string, maxRequestNum int, duration int64, rejected bool) {
+	count, reset := inMemoryRateLimiter.Status(key, duration)
+	setRequestRateLimitHeaders(c, maxRequestNum, count, time.Duration(reset)*time.Second, rejected)
 }
 
 func rateLimitFactory(maxRequestNum int, duration int64, mark string) func(c *gin.Context) {
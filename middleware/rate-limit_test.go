@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimiter_SetsRequestHeaders(t *testing.T) {
+	redisEnabled := common.RedisEnabled
+	common.RedisEnabled = false
+	t.Cleanup(func() { common.RedisEnabled = redisEnabled })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(rateLimitFactory(2, 60, "TEST-HEADERS"))
+	router.GET("/api/status", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+		return w
+	}
+
+	w := request()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get("x-ratelimit-limit-requests"))
+	require.Equal(t, "1", w.Header().Get("x-ratelimit-remaining-requests"))
+	require.Equal(t, "1m0s", w.Header().Get("x-ratelimit-reset-requests"))
+
+	w = request()
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+	require.Empty(t, w.Header().Get("Retry-After"))
+
+	w = request()
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+}
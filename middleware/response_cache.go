@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseCache 在 Distribute 之前查询响应缓存（精确匹配与语义缓存）。
+// 命中时 Distribute 不再选择渠道，由 Relay 完成计费检查后直接返回缓存的响应，
+// 因此命中不占用渠道 Key 的 RPM/TPM，也不受渠道可用性影响
+func ResponseCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service.LookupResponseCache(c)
+		c.Next()
+	}
+}
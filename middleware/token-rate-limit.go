@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenRateLimit 令牌级限流中间件：RPM、TPM（按实际用量结算）与最大并发请求数，需在 TokenAuth 之后使用
+func TokenRateLimit() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		rpmLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenRpmLimit)
+		tpmLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenTpmLimit)
+		concurrencyLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenConcurrencyLimit)
+		tokenId := common.GetContextKeyInt(c, constant.ContextKeyTokenId)
+		if rpmLimit <= 0 && tpmLimit <= 0 && concurrencyLimit <= 0 {
+			service.SetTokenRateLimitHeaders(c, tokenId, 0, 0, false)
+			c.Next()
+			return
+		}
+		// count_tokens 端点免费且不计入 RPM 统计，直接放行
+		if common.IsClaudeCountTokensPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		// 1. TPM 只检查余量，实际扣减在请求结算时进行
+		allowed, err := service.IsTokenTpmAvailable(c, tokenId, tpmLimit)
+		if err != nil {
+			logger.LogError(c, "check token tpm limit failed: "+err.Error())
+			abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+			return
+		}
+		if !allowed {
+			service.SetTokenRateLimitHeaders(c, tokenId, rpmLimit, tpmLimit, true)
+			abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("该令牌已达到 TPM 限制：每分钟最多使用 %d tokens", tpmLimit))
+			return
+		}
+
+		// 2. RPM
+		allowed, err = service.AllowTokenRequest(c, tokenId, rpmLimit)
+		if err != nil {
+			logger.LogError(c, "check token rpm limit failed: "+err.Error())
+			abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+			return
+		}
+		if !allowed {
+			service.SetTokenRateLimitHeaders(c, tokenId, rpmLimit, tpmLimit, true)
+			abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("该令牌已达到 RPM 限制：每分钟最多请求 %d 次", rpmLimit))
+			return
+		}
+
+		// 3. 并发数，请求结束后释放
+		release, allowed, err := service.AcquireTokenConcurrency(c, tokenId, concurrencyLimit)
+		if err != nil {
+			logger.LogError(c, "check token concurrency limit failed: "+err.Error())
+			abortWithOpenAiMessage(c, http.StatusInternalServerError, "rate_limit_check_failed")
+			return
+		}
+		if !allowed {
+			abortWithOpenAiMessage(c, http.StatusTooManyRequests, fmt.Sprintf("该令牌已达到并发请求数限制：最多同时处理 %d 个请求", concurrencyLimit))
+			return
+		}
+		defer release()
+		service.SetTokenRateLimitHeaders(c, tokenId, rpmLimit, tpmLimit, false)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errStageAborted = errors.New("request aborted")
+
+// Tracing 为已匹配路由的请求创建服务端 span，后续 span 均以其为父 span
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// 未匹配路由（前端静态资源等）不记录
+			c.Next()
+			return
+		}
+		span := tracing.StartServerSpan(c, route)
+		defer tracing.EndServerSpan(c, span)
+		c.Next()
+	}
+}
+
+// startStageSpan 为中间件阶段创建 span，返回的 end 只生效一次：
+// 在 c.Next() 前调用以排除后续 handler 的耗时，同时 defer 调用以覆盖提前返回的情况
+func startStageSpan(c *gin.Context, name string) func() {
+	_, span := tracing.Start(c, name)
+	return sync.OnceFunc(func() {
+		span.SetAttributes(
+			attribute.Int("new_api.user_id", common.GetContextKeyInt(c, constant.ContextKeyUserId)),
+			attribute.Int("new_api.token_id", common.GetContextKeyInt(c, constant.ContextKeyTokenId)),
+			attribute.String("new_api.group", common.GetContextKeyString(c, constant.ContextKeyUsingGroup)),
+			attribute.String("new_api.model", common.GetContextKeyString(c, constant.ContextKeyOriginalModel)),
+			attribute.Int("new_api.channel_id", common.GetContextKeyInt(c, constant.ContextKeyChannelId)),
+		)
+		var err error
+		if c.IsAborted() {
+			err = errStageAborted
+		}
+		tracing.End(span, err)
+	})
+}
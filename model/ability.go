@@ -9,6 +9,8 @@ import (
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/constant"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 
 	"github.com/samber/lo"
@@ -124,21 +126,23 @@ func GetChannel(group string, model string, retry int) (*Channel, error) {
 	}
 	channel := Channel{}
 	if len(abilities) > 0 {
+		abilities, healthScores := filterHealthyAbilities(abilities)
 		// Randomly choose one
-		weightSum := uint(0)
+		weightSum := 0
 		for _, ability_ := range abilities {
-			weightSum += ability_.Weight + 10
+			weightSum += circuitbreaker.ScaleWeight(int(ability_.Weight)+10, healthScores[ability_.ChannelId])
 		}
 		// Randomly choose one
-		weight := common.GetRandomInt(int(weightSum))
+		weight := common.GetRandomInt(weightSum)
 		for _, ability_ := range abilities {
-			weight -= int(ability_.Weight) + 10
+			weight -= circuitbreaker.ScaleWeight(int(ability_.Weight)+10, healthScores[ability_.ChannelId])
 			//log.Printf("weight: %d, ability weight: %d", weight, *ability_.Weight)
 			if weight <= 0 {
 				channel.Id = ability_.ChannelId
 				break
 			}
 		}
+		circuitbreaker.MarkSelected(channel.Id, circuitbreaker.ChannelLevel)
 	} else {
 		return nil, nil
 	}
@@ -147,7 +151,8 @@ func GetChannel(group string, model string, retry int) (*Channel, error) {
 }
 
 func GetChannelForEndpoint(group string, modelName string, retry int, endpointType constant.EndpointType) (*Channel, error) {
-	if endpointType == "" {
+	// 按权重随机且无需过滤端点时直接在数据库中按优先级查询，其余路由策略需要加载渠道信息
+	if endpointType == "" && operation_setting.GetRoutingStrategy(group, modelName) == operation_setting.RoutingStrategyWeightedRandom {
 		return GetChannel(group, modelName, retry)
 	}
 	channel, err := getChannelForEndpointDB(group, modelName, modelName, retry, endpointType)
@@ -184,11 +189,11 @@ func getChannelForEndpointDB(group string, abilityModel string, requestModel str
 			}
 			channelByID[ability.ChannelId] = channel
 		}
-		if common.ChannelSupportsEndpointType(channel.Type, endpointType) {
+		if endpointType == "" || common.ChannelSupportsEndpointType(channel.Type, endpointType) {
 			filtered = append(filtered, ability)
 		}
 	}
-	return chooseChannelFromAbilities(filtered, channelByID, group, abilityModel, retry)
+	return chooseChannelFromAbilities(filtered, channelByID, group, requestModel, retry)
 }
 
 func chooseChannelFromAbilities(abilities []Ability, channelByID map[int]*Channel, group string, modelName string, retry int) (*Channel, error) {
@@ -196,6 +201,9 @@ func chooseChannelFromAbilities(abilities []Ability, channelByID map[int]*Channe
 		return nil, nil
 	}
 
+	// 熔断中的渠道不参与选择，降级渠道按健康分降低权重
+	abilities, healthScores := filterHealthyAbilities(abilities)
+
 	uniquePriorities := make(map[int64]bool)
 	for _, ability := range abilities {
 		uniquePriorities[getAbilityPriority(ability)] = true
@@ -213,26 +221,34 @@ func chooseChannelFromAbilities(abilities []Ability, channelByID map[int]*Channe
 	targetPriority := priorities[retry]
 
 	targetAbilities := make([]Ability, 0, len(abilities))
-	weightSum := uint(0)
 	for _, ability := range abilities {
 		if getAbilityPriority(ability) != targetPriority {
 			continue
 		}
 		targetAbilities = append(targetAbilities, ability)
-		weightSum += ability.Weight + 10
 	}
 	if len(targetAbilities) == 0 {
 		return nil, fmt.Errorf("no channel found, group: %s, model: %s, priority: %d", group, modelName, targetPriority)
 	}
 
-	weight := common.GetRandomInt(int(weightSum))
+	// 按路由策略筛选出最优的一组渠道，组内仍按权重随机
+	targetAbilities = narrowByRoutingStrategy(operation_setting.GetRoutingStrategy(group, modelName), targetAbilities, func(ability Ability) *Channel {
+		return channelByID[ability.ChannelId]
+	}, modelName)
+	weightSum := 0
+	for _, ability := range targetAbilities {
+		weightSum += circuitbreaker.ScaleWeight(int(ability.Weight)+10, healthScores[ability.ChannelId])
+	}
+
+	weight := common.GetRandomInt(weightSum)
 	for _, ability := range targetAbilities {
-		weight -= int(ability.Weight) + 10
+		weight -= circuitbreaker.ScaleWeight(int(ability.Weight)+10, healthScores[ability.ChannelId])
 		if weight <= 0 {
 			channel, ok := channelByID[ability.ChannelId]
 			if !ok {
 				return nil, fmt.Errorf("数据库一致性错误，渠道# %d 不存在，请联系管理员修复", ability.ChannelId)
 			}
+			circuitbreaker.MarkSelected(channel.Id, circuitbreaker.ChannelLevel)
 			return channel, nil
 		}
 	}
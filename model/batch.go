@@ -0,0 +1,241 @@
+package model
+
+import (
+	"database/sql/driver"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+// Batch OpenAI Batch API 批处理记录
+// 批处理提交到上游渠道（OpenAI/Azure Batch、Claude Message Batches）后由任务轮询循环同步状态，
+// 完成后根据输出文件逐行结算额度。
+// 没有支持原生批处理接口的渠道时为本地批处理（IsLocal），由本地执行器逐行调用普通接口并按普通请求计费。
+type Batch struct {
+	Id               int    `json:"id"`
+	BatchId          string `json:"batch_id" gorm:"type:varchar(64);uniqueIndex"` // 对外暴露的 batch_xxxx ID
+	UserId           int    `json:"user_id" gorm:"index"`
+	TokenId          int    `json:"token_id" gorm:"index"` // 创建批处理的令牌，仅该令牌可以访问
+	Group            string `json:"group" gorm:"type:varchar(50)"`
+	ChannelId        int    `json:"channel_id" gorm:"index"`
+	IsLocal          bool   `json:"is_local" gorm:"default:false;index"` // 本地执行
+	KeyIndex         int    `json:"-" gorm:"default:0"`                  // 多 Key 渠道提交时使用的 key 索引
+	UpstreamBatchId  string `json:"-" gorm:"type:varchar(191)"`          // 上游批处理 ID
+	Endpoint         string `json:"endpoint" gorm:"type:varchar(64)"`    // 例如 /v1/chat/completions
+	ModelName        string `json:"model_name" gorm:"type:varchar(191);index"`
+	CompletionWindow string `json:"completion_window" gorm:"type:varchar(16)"`
+	InputFileId      string `json:"input_file_id" gorm:"type:varchar(64)"`
+	OutputFileId     string `json:"output_file_id" gorm:"type:varchar(64)"`
+	ErrorFileId      string `json:"error_file_id" gorm:"type:varchar(64)"`
+	Status           string `json:"status" gorm:"type:varchar(20);index"`
+	RequestTotal     int    `json:"request_total"`
+	RequestCompleted int    `json:"request_completed"`
+	RequestFailed    int    `json:"request_failed"`
+	Quota            int    `json:"quota"`                     // 当前已扣额度：提交时为预扣额度，完成后为实际额度
+	Errors           string `json:"errors" gorm:"type:text"`   // dto.OpenAIBatchErrors JSON
+	Metadata         string `json:"metadata" gorm:"type:text"` // 用户提交的 metadata JSON
+	CreatedAt        int64  `json:"created_at" gorm:"bigint;index"`
+	UpdatedAt        int64  `json:"updated_at" gorm:"bigint"`
+	InProgressAt     int64  `json:"in_progress_at" gorm:"bigint"`
+	FinalizingAt     int64  `json:"finalizing_at" gorm:"bigint"`
+	CompletedAt      int64  `json:"completed_at" gorm:"bigint"`
+	FailedAt         int64  `json:"failed_at" gorm:"bigint"`
+	ExpiredAt        int64  `json:"expired_at" gorm:"bigint"`
+	CancellingAt     int64  `json:"cancelling_at" gorm:"bigint"`
+	CancelledAt      int64  `json:"cancelled_at" gorm:"bigint"`
+	ExpiresAt        int64  `json:"expires_at" gorm:"bigint"`
+	// 禁止返回给用户，内部可能包含计费等信息
+	PrivateData BatchPrivateData `json:"-" gorm:"column:private_data;type:json"`
+}
+
+type BatchPrivateData struct {
+	// 计费上下文：用于完成后的逐行结算与退款
+	BillingSource  string               `json:"billing_source,omitempty"`  // "wallet"、"subscription" 或 "organization"
+	SubscriptionId int                  `json:"subscription_id,omitempty"` // 订阅 ID，用于订阅退款
+	OrganizationId int                  `json:"organization_id,omitempty"` // 组织 ID，用于组织钱包退款与日志
+	BillingContext *BatchBillingContext `json:"billing_context,omitempty"`
+	// 提交时为适配上游（模型映射、Azure 路径）而重新生成并上传的输入文件，完成后删除
+	UpstreamInputFileId string `json:"upstream_input_file_id,omitempty"`
+	// 本地执行状态：已处理的输入行数，以及执行过程中追加写入的输出/错误文件
+	LocalCursor       int    `json:"local_cursor,omitempty"`
+	LocalOutputFileId string `json:"local_output_file_id,omitempty"`
+	LocalErrorFileId  string `json:"local_error_file_id,omitempty"`
+	SpecificChannelId string `json:"specific_channel_id,omitempty"` // 令牌指定的渠道
+}
+
+// BatchBillingContext 记录批处理提交时的计费参数，完成后按输出文件中的 usage 逐行计算额度。
+type BatchBillingContext struct {
+	ModelPrice         float64 `json:"model_price,omitempty"`
+	ModelRatio         float64 `json:"model_ratio,omitempty"`
+	CompletionRatio    float64 `json:"completion_ratio,omitempty"`
+	CacheRatio         float64 `json:"cache_ratio,omitempty"`
+	CacheCreationRatio float64 `json:"cache_creation_ratio,omitempty"`
+	GroupRatio         float64 `json:"group_ratio,omitempty"`
+	BatchRatio         float64 `json:"batch_ratio,omitempty"` // 批处理折扣
+	UsePrice           bool    `json:"use_price,omitempty"`   // 按次计费：每个成功的请求收取一次 ModelPrice
+	UpstreamModelName  string  `json:"upstream_model_name,omitempty"`
+}
+
+func (p *BatchPrivateData) Scan(val interface{}) error {
+	bytesValue, _ := val.([]byte)
+	if len(bytesValue) == 0 {
+		return nil
+	}
+	return common.Unmarshal(bytesValue, p)
+}
+
+func (p BatchPrivateData) Value() (driver.Value, error) {
+	if (p == BatchPrivateData{}) {
+		return nil, nil
+	}
+	return common.Marshal(p)
+}
+
+// GenerateBatchID 生成对外暴露的 batch_xxxx 格式 ID
+func GenerateBatchID() string {
+	key, _ := common.GenerateRandomCharsKey(24)
+	return "batch_" + key
+}
+
+// IsBatchStatusFinished 判断批处理是否已到达终态
+func IsBatchStatusFinished(status string) bool {
+	switch status {
+	case dto.BatchStatusCompleted, dto.BatchStatusFailed, dto.BatchStatusExpired, dto.BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (b *Batch) ToOpenAIBatch() dto.OpenAIBatch {
+	batch := dto.OpenAIBatch{
+		ID:               b.BatchId,
+		Object:           "batch",
+		Endpoint:         b.Endpoint,
+		InputFileID:      b.InputFileId,
+		CompletionWindow: b.CompletionWindow,
+		Status:           b.Status,
+		OutputFileID:     b.OutputFileId,
+		ErrorFileID:      b.ErrorFileId,
+		CreatedAt:        b.CreatedAt,
+		InProgressAt:     b.InProgressAt,
+		ExpiresAt:        b.ExpiresAt,
+		FinalizingAt:     b.FinalizingAt,
+		CompletedAt:      b.CompletedAt,
+		FailedAt:         b.FailedAt,
+		ExpiredAt:        b.ExpiredAt,
+		CancellingAt:     b.CancellingAt,
+		CancelledAt:      b.CancelledAt,
+		RequestCounts: dto.OpenAIBatchRequestCounts{
+			Total:     b.RequestTotal,
+			Completed: b.RequestCompleted,
+			Failed:    b.RequestFailed,
+		},
+	}
+	if b.Errors != "" {
+		var errs dto.OpenAIBatchErrors
+		if err := common.UnmarshalJsonStr(b.Errors, &errs); err == nil && len(errs.Data) > 0 {
+			batch.Errors = &errs
+		}
+	}
+	if b.Metadata != "" {
+		_ = common.UnmarshalJsonStr(b.Metadata, &batch.Metadata)
+	}
+	return batch
+}
+
+// SetErrors 记录批处理错误信息
+func (b *Batch) SetErrors(errs []dto.OpenAIBatchError) {
+	if len(errs) == 0 {
+		b.Errors = ""
+		return
+	}
+	data, _ := common.Marshal(dto.OpenAIBatchErrors{Object: "list", Data: errs})
+	b.Errors = string(data)
+}
+
+func (b *Batch) Insert() error {
+	now := common.GetTimestamp()
+	if b.CreatedAt == 0 {
+		b.CreatedAt = now
+	}
+	b.UpdatedAt = now
+	return DB.Create(b).Error
+}
+
+// UpdateWithStatus 以 fromStatus 为条件更新批处理（CAS），与 Task.UpdateWithStatus 语义一致。
+// 返回 (true, nil) 表示更新成功，(false, nil) 表示状态已被其他流程修改。
+func (b *Batch) UpdateWithStatus(fromStatus string) (bool, error) {
+	b.UpdatedAt = common.GetTimestamp()
+	result := DB.Model(b).Where("status = ?", fromStatus).Select("*").Updates(b)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateColumnsWithStatus 以 fromStatus 为条件仅更新指定字段（CAS），避免覆盖本地执行器同时写入的进度
+func (b *Batch) UpdateColumnsWithStatus(fromStatus string, columns ...string) (bool, error) {
+	b.UpdatedAt = common.GetTimestamp()
+	result := DB.Model(b).Where("status = ?", fromStatus).Select(append(columns, "updated_at")).Updates(b)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateLocalProgress 保存本地执行进度（已处理行数、计数与结果文件）
+func (b *Batch) UpdateLocalProgress() error {
+	b.UpdatedAt = common.GetTimestamp()
+	return DB.Model(b).Select("request_completed", "request_failed", "private_data", "updated_at").Updates(b).Error
+}
+
+// GetTokenBatch 获取令牌拥有的批处理，不存在时返回 (nil, false, nil)
+func GetTokenBatch(userId int, tokenId int, batchId string) (*Batch, bool, error) {
+	if batchId == "" {
+		return nil, false, nil
+	}
+	var batch Batch
+	err := DB.Where("user_id = ? AND token_id = ? AND batch_id = ?", userId, tokenId, batchId).First(&batch).Error
+	exist, err := RecordExist(err)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	return &batch, true, nil
+}
+
+// GetTokenBatches 按游标分页获取令牌的批处理列表（按创建时间倒序），返回结果最多比 limit 多一条用于判断 has_more
+func GetTokenBatches(userId int, tokenId int, after string, limit int) ([]*Batch, error) {
+	var batches []*Batch
+	query := DB.Where("user_id = ? AND token_id = ?", userId, tokenId)
+	if after != "" {
+		var cursor Batch
+		if err := DB.Select("id").Where("user_id = ? AND token_id = ? AND batch_id = ?", userId, tokenId, after).First(&cursor).Error; err == nil {
+			query = query.Where("id < ?", cursor.Id)
+		}
+	}
+	err := query.Order("id desc").Limit(limit + 1).Find(&batches).Error
+	return batches, err
+}
+
+// GetUnfinishedBatches 获取尚未到达终态的上游批处理
+func GetUnfinishedBatches(limit int) ([]*Batch, error) {
+	var batches []*Batch
+	err := DB.Where("is_local = ? AND status NOT IN ?", false, []string{dto.BatchStatusCompleted, dto.BatchStatusFailed, dto.BatchStatusExpired, dto.BatchStatusCancelled}).
+		Order("updated_at").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+// GetUnfinishedLocalBatches 获取尚未到达终态的本地批处理，按创建顺序执行
+func GetUnfinishedLocalBatches(limit int) ([]*Batch, error) {
+	var batches []*Batch
+	err := DB.Where("is_local = ? AND status NOT IN ?", true, []string{dto.BatchStatusCompleted, dto.BatchStatusFailed, dto.BatchStatusExpired, dto.BatchStatusCancelled}).
+		Order("id").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+// GetBatchStatus 查询批处理的最新状态
+func GetBatchStatus(id int) (string, error) {
+	var batch Batch
+	err := DB.Select("status").Where("id = ?", id).First(&batch).Error
+	return batch.Status, err
+}
@@ -0,0 +1,301 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	BudgetPeriodDaily   = "daily"
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodMonthly = "monthly"
+)
+
+const (
+	BudgetStatusEnabled  = 1
+	BudgetStatusDisabled = 2
+)
+
+// 预算的创建者，用户只能管理自己创建的预算，管理员设置的预算用户最多只能调低上限
+const (
+	BudgetCreatorAdmin = "admin"
+	BudgetCreatorSelf  = "self"
+)
+
+// Budget 用户或令牌的周期预算，TokenId 为 0 时表示用户级预算（对该用户的所有令牌生效）
+type Budget struct {
+	Id         int    `json:"id"`
+	UserId     int    `json:"user_id" gorm:"uniqueIndex:idx_budget_scope,priority:1"`
+	TokenId    int    `json:"token_id" gorm:"uniqueIndex:idx_budget_scope,priority:2"`
+	PeriodType string `json:"period_type" gorm:"type:varchar(16);uniqueIndex:idx_budget_scope,priority:3"`
+	// QuotaLimit 窗口内的硬上限，超出后拒绝请求
+	QuotaLimit int `json:"quota_limit" gorm:"type:int;default:0"`
+	// SoftPercent 达到 QuotaLimit 的该百分比时发送提醒，0 表示使用全局默认值
+	SoftPercent int `json:"soft_percent" gorm:"type:int;default:0"`
+	Status      int `json:"status" gorm:"type:int;default:1"`
+	// CreatedBy 预算创建者，admin 或 self
+	CreatedBy   string `json:"created_by" gorm:"type:varchar(16);default:'admin'"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64  `json:"updated_time" gorm:"bigint"`
+	// 以下字段仅用于 API 响应，表示当前窗口的使用情况
+	WindowStart int64 `json:"window_start" gorm:"-:all"`
+	WindowEnd   int64 `json:"window_end" gorm:"-:all"`
+	UsedQuota   int   `json:"used_quota" gorm:"-:all"`
+}
+
+// BudgetUsage 预算在某个窗口内的已用额度
+type BudgetUsage struct {
+	Id           int   `json:"id"`
+	BudgetId     int   `json:"budget_id" gorm:"uniqueIndex:idx_budget_window,priority:1"`
+	WindowStart  int64 `json:"window_start" gorm:"bigint;uniqueIndex:idx_budget_window,priority:2"`
+	UsedQuota    int   `json:"used_quota" gorm:"type:int;default:0"`
+	SoftNotified bool  `json:"soft_notified" gorm:"default:false"`
+}
+
+// BudgetExceededError 预留额度超出某个预算的硬上限
+type BudgetExceededError struct {
+	Budget *Budget
+	Used   int
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget %d (%s) exceeded: used %d, limit %d", e.Budget.Id, e.Budget.PeriodType, e.Used, e.Budget.QuotaLimit)
+}
+
+func IsValidBudgetPeriod(period string) bool {
+	switch period {
+	case BudgetPeriodDaily, BudgetPeriodWeekly, BudgetPeriodMonthly:
+		return true
+	default:
+		return false
+	}
+}
+
+// BudgetWindow 返回 t 所在预算窗口的起止时间戳，窗口按预算时区的自然日、自然周（周一开始）或自然月对齐
+func BudgetWindow(period string, t time.Time) (start int64, end int64) {
+	loc := operation_setting.GetBudgetLocation()
+	t = t.In(loc)
+	year, month, day := t.Date()
+	var from, to time.Time
+	switch period {
+	case BudgetPeriodWeekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		from = time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+		to = from.AddDate(0, 0, 7)
+	case BudgetPeriodMonthly:
+		from = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		to = from.AddDate(0, 1, 0)
+	default:
+		from = time.Date(year, month, day, 0, 0, 0, 0, loc)
+		to = from.AddDate(0, 0, 1)
+	}
+	return from.Unix(), to.Unix()
+}
+
+// SoftQuota 返回触发提醒的额度阈值，0 表示不提醒
+func (b *Budget) SoftQuota() int {
+	percent := b.SoftPercent
+	if percent <= 0 {
+		percent = operation_setting.GetBudgetSetting().DefaultSoftPercent
+	}
+	if percent <= 0 || b.QuotaLimit <= 0 {
+		return 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	return b.QuotaLimit * percent / 100
+}
+
+// GetActiveBudgets 获取对本次请求生效的预算：用户级预算以及当前令牌的预算
+func GetActiveBudgets(userId int, tokenId int) ([]*Budget, error) {
+	var budgets []*Budget
+	err := DB.Where("user_id = ? AND token_id IN ? AND status = ?", userId, []int{0, tokenId}, BudgetStatusEnabled).
+		Order("token_id desc, id asc").
+		Find(&budgets).Error
+	return budgets, err
+}
+
+// GetBudgets 获取用户的预算列表，tokenId 小于 0 时返回该用户的全部预算，并填充当前窗口的使用情况
+func GetBudgets(userId int, tokenId int) ([]*Budget, error) {
+	var budgets []*Budget
+	query := DB.Where("user_id = ?", userId)
+	if tokenId >= 0 {
+		query = query.Where("token_id = ?", tokenId)
+	}
+	if err := query.Order("token_id asc, id asc").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	if err := fillBudgetUsage(budgets, time.Now()); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetBudgetByScope 按 (user_id, token_id, period_type) 获取预算，不存在时返回 nil
+func GetBudgetByScope(userId int, tokenId int, periodType string) (*Budget, error) {
+	var budget Budget
+	result := DB.Where("user_id = ? AND token_id = ? AND period_type = ?", userId, tokenId, periodType).Limit(1).Find(&budget)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &budget, nil
+}
+
+func GetBudgetById(id int) (*Budget, error) {
+	if id == 0 {
+		return nil, errors.New("id 为空！")
+	}
+	var budget Budget
+	if err := DB.First(&budget, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func fillBudgetUsage(budgets []*Budget, now time.Time) error {
+	for _, budget := range budgets {
+		budget.WindowStart, budget.WindowEnd = BudgetWindow(budget.PeriodType, now)
+		var usage BudgetUsage
+		err := DB.Where("budget_id = ? AND window_start = ?", budget.Id, budget.WindowStart).First(&usage).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		budget.UsedQuota = usage.UsedQuota
+	}
+	return nil
+}
+
+// UpsertBudget 按 (user_id, token_id, period_type) 创建或更新预算
+func UpsertBudget(budget *Budget) error {
+	now := common.GetTimestamp()
+	budget.UpdatedTime = now
+	if budget.Status == 0 {
+		budget.Status = BudgetStatusEnabled
+	}
+	if budget.CreatedBy == "" {
+		budget.CreatedBy = BudgetCreatorAdmin
+	}
+	var existing Budget
+	result := DB.Where("user_id = ? AND token_id = ? AND period_type = ?", budget.UserId, budget.TokenId, budget.PeriodType).
+		Limit(1).Find(&existing)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		budget.Id = existing.Id
+		budget.CreatedTime = existing.CreatedTime
+		return DB.Model(&existing).Select("quota_limit", "soft_percent", "status", "created_by", "updated_time").Updates(budget).Error
+	}
+	budget.CreatedTime = now
+	return DB.Create(budget).Error
+}
+
+func DeleteBudgetById(id int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_id = ?", id).Delete(&BudgetUsage{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Budget{}, id).Error
+	})
+}
+
+func ensureBudgetUsageTx(tx *gorm.DB, budgetId int, windowStart int64) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&BudgetUsage{
+		BudgetId:    budgetId,
+		WindowStart: windowStart,
+	}).Error
+}
+
+// ReserveBudgets 在 at 所在窗口内为所有预算预留 quota，任一预算超出硬上限时整体回滚并返回 *BudgetExceededError。
+// quota 为 0 时仍会检查窗口是否已用尽，避免结算超额后后续的免费或零预扣请求绕过预算。
+func ReserveBudgets(budgets []*Budget, at time.Time, quota int) error {
+	if len(budgets) == 0 || quota < 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, budget := range budgets {
+			windowStart, _ := BudgetWindow(budget.PeriodType, at)
+			if err := ensureBudgetUsageTx(tx, budget.Id, windowStart); err != nil {
+				return err
+			}
+			// MySQL 未开启 clientFoundRows 时，值未变化的 UPDATE（used_quota + 0）RowsAffected 为 0，
+			// 因此 quota 为 0 时直接查询已用额度，不能依赖条件更新的影响行数
+			if quota == 0 {
+				var usage BudgetUsage
+				if err := tx.Where("budget_id = ? AND window_start = ?", budget.Id, windowStart).First(&usage).Error; err != nil {
+					return err
+				}
+				if usage.UsedQuota >= budget.QuotaLimit {
+					return &BudgetExceededError{Budget: budget, Used: usage.UsedQuota}
+				}
+				continue
+			}
+			result := tx.Model(&BudgetUsage{}).
+				Where("budget_id = ? AND window_start = ? AND used_quota + ? <= ? AND used_quota < ?",
+					budget.Id, windowStart, quota, budget.QuotaLimit, budget.QuotaLimit).
+				Update("used_quota", gorm.Expr("used_quota + ?", quota))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				var usage BudgetUsage
+				tx.Where("budget_id = ? AND window_start = ?", budget.Id, windowStart).First(&usage)
+				return &BudgetExceededError{Budget: budget, Used: usage.UsedQuota}
+			}
+		}
+		return nil
+	})
+}
+
+// AdjustBudgets 按差额调整 at 所在窗口的预算已用额度（正数补扣，负数退还），不做上限检查
+func AdjustBudgets(budgets []*Budget, at time.Time, delta int) error {
+	if len(budgets) == 0 || delta == 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, budget := range budgets {
+			windowStart, _ := BudgetWindow(budget.PeriodType, at)
+			if err := ensureBudgetUsageTx(tx, budget.Id, windowStart); err != nil {
+				return err
+			}
+			if err := tx.Model(&BudgetUsage{}).
+				Where("budget_id = ? AND window_start = ?", budget.Id, windowStart).
+				Update("used_quota", gorm.Expr("used_quota + ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkBudgetSoftNotified 若 at 所在窗口的已用额度达到软阈值且尚未提醒，则标记为已提醒并返回 true 与当前已用额度。
+// 通过条件更新保证同一窗口只提醒一次。
+func MarkBudgetSoftNotified(budget *Budget, at time.Time) (bool, int, error) {
+	softQuota := budget.SoftQuota()
+	if softQuota <= 0 {
+		return false, 0, nil
+	}
+	windowStart, _ := BudgetWindow(budget.PeriodType, at)
+	result := DB.Model(&BudgetUsage{}).
+		Where("budget_id = ? AND window_start = ? AND soft_notified = ? AND used_quota >= ?", budget.Id, windowStart, false, softQuota).
+		Update("soft_notified", true)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, 0, result.Error
+	}
+	var usage BudgetUsage
+	if err := DB.Where("budget_id = ? AND window_start = ?", budget.Id, windowStart).First(&usage).Error; err != nil {
+		return true, 0, err
+	}
+	return true, usage.UsedQuota, nil
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func withBudgetTimezone(t *testing.T, tz string) {
+	t.Helper()
+	setting := operation_setting.GetBudgetSetting()
+	original := setting.Timezone
+	setting.Timezone = tz
+	t.Cleanup(func() { setting.Timezone = original })
+}
+
+func TestBudgetWindow_CalendarAligned(t *testing.T) {
+	withBudgetTimezone(t, "Asia/Shanghai")
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	// 2026-03-04 01:30 (Wednesday) in Asia/Shanghai, still 2026-03-03 in UTC
+	at := time.Date(2026, 3, 4, 1, 30, 0, 0, loc)
+
+	start, end := BudgetWindow(BudgetPeriodDaily, at)
+	assert.Equal(t, time.Date(2026, 3, 4, 0, 0, 0, 0, loc).Unix(), start)
+	assert.Equal(t, time.Date(2026, 3, 5, 0, 0, 0, 0, loc).Unix(), end)
+
+	start, end = BudgetWindow(BudgetPeriodWeekly, at)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, loc).Unix(), start)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, loc).Unix(), end)
+
+	start, end = BudgetWindow(BudgetPeriodMonthly, at)
+	assert.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, loc).Unix(), start)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, loc).Unix(), end)
+}
+
+func TestBudgetWindow_WeekStartsOnMonday(t *testing.T) {
+	withBudgetTimezone(t, "")
+	sunday := time.Date(2026, 3, 8, 23, 0, 0, 0, time.UTC)
+	start, _ := BudgetWindow(BudgetPeriodWeekly, sunday)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC).Unix(), start)
+}
+
+func TestReserveBudgets_HardLimit(t *testing.T) {
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM budgets")
+		DB.Exec("DELETE FROM budget_usages")
+	})
+	withBudgetTimezone(t, "")
+
+	userBudget := &Budget{UserId: 1, PeriodType: BudgetPeriodMonthly, QuotaLimit: 1000}
+	tokenBudget := &Budget{UserId: 1, TokenId: 7, PeriodType: BudgetPeriodDaily, QuotaLimit: 300}
+	require.NoError(t, UpsertBudget(userBudget))
+	require.NoError(t, UpsertBudget(tokenBudget))
+
+	budgets, err := GetActiveBudgets(1, 7)
+	require.NoError(t, err)
+	require.Len(t, budgets, 2)
+
+	now := time.Now()
+	require.NoError(t, ReserveBudgets(budgets, now, 200))
+
+	// 令牌日预算剩余 100，预留 150 应整体失败且不影响用户预算
+	err = ReserveBudgets(budgets, now, 150)
+	var exceeded *BudgetExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, tokenBudget.Id, exceeded.Budget.Id)
+	assert.Equal(t, 200, exceeded.Used)
+
+	list, err := GetBudgets(1, -1)
+	require.NoError(t, err)
+	for _, budget := range list {
+		assert.Equal(t, 200, budget.UsedQuota)
+	}
+
+	// 结算超额后，即使预留 0 也应被拒绝
+	require.NoError(t, AdjustBudgets(budgets, now, 150))
+	assert.Error(t, ReserveBudgets(budgets, now, 0))
+
+	// 其他令牌只受用户级预算约束
+	others, err := GetActiveBudgets(1, 8)
+	require.NoError(t, err)
+	require.Len(t, others, 1)
+	require.NoError(t, ReserveBudgets(others, now, 500))
+	assert.Error(t, ReserveBudgets(others, now, 200))
+}
+
+func TestMarkBudgetSoftNotified_OncePerWindow(t *testing.T) {
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM budgets")
+		DB.Exec("DELETE FROM budget_usages")
+	})
+	withBudgetTimezone(t, "")
+
+	budget := &Budget{UserId: 2, PeriodType: BudgetPeriodDaily, QuotaLimit: 1000, SoftPercent: 50}
+	require.NoError(t, UpsertBudget(budget))
+	now := time.Now()
+
+	require.NoError(t, ReserveBudgets([]*Budget{budget}, now, 400))
+	notified, _, err := MarkBudgetSoftNotified(budget, now)
+	require.NoError(t, err)
+	assert.False(t, notified)
+
+	require.NoError(t, AdjustBudgets([]*Budget{budget}, now, 200))
+	notified, used, err := MarkBudgetSoftNotified(budget, now)
+	require.NoError(t, err)
+	assert.True(t, notified)
+	assert.Equal(t, 600, used)
+
+	notified, _, err = MarkBudgetSoftNotified(budget, now)
+	require.NoError(t, err)
+	assert.False(t, notified)
+}
+
+// MySQL 未开启 clientFoundRows 时值未变化的 UPDATE 影响行数为 0，预留 0 不能依赖 UPDATE 的影响行数
+func TestReserveBudgets_ZeroQuotaDoesNotUpdate(t *testing.T) {
+	t.Cleanup(func() {
+		DB.Exec("DELETE FROM budgets")
+		DB.Exec("DELETE FROM budget_usages")
+	})
+	withBudgetTimezone(t, "")
+
+	budget := &Budget{UserId: 1, PeriodType: BudgetPeriodDaily, QuotaLimit: 1000}
+	require.NoError(t, UpsertBudget(budget))
+	budgets, err := GetActiveBudgets(1, 0)
+	require.NoError(t, err)
+
+	const callbackName = "test:forbid_budget_usage_update"
+	require.NoError(t, DB.Callback().Update().Before("gorm:update").Register(callbackName, func(db *gorm.DB) {
+		if db.Statement.Table == "budget_usages" {
+			_ = db.AddError(errors.New("unexpected budget usage update"))
+		}
+	}))
+	t.Cleanup(func() { _ = DB.Callback().Update().Remove(callbackName) })
+
+	now := time.Now()
+	require.NoError(t, ReserveBudgets(budgets, now, 0))
+	require.NoError(t, DB.Exec("UPDATE budget_usages SET used_quota = ? WHERE budget_id = ?", 1000, budget.Id).Error)
+	var exceeded *BudgetExceededError
+	require.True(t, errors.As(ReserveBudgets(budgets, now, 0), &exceeded))
+	assert.Equal(t, 1000, exceeded.Used)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/dto"
 	"github.com/QuantumNous/new-api/logger"
+	prommetrics "github.com/QuantumNous/new-api/pkg/prom_metrics"
 	"github.com/QuantumNous/new-api/types"
 
 	"github.com/samber/lo"
@@ -67,6 +69,14 @@ type ChannelInfo struct {
 	MultiKeyDisabledTime   map[int]int64         `json:"multi_key_disabled_time,omitempty"`   // key禁用时间列表，key index -> time
 	MultiKeyPollingIndex   int                   `json:"multi_key_polling_index"`             // 多Key模式下轮询的key索引
 	MultiKeyMode           constant.MultiKeyMode `json:"multi_key_mode"`
+	MultiKeyWeights        map[int]int           `json:"multi_key_weights,omitempty"` // weighted 模式下的 Key 权重，key index -> weight，缺省为 1
+}
+
+// MultiKeyUsage 多 Key 渠道中单个 Key 的累计用量，由内存计数定期累加到 channel_key_usages 表
+type MultiKeyUsage struct {
+	RequestCount int64 `json:"request_count"`
+	TokenCount   int64 `json:"token_count"`
+	LastUsedTime int64 `json:"last_used_time,omitempty"`
 }
 
 type ChannelSortOptions struct {
@@ -209,6 +219,8 @@ func (channel *Channel) GetNextEnabledKey() (string, int, *types.NewAPIError) {
 		return "", 0, types.NewError(errors.New("no keys available"), types.ErrorCodeChannelNoAvailableKey)
 	}
 
+	settings := channel.GetOtherSettings()
+
 	lock := GetChannelPollingLock(channel.Id)
 	lock.Lock()
 	defer lock.Unlock()
@@ -225,10 +237,11 @@ func (channel *Channel) GetNextEnabledKey() (string, int, *types.NewAPIError) {
 		return common.ChannelStatusEnabled
 	}
 
-	// Collect indexes of enabled keys
+	// Collect indexes of enabled keys, auto-disabled keys past their cooldown count as enabled
+	now := common.GetTimestamp()
 	enabledIdx := make([]int, 0, len(keys))
 	for i := range keys {
-		if getStatus(i) == common.ChannelStatusEnabled {
+		if getStatus(i) == common.ChannelStatusEnabled || channel.ChannelInfo.isKeyCooledDown(i, settings.MultiKeyCooldownSeconds, now) {
 			enabledIdx = append(enabledIdx, i)
 		}
 	}
@@ -238,11 +251,31 @@ func (channel *Channel) GetNextEnabledKey() (string, int, *types.NewAPIError) {
 	if len(enabledIdx) == 0 {
 		return "", 0, types.NewError(errors.New("no enabled keys"), types.ErrorCodeChannelNoAvailableKey)
 	}
+	// Skip keys whose circuit breaker is open; all keys are kept if every key is open
+	enabledIdx = filterHealthyKeyIndexes(channel.Id, enabledIdx)
+	// Skip keys that reached their per-key RPM/TPM limit in the last minute
+	enabledIdx = filterKeysWithinLimits(channel.Id, enabledIdx, settings.MultiKeyRPMLimit, settings.MultiKeyTPMLimit)
+	if len(enabledIdx) == 0 {
+		return "", 0, types.NewErrorWithStatusCode(fmt.Errorf("all keys of channel #%d reached the per-key rate limit", channel.Id), types.ErrorCodeChannelKeyRateLimited, http.StatusTooManyRequests)
+	}
+	selectable := make(map[int]bool, len(enabledIdx))
+	for _, idx := range enabledIdx {
+		selectable[idx] = true
+	}
 
 	switch channel.ChannelInfo.MultiKeyMode {
 	case constant.MultiKeyModeRandom:
 		// Randomly pick one enabled key
 		selectedIdx := enabledIdx[rand.Intn(len(enabledIdx))]
+		markKeySelected(channel.Id, selectedIdx)
+		return keys[selectedIdx], selectedIdx, nil
+	case constant.MultiKeyModeLeastUsed:
+		selectedIdx := selectLeastUsedKey(channel.Id, enabledIdx)
+		markKeySelected(channel.Id, selectedIdx)
+		return keys[selectedIdx], selectedIdx, nil
+	case constant.MultiKeyModeWeighted:
+		selectedIdx := selectWeightedKey(channel.Id, &channel.ChannelInfo, enabledIdx)
+		markKeySelected(channel.Id, selectedIdx)
 		return keys[selectedIdx], selectedIdx, nil
 	case constant.MultiKeyModePolling:
 		// Use channel-specific lock to ensure thread-safe polling
@@ -268,16 +301,19 @@ func (channel *Channel) GetNextEnabledKey() (string, int, *types.NewAPIError) {
 		}
 		for i := 0; i < len(keys); i++ {
 			idx := (start + i) % len(keys)
-			if getStatus(idx) == common.ChannelStatusEnabled {
+			if selectable[idx] {
 				// update polling index for next call (point to the next position)
 				channel.ChannelInfo.MultiKeyPollingIndex = (idx + 1) % len(keys)
+				markKeySelected(channel.Id, idx)
 				return keys[idx], idx, nil
 			}
 		}
 		// Fallback – should not happen, but return first enabled key
+		markKeySelected(channel.Id, enabledIdx[0])
 		return keys[enabledIdx[0]], enabledIdx[0], nil
 	default:
 		// Unknown mode, default to first enabled key (or original key string)
+		markKeySelected(channel.Id, enabledIdx[0])
 		return keys[enabledIdx[0]], enabledIdx[0], nil
 	}
 }
@@ -470,6 +506,10 @@ func BatchDeleteChannels(ids []int) error {
 			tx.Rollback()
 			return err
 		}
+		if err := tx.Where("channel_id in (?)", chunk).Delete(&ChannelKeyUsage{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 	return tx.Commit().Error
 }
@@ -561,12 +601,23 @@ func (channel *Channel) Update() error {
 				}
 			}
 		}
+		for idx := range channel.ChannelInfo.MultiKeyWeights {
+			if idx >= channel.ChannelInfo.MultiKeySize {
+				delete(channel.ChannelInfo.MultiKeyWeights, idx)
+			}
+		}
 	}
 	var err error
 	err = DB.Model(channel).Updates(channel).Error
 	if err != nil {
 		return err
 	}
+	if channel.ChannelInfo.IsMultiKey {
+		// 清理已不存在的 Key 的用量
+		if err := DB.Where("channel_id = ? AND key_index >= ?", channel.Id, channel.ChannelInfo.MultiKeySize).Delete(&ChannelKeyUsage{}).Error; err != nil {
+			common.SysLog(fmt.Sprintf("failed to trim multi-key usage: channel_id=%d, error=%v", channel.Id, err))
+		}
+	}
 	DB.Model(channel).First(channel, "id = ?", channel.Id)
 	err = channel.UpdateAbilities(nil)
 	return err
@@ -598,6 +649,9 @@ func (channel *Channel) Delete() error {
 	if err != nil {
 		return err
 	}
+	if err = DB.Where("channel_id = ?", channel.Id).Delete(&ChannelKeyUsage{}).Error; err != nil {
+		return err
+	}
 	err = channel.DeleteAbilities()
 	return err
 }
@@ -669,7 +723,7 @@ func handlerMultiKeyUpdate(channel *Channel, usingKey string, status int, reason
 		if len(channel.ChannelInfo.MultiKeyStatusList) >= channel.ChannelInfo.MultiKeySize {
 			channel.Status = common.ChannelStatusAutoDisabled
 			info := channel.GetOtherInfo()
-			info["status_reason"] = "All keys are disabled"
+			info["status_reason"] = multiKeyAllDisabledReason
 			info["status_time"] = common.GetTimestamp()
 			channel.SetOtherInfo(info)
 		}
@@ -1058,6 +1112,33 @@ func CountChannelsByType(channelType int) (int64, error) {
 	return count, err
 }
 
+// GetChannelMetricStates 返回各渠道的状态与多 Key 渠道的禁用 Key 数量，供 /metrics 抓取
+func GetChannelMetricStates() ([]prommetrics.ChannelState, error) {
+	var channels []*Channel
+	err := DB.Select("id", "name", "status", "channel_info").Find(&channels).Error
+	if err != nil {
+		return nil, err
+	}
+	states := make([]prommetrics.ChannelState, 0, len(channels))
+	for _, channel := range channels {
+		state := prommetrics.ChannelState{
+			Id:     channel.Id,
+			Name:   channel.Name,
+			Status: channel.Status,
+		}
+		if channel.ChannelInfo.IsMultiKey {
+			state.MultiKeySize = channel.ChannelInfo.MultiKeySize
+			for idx, status := range channel.ChannelInfo.MultiKeyStatusList {
+				if idx < state.MultiKeySize && status != common.ChannelStatusEnabled {
+					state.DisabledKeys++
+				}
+			}
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
 // Return map[type]count for all channels
 func CountChannelsGroupByType() (map[int64]int64, error) {
 	type result struct {
@@ -12,6 +12,8 @@ import (
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
 	"github.com/QuantumNous/new-api/setting/ratio_setting"
 )
 
@@ -135,6 +137,9 @@ func GetRandomSatisfiedChannelForEndpoint(group string, modelName string, retry
 		return nil, fmt.Errorf("数据库一致性错误，渠道# %d 不存在，请联系管理员修复", channels[0])
 	}
 
+	// 熔断中的渠道不参与选择，降级渠道按健康分降低权重
+	channels, healthScores := filterHealthyChannelIds(channels)
+
 	uniquePriorities := make(map[int]bool)
 	for _, channelId := range channels {
 		if channel, ok := channelsIDM[channelId]; ok {
@@ -155,12 +160,10 @@ func GetRandomSatisfiedChannelForEndpoint(group string, modelName string, retry
 	targetPriority := int64(sortedUniquePriorities[retry])
 
 	// get the priority for the given retry number
-	var sumWeight = 0
 	var targetChannels []*Channel
 	for _, channelId := range channels {
 		if channel, ok := channelsIDM[channelId]; ok {
 			if channel.GetPriority() == targetPriority {
-				sumWeight += channel.GetWeight()
 				targetChannels = append(targetChannels, channel)
 			}
 		} else {
@@ -172,6 +175,15 @@ func GetRandomSatisfiedChannelForEndpoint(group string, modelName string, retry
 		return nil, errors.New(fmt.Sprintf("no channel found, group: %s, model: %s, priority: %d", group, modelName, targetPriority))
 	}
 
+	// 按路由策略筛选出最优的一组渠道，组内仍按权重随机
+	targetChannels = narrowByRoutingStrategy(operation_setting.GetRoutingStrategy(group, modelName), targetChannels, func(channel *Channel) *Channel {
+		return channel
+	}, modelName)
+	sumWeight := 0
+	for _, channel := range targetChannels {
+		sumWeight += channel.GetWeight()
+	}
+
 	// smoothing factor and adjustment
 	smoothingFactor := 1
 	smoothingAdjustment := 0
@@ -186,16 +198,22 @@ func GetRandomSatisfiedChannelForEndpoint(group string, modelName string, retry
 		smoothingFactor = 100
 	}
 
-	// Calculate the total weight of all channels up to endIdx
-	totalWeight := sumWeight * smoothingFactor
+	// Calculate the effective weight of each channel, scaled by its health score
+	weights := make([]int, len(targetChannels))
+	totalWeight := 0
+	for i, channel := range targetChannels {
+		weights[i] = circuitbreaker.ScaleWeight(channel.GetWeight()*smoothingFactor+smoothingAdjustment, healthScores[channel.Id])
+		totalWeight += weights[i]
+	}
 
 	// Generate a random value in the range [0, totalWeight)
 	randomWeight := rand.Intn(totalWeight)
 
 	// Find a channel based on its weight
-	for _, channel := range targetChannels {
-		randomWeight -= channel.GetWeight()*smoothingFactor + smoothingAdjustment
+	for i, channel := range targetChannels {
+		randomWeight -= weights[i]
 		if randomWeight < 0 {
+			circuitbreaker.MarkSelected(channel.Id, circuitbreaker.ChannelLevel)
 			return channel, nil
 		}
 	}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+)
+
+// filterHealthyChannelIds 过滤掉熔断中的渠道并返回各渠道的健康分。
+// 候选渠道全部熔断时不做过滤，避免熔断本身导致无渠道可用。
+func filterHealthyChannelIds(channelIds []int) ([]int, map[int]float64) {
+	scores := make(map[int]float64, len(channelIds))
+	healthy := make([]int, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		score := circuitbreaker.Score(channelId)
+		scores[channelId] = score
+		if score > 0 {
+			healthy = append(healthy, channelId)
+		}
+	}
+	if len(healthy) == 0 {
+		for _, channelId := range channelIds {
+			scores[channelId] = 1
+		}
+		return channelIds, scores
+	}
+	return healthy, scores
+}
+
+// filterHealthyAbilities 与 filterHealthyChannelIds 相同，用于未启用内存缓存时基于 Ability 的选择
+func filterHealthyAbilities(abilities []Ability) ([]Ability, map[int]float64) {
+	scores := make(map[int]float64, len(abilities))
+	healthy := make([]Ability, 0, len(abilities))
+	for _, ability := range abilities {
+		score, ok := scores[ability.ChannelId]
+		if !ok {
+			score = circuitbreaker.Score(ability.ChannelId)
+			scores[ability.ChannelId] = score
+		}
+		if score > 0 {
+			healthy = append(healthy, ability)
+		}
+	}
+	if len(healthy) == 0 {
+		for channelId := range scores {
+			scores[channelId] = 1
+		}
+		return abilities, scores
+	}
+	return healthy, scores
+}
+
+// filterHealthyKeyIndexes 过滤掉多 Key 渠道中熔断中的 Key，全部熔断时不做过滤
+func filterHealthyKeyIndexes(channelId int, keyIndexes []int) []int {
+	healthy := make([]int, 0, len(keyIndexes))
+	for _, idx := range keyIndexes {
+		if circuitbreaker.KeyScore(channelId, idx) > 0 {
+			healthy = append(healthy, idx)
+		}
+	}
+	if len(healthy) == 0 {
+		return keyIndexes
+	}
+	return healthy
+}
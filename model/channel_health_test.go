@@ -0,0 +1,80 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/require"
+)
+
+func tripChannelBreaker(t *testing.T, channelId int) {
+	t.Helper()
+	setting := operation_setting.GetCircuitBreakerSetting()
+	for i := 0; i < setting.MinRequests; i++ {
+		circuitbreaker.Record(channelId, circuitbreaker.ChannelLevel, circuitbreaker.OutcomeFailure, 0)
+	}
+	require.Equal(t, 0.0, circuitbreaker.Score(channelId))
+	t.Cleanup(func() { circuitbreaker.Reset(channelId) })
+}
+
+func setupHealthChannelCache(t *testing.T, channels map[int]*Channel) {
+	t.Helper()
+	oldMemoryCacheEnabled := common.MemoryCacheEnabled
+	oldGroup2Model2Channels := group2model2channels
+	oldChannelsIDM := channelsIDM
+	t.Cleanup(func() {
+		common.MemoryCacheEnabled = oldMemoryCacheEnabled
+		channelSyncLock.Lock()
+		group2model2channels = oldGroup2Model2Channels
+		channelsIDM = oldChannelsIDM
+		channelSyncLock.Unlock()
+	})
+
+	ids := make([]int, 0, len(channels))
+	for id := range channels {
+		ids = append(ids, id)
+	}
+	common.MemoryCacheEnabled = true
+	channelSyncLock.Lock()
+	group2model2channels = map[string]map[string][]int{
+		"default": {"gpt-4o": ids},
+	}
+	channelsIDM = channels
+	channelSyncLock.Unlock()
+}
+
+func TestGetRandomSatisfiedChannelSkipsOpenCircuit(t *testing.T) {
+	setupHealthChannelCache(t, map[int]*Channel{
+		60: testEndpointChannel(60, 1, 10, 100),
+		61: testEndpointChannel(61, 1, 10, 100),
+		62: testEndpointChannel(62, 1, 0, 100),
+	})
+	tripChannelBreaker(t, 60)
+
+	for i := 0; i < 50; i++ {
+		channel, err := GetRandomSatisfiedChannel("default", "gpt-4o", 0)
+		require.NoError(t, err)
+		require.Equal(t, 61, channel.Id)
+	}
+
+	// 同一优先级全部熔断时，流量转向下一优先级
+	tripChannelBreaker(t, 61)
+	channel, err := GetRandomSatisfiedChannel("default", "gpt-4o", 0)
+	require.NoError(t, err)
+	require.Equal(t, 62, channel.Id)
+}
+
+func TestGetRandomSatisfiedChannelFailsOpenWhenAllCircuitsOpen(t *testing.T) {
+	setupHealthChannelCache(t, map[int]*Channel{
+		63: testEndpointChannel(63, 1, 10, 100),
+		64: testEndpointChannel(64, 1, 10, 100),
+	})
+	tripChannelBreaker(t, 63)
+	tripChannelBreaker(t, 64)
+
+	channel, err := GetRandomSatisfiedChannel("default", "gpt-4o", 0)
+	require.NoError(t, err)
+	require.NotNil(t, channel)
+}
@@ -0,0 +1,349 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	keyusage "github.com/QuantumNous/new-api/pkg/key_usage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// multiKeyAllDisabledReason 所有 Key 被禁用时写入渠道 other_info 的 status_reason，见 handlerMultiKeyUpdate
+const multiKeyAllDisabledReason = "All keys are disabled"
+
+// isKeyCooledDown 自动禁用的 Key 超过冷却时间后视为可用，数据库中的状态由 ReenableCooledDownKeys 定期恢复
+func (info *ChannelInfo) isKeyCooledDown(idx int, cooldownSeconds int, now int64) bool {
+	if cooldownSeconds <= 0 || info.MultiKeyStatusList[idx] != common.ChannelStatusAutoDisabled {
+		return false
+	}
+	disabledTime := info.MultiKeyDisabledTime[idx]
+	return disabledTime > 0 && now-disabledTime >= int64(cooldownSeconds)
+}
+
+// cooledDownKeys 返回已过冷却时间的自动禁用 Key 索引
+func (info *ChannelInfo) cooledDownKeys(cooldownSeconds int, now int64) []int {
+	restored := make([]int, 0)
+	for idx := range info.MultiKeyStatusList {
+		if info.isKeyCooledDown(idx, cooldownSeconds, now) {
+			restored = append(restored, idx)
+		}
+	}
+	return restored
+}
+
+// restoreCooledDownKeys 清除已过冷却时间的自动禁用 Key 的状态，返回被恢复的 Key 索引
+func (info *ChannelInfo) restoreCooledDownKeys(cooldownSeconds int, now int64) []int {
+	restored := info.cooledDownKeys(cooldownSeconds, now)
+	for _, idx := range restored {
+		delete(info.MultiKeyStatusList, idx)
+		delete(info.MultiKeyDisabledTime, idx)
+		delete(info.MultiKeyDisabledReason, idx)
+	}
+	return restored
+}
+
+// GetKeyWeight 返回 weighted 模式下 Key 的权重，未设置时为 1
+func (info *ChannelInfo) GetKeyWeight(idx int) int {
+	if weight, ok := info.MultiKeyWeights[idx]; ok {
+		return weight
+	}
+	return 1
+}
+
+// RemapMultiKeyStats 删除 Key 后按新的索引顺序重排权重，oldIndexes[i] 为新索引 i 对应的原索引。
+// Key 用量保存在 channel_key_usages 表中，由 RemapChannelKeyUsage 单独重排
+func (info *ChannelInfo) RemapMultiKeyStats(oldIndexes []int) {
+	var weights map[int]int
+	for newIdx, oldIdx := range oldIndexes {
+		if weight, ok := info.MultiKeyWeights[oldIdx]; ok {
+			if weights == nil {
+				weights = make(map[int]int)
+			}
+			weights[newIdx] = weight
+		}
+	}
+	info.MultiKeyWeights = weights
+}
+
+// ChannelKeyUsage 多 Key 渠道中单个 Key 的累计用量。
+// 独立成表，各节点以 UPDATE ... SET x = x + ? 原子累加，不会与渠道的其他更新互相覆盖
+type ChannelKeyUsage struct {
+	Id           int   `json:"id"`
+	ChannelId    int   `json:"channel_id" gorm:"uniqueIndex:idx_channel_key_usage,priority:1"`
+	KeyIndex     int   `json:"key_index" gorm:"uniqueIndex:idx_channel_key_usage,priority:2"`
+	RequestCount int64 `json:"request_count" gorm:"bigint;default:0"`
+	TokenCount   int64 `json:"token_count" gorm:"bigint;default:0"`
+	LastUsedTime int64 `json:"last_used_time" gorm:"bigint;default:0"`
+}
+
+// addChannelKeyUsage 原子累加单个 Key 的用量，记录不存在时创建
+func addChannelKeyUsage(tx *gorm.DB, usage *ChannelKeyUsage) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "channel_id"},
+			{Name: "key_index"},
+		},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"request_count": gorm.Expr("channel_key_usages.request_count + ?", usage.RequestCount),
+			"token_count":   gorm.Expr("channel_key_usages.token_count + ?", usage.TokenCount),
+			"last_used_time": gorm.Expr("CASE WHEN channel_key_usages.last_used_time < ? THEN ? ELSE channel_key_usages.last_used_time END",
+				usage.LastUsedTime, usage.LastUsedTime),
+		}),
+	}).Create(usage).Error
+}
+
+// GetChannelKeyUsage 返回渠道各 Key 的累计用量，key index -> usage
+func GetChannelKeyUsage(channelId int) (map[int]MultiKeyUsage, error) {
+	var rows []ChannelKeyUsage
+	if err := DB.Where("channel_id = ?", channelId).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	usage := make(map[int]MultiKeyUsage, len(rows))
+	for _, row := range rows {
+		usage[row.KeyIndex] = MultiKeyUsage{
+			RequestCount: row.RequestCount,
+			TokenCount:   row.TokenCount,
+			LastUsedTime: row.LastUsedTime,
+		}
+	}
+	return usage, nil
+}
+
+// RemapChannelKeyUsage 删除 Key 后按新的索引顺序重排用量，oldIndexes[i] 为新索引 i 对应的原索引
+func RemapChannelKeyUsage(channelId int, oldIndexes []int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var rows []ChannelKeyUsage
+		if err := tx.Where("channel_id = ?", channelId).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		byIndex := make(map[int]ChannelKeyUsage, len(rows))
+		for _, row := range rows {
+			byIndex[row.KeyIndex] = row
+		}
+		if err := tx.Where("channel_id = ?", channelId).Delete(&ChannelKeyUsage{}).Error; err != nil {
+			return err
+		}
+		for newIdx, oldIdx := range oldIndexes {
+			row, ok := byIndex[oldIdx]
+			if !ok {
+				continue
+			}
+			// 删除与重建之间其他节点可能已写入新索引的用量，同样按累加合并
+			if err := addChannelKeyUsage(tx, &ChannelKeyUsage{
+				ChannelId:    channelId,
+				KeyIndex:     newIdx,
+				RequestCount: row.RequestCount,
+				TokenCount:   row.TokenCount,
+				LastUsedTime: row.LastUsedTime,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// filterKeysWithinLimits 过滤掉最近一分钟已达到单 Key RPM/TPM 上限的 Key，全部超限时返回空
+func filterKeysWithinLimits(channelId int, keyIndexes []int, rpmLimit int, tpmLimit int) []int {
+	if rpmLimit <= 0 && tpmLimit <= 0 {
+		return keyIndexes
+	}
+	available := make([]int, 0, len(keyIndexes))
+	for _, idx := range keyIndexes {
+		if keyusage.WithinLimits(channelId, idx, rpmLimit, tpmLimit) {
+			available = append(available, idx)
+		}
+	}
+	return available
+}
+
+// selectLeastUsedKey 选择最近一分钟请求数最少的 Key，相同时优先最久未使用的，仍相同则随机
+func selectLeastUsedKey(channelId int, keyIndexes []int) int {
+	candidates := make([]int, 0, len(keyIndexes))
+	var minRequests, minLastUsed int64
+	for _, idx := range keyIndexes {
+		requests, _ := keyusage.Minute(channelId, idx)
+		lastUsed := keyusage.LastUsed(channelId, idx)
+		switch {
+		case len(candidates) == 0 || requests < minRequests || (requests == minRequests && lastUsed < minLastUsed):
+			candidates = append(candidates[:0], idx)
+			minRequests, minLastUsed = requests, lastUsed
+		case requests == minRequests && lastUsed == minLastUsed:
+			candidates = append(candidates, idx)
+		}
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// selectWeightedKey 按 Key 权重随机选择，权重按 Key 健康分缩放；权重均为 0 时退化为均匀随机
+func selectWeightedKey(channelId int, info *ChannelInfo, keyIndexes []int) int {
+	weights := make([]int, len(keyIndexes))
+	totalWeight := 0
+	for i, idx := range keyIndexes {
+		weights[i] = circuitbreaker.ScaleWeight(info.GetKeyWeight(idx), circuitbreaker.KeyScore(channelId, idx))
+		if weights[i] > 0 {
+			totalWeight += weights[i]
+		}
+	}
+	if totalWeight == 0 {
+		return keyIndexes[rand.Intn(len(keyIndexes))]
+	}
+	r := rand.Intn(totalWeight)
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		r -= weight
+		if r < 0 {
+			return keyIndexes[i]
+		}
+	}
+	return keyIndexes[len(keyIndexes)-1]
+}
+
+// markKeySelected 记录 Key 被选中，用于熔断半开探测与单 Key 用量统计
+func markKeySelected(channelId int, keyIndex int) {
+	circuitbreaker.MarkSelected(channelId, keyIndex)
+	keyusage.RecordRequest(channelId, keyIndex)
+}
+
+// SyncMultiKeyUsage 将内存中的 Key 用量增量原子累加到 channel_key_usages 表
+func SyncMultiKeyUsage() {
+	for _, delta := range keyusage.Drain() {
+		err := addChannelKeyUsage(DB, &ChannelKeyUsage{
+			ChannelId:    delta.ChannelId,
+			KeyIndex:     delta.KeyIndex,
+			RequestCount: delta.Requests,
+			TokenCount:   delta.Tokens,
+			LastUsedTime: delta.LastUsed,
+		})
+		if err != nil {
+			common.SysLog(fmt.Sprintf("failed to sync multi-key usage: channel_id=%d, key_index=%d, error=%v", delta.ChannelId, delta.KeyIndex, err))
+		}
+	}
+}
+
+// ReenableCooledDownKeys 恢复已过冷却时间的自动禁用 Key。
+// 渠道因所有 Key 被禁用而自动禁用时一并恢复渠道，返回被恢复启用的渠道。
+func ReenableCooledDownKeys() ([]*Channel, error) {
+	var channels []*Channel
+	err := DB.Select("id", "name", "status", "channel_info", "other_info", "settings").
+		Where("status IN ?", []int{common.ChannelStatusEnabled, common.ChannelStatusAutoDisabled}).
+		Find(&channels).Error
+	if err != nil {
+		return nil, err
+	}
+	enabledChannels := make([]*Channel, 0)
+	now := common.GetTimestamp()
+	for _, channel := range channels {
+		if !channel.ChannelInfo.IsMultiKey || len(channel.ChannelInfo.cooledDownKeys(channelCooldownSeconds(channel), now)) == 0 {
+			continue
+		}
+		// 上面的查询结果只用于筛选，实际恢复在加锁重新读取的行上进行，避免覆盖期间其他节点对渠道的更新
+		restored, channelEnabled, err := reenableChannelCooledDownKeys(channel, now)
+		if err != nil {
+			common.SysLog(fmt.Sprintf("failed to re-enable cooled down keys: channel_id=%d, error=%v", channel.Id, err))
+			continue
+		}
+		if len(restored) == 0 {
+			continue
+		}
+		common.SysLog(fmt.Sprintf("channel #%d: %d cooled down keys re-enabled", channel.Id, len(restored)))
+		if channelEnabled {
+			if err := UpdateAbilityStatus(channel.Id, true); err != nil {
+				common.SysLog(fmt.Sprintf("failed to update ability status: channel_id=%d, error=%v", channel.Id, err))
+			}
+			enabledChannels = append(enabledChannels, channel)
+		}
+		restoreCachedCooledDownKeys(channel.Id, restored)
+	}
+	if len(enabledChannels) > 0 && common.MemoryCacheEnabled {
+		InitChannelCache()
+	}
+	return enabledChannels, nil
+}
+
+// channelCooldownSeconds 读取渠道的 Key 冷却时间。
+// 仅查询了部分字段，不使用 GetOtherSettings，避免解析失败时整行保存覆盖其他字段
+func channelCooldownSeconds(channel *Channel) int {
+	settings := dto.ChannelOtherSettings{}
+	if channel.OtherSettings != "" {
+		_ = common.UnmarshalJsonStr(channel.OtherSettings, &settings)
+	}
+	return settings.MultiKeyCooldownSeconds
+}
+
+// reenableChannelCooledDownKeys 在事务中锁定渠道行后恢复冷却完毕的 Key，并把最新状态写回 channel。
+// 返回被恢复的 Key 索引，以及渠道是否随之被重新启用
+func reenableChannelCooledDownKeys(channel *Channel, now int64) ([]int, bool, error) {
+	var restored []int
+	channelEnabled := false
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		fresh := &Channel{}
+		err := lockForUpdate(tx).Select("id", "name", "status", "channel_info", "other_info", "settings").
+			First(fresh, "id = ?", channel.Id).Error
+		if err != nil {
+			return err
+		}
+		if !fresh.ChannelInfo.IsMultiKey || (fresh.Status != common.ChannelStatusEnabled && fresh.Status != common.ChannelStatusAutoDisabled) {
+			return nil
+		}
+		restored = fresh.ChannelInfo.restoreCooledDownKeys(channelCooldownSeconds(fresh), now)
+		if len(restored) == 0 {
+			return nil
+		}
+		if fresh.Status == common.ChannelStatusAutoDisabled && len(fresh.ChannelInfo.MultiKeyStatusList) < fresh.ChannelInfo.MultiKeySize {
+			info := fresh.GetOtherInfo()
+			if reason, _ := info["status_reason"].(string); reason == multiKeyAllDisabledReason {
+				delete(info, "status_reason")
+				info["status_time"] = now
+				fresh.SetOtherInfo(info)
+				fresh.Status = common.ChannelStatusEnabled
+				channelEnabled = true
+			}
+		}
+		err = tx.Model(&Channel{}).Where("id = ?", fresh.Id).Updates(map[string]interface{}{
+			"status":       fresh.Status,
+			"channel_info": fresh.ChannelInfo,
+			"other_info":   fresh.OtherInfo,
+		}).Error
+		if err != nil {
+			return err
+		}
+		*channel = *fresh
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return restored, channelEnabled, nil
+}
+
+// restoreCachedCooledDownKeys 同步清除内存缓存中已恢复 Key 的禁用状态
+func restoreCachedCooledDownKeys(channelId int, restored []int) {
+	if !common.MemoryCacheEnabled {
+		return
+	}
+	cached, err := CacheGetChannel(channelId)
+	if err != nil || cached == nil {
+		return
+	}
+	lock := GetChannelPollingLock(channelId)
+	lock.Lock()
+	defer lock.Unlock()
+	for _, idx := range restored {
+		if cached.ChannelInfo.MultiKeyStatusList[idx] == common.ChannelStatusAutoDisabled {
+			delete(cached.ChannelInfo.MultiKeyStatusList, idx)
+			delete(cached.ChannelInfo.MultiKeyDisabledTime, idx)
+			delete(cached.ChannelInfo.MultiKeyDisabledReason, idx)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	keyusage "github.com/QuantumNous/new-api/pkg/key_usage"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testMultiKeyChannel(t *testing.T, id int, mode constant.MultiKeyMode, settings string) *Channel {
+	t.Helper()
+	t.Cleanup(func() { keyusage.Reset(id) })
+	return &Channel{
+		Id:            id,
+		Key:           "key-a\nkey-b\nkey-c",
+		OtherSettings: settings,
+		ChannelInfo: ChannelInfo{
+			IsMultiKey:   true,
+			MultiKeySize: 3,
+			MultiKeyMode: mode,
+		},
+	}
+}
+
+func TestGetNextEnabledKeyLeastUsedSpreadsRequests(t *testing.T) {
+	channel := testMultiKeyChannel(t, 9101, constant.MultiKeyModeLeastUsed, "")
+	counts := make(map[int]int)
+	for i := 0; i < 6; i++ {
+		_, idx, err := channel.GetNextEnabledKey()
+		require.Nil(t, err)
+		counts[idx]++
+	}
+	require.Equal(t, map[int]int{0: 2, 1: 2, 2: 2}, counts)
+}
+
+func TestGetNextEnabledKeyWeightedSkipsZeroWeight(t *testing.T) {
+	channel := testMultiKeyChannel(t, 9102, constant.MultiKeyModeWeighted, "")
+	channel.ChannelInfo.MultiKeyWeights = map[int]int{0: 0, 2: 0}
+	for i := 0; i < 20; i++ {
+		key, idx, err := channel.GetNextEnabledKey()
+		require.Nil(t, err)
+		require.Equal(t, 1, idx)
+		require.Equal(t, "key-b", key)
+	}
+}
+
+func TestGetNextEnabledKeyPerKeyRPMLimit(t *testing.T) {
+	channel := testMultiKeyChannel(t, 9103, constant.MultiKeyModeLeastUsed, `{"multi_key_rpm_limit":1}`)
+	seen := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		_, idx, err := channel.GetNextEnabledKey()
+		require.Nil(t, err)
+		seen[idx] = true
+	}
+	require.Len(t, seen, 3)
+
+	_, _, err := channel.GetNextEnabledKey()
+	require.NotNil(t, err)
+	require.Equal(t, types.ErrorCodeChannelKeyRateLimited, err.GetErrorCode())
+	require.Equal(t, http.StatusTooManyRequests, err.StatusCode)
+}
+
+func TestGetNextEnabledKeyCooldown(t *testing.T) {
+	channel := testMultiKeyChannel(t, 9104, constant.MultiKeyModeRandom, "")
+	channel.ChannelInfo.MultiKeyStatusList = map[int]int{
+		0: common.ChannelStatusAutoDisabled,
+		1: common.ChannelStatusManuallyDisabled,
+		2: common.ChannelStatusAutoDisabled,
+	}
+	channel.ChannelInfo.MultiKeyDisabledTime = map[int]int64{
+		0: common.GetTimestamp() - 120,
+		1: common.GetTimestamp() - 120,
+		2: common.GetTimestamp(),
+	}
+	_, _, err := channel.GetNextEnabledKey()
+	require.NotNil(t, err)
+	require.Equal(t, types.ErrorCodeChannelNoAvailableKey, err.GetErrorCode())
+
+	// 仅自动禁用且已过冷却时间的 Key 可以被选中，手动禁用的 Key 不会自动恢复
+	channel.OtherSettings = `{"multi_key_cooldown_seconds":60}`
+	for i := 0; i < 10; i++ {
+		_, idx, err := channel.GetNextEnabledKey()
+		require.Nil(t, err)
+		require.Equal(t, 0, idx)
+	}
+
+	restored := channel.ChannelInfo.restoreCooledDownKeys(60, common.GetTimestamp())
+	require.Equal(t, []int{0}, restored)
+	require.Equal(t, map[int]int{
+		1: common.ChannelStatusManuallyDisabled,
+		2: common.ChannelStatusAutoDisabled,
+	}, channel.ChannelInfo.MultiKeyStatusList)
+}
+
+func TestRemapMultiKeyStats(t *testing.T) {
+	info := ChannelInfo{
+		MultiKeyWeights: map[int]int{0: 5, 2: 3},
+	}
+	// 删除索引 1 的 Key 后，原索引 2 变为 1
+	info.RemapMultiKeyStats([]int{0, 2})
+	require.Equal(t, map[int]int{0: 5, 1: 3}, info.MultiKeyWeights)
+	require.Equal(t, 1, info.GetKeyWeight(5))
+}
+
+func TestSyncMultiKeyUsageAccumulates(t *testing.T) {
+	const channelId = 9105
+	t.Cleanup(func() {
+		keyusage.Reset(channelId)
+		DB.Where("channel_id = ?", channelId).Delete(&ChannelKeyUsage{})
+	})
+
+	// 模拟两个节点各自落库：用量累加而不是互相覆盖，最近使用时间取较大值
+	require.NoError(t, addChannelKeyUsage(DB, &ChannelKeyUsage{ChannelId: channelId, KeyIndex: 1, RequestCount: 10, TokenCount: 100, LastUsedTime: 2000}))
+	require.NoError(t, addChannelKeyUsage(DB, &ChannelKeyUsage{ChannelId: channelId, KeyIndex: 1, RequestCount: 5, TokenCount: 50, LastUsedTime: 1000}))
+	keyusage.RecordRequest(channelId, 2)
+	keyusage.RecordTokens(channelId, 2, 30)
+	SyncMultiKeyUsage()
+
+	usage, err := GetChannelKeyUsage(channelId)
+	require.NoError(t, err)
+	require.Equal(t, MultiKeyUsage{RequestCount: 15, TokenCount: 150, LastUsedTime: 2000}, usage[1])
+	require.Equal(t, int64(1), usage[2].RequestCount)
+	require.Equal(t, int64(30), usage[2].TokenCount)
+
+	// 删除索引 1 的 Key 后，原索引 2 变为 1
+	require.NoError(t, RemapChannelKeyUsage(channelId, []int{0, 2}))
+	usage, err = GetChannelKeyUsage(channelId)
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	require.Equal(t, int64(1), usage[1].RequestCount)
+}
+
+func TestReenableCooledDownKeys(t *testing.T) {
+	truncateTables(t)
+	now := common.GetTimestamp()
+	channel := testMultiKeyChannel(t, 9106, constant.MultiKeyModeRandom, `{"multi_key_cooldown_seconds":60}`)
+	channel.Status = common.ChannelStatusAutoDisabled
+	channel.ChannelInfo.MultiKeyStatusList = map[int]int{
+		0: common.ChannelStatusAutoDisabled,
+		1: common.ChannelStatusAutoDisabled,
+		2: common.ChannelStatusManuallyDisabled,
+	}
+	channel.ChannelInfo.MultiKeyDisabledTime = map[int]int64{0: now - 120, 1: now, 2: now - 120}
+	channel.OtherInfo = `{"status_reason":"All keys are disabled"}`
+	require.NoError(t, DB.Create(channel).Error)
+
+	enabled, err := ReenableCooledDownKeys()
+	require.NoError(t, err)
+	require.Len(t, enabled, 1)
+
+	// 只清除冷却完毕的自动禁用 Key，渠道因此恢复启用
+	stored := &Channel{}
+	require.NoError(t, DB.First(stored, "id = ?", channel.Id).Error)
+	require.Equal(t, common.ChannelStatusEnabled, stored.Status)
+	require.Equal(t, map[int]int{
+		1: common.ChannelStatusAutoDisabled,
+		2: common.ChannelStatusManuallyDisabled,
+	}, stored.ChannelInfo.MultiKeyStatusList)
+	require.NotContains(t, stored.OtherInfo, multiKeyAllDisabledReason)
+}
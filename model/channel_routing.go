@@ -0,0 +1,118 @@
+package model
+
+import (
+	"math"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/pkg/routing"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+)
+
+// latencyTieTolerance 延迟与最优渠道相差不超过该比例时视为同等，避免所有流量集中到单个渠道
+const latencyTieTolerance = 0.1
+
+// narrowByRoutingStrategy 按路由策略从同一优先级的候选中筛选出最优的一组，组内仍按权重随机选择。
+// 按权重随机策略或无法比较时原样返回。
+func narrowByRoutingStrategy[T any](strategy string, items []T, channelOf func(T) *Channel, modelName string) []T {
+	if len(items) <= 1 {
+		return items
+	}
+	for _, item := range items {
+		// 渠道缺失属于数据一致性问题，交由调用方报错
+		if channelOf(item) == nil {
+			return items
+		}
+	}
+	switch strategy {
+	case operation_setting.RoutingStrategyLeastLatency:
+		// 没有延迟样本的渠道按已知渠道的平均延迟处理，使其有机会被采样
+		return narrowByMetric(items, func(item T) (float64, bool) {
+			return routing.Latency(channelOf(item).Id, modelName)
+		}, true, latencyTieTolerance)
+	case operation_setting.RoutingStrategyLeastCost:
+		return narrowByMetric(items, func(item T) (float64, bool) {
+			return channelModelCost(channelOf(item), modelName)
+		}, false, 0)
+	case operation_setting.RoutingStrategyLeastOutstanding:
+		return narrowByMetric(items, func(item T) (float64, bool) {
+			return float64(routing.Outstanding(channelOf(item).Id)), true
+		}, false, 0)
+	default:
+		return items
+	}
+}
+
+// narrowByMetric 保留指标不超过最小值 (1+tolerance) 倍的候选。
+// 指标未知的候选在 unknownAsAverage 为 true 时按已知指标的平均值处理，否则排除；全部未知时原样返回。
+func narrowByMetric[T any](items []T, metric func(T) (float64, bool), unknownAsAverage bool, tolerance float64) []T {
+	values := make([]float64, len(items))
+	known := make([]bool, len(items))
+	knownSum, knownCount := 0.0, 0
+	for i, item := range items {
+		values[i], known[i] = metric(item)
+		if known[i] {
+			knownSum += values[i]
+			knownCount++
+		}
+	}
+	if knownCount == 0 {
+		return items
+	}
+	for i := range items {
+		if known[i] {
+			continue
+		}
+		if unknownAsAverage {
+			values[i] = knownSum / float64(knownCount)
+		} else {
+			values[i] = math.Inf(1)
+		}
+	}
+
+	best := math.Inf(1)
+	for _, value := range values {
+		best = math.Min(best, value)
+	}
+	limit := best*(1+tolerance) + 1e-9
+	narrowed := make([]T, 0, len(items))
+	for i, item := range items {
+		if values[i] <= limit {
+			narrowed = append(narrowed, item)
+		}
+	}
+	return narrowed
+}
+
+// channelModelCost 返回渠道经模型重定向后实际调用模型的价格：配置了按次价格时使用价格，否则使用模型倍率。
+// 同一优先级内的渠道通常重定向到计费方式相同的模型，因此两者不做换算。
+func channelModelCost(channel *Channel, modelName string) (float64, bool) {
+	upstreamModel := channelUpstreamModel(channel, modelName)
+	if price, ok := ratio_setting.GetModelPrice(upstreamModel, false); ok {
+		return price, true
+	}
+	ratio, ok, _ := ratio_setting.GetModelRatio(upstreamModel)
+	return ratio, ok
+}
+
+// channelUpstreamModel 按渠道的模型重定向配置解析实际调用的模型，支持链式重定向
+func channelUpstreamModel(channel *Channel, modelName string) string {
+	modelMapping := channel.GetModelMapping()
+	if modelMapping == "" || modelMapping == "{}" {
+		return modelName
+	}
+	modelMap := make(map[string]string)
+	if err := common.UnmarshalJsonStr(modelMapping, &modelMap); err != nil {
+		return modelName
+	}
+	current := modelName
+	visited := map[string]bool{current: true}
+	for {
+		mapped, ok := modelMap[current]
+		if !ok || mapped == "" || visited[mapped] {
+			return current
+		}
+		visited[mapped] = true
+		current = mapped
+	}
+}
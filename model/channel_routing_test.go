@@ -0,0 +1,79 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/pkg/routing"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+	"github.com/stretchr/testify/require"
+)
+
+func setGroupRoutingStrategy(t *testing.T, group string, strategy string) {
+	t.Helper()
+	setting := operation_setting.GetRoutingSetting()
+	oldGroupStrategies := setting.GroupStrategies
+	t.Cleanup(func() { setting.GroupStrategies = oldGroupStrategies })
+	setting.GroupStrategies = map[string]string{group: strategy}
+}
+
+func TestGetRandomSatisfiedChannelLeastCostUsesMappedModel(t *testing.T) {
+	oldModelRatio := ratio_setting.ModelRatio2JSONString()
+	t.Cleanup(func() { require.NoError(t, ratio_setting.UpdateModelRatioByJSONString(oldModelRatio)) })
+	require.NoError(t, ratio_setting.UpdateModelRatioByJSONString(`{"route-expensive":10,"route-cheap":1}`))
+
+	expensiveMapping := `{"gpt-4o":"route-expensive"}`
+	cheapMapping := `{"gpt-4o":"route-cheap"}`
+	expensive := testEndpointChannel(70, 1, 0, 100)
+	expensive.ModelMapping = &expensiveMapping
+	cheap := testEndpointChannel(71, 1, 0, 1)
+	cheap.ModelMapping = &cheapMapping
+	setupHealthChannelCache(t, map[int]*Channel{70: expensive, 71: cheap})
+
+	setGroupRoutingStrategy(t, "default", operation_setting.RoutingStrategyLeastCost)
+	for i := 0; i < 50; i++ {
+		channel, err := GetRandomSatisfiedChannel("default", "gpt-4o", 0)
+		require.NoError(t, err)
+		require.Equal(t, 71, channel.Id)
+	}
+}
+
+func TestGetRandomSatisfiedChannelLeastOutstanding(t *testing.T) {
+	setupHealthChannelCache(t, map[int]*Channel{
+		72: testEndpointChannel(72, 1, 0, 100),
+		73: testEndpointChannel(73, 1, 0, 100),
+	})
+	setGroupRoutingStrategy(t, "default", operation_setting.RoutingStrategyLeastOutstanding)
+
+	release := routing.Acquire(72)
+	for i := 0; i < 50; i++ {
+		channel, err := GetRandomSatisfiedChannel("default", "gpt-4o", 0)
+		require.NoError(t, err)
+		require.Equal(t, 73, channel.Id)
+	}
+	release()
+	release()
+	require.Equal(t, int64(0), routing.Outstanding(72))
+}
+
+func TestNarrowByRoutingStrategyLeastLatency(t *testing.T) {
+	channels := []*Channel{
+		testEndpointChannel(74, 1, 0, 100),
+		testEndpointChannel(75, 1, 0, 100),
+		testEndpointChannel(76, 1, 0, 100),
+	}
+	routing.ObserveLatency(74, "route-latency", 800)
+	routing.ObserveLatency(75, "route-latency", 200)
+
+	narrowed := narrowByRoutingStrategy(operation_setting.RoutingStrategyLeastLatency, channels, func(channel *Channel) *Channel {
+		return channel
+	}, "route-latency")
+	require.Len(t, narrowed, 1)
+	require.Equal(t, 75, narrowed[0].Id)
+
+	// 按权重随机时不做筛选
+	narrowed = narrowByRoutingStrategy(operation_setting.RoutingStrategyWeightedRandom, channels, func(channel *Channel) *Channel {
+		return channel
+	}, "route-latency")
+	require.Len(t, narrowed, 3)
+}
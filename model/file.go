@@ -0,0 +1,154 @@
+package model
+
+import (
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+)
+
+// File OpenAI Files API 上传的文件记录
+// 文件可以保存在本地（Stored 为 true），也可以保存在上游渠道（ChannelId + UpstreamFileId），
+// 两者可以同时存在：本地文件在被批处理等功能使用时会按需上传到上游渠道。
+type File struct {
+	Id             int    `json:"id"`
+	FileId         string `json:"file_id" gorm:"type:varchar(64);uniqueIndex"` // 对外暴露的 file-xxxx ID
+	UserId         int    `json:"user_id" gorm:"index"`
+	TokenId        int    `json:"token_id" gorm:"index"` // 上传文件的令牌，仅该令牌可以访问
+	Purpose        string `json:"purpose" gorm:"type:varchar(32);index"`
+	Filename       string `json:"filename" gorm:"type:varchar(255)"`
+	Bytes          int64  `json:"bytes" gorm:"bigint"`
+	Status         string `json:"status" gorm:"type:varchar(20)"`
+	StatusDetails  string `json:"status_details" gorm:"type:text"`
+	Stored         bool   `json:"-" gorm:"default:false"`     // 本地是否保存了文件内容，路径由 FileId 与当前存储目录决定
+	ChannelId      int    `json:"channel_id" gorm:"index"`    // 上游渠道 ID
+	UpstreamFileId string `json:"-" gorm:"type:varchar(191)"` // 上游渠道返回的文件 ID
+	KeyIndex       int    `json:"-" gorm:"default:0"`         // 多 Key 渠道上传时使用的 key 索引
+	CreatedAt      int64  `json:"created_at" gorm:"bigint;index"`
+	ExpiresAt      int64  `json:"expires_at" gorm:"bigint;index"` // 0 表示永不过期
+}
+
+// GenerateFileID 生成对外暴露的 file-xxxx 格式 ID
+func GenerateFileID() string {
+	key, _ := common.GenerateRandomCharsKey(24)
+	return "file-" + key
+}
+
+func (f *File) IsLocal() bool {
+	return f.Stored
+}
+
+func (f *File) IsUpstream() bool {
+	return f.ChannelId != 0 && f.UpstreamFileId != ""
+}
+
+func (f *File) ToOpenAIFile() dto.OpenAIFile {
+	return dto.OpenAIFile{
+		ID:            f.FileId,
+		Object:        "file",
+		Bytes:         f.Bytes,
+		CreatedAt:     f.CreatedAt,
+		ExpiresAt:     f.ExpiresAt,
+		Filename:      f.Filename,
+		Purpose:       f.Purpose,
+		Status:        f.Status,
+		StatusDetails: f.StatusDetails,
+	}
+}
+
+func (f *File) Insert() error {
+	if f.CreatedAt == 0 {
+		f.CreatedAt = common.GetTimestamp()
+	}
+	return DB.Create(f).Error
+}
+
+// UpdateUpstream 记录文件在上游渠道上的副本
+func (f *File) UpdateUpstream(channelId int, upstreamFileId string, keyIndex int) error {
+	f.ChannelId = channelId
+	f.UpstreamFileId = upstreamFileId
+	f.KeyIndex = keyIndex
+	return DB.Model(f).Updates(map[string]any{
+		"channel_id":       channelId,
+		"upstream_file_id": upstreamFileId,
+		"key_index":        keyIndex,
+	}).Error
+}
+
+func (f *File) Delete() error {
+	return DB.Delete(f).Error
+}
+
+// GetTokenFile 获取令牌拥有的文件，文件不存在时返回 (nil, false, nil)
+func GetTokenFile(userId int, tokenId int, fileId string) (*File, bool, error) {
+	if fileId == "" {
+		return nil, false, nil
+	}
+	var file File
+	err := DB.Where("user_id = ? AND token_id = ? AND file_id = ?", userId, tokenId, fileId).First(&file).Error
+	exist, err := RecordExist(err)
+	if err != nil || !exist {
+		return nil, exist, err
+	}
+	if file.ExpiresAt > 0 && file.ExpiresAt <= common.GetTimestamp() {
+		return nil, false, nil
+	}
+	return &file, true, nil
+}
+
+// GetFileByFileId 不校验归属直接获取文件（仅供内部任务使用）
+func GetFileByFileId(fileId string) (*File, error) {
+	var file File
+	err := DB.Where("file_id = ?", fileId).First(&file).Error
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FileListParams 文件列表查询参数，after 为游标（file_id）
+type FileListParams struct {
+	Purpose string
+	After   string
+	Limit   int
+	Order   string // asc 或 desc
+}
+
+// GetTokenFiles 按游标分页获取令牌的文件列表，返回结果最多比 Limit 多一条用于判断 has_more
+func GetTokenFiles(userId int, tokenId int, params FileListParams) ([]*File, error) {
+	var files []*File
+	query := DB.Where("user_id = ? AND token_id = ?", userId, tokenId).
+		Where("(expires_at = 0 OR expires_at > ?)", common.GetTimestamp())
+	if params.Purpose != "" {
+		query = query.Where("purpose = ?", params.Purpose)
+	}
+	desc := params.Order != "asc"
+	if params.After != "" {
+		var cursor File
+		if err := DB.Select("id").Where("user_id = ? AND token_id = ? AND file_id = ?", userId, tokenId, params.After).First(&cursor).Error; err == nil {
+			if desc {
+				query = query.Where("id < ?", cursor.Id)
+			} else {
+				query = query.Where("id > ?", cursor.Id)
+			}
+		}
+	}
+	if desc {
+		query = query.Order("id desc")
+	} else {
+		query = query.Order("id asc")
+	}
+	err := query.Limit(params.Limit + 1).Find(&files).Error
+	return files, err
+}
+
+func CountUserFiles(userId int) (int64, error) {
+	var count int64
+	err := DB.Model(&File{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+// GetExpiredFiles 获取已过期的文件
+func GetExpiredFiles(now int64, limit int) ([]*File, error) {
+	var files []*File
+	err := DB.Where("expires_at > 0 AND expires_at <= ?", now).Order("id").Limit(limit).Find(&files).Error
+	return files, err
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertFile(t *testing.T, userId int, tokenId int, purpose string) *File {
+	t.Helper()
+	file := &File{
+		FileId:  GenerateFileID(),
+		UserId:  userId,
+		TokenId: tokenId,
+		Purpose: purpose,
+	}
+	require.NoError(t, file.Insert())
+	return file
+}
+
+func TestGetTokenFile_EnforcesTokenOwnership(t *testing.T) {
+	truncateTables(t)
+
+	file := insertFile(t, 1, 10, "batch")
+
+	got, exists, err := GetTokenFile(1, 10, file.FileId)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, file.Id, got.Id)
+
+	_, exists, err = GetTokenFile(1, 11, file.FileId)
+	require.NoError(t, err)
+	assert.False(t, exists, "another token of the same user must not see the file")
+
+	_, exists, err = GetTokenFile(2, 10, file.FileId)
+	require.NoError(t, err)
+	assert.False(t, exists, "another user must not see the file")
+}
+
+func TestGetTokenFile_Expired(t *testing.T) {
+	truncateTables(t)
+
+	file := &File{
+		FileId:    GenerateFileID(),
+		UserId:    1,
+		TokenId:   10,
+		Purpose:   "batch",
+		ExpiresAt: common.GetTimestamp() - 1,
+	}
+	require.NoError(t, file.Insert())
+
+	_, exists, err := GetTokenFile(1, 10, file.FileId)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestGetTokenFiles_CursorPagination(t *testing.T) {
+	truncateTables(t)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, insertFile(t, 1, 10, "batch").FileId)
+	}
+	insertFile(t, 1, 10, "assistants")
+	insertFile(t, 1, 11, "batch")
+
+	page, err := GetTokenFiles(1, 10, FileListParams{Purpose: "batch", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 3, "one extra row signals has_more")
+	assert.Equal(t, ids[4], page[0].FileId)
+	assert.Equal(t, ids[3], page[1].FileId)
+
+	page, err = GetTokenFiles(1, 10, FileListParams{Purpose: "batch", Limit: 2, After: ids[3]})
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, ids[2], page[0].FileId)
+
+	page, err = GetTokenFiles(1, 10, FileListParams{Purpose: "batch", Limit: 10, After: ids[1], Order: "asc"})
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, ids[2], page[0].FileId)
+}
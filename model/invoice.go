@@ -0,0 +1,145 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"gorm.io/gorm"
+)
+
+const (
+	InvoiceKindTopUp        = "topup"        // 钱包充值
+	InvoiceKindSubscription = "subscription" // 订阅套餐购买
+)
+
+var ErrInvoiceNotAvailable = errors.New("invoice is only available for completed orders")
+
+// Invoice 已完成充值/订阅订单的发票记录。首次下载时开具，开具后内容（含购买方抬头）不再变化
+type Invoice struct {
+	Id            int     `json:"id"`
+	InvoiceNo     string  `json:"invoice_no" gorm:"type:varchar(64);index"`
+	UserId        int     `json:"user_id" gorm:"index"`
+	TradeNo       string  `json:"trade_no" gorm:"type:varchar(255);uniqueIndex"`
+	Kind          string  `json:"kind" gorm:"type:varchar(16)"`
+	Description   string  `json:"description" gorm:"type:varchar(255)"`
+	Amount        int64   `json:"amount"` // 充值数量，与 TopUp.Amount 一致；订阅为 0
+	Money         float64 `json:"money"`
+	Currency      string  `json:"currency" gorm:"type:varchar(8)"`
+	PaymentMethod string  `json:"payment_method" gorm:"type:varchar(50)"`
+	BuyerName     string  `json:"buyer_name" gorm:"type:varchar(128)"`
+	BuyerTaxId    string  `json:"buyer_tax_id" gorm:"type:varchar(64)"`
+	BuyerEmail    string  `json:"buyer_email" gorm:"type:varchar(255)"`
+	PaidAt        int64   `json:"paid_at"`
+	CreatedAt     int64   `json:"created_at"`
+}
+
+// GetOrCreateInvoice 获取订单对应的发票，不存在时按订单与用户当前抬头开具。
+// 同一 trade_no 只会开具一张发票。
+func GetOrCreateInvoice(topUp *TopUp) (*Invoice, error) {
+	if topUp == nil || topUp.Status != common.TopUpStatusSuccess {
+		return nil, ErrInvoiceNotAvailable
+	}
+	var invoice Invoice
+	err := DB.Where("trade_no = ?", topUp.TradeNo).First(&invoice).Error
+	if err == nil {
+		return &invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user, err := GetUserById(topUp.UserId, false)
+	if err != nil {
+		return nil, err
+	}
+	setting := operation_setting.GetInvoiceSetting()
+	invoice = Invoice{
+		UserId:        topUp.UserId,
+		TradeNo:       topUp.TradeNo,
+		Kind:          InvoiceKindTopUp,
+		Description:   "Wallet top-up",
+		Amount:        topUp.Amount,
+		Money:         topUp.Money,
+		Currency:      setting.GetCurrency(),
+		PaymentMethod: topUp.PaymentMethod,
+		BuyerName:     user.CompanyName,
+		BuyerTaxId:    user.TaxId,
+		BuyerEmail:    user.Email,
+		PaidAt:        topUp.CompleteTime,
+		CreatedAt:     common.GetTimestamp(),
+	}
+	if invoice.BuyerName == "" {
+		invoice.BuyerName = user.Username
+	}
+	if invoice.PaidAt == 0 {
+		invoice.PaidAt = topUp.CreateTime
+	}
+	// 订阅订单完成时会写入同 trade_no 的充值记录，按套餐开具
+	if order := GetSubscriptionOrderByTradeNo(topUp.TradeNo); order != nil {
+		invoice.Kind = InvoiceKindSubscription
+		invoice.Amount = 0
+		invoice.Description = "Subscription"
+		if plan, err := GetSubscriptionPlanById(order.PlanId); err == nil {
+			invoice.Description = "Subscription: " + plan.Title
+			if plan.Currency != "" {
+				invoice.Currency = plan.Currency
+			}
+		}
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invoice).Error; err != nil {
+			return err
+		}
+		// 编号按开具顺序递增，月份取支付时间
+		invoice.InvoiceNo = fmt.Sprintf("%s-%s-%06d", setting.GetNumberPrefix(), time.Unix(invoice.PaidAt, 0).In(operation_setting.GetInvoiceLocation()).Format("200601"), invoice.Id)
+		return tx.Model(&invoice).Update("invoice_no", invoice.InvoiceNo).Error
+	})
+	if err != nil {
+		// 并发开具时唯一索引冲突，返回已开具的发票
+		var existing Invoice
+		if DB.Where("trade_no = ?", topUp.TradeNo).First(&existing).Error == nil {
+			return &existing, nil
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetUserPaidTopUps 获取用户在时间范围内已完成的充值/订阅订单，用于月度账单
+func GetUserPaidTopUps(userId int, startTime int64, endTime int64) ([]*TopUp, error) {
+	var topUps []*TopUp
+	err := DB.Where("user_id = ? AND status = ? AND complete_time >= ? AND complete_time < ?",
+		userId, common.TopUpStatusSuccess, startTime, endTime).Order("complete_time").Find(&topUps).Error
+	return topUps, err
+}
+
+// StatementItem 月度账单中按模型与令牌汇总的消费明细，Quota 为扣除退款后的净额度
+type StatementItem struct {
+	ModelName        string `json:"model_name"`
+	TokenName        string `json:"token_name"`
+	Requests         int64  `json:"requests"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Quota            int64  `json:"quota"`
+}
+
+// GetUserStatementItems 汇总用户在 [startTime, endTime) 内的消费与退款日志
+func GetUserStatementItems(userId int, startTime int64, endTime int64) ([]*StatementItem, error) {
+	var items []*StatementItem
+	err := LOG_DB.Table("logs").
+		Select("model_name, token_name, "+
+			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) AS requests, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(CASE WHEN type = ? THEN quota ELSE -quota END) AS quota", LogTypeConsume, LogTypeConsume).
+		Where("user_id = ? AND created_at >= ? AND created_at < ? AND type IN ?",
+			userId, startTime, endTime, []int{LogTypeConsume, LogTypeRefund}).
+		Group("model_name, token_name").
+		Order("quota DESC, model_name, token_name").
+		Scan(&items).Error
+	return items, err
+}
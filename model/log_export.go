@@ -0,0 +1,132 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// LogExportFilter 日志导出的筛选条件，与日志列表接口一致
+type LogExportFilter struct {
+	UserId         int // 非 0 时只导出该用户的日志（用户自助导出）
+	LogType        int
+	StartTimestamp int64
+	EndTimestamp   int64
+	ModelName      string
+	Username       string
+	TokenName      string
+	Channel        int
+	Group          string
+	OrganizationId int
+}
+
+// Validate 在开始输出前校验筛选条件，避免导出中途才发现模型名称模式不合法
+func (f *LogExportFilter) Validate() error {
+	if f.ModelName == "" {
+		return nil
+	}
+	_, err := sanitizeLikePattern(f.ModelName)
+	return err
+}
+
+func (f *LogExportFilter) query() (*gorm.DB, error) {
+	tx := LOG_DB.Model(&Log{})
+	if f.UserId != 0 {
+		tx = tx.Where("logs.user_id = ?", f.UserId)
+	}
+	if f.LogType != LogTypeUnknown {
+		tx = tx.Where("logs.type = ?", f.LogType)
+	}
+	if f.ModelName != "" {
+		modelNamePattern, err := sanitizeLikePattern(f.ModelName)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("logs.model_name LIKE ? ESCAPE '!'", modelNamePattern)
+	}
+	if f.Username != "" {
+		tx = tx.Where("logs.username = ?", f.Username)
+	}
+	if f.TokenName != "" {
+		tx = tx.Where("logs.token_name = ?", f.TokenName)
+	}
+	if f.StartTimestamp != 0 {
+		tx = tx.Where("logs.created_at >= ?", f.StartTimestamp)
+	}
+	if f.EndTimestamp != 0 {
+		tx = tx.Where("logs.created_at <= ?", f.EndTimestamp)
+	}
+	if f.Channel != 0 {
+		tx = tx.Where("logs.channel_id = ?", f.Channel)
+	}
+	if f.Group != "" {
+		tx = tx.Where("logs."+logGroupCol+" = ?", f.Group)
+	}
+	if f.OrganizationId != 0 {
+		tx = tx.Where("logs.organization_id = ?", f.OrganizationId)
+	}
+	return tx, nil
+}
+
+// StreamLogs 按 id 升序分批读取符合条件的日志，避免一次性加载全部数据。
+// 用户自助导出时与 GetUserLogs 一样隐藏管理员字段并使用序号代替日志 id。
+func StreamLogs(filter LogExportFilter, batchSize int, fn func(logs []*Log) error) error {
+	tx, err := filter.query()
+	if err != nil {
+		return err
+	}
+	tx = tx.Session(&gorm.Session{})
+	lastId := 0
+	exported := 0
+	for {
+		var logs []*Log
+		if err := tx.Where("logs.id > ?", lastId).Order("logs.id").Limit(batchSize).Find(&logs).Error; err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			return nil
+		}
+		// 先记录游标，formatUserLogs 会覆盖日志 id
+		lastId = logs[len(logs)-1].Id
+		if filter.UserId != 0 {
+			formatUserLogs(logs, exported)
+		} else if err := fillLogChannelNames(logs); err != nil {
+			return err
+		}
+		exported += len(logs)
+		if err := fn(logs); err != nil {
+			return err
+		}
+		if len(logs) < batchSize {
+			return nil
+		}
+	}
+}
+
+// StreamUsageData 按小时汇总符合条件的消费日志，字段与 QuotaData 一致。
+// quota_data 表不含令牌、渠道与分组维度，为支持相同的筛选条件直接从日志汇总。
+func StreamUsageData(filter LogExportFilter, fn func(data *QuotaData) error) error {
+	filter.LogType = LogTypeConsume
+	tx, err := filter.query()
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Select("logs.user_id, logs.username, logs.model_name, " +
+		"logs.created_at - logs.created_at % 3600 AS created_at, COUNT(*) AS count, " +
+		"SUM(logs.prompt_tokens) + SUM(logs.completion_tokens) AS token_used, SUM(logs.quota) AS quota").
+		Group("logs.user_id, logs.username, logs.model_name, logs.created_at - logs.created_at % 3600").
+		Order("created_at, logs.user_id, logs.model_name").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data QuotaData
+		if err := LOG_DB.ScanRows(rows, &data); err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedExportLogs(t *testing.T) {
+	t.Helper()
+	truncateTables(t)
+	require.NoError(t, DB.Create(&Channel{Id: 7, Name: "primary", Key: "sk-test"}).Error)
+	hour := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC).Unix()
+	logs := []*Log{
+		{UserId: 1, Username: "alice", Type: LogTypeConsume, CreatedAt: hour + 60, ModelName: "gpt-4o", TokenName: "prod", Group: "default", ChannelId: 7, Quota: 100, PromptTokens: 10, CompletionTokens: 5, Other: `{"admin_info":{"x":1},"cache_tokens":3}`},
+		{UserId: 1, Username: "alice", Type: LogTypeConsume, CreatedAt: hour + 120, ModelName: "gpt-4o", TokenName: "prod", Group: "default", ChannelId: 7, Quota: 200, PromptTokens: 20, CompletionTokens: 10},
+		{UserId: 1, Username: "alice", Type: LogTypeConsume, CreatedAt: hour + 3700, ModelName: "gpt-4o", TokenName: "dev", Group: "vip", ChannelId: 7, Quota: 300, PromptTokens: 30, CompletionTokens: 15},
+		{UserId: 1, Username: "alice", Type: LogTypeTopup, CreatedAt: hour + 180, Quota: 5000},
+		{UserId: 2, Username: "bob", Type: LogTypeConsume, CreatedAt: hour + 240, ModelName: "claude-sonnet", TokenName: "prod", Group: "default", ChannelId: 7, Quota: 400, PromptTokens: 40, CompletionTokens: 20},
+	}
+	require.NoError(t, LOG_DB.Create(&logs).Error)
+}
+
+func TestStreamLogs(t *testing.T) {
+	seedExportLogs(t)
+
+	var userLogs []*Log
+	batches := 0
+	err := StreamLogs(LogExportFilter{UserId: 1}, 2, func(logs []*Log) error {
+		batches++
+		userLogs = append(userLogs, logs...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, batches)
+	require.Len(t, userLogs, 4)
+	for i, log := range userLogs {
+		assert.Equal(t, i+1, log.Id)
+		assert.Empty(t, log.ChannelName)
+	}
+	assert.NotContains(t, userLogs[0].Other, "admin_info")
+	assert.Contains(t, userLogs[0].Other, "cache_tokens")
+
+	var adminLogs []*Log
+	err = StreamLogs(LogExportFilter{LogType: LogTypeConsume, ModelName: "gpt%", TokenName: "prod"}, 100, func(logs []*Log) error {
+		adminLogs = append(adminLogs, logs...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, adminLogs, 2)
+	assert.Equal(t, "primary", adminLogs[0].ChannelName)
+	assert.Contains(t, adminLogs[0].Other, "admin_info")
+
+	assert.Error(t, (&LogExportFilter{ModelName: "%%"}).Validate())
+}
+
+func TestStreamUsageData(t *testing.T) {
+	seedExportLogs(t)
+
+	var rows []*QuotaData
+	err := StreamUsageData(LogExportFilter{Group: "default"}, func(data *QuotaData) error {
+		rows = append(rows, data)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	hour := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC).Unix()
+	assert.Equal(t, hour, rows[0].CreatedAt)
+	assert.Equal(t, "alice", rows[0].Username)
+	assert.Equal(t, 2, rows[0].Count)
+	assert.Equal(t, 300, rows[0].Quota)
+	assert.Equal(t, 45, rows[0].TokenUsed)
+	assert.Equal(t, "bob", rows[1].Username)
+
+	rows = nil
+	err = StreamUsageData(LogExportFilter{UserId: 1}, func(data *QuotaData) error {
+		rows = append(rows, data)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, hour+3600, rows[1].CreatedAt)
+	assert.Equal(t, 300, rows[1].Quota)
+}
+
+func TestClaimUsageReport(t *testing.T) {
+	t.Cleanup(func() { DB.Exec("DELETE FROM usage_report_deliveries") })
+
+	delivery, claimed, err := ClaimUsageReport(1, "weekly:2026-10-05")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	require.NoError(t, FinishUsageReport(delivery, nil))
+
+	_, claimed, err = ClaimUsageReport(1, "weekly:2026-10-05")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	_, claimed, err = ClaimUsageReport(2, "weekly:2026-10-05")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	var stored UsageReportDelivery
+	require.NoError(t, DB.Where("user_id = ?", 1).First(&stored).Error)
+	assert.True(t, stored.Success)
+	assert.NotZero(t, stored.CreatedAt)
+	assert.LessOrEqual(t, stored.CreatedAt, common.GetTimestamp())
+}
+
+func TestClaimUsageReportRetriesStaleFailure(t *testing.T) {
+	t.Cleanup(func() { DB.Exec("DELETE FROM usage_report_deliveries") })
+
+	const period = "weekly:2026-10-05"
+	expire := func() {
+		require.NoError(t, DB.Model(&UsageReportDelivery{}).Where("user_id = ?", 1).
+			Update("created_at", common.GetTimestamp()-UsageReportClaimTimeoutSeconds-1).Error)
+	}
+
+	delivery, claimed, err := ClaimUsageReport(1, period)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, FinishUsageReport(delivery, errors.New("smtp unavailable")))
+
+	// 未超时的失败记录不能立即重试
+	_, claimed, err = ClaimUsageReport(1, period)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// 超时后可以重新占用，直到达到最大尝试次数
+	for attempt := 2; attempt <= UsageReportMaxAttempts; attempt++ {
+		expire()
+		delivery, claimed, err = ClaimUsageReport(1, period)
+		require.NoError(t, err)
+		require.True(t, claimed)
+		assert.Equal(t, attempt, delivery.Attempts)
+	}
+	expire()
+	_, claimed, err = ClaimUsageReport(1, period)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// 发送成功后不再重试
+	require.NoError(t, DB.Model(&UsageReportDelivery{}).Where("user_id = ?", 1).Update("attempts", 1).Error)
+	expire()
+	delivery, claimed, err = ClaimUsageReport(1, period)
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, FinishUsageReport(delivery, nil))
+	expire()
+	_, claimed, err = ClaimUsageReport(1, period)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	var stored UsageReportDelivery
+	require.NoError(t, DB.Where("user_id = ?", 1).First(&stored).Error)
+	assert.True(t, stored.Success)
+	assert.Empty(t, stored.Error)
+}
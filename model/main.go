@@ -281,6 +281,19 @@ func migrateDB() error {
 		&CustomOAuthProvider{},
 		&UserOAuthBinding{},
 		&PerfMetric{},
+		&File{},
+		&Batch{},
+		&SemanticCacheEntry{},
+		&Organization{},
+		&OrganizationMember{},
+		&OrganizationInvitation{},
+		&Budget{},
+		&BudgetUsage{},
+		&TaskWebhookDelivery{},
+		&TaskAsset{},
+		&Invoice{},
+		&UsageReportDelivery{},
+		&ChannelKeyUsage{},
 	)
 	if err != nil {
 		return err
@@ -330,6 +343,19 @@ func migrateDBFast() error {
 		{&CustomOAuthProvider{}, "CustomOAuthProvider"},
 		{&UserOAuthBinding{}, "UserOAuthBinding"},
 		{&PerfMetric{}, "PerfMetric"},
+		{&File{}, "File"},
+		{&Batch{}, "Batch"},
+		{&SemanticCacheEntry{}, "SemanticCacheEntry"},
+		{&Organization{}, "Organization"},
+		{&OrganizationMember{}, "OrganizationMember"},
+		{&OrganizationInvitation{}, "OrganizationInvitation"},
+		{&Budget{}, "Budget"},
+		{&BudgetUsage{}, "BudgetUsage"},
+		{&TaskWebhookDelivery{}, "TaskWebhookDelivery"},
+		{&TaskAsset{}, "TaskAsset"},
+		{&Invoice{}, "Invoice"},
+		{&UsageReportDelivery{}, "UsageReportDelivery"},
+		{&ChannelKeyUsage{}, "ChannelKeyUsage"},
 	}
 	// 动态计算migration数量，确保errChan缓冲区足够大
 	errChan := make(chan error, len(migrations))
@@ -0,0 +1,494 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OrganizationStatusEnabled  = 1
+	OrganizationStatusDisabled = 2
+)
+
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+var (
+	ErrOrganizationDisabled          = errors.New("organization is disabled")
+	ErrOrganizationQuotaInsufficient = errors.New("organization quota insufficient")
+	ErrOrganizationMemberCapExceeded = errors.New("organization member spending cap exceeded")
+	ErrOrganizationMemberExists      = errors.New("user already belongs to an organization")
+	ErrOrganizationMemberNotFound    = errors.New("organization member not found")
+	ErrOrganizationInvitationMissing = errors.New("organization invitation not found")
+	ErrOrganizationOwnerCannotLeave  = errors.New("organization owner cannot leave the organization")
+)
+
+// Organization 组织，成员共享组织钱包额度
+type Organization struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);index"`
+	Status      int    `json:"status" gorm:"type:int;default:1"`
+	Quota       int    `json:"quota" gorm:"type:int;default:0"` // 共享钱包剩余额度
+	UsedQuota   int    `json:"used_quota" gorm:"type:int;default:0"`
+	Remark      string `json:"remark,omitempty" gorm:"type:varchar(255)"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	MemberCount int64  `json:"member_count" gorm:"-:all"` // only for api response
+}
+
+// OrganizationMember 组织成员，一个用户最多属于一个组织
+type OrganizationMember struct {
+	Id             int    `json:"id"`
+	OrganizationId int    `json:"organization_id" gorm:"index"`
+	UserId         int    `json:"user_id" gorm:"uniqueIndex"`
+	Role           string `json:"role" gorm:"type:varchar(16);default:'member'"`
+	// QuotaLimit 成员可从组织钱包消费的额度上限，0 表示不限制
+	QuotaLimit  int    `json:"quota_limit" gorm:"type:int;default:0"`
+	UsedQuota   int    `json:"used_quota" gorm:"type:int;default:0"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	Username    string `json:"username" gorm:"-:all"` // only for api response
+}
+
+// OrganizationInvitation 组织邀请，被邀请用户接受后才会成为组织成员
+type OrganizationInvitation struct {
+	Id               int    `json:"id"`
+	OrganizationId   int    `json:"organization_id" gorm:"index"`
+	UserId           int    `json:"user_id" gorm:"index"`
+	Role             string `json:"role" gorm:"type:varchar(16);default:'member'"`
+	QuotaLimit       int    `json:"quota_limit" gorm:"type:int;default:0"`
+	InviterId        int    `json:"inviter_id" gorm:"type:int;default:0"`
+	CreatedTime      int64  `json:"created_time" gorm:"bigint"`
+	OrganizationName string `json:"organization_name" gorm:"-:all"` // only for api response
+	Username         string `json:"username" gorm:"-:all"`          // only for api response
+}
+
+func IsValidOrganizationRole(role string) bool {
+	switch role {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	default:
+		return false
+	}
+}
+
+func fillOrganizationMemberCount(orgs []*Organization) error {
+	if len(orgs) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(orgs))
+	for _, org := range orgs {
+		ids = append(ids, org.Id)
+	}
+	var counts []struct {
+		OrganizationId int
+		Count          int64
+	}
+	err := DB.Model(&OrganizationMember{}).
+		Select("organization_id, count(*) as count").
+		Where("organization_id IN ?", ids).
+		Group("organization_id").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	countMap := make(map[int]int64, len(counts))
+	for _, count := range counts {
+		countMap[count.OrganizationId] = count.Count
+	}
+	for _, org := range orgs {
+		org.MemberCount = countMap[org.Id]
+	}
+	return nil
+}
+
+func GetAllOrganizations(keyword string, startIdx int, num int) (orgs []*Organization, total int64, err error) {
+	query := DB.Model(&Organization{})
+	if keyword != "" {
+		if id, convErr := strconv.Atoi(keyword); convErr == nil {
+			query = query.Where("id = ? OR name LIKE ?", id, keyword+"%")
+		} else {
+			query = query.Where("name LIKE ?", keyword+"%")
+		}
+	}
+	if err = query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err = query.Order("id desc").Limit(num).Offset(startIdx).Find(&orgs).Error; err != nil {
+		return nil, 0, err
+	}
+	if err = fillOrganizationMemberCount(orgs); err != nil {
+		return nil, 0, err
+	}
+	return orgs, total, nil
+}
+
+func GetOrganizationById(id int) (*Organization, error) {
+	if id == 0 {
+		return nil, errors.New("id 为空！")
+	}
+	var org Organization
+	if err := DB.First(&org, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// CreateOrganization 创建组织并将 ownerId 对应的用户设为所有者
+func CreateOrganization(org *Organization, ownerId int) error {
+	org.CreatedTime = common.GetTimestamp()
+	if org.Status == 0 {
+		org.Status = OrganizationStatusEnabled
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return addOrganizationMemberTx(tx, &OrganizationMember{
+			OrganizationId: org.Id,
+			UserId:         ownerId,
+			Role:           OrganizationRoleOwner,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	_ = invalidateUserCache(ownerId)
+	return nil
+}
+
+// Update 更新组织基本信息，钱包额度通过 IncreaseOrganizationQuota 等函数单独调整
+func (org *Organization) Update() error {
+	return DB.Model(org).Select("name", "status", "remark").Updates(org).Error
+}
+
+func IncreaseOrganizationQuota(id int, quota int) error {
+	if quota < 0 {
+		return errors.New("quota 不能为负数！")
+	}
+	return DB.Model(&Organization{}).Where("id = ?", id).Update("quota", gorm.Expr("quota + ?", quota)).Error
+}
+
+func DecreaseOrganizationQuota(id int, quota int) error {
+	if quota < 0 {
+		return errors.New("quota 不能为负数！")
+	}
+	return DB.Model(&Organization{}).Where("id = ?", id).Update("quota", gorm.Expr("quota - ?", quota)).Error
+}
+
+func SetOrganizationQuota(id int, quota int) error {
+	return DB.Model(&Organization{}).Where("id = ?", id).Update("quota", quota).Error
+}
+
+// DeleteOrganizationById 删除组织并解除所有成员关系，组织钱包中的剩余额度一并作废
+func DeleteOrganizationById(id int) error {
+	var userIds []int
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&OrganizationMember{}).Where("organization_id = ?", id).Pluck("user_id", &userIds).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&User{}).Where("organization_id = ?", id).Update("organization_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", id).Delete(&OrganizationInvitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Organization{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		_ = invalidateUserCache(userId)
+	}
+	return nil
+}
+
+func GetOrganizationMembers(orgId int) ([]*OrganizationMember, error) {
+	var members []*OrganizationMember
+	if err := DB.Where("organization_id = ?", orgId).Order("id asc").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return members, nil
+	}
+	userIds := make([]int, 0, len(members))
+	for _, member := range members {
+		userIds = append(userIds, member.UserId)
+	}
+	var users []struct {
+		Id       int
+		Username string
+	}
+	if err := DB.Model(&User{}).Select("id, username").Where("id IN ?", userIds).Scan(&users).Error; err != nil {
+		return nil, err
+	}
+	usernames := make(map[int]string, len(users))
+	for _, user := range users {
+		usernames[user.Id] = user.Username
+	}
+	for _, member := range members {
+		member.Username = usernames[member.UserId]
+	}
+	return members, nil
+}
+
+func GetOrganizationMemberByUserId(userId int) (*OrganizationMember, error) {
+	var member OrganizationMember
+	if err := DB.Where("user_id = ?", userId).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func addOrganizationMemberTx(tx *gorm.DB, member *OrganizationMember) error {
+	var user User
+	if err := tx.Select("id", "organization_id").First(&user, "id = ?", member.UserId).Error; err != nil {
+		return err
+	}
+	if user.OrganizationId != 0 {
+		return ErrOrganizationMemberExists
+	}
+	member.CreatedTime = common.GetTimestamp()
+	if member.Role == "" {
+		member.Role = OrganizationRoleMember
+	}
+	if err := tx.Create(member).Error; err != nil {
+		return err
+	}
+	return tx.Model(&User{}).Where("id = ?", member.UserId).Update("organization_id", member.OrganizationId).Error
+}
+
+func AddOrganizationMember(member *OrganizationMember) error {
+	if err := DB.Transaction(func(tx *gorm.DB) error {
+		return addOrganizationMemberTx(tx, member)
+	}); err != nil {
+		return err
+	}
+	_ = invalidateUserCache(member.UserId)
+	return nil
+}
+
+// CreateOrganizationInvitation 邀请用户加入组织，同一组织对同一用户的未处理邀请会被覆盖
+func CreateOrganizationInvitation(invitation *OrganizationInvitation) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var user User
+		if err := tx.Select("id", "organization_id").First(&user, "id = ?", invitation.UserId).Error; err != nil {
+			return err
+		}
+		if user.OrganizationId == invitation.OrganizationId {
+			return ErrOrganizationMemberExists
+		}
+		if err := tx.Where("organization_id = ? AND user_id = ?", invitation.OrganizationId, invitation.UserId).
+			Delete(&OrganizationInvitation{}).Error; err != nil {
+			return err
+		}
+		invitation.CreatedTime = common.GetTimestamp()
+		if invitation.Role == "" {
+			invitation.Role = OrganizationRoleMember
+		}
+		return tx.Create(invitation).Error
+	})
+}
+
+func GetOrganizationInvitationById(id int) (*OrganizationInvitation, error) {
+	var invitation OrganizationInvitation
+	if err := DB.First(&invitation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationInvitationMissing
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// GetOrganizationInvitations 获取组织发出的未处理邀请
+func GetOrganizationInvitations(orgId int) ([]*OrganizationInvitation, error) {
+	var invitations []*OrganizationInvitation
+	if err := DB.Where("organization_id = ?", orgId).Order("id desc").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	for _, invitation := range invitations {
+		invitation.Username, _ = GetUsernameById(invitation.UserId, false)
+	}
+	return invitations, nil
+}
+
+// GetUserOrganizationInvitations 获取用户收到的未处理邀请
+func GetUserOrganizationInvitations(userId int) ([]*OrganizationInvitation, error) {
+	var invitations []*OrganizationInvitation
+	if err := DB.Where("user_id = ?", userId).Order("id desc").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	for _, invitation := range invitations {
+		var org Organization
+		if err := DB.Select("id", "name").First(&org, "id = ?", invitation.OrganizationId).Error; err == nil {
+			invitation.OrganizationName = org.Name
+		}
+	}
+	return invitations, nil
+}
+
+// AcceptOrganizationInvitation 用户接受邀请并按邀请中的角色与消费上限加入组织
+func AcceptOrganizationInvitation(id int, userId int) (*OrganizationMember, error) {
+	var member *OrganizationMember
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var invitation OrganizationInvitation
+		if err := tx.Where("id = ? AND user_id = ?", id, userId).First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrganizationInvitationMissing
+			}
+			return err
+		}
+		var org Organization
+		if err := tx.First(&org, "id = ?", invitation.OrganizationId).Error; err != nil {
+			return err
+		}
+		if org.Status != OrganizationStatusEnabled {
+			return ErrOrganizationDisabled
+		}
+		member = &OrganizationMember{
+			OrganizationId: invitation.OrganizationId,
+			UserId:         userId,
+			Role:           invitation.Role,
+			QuotaLimit:     invitation.QuotaLimit,
+		}
+		if err := addOrganizationMemberTx(tx, member); err != nil {
+			return err
+		}
+		return tx.Delete(&invitation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = invalidateUserCache(userId)
+	return member, nil
+}
+
+func DeleteOrganizationInvitation(id int) error {
+	result := DB.Delete(&OrganizationInvitation{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrganizationInvitationMissing
+	}
+	return nil
+}
+
+// UpdateOrganizationMember 更新成员角色与消费上限，resetUsed 为 true 时清零成员已用额度
+func UpdateOrganizationMember(member *OrganizationMember, resetUsed bool) error {
+	updates := map[string]interface{}{
+		"role":        member.Role,
+		"quota_limit": member.QuotaLimit,
+	}
+	if resetUsed {
+		updates["used_quota"] = 0
+	}
+	result := DB.Model(&OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", member.OrganizationId, member.UserId).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrganizationMemberNotFound
+	}
+	return nil
+}
+
+func RemoveOrganizationMember(orgId int, userId int) error {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("organization_id = ? AND user_id = ?", orgId, userId).Delete(&OrganizationMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrganizationMemberNotFound
+		}
+		return tx.Model(&User{}).Where("id = ?", userId).Update("organization_id", 0).Error
+	})
+	if err != nil {
+		return err
+	}
+	_ = invalidateUserCache(userId)
+	return nil
+}
+
+// LeaveOrganization 成员主动退出组织，所有者需要先由系统管理员转移或删除组织
+func LeaveOrganization(userId int) error {
+	member, err := GetOrganizationMemberByUserId(userId)
+	if err != nil {
+		return err
+	}
+	if member.Role == OrganizationRoleOwner {
+		return ErrOrganizationOwnerCannotLeave
+	}
+	return RemoveOrganizationMember(member.OrganizationId, userId)
+}
+
+// PreConsumeOrganizationQuota 从组织钱包预扣额度，同时检查组织状态、余额以及成员消费上限
+func PreConsumeOrganizationQuota(orgId int, userId int, quota int) error {
+	if quota < 0 {
+		return errors.New("quota 不能为负数！")
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var member OrganizationMember
+		// 锁定成员与组织行，避免并发预扣超出成员上限或透支组织钱包
+		if err := lockForUpdate(tx).
+			Where("organization_id = ? AND user_id = ?", orgId, userId).
+			First(&member).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrganizationMemberNotFound
+			}
+			return err
+		}
+		if member.QuotaLimit > 0 && member.UsedQuota+quota > member.QuotaLimit {
+			return ErrOrganizationMemberCapExceeded
+		}
+		var org Organization
+		if err := lockForUpdate(tx).First(&org, "id = ?", orgId).Error; err != nil {
+			return err
+		}
+		if org.Status != OrganizationStatusEnabled {
+			return ErrOrganizationDisabled
+		}
+		if org.Quota < quota || org.Quota <= 0 {
+			return ErrOrganizationQuotaInsufficient
+		}
+		return adjustOrganizationQuotaTx(tx, orgId, userId, quota)
+	})
+}
+
+// AdjustOrganizationQuota 按差额调整组织钱包与成员已用额度（正数补扣，负数退还），不做余额与上限检查
+func AdjustOrganizationQuota(orgId int, userId int, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return adjustOrganizationQuotaTx(tx, orgId, userId, delta)
+	})
+}
+
+func adjustOrganizationQuotaTx(tx *gorm.DB, orgId int, userId int, delta int) error {
+	if err := tx.Model(&Organization{}).Where("id = ?", orgId).Updates(map[string]interface{}{
+		"quota":      gorm.Expr("quota - ?", delta),
+		"used_quota": gorm.Expr("used_quota + ?", delta),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", orgId, userId).
+		Update("used_quota", gorm.Expr("used_quota + ?", delta)).Error
+}
@@ -0,0 +1,61 @@
+package model
+
+// SemanticCacheEntry 语义缓存条目，向量索引在内存中维护，条目持久化到数据库以便重启和多实例间共享
+type SemanticCacheEntry struct {
+	Id int `json:"id" gorm:"primaryKey"`
+	// PartitionKey 分组、模型与除最后一条用户消息外的请求内容（非共享分组还包括用户）的哈希，仅在同一分区内比较相似度
+	PartitionKey string `json:"partition_key" gorm:"type:varchar(64);index"`
+	Group        string `json:"group" gorm:"column:group;size:64"`
+	ModelName    string `json:"model_name" gorm:"size:128"`
+	UserId       int    `json:"user_id" gorm:"index"`
+	// PromptHash 最后一条用户消息的 SHA-256，提示词原文不落库
+	PromptHash       string `json:"prompt_hash" gorm:"type:varchar(64)"`
+	Embedding        string `json:"-" gorm:"type:text"` // 归一化后的向量，float32 小端序 base64 编码
+	ContentType      string `json:"content_type" gorm:"size:128"`
+	Body             string `json:"-" gorm:"type:text"`
+	IsStream         bool   `json:"is_stream"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	Quota            int    `json:"quota"`
+	CreatedAt        int64  `json:"created_at" gorm:"bigint"`
+	ExpiresAt        int64  `json:"expires_at" gorm:"bigint;index"`
+}
+
+func (SemanticCacheEntry) TableName() string {
+	return "semantic_cache_entries"
+}
+
+func CreateSemanticCacheEntry(entry *SemanticCacheEntry) error {
+	return DB.Create(entry).Error
+}
+
+func GetSemanticCacheEntryById(id int) (*SemanticCacheEntry, error) {
+	var entry SemanticCacheEntry
+	if err := DB.First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetSemanticCacheEntriesAfter 按 id 顺序加载未过期的条目（不含响应内容），用于构建内存索引
+func GetSemanticCacheEntriesAfter(afterId int, now int64, limit int) ([]*SemanticCacheEntry, error) {
+	var entries []*SemanticCacheEntry
+	err := DB.Select("id", "partition_key", "embedding", "expires_at").
+		Where("id > ? AND expires_at > ?", afterId, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func DeleteSemanticCacheEntries(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return DB.Where("id IN ?", ids).Delete(&SemanticCacheEntry{}).Error
+}
+
+func DeleteExpiredSemanticCacheEntries(now int64) (int64, error) {
+	result := DB.Where("expires_at <= ?", now).Delete(&SemanticCacheEntry{})
+	return result.RowsAffected, result.Error
+}
@@ -101,10 +101,13 @@ type TaskPrivateData struct {
 	UpstreamTaskID string `json:"upstream_task_id,omitempty"` // 上游真实 task ID
 	ResultURL      string `json:"result_url,omitempty"`       // 任务成功后的结果 URL（视频地址等）
 	// 计费上下文：用于异步退款/差额结算（轮询阶段读取）
-	BillingSource  string              `json:"billing_source,omitempty"`  // "wallet" 或 "subscription"
+	BillingSource  string              `json:"billing_source,omitempty"`  // "wallet"、"subscription" 或 "organization"
 	SubscriptionId int                 `json:"subscription_id,omitempty"` // 订阅 ID，用于订阅退款
+	OrganizationId int                 `json:"organization_id,omitempty"` // 组织 ID，用于组织钱包退款与日志
 	TokenId        int                 `json:"token_id,omitempty"`        // 令牌 ID，用于令牌额度退款
 	BillingContext *TaskBillingContext `json:"billing_context,omitempty"` // 计费参数快照（用于轮询阶段重新计算）
+	CallbackURL    string              `json:"callback_url,omitempty"`    // 任务完成（成功/失败）时推送通知的地址
+	AssetId        string              `json:"asset_id,omitempty"`        // 结果已转存时对应的 TaskAsset.AssetId
 }
 
 // TaskBillingContext 记录任务提交时的计费参数，以便轮询阶段可以重新计算额度。
@@ -127,8 +130,11 @@ func (t *Task) GetUpstreamTaskID() string {
 }
 
 // GetResultURL 获取任务结果 URL（视频地址等）
-// 新数据存在 PrivateData.ResultURL 中；旧数据回退到 FailReason（历史兼容）
+// 结果已转存时每次重新签发下载地址；新数据存在 PrivateData.ResultURL 中；旧数据回退到 FailReason（历史兼容）
 func (t *Task) GetResultURL() string {
+	if t.PrivateData.AssetId != "" {
+		return BuildTaskAssetURL(t.PrivateData.AssetId)
+	}
 	if t.PrivateData.ResultURL != "" {
 		return t.PrivateData.ResultURL
 	}
@@ -289,6 +295,24 @@ func TaskGetAllTasks(startIdx int, num int, queryParams SyncTaskQueryParams) []*
 	return tasks
 }
 
+// GetUserVideoTasksByCursor 按主键游标分页查询用户的视频任务（排除 Suno），
+// afterId 为 0 时从第一条开始，asc 为 false 时按提交先后倒序
+func GetUserVideoTasksByCursor(userId int, afterId int64, limit int, asc bool) ([]*Task, error) {
+	var tasks []*Task
+	query := DB.Where("user_id = ? AND platform != ?", userId, constant.TaskPlatformSuno)
+	order := "id desc"
+	if asc {
+		order = "id asc"
+		if afterId > 0 {
+			query = query.Where("id > ?", afterId)
+		}
+	} else if afterId > 0 {
+		query = query.Where("id < ?", afterId)
+	}
+	err := query.Order(order).Limit(limit).Find(&tasks).Error
+	return tasks, err
+}
+
 func GetTimedOutUnfinishedTasks(cutoffUnix int64, limit int) []*Task {
 	var tasks []*Task
 	err := DB.Where("progress != ?", "100%").
@@ -314,6 +338,32 @@ func GetAllUnFinishSyncTasks(limit int) []*Task {
 	return tasks
 }
 
+// CountUnfinishedTasksByPlatform 按平台统计尚未完成、仍需轮询的任务数（含 Midjourney 任务），供 /metrics 抓取
+func CountUnfinishedTasksByPlatform() (map[string]int64, error) {
+	type result struct {
+		Platform string `gorm:"column:platform"`
+		Count    int64  `gorm:"column:count"`
+	}
+	var results []result
+	err := DB.Model(&Task{}).Select("platform, count(*) as count").
+		Where("progress != ?", "100%").
+		Where("status NOT IN ?", []string{TaskStatusFailure, TaskStatusSuccess}).
+		Group("platform").Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(results)+1)
+	for _, r := range results {
+		counts[r.Platform] = r.Count
+	}
+	var midjourneyCount int64
+	if err := DB.Model(&Midjourney{}).Where("progress != ?", "100%").Count(&midjourneyCount).Error; err != nil {
+		return nil, err
+	}
+	counts[string(constant.TaskPlatformMidjourney)] = midjourneyCount
+	return counts, nil
+}
+
 func GetByOnlyTaskId(taskId string) (*Task, bool, error) {
 	if taskId == "" {
 		return nil, false, nil
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/system_setting"
+)
+
+// TaskAsset 已转存的任务结果（视频、图片），上游地址过期后仍可通过签名地址下载
+type TaskAsset struct {
+	Id          int64  `json:"id" gorm:"primary_key;AUTO_INCREMENT"`
+	AssetId     string `json:"asset_id" gorm:"type:varchar(64);uniqueIndex"`
+	TaskId      string `json:"task_id" gorm:"type:varchar(191);index"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	StorageMode string `json:"storage_mode" gorm:"type:varchar(16)"` // 写入时的存储方式，读取时使用同一后端
+	StorageKey  string `json:"storage_key" gorm:"type:varchar(255)"`
+	ContentType string `json:"content_type" gorm:"type:varchar(128)"`
+	Bytes       int64  `json:"bytes"`
+	SourceUrl   string `json:"source_url" gorm:"type:text"` // 转存前的上游地址，清理后回填到任务
+	CreatedAt   int64  `json:"created_at"`
+	ExpiresAt   int64  `json:"expires_at" gorm:"index"` // 0 表示永久保留
+}
+
+func (a *TaskAsset) Insert() error {
+	return DB.Create(a).Error
+}
+
+func (a *TaskAsset) Delete() error {
+	return DB.Delete(a).Error
+}
+
+func GetTaskAssetByAssetId(assetId string) (*TaskAsset, error) {
+	var asset TaskAsset
+	err := DB.Where("asset_id = ?", assetId).First(&asset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// GetUserTaskAssetBytes 统计用户已占用的转存空间
+func GetUserTaskAssetBytes(userId int) (int64, error) {
+	var total int64
+	err := DB.Model(&TaskAsset{}).Where("user_id = ?", userId).Select("COALESCE(SUM(bytes), 0)").Scan(&total).Error
+	return total, err
+}
+
+// GetExpiredTaskAssets 获取已过期的转存结果
+func GetExpiredTaskAssets(now int64, limit int) ([]*TaskAsset, error) {
+	var assets []*TaskAsset
+	err := DB.Where("expires_at > 0 AND expires_at <= ?", now).Order("id").Limit(limit).Find(&assets).Error
+	return assets, err
+}
+
+// UpdateTaskPrivateData 只更新任务的 private_data，用于转存完成或清理后改写结果地址
+func (t *Task) UpdateTaskPrivateData() error {
+	return DB.Model(t).Select("private_data").Updates(t).Error
+}
+
+// signTaskAsset 对 asset ID 与过期时间签名，签名密钥为 CRYPTO_SECRET
+func signTaskAsset(assetId string, expires int64) string {
+	return common.GenerateHMAC(fmt.Sprintf("task_asset:%s:%d", assetId, expires))
+}
+
+// BuildTaskAssetURL 签发转存结果的下载地址，有效期由 asset_setting.signed_url_ttl_minutes 控制
+// e.g., "https://your-server.com/v1/assets/asset_xxxx?expires=1700000000&signature=..."
+func BuildTaskAssetURL(assetId string) string {
+	expires := time.Now().Unix() + operation_setting.GetAssetSetting().GetSignedURLTTLSeconds()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signTaskAsset(assetId, expires))
+	return fmt.Sprintf("%s/v1/assets/%s?%s", system_setting.ServerAddress, assetId, query.Encode())
+}
+
+// VerifyTaskAssetSignature 校验下载地址的签名与有效期
+func VerifyTaskAssetSignature(assetId string, expiresStr string, signature string) bool {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || expires < time.Now().Unix() {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signTaskAsset(assetId, expires)))
+}
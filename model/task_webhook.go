@@ -0,0 +1,26 @@
+package model
+
+// TaskWebhookDelivery 异步任务完成回调的投递记录，每次尝试一条
+type TaskWebhookDelivery struct {
+	Id         int64  `json:"id" gorm:"primary_key;AUTO_INCREMENT"`
+	TaskId     string `json:"task_id" gorm:"type:varchar(191);index"`
+	UserId     int    `json:"user_id" gorm:"index"`
+	Url        string `json:"url" gorm:"type:text"`
+	Status     string `json:"status" gorm:"type:varchar(20)"` // 推送时任务的状态（SUCCESS / FAILURE）
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error" gorm:"type:text"`
+	CreatedAt  int64  `json:"created_at" gorm:"index"`
+}
+
+func (d *TaskWebhookDelivery) Insert() error {
+	return DB.Create(d).Error
+}
+
+// GetTaskWebhookDeliveries 返回用户某个任务的全部投递记录，按尝试先后排序
+func GetTaskWebhookDeliveries(userId int, taskId string) ([]*TaskWebhookDelivery, error) {
+	var deliveries []*TaskWebhookDelivery
+	err := DB.Where("user_id = ? AND task_id = ?", userId, taskId).Order("id asc").Find(&deliveries).Error
+	return deliveries, err
+}
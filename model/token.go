@@ -12,23 +12,30 @@ import (
 )
 
 type Token struct {
-	Id                 int            `json:"id"`
-	UserId             int            `json:"user_id" gorm:"index"`
-	Key                string         `json:"key" gorm:"type:varchar(128);uniqueIndex"`
-	Status             int            `json:"status" gorm:"default:1"`
-	Name               string         `json:"name" gorm:"index" `
-	CreatedTime        int64          `json:"created_time" gorm:"bigint"`
-	AccessedTime       int64          `json:"accessed_time" gorm:"bigint"`
-	ExpiredTime        int64          `json:"expired_time" gorm:"bigint;default:-1"` // -1 means never expired
-	RemainQuota        int            `json:"remain_quota" gorm:"default:0"`
-	UnlimitedQuota     bool           `json:"unlimited_quota"`
-	ModelLimitsEnabled bool           `json:"model_limits_enabled"`
-	ModelLimits        string         `json:"model_limits" gorm:"type:text"`
-	AllowIps           *string        `json:"allow_ips" gorm:"default:''"`
-	UsedQuota          int            `json:"used_quota" gorm:"default:0"` // used quota
-	Group              string         `json:"group" gorm:"default:''"`
-	CrossGroupRetry    bool           `json:"cross_group_retry"` // 跨分组重试，仅auto分组有效
-	DeletedAt          gorm.DeletedAt `gorm:"index"`
+	Id                    int            `json:"id"`
+	UserId                int            `json:"user_id" gorm:"index"`
+	Key                   string         `json:"key" gorm:"type:varchar(128);uniqueIndex"`
+	Status                int            `json:"status" gorm:"default:1"`
+	Name                  string         `json:"name" gorm:"index" `
+	CreatedTime           int64          `json:"created_time" gorm:"bigint"`
+	AccessedTime          int64          `json:"accessed_time" gorm:"bigint"`
+	ExpiredTime           int64          `json:"expired_time" gorm:"bigint;default:-1"` // -1 means never expired
+	RemainQuota           int            `json:"remain_quota" gorm:"default:0"`
+	UnlimitedQuota        bool           `json:"unlimited_quota"`
+	ModelLimitsEnabled    bool           `json:"model_limits_enabled"`
+	ModelLimits           string         `json:"model_limits" gorm:"type:text"`
+	AllowIps              *string        `json:"allow_ips" gorm:"default:''"`
+	UsedQuota             int            `json:"used_quota" gorm:"default:0"` // used quota
+	Group                 string         `json:"group" gorm:"default:''"`
+	CrossGroupRetry       bool           `json:"cross_group_retry"`                      // 跨分组重试，仅auto分组有效
+	RpmLimit              int            `json:"rpm_limit" gorm:"default:0"`             // 每分钟请求数限制，0 表示不限制
+	TpmLimit              int            `json:"tpm_limit" gorm:"default:0"`             // 每分钟 token 数限制（按实际用量结算），0 表示不限制
+	ConcurrencyLimit      int            `json:"concurrency_limit" gorm:"default:0"`     // 最大并发请求数，0 表示不限制
+	SemanticCacheDisabled bool           `json:"semantic_cache_disabled"`                // 不使用语义缓存
+	ModelFallbacks        string         `json:"model_fallbacks" gorm:"type:text"`       // 模型降级链 JSON，按模型覆盖全局配置
+	TaskCallbackUrl       string         `json:"task_callback_url" gorm:"type:text"`     // 异步任务完成回调地址，请求未指定 callback_url 时使用
+	OrganizationId        int            `json:"organization_id" gorm:"default:0;index"` // 绑定的组织，非 0 时钱包计费从该组织的共享钱包扣除
+	DeletedAt             gorm.DeletedAt `gorm:"index"`
 }
 
 func (token *Token) Clean() {
@@ -295,7 +302,8 @@ func (token *Token) Update() (err error) {
 		}
 	}()
 	err = DB.Model(token).Select("name", "status", "expired_time", "remain_quota", "unlimited_quota",
-		"model_limits_enabled", "model_limits", "allow_ips", "group", "cross_group_retry").Updates(token).Error
+		"model_limits_enabled", "model_limits", "allow_ips", "group", "cross_group_retry",
+		"rpm_limit", "tpm_limit", "concurrency_limit", "semantic_cache_disabled", "model_fallbacks", "task_callback_url", "organization_id").Updates(token).Error
 	return err
 }
 
@@ -349,6 +357,18 @@ func (token *Token) GetModelLimitsMap() map[string]bool {
 	return limitsMap
 }
 
+// GetModelFallbacks 返回令牌配置的模型降级链，未配置或格式错误时返回 nil
+func (token *Token) GetModelFallbacks() map[string][]string {
+	if token.ModelFallbacks == "" {
+		return nil
+	}
+	chains, err := operation_setting.ParseModelFallbackChains(token.ModelFallbacks)
+	if err != nil || len(chains) == 0 {
+		return nil
+	}
+	return chains
+}
+
 func DisableModelLimits(tokenId int) error {
 	token, err := GetTokenById(tokenId)
 	if err != nil {
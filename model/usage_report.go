@@ -0,0 +1,81 @@
+package model
+
+import (
+	"github.com/QuantumNous/new-api/common"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// UsageReportClaimTimeoutSeconds 未成功的发送记录超过该时间后可以被重新占用（发送失败或节点在发送中途退出）
+	UsageReportClaimTimeoutSeconds = 3600
+	// UsageReportMaxAttempts 同一用户同一周期的最大发送尝试次数
+	UsageReportMaxAttempts = 3
+)
+
+// UsageReportDelivery 定期用量报告的发送记录，同一用户同一周期只发送一次
+type UsageReportDelivery struct {
+	Id        int    `json:"id"`
+	UserId    int    `json:"user_id" gorm:"uniqueIndex:idx_usage_report_user_period,priority:1"`
+	Period    string `json:"period" gorm:"type:varchar(32);uniqueIndex:idx_usage_report_user_period,priority:2"` // 例如 weekly:2026-10-05、monthly:2026-09
+	Success   bool   `json:"success"`
+	Error     string `json:"error" gorm:"type:text"`
+	Attempts  int    `json:"attempts" gorm:"default:0"`
+	CreatedAt int64  `json:"created_at" gorm:"index"` // 最近一次占用的时间
+}
+
+// ClaimUsageReport 占用用户某个周期的报告发送权，已发送过（或其他节点正在发送）时返回 false。
+// 未成功且占用已超过 UsageReportClaimTimeoutSeconds 的记录可以重新占用，最多尝试 UsageReportMaxAttempts 次
+func ClaimUsageReport(userId int, period string) (*UsageReportDelivery, bool, error) {
+	now := common.GetTimestamp()
+	delivery := &UsageReportDelivery{
+		UserId:    userId,
+		Period:    period,
+		Attempts:  1,
+		CreatedAt: now,
+	}
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return delivery, true, nil
+	}
+
+	// 条件更新保证多个节点同时重试时只有一个能占用成功
+	result = DB.Model(&UsageReportDelivery{}).
+		Where("user_id = ? AND period = ? AND success = ? AND created_at < ? AND attempts < ?",
+			userId, period, false, now-UsageReportClaimTimeoutSeconds, UsageReportMaxAttempts).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + ?", 1),
+			"created_at": now,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false, result.Error
+	}
+	delivery = &UsageReportDelivery{}
+	if err := DB.Where("user_id = ? AND period = ?", userId, period).First(delivery).Error; err != nil {
+		return nil, false, err
+	}
+	return delivery, true, nil
+}
+
+// FinishUsageReport 记录报告发送结果
+func FinishUsageReport(delivery *UsageReportDelivery, sendErr error) error {
+	delivery.Success = sendErr == nil
+	delivery.Error = ""
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	return DB.Model(delivery).Select("success", "error").Updates(delivery).Error
+}
+
+// GetUsageReportSubscribers 按 id 分页返回可能订阅了用量报告的启用用户，调用方需再检查设置中的频率
+func GetUsageReportSubscribers(afterId int, limit int) ([]*User, error) {
+	var users []*User
+	err := DB.Select("id", "username", "email", "setting").
+		Where("id > ? AND status = ? AND setting LIKE ?", afterId, common.UserStatusEnabled, "%usage_report_frequency%").
+		Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
@@ -125,11 +125,17 @@ func GetQuotaDataGroupByUser(startTime int64, endTime int64) (quotaData []*Quota
 	return quotaDatas, err
 }
 
-func GetAllQuotaDates(startTime int64, endTime int64, username string) (quotaData []*QuotaData, err error) {
+// GetAllQuotaDates 按模型汇总看板数据，organizationId 不为 0 时只统计该组织当前成员的数据
+func GetAllQuotaDates(startTime int64, endTime int64, username string, organizationId int) (quotaData []*QuotaData, err error) {
 	if username != "" {
 		return GetQuotaDataByUsername(username, startTime, endTime)
 	}
 	var quotaDatas []*QuotaData
+	if organizationId != 0 {
+		memberIds := DB.Model(&OrganizationMember{}).Select("user_id").Where("organization_id = ?", organizationId)
+		err = DB.Table("quota_data").Select("model_name, sum(count) as count, sum(quota) as quota, sum(token_used) as token_used, created_at").Where("created_at >= ? and created_at <= ? and user_id IN (?)", startTime, endTime, memberIds).Group("model_name, created_at").Find(&quotaDatas).Error
+		return quotaDatas, err
+	}
 	// 从quota_data表中查询数据
 	// only select model_name, sum(count) as count, sum(quota) as quota, model_name, created_at from quota_data group by model_name, created_at;
 	//err = DB.Table("quota_data").Where("created_at >= ? and created_at <= ?", startTime, endTime).Find(&quotaDatas).Error
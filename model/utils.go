@@ -9,6 +9,7 @@ import (
 
 	"github.com/bytedance/gopkg/util/gopool"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -110,3 +111,12 @@ func RecordExist(err error) (bool, error) {
 func shouldUpdateRedis(fromDB bool, err error) bool {
 	return common.RedisEnabled && fromDB && err == nil
 }
+
+// lockForUpdate 对事务中读取的行加写锁（SELECT ... FOR UPDATE）。
+// SQLite 不支持 FOR UPDATE，其写事务本身即为串行执行
+func lockForUpdate(tx *gorm.DB) *gorm.DB {
+	if common.UsingSQLite {
+		return tx
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
@@ -0,0 +1,139 @@
+package assetstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// unsignedPayload 请求体不参与签名，上传时无需预先计算整个文件的哈希
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config S3 兼容存储的连接配置
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyId     string
+	SecretAccessKey string
+	// PathStyle 为 true 时使用 endpoint/bucket/key，否则使用 bucket.endpoint/key
+	PathStyle bool
+}
+
+// S3 通过 SigV4 签名的 REST 请求访问 S3 兼容存储（AWS S3、MinIO、R2 等）
+type S3 struct {
+	config   S3Config
+	endpoint *url.URL
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+// NewS3 创建 S3 存储，client 为空时使用 http.DefaultClient
+func NewS3(config S3Config, client *http.Client) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(config.Endpoint), "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %q", config.Endpoint)
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	signer := v4.NewSigner(func(o *v4.SignerOptions) {
+		// S3 的路径不做二次转义
+		o.DisableURIPathEscaping = true
+	})
+	return &S3{config: config, endpoint: endpoint, signer: signer, client: client}, nil
+}
+
+func (s *S3) objectURL(key string) string {
+	u := *s.endpoint
+	if s.config.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	return u.String()
+}
+
+func (s *S3) do(ctx context.Context, method string, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	credentials := aws.Credentials{
+		AccessKeyID:     s.config.AccessKeyId,
+		SecretAccessKey: s.config.SecretAccessKey,
+	}
+	if err := s.signer.SignHTTP(ctx, credentials, req, unsignedPayload, "s3", s.config.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign s3 request: %w", err)
+	}
+	return s.client.Do(req)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError("put", resp)
+	}
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, responseError("get", resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return responseError("delete", resp)
+	}
+	return nil
+}
+
+func responseError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s failed: status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,35 @@
+package assetstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("asset not found")
+
+// Storage 任务结果的存储后端，key 由调用方生成，形如 "2026/10/asset_xxx.mp4"
+type Storage interface {
+	// Put 写入对象，size 为 r 的总字节数（S3 需要 Content-Length）
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 读取对象，调用方负责关闭；本地存储返回的 reader 同时实现 io.Seeker
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey 拒绝空 key、绝对路径与路径穿越
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("invalid asset key: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid asset key: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package assetstorage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 模拟 MinIO 的 path-style 对象接口，只接受带 SigV4 签名的请求
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=minio-ak/") ||
+		!strings.Contains(auth, "/us-east-1/s3/aws4_request") ||
+		r.Header.Get("X-Amz-Date") == "" ||
+		r.Header.Get("X-Amz-Content-Sha256") != unsignedPayload {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if int64(len(body)) != r.ContentLength {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		_, _ = w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	storage, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "assets",
+		AccessKeyId:     "minio-ak",
+		SecretAccessKey: "minio-sk",
+		PathStyle:       true,
+	}, server.Client())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	data := []byte("fake mp4 bytes")
+	require.NoError(t, storage.Put(ctx, "2026/10/asset_1.mp4", bytes.NewReader(data), int64(len(data)), "video/mp4"))
+	assert.Equal(t, data, fake.objects["/assets/2026/10/asset_1.mp4"])
+	assert.Equal(t, "video/mp4", fake.types["/assets/2026/10/asset_1.mp4"])
+
+	reader, err := storage.Open(ctx, "2026/10/asset_1.mp4")
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	require.NoError(t, storage.Delete(ctx, "2026/10/asset_1.mp4"))
+	_, err = storage.Open(ctx, "2026/10/asset_1.mp4")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3RejectedCredentials(t *testing.T) {
+	_, server := newFakeS3(t)
+	storage, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "assets",
+		AccessKeyId:     "other",
+		SecretAccessKey: "other",
+		PathStyle:       true,
+	}, server.Client())
+	require.NoError(t, err)
+
+	err = storage.Put(context.Background(), "a.mp4", strings.NewReader("x"), 1, "video/mp4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+}
+
+func TestS3VirtualHostedURL(t *testing.T) {
+	storage, err := NewS3(S3Config{Endpoint: "https://s3.example.com", Bucket: "assets"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://assets.s3.example.com/2026/a.mp4", storage.objectURL("2026/a.mp4"))
+
+	_, err = NewS3(S3Config{Endpoint: "s3.example.com", Bucket: "assets"}, nil)
+	assert.Error(t, err)
+}
+
+func TestLocalPutOpenDelete(t *testing.T) {
+	storage := NewLocal(t.TempDir())
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "2026/10/asset_1.png", strings.NewReader("png"), 3, "image/png"))
+	reader, err := storage.Open(ctx, "2026/10/asset_1.png")
+	require.NoError(t, err)
+	_, seekable := reader.(io.Seeker)
+	assert.True(t, seekable)
+	got, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "png", string(got))
+
+	require.NoError(t, storage.Delete(ctx, "2026/10/asset_1.png"))
+	require.NoError(t, storage.Delete(ctx, "2026/10/asset_1.png"))
+	_, err = storage.Open(ctx, "2026/10/asset_1.png")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCheckKeyRejectsTraversal(t *testing.T) {
+	for _, key := range []string{"", "/etc/passwd", "../a", "a/../../b", "a//b", `a\b`} {
+		assert.Error(t, checkKey(key), key)
+	}
+	assert.NoError(t, checkKey("2026/10/asset_1.mp4"))
+}
@@ -0,0 +1,321 @@
+package circuitbreaker
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+)
+
+// State 熔断器状态
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+)
+
+// ChannelLevel 作为 keyIndex 时表示渠道整体，而不是多 Key 渠道中的某一个 Key
+const ChannelLevel = -1
+
+const (
+	bucketCount = 10
+	// minHealthScore 闭合状态下健康分的下限，降级的渠道仍保留少量流量以便恢复后重新获得权重
+	minHealthScore = 0.05
+)
+
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeFailure
+)
+
+// Status 熔断器快照，用于管理接口展示
+type Status struct {
+	ChannelId    int     `json:"channel_id"`
+	KeyIndex     int     `json:"key_index"`
+	State        State   `json:"state"`
+	Score        float64 `json:"score"`
+	Requests     int     `json:"requests"`
+	Failures     int     `json:"failures"`
+	SlowCalls    int     `json:"slow_calls"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+	OpenedAt     int64   `json:"opened_at"`
+}
+
+type breakerKey struct {
+	channelId int
+	keyIndex  int
+}
+
+type bucket struct {
+	slot      int64
+	total     int
+	failures  int
+	slow      int
+	latencyMs int64
+}
+
+type windowStats struct {
+	total     int
+	failures  int
+	slow      int
+	latencyMs int64
+}
+
+type breaker struct {
+	mu             sync.Mutex
+	state          State
+	buckets        [bucketCount]bucket
+	openedAt       time.Time
+	nextProbeAt    time.Time
+	probeSuccesses int
+}
+
+var breakers sync.Map // breakerKey -> *breaker
+
+// now 便于测试替换
+var now = time.Now
+
+// Score 返回渠道的健康分：1 表示健康，(0, 1) 表示降级，0 表示熔断中或半开状态下暂不探测
+func Score(channelId int) float64 {
+	return score(breakerKey{channelId: channelId, keyIndex: ChannelLevel})
+}
+
+// KeyScore 返回多 Key 渠道中某个 Key 的健康分，含义同 Score
+func KeyScore(channelId int, keyIndex int) float64 {
+	return score(breakerKey{channelId: channelId, keyIndex: keyIndex})
+}
+
+// MarkSelected 在渠道（或 Key）被选中后调用，半开状态下据此限制探测频率
+func MarkSelected(channelId int, keyIndex int) {
+	value, ok := breakers.Load(breakerKey{channelId: channelId, keyIndex: keyIndex})
+	if !ok {
+		return
+	}
+	setting := operation_setting.GetCircuitBreakerSetting()
+	b := value.(*breaker)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := now()
+	b.advance(t, setting)
+	if b.state == StateHalfOpen {
+		b.nextProbeAt = t.Add(time.Duration(setting.ProbeIntervalSeconds) * time.Second)
+	}
+}
+
+// Record 记录一次上游调用结果，同时计入渠道整体与对应 Key（keyIndex >= 0 时）
+func Record(channelId int, keyIndex int, outcome Outcome, latency time.Duration) {
+	setting := operation_setting.GetCircuitBreakerSetting()
+	if !setting.Enabled {
+		return
+	}
+	getBreaker(breakerKey{channelId: channelId, keyIndex: ChannelLevel}).record(setting, outcome, latency)
+	if keyIndex >= 0 {
+		getBreaker(breakerKey{channelId: channelId, keyIndex: keyIndex}).record(setting, outcome, latency)
+	}
+}
+
+// Reset 清除渠道及其所有 Key 的熔断状态
+func Reset(channelId int) {
+	breakers.Range(func(k, _ any) bool {
+		if k.(breakerKey).channelId == channelId {
+			breakers.Delete(k)
+		}
+		return true
+	})
+}
+
+// Snapshot 返回所有已记录过请求的熔断器状态，按渠道与 Key 排序
+func Snapshot() []Status {
+	setting := operation_setting.GetCircuitBreakerSetting()
+	t := now()
+	statuses := make([]Status, 0)
+	breakers.Range(func(k, value any) bool {
+		key := k.(breakerKey)
+		b := value.(*breaker)
+		b.mu.Lock()
+		b.advance(t, setting)
+		stats := b.stats(t, setting)
+		status := Status{
+			ChannelId: key.channelId,
+			KeyIndex:  key.keyIndex,
+			State:     b.state,
+			Score:     b.score(t, setting),
+			Requests:  stats.total,
+			Failures:  stats.failures,
+			SlowCalls: stats.slow,
+		}
+		if stats.total > 0 {
+			status.AvgLatencyMs = stats.latencyMs / int64(stats.total)
+		}
+		if b.state != StateClosed {
+			status.OpenedAt = b.openedAt.Unix()
+		}
+		b.mu.Unlock()
+		statuses = append(statuses, status)
+		return true
+	})
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].ChannelId != statuses[j].ChannelId {
+			return statuses[i].ChannelId < statuses[j].ChannelId
+		}
+		return statuses[i].KeyIndex < statuses[j].KeyIndex
+	})
+	return statuses
+}
+
+// ScaleWeight 按健康分缩放选择权重，降级渠道的权重至少保留 1
+func ScaleWeight(weight int, score float64) int {
+	if score >= 1 || weight <= 0 {
+		return weight
+	}
+	scaled := int(math.Ceil(float64(weight) * score))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
+
+func score(key breakerKey) float64 {
+	setting := operation_setting.GetCircuitBreakerSetting()
+	if !setting.Enabled {
+		return 1
+	}
+	value, ok := breakers.Load(key)
+	if !ok {
+		return 1
+	}
+	b := value.(*breaker)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := now()
+	b.advance(t, setting)
+	return b.score(t, setting)
+}
+
+func getBreaker(key breakerKey) *breaker {
+	if value, ok := breakers.Load(key); ok {
+		return value.(*breaker)
+	}
+	value, _ := breakers.LoadOrStore(key, &breaker{state: StateClosed})
+	return value.(*breaker)
+}
+
+func slotSeconds(setting *operation_setting.CircuitBreakerSetting) int64 {
+	seconds := int64(setting.WindowSeconds) / bucketCount
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// advance 熔断时间到期后进入半开状态，允许立即探测
+func (b *breaker) advance(t time.Time, setting *operation_setting.CircuitBreakerSetting) {
+	if b.state != StateOpen {
+		return
+	}
+	if t.Before(b.openedAt.Add(time.Duration(setting.OpenSeconds) * time.Second)) {
+		return
+	}
+	b.state = StateHalfOpen
+	b.probeSuccesses = 0
+	b.nextProbeAt = t
+}
+
+func (b *breaker) score(t time.Time, setting *operation_setting.CircuitBreakerSetting) float64 {
+	switch b.state {
+	case StateOpen:
+		return 0
+	case StateHalfOpen:
+		if t.Before(b.nextProbeAt) {
+			return 0
+		}
+		return 1
+	}
+	stats := b.stats(t, setting)
+	if stats.total == 0 || stats.total < setting.MinRequests {
+		return 1
+	}
+	total := float64(stats.total)
+	s := 1 - float64(stats.failures)/total
+	if setting.SlowCallMs > 0 {
+		s *= 1 - float64(stats.slow)/total
+	}
+	return math.Max(s, minHealthScore)
+}
+
+func (b *breaker) stats(t time.Time, setting *operation_setting.CircuitBreakerSetting) windowStats {
+	current := t.Unix() / slotSeconds(setting)
+	var stats windowStats
+	for _, bk := range b.buckets {
+		if bk.slot <= current-bucketCount || bk.slot > current {
+			continue
+		}
+		stats.total += bk.total
+		stats.failures += bk.failures
+		stats.slow += bk.slow
+		stats.latencyMs += bk.latencyMs
+	}
+	return stats
+}
+
+func (b *breaker) record(setting *operation_setting.CircuitBreakerSetting, outcome Outcome, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := now()
+	b.advance(t, setting)
+
+	switch b.state {
+	case StateOpen:
+		// 熔断前已发出的请求，结果不再影响状态
+		return
+	case StateHalfOpen:
+		if outcome == OutcomeFailure {
+			b.trip(t)
+			return
+		}
+		b.probeSuccesses++
+		b.nextProbeAt = t
+		if b.probeSuccesses >= setting.HalfOpenSuccesses {
+			b.state = StateClosed
+			b.buckets = [bucketCount]bucket{}
+		}
+		return
+	}
+
+	slot := t.Unix() / slotSeconds(setting)
+	bk := &b.buckets[slot%bucketCount]
+	if bk.slot != slot {
+		*bk = bucket{slot: slot}
+	}
+	bk.total++
+	bk.latencyMs += latency.Milliseconds()
+	if outcome == OutcomeFailure {
+		bk.failures++
+	} else if setting.SlowCallMs > 0 && latency.Milliseconds() >= int64(setting.SlowCallMs) {
+		bk.slow++
+	}
+
+	stats := b.stats(t, setting)
+	if stats.total == 0 || stats.total < setting.MinRequests {
+		return
+	}
+	if setting.FailureRatePercent > 0 && stats.failures*100 >= setting.FailureRatePercent*stats.total {
+		b.trip(t)
+		return
+	}
+	if setting.SlowCallMs > 0 && setting.SlowCallRatePercent > 0 && stats.slow*100 >= setting.SlowCallRatePercent*stats.total {
+		b.trip(t)
+	}
+}
+
+func (b *breaker) trip(t time.Time) {
+	b.state = StateOpen
+	b.openedAt = t
+	b.probeSuccesses = 0
+}
@@ -0,0 +1,120 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBreakerTest(t *testing.T) *time.Time {
+	t.Helper()
+	setting := operation_setting.GetCircuitBreakerSetting()
+	oldSetting := *setting
+	oldNow := now
+	*setting = operation_setting.CircuitBreakerSetting{
+		Enabled:              true,
+		WindowSeconds:        60,
+		MinRequests:          4,
+		FailureRatePercent:   50,
+		SlowCallMs:           1000,
+		SlowCallRatePercent:  80,
+		OpenSeconds:          30,
+		ProbeIntervalSeconds: 5,
+		HalfOpenSuccesses:    2,
+	}
+	clock := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() {
+		*setting = oldSetting
+		now = oldNow
+		breakers.Range(func(k, _ any) bool {
+			breakers.Delete(k)
+			return true
+		})
+	})
+	return &clock
+}
+
+func TestBreakerTripsAndRecoversThroughHalfOpen(t *testing.T) {
+	clock := setupBreakerTest(t)
+
+	Record(1, ChannelLevel, OutcomeSuccess, 100*time.Millisecond)
+	Record(1, ChannelLevel, OutcomeFailure, 100*time.Millisecond)
+	Record(1, ChannelLevel, OutcomeSuccess, 100*time.Millisecond)
+	require.Equal(t, 1.0, Score(1), "below min requests should not be scored")
+
+	Record(1, ChannelLevel, OutcomeFailure, 100*time.Millisecond)
+	require.Equal(t, 0.0, Score(1))
+
+	*clock = clock.Add(31 * time.Second)
+	require.Equal(t, 1.0, Score(1), "half-open should allow a probe")
+	MarkSelected(1, ChannelLevel)
+	require.Equal(t, 0.0, Score(1), "only one probe per interval")
+
+	Record(1, ChannelLevel, OutcomeSuccess, 100*time.Millisecond)
+	require.Equal(t, 1.0, Score(1))
+	Record(1, ChannelLevel, OutcomeSuccess, 100*time.Millisecond)
+
+	statuses := Snapshot()
+	require.Len(t, statuses, 1)
+	require.Equal(t, StateClosed, statuses[0].State)
+	require.Equal(t, 0, statuses[0].Requests)
+}
+
+func TestBreakerReopensOnFailedProbe(t *testing.T) {
+	clock := setupBreakerTest(t)
+
+	for i := 0; i < 4; i++ {
+		Record(2, ChannelLevel, OutcomeFailure, 0)
+	}
+	*clock = clock.Add(31 * time.Second)
+	require.Equal(t, 1.0, Score(2))
+
+	Record(2, ChannelLevel, OutcomeFailure, 0)
+	require.Equal(t, 0.0, Score(2))
+	*clock = clock.Add(29 * time.Second)
+	require.Equal(t, 0.0, Score(2), "open duration restarts after a failed probe")
+}
+
+func TestBreakerScoresDegradedChannel(t *testing.T) {
+	setupBreakerTest(t)
+
+	for i := 0; i < 8; i++ {
+		Record(3, ChannelLevel, OutcomeSuccess, 100*time.Millisecond)
+	}
+	Record(3, ChannelLevel, OutcomeSuccess, 2*time.Second)
+	Record(3, ChannelLevel, OutcomeFailure, 0)
+
+	// 10% 失败、10% 慢请求
+	require.InDelta(t, 0.81, Score(3), 0.0001)
+	require.Equal(t, 81, ScaleWeight(100, Score(3)))
+	require.Equal(t, 1, ScaleWeight(1, 0.05))
+}
+
+func TestBreakerTracksKeysSeparately(t *testing.T) {
+	setupBreakerTest(t)
+
+	for i := 0; i < 4; i++ {
+		Record(4, 0, OutcomeFailure, 0)
+		Record(4, 1, OutcomeSuccess, 0)
+	}
+	require.Equal(t, 0.0, KeyScore(4, 0))
+	require.Equal(t, 1.0, KeyScore(4, 1))
+	require.Equal(t, 0.0, Score(4), "channel level aggregates all keys")
+
+	Reset(4)
+	require.Equal(t, 1.0, KeyScore(4, 0))
+	require.Empty(t, Snapshot())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	setupBreakerTest(t)
+	operation_setting.GetCircuitBreakerSetting().Enabled = false
+
+	for i := 0; i < 4; i++ {
+		Record(5, ChannelLevel, OutcomeFailure, 0)
+	}
+	require.Equal(t, 1.0, Score(5))
+}
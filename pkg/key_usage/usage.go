@@ -0,0 +1,168 @@
+package keyusage
+
+import (
+	"sync"
+	"time"
+)
+
+// window 单 Key RPM/TPM 统计的滑动窗口，按秒分槽
+const window = 60
+
+type keyId struct {
+	channelId int
+	keyIndex  int
+}
+
+type slot struct {
+	second   int64
+	requests int64
+	tokens   int64
+}
+
+type keyStat struct {
+	mu       sync.Mutex
+	slots    [window]slot
+	lastUsed int64
+	// 尚未合并到数据库的增量
+	pendingRequests int64
+	pendingTokens   int64
+}
+
+// Delta 自上次 Drain 以来某个 Key 的用量增量
+type Delta struct {
+	ChannelId int
+	KeyIndex  int
+	Requests  int64
+	Tokens    int64
+	LastUsed  int64
+}
+
+var stats sync.Map // keyId -> *keyStat
+
+// now 便于测试替换
+var now = time.Now
+
+// RecordRequest 记录 Key 被选中发起一次请求。
+// 计数仅保存在当前实例内存中，多实例部署时 RPM/TPM 上限按实例分别生效。
+func RecordRequest(channelId int, keyIndex int) {
+	stat := getStat(channelId, keyIndex)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	t := now().Unix()
+	stat.current(t).requests++
+	stat.lastUsed = t
+	stat.pendingRequests++
+}
+
+// RecordTokens 记录 Key 在请求结算时实际消耗的 token 数
+func RecordTokens(channelId int, keyIndex int, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	stat := getStat(channelId, keyIndex)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	stat.current(now().Unix()).tokens += int64(tokens)
+	stat.pendingTokens += int64(tokens)
+}
+
+// Minute 返回 Key 最近一分钟内的请求数与 token 数
+func Minute(channelId int, keyIndex int) (requests int64, tokens int64) {
+	value, ok := stats.Load(keyId{channelId: channelId, keyIndex: keyIndex})
+	if !ok {
+		return 0, 0
+	}
+	stat := value.(*keyStat)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	return stat.sum(now().Unix())
+}
+
+// LastUsed 返回 Key 在当前实例上最近一次被选中的时间戳，从未使用时返回 0
+func LastUsed(channelId int, keyIndex int) int64 {
+	value, ok := stats.Load(keyId{channelId: channelId, keyIndex: keyIndex})
+	if !ok {
+		return 0
+	}
+	stat := value.(*keyStat)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	return stat.lastUsed
+}
+
+// WithinLimits 判断 Key 最近一分钟的用量是否仍低于 RPM/TPM 上限，上限小于等于 0 表示不限制。
+// TPM 按实际用量事后累计，因此只要未达到上限即放行。
+func WithinLimits(channelId int, keyIndex int, rpmLimit int, tpmLimit int) bool {
+	if rpmLimit <= 0 && tpmLimit <= 0 {
+		return true
+	}
+	requests, tokens := Minute(channelId, keyIndex)
+	if rpmLimit > 0 && requests >= int64(rpmLimit) {
+		return false
+	}
+	if tpmLimit > 0 && tokens >= int64(tpmLimit) {
+		return false
+	}
+	return true
+}
+
+// Drain 取出并清空所有 Key 尚未落库的用量增量
+func Drain() []Delta {
+	deltas := make([]Delta, 0)
+	stats.Range(func(key, value interface{}) bool {
+		id := key.(keyId)
+		stat := value.(*keyStat)
+		stat.mu.Lock()
+		if stat.pendingRequests > 0 || stat.pendingTokens > 0 {
+			deltas = append(deltas, Delta{
+				ChannelId: id.channelId,
+				KeyIndex:  id.keyIndex,
+				Requests:  stat.pendingRequests,
+				Tokens:    stat.pendingTokens,
+				LastUsed:  stat.lastUsed,
+			})
+			stat.pendingRequests = 0
+			stat.pendingTokens = 0
+		}
+		stat.mu.Unlock()
+		return true
+	})
+	return deltas
+}
+
+// Reset 清除渠道下所有 Key 的内存计数，用于删除 Key 导致索引变化时
+func Reset(channelId int) {
+	stats.Range(func(key, value interface{}) bool {
+		if key.(keyId).channelId == channelId {
+			stats.Delete(key)
+		}
+		return true
+	})
+}
+
+func getStat(channelId int, keyIndex int) *keyStat {
+	id := keyId{channelId: channelId, keyIndex: keyIndex}
+	if value, ok := stats.Load(id); ok {
+		return value.(*keyStat)
+	}
+	value, _ := stats.LoadOrStore(id, &keyStat{})
+	return value.(*keyStat)
+}
+
+func (s *keyStat) current(second int64) *slot {
+	sl := &s.slots[second%window]
+	if sl.second != second {
+		*sl = slot{second: second}
+	}
+	return sl
+}
+
+func (s *keyStat) sum(second int64) (requests int64, tokens int64) {
+	for i := range s.slots {
+		if second-s.slots[i].second < window {
+			requests += s.slots[i].requests
+			tokens += s.slots[i].tokens
+		}
+	}
+	return requests, tokens
+}
@@ -0,0 +1,66 @@
+package keyusage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinuteWindowAndLimits(t *testing.T) {
+	current := time.Unix(1700000000, 0)
+	now = func() time.Time { return current }
+	t.Cleanup(func() {
+		now = time.Now
+		Reset(1)
+	})
+
+	require.True(t, WithinLimits(1, 0, 2, 100))
+	RecordRequest(1, 0)
+	RecordTokens(1, 0, 60)
+	current = current.Add(30 * time.Second)
+	RecordRequest(1, 0)
+	RecordTokens(1, 0, 30)
+
+	requests, tokens := Minute(1, 0)
+	require.Equal(t, int64(2), requests)
+	require.Equal(t, int64(90), tokens)
+	require.Equal(t, current.Unix(), LastUsed(1, 0))
+	require.False(t, WithinLimits(1, 0, 2, 0))
+	require.True(t, WithinLimits(1, 0, 3, 100))
+	require.False(t, WithinLimits(1, 0, 0, 90))
+	// 其他 Key 的计数互不影响
+	require.True(t, WithinLimits(1, 1, 1, 1))
+
+	// 第一个请求滑出窗口后释放额度
+	current = current.Add(31 * time.Second)
+	requests, tokens = Minute(1, 0)
+	require.Equal(t, int64(1), requests)
+	require.Equal(t, int64(30), tokens)
+	require.True(t, WithinLimits(1, 0, 2, 0))
+}
+
+func TestDrainReturnsPendingDeltasOnce(t *testing.T) {
+	t.Cleanup(func() { Reset(2) })
+	RecordRequest(2, 3)
+	RecordRequest(2, 3)
+	RecordTokens(2, 3, 10)
+
+	var found *Delta
+	for _, delta := range Drain() {
+		if delta.ChannelId == 2 && delta.KeyIndex == 3 {
+			found = &delta
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, int64(2), found.Requests)
+	require.Equal(t, int64(10), found.Tokens)
+	require.NotZero(t, found.LastUsed)
+
+	for _, delta := range Drain() {
+		require.False(t, delta.ChannelId == 2 && delta.KeyIndex == 3)
+	}
+	// Drain 不影响窗口内的计数
+	requests, _ := Minute(2, 3)
+	require.Equal(t, int64(2), requests)
+}
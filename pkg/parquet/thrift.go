@@ -0,0 +1,101 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol 的字段类型，Parquet 元数据只用到其中几种
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactEncoder 按 Thrift compact protocol 编码 Parquet 的页头与文件元数据，
+// 只实现写入所需的子集，避免引入完整的 thrift 依赖
+type compactEncoder struct {
+	buf       bytes.Buffer
+	lastField []int16
+}
+
+func newCompactEncoder() *compactEncoder {
+	return &compactEncoder{lastField: []int16{0}}
+}
+
+func (e *compactEncoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+func (e *compactEncoder) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf.Write(tmp[:n])
+}
+
+func (e *compactEncoder) zigzag(v int64) {
+	e.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *compactEncoder) fieldHeader(id int16, fieldType byte) {
+	last := &e.lastField[len(e.lastField)-1]
+	delta := id - *last
+	if delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		e.buf.WriteByte(fieldType)
+		e.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (e *compactEncoder) I32(id int16, v int32) {
+	e.fieldHeader(id, compactI32)
+	e.zigzag(int64(v))
+}
+
+func (e *compactEncoder) I64(id int16, v int64) {
+	e.fieldHeader(id, compactI64)
+	e.zigzag(v)
+}
+
+func (e *compactEncoder) String(id int16, v string) {
+	e.fieldHeader(id, compactBinary)
+	e.varint(uint64(len(v)))
+	e.buf.WriteString(v)
+}
+
+// ListBegin 写入 list 字段头，随后依次写入 size 个元素
+func (e *compactEncoder) ListBegin(id int16, elemType byte, size int) {
+	e.fieldHeader(id, compactList)
+	if size < 15 {
+		e.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		e.buf.WriteByte(0xf0 | elemType)
+		e.varint(uint64(size))
+	}
+}
+
+func (e *compactEncoder) ListI32(v int32) {
+	e.zigzag(int64(v))
+}
+
+func (e *compactEncoder) ListString(v string) {
+	e.varint(uint64(len(v)))
+	e.buf.WriteString(v)
+}
+
+// StructBegin 开始一个结构体字段；id 为 0 时表示 list 中的结构体元素，不写字段头
+func (e *compactEncoder) StructBegin(id int16) {
+	if id != 0 {
+		e.fieldHeader(id, compactStruct)
+	}
+	e.lastField = append(e.lastField, 0)
+}
+
+func (e *compactEncoder) StructEnd() {
+	e.buf.WriteByte(0)
+	e.lastField = e.lastField[:len(e.lastField)-1]
+}
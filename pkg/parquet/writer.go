@@ -0,0 +1,363 @@
+// Package parquet 提供一个只写的最小 Parquet 编码器：扁平 schema、全部列 REQUIRED、
+// PLAIN 编码并以 GZIP 压缩，目标是供 pandas/pyarrow、DuckDB、Spark 等直接读取。
+// 输出与 testdata/usage.parquet 逐字节比对，但该文件同样由本编码器生成；经 testdata/README.md
+// 中的独立实现校验之前，用量导出接口不开放 Parquet 格式（见 service.UsageExportContentType）。
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type 列的数据类型
+type Type int
+
+const (
+	Int64     Type = iota
+	Double         // float64
+	String         // UTF-8 字符串
+	Boolean        // bool
+	Timestamp      // int64 Unix 秒，写入为 TIMESTAMP_MILLIS
+)
+
+// Parquet 物理类型与相关枚举，取值见 parquet.thrift
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecGzip          = 2
+	pageTypeData       = 0
+)
+
+const (
+	magic = "PAR1"
+	// DefaultRowGroupSize 每个行组的行数，行组写出后即释放内存，便于流式导出大量数据
+	DefaultRowGroupSize = 10000
+)
+
+var ErrClosed = errors.New("parquet: writer is closed")
+
+// Column 列定义
+type Column struct {
+	Name string
+	Type Type
+}
+
+type columnChunk struct {
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+type rowGroup struct {
+	columns []columnChunk
+	numRows int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Writer 按行写入 Parquet 文件，每满 RowGroupSize 行写出一个行组，Close 时写入文件尾
+type Writer struct {
+	RowGroupSize int
+
+	out       *countingWriter
+	columns   []Column
+	buffers   []bytes.Buffer
+	bits      []byte // Boolean 列按位打包时当前未满的字节
+	rows      int
+	rowGroups []rowGroup
+	started   bool
+	closed    bool
+}
+
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		RowGroupSize: DefaultRowGroupSize,
+		out:          &countingWriter{w: w},
+		columns:      columns,
+		buffers:      make([]bytes.Buffer, len(columns)),
+		bits:         make([]byte, len(columns)),
+	}
+}
+
+// Write 写入一行，values 与列定义一一对应：
+// Int64/Timestamp 接受 int 或 int64，Double 接受 float64，String 接受 string，Boolean 接受 bool
+func (w *Writer) Write(values ...any) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("parquet: expected %d values, got %d", len(w.columns), len(values))
+	}
+	// 先校验整行再写入，避免类型错误的行残留部分列的值
+	for i, column := range w.columns {
+		if err := checkValue(column, values[i]); err != nil {
+			return err
+		}
+	}
+	for i, column := range w.columns {
+		w.appendValue(i, column, values[i])
+	}
+	w.rows++
+	if w.RowGroupSize > 0 && w.rows >= w.RowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+func checkValue(column Column, value any) error {
+	var ok bool
+	switch column.Type {
+	case Int64, Timestamp:
+		switch value.(type) {
+		case int, int64:
+			ok = true
+		}
+	case Double:
+		_, ok = value.(float64)
+	case String:
+		_, ok = value.(string)
+	case Boolean:
+		_, ok = value.(bool)
+	default:
+		return fmt.Errorf("parquet: unsupported type for column %s", column.Name)
+	}
+	if !ok {
+		return fmt.Errorf("parquet: invalid value %T for column %s", value, column.Name)
+	}
+	return nil
+}
+
+func (w *Writer) appendValue(i int, column Column, value any) {
+	buf := &w.buffers[i]
+	var tmp [8]byte
+	switch column.Type {
+	case Int64, Timestamp:
+		var v int64
+		if n, ok := value.(int); ok {
+			v = int64(n)
+		} else {
+			v = value.(int64)
+		}
+		if column.Type == Timestamp {
+			v *= 1000
+		}
+		binary.LittleEndian.PutUint64(tmp[:], uint64(v))
+		buf.Write(tmp[:])
+	case Double:
+		binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(value.(float64)))
+		buf.Write(tmp[:])
+	case String:
+		v := value.(string)
+		binary.LittleEndian.PutUint32(tmp[:4], uint32(len(v)))
+		buf.Write(tmp[:4])
+		buf.WriteString(v)
+	case Boolean:
+		// PLAIN 编码的布尔值按位打包，低位在前
+		bit := w.rows % 8
+		if value.(bool) {
+			w.bits[i] |= 1 << bit
+		}
+		if bit == 7 {
+			buf.WriteByte(w.bits[i])
+			w.bits[i] = 0
+		}
+	}
+}
+
+func (w *Writer) writeMagic() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	_, err := io.WriteString(w.out, magic)
+	return err
+}
+
+// Flush 将已缓冲的行写出为一个行组
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if err := w.writeMagic(); err != nil {
+		return err
+	}
+	if w.rows == 0 {
+		return nil
+	}
+	group := rowGroup{numRows: int64(w.rows), columns: make([]columnChunk, len(w.columns))}
+	for i, column := range w.columns {
+		if column.Type == Boolean && w.rows%8 != 0 {
+			w.buffers[i].WriteByte(w.bits[i])
+			w.bits[i] = 0
+		}
+		chunk, err := w.writeColumnChunk(w.buffers[i].Bytes(), w.rows)
+		if err != nil {
+			return err
+		}
+		group.columns[i] = chunk
+		w.buffers[i].Reset()
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows = 0
+	return nil
+}
+
+// writeColumnChunk 每个列块只包含一个数据页，REQUIRED 列无需写入定义/重复级别
+func (w *Writer) writeColumnChunk(data []byte, numValues int) (columnChunk, error) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(data); err != nil {
+		return columnChunk{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return columnChunk{}, err
+	}
+
+	header := newCompactEncoder()
+	header.StructBegin(0)
+	header.I32(1, pageTypeData)
+	header.I32(2, int32(len(data)))
+	header.I32(3, int32(compressed.Len()))
+	header.StructBegin(5)
+	header.I32(1, int32(numValues))
+	header.I32(2, encodingPlain)
+	header.I32(3, encodingRLE)
+	header.I32(4, encodingRLE)
+	header.StructEnd()
+	header.StructEnd()
+
+	chunk := columnChunk{
+		offset:           w.out.n,
+		numValues:        int64(numValues),
+		uncompressedSize: int64(len(header.Bytes()) + len(data)),
+		compressedSize:   int64(len(header.Bytes()) + compressed.Len()),
+	}
+	if _, err := w.out.Write(header.Bytes()); err != nil {
+		return columnChunk{}, err
+	}
+	if _, err := w.out.Write(compressed.Bytes()); err != nil {
+		return columnChunk{}, err
+	}
+	return chunk, nil
+}
+
+// Close 写出剩余的行并写入文件尾，不会关闭底层的 io.Writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	footer := w.encodeFileMetaData()
+	if _, err := w.out.Write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if _, err := w.out.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, magic)
+	return err
+}
+
+func (w *Writer) encodeFileMetaData() []byte {
+	var numRows int64
+	for _, group := range w.rowGroups {
+		numRows += group.numRows
+	}
+
+	e := newCompactEncoder()
+	e.StructBegin(0)
+	e.I32(1, 1)
+	e.ListBegin(2, compactStruct, len(w.columns)+1)
+	e.StructBegin(0)
+	e.String(4, "schema")
+	e.I32(5, int32(len(w.columns)))
+	e.StructEnd()
+	for _, column := range w.columns {
+		e.StructBegin(0)
+		e.I32(1, physicalType(column.Type))
+		e.I32(3, repetitionRequired)
+		e.String(4, column.Name)
+		switch column.Type {
+		case String:
+			e.I32(6, convertedUTF8)
+		case Timestamp:
+			e.I32(6, convertedTimestampMillis)
+		}
+		e.StructEnd()
+	}
+	e.I64(3, numRows)
+	e.ListBegin(4, compactStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		var totalSize int64
+		e.StructBegin(0)
+		e.ListBegin(1, compactStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			totalSize += chunk.uncompressedSize
+			e.StructBegin(0)
+			e.I64(2, chunk.offset)
+			e.StructBegin(3)
+			e.I32(1, physicalType(w.columns[i].Type))
+			e.ListBegin(2, compactI32, 2)
+			e.ListI32(encodingPlain)
+			e.ListI32(encodingRLE)
+			e.ListBegin(3, compactBinary, 1)
+			e.ListString(w.columns[i].Name)
+			e.I32(4, codecGzip)
+			e.I64(5, chunk.numValues)
+			e.I64(6, chunk.uncompressedSize)
+			e.I64(7, chunk.compressedSize)
+			e.I64(9, chunk.offset)
+			e.StructEnd()
+			e.StructEnd()
+		}
+		e.I64(2, totalSize)
+		e.I64(3, group.numRows)
+		e.StructEnd()
+	}
+	e.String(6, "new-api")
+	e.StructEnd()
+	return e.Bytes()
+}
+
+func physicalType(t Type) int32 {
+	switch t {
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	case Boolean:
+		return physicalBoolean
+	default:
+		return physicalInt64
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/routing"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
 	"github.com/QuantumNous/new-api/setting/perf_metrics_setting"
 )
@@ -42,6 +43,14 @@ func RecordRelaySample(info *relaycommon.RelayInfo, success bool, outputTokens i
 	if generationMs <= 0 {
 		generationMs = latencyMs
 	}
+	if success && info.ChannelMeta != nil {
+		// 供按延迟路由使用：流式请求取首字延迟，非流式取整体耗时
+		routingLatencyMs := latencyMs
+		if hasTtft {
+			routingLatencyMs = ttftMs
+		}
+		routing.ObserveLatency(info.ChannelId, info.OriginModelName, routingLatencyMs)
+	}
 	Record(Sample{
 		Model:        info.OriginModelName,
 		Group:        info.UsingGroup,
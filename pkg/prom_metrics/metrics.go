@@ -0,0 +1,131 @@
+package prommetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "new_api"
+
+// registry 独立于 prometheus 默认注册表，避免第三方依赖注册的指标混入 /metrics
+var registry = prometheus.NewRegistry()
+
+var relayLabels = []string{"model", "group", "channel"}
+
+var (
+	relayRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "relay_requests_total",
+		Help:      "Relay requests by final result (success or error).",
+	}, append(relayLabels, "status"))
+	relayErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "relay_errors_total",
+		Help:      "Failed relay requests by error code.",
+	}, append(relayLabels, "error_code"))
+	relayDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "relay_request_duration_seconds",
+		Help:      "Total latency of successful relay requests.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300, 600},
+	}, relayLabels)
+	relayTtft = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "relay_ttft_seconds",
+		Help:      "Time to first token of successful streaming relay requests.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2, 3, 5, 10, 20, 30, 60},
+	}, relayLabels)
+	relayTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "relay_tokens_total",
+		Help:      "Billed tokens by direction (input or output).",
+	}, append(relayLabels, "direction"))
+	quotaConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_consumed_total",
+		Help:      "Quota consumed by billed requests.",
+	}, relayLabels)
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		relayRequests,
+		relayErrors,
+		relayDuration,
+		relayTtft,
+		relayTokens,
+		quotaConsumed,
+	)
+}
+
+// Handler 返回输出全部指标的 HTTP handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+func channelLabel(channelId int) string {
+	if channelId <= 0 {
+		return ""
+	}
+	return strconv.Itoa(channelId)
+}
+
+func groupLabel(group string) string {
+	if group == "" {
+		return "default"
+	}
+	return group
+}
+
+// ObserveRelay 记录一次 relay 请求的最终结果，成功时同时记录总耗时与流式请求的首字耗时
+func ObserveRelay(info *relaycommon.RelayInfo, err *types.NewAPIError) {
+	if info == nil || info.OriginModelName == "" {
+		return
+	}
+	channelId := 0
+	if info.ChannelMeta != nil {
+		channelId = info.ChannelId
+	}
+	labels := []string{info.OriginModelName, groupLabel(info.UsingGroup), channelLabel(channelId)}
+	if err != nil {
+		relayRequests.WithLabelValues(append(labels, "error")...).Inc()
+		relayErrors.WithLabelValues(append(labels, string(err.GetErrorCode()))...).Inc()
+		return
+	}
+	relayRequests.WithLabelValues(append(labels, "success")...).Inc()
+	// Realtime 为长连接会话，耗时没有参考意义
+	if info.RelayFormat == types.RelayFormatOpenAIRealtime || info.StartTime.IsZero() {
+		return
+	}
+	relayDuration.WithLabelValues(labels...).Observe(time.Since(info.StartTime).Seconds())
+	if info.IsStream && info.HasSendResponse() {
+		relayTtft.WithLabelValues(labels...).Observe(info.FirstResponseTime.Sub(info.StartTime).Seconds())
+	}
+}
+
+// ObserveConsume 记录一次计费的 token 用量与消耗额度
+func ObserveConsume(modelName string, group string, channelId int, promptTokens int, completionTokens int, quota int) {
+	if modelName == "" {
+		return
+	}
+	labels := []string{modelName, groupLabel(group), channelLabel(channelId)}
+	if promptTokens > 0 {
+		relayTokens.WithLabelValues(append(labels, "input")...).Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		relayTokens.WithLabelValues(append(labels, "output")...).Add(float64(completionTokens))
+	}
+	if quota > 0 {
+		quotaConsumed.WithLabelValues(labels...).Add(float64(quota))
+	}
+}
@@ -0,0 +1,70 @@
+package prommetrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestObserveRelayAndConsume(t *testing.T) {
+	start := time.Now().Add(-2 * time.Second)
+	info := &relaycommon.RelayInfo{
+		OriginModelName: "metrics-test-model",
+		UsingGroup:      "vip",
+		StartTime:       start,
+		IsStream:        true,
+		ChannelMeta:     &relaycommon.ChannelMeta{ChannelId: 7},
+	}
+	info.FirstResponseTime = start.Add(500 * time.Millisecond)
+	ObserveRelay(info, nil)
+	ObserveRelay(info, types.NewError(errors.New("boom"), types.ErrorCodeBadResponseStatusCode))
+	ObserveConsume("metrics-test-model", "vip", 7, 100, 20, 360)
+
+	body := scrape(t)
+	labels := `channel="7",group="vip",model="metrics-test-model"`
+	require.Contains(t, body, `new_api_relay_requests_total{`+labels+`,status="success"} 1`)
+	require.Contains(t, body, `new_api_relay_requests_total{`+labels+`,status="error"} 1`)
+	require.Contains(t, body, `new_api_relay_errors_total{channel="7",error_code="bad_response_status_code",group="vip",model="metrics-test-model"} 1`)
+	require.Contains(t, body, `new_api_relay_ttft_seconds_count{`+labels+`} 1`)
+	require.Contains(t, body, `new_api_relay_request_duration_seconds_count{`+labels+`} 1`)
+	require.Contains(t, body, `new_api_relay_tokens_total{channel="7",direction="input",group="vip",model="metrics-test-model"} 100`)
+	require.Contains(t, body, `new_api_relay_tokens_total{channel="7",direction="output",group="vip",model="metrics-test-model"} 20`)
+	require.Contains(t, body, `new_api_quota_consumed_total{`+labels+`} 360`)
+}
+
+func TestStateCollector(t *testing.T) {
+	RegisterStateSources(StateSources{
+		Channels: func() ([]ChannelState, error) {
+			return []ChannelState{
+				{Id: 1, Name: "openai", Status: 1},
+				{Id: 2, Name: "multi", Status: 3, MultiKeySize: 4, DisabledKeys: 2},
+			}, nil
+		},
+		TaskBacklog: func() (map[string]int64, error) {
+			return map[string]int64{"suno": 3}, nil
+		},
+	})
+
+	body := scrape(t)
+	require.Contains(t, body, `new_api_channel_status{channel="2",channel_name="multi"} 3`)
+	require.Contains(t, body, `new_api_channel_multi_keys_disabled{channel="2",channel_name="multi"} 2`)
+	require.NotContains(t, body, `new_api_channel_multi_keys{channel="1"`)
+	require.Contains(t, body, `new_api_task_polling_backlog{platform="suno"} 3`)
+	require.Contains(t, body, `new_api_state_scrape_error{source="channels"} 0`)
+}
@@ -0,0 +1,157 @@
+package prommetrics
+
+import (
+	"database/sql"
+	"strconv"
+	"sync"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// ChannelState 抓取时的渠道状态
+type ChannelState struct {
+	Id           int
+	Name         string
+	Status       int
+	MultiKeySize int
+	DisabledKeys int
+}
+
+// StateSources 抓取时读取的数据来源，由启动流程注入，避免本包依赖 model
+type StateSources struct {
+	Channels func() ([]ChannelState, error)
+	// TaskBacklog 返回各平台尚未完成、仍需轮询的异步任务数
+	TaskBacklog func() (map[string]int64, error)
+}
+
+var (
+	channelStatusDesc = prometheus.NewDesc(namespace+"_channel_status",
+		"Channel status (1 enabled, 2 manually disabled, 3 auto disabled).",
+		[]string{"channel", "channel_name"}, nil)
+	channelKeysDesc = prometheus.NewDesc(namespace+"_channel_multi_keys",
+		"Number of keys of a multi-key channel.",
+		[]string{"channel", "channel_name"}, nil)
+	channelDisabledKeysDesc = prometheus.NewDesc(namespace+"_channel_multi_keys_disabled",
+		"Number of disabled keys of a multi-key channel.",
+		[]string{"channel", "channel_name"}, nil)
+	taskBacklogDesc = prometheus.NewDesc(namespace+"_task_polling_backlog",
+		"Unfinished async tasks waiting to be polled.",
+		[]string{"platform"}, nil)
+	stateScrapeErrorDesc = prometheus.NewDesc(namespace+"_state_scrape_error",
+		"Whether reading a state source failed during this scrape (1 failed).",
+		[]string{"source"}, nil)
+)
+
+type stateCollector struct {
+	sources StateSources
+}
+
+func (s *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- channelStatusDesc
+	ch <- channelKeysDesc
+	ch <- channelDisabledKeysDesc
+	ch <- taskBacklogDesc
+	ch <- stateScrapeErrorDesc
+}
+
+func (s *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	if s.sources.Channels != nil {
+		channels, err := s.sources.Channels()
+		ch <- scrapeErrorMetric("channels", err)
+		for _, channel := range channels {
+			id := strconv.Itoa(channel.Id)
+			ch <- prometheus.MustNewConstMetric(channelStatusDesc, prometheus.GaugeValue, float64(channel.Status), id, channel.Name)
+			if channel.MultiKeySize > 0 {
+				ch <- prometheus.MustNewConstMetric(channelKeysDesc, prometheus.GaugeValue, float64(channel.MultiKeySize), id, channel.Name)
+				ch <- prometheus.MustNewConstMetric(channelDisabledKeysDesc, prometheus.GaugeValue, float64(channel.DisabledKeys), id, channel.Name)
+			}
+		}
+	}
+	if s.sources.TaskBacklog != nil {
+		backlog, err := s.sources.TaskBacklog()
+		ch <- scrapeErrorMetric("tasks", err)
+		for platform, count := range backlog {
+			ch <- prometheus.MustNewConstMetric(taskBacklogDesc, prometheus.GaugeValue, float64(count), platform)
+		}
+	}
+}
+
+func scrapeErrorMetric(source string, err error) prometheus.Metric {
+	value := 0.0
+	if err != nil {
+		common.SysError("failed to collect " + source + " metrics: " + err.Error())
+		value = 1
+	}
+	return prometheus.MustNewConstMetric(stateScrapeErrorDesc, prometheus.GaugeValue, value, source)
+}
+
+var registerStateOnce sync.Once
+
+// RegisterStateSources 注册抓取时读取的渠道状态与任务积压来源，只生效一次
+func RegisterStateSources(sources StateSources) {
+	registerStateOnce.Do(func() {
+		registry.MustRegister(&stateCollector{sources: sources})
+	})
+}
+
+// RegisterDB 注册数据库连接池指标，name 用于区分主库与日志库
+func RegisterDB(name string, db *sql.DB) {
+	if db == nil {
+		return
+	}
+	if err := registry.Register(collectors.NewDBStatsCollector(db, name)); err != nil {
+		common.SysError("failed to register db metrics: " + err.Error())
+	}
+}
+
+var (
+	redisHitsDesc = prometheus.NewDesc(namespace+"_redis_pool_hits_total",
+		"Times a free connection was found in the Redis pool.", nil, nil)
+	redisMissesDesc = prometheus.NewDesc(namespace+"_redis_pool_misses_total",
+		"Times a free connection was not found in the Redis pool.", nil, nil)
+	redisTimeoutsDesc = prometheus.NewDesc(namespace+"_redis_pool_timeouts_total",
+		"Times a wait for a Redis pool connection timed out.", nil, nil)
+	redisTotalConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_total_connections",
+		"Total connections in the Redis pool.", nil, nil)
+	redisIdleConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_idle_connections",
+		"Idle connections in the Redis pool.", nil, nil)
+	redisStaleConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_stale_connections_total",
+		"Stale connections removed from the Redis pool.", nil, nil)
+)
+
+type redisPoolCollector struct {
+	client *redis.Client
+}
+
+func (r *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHitsDesc
+	ch <- redisMissesDesc
+	ch <- redisTimeoutsDesc
+	ch <- redisTotalConnsDesc
+	ch <- redisIdleConnsDesc
+	ch <- redisStaleConnsDesc
+}
+
+func (r *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := r.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeoutsDesc, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotalConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdleConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStaleConnsDesc, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// RegisterRedis 注册 Redis 连接池指标
+func RegisterRedis(client *redis.Client) {
+	if client == nil {
+		return
+	}
+	if err := registry.Register(&redisPoolCollector{client: client}); err != nil {
+		common.SysError("failed to register redis metrics: " + err.Error())
+	}
+}
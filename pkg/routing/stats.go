@@ -0,0 +1,97 @@
+package routing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencyAlpha 延迟指数滑动平均的平滑系数，越大越偏向最近的样本
+	latencyAlpha = 0.2
+	// latencyTTL 超过该时间未更新的延迟样本视为过期，渠道重新按未知延迟处理
+	latencyTTL = 10 * time.Minute
+)
+
+type latencyKey struct {
+	channelId int
+	modelName string
+}
+
+type latencyStat struct {
+	mu        sync.Mutex
+	ewmaMs    float64
+	updatedAt time.Time
+}
+
+var latencies sync.Map   // latencyKey -> *latencyStat
+var outstanding sync.Map // channelId -> *atomic.Int64
+
+// now 便于测试替换
+var now = time.Now
+
+// ObserveLatency 记录渠道在某个模型上的延迟样本（流式请求为首字延迟，非流式为整体耗时）
+func ObserveLatency(channelId int, modelName string, latencyMs int64) {
+	if channelId <= 0 || latencyMs < 0 {
+		return
+	}
+	key := latencyKey{channelId: channelId, modelName: modelName}
+	value, ok := latencies.Load(key)
+	if !ok {
+		value, _ = latencies.LoadOrStore(key, &latencyStat{})
+	}
+	stat := value.(*latencyStat)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	t := now()
+	if stat.updatedAt.IsZero() || t.Sub(stat.updatedAt) > latencyTTL {
+		stat.ewmaMs = float64(latencyMs)
+	} else {
+		stat.ewmaMs = latencyAlpha*float64(latencyMs) + (1-latencyAlpha)*stat.ewmaMs
+	}
+	stat.updatedAt = t
+}
+
+// Latency 返回渠道在某个模型上的平滑延迟（毫秒），没有有效样本时返回 false
+func Latency(channelId int, modelName string) (float64, bool) {
+	value, ok := latencies.Load(latencyKey{channelId: channelId, modelName: modelName})
+	if !ok {
+		return 0, false
+	}
+	stat := value.(*latencyStat)
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	if now().Sub(stat.updatedAt) > latencyTTL {
+		return 0, false
+	}
+	return stat.ewmaMs, true
+}
+
+// Acquire 渠道在途请求数加一，返回的函数用于在请求结束时减一
+func Acquire(channelId int) func() {
+	counter := outstandingCounter(channelId)
+	counter.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			counter.Add(-1)
+		})
+	}
+}
+
+// Outstanding 返回渠道当前的在途请求数
+func Outstanding(channelId int) int64 {
+	value, ok := outstanding.Load(channelId)
+	if !ok {
+		return 0
+	}
+	return value.(*atomic.Int64).Load()
+}
+
+func outstandingCounter(channelId int) *atomic.Int64 {
+	if value, ok := outstanding.Load(channelId); ok {
+		return value.(*atomic.Int64)
+	}
+	value, _ := outstanding.LoadOrStore(channelId, &atomic.Int64{})
+	return value.(*atomic.Int64)
+}
@@ -0,0 +1,50 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyEWMAAndExpiry(t *testing.T) {
+	current := time.Unix(1700000000, 0)
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = time.Now })
+
+	_, ok := Latency(1, "gpt-4o")
+	require.False(t, ok)
+
+	ObserveLatency(1, "gpt-4o", 1000)
+	ObserveLatency(1, "gpt-4o", 500)
+	latency, ok := Latency(1, "gpt-4o")
+	require.True(t, ok)
+	require.InDelta(t, 900, latency, 1e-9)
+
+	// 不同模型的样本互不影响
+	_, ok = Latency(1, "gpt-4o-mini")
+	require.False(t, ok)
+
+	current = current.Add(latencyTTL + time.Second)
+	_, ok = Latency(1, "gpt-4o")
+	require.False(t, ok)
+
+	// 过期后的新样本重新开始计算
+	ObserveLatency(1, "gpt-4o", 300)
+	latency, ok = Latency(1, "gpt-4o")
+	require.True(t, ok)
+	require.InDelta(t, 300, latency, 1e-9)
+}
+
+func TestOutstandingAcquireRelease(t *testing.T) {
+	require.Equal(t, int64(0), Outstanding(2))
+	releaseA := Acquire(2)
+	releaseB := Acquire(2)
+	require.Equal(t, int64(2), Outstanding(2))
+
+	releaseA()
+	releaseA()
+	require.Equal(t, int64(1), Outstanding(2))
+	releaseB()
+	require.Equal(t, int64(0), Outstanding(2))
+}
@@ -0,0 +1,187 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/QuantumNous/new-api"
+
+// tracer 通过全局 TracerProvider 创建 span，未调用 Init 或未配置导出器时为 no-op
+var tracer = otel.Tracer(tracerName)
+
+// Init 按环境变量初始化链路追踪：
+// OTEL_TRACES_EXPORTER=otlp 时通过 OTLP/HTTP 导出（地址、鉴权头等使用标准的 OTEL_EXPORTER_OTLP_* 变量），
+// 未设置或为 none 时不导出。无论是否导出，都会透传请求中的 traceparent 到上游。
+func Init() error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter := strings.ToLower(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")))
+	switch exporter {
+	case "", "none":
+		return nil
+	case "otlp":
+	default:
+		return fmt.Errorf("unsupported OTEL_TRACES_EXPORTER: %s, must be otlp or none", exporter)
+	}
+
+	spanExporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return err
+	}
+	// OTEL_SERVICE_NAME、OTEL_RESOURCE_ATTRIBUTES 优先于默认服务名
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(semconv.ServiceName("new-api")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return nil
+}
+
+// Start 以请求上下文中的 span 为父 span 创建子 span
+func Start(c *gin.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return StartContext(requestContext(c), name, attrs...)
+}
+
+// StartContext 以 ctx 中的 span 为父 span 创建子 span
+func StartContext(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误并将状态置为 Error
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func requestContext(c *gin.Context) context.Context {
+	if c == nil || c.Request == nil {
+		return context.Background()
+	}
+	return c.Request.Context()
+}
+
+// StartServerSpan 从请求头中提取 traceparent 作为父 span，为请求创建服务端 span 并写回请求上下文
+func StartServerSpan(c *gin.Context, route string) trace.Span {
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+		),
+	)
+	c.Request = c.Request.WithContext(ctx)
+	return span
+}
+
+// EndServerSpan 记录响应状态码与请求 ID 后结束服务端 span
+func EndServerSpan(c *gin.Context, span trace.Span) {
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if requestId := c.GetString(common.RequestIdKey); requestId != "" {
+		span.SetAttributes(attribute.String("new_api.request_id", requestId))
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+
+// Transport 将请求上下文中的 trace 信息写入上游请求头
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport 包装 base，使经过它的上游请求携带 traceparent
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if trace.SpanContextFromContext(req.Context()).IsValid() {
+		req = req.Clone(req.Context())
+		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// CloseIdleConnections 使 http.Client.CloseIdleConnections 对包装后的 Transport 仍然生效
+func (t *Transport) CloseIdleConnections() {
+	if closer, ok := t.Base.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// WithSpan 将 span 附加到 ctx，不改变 ctx 的取消与超时
+func WithSpan(ctx context.Context, span trace.Span) context.Context {
+	return trace.ContextWithSpan(ctx, span)
+}
+
+// TraceResponseBody 包装上游响应体：首次读到数据时在 span 上记录 first_token 事件（含相对 start 的首字耗时），
+// 响应体关闭时结束 span，使 span 覆盖完整的上游调用
+func TraceResponseBody(resp *http.Response, span trace.Span, start time.Time) {
+	if resp == nil || resp.Body == nil {
+		span.End()
+		return
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, span: span, start: start}
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	span      trace.Span
+	start     time.Time
+	firstRead bool
+	closeOnce sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.firstRead {
+		b.firstRead = true
+		b.span.AddEvent("first_token", trace.WithAttributes(
+			attribute.Int64("ttft_ms", time.Since(b.start).Milliseconds()),
+		))
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.closeOnce.Do(func() { b.span.End() })
+	return err
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// setupRecorder 全局 TracerProvider 只会被委托一次，各测试共用同一个 SpanRecorder
+func setupRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return recorder
+}
+
+func TestTransportPropagatesIncomingTraceparent(t *testing.T) {
+	setupRecorder()
+	var upstreamTraceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	t.Cleanup(server.Close)
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(incoming))
+	_, span := StartContext(ctx, "relay.upstream")
+	defer span.End()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req = req.WithContext(WithSpan(req.Context(), span))
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.True(t, strings.HasPrefix(upstreamTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	require.Contains(t, upstreamTraceparent, span.SpanContext().SpanID().String())
+	// 原请求头不应被修改
+	require.Empty(t, req.Header.Get("traceparent"))
+}
+
+func TestTraceResponseBodyRecordsFirstTokenAndEndsOnClose(t *testing.T) {
+	spans := setupRecorder()
+	_, span := StartContext(context.Background(), "relay.upstream.body")
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("data: hello\n\n"))}
+	TraceResponseBody(resp, span, time.Now())
+
+	_, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.NoError(t, resp.Body.Close())
+
+	var ended sdktrace.ReadOnlySpan
+	for _, s := range spans.Ended() {
+		if s.SpanContext().SpanID() == span.SpanContext().SpanID() {
+			ended = s
+		}
+	}
+	require.NotNil(t, ended)
+	require.Len(t, ended.Events(), 1)
+	require.Equal(t, "first_token", ended.Events()[0].Name)
+	require.Equal(t, trace.SpanKindInternal, ended.SpanKind())
+}
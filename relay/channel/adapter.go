@@ -78,6 +78,14 @@ type TaskAdaptor interface {
 	ParseTaskResult(respBody []byte) (*relaycommon.TaskInfo, error)
 }
 
+// TaskCanceler is implemented by task adaptors whose upstream can cancel a
+// queued or running task. A nil error means the upstream accepted the request.
+// Adaptors without an upstream cancel API must not implement it: cancelling
+// only locally would refund the quota while the upstream job keeps running.
+type TaskCanceler interface {
+	CancelTask(baseUrl, key string, taskID string, proxy string) error
+}
+
 type OpenAIVideoConverter interface {
 	ConvertToOpenAIVideo(originTask *model.Task) ([]byte, error)
 }
@@ -13,6 +13,7 @@ import (
 
 	common2 "github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/relay/common"
 	"github.com/QuantumNous/new-api/relay/constant"
 	"github.com/QuantumNous/new-api/relay/helper"
@@ -23,6 +24,7 @@ import (
 	"github.com/bytedance/gopkg/util/gopool"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func SetupApiRequestHeader(info *common.RelayInfo, c *gin.Context, req *http.Header) {
@@ -505,6 +507,22 @@ func doRequest(c *gin.Context, req *http.Request, info *common.RelayInfo) (*http
 		client = service.GetHttpClient()
 	}
 
+	// 对冲请求：上游连接随竞争结果取消，首字节到达前的输出暂不写入客户端
+	hedgeAttempt := common.GetHedgeAttempt(c)
+	if hedgeAttempt != nil {
+		req = hedgeAttempt.PrepareRequest(req)
+	}
+
+	// 上游调用 span 在响应体关闭时结束；仅附加 span 而不沿用客户端请求的上下文，避免客户端断开时取消上游请求
+	upstreamStart := time.Now()
+	_, span := tracing.Start(c, "relay.upstream",
+		attribute.Int("new_api.channel_id", info.ChannelId),
+		attribute.Int("new_api.channel_type", info.ChannelType),
+		attribute.String("new_api.upstream_model", info.UpstreamModelName),
+		attribute.String("server.address", req.URL.Host),
+	)
+	req = req.WithContext(tracing.WithSpan(req.Context(), span))
+
 	var stopPinger context.CancelFunc
 	if info.IsStream {
 		helper.SetEventStreamHeaders(c)
@@ -526,11 +544,14 @@ func doRequest(c *gin.Context, req *http.Request, info *common.RelayInfo) (*http
 	resp, err := client.Do(req)
 	if err != nil {
 		logger.LogError(c, "do request failed: "+err.Error())
+		tracing.End(span, err)
 		return nil, types.NewError(err, types.ErrorCodeDoRequestFailed, types.ErrOptionWithHideErrMsg("upstream error: do request failed"))
 	}
 	if resp == nil {
+		span.End()
 		return nil, errors.New("resp is nil")
 	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if upID := resp.Header.Get(common2.RequestIdKey); upID != "" {
 		c.Set(common2.UpstreamRequestIdKey, upID)
@@ -538,6 +559,14 @@ func doRequest(c *gin.Context, req *http.Request, info *common.RelayInfo) (*http
 
 	_ = req.Body.Close()
 	_ = c.Request.Body.Close()
+	if hedgeAttempt != nil {
+		resp, err = hedgeAttempt.AwaitFirstByte(resp)
+		if err != nil {
+			tracing.End(span, err)
+			return nil, types.NewError(err, types.ErrorCodeDoRequestFailed, types.ErrOptionWithHideErrMsg("upstream error: do request failed"))
+		}
+	}
+	tracing.TraceResponseBody(resp, span, upstreamStart)
 	return resp, nil
 }
 
@@ -259,6 +259,23 @@ func (a *TaskAdaptor) FetchTask(baseUrl, key string, body map[string]any, proxy
 	return client.Do(req)
 }
 
+// CancelTask cancels a queued task; the upstream rejects tasks that are already running.
+func (a *TaskAdaptor) CancelTask(baseUrl, key string, taskID string, proxy string) error {
+	uri := fmt.Sprintf("%s/api/v3/contents/generations/tasks/%s", baseUrl, taskID)
+	req, err := http.NewRequest(http.MethodDelete, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	client, err := service.GetHttpClientWithProxy(proxy)
+	if err != nil {
+		return fmt.Errorf("new proxy http client failed: %w", err)
+	}
+	return taskcommon.CheckCancelResponse(client.Do(req))
+}
+
 func (a *TaskAdaptor) GetModelList() []string {
 	return ModelList
 }
@@ -279,6 +279,21 @@ func (a *TaskAdaptor) FetchTask(baseUrl, key string, body map[string]any, proxy
 	return client.Do(req)
 }
 
+// CancelTask deletes the video job upstream, which also stops a queued or running job.
+func (a *TaskAdaptor) CancelTask(baseUrl, key string, taskID string, proxy string) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v1/videos/%s", baseUrl, taskID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	client, err := service.GetHttpClientWithProxy(proxy)
+	if err != nil {
+		return fmt.Errorf("new proxy http client failed: %w", err)
+	}
+	return taskcommon.CheckCancelResponse(client.Do(req))
+}
+
 func (a *TaskAdaptor) GetModelList() []string {
 	return ModelList
 }
@@ -212,6 +212,26 @@ func (a *TaskAdaptor) FetchTask(baseUrl, key string, body map[string]any, proxy
 	return client.Do(req)
 }
 
+func (a *TaskAdaptor) CancelTask(baseUrl, key string, taskID string, proxy string) error {
+	url := fmt.Sprintf("%s/ent/v2/tasks/%s/cancel", baseUrl, taskID)
+	body, err := common.Marshal(map[string]string{"id": taskID})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+key)
+
+	client, err := service.GetHttpClientWithProxy(proxy)
+	if err != nil {
+		return fmt.Errorf("new proxy http client failed: %w", err)
+	}
+	return taskcommon.CheckCancelResponse(client.Do(req))
+}
+
 func (a *TaskAdaptor) GetModelList() []string {
 	return []string{"viduq2", "viduq1", "vidu2.0", "vidu1.5"}
 }
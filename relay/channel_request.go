@@ -0,0 +1,109 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/middleware"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/relay/helper"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+const channelRequestResponseLimit = 8 << 20
+
+// DoChannelRequest 以指定渠道向上游发送一个内部请求（例如内容审核），返回上游响应体。
+// 请求在独立的上下文中经由渠道适配器发送：请求地址、鉴权、Azure 等渠道差异、代理以及渠道的模型重定向、
+// 请求头/参数覆盖均与普通中继一致；响应不写回客户端，也不计费。
+// path 决定中继模式（如 /v1/moderations），request 支持 *dto.GeneralOpenAIRequest 与 *dto.EmbeddingRequest。
+func DoChannelRequest(ctx context.Context, channel *model.Channel, path string, request dto.Request) ([]byte, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.Request = req
+	if requestId, ok := ctx.Value(common.RequestIdKey).(string); ok {
+		c.Set(common.RequestIdKey, requestId)
+	}
+
+	var modelName string
+	relayFormat := types.RelayFormatOpenAI
+	switch r := request.(type) {
+	case *dto.GeneralOpenAIRequest:
+		modelName = r.Model
+	case *dto.EmbeddingRequest:
+		modelName = r.Model
+		relayFormat = types.RelayFormatEmbedding
+	default:
+		return nil, fmt.Errorf("unsupported channel request type %T", request)
+	}
+	if apiErr := middleware.SetupContextForSelectedChannel(c, channel, modelName); apiErr != nil {
+		return nil, apiErr
+	}
+	if common.GetContextKeyString(c, constant.ContextKeyChannelBaseUrl) == "" && channel.Type < len(constant.ChannelBaseURLs) {
+		common.SetContextKey(c, constant.ContextKeyChannelBaseUrl, constant.ChannelBaseURLs[channel.Type])
+	}
+
+	info, err := relaycommon.GenRelayInfo(c, relayFormat, request, nil)
+	if err != nil {
+		return nil, err
+	}
+	info.InitChannelMeta(c)
+	if err := helper.ModelMappedHelper(c, info, request); err != nil {
+		return nil, err
+	}
+	adaptor := GetAdaptor(info.ApiType)
+	if adaptor == nil {
+		return nil, fmt.Errorf("invalid api type: %d", info.ApiType)
+	}
+	adaptor.Init(info)
+
+	var convertedRequest any
+	switch r := request.(type) {
+	case *dto.GeneralOpenAIRequest:
+		convertedRequest, err = adaptor.ConvertOpenAIRequest(c, info, r)
+	case *dto.EmbeddingRequest:
+		convertedRequest, err = adaptor.ConvertEmbeddingRequest(c, info, *r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	jsonData, err := common.Marshal(convertedRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.ParamOverride) > 0 {
+		jsonData, err = relaycommon.ApplyParamOverrideWithRelayInfo(jsonData, info)
+		if err != nil {
+			return nil, newAPIErrorFromParamOverride(err)
+		}
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(jsonData))
+	resp, err := adaptor.DoRequest(c, info, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpResp, ok := resp.(*http.Response)
+	if !ok || httpResp == nil {
+		return nil, fmt.Errorf("channel #%d returned no response", channel.Id)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, service.RelayErrorHandler(ctx, httpResp, true)
+	}
+	defer httpResp.Body.Close()
+	return io.ReadAll(io.LimitReader(httpResp.Body, channelRequestResponseLimit))
+}
@@ -0,0 +1,52 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoChannelRequest_ModerationUsesAdaptor(t *testing.T) {
+	if service.GetHttpClient() == nil {
+		service.InitHttpClient()
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Azure 渠道的请求地址、鉴权方式与请求头覆盖均由适配器处理
+		require.Equal(t, "/openai/deployments/omni-moderation/moderations", r.URL.Path)
+		require.Equal(t, "2024-10-21", r.URL.Query().Get("api-version"))
+		require.Equal(t, "sk-test", r.Header.Get("api-key"))
+		require.Equal(t, "moderation", r.Header.Get("X-Test"))
+		var req map[string]any
+		require.NoError(t, common.DecodeJson(r.Body, &req))
+		require.Equal(t, "omni-moderation", req["model"])
+		require.Equal(t, "hello", req["input"])
+		_, _ = w.Write([]byte(`{"results":[{"flagged":false}]}`))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL
+	modelMapping := `{"omni-moderation-latest":"omni-moderation"}`
+	headerOverride := `{"X-Test":"moderation"}`
+	channel := &model.Channel{
+		Id:             1,
+		Type:           constant.ChannelTypeAzure,
+		Key:            "sk-test",
+		BaseURL:        &baseURL,
+		Other:          "2024-10-21",
+		ModelMapping:   &modelMapping,
+		HeaderOverride: &headerOverride,
+	}
+
+	body, err := DoChannelRequest(context.Background(), channel, "/v1/moderations", &dto.GeneralOpenAIRequest{Model: "omni-moderation-latest", Input: "hello"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"results":[{"flagged":false}]}`, string(body))
+}
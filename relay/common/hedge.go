@@ -0,0 +1,378 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrHedgeLost 对冲请求中另一个渠道先返回了首字节，本次请求被放弃
+var ErrHedgeLost = errors.New("hedged request lost the race")
+
+// HedgeRace 协调同一请求在多个渠道上的竞争：先收到上游首字节的请求获胜并开始向客户端输出，其余请求被取消。
+// 获胜前各请求写入的内容（如 SSE 保活）暂存在各自的缓冲区中，获胜时一并输出。
+type HedgeRace struct {
+	mu        sync.Mutex
+	writer    gin.ResponseWriter
+	delay     time.Duration
+	billLoser bool
+	attempts  []*HedgeAttempt
+	winner    *HedgeAttempt
+	// chargedLoserQuota 获胜请求结算时一并扣除的未采用请求额度
+	chargedLoserQuota int
+}
+
+// HedgeAttempt 对冲竞争中的一次渠道请求
+type HedgeAttempt struct {
+	race       *HedgeRace
+	channelId  int
+	loserQuota int
+	ctx        context.Context
+	cancel     context.CancelFunc
+	writer     *hedgeWriter
+	gated      bool // 已进入上游请求，首字节到达前的写入只做缓冲
+	finished   bool
+	cancelled  bool // 因其他请求获胜而被取消
+}
+
+func NewHedgeRace(writer gin.ResponseWriter, delay time.Duration, billLoser bool) *HedgeRace {
+	return &HedgeRace{
+		writer:    writer,
+		delay:     delay,
+		billLoser: billLoser,
+	}
+}
+
+// Join 为渠道创建一次竞争请求，loserQuota 为该请求未被采用时的计费额度。竞争已决出胜者时返回 nil。
+func (r *HedgeRace) Join(parent context.Context, channelId int, loserQuota int) *HedgeAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.winner != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(parent)
+	attempt := &HedgeAttempt{
+		race:       r,
+		channelId:  channelId,
+		loserQuota: loserQuota,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	attempt.writer = &hedgeWriter{ResponseWriter: r.writer, attempt: attempt, header: http.Header{}}
+	r.attempts = append(r.attempts, attempt)
+	return attempt
+}
+
+// Winner 返回获胜的请求，尚未决出时返回 nil
+func (r *HedgeRace) Winner() *HedgeAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.winner
+}
+
+// Close 取消所有请求的上游连接，应在所有请求结束后调用
+func (r *HedgeRace) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, attempt := range r.attempts {
+		attempt.cancel()
+	}
+}
+
+// ChargedLoserQuota 返回获胜请求结算时一并扣除的未采用请求额度
+func (r *HedgeRace) ChargedLoserQuota() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.chargedLoserQuota
+}
+
+// LogInfo 返回写入日志的对冲信息，未发起对冲时返回 nil
+func (r *HedgeRace) LogInfo() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.attempts) < 2 {
+		return nil
+	}
+	info := map[string]interface{}{
+		"delay_ms":        r.delay.Milliseconds(),
+		"primary_channel": r.attempts[0].channelId,
+		"hedge_channel":   r.attempts[1].channelId,
+		"loser_billed":    r.billLoser,
+	}
+	if r.winner != nil {
+		info["winner_channel"] = r.winner.channelId
+	}
+	return info
+}
+
+func (a *HedgeAttempt) Race() *HedgeRace {
+	return a.race
+}
+
+func (a *HedgeAttempt) ChannelId() int {
+	return a.channelId
+}
+
+func (a *HedgeAttempt) LoserQuota() int {
+	return a.loserQuota
+}
+
+// Writer 返回该请求使用的响应写入器，获胜前的写入会被缓冲，落败后的写入会被丢弃
+func (a *HedgeAttempt) Writer() gin.ResponseWriter {
+	return a.writer
+}
+
+// Finish 标记请求已结束，之后其他请求获胜也不再视为被取消
+func (a *HedgeAttempt) Finish() {
+	a.race.mu.Lock()
+	defer a.race.mu.Unlock()
+	a.finished = true
+}
+
+// Cancelled 返回请求是否因其他请求获胜而被取消
+func (a *HedgeAttempt) Cancelled() bool {
+	a.race.mu.Lock()
+	defer a.race.mu.Unlock()
+	return a.cancelled
+}
+
+// Lost 返回是否已有其他请求获胜
+func (a *HedgeAttempt) Lost() bool {
+	a.race.mu.Lock()
+	defer a.race.mu.Unlock()
+	return a.lostLocked()
+}
+
+func (a *HedgeAttempt) lostLocked() bool {
+	return a.race.winner != nil && a.race.winner != a
+}
+
+func (a *HedgeAttempt) isGated() bool {
+	a.race.mu.Lock()
+	defer a.race.mu.Unlock()
+	return a.gated
+}
+
+// PrepareRequest 将上游请求绑定到该请求的生命周期，落败时上游连接随之取消
+func (a *HedgeAttempt) PrepareRequest(req *http.Request) *http.Request {
+	a.race.mu.Lock()
+	a.gated = true
+	a.race.mu.Unlock()
+	return req.WithContext(a.ctx)
+}
+
+// AwaitFirstByte 等待上游响应体的首字节并参与竞争。错误状态码的响应不参与竞争，交由后续流程处理。
+// 落败时关闭响应并返回 ErrHedgeLost。
+func (a *HedgeAttempt) AwaitFirstByte(resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, nil
+	}
+	reader := bufio.NewReader(resp.Body)
+	if _, err := reader.Peek(1); err != nil && !errors.Is(err, io.EOF) {
+		_ = resp.Body.Close()
+		if a.Lost() {
+			return nil, ErrHedgeLost
+		}
+		return nil, err
+	}
+	if !a.claim() {
+		_ = resp.Body.Close()
+		return nil, ErrHedgeLost
+	}
+	resp.Body = hedgeBody{Reader: reader, Closer: resp.Body}
+	return resp, nil
+}
+
+// WrapBilling 包装计费会话：落败的请求不结算，获胜的请求结算时一并扣除其他已发起请求的未采用额度
+func (a *HedgeAttempt) WrapBilling(billing BillingSettler) BillingSettler {
+	if billing == nil {
+		return nil
+	}
+	return &hedgeBilling{BillingSettler: billing, attempt: a}
+}
+
+func (a *HedgeAttempt) claim() bool {
+	r := a.race
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.winner != nil {
+		return r.winner == a
+	}
+	r.winner = a
+	a.writer.flushLocked()
+	for _, other := range r.attempts {
+		if other == a {
+			continue
+		}
+		if !other.finished {
+			other.cancelled = true
+		}
+		other.cancel()
+	}
+	return true
+}
+
+// GetHedgeAttempt 返回当前上下文所属的对冲请求，未参与对冲时返回 nil
+func GetHedgeAttempt(c *gin.Context) *HedgeAttempt {
+	value, ok := common.GetContextKey(c, constant.ContextKeyHedgeAttempt)
+	if !ok {
+		return nil
+	}
+	attempt, _ := value.(*HedgeAttempt)
+	return attempt
+}
+
+type hedgeBody struct {
+	io.Reader
+	io.Closer
+}
+
+type hedgeBilling struct {
+	BillingSettler
+	attempt *HedgeAttempt
+}
+
+func (b *hedgeBilling) Settle(actualQuota int) error {
+	r := b.attempt.race
+	r.mu.Lock()
+	if b.attempt.lostLocked() {
+		r.mu.Unlock()
+		return nil
+	}
+	loserQuota := 0
+	if r.billLoser {
+		for _, other := range r.attempts {
+			if other != b.attempt {
+				loserQuota += other.loserQuota
+			}
+		}
+	}
+	r.chargedLoserQuota = loserQuota
+	r.mu.Unlock()
+	return b.BillingSettler.Settle(actualQuota + loserQuota)
+}
+
+// hedgeWriter 竞争期间的响应写入器。获胜后直接写入客户端；未获胜时缓冲写入，
+// 未进入上游请求的适配器（不经过 AwaitFirstByte）以首次写入作为获胜条件。
+type hedgeWriter struct {
+	gin.ResponseWriter
+	attempt *HedgeAttempt
+	header  http.Header
+	status  int
+	buf     bytes.Buffer
+}
+
+func (w *hedgeWriter) Header() http.Header {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *hedgeWriter) WriteHeader(code int) {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	switch w.attempt.race.winner {
+	case w.attempt:
+		w.ResponseWriter.WriteHeader(code)
+	case nil:
+		w.status = code
+	}
+}
+
+func (w *hedgeWriter) WriteHeaderNow() {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *hedgeWriter) Write(data []byte) (int, error) {
+	if !w.attempt.isGated() && w.attempt.race.Winner() == nil {
+		w.attempt.claim()
+	}
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	switch w.attempt.race.winner {
+	case w.attempt:
+		return w.ResponseWriter.Write(data)
+	case nil:
+		return w.buf.Write(data)
+	default:
+		return len(data), nil
+	}
+}
+
+func (w *hedgeWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *hedgeWriter) Flush() {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *hedgeWriter) Status() int {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		return w.ResponseWriter.Status()
+	}
+	if w.status != 0 {
+		return w.status
+	}
+	return http.StatusOK
+}
+
+func (w *hedgeWriter) Size() int {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		return w.ResponseWriter.Size()
+	}
+	if w.buf.Len() == 0 {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *hedgeWriter) Written() bool {
+	w.attempt.race.mu.Lock()
+	defer w.attempt.race.mu.Unlock()
+	if w.attempt.race.winner == w.attempt {
+		return w.ResponseWriter.Written()
+	}
+	return w.buf.Len() > 0
+}
+
+// flushLocked 获胜时输出缓冲的响应头与内容，调用方需持有竞争锁
+func (w *hedgeWriter) flushLocked() {
+	header := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+		w.ResponseWriter.Flush()
+	}
+}
@@ -0,0 +1,174 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBilling struct {
+	BillingSettler
+	settled []int
+}
+
+func (b *fakeBilling) Settle(actualQuota int) error {
+	b.settled = append(b.settled, actualQuota)
+	return nil
+}
+
+func newHedgeTestRace(t *testing.T, billLoser bool) (*HedgeRace, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	return NewHedgeRace(c.Writer, 500*time.Millisecond, billLoser), recorder
+}
+
+func newHedgeTestResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestHedgeRace_FirstByteWinsAndFlushesBufferedOutput(t *testing.T) {
+	race, recorder := newHedgeTestRace(t, false)
+	primary := race.Join(context.Background(), 1, 0)
+	hedge := race.Join(context.Background(), 2, 0)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	primary.PrepareRequest(req)
+	hedgeReq := hedge.PrepareRequest(req)
+
+	primary.Writer().Header().Set("Content-Type", "text/event-stream")
+	_, err := primary.Writer().WriteString(": PING\n\n")
+	require.NoError(t, err)
+	hedge.Writer().Header().Set("X-Hedge", "1")
+	_, err = hedge.Writer().WriteString(": PING FROM HEDGE\n\n")
+	require.NoError(t, err)
+	require.Empty(t, recorder.Body.String())
+
+	resp, err := hedge.AwaitFirstByte(newHedgeTestResponse(http.StatusOK, "data: hello\n\n"))
+	require.NoError(t, err)
+	require.Equal(t, hedge, race.Winner())
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "data: hello\n\n", string(body))
+	require.Equal(t, ": PING FROM HEDGE\n\n", recorder.Body.String())
+	require.Equal(t, "1", recorder.Header().Get("X-Hedge"))
+	require.Empty(t, recorder.Header().Get("Content-Type"))
+	require.NoError(t, hedgeReq.Context().Err())
+
+	require.True(t, primary.Lost())
+	require.True(t, primary.Cancelled())
+	_, err = primary.AwaitFirstByte(newHedgeTestResponse(http.StatusOK, "data: late\n\n"))
+	require.ErrorIs(t, err, ErrHedgeLost)
+	_, err = primary.Writer().WriteString("data: late\n\n")
+	require.NoError(t, err)
+	require.NotContains(t, recorder.Body.String(), "late")
+
+	_, err = hedge.Writer().WriteString("data: done\n\n")
+	require.NoError(t, err)
+	require.Contains(t, recorder.Body.String(), "data: done")
+	require.Nil(t, race.Join(context.Background(), 3, 0))
+}
+
+func TestHedgeRace_ErrorResponseDoesNotClaim(t *testing.T) {
+	race, _ := newHedgeTestRace(t, false)
+	primary := race.Join(context.Background(), 1, 0)
+	primary.PrepareRequest(httptest.NewRequest(http.MethodPost, "/", nil))
+
+	resp, err := primary.AwaitFirstByte(newHedgeTestResponse(http.StatusTooManyRequests, "rate limited"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Nil(t, race.Winner())
+}
+
+func TestHedgeRace_FinishedAttemptIsNotCancelled(t *testing.T) {
+	race, _ := newHedgeTestRace(t, false)
+	primary := race.Join(context.Background(), 1, 0)
+	hedge := race.Join(context.Background(), 2, 0)
+	hedge.PrepareRequest(httptest.NewRequest(http.MethodPost, "/", nil))
+
+	primary.Finish()
+	_, err := hedge.AwaitFirstByte(newHedgeTestResponse(http.StatusOK, "{}"))
+	require.NoError(t, err)
+	require.False(t, primary.Cancelled())
+}
+
+func TestHedgeRace_UngatedWriteClaims(t *testing.T) {
+	race, recorder := newHedgeTestRace(t, false)
+	primary := race.Join(context.Background(), 1, 0)
+	race.Join(context.Background(), 2, 0)
+
+	_, err := primary.Writer().Write([]byte("{}"))
+	require.NoError(t, err)
+	require.Equal(t, primary, race.Winner())
+	require.Equal(t, "{}", recorder.Body.String())
+}
+
+func TestHedgeRace_BillingChargesOnlyWinner(t *testing.T) {
+	for _, billLoser := range []bool{false, true} {
+		race, _ := newHedgeTestRace(t, billLoser)
+		billing := &fakeBilling{}
+		primary := race.Join(context.Background(), 1, 30)
+		hedge := race.Join(context.Background(), 2, 40)
+		hedge.PrepareRequest(httptest.NewRequest(http.MethodPost, "/", nil))
+		_, err := hedge.AwaitFirstByte(newHedgeTestResponse(http.StatusOK, "{}"))
+		require.NoError(t, err)
+
+		require.NoError(t, primary.WrapBilling(billing).Settle(100))
+		require.NoError(t, hedge.WrapBilling(billing).Settle(100))
+		if billLoser {
+			require.Equal(t, []int{130}, billing.settled)
+			require.Equal(t, 30, race.ChargedLoserQuota())
+		} else {
+			require.Equal(t, []int{100}, billing.settled)
+			require.Zero(t, race.ChargedLoserQuota())
+		}
+	}
+}
+
+func TestHedgeRace_LogInfo(t *testing.T) {
+	race, _ := newHedgeTestRace(t, true)
+	primary := race.Join(context.Background(), 1, 0)
+	require.Nil(t, race.LogInfo())
+
+	race.Join(context.Background(), 2, 0)
+	primary.PrepareRequest(httptest.NewRequest(http.MethodPost, "/", nil))
+	_, err := primary.AwaitFirstByte(newHedgeTestResponse(http.StatusOK, "{}"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"delay_ms":        int64(500),
+		"primary_channel": 1,
+		"hedge_channel":   2,
+		"winner_channel":  1,
+		"loser_billed":    true,
+	}, race.LogInfo())
+}
+
+func TestRelayInfoClone_CopiesMutableFields(t *testing.T) {
+	info := &RelayInfo{
+		ParamOverrideAudit: []string{"a"},
+		StreamStatus:       NewStreamStatus(),
+		ChannelMeta:        &ChannelMeta{ChannelId: 1},
+		ClaudeConvertInfo:  &ClaudeConvertInfo{Index: 1},
+		ResponsesUsageInfo: &ResponsesUsageInfo{BuiltInTools: map[string]*BuildInToolInfo{
+			"web_search": {ToolName: "web_search"},
+		}},
+	}
+	clone := info.Clone()
+	clone.ParamOverrideAudit[0] = "b"
+	clone.ChannelMeta.ChannelId = 2
+	clone.ClaudeConvertInfo.Index = 2
+	clone.ResponsesUsageInfo.BuiltInTools["web_search"].CallCount = 1
+
+	require.Nil(t, clone.StreamStatus)
+	require.Equal(t, "a", info.ParamOverrideAudit[0])
+	require.Equal(t, 1, info.ChannelMeta.ChannelId)
+	require.Equal(t, 1, info.ClaudeConvertInfo.Index)
+	require.Zero(t, info.ResponsesUsageInfo.BuiltInTools["web_search"].CallCount)
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	"github.com/QuantumNous/new-api/types"
 	"github.com/samber/lo"
 	"github.com/tidwall/gjson"
@@ -188,7 +190,13 @@ func ApplyParamOverrideWithRelayInfo(jsonData []byte, info *RelayInfo) ([]byte,
 		recorder = &paramOverrideAuditRecorder{}
 		overrideCtx[paramOverrideContextAuditRecorder] = recorder
 	}
+	var traceCtx context.Context
+	if info != nil {
+		traceCtx = info.TraceContext
+	}
+	_, span := tracing.StartContext(traceCtx, "relay.param_override")
 	result, err := ApplyParamOverride(jsonData, paramOverride, overrideCtx)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
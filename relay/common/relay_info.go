@@ -1,9 +1,12 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -129,8 +132,10 @@ type RelayInfo struct {
 	// Billing 是计费会话，封装了预扣费/结算/退款的统一生命周期。
 	// 免费模型时为 nil。
 	Billing BillingSettler
-	// BillingSource indicates whether this request is billed from wallet quota or subscription.
-	// "" or "wallet" => wallet; "subscription" => subscription
+	// OrganizationId 令牌绑定的组织，0 表示使用个人钱包；非 0 时钱包计费从组织共享钱包扣除
+	OrganizationId int
+	// BillingSource indicates whether this request is billed from wallet quota, subscription or organization wallet.
+	// "" or "wallet" => wallet; "subscription" => subscription; "organization" => organization wallet
 	BillingSource string
 	// SubscriptionId is the user_subscriptions.id used when BillingSource == "subscription"
 	SubscriptionId int
@@ -153,6 +158,8 @@ type RelayInfo struct {
 	RuntimeHeadersOverride                map[string]interface{}
 	UseRuntimeHeadersOverride             bool
 	ParamOverrideAudit                    []string
+	// TraceContext 请求的链路追踪上下文，供没有 gin.Context 的流程创建子 span
+	TraceContext context.Context
 
 	PriceData types.PriceData
 
@@ -453,8 +460,14 @@ func genBaseRelayInfo(c *gin.Context, request dto.Request) *RelayInfo {
 	if reqId == "" {
 		reqId = common.GetTimeString() + common.GetRandomString(8)
 	}
+	traceCtx := context.Background()
+	if c.Request != nil {
+		traceCtx = c.Request.Context()
+	}
+
 	info := &RelayInfo{
-		Request: request,
+		Request:      request,
+		TraceContext: traceCtx,
 
 		RequestId:  reqId,
 		UserId:     common.GetContextKeyInt(c, constant.ContextKeyUserId),
@@ -463,6 +476,8 @@ func genBaseRelayInfo(c *gin.Context, request dto.Request) *RelayInfo {
 		UserQuota:  common.GetContextKeyInt(c, constant.ContextKeyUserQuota),
 		UserEmail:  common.GetContextKeyString(c, constant.ContextKeyUserEmail),
 
+		OrganizationId: common.GetContextKeyInt(c, constant.ContextKeyTokenOrganizationId),
+
 		OriginModelName: common.GetContextKeyString(c, constant.ContextKeyOriginalModel),
 
 		TokenId:        common.GetContextKeyInt(c, constant.ContextKeyTokenId),
@@ -640,6 +655,14 @@ func GenRelayInfoResponsesCompaction(c *gin.Context, request *dto.OpenAIResponse
 //	info.promptTokens = promptTokens
 //}
 
+// BillingOrganizationId 实际从组织钱包计费时返回组织 id，个人钱包或订阅计费时返回 0
+func (info *RelayInfo) BillingOrganizationId() int {
+	if info.BillingSource == "organization" {
+		return info.OrganizationId
+	}
+	return 0
+}
+
 func (info *RelayInfo) SetEstimatePromptTokens(promptTokens int) {
 	info.estimatePromptTokens = promptTokens
 }
@@ -659,6 +682,43 @@ func (info *RelayInfo) HasSendResponse() bool {
 	return info.FirstResponseTime.After(info.StartTime)
 }
 
+// Clone 复制一份可在另一个渠道上独立发起调用的 RelayInfo（用于对冲请求），
+// 调用过程中会被修改的字段均做深拷贝，流状态与最近一次错误不继承
+func (info *RelayInfo) Clone() *RelayInfo {
+	clone := *info
+	clone.StreamStatus = nil
+	clone.LastError = nil
+	clone.RequestConversionChain = slices.Clone(info.RequestConversionChain)
+	clone.ParamOverrideAudit = slices.Clone(info.ParamOverrideAudit)
+	clone.RuntimeHeadersOverride = maps.Clone(info.RuntimeHeadersOverride)
+	clone.PriceData.OtherRatios = maps.Clone(info.PriceData.OtherRatios)
+	if info.ClaudeConvertInfo != nil {
+		claudeConvertInfo := *info.ClaudeConvertInfo
+		if claudeConvertInfo.Usage != nil {
+			usage := *claudeConvertInfo.Usage
+			claudeConvertInfo.Usage = &usage
+		}
+		clone.ClaudeConvertInfo = &claudeConvertInfo
+	}
+	if info.RerankerInfo != nil {
+		rerankerInfo := *info.RerankerInfo
+		clone.RerankerInfo = &rerankerInfo
+	}
+	if info.ResponsesUsageInfo != nil {
+		builtInTools := make(map[string]*BuildInToolInfo, len(info.ResponsesUsageInfo.BuiltInTools))
+		for name, tool := range info.ResponsesUsageInfo.BuiltInTools {
+			toolCopy := *tool
+			builtInTools[name] = &toolCopy
+		}
+		clone.ResponsesUsageInfo = &ResponsesUsageInfo{BuiltInTools: builtInTools}
+	}
+	if info.ChannelMeta != nil {
+		channelMeta := *info.ChannelMeta
+		clone.ChannelMeta = &channelMeta
+	}
+	return &clone
+}
+
 type TaskRelayInfo struct {
 	Action       string
 	OriginTaskID string
@@ -0,0 +1,365 @@
+package common
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamFailover 流式响应中途断开时的续写会话，仅用于 OpenAI Chat Completions 流式请求。
+// 写入器记录已输出给客户端的 assistant 文本，并在当前尝试输出 finish_reason 前暂缓输出末尾的用量块与 [DONE]；
+// 上游中途断开时由上层换渠道，以已输出的文本作为 assistant 预填充续写，续写的内容直接接在原响应之后。
+type StreamFailover struct {
+	mu           sync.Mutex
+	writer       *streamFailoverWriter
+	request      *dto.GeneralOpenAIRequest
+	maxFailovers int
+
+	pending    []byte // 尚未凑成完整事件的写入
+	content    strings.Builder
+	started    bool // 已向客户端输出过 choices
+	resumable  bool // 未输出工具调用、多个 choice 或错误事件
+	finished   bool // 当前尝试已输出 finish_reason
+	responseId string
+	created    int64
+	tail       [][]byte // 当前尝试结束前暂缓输出的用量块与 [DONE]
+
+	holding     bool
+	usage       *dto.Usage // 已中断尝试的累计用量
+	channelMeta *ChannelMeta
+	channels    []int // 中断的渠道
+	failovers   int
+	resumed     bool
+}
+
+func NewStreamFailover(writer gin.ResponseWriter, request *dto.GeneralOpenAIRequest, maxFailovers int) *StreamFailover {
+	f := &StreamFailover{
+		request:      request,
+		maxFailovers: maxFailovers,
+		resumable:    true,
+	}
+	f.writer = &streamFailoverWriter{ResponseWriter: writer, failover: f}
+	return f
+}
+
+func GetStreamFailover(c *gin.Context) *StreamFailover {
+	value, ok := common.GetContextKey(c, constant.ContextKeyStreamFailover)
+	if !ok {
+		return nil
+	}
+	failover, _ := value.(*StreamFailover)
+	return failover
+}
+
+// Writer 返回记录输出内容的响应写入器
+func (f *StreamFailover) Writer() gin.ResponseWriter {
+	return f.writer
+}
+
+// Interrupted 返回当前尝试是否在输出内容后、输出 finish_reason 前中途断开且可以续写
+func (f *StreamFailover) Interrupted(info *RelayInfo) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.started || f.finished || !f.resumable || info.StreamStatus == nil {
+		return false
+	}
+	switch info.StreamStatus.EndReason {
+	case StreamEndReasonNone, StreamEndReasonDone, StreamEndReasonClientGone:
+		return false
+	}
+	return true
+}
+
+// Hold 暂存中断尝试的用量与渠道信息，等待续写完成后合并结算
+func (f *StreamFailover) Hold(info *RelayInfo, usage *dto.Usage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.holding = true
+	f.usage = mergeUsage(f.usage, usage)
+	if info.ChannelMeta != nil {
+		channelMeta := *info.ChannelMeta
+		f.channelMeta = &channelMeta
+		f.channels = append(f.channels, info.ChannelId)
+	}
+}
+
+// Pending 返回是否有中断尝试的用量尚未结算
+func (f *StreamFailover) Pending() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.holding
+}
+
+// CanContinue 返回是否还能继续续写
+func (f *StreamFailover) CanContinue() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resumable && f.failovers < f.maxFailovers
+}
+
+// Continue 开始一次续写，丢弃中断尝试暂缓的末尾输出，返回以已输出文本作为 assistant 预填充的请求。
+// 部分上游不接受以空白结尾的预填充，预填充会去掉末尾空白。
+func (f *StreamFailover) Continue() *dto.GeneralOpenAIRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failovers++
+	f.finished = false
+	f.tail = nil
+	f.pending = nil
+
+	request := *f.request
+	request.Messages = slices.Clone(f.request.Messages)
+	if prefill := strings.TrimRightFunc(f.content.String(), unicode.IsSpace); prefill != "" {
+		request.Messages = append(request.Messages, dto.Message{
+			Role:    "assistant",
+			Content: prefill,
+		})
+	}
+	return &request
+}
+
+// Continuing 返回当前尝试是否为续写
+func (f *StreamFailover) Continuing() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failovers > 0
+}
+
+// Settle 输出暂缓的末尾内容，返回合并了中断尝试用量的本次用量
+func (f *StreamFailover) Settle(usage *dto.Usage) *dto.Usage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushTail()
+	if !f.holding {
+		return usage
+	}
+	f.holding = false
+	f.resumed = true
+	return mergeUsage(f.usage, usage)
+}
+
+// Abandon 放弃续写，输出中断尝试暂缓的末尾内容，返回中断尝试的累计用量与最后中断的渠道信息
+func (f *StreamFailover) Abandon() (*dto.Usage, *ChannelMeta) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushTail()
+	f.holding = false
+	return f.usage, f.channelMeta
+}
+
+// Close 输出仍未写出的内容，应在请求结束、恢复原写入器前调用
+func (f *StreamFailover) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushTail()
+	if len(f.pending) > 0 {
+		_, _ = f.writer.ResponseWriter.Write(f.pending)
+		f.pending = nil
+	}
+}
+
+// LogInfo 返回写入日志的续写信息，未发生中断时返回 nil
+func (f *StreamFailover) LogInfo() map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.channels) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"interrupted_channels": slices.Clone(f.channels),
+		"failovers":            f.failovers,
+		"resumed":              f.resumed,
+	}
+}
+
+func (f *StreamFailover) flushTail() {
+	for _, event := range f.tail {
+		_, _ = f.writer.ResponseWriter.Write(event)
+	}
+	f.tail = nil
+}
+
+// handleEvent 处理一个完整的 SSE 事件，调用方需持有锁
+func (f *StreamFailover) handleEvent(event []byte) error {
+	line := bytes.TrimSpace(event)
+	if !bytes.HasPrefix(line, []byte("data:")) {
+		return f.write(event)
+	}
+	payload := bytes.TrimSpace(line[len("data:"):])
+	if string(payload) == "[DONE]" {
+		return f.writeTail(event)
+	}
+	if !gjson.ValidBytes(payload) {
+		return f.write(event)
+	}
+	if gjson.GetBytes(payload, "error").Exists() {
+		// 上游或内容审核返回的错误事件，客户端已收到错误，不再续写
+		f.resumable = false
+		return f.write(event)
+	}
+
+	choices := gjson.GetBytes(payload, "choices").Array()
+	hasUsage := gjson.GetBytes(payload, "usage").IsObject()
+	if hasUsage && f.failovers > 0 && f.usage != nil {
+		payload = f.mergeUsageChunk(payload)
+	}
+	if len(choices) == 0 {
+		if hasUsage {
+			return f.writeTail(f.formatEvent(f.rewriteChunk(payload)))
+		}
+		return f.write(event)
+	}
+
+	if len(choices) > 1 {
+		f.resumable = false
+	}
+	delta := choices[0].Get("delta")
+	hasToolCalls := delta.Get("tool_calls").Exists() || delta.Get("function_call").Exists()
+	if hasToolCalls {
+		f.resumable = false
+	}
+	content := delta.Get("content").String()
+	finishReason := choices[0].Get("finish_reason").String()
+
+	if f.failovers > 0 {
+		// 续写的角色块与思考过程与已输出的内容重复或无关，不再输出
+		if content == "" && finishReason == "" && !hasToolCalls && !hasUsage {
+			return nil
+		}
+		payload, _ = sjson.DeleteBytes(payload, "choices.0.delta.reasoning_content")
+		payload, _ = sjson.DeleteBytes(payload, "choices.0.delta.reasoning")
+		payload = f.rewriteChunk(payload)
+	} else if !f.started {
+		f.responseId = gjson.GetBytes(payload, "id").String()
+		f.created = gjson.GetBytes(payload, "created").Int()
+	}
+
+	f.started = true
+	f.content.WriteString(content)
+	if finishReason != "" {
+		f.finished = true
+	}
+	f.flushTail()
+	return f.write(f.formatEvent(payload))
+}
+
+// rewriteChunk 续写的数据块沿用首次尝试的 id 与 created，客户端看到的是同一个响应
+func (f *StreamFailover) rewriteChunk(payload []byte) []byte {
+	if f.failovers == 0 || f.responseId == "" {
+		return payload
+	}
+	payload, _ = sjson.SetBytes(payload, "id", f.responseId)
+	payload, _ = sjson.SetBytes(payload, "created", f.created)
+	return payload
+}
+
+// mergeUsageChunk 续写返回的用量加上中断尝试的用量
+func (f *StreamFailover) mergeUsageChunk(payload []byte) []byte {
+	var usage dto.Usage
+	if err := common.Unmarshal([]byte(gjson.GetBytes(payload, "usage").Raw), &usage); err != nil {
+		return payload
+	}
+	merged, err := common.Marshal(mergeUsage(f.usage, &usage))
+	if err != nil {
+		return payload
+	}
+	result, err := sjson.SetRawBytes(payload, "usage", merged)
+	if err != nil {
+		return payload
+	}
+	return result
+}
+
+func (f *StreamFailover) formatEvent(payload []byte) []byte {
+	event := make([]byte, 0, len(payload)+8)
+	event = append(event, "data: "...)
+	event = append(event, payload...)
+	return append(event, "\n\n"...)
+}
+
+func (f *StreamFailover) write(event []byte) error {
+	_, err := f.writer.ResponseWriter.Write(event)
+	return err
+}
+
+// writeTail 当前尝试已输出 finish_reason 时直接输出，否则暂缓到确认是否续写
+func (f *StreamFailover) writeTail(event []byte) error {
+	if f.finished {
+		return f.write(event)
+	}
+	f.tail = append(f.tail, event)
+	return nil
+}
+
+// mergeUsage 累加两次尝试的用量，返回新的用量
+func mergeUsage(a *dto.Usage, b *dto.Usage) *dto.Usage {
+	merged := &dto.Usage{}
+	for _, usage := range []*dto.Usage{a, b} {
+		if usage == nil {
+			continue
+		}
+		merged.PromptTokens += usage.PromptTokens
+		merged.CompletionTokens += usage.CompletionTokens
+		merged.TotalTokens += usage.TotalTokens
+		merged.PromptCacheHitTokens += usage.PromptCacheHitTokens
+		merged.PromptTokensDetails.CachedTokens += usage.PromptTokensDetails.CachedTokens
+		merged.PromptTokensDetails.CachedCreationTokens += usage.PromptTokensDetails.CachedCreationTokens
+		merged.PromptTokensDetails.TextTokens += usage.PromptTokensDetails.TextTokens
+		merged.PromptTokensDetails.AudioTokens += usage.PromptTokensDetails.AudioTokens
+		merged.PromptTokensDetails.ImageTokens += usage.PromptTokensDetails.ImageTokens
+		merged.CompletionTokenDetails.TextTokens += usage.CompletionTokenDetails.TextTokens
+		merged.CompletionTokenDetails.AudioTokens += usage.CompletionTokenDetails.AudioTokens
+		merged.CompletionTokenDetails.ImageTokens += usage.CompletionTokenDetails.ImageTokens
+		merged.CompletionTokenDetails.ReasoningTokens += usage.CompletionTokenDetails.ReasoningTokens
+		merged.InputTokens += usage.InputTokens
+		merged.OutputTokens += usage.OutputTokens
+		merged.ClaudeCacheCreation5mTokens += usage.ClaudeCacheCreation5mTokens
+		merged.ClaudeCacheCreation1hTokens += usage.ClaudeCacheCreation1hTokens
+		if usage.UsageSemantic != "" {
+			merged.UsageSemantic = usage.UsageSemantic
+		}
+		if usage.UsageSource != "" {
+			merged.UsageSource = usage.UsageSource
+		}
+	}
+	return merged
+}
+
+// streamFailoverWriter 按 SSE 事件解析写入内容的响应写入器
+type streamFailoverWriter struct {
+	gin.ResponseWriter
+	failover *StreamFailover
+}
+
+func (w *streamFailoverWriter) Write(data []byte) (int, error) {
+	f := w.failover
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, data...)
+	for {
+		idx := bytes.Index(f.pending, []byte("\n\n"))
+		if idx < 0 {
+			break
+		}
+		event := bytes.Clone(f.pending[:idx+2])
+		f.pending = f.pending[idx+2:]
+		if err := f.handleEvent(event); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *streamFailoverWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
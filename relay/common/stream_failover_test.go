@@ -0,0 +1,129 @@
+package common
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamFailoverTest(t *testing.T, maxFailovers int) (*StreamFailover, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	request := &dto.GeneralOpenAIRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []dto.Message{{Role: "user", Content: "hi"}},
+	}
+	return NewStreamFailover(c.Writer, request, maxFailovers), recorder
+}
+
+func writeStreamFailoverData(t *testing.T, f *StreamFailover, data string) {
+	t.Helper()
+	// 与 helper.StringData 一致，数据与结尾换行分两次写入
+	_, err := f.Writer().WriteString("data: " + data)
+	require.NoError(t, err)
+	_, err = f.Writer().WriteString("\n\n")
+	require.NoError(t, err)
+}
+
+func interruptedInfo(channelId int) *RelayInfo {
+	status := NewStreamStatus()
+	status.SetEndReason(StreamEndReasonTimeout, nil)
+	return &RelayInfo{StreamStatus: status, ChannelMeta: &ChannelMeta{ChannelId: channelId}}
+}
+
+func TestStreamFailover_ContinuesInterruptedStream(t *testing.T) {
+	f, recorder := newStreamFailoverTest(t, 1)
+	writeStreamFailoverData(t, f, `{"id":"a","created":1,"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`)
+	writeStreamFailoverData(t, f, `{"id":"a","created":1,"choices":[{"index":0,"delta":{"content":"Hello "}}]}`)
+	writeStreamFailoverData(t, f, `{"id":"a","created":1,"choices":[],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`)
+	writeStreamFailoverData(t, f, `[DONE]`)
+	require.NotContains(t, recorder.Body.String(), "[DONE]")
+	require.NotContains(t, recorder.Body.String(), "usage")
+
+	info := interruptedInfo(1)
+	require.True(t, f.Interrupted(info))
+	f.Hold(info, &dto.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12})
+	require.True(t, f.Pending())
+	require.True(t, f.CanContinue())
+
+	request := f.Continue()
+	require.Len(t, request.Messages, 2)
+	require.Len(t, f.request.Messages, 1)
+	require.Equal(t, "assistant", request.Messages[1].Role)
+	require.Equal(t, "Hello", request.Messages[1].Content)
+	require.False(t, f.CanContinue())
+
+	writeStreamFailoverData(t, f, `{"id":"b","created":2,"choices":[{"index":0,"delta":{"role":"assistant","content":"","reasoning_content":"think"}}]}`)
+	writeStreamFailoverData(t, f, `{"id":"b","created":2,"choices":[{"index":0,"delta":{"content":"world"},"finish_reason":"stop"}]}`)
+	writeStreamFailoverData(t, f, `{"id":"b","created":2,"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":1,"total_tokens":13}}`)
+	writeStreamFailoverData(t, f, `[DONE]`)
+
+	body := recorder.Body.String()
+	require.NotContains(t, body, `"id":"b"`)
+	require.NotContains(t, body, "think")
+	require.Contains(t, body, `{"id":"a","created":1,"choices":[{"index":0,"delta":{"content":"world"},"finish_reason":"stop"}]}`)
+	require.Contains(t, body, `"prompt_tokens":22,"completion_tokens":3,"total_tokens":25`)
+	require.Contains(t, body, "data: [DONE]\n\n")
+
+	usage := f.Settle(&dto.Usage{PromptTokens: 12, CompletionTokens: 1, TotalTokens: 13})
+	require.Equal(t, 22, usage.PromptTokens)
+	require.Equal(t, 3, usage.CompletionTokens)
+	require.False(t, f.Pending())
+	require.Equal(t, map[string]interface{}{
+		"interrupted_channels": []int{1},
+		"failovers":            1,
+		"resumed":              true,
+	}, f.LogInfo())
+}
+
+func TestStreamFailover_FinishedStreamIsNotInterrupted(t *testing.T) {
+	f, recorder := newStreamFailoverTest(t, 1)
+	writeStreamFailoverData(t, f, `{"id":"a","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":"stop"}]}`)
+	writeStreamFailoverData(t, f, `[DONE]`)
+
+	require.False(t, f.Interrupted(interruptedInfo(1)))
+	require.Contains(t, recorder.Body.String(), "data: [DONE]\n\n")
+	require.Nil(t, f.LogInfo())
+}
+
+func TestStreamFailover_ToolCallsAreNotResumable(t *testing.T) {
+	f, _ := newStreamFailoverTest(t, 1)
+	writeStreamFailoverData(t, f, `{"id":"a","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"get_weather"}}]}}]}`)
+
+	require.False(t, f.Interrupted(interruptedInfo(1)))
+}
+
+func TestStreamFailover_ClientGoneIsNotInterrupted(t *testing.T) {
+	f, _ := newStreamFailoverTest(t, 1)
+	writeStreamFailoverData(t, f, `{"id":"a","choices":[{"index":0,"delta":{"content":"Hi"}}]}`)
+
+	info := interruptedInfo(1)
+	info.StreamStatus.EndReason = StreamEndReasonClientGone
+	require.False(t, f.Interrupted(info))
+}
+
+func TestStreamFailover_AbandonFlushesHeldTail(t *testing.T) {
+	f, recorder := newStreamFailoverTest(t, 1)
+	writeStreamFailoverData(t, f, `{"id":"a","choices":[{"index":0,"delta":{"content":"Hi"}}]}`)
+	writeStreamFailoverData(t, f, `[DONE]`)
+	f.Hold(interruptedInfo(3), &dto.Usage{CompletionTokens: 1})
+
+	usage, channelMeta := f.Abandon()
+	require.Equal(t, 1, usage.CompletionTokens)
+	require.Equal(t, 3, channelMeta.ChannelId)
+	require.False(t, f.Pending())
+	require.Contains(t, recorder.Body.String(), "data: [DONE]\n\n")
+}
+
+func TestStreamFailover_PassesThroughComments(t *testing.T) {
+	f, recorder := newStreamFailoverTest(t, 1)
+	_, err := f.Writer().WriteString(": PING\n\n")
+	require.NoError(t, err)
+	require.Equal(t, ": PING\n\n", recorder.Body.String())
+}
@@ -81,6 +81,10 @@ func TextHelper(c *gin.Context, info *relaycommon.RelayInfo) (newAPIError *types
 		if newApiErr != nil {
 			return newApiErr
 		}
+		usage, newApiErr = settleStreamFailover(c, info, usage)
+		if newApiErr != nil {
+			return newApiErr
+		}
 
 		var containAudioTokens = usage.CompletionTokenDetails.AudioTokens > 0 || usage.PromptTokensDetails.AudioTokens > 0
 		var containsAudioRatios = ratio_setting.ContainsAudioRatio(info.OriginModelName) || ratio_setting.ContainsAudioCompletionRatio(info.OriginModelName)
@@ -95,7 +99,13 @@ func TextHelper(c *gin.Context, info *relaycommon.RelayInfo) (newAPIError *types
 
 	var requestBody io.Reader
 
-	if passThroughGlobal || info.ChannelSetting.PassThroughBodyEnabled {
+	// 续写请求追加了 assistant 预填充，不能透传原始请求体
+	passThrough := passThroughGlobal || info.ChannelSetting.PassThroughBodyEnabled
+	if failover := relaycommon.GetStreamFailover(c); failover != nil && failover.Continuing() {
+		passThrough = false
+	}
+
+	if passThrough {
 		storage, err := common.GetBodyStorage(c)
 		if err != nil {
 			return types.NewErrorWithStatusCode(err, types.ErrorCodeReadRequestBodyFailed, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
@@ -204,14 +214,32 @@ func TextHelper(c *gin.Context, info *relaycommon.RelayInfo) (newAPIError *types
 		service.ResetStatusCode(newApiErr, statusCodeMappingStr)
 		return newApiErr
 	}
+	textUsage, newApiErr := settleStreamFailover(c, info, usage.(*dto.Usage))
+	if newApiErr != nil {
+		return newApiErr
+	}
 
-	var containAudioTokens = usage.(*dto.Usage).CompletionTokenDetails.AudioTokens > 0 || usage.(*dto.Usage).PromptTokensDetails.AudioTokens > 0
+	var containAudioTokens = textUsage.CompletionTokenDetails.AudioTokens > 0 || textUsage.PromptTokensDetails.AudioTokens > 0
 	var containsAudioRatios = ratio_setting.ContainsAudioRatio(info.OriginModelName) || ratio_setting.ContainsAudioCompletionRatio(info.OriginModelName)
 
 	if containAudioTokens && containsAudioRatios {
-		service.PostAudioConsumeQuota(c, info, usage.(*dto.Usage), "")
+		service.PostAudioConsumeQuota(c, info, textUsage, "")
 	} else {
-		service.PostTextConsumeQuota(c, info, usage.(*dto.Usage), nil)
+		service.PostTextConsumeQuota(c, info, textUsage, nil)
 	}
 	return nil
 }
+
+// settleStreamFailover 流式响应中途断开且可以续写时暂存本次用量，返回中断错误由上层换渠道续写；
+// 否则返回合并了此前中断尝试用量的本次用量
+func settleStreamFailover(c *gin.Context, info *relaycommon.RelayInfo, usage *dto.Usage) (*dto.Usage, *types.NewAPIError) {
+	failover := relaycommon.GetStreamFailover(c)
+	if failover == nil {
+		return usage, nil
+	}
+	if failover.Interrupted(info) {
+		failover.Hold(info, usage)
+		return nil, types.NewError(fmt.Errorf("stream interrupted on channel #%d: %s", info.ChannelId, info.StreamStatus.Summary()), types.ErrorCodeStreamInterrupted)
+	}
+	return failover.Settle(usage), nil
+}
@@ -209,3 +209,32 @@ func GenerateFinalUsageResponse(id string, createAt int64, model string, usage d
 		Usage:             &usage,
 	}
 }
+
+// StreamErrorData 以入站格式的错误事件结束流式输出：Claude / Responses 使用 event: error，其余格式为 data: {"error": ...}
+func StreamErrorData(c *gin.Context, format types.RelayFormat, apiErr *types.NewAPIError) {
+	var event string
+	var payload any
+	switch format {
+	case types.RelayFormatClaude:
+		event = "error"
+		payload = gin.H{"type": "error", "error": apiErr.ToClaudeError()}
+	case types.RelayFormatOpenAIResponses:
+		openaiErr := apiErr.ToOpenAIError()
+		event = "error"
+		payload = gin.H{"type": "error", "code": openaiErr.Code, "message": openaiErr.Message, "param": openaiErr.Param}
+	case types.RelayFormatGemini:
+		payload = gin.H{"error": apiErr.ToGeminiError()}
+	default:
+		payload = gin.H{"error": apiErr.ToOpenAIError()}
+	}
+	jsonData, err := common.Marshal(payload)
+	if err != nil {
+		common.SysError("error marshalling stream error: " + err.Error())
+		return
+	}
+	if event != "" {
+		c.Render(-1, common.CustomEvent{Data: fmt.Sprintf("event: %s\n", event)})
+	}
+	c.Render(-1, common.CustomEvent{Data: "data: " + string(jsonData)})
+	_ = FlushWriter(c)
+}
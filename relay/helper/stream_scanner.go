@@ -13,12 +13,15 @@ import (
 	"github.com/QuantumNous/new-api/common"
 	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/pkg/tracing"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/service"
 	"github.com/QuantumNous/new-api/setting/operation_setting"
 
 	"github.com/bytedance/gopkg/util/gopool"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -43,6 +46,15 @@ func StreamScannerHandler(c *gin.Context, resp *http.Response, info *relaycommon
 	// 无条件新建 StreamStatus
 	info.StreamStatus = relaycommon.NewStreamStatus()
 
+	_, span := tracing.Start(c, "relay.stream")
+	defer func() {
+		span.SetAttributes(
+			attribute.String("new_api.stream.end_reason", string(info.StreamStatus.EndReason)),
+			attribute.Int("new_api.stream.received", info.ReceivedResponseCount),
+		)
+		tracing.End(span, info.StreamStatus.EndError)
+	}()
+
 	// 确保响应体总是被关闭
 	defer func() {
 		if resp.Body != nil {
@@ -188,15 +200,44 @@ func StreamScannerHandler(c *gin.Context, resp *http.Response, info *relaycommon
 			common.SafeSendBool(stopChan, true)
 		}()
 		sr := newStreamResult(info.StreamStatus)
-		for data := range dataChan {
+		handle := func(data string) bool {
 			sr.reset()
 			writeMutex.Lock()
 			dataHandler(data, sr)
 			writeMutex.Unlock()
-			if sr.IsStopped() {
+			return sr.IsStopped()
+		}
+		sensitiveFilter := newStreamSensitiveFilter(c)
+		for data := range dataChan {
+			if sensitiveFilter == nil {
+				if handle(data) {
+					return
+				}
+				continue
+			}
+			ready, blocked := sensitiveFilter.push(data)
+			for _, d := range ready {
+				if handle(d) {
+					return
+				}
+			}
+			if blocked {
+				apiErr := service.NewSensitiveOutputError(c)
+				writeMutex.Lock()
+				StreamErrorData(c, info.RelayFormat, apiErr)
+				writeMutex.Unlock()
+				info.StreamStatus.RecordError(apiErr.Error())
+				info.StreamStatus.SetEndReason(relaycommon.StreamEndReasonHandlerStop, apiErr)
 				return
 			}
 		}
+		if sensitiveFilter != nil {
+			for _, d := range sensitiveFilter.flush() {
+				if handle(d) {
+					return
+				}
+			}
+		}
 	})
 
 	// Scanner goroutine with improved error handling
@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"github.com/QuantumNous/new-api/service"
+	"github.com/QuantumNous/new-api/setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamSensitiveFilter 流式输出的敏感词过滤。
+// 分片至少缓存到其后的文本达到最长敏感词长度 - 1 个字符（且不少于 setting.StreamCacheQueueLength 个分片）才发送，
+// 缓存中的分片在发送前仍可被跨分片的命中替换或整体丢弃，避免被拆分到多个分片的敏感词漏出。
+type streamSensitiveFilter struct {
+	c           *gin.Context
+	filter      *service.SensitiveOutputFilter
+	queue       []*service.SensitiveOutputChunk
+	queueLength int
+}
+
+// newStreamSensitiveFilter 未开启输出检查时返回 nil
+func newStreamSensitiveFilter(c *gin.Context) *streamSensitiveFilter {
+	filter := service.NewSensitiveOutputFilter()
+	if filter == nil {
+		return nil
+	}
+	return &streamSensitiveFilter{c: c, filter: filter, queueLength: max(setting.StreamCacheQueueLength, 0)}
+}
+
+// push 检查一个分片，返回可以发送的分片；开启 StopOnSensitiveEnabled 且命中时丢弃缓存并返回 blocked=true
+func (f *streamSensitiveFilter) push(data string) (ready []string, blocked bool) {
+	chunk, words := f.filter.Check(data)
+	if len(words) > 0 {
+		service.LogSensitiveOutput(f.c, words)
+		if setting.StopOnSensitiveEnabled {
+			f.queue = nil
+			return nil, true
+		}
+	}
+	f.queue = append(f.queue, chunk)
+	for len(f.queue) > f.queueLength && f.pendingTextLen(1) >= f.filter.HoldbackLen() {
+		ready = append(ready, f.queue[0].String())
+		f.queue = f.queue[1:]
+	}
+	return ready, false
+}
+
+// pendingTextLen 返回缓存中从 from 开始的分片的文本字符数
+func (f *streamSensitiveFilter) pendingTextLen(from int) int {
+	n := 0
+	for _, chunk := range f.queue[from:] {
+		n += chunk.TextLen()
+	}
+	return n
+}
+
+// flush 上游输出结束后发送剩余的缓存分片
+func (f *streamSensitiveFilter) flush() []string {
+	ready := make([]string, 0, len(f.queue))
+	for _, chunk := range f.queue {
+		ready = append(ready, chunk.String())
+	}
+	f.queue = nil
+	return ready
+}
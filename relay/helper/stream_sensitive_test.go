@@ -0,0 +1,117 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useStreamSensitiveWords(t *testing.T, stop bool, queueLength int, words ...string) {
+	t.Helper()
+	enabled, onCompletion, stopOnSensitive, queue, sensitiveWords := setting.CheckSensitiveEnabled, setting.CheckSensitiveOnCompletionEnabled, setting.StopOnSensitiveEnabled, setting.StreamCacheQueueLength, setting.SensitiveWords
+	setting.CheckSensitiveEnabled = true
+	setting.CheckSensitiveOnCompletionEnabled = true
+	setting.StopOnSensitiveEnabled = stop
+	setting.StreamCacheQueueLength = queueLength
+	setting.SensitiveWords = words
+	t.Cleanup(func() {
+		setting.CheckSensitiveEnabled = enabled
+		setting.CheckSensitiveOnCompletionEnabled = onCompletion
+		setting.StopOnSensitiveEnabled = stopOnSensitive
+		setting.StreamCacheQueueLength = queue
+		setting.SensitiveWords = sensitiveWords
+	})
+}
+
+func sensitiveSSEBody(parts ...string) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(`data: {"choices":[{"delta":{"content":"` + part + `"}}]}` + "\n")
+	}
+	b.WriteString("data: [DONE]\n")
+	return b.String()
+}
+
+func TestStreamScannerHandler_SensitiveMaskAcrossChunks(t *testing.T) {
+	useStreamSensitiveWords(t, false, 2, "forbidden")
+
+	c, resp, info := setupStreamTest(t, strings.NewReader(sensitiveSSEBody("a for", "bid", "den b", "c")))
+	var received []string
+	StreamScannerHandler(c, resp, info, func(data string, sr *StreamResult) {
+		received = append(received, data)
+	})
+
+	require.Len(t, received, 4)
+	assert.Contains(t, received[0], `"a **###**"`)
+	assert.Contains(t, received[1], `"**###**"`)
+	assert.Contains(t, received[2], `"**###** b"`)
+	assert.Contains(t, received[3], `"c"`)
+}
+
+func TestStreamScannerHandler_SensitiveStopWithClaudeError(t *testing.T) {
+	useStreamSensitiveWords(t, true, 1, "forbidden")
+
+	_, resp, info := setupStreamTest(t, strings.NewReader(sensitiveSSEBody("hello ", "this is fine ", "a for", "bidden", "never sent")))
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	info.RelayFormat = types.RelayFormatClaude
+	var received []string
+	StreamScannerHandler(c, resp, info, func(data string, sr *StreamResult) {
+		received = append(received, data)
+	})
+
+	// 命中时缓存中的 "this is fine " 与 "a for" 被丢弃
+	require.Len(t, received, 1)
+	assert.Contains(t, received[0], "hello ")
+	assert.True(t, info.StreamStatus.HasErrors())
+	output := recorder.Body.String()
+	assert.Contains(t, output, "event: error\n")
+	assert.Contains(t, output, `"type":"error"`)
+	assert.Contains(t, output, "sensitive words detected in completion")
+	assert.NotContains(t, output, "never sent")
+}
+
+func TestStreamScannerHandler_SensitiveMaskAcrossChunksWithoutQueue(t *testing.T) {
+	useStreamSensitiveWords(t, false, 0, "forbidden")
+
+	c, resp, info := setupStreamTest(t, strings.NewReader(sensitiveSSEBody("a for", "bid", "den b", "c")))
+	var received []string
+	StreamScannerHandler(c, resp, info, func(data string, sr *StreamResult) {
+		received = append(received, data)
+	})
+
+	// 未设置缓存队列时仍需保留足够的后续文本，拆分到多个分片的敏感词不会漏出
+	require.Len(t, received, 4)
+	assert.Contains(t, received[0], `"a **###**"`)
+	assert.Contains(t, received[1], `"**###**"`)
+	assert.Contains(t, received[2], `"**###** b"`)
+	assert.Contains(t, received[3], `"c"`)
+	for _, data := range received {
+		assert.NotContains(t, data, "for")
+		assert.NotContains(t, data, "bid")
+	}
+}
+
+func TestStreamScannerHandler_SensitiveStopWithoutQueue(t *testing.T) {
+	useStreamSensitiveWords(t, true, 0, "forbidden")
+
+	c, resp, info := setupStreamTest(t, strings.NewReader(sensitiveSSEBody("hello ", "there friend ", "a for", "bidden", "never sent")))
+	var received []string
+	StreamScannerHandler(c, resp, info, func(data string, sr *StreamResult) {
+		received = append(received, data)
+	})
+
+	// "there friend " 之后的文本不足以确认，与 "a for" 一起被丢弃
+	require.Len(t, received, 1)
+	assert.Contains(t, received[0], "hello ")
+	assert.True(t, info.StreamStatus.HasErrors())
+}
@@ -136,6 +136,28 @@ func ResolveOriginTask(c *gin.Context, info *relaycommon.RelayInfo) *dto.TaskErr
 	return nil
 }
 
+// ResolveTaskCallbackURL 读取任务完成回调地址：优先使用请求体中的 callback_url，
+// 未指定时使用令牌配置的默认回调地址。该字段只用于本系统推送，不会转发给上游。
+func ResolveTaskCallbackURL(c *gin.Context) (string, *dto.TaskError) {
+	var req struct {
+		CallbackURL string `json:"callback_url"`
+	}
+	if err := common.UnmarshalBodyReusable(c, &req); err != nil {
+		if common.IsRequestBodyTooLargeError(err) || errors.Is(err, common.ErrRequestBodyTooLarge) {
+			return "", service.TaskErrorWrapperLocal(err, "read_request_body_failed", http.StatusRequestEntityTooLarge)
+		}
+		return "", service.TaskErrorWrapperLocal(err, "invalid_request", http.StatusBadRequest)
+	}
+	callbackURL := strings.TrimSpace(req.CallbackURL)
+	if callbackURL == "" {
+		callbackURL = common.GetContextKeyString(c, constant.ContextKeyTokenTaskCallbackUrl)
+	}
+	if err := service.CheckTaskCallbackURL(callbackURL); err != nil {
+		return "", service.TaskErrorWrapperLocal(err, "invalid_callback_url", http.StatusBadRequest)
+	}
+	return callbackURL, nil
+}
+
 // RelayTaskSubmit 完成 task 提交的全部流程（每次尝试调用一次）：
 // 刷新渠道元数据 → 确定 platform/adaptor → 验证请求 →
 // 估算计费(EstimateBilling) → 计算价格 → 预扣费（仅首次）→
@@ -384,6 +406,13 @@ func videoFetchByIDRespBodyBuilder(c *gin.Context) (respBody []byte, taskResp *d
 		return
 	}
 
+	return videoTaskRespBody(originTask, isOpenAIVideoAPI)
+}
+
+// videoTaskRespBody 构建单个视频任务的响应体：OpenAI Video API 走各 adaptor 的 ConvertToOpenAIVideo，
+// 其余接口使用通用 TaskDto 格式
+func videoTaskRespBody(originTask *model.Task, isOpenAIVideoAPI bool) (respBody []byte, taskResp *dto.TaskError) {
+	var err error
 	// OpenAI Video API 格式: 走各 adaptor 的 ConvertToOpenAIVideo
 	if isOpenAIVideoAPI {
 		adaptor := GetTaskAdaptor(originTask.Platform)
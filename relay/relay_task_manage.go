@@ -0,0 +1,145 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/relay/channel"
+	"github.com/QuantumNous/new-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultVideoListLimit = 20
+	maxVideoListLimit     = 100
+)
+
+// RelayTaskCancel 取消当前用户尚未完成的任务：先调用上游的取消接口，上游接受后将任务标记为失败并退还预扣额度。
+// 上游不支持取消的平台直接返回错误，避免上游仍在生成而本地已经退款。
+func RelayTaskCancel(c *gin.Context) *dto.TaskError {
+	taskId := c.Param("task_id")
+	if taskId == "" {
+		taskId = c.Param("video_id")
+	}
+	task, exist, err := model.GetByTaskId(c.GetInt("id"), taskId)
+	if err != nil {
+		return service.TaskErrorWrapper(err, "get_task_failed", http.StatusInternalServerError)
+	}
+	if !exist {
+		return service.TaskErrorWrapperLocal(errors.New("task_not_exist"), "task_not_exist", http.StatusBadRequest)
+	}
+	if task.Status == model.TaskStatusSuccess || task.Status == model.TaskStatusFailure {
+		return service.TaskErrorWrapperLocal(errors.New("task is already finished"), "task_already_finished", http.StatusBadRequest)
+	}
+
+	canceler, ok := GetTaskAdaptor(task.Platform).(channel.TaskCanceler)
+	if !ok {
+		return service.TaskErrorWrapperLocal(fmt.Errorf("task cancellation is not supported for platform %s", task.Platform), "task_cancel_not_supported", http.StatusBadRequest)
+	}
+	ch, err := model.GetChannelById(task.ChannelId, true)
+	if err != nil {
+		return service.TaskErrorWrapperLocal(err, "channel_not_found", http.StatusBadRequest)
+	}
+	key := task.PrivateData.Key
+	if key == "" {
+		key = ch.Key
+	}
+	if err := canceler.CancelTask(ch.GetBaseURL(), key, task.GetUpstreamTaskID(), ch.GetSetting().Proxy); err != nil {
+		return service.TaskErrorWrapper(err, "cancel_task_failed", http.StatusBadGateway)
+	}
+
+	// 上游已接受取消但轮询先一步把任务推进到终态时，task 为重新读取的实际状态，按实际状态返回
+	won, err := service.CancelTaskAndRefund(c.Request.Context(), task)
+	if err != nil {
+		return service.TaskErrorWrapper(err, "update_task_failed", http.StatusInternalServerError)
+	}
+
+	var respBody []byte
+	if c.Request.Method == http.MethodDelete {
+		respBody, err = common.Marshal(dto.OpenAIVideoDeleted{ID: task.TaskID, Object: "video.deleted", Deleted: won})
+		if err != nil {
+			return service.TaskErrorWrapper(err, "marshal_response_failed", http.StatusInternalServerError)
+		}
+	} else {
+		var taskErr *dto.TaskError
+		respBody, taskErr = videoTaskRespBody(task, strings.HasPrefix(c.Request.URL.Path, "/v1/videos/"))
+		if taskErr != nil {
+			return taskErr
+		}
+	}
+	c.Data(http.StatusOK, "application/json", respBody)
+	return nil
+}
+
+// RelayVideoList 以 OpenAI 列表格式返回当前用户的视频任务，支持 after / limit / order 游标分页
+func RelayVideoList(c *gin.Context) *dto.TaskError {
+	userId := c.GetInt("id")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 {
+		limit = defaultVideoListLimit
+	} else if limit > maxVideoListLimit {
+		limit = maxVideoListLimit
+	}
+	asc := c.Query("order") == "asc"
+
+	var afterId int64
+	if after := c.Query("after"); after != "" {
+		afterTask, exist, err := model.GetByTaskId(userId, after)
+		if err != nil {
+			return service.TaskErrorWrapper(err, "get_task_failed", http.StatusInternalServerError)
+		}
+		if !exist {
+			return service.TaskErrorWrapperLocal(fmt.Errorf("invalid after: %s", after), "invalid_request", http.StatusBadRequest)
+		}
+		afterId = afterTask.ID
+	}
+
+	// 多取一条用于判断是否还有下一页
+	tasks, err := model.GetUserVideoTasksByCursor(userId, afterId, limit+1, asc)
+	if err != nil {
+		return service.TaskErrorWrapper(err, "get_tasks_failed", http.StatusInternalServerError)
+	}
+	list := dto.OpenAIVideoList{Object: "list", HasMore: len(tasks) > limit}
+	if list.HasMore {
+		tasks = tasks[:limit]
+	}
+	list.Data = make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		item, err := openAIVideoItem(task)
+		if err != nil {
+			return service.TaskErrorWrapper(err, "convert_to_openai_video_failed", http.StatusInternalServerError)
+		}
+		list.Data = append(list.Data, item)
+	}
+	if len(tasks) > 0 {
+		list.FirstID = tasks[0].TaskID
+		list.LastID = tasks[len(tasks)-1].TaskID
+	}
+	c.JSON(http.StatusOK, list)
+	return nil
+}
+
+// openAIVideoItem 优先使用 adaptor 的 ConvertToOpenAIVideo，不支持的平台按任务字段生成基础视频对象
+func openAIVideoItem(task *model.Task) (json.RawMessage, error) {
+	if converter, ok := GetTaskAdaptor(task.Platform).(channel.OpenAIVideoConverter); ok {
+		return converter.ConvertToOpenAIVideo(task)
+	}
+	video := dto.NewOpenAIVideo()
+	video.ID = task.TaskID
+	video.Model = task.Properties.OriginModelName
+	video.Status = task.Status.ToVideoStatus()
+	video.SetProgressStr(task.Progress)
+	video.CreatedAt = task.SubmitTime
+	video.CompletedAt = task.FinishTime
+	if task.Status == model.TaskStatusFailure {
+		video.Error = &dto.OpenAIVideoError{Message: task.FailReason, Code: "task_failed"}
+	}
+	return common.Marshal(video)
+}
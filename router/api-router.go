@@ -94,6 +94,9 @@ func SetApiRouter(router *gin.Engine) {
 				selfRoute.GET("/aff", controller.GetAffCode)
 				selfRoute.GET("/topup/info", controller.GetTopUpInfo)
 				selfRoute.GET("/topup/self", controller.GetUserTopUps)
+				selfRoute.GET("/topup/:id/invoice", controller.GetTopUpInvoice)
+				selfRoute.GET("/statement", controller.GetSelfStatement)
+				selfRoute.PUT("/billing_profile", controller.UpdateBillingProfile)
 				selfRoute.POST("/topup", middleware.CriticalRateLimit(), controller.TopUp)
 				selfRoute.POST("/pay", middleware.CriticalRateLimit(), controller.RequestEpay)
 				selfRoute.POST("/amount", controller.RequestAmount)
@@ -130,6 +133,7 @@ func SetApiRouter(router *gin.Engine) {
 				adminRoute.GET("/topup", controller.GetAllTopUps)
 				adminRoute.POST("/topup/complete", controller.AdminCompleteTopUp)
 				adminRoute.GET("/search", controller.SearchUsers)
+				adminRoute.GET("/:id/statement", controller.GetUserStatement)
 				adminRoute.GET("/:id/oauth/bindings", controller.GetUserOAuthBindingsByAdmin)
 				adminRoute.DELETE("/:id/oauth/bindings/:provider_id", controller.UnbindCustomOAuthByAdmin)
 				adminRoute.DELETE("/:id/bindings/:binding_type", controller.AdminClearUserBinding)
@@ -174,6 +178,57 @@ func SetApiRouter(router *gin.Engine) {
 			subscriptionAdminRoute.DELETE("/user_subscriptions/:id", controller.AdminDeleteUserSubscription)
 		}
 
+		// Organizations (shared wallet, member roles)
+		organizationRoute := apiRouter.Group("/organization")
+		{
+			organizationSelfRoute := organizationRoute.Group("/self")
+			organizationSelfRoute.Use(middleware.UserAuth())
+			{
+				organizationSelfRoute.GET("", controller.GetSelfOrganization)
+				organizationSelfRoute.POST("/members", controller.AddSelfOrganizationMember)
+				organizationSelfRoute.PUT("/members", controller.UpdateSelfOrganizationMember)
+				organizationSelfRoute.DELETE("/members/:user_id", controller.RemoveSelfOrganizationMember)
+				organizationSelfRoute.POST("/leave", controller.LeaveSelfOrganization)
+				organizationSelfRoute.GET("/invitations", controller.GetSelfOrganizationInvitations)
+				organizationSelfRoute.POST("/invitations/:id/accept", controller.AcceptSelfOrganizationInvitation)
+				organizationSelfRoute.DELETE("/invitations/:id", controller.DeleteSelfOrganizationInvitation)
+			}
+
+			organizationAdminRoute := organizationRoute.Group("/")
+			organizationAdminRoute.Use(middleware.AdminAuth())
+			{
+				organizationAdminRoute.GET("/", controller.GetAllOrganizations)
+				organizationAdminRoute.GET("/:id", controller.GetOrganization)
+				organizationAdminRoute.POST("/", controller.CreateOrganization)
+				organizationAdminRoute.PUT("/", controller.UpdateOrganization)
+				organizationAdminRoute.DELETE("/:id", controller.DeleteOrganization)
+				organizationAdminRoute.POST("/:id/quota", controller.AdjustOrganizationQuota)
+				organizationAdminRoute.POST("/:id/members", controller.AdminAddOrganizationMember)
+				organizationAdminRoute.PUT("/:id/members", controller.AdminUpdateOrganizationMember)
+				organizationAdminRoute.DELETE("/:id/members/:user_id", controller.AdminRemoveOrganizationMember)
+			}
+		}
+
+		// Rolling budgets (daily / weekly / monthly caps on users and tokens)
+		budgetRoute := apiRouter.Group("/budget")
+		{
+			budgetSelfRoute := budgetRoute.Group("/self")
+			budgetSelfRoute.Use(middleware.UserAuth())
+			{
+				budgetSelfRoute.GET("", controller.GetSelfBudgets)
+				budgetSelfRoute.POST("", controller.SaveSelfBudget)
+				budgetSelfRoute.DELETE("/:id", controller.DeleteSelfBudget)
+			}
+
+			budgetAdminRoute := budgetRoute.Group("/")
+			budgetAdminRoute.Use(middleware.AdminAuth())
+			{
+				budgetAdminRoute.GET("/", controller.GetBudgets)
+				budgetAdminRoute.POST("/", controller.SaveBudget)
+				budgetAdminRoute.DELETE("/:id", controller.DeleteBudget)
+			}
+		}
+
 		// Subscription payment callbacks (no auth)
 		apiRouter.POST("/subscription/epay/notify", controller.SubscriptionEpayNotify)
 		apiRouter.GET("/subscription/epay/notify", controller.SubscriptionEpayNotify)
@@ -230,6 +285,8 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.GET("/search", controller.SearchChannels)
 			channelRoute.GET("/models", controller.ChannelListModels)
 			channelRoute.GET("/models_enabled", controller.EnabledListModels)
+			channelRoute.GET("/health", controller.GetChannelHealth)
+			channelRoute.POST("/:id/health/reset", controller.ResetChannelHealth)
 			channelRoute.GET("/:id", controller.GetChannel)
 			channelRoute.POST("/:id/key", middleware.RootAuth(), middleware.CriticalRateLimit(), middleware.DisableCache(), middleware.SecureVerificationRequired(), controller.GetChannelKey)
 			channelRoute.GET("/test", controller.TestAllChannels)
@@ -310,11 +367,15 @@ func SetApiRouter(router *gin.Engine) {
 		logRoute.GET("/search", middleware.AdminAuth(), controller.SearchAllLogs)
 		logRoute.GET("/self", middleware.UserAuth(), controller.GetUserLogs)
 		logRoute.GET("/self/search", middleware.UserAuth(), middleware.SearchRateLimit(), controller.SearchUserLogs)
+		logRoute.GET("/export", middleware.AdminAuth(), controller.ExportAllLogs)
+		logRoute.GET("/self/export", middleware.UserAuth(), middleware.SearchRateLimit(), controller.ExportUserLogs)
 
 		dataRoute := apiRouter.Group("/data")
 		dataRoute.GET("/", middleware.AdminAuth(), controller.GetAllQuotaDates)
 		dataRoute.GET("/users", middleware.AdminAuth(), controller.GetQuotaDatesByUser)
 		dataRoute.GET("/self", middleware.UserAuth(), controller.GetUserQuotaDates)
+		dataRoute.GET("/export", middleware.AdminAuth(), controller.ExportAllUsageData)
+		dataRoute.GET("/self/export", middleware.UserAuth(), middleware.SearchRateLimit(), controller.ExportUserUsageData)
 
 		logRoute.Use(middleware.CORS(), middleware.CriticalRateLimit())
 		{
@@ -342,6 +403,7 @@ func SetApiRouter(router *gin.Engine) {
 		taskRoute := apiRouter.Group("/task")
 		{
 			taskRoute.GET("/self", middleware.UserAuth(), controller.GetUserTask)
+			taskRoute.GET("/self/:task_id/webhook_deliveries", middleware.UserAuth(), controller.GetUserTaskWebhookDeliveries)
 			taskRoute.GET("/", middleware.AdminAuth(), controller.GetAllTask)
 		}
 
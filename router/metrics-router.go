@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/QuantumNous/new-api/middleware"
+	prommetrics "github.com/QuantumNous/new-api/pkg/prom_metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetMetricsRouter(router *gin.Engine) {
+	router.GET("/metrics", middleware.RouteTag("metrics"), middleware.MetricsAuth(), gin.WrapH(prommetrics.Handler()))
+}
@@ -16,9 +16,16 @@ func SetVideoRouter(router *gin.Engine) {
 		videoProxyRouter.GET("/videos/:task_id/content", controller.VideoProxy)
 	}
 
+	// 已转存的任务结果，凭签名地址下载
+	assetRouter := router.Group("/v1")
+	assetRouter.Use(middleware.RouteTag("relay"))
+	{
+		assetRouter.GET("/assets/:asset_id", controller.TaskAssetDownload)
+	}
+
 	videoV1Router := router.Group("/v1")
 	videoV1Router.Use(middleware.RouteTag("relay"))
-	videoV1Router.Use(middleware.TokenAuth(), middleware.Distribute())
+	videoV1Router.Use(middleware.TokenAuth(), middleware.TokenRateLimit(), middleware.Distribute())
 	{
 		videoV1Router.POST("/video/generations", controller.RelayTask)
 		videoV1Router.GET("/video/generations/:task_id", controller.RelayTaskFetch)
@@ -31,9 +38,20 @@ func SetVideoRouter(router *gin.Engine) {
 		videoV1Router.GET("/videos/:task_id", controller.RelayTaskFetch)
 	}
 
+	// 任务取消与列表只操作已有任务，不经过渠道分发
+	videoTaskRouter := router.Group("/v1")
+	videoTaskRouter.Use(middleware.RouteTag("relay"))
+	videoTaskRouter.Use(middleware.TokenAuth(), middleware.TokenRateLimit())
+	{
+		videoTaskRouter.GET("/videos", controller.RelayVideoList)
+		videoTaskRouter.DELETE("/videos/:task_id", controller.RelayTaskCancel)
+		videoTaskRouter.POST("/videos/:video_id/cancel", controller.RelayTaskCancel)
+		videoTaskRouter.POST("/video/generations/:task_id/cancel", controller.RelayTaskCancel)
+	}
+
 	klingV1Router := router.Group("/kling/v1")
 	klingV1Router.Use(middleware.RouteTag("relay"))
-	klingV1Router.Use(middleware.KlingRequestConvert(), middleware.TokenAuth(), middleware.Distribute())
+	klingV1Router.Use(middleware.KlingRequestConvert(), middleware.TokenAuth(), middleware.TokenRateLimit(), middleware.Distribute())
 	{
 		klingV1Router.POST("/videos/text2video", controller.RelayTask)
 		klingV1Router.POST("/videos/image2video", controller.RelayTask)
@@ -44,7 +62,7 @@ func SetVideoRouter(router *gin.Engine) {
 	// Jimeng official API routes - direct mapping to official API format
 	jimengOfficialGroup := router.Group("jimeng")
 	jimengOfficialGroup.Use(middleware.RouteTag("relay"))
-	jimengOfficialGroup.Use(middleware.JimengRequestConvert(), middleware.TokenAuth(), middleware.Distribute())
+	jimengOfficialGroup.Use(middleware.JimengRequestConvert(), middleware.TokenAuth(), middleware.TokenRateLimit(), middleware.Distribute())
 	{
 		// Maps to: /?Action=CVSync2AsyncSubmitTask&Version=2022-08-31 and /?Action=CVSync2AsyncGetResult&Version=2022-08-31
 		jimengOfficialGroup.POST("/", controller.RelayTask)
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+)
+
+const (
+	BatchCompletionWindow = "24h"
+	BatchFilePurpose      = "batch"
+	BatchOutputPurpose    = "batch_output"
+
+	batchQueryLimit   = 100
+	batchPollInterval = 60   // 同一批处理两次轮询的最小间隔（秒）
+	batchExpireGrace  = 3600 // 超过完成窗口后仍无法获取上游状态，额外等待的时间（秒）
+	batchMaxLineBytes = 32 << 20
+)
+
+var errBatchChannelUnsupported = errors.New("channel does not support batch api")
+
+// batchEndpoints 支持批处理的接口
+var batchEndpoints = map[string]bool{
+	"/v1/chat/completions": true,
+	"/v1/completions":      true,
+	"/v1/embeddings":       true,
+	"/v1/responses":        true,
+	"/v1/messages":         true,
+}
+
+// IsBatchEndpointSupported 判断 endpoint 是否支持批处理
+func IsBatchEndpointSupported(endpoint string) bool {
+	return batchEndpoints[endpoint]
+}
+
+// BatchEndpointType 返回选择渠道时该 endpoint 批处理所需的端点类型
+// OpenAI/Azure 使用 Batch API，Claude 使用 Message Batches（仅支持 /v1/messages）
+func BatchEndpointType(endpoint string) constant.EndpointType {
+	if endpoint == "/v1/messages" {
+		return constant.EndpointTypeAnthropicBatch
+	}
+	return constant.EndpointTypeOpenAIBatch
+}
+
+// IsBatchChannelSupported 判断渠道是否能以原生批处理接口执行该 endpoint 的批处理
+func IsBatchChannelSupported(channelType int, endpoint string) bool {
+	return common.ChannelSupportsEndpointType(channelType, BatchEndpointType(endpoint))
+}
+
+// BatchInput 解析后的批处理输入文件
+type BatchInput struct {
+	Model           string
+	Lines           []dto.OpenAIBatchInputLine
+	EstimatedTokens int // 所有请求预估的输入 token 与 max_tokens 之和，用于预扣费
+}
+
+// batchLineBody 仅解析校验与预扣费需要的字段
+type batchLineBody struct {
+	Model               string `json:"model"`
+	Stream              bool   `json:"stream"`
+	MaxTokens           int    `json:"max_tokens"`
+	MaxCompletionTokens int    `json:"max_completion_tokens"`
+	MaxOutputTokens     int    `json:"max_output_tokens"`
+}
+
+// ParseBatchInput 解析并校验批处理输入文件：每行的 url 必须与 endpoint 一致，所有请求必须使用同一个模型
+func ParseBatchInput(r io.Reader, endpoint string, maxRequests int) (*BatchInput, error) {
+	input := &BatchInput{}
+	customIds := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), batchMaxLineBytes)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line dto.OpenAIBatchInputLine
+		if err := common.Unmarshal(raw, &line); err != nil {
+			return nil, fmt.Errorf("line %d: invalid json: %s", lineNo, err.Error())
+		}
+		if line.CustomID == "" {
+			return nil, fmt.Errorf("line %d: custom_id is required", lineNo)
+		}
+		if _, ok := customIds[line.CustomID]; ok {
+			return nil, fmt.Errorf("line %d: duplicate custom_id %s", lineNo, line.CustomID)
+		}
+		customIds[line.CustomID] = struct{}{}
+		if !strings.EqualFold(line.Method, "POST") {
+			return nil, fmt.Errorf("line %d: method must be POST", lineNo)
+		}
+		if line.URL != endpoint {
+			return nil, fmt.Errorf("line %d: url %s does not match batch endpoint %s", lineNo, line.URL, endpoint)
+		}
+		var body batchLineBody
+		if err := common.Unmarshal(line.Body, &body); err != nil {
+			return nil, fmt.Errorf("line %d: invalid body: %s", lineNo, err.Error())
+		}
+		if body.Model == "" {
+			return nil, fmt.Errorf("line %d: body.model is required", lineNo)
+		}
+		if input.Model == "" {
+			input.Model = body.Model
+		} else if input.Model != body.Model {
+			return nil, fmt.Errorf("line %d: all requests in a batch must use the same model, got %s and %s", lineNo, input.Model, body.Model)
+		}
+		if body.Stream {
+			return nil, fmt.Errorf("line %d: stream is not supported in batch requests", lineNo)
+		}
+		if maxRequests > 0 && len(input.Lines) >= maxRequests {
+			return nil, fmt.Errorf("batch contains more than %d requests", maxRequests)
+		}
+		line.Body = bytes.Clone(line.Body)
+		input.Lines = append(input.Lines, line)
+		input.EstimatedTokens += EstimateTokenByModel(body.Model, string(line.Body)) +
+			max(body.MaxTokens, body.MaxCompletionTokens, body.MaxOutputTokens)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch input: %w", err)
+	}
+	if len(input.Lines) == 0 {
+		return nil, errors.New("batch input file is empty")
+	}
+	return input, nil
+}
+
+// replaceBatchBodyModel 替换请求体中的模型名，保留其他字段原样
+func replaceBatchBodyModel(body json.RawMessage, modelName string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := common.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	modelValue, err := common.Marshal(modelName)
+	if err != nil {
+		return nil, err
+	}
+	fields["model"] = modelValue
+	return common.Marshal(fields)
+}
+
+// encodeBatchLines 重新生成上游输入 JSONL，替换模型名与请求路径
+func encodeBatchLines(lines []dto.OpenAIBatchInputLine, modelName string, url string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		body, err := replaceBatchBodyModel(line.Body, modelName)
+		if err != nil {
+			return nil, fmt.Errorf("custom_id %s: %w", line.CustomID, err)
+		}
+		line.Body = body
+		line.URL = url
+		data, err := common.Marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// batchUpstreamState 上游批处理的最新状态（已统一为 OpenAI 批处理状态）
+type batchUpstreamState struct {
+	Status       string
+	Total        int
+	Completed    int
+	Failed       int
+	InProgressAt int64
+	FinalizingAt int64
+	CompletedAt  int64
+	FailedAt     int64
+	ExpiredAt    int64
+	CancellingAt int64
+	CancelledAt  int64
+	Errors       []dto.OpenAIBatchError
+	OutputFileId string // 上游输出文件 ID（OpenAI/Azure）
+	ErrorFileId  string // 上游错误文件 ID（OpenAI/Azure）
+}
+
+// batchCollectResult 批处理终态时读取输出结果的汇总
+type batchCollectResult struct {
+	Accumulator *batchQuotaAccumulator
+	OutputFile  *model.File // 尚未写入数据库的输出文件记录
+	ErrorFile   *model.File
+}
+
+// batchProvider 上游原生批处理接口
+type batchProvider interface {
+	// submit 提交批处理，成功后填充 batch.UpstreamBatchId、KeyIndex 与初始状态
+	submit(ctx context.Context, channel *model.Channel, batch *model.Batch, file *model.File, input *BatchInput) error
+	fetch(ctx context.Context, channel *model.Channel, batch *model.Batch) (*batchUpstreamState, error)
+	cancel(ctx context.Context, channel *model.Channel, batch *model.Batch) error
+	// collect 读取输出结果并逐行计算额度
+	collect(ctx context.Context, channel *model.Channel, batch *model.Batch, state *batchUpstreamState) (*batchCollectResult, error)
+	// cleanup 清理提交时生成的上游临时资源
+	cleanup(ctx context.Context, channel *model.Channel, batch *model.Batch)
+}
+
+func getBatchProvider(channelType int) batchProvider {
+	switch channelType {
+	case constant.ChannelTypeOpenAI, constant.ChannelTypeAzure:
+		return openAIBatchProvider{}
+	case constant.ChannelTypeAnthropic:
+		return claudeBatchProvider{}
+	}
+	return nil
+}
+
+// newBatchOutputFile 为批处理结果创建文件记录（调用方负责写入数据库）
+func newBatchOutputFile(batch *model.Batch, suffix string) *model.File {
+	now := common.GetTimestamp()
+	return &model.File{
+		FileId:    model.GenerateFileID(),
+		UserId:    batch.UserId,
+		TokenId:   batch.TokenId,
+		Purpose:   BatchOutputPurpose,
+		Filename:  fmt.Sprintf("%s_%s.jsonl", batch.BatchId, suffix),
+		Status:    dto.FileStatusProcessed,
+		CreatedAt: now,
+		ExpiresAt: fileExpiresAt(now),
+	}
+}
+
+// SubmitBatch 将批处理提交到上游渠道
+func SubmitBatch(ctx context.Context, channel *model.Channel, batch *model.Batch, file *model.File, input *BatchInput) error {
+	provider := getBatchProvider(channel.Type)
+	if provider == nil || !IsBatchChannelSupported(channel.Type, batch.Endpoint) {
+		return errBatchChannelUnsupported
+	}
+	return provider.submit(ctx, channel, batch, file, input)
+}
+
+// CancelBatch 请求上游取消批处理，并将状态更新为 cancelling，由轮询循环完成后续结算
+func CancelBatch(ctx context.Context, batch *model.Batch) error {
+	if batch.IsLocal {
+		return cancelLocalBatch(ctx, batch)
+	}
+	channel, err := model.CacheGetChannel(batch.ChannelId)
+	if err != nil {
+		return err
+	}
+	provider := getBatchProvider(channel.Type)
+	if provider == nil {
+		return errBatchChannelUnsupported
+	}
+	if err := provider.cancel(ctx, channel, batch); err != nil {
+		return err
+	}
+	oldStatus := batch.Status
+	batch.Status = dto.BatchStatusCancelling
+	batch.CancellingAt = common.GetTimestamp()
+	won, err := batch.UpdateWithStatus(oldStatus)
+	if err != nil {
+		return err
+	}
+	if !won {
+		return fmt.Errorf("batch %s status changed concurrently", batch.BatchId)
+	}
+	return nil
+}
+
+// AbortSubmittedBatch 撤销已提交到上游但未能保存到本地的批处理：取消上游任务并清理临时资源。
+// 本地没有记录的批处理不会被轮询，不撤销的话上游会继续执行且结果无人结算
+func AbortSubmittedBatch(ctx context.Context, batch *model.Batch) error {
+	channel, err := model.CacheGetChannel(batch.ChannelId)
+	if err != nil {
+		return err
+	}
+	provider := getBatchProvider(channel.Type)
+	if provider == nil {
+		return errBatchChannelUnsupported
+	}
+	err = provider.cancel(ctx, channel, batch)
+	provider.cleanup(ctx, channel, batch)
+	return err
+}
+
+// UpdateBatches 同步所有未完成批处理的上游状态，由 TaskPollingLoop 每轮调用
+func UpdateBatches(ctx context.Context) {
+	batches, err := model.GetUnfinishedBatches(batchQueryLimit)
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("get unfinished batches failed: %s", err.Error()))
+		return
+	}
+	now := time.Now().Unix()
+	for _, batch := range batches {
+		if now-batch.UpdatedAt < batchPollInterval {
+			continue
+		}
+		if err := updateBatch(ctx, batch); err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("update batch %s failed: %s", batch.BatchId, err.Error()))
+		}
+	}
+}
+
+func applyBatchState(batch *model.Batch, state *batchUpstreamState) {
+	batch.Status = state.Status
+	if state.Total > 0 {
+		batch.RequestTotal = state.Total
+	}
+	batch.RequestCompleted = state.Completed
+	batch.RequestFailed = state.Failed
+	batch.InProgressAt = state.InProgressAt
+	batch.FinalizingAt = state.FinalizingAt
+	batch.CompletedAt = state.CompletedAt
+	batch.FailedAt = state.FailedAt
+	batch.ExpiredAt = state.ExpiredAt
+	if state.CancellingAt > 0 {
+		batch.CancellingAt = state.CancellingAt
+	}
+	batch.CancelledAt = state.CancelledAt
+	if len(state.Errors) > 0 {
+		batch.SetErrors(state.Errors)
+	}
+}
+
+func updateBatch(ctx context.Context, batch *model.Batch) error {
+	channel, err := model.CacheGetChannel(batch.ChannelId)
+	if err != nil {
+		return expireStaleBatch(ctx, batch, err)
+	}
+	provider := getBatchProvider(channel.Type)
+	if provider == nil {
+		return expireStaleBatch(ctx, batch, errBatchChannelUnsupported)
+	}
+	state, err := provider.fetch(ctx, channel, batch)
+	if err != nil {
+		return expireStaleBatch(ctx, batch, err)
+	}
+
+	oldStatus := batch.Status
+	applyBatchState(batch, state)
+	if !model.IsBatchStatusFinished(batch.Status) {
+		_, err := batch.UpdateWithStatus(oldStatus)
+		return err
+	}
+
+	// 终态：读取输出文件逐行计算额度，CAS 成功后再登记文件并结算，保证只结算一次
+	result, err := provider.collect(ctx, channel, batch, state)
+	if err != nil {
+		return fmt.Errorf("collect batch output: %w", err)
+	}
+	if result.OutputFile != nil {
+		batch.OutputFileId = result.OutputFile.FileId
+	}
+	if result.ErrorFile != nil {
+		batch.ErrorFileId = result.ErrorFile.FileId
+	}
+	won, err := batch.UpdateWithStatus(oldStatus)
+	if err != nil || !won {
+		discardBatchFiles(result)
+		return err
+	}
+	for _, file := range []*model.File{result.OutputFile, result.ErrorFile} {
+		if file == nil {
+			continue
+		}
+		if err := file.Insert(); err != nil {
+			logger.LogError(ctx, fmt.Sprintf("insert output file of batch %s failed: %s", batch.BatchId, err.Error()))
+		}
+	}
+	settleBatch(ctx, batch, result.Accumulator)
+	provider.cleanup(ctx, channel, batch)
+	return nil
+}
+
+// settleBatch 按逐行累计的实际额度对批处理进行差额结算
+func settleBatch(ctx context.Context, batch *model.Batch, acc *batchQuotaAccumulator) {
+	reason := fmt.Sprintf("批处理%s：成功 %d 个请求，输入 %d tokens，输出 %d tokens",
+		batch.Status, acc.Succeeded, acc.PromptTokens, acc.CompletionTokens)
+	RecalculateBatchQuota(ctx, batch, acc.Quota(), reason)
+	if err := model.DB.Model(batch).Update("quota", batch.Quota).Error; err != nil {
+		logger.LogError(ctx, fmt.Sprintf("update quota of batch %s failed: %s", batch.BatchId, err.Error()))
+	}
+}
+
+func discardBatchFiles(result *batchCollectResult) {
+	for _, file := range []*model.File{result.OutputFile, result.ErrorFile} {
+		if file != nil && file.IsLocal() {
+			_ = common.RemoveStoredFile(file.FileId)
+		}
+	}
+}
+
+// expireStaleBatch 上游长时间不可用（渠道被删除、请求持续失败）且已超过完成窗口时，将批处理标记为过期并全额退款
+func expireStaleBatch(ctx context.Context, batch *model.Batch, cause error) error {
+	now := time.Now().Unix()
+	if batch.ExpiresAt == 0 || now < batch.ExpiresAt+batchExpireGrace {
+		return cause
+	}
+	oldStatus := batch.Status
+	batch.Status = dto.BatchStatusExpired
+	batch.ExpiredAt = now
+	batch.SetErrors([]dto.OpenAIBatchError{{Code: "upstream_unavailable", Message: cause.Error()}})
+	won, err := batch.UpdateWithStatus(oldStatus)
+	if err != nil || !won {
+		return err
+	}
+	RefundBatchQuota(ctx, batch, "批处理上游状态不可用，已过期")
+	if err := model.DB.Model(batch).Update("quota", batch.Quota).Error; err != nil {
+		logger.LogError(ctx, fmt.Sprintf("update quota of batch %s failed: %s", batch.BatchId, err.Error()))
+	}
+	return nil
+}
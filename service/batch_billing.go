@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 批处理计费：提交时按预估 token 预扣费，完成后按输出文件逐行结算
+// ---------------------------------------------------------------------------
+
+// batchLineUsage 单行请求的用量（已统一为不含缓存的输入 token）
+type batchLineUsage struct {
+	PromptTokens        int
+	CachedTokens        int
+	CacheCreationTokens int
+	CompletionTokens    int
+}
+
+// batchQuotaAccumulator 逐行累计批处理额度
+type batchQuotaAccumulator struct {
+	bc               *model.BatchBillingContext
+	quota            float64
+	Succeeded        int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+func newBatchQuotaAccumulator(bc *model.BatchBillingContext) *batchQuotaAccumulator {
+	if bc == nil {
+		bc = &model.BatchBillingContext{}
+	}
+	return &batchQuotaAccumulator{bc: bc}
+}
+
+// add 累计一个成功请求的额度
+func (a *batchQuotaAccumulator) add(usage batchLineUsage) {
+	a.Succeeded++
+	a.PromptTokens += usage.PromptTokens + usage.CachedTokens + usage.CacheCreationTokens
+	a.CompletionTokens += usage.CompletionTokens
+	bc := a.bc
+	if bc.UsePrice {
+		a.quota += bc.ModelPrice * common.QuotaPerUnit * bc.GroupRatio * bc.BatchRatio
+		return
+	}
+	tokens := float64(usage.PromptTokens) +
+		float64(usage.CachedTokens)*bc.CacheRatio +
+		float64(usage.CacheCreationTokens)*bc.CacheCreationRatio +
+		float64(usage.CompletionTokens)*bc.CompletionRatio
+	a.quota += tokens * bc.ModelRatio * bc.GroupRatio * bc.BatchRatio
+}
+
+func (a *batchQuotaAccumulator) Quota() int {
+	return int(math.Round(a.quota))
+}
+
+// EstimateBatchQuota 根据输入文件的预估 token 计算批处理的预扣额度
+func EstimateBatchQuota(bc *model.BatchBillingContext, input *BatchInput) int {
+	if bc.UsePrice {
+		return int(math.Round(bc.ModelPrice * common.QuotaPerUnit * bc.GroupRatio * bc.BatchRatio * float64(len(input.Lines))))
+	}
+	return int(math.Round(float64(input.EstimatedTokens) * bc.ModelRatio * bc.GroupRatio * bc.BatchRatio))
+}
+
+// openAIBatchLineUsage 从 OpenAI 批处理输出行的响应体中解析用量，兼容 chat/completions、responses 与 embeddings
+func openAIBatchLineUsage(body []byte) batchLineUsage {
+	var resp struct {
+		Usage *dto.Usage `json:"usage"`
+	}
+	if err := common.Unmarshal(body, &resp); err != nil || resp.Usage == nil {
+		return batchLineUsage{}
+	}
+	u := resp.Usage
+	prompt, completion, cached := u.PromptTokens, u.CompletionTokens, u.PromptTokensDetails.CachedTokens
+	if prompt == 0 && u.InputTokens > 0 {
+		prompt, completion = u.InputTokens, u.OutputTokens
+		if u.InputTokensDetails != nil {
+			cached = u.InputTokensDetails.CachedTokens
+		}
+	}
+	if cached > prompt {
+		cached = prompt
+	}
+	return batchLineUsage{
+		PromptTokens:     prompt - cached,
+		CachedTokens:     cached,
+		CompletionTokens: completion,
+	}
+}
+
+// claudeBatchLineUsage 从 Claude 批处理结果中的 message 解析用量
+func claudeBatchLineUsage(message []byte) batchLineUsage {
+	var msg struct {
+		Usage *dto.ClaudeUsage `json:"usage"`
+	}
+	if err := common.Unmarshal(message, &msg); err != nil || msg.Usage == nil {
+		return batchLineUsage{}
+	}
+	return batchLineUsage{
+		PromptTokens:        msg.Usage.InputTokens,
+		CachedTokens:        msg.Usage.CacheReadInputTokens,
+		CacheCreationTokens: msg.Usage.CacheCreationInputTokens,
+		CompletionTokens:    msg.Usage.OutputTokens,
+	}
+}
+
+func batchBillingAccount(batch *model.Batch) asyncBillingAccount {
+	return asyncBillingAccount{
+		UserId:         batch.UserId,
+		TokenId:        batch.TokenId,
+		BillingSource:  batch.PrivateData.BillingSource,
+		SubscriptionId: batch.PrivateData.SubscriptionId,
+		OrganizationId: batch.PrivateData.OrganizationId,
+		CreatedAt:      batch.CreatedAt,
+		Kind:           "批处理",
+		RefId:          batch.BatchId,
+		ChannelId:      batch.ChannelId,
+		ModelName:      batch.ModelName,
+		Group:          batch.Group,
+	}
+}
+
+// batchBillingOther 从批处理的 BillingContext 构建日志 Other 字段
+func batchBillingOther(batch *model.Batch) map[string]interface{} {
+	other := make(map[string]interface{})
+	other["is_batch"] = true
+	other["batch_id"] = batch.BatchId
+	if bc := batch.PrivateData.BillingContext; bc != nil {
+		other["model_price"] = bc.ModelPrice
+		if bc.ModelRatio > 0 {
+			other["model_ratio"] = bc.ModelRatio
+			other["completion_ratio"] = bc.CompletionRatio
+		}
+		other["group_ratio"] = bc.GroupRatio
+		other["batch_ratio"] = bc.BatchRatio
+		if bc.UpstreamModelName != "" && bc.UpstreamModelName != batch.ModelName {
+			other["is_model_mapped"] = true
+			other["upstream_model_name"] = bc.UpstreamModelName
+		}
+	}
+	return other
+}
+
+// LogBatchConsumption 记录批处理提交时的预扣费日志（实际扣费已由 BillingSession 完成）
+func LogBatchConsumption(c *gin.Context, info *relaycommon.RelayInfo, batch *model.Batch) {
+	other := batchBillingOther(batch)
+	other["request_path"] = c.Request.URL.Path
+	other["request_count"] = batch.RequestTotal
+	model.RecordConsumeLog(c, info.UserId, model.RecordConsumeLogParams{
+		ChannelId: info.ChannelId,
+		ModelName: batch.ModelName,
+		TokenName: c.GetString("token_name"),
+		Quota:     batch.Quota,
+		Content:   fmt.Sprintf("批处理 %s，共 %d 个请求，按预估用量预扣费", batch.BatchId, batch.RequestTotal),
+		TokenId:   info.TokenId,
+		Group:     info.UsingGroup,
+		Other:     other,
+	})
+	model.UpdateUserUsedQuotaAndRequestCount(info.UserId, batch.Quota)
+	model.UpdateChannelUsedQuota(info.ChannelId, batch.Quota)
+}
+
+// RefundBatchQuota 批处理失败时退还全部预扣额度
+func RefundBatchQuota(ctx context.Context, batch *model.Batch, reason string) {
+	quota := batch.Quota
+	if quota == 0 {
+		return
+	}
+	if batchBillingAccount(batch).refund(ctx, quota, reason, batchBillingOther(batch)) {
+		batch.Quota = 0
+	}
+}
+
+// RecalculateBatchQuota 批处理完成后的差额结算，actualQuota 为按输出文件逐行计算的实际额度。
+// actualQuota 为 0（没有任何成功的请求）时全额退款。
+func RecalculateBatchQuota(ctx context.Context, batch *model.Batch, actualQuota int, reason string) {
+	if actualQuota <= 0 {
+		RefundBatchQuota(ctx, batch, reason)
+		return
+	}
+	if batchBillingAccount(batch).recalculate(ctx, batch.Quota, actualQuota, reason, batchBillingOther(batch)) {
+		batch.Quota = actualQuota
+	}
+}
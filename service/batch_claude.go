@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+)
+
+const claudeBatchAnthropicVersion = "2023-06-01"
+
+// claudeBatchProvider Claude Message Batches API
+// docs: https://docs.anthropic.com/en/docs/build-with-claude/batch-processing
+// Claude 的结果不是文件，完成后下载结果保存为本地输出文件（每行为 Claude 原生结果格式）。
+type claudeBatchProvider struct{}
+
+func (claudeBatchProvider) submit(ctx context.Context, channel *model.Channel, batch *model.Batch, _ *model.File, input *BatchInput) error {
+	upstreamModel := batch.ModelName
+	if bc := batch.PrivateData.BillingContext; bc != nil && bc.UpstreamModelName != "" {
+		upstreamModel = bc.UpstreamModelName
+	}
+	request := dto.ClaudeMessageBatchRequest{Requests: make([]dto.ClaudeMessageBatchItem, 0, len(input.Lines))}
+	for _, line := range input.Lines {
+		params := line.Body
+		if upstreamModel != batch.ModelName {
+			var err error
+			params, err = replaceBatchBodyModel(line.Body, upstreamModel)
+			if err != nil {
+				return fmt.Errorf("custom_id %s: %w", line.CustomID, err)
+			}
+		}
+		request.Requests = append(request.Requests, dto.ClaudeMessageBatchItem{CustomID: line.CustomID, Params: params})
+	}
+	reqBody, err := common.Marshal(request)
+	if err != nil {
+		return err
+	}
+	_, keyIndex, apiErr := channel.GetNextEnabledKey()
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	batch.KeyIndex = keyIndex
+	var upstream dto.ClaudeMessageBatch
+	if err := doClaudeBatchJSON(ctx, channel, keyIndex, http.MethodPost, "", reqBody, &upstream); err != nil {
+		return err
+	}
+	if upstream.ID == "" {
+		return errors.New("upstream returned empty batch id")
+	}
+	batch.UpstreamBatchId = upstream.ID
+	applyBatchState(batch, claudeBatchState(&upstream))
+	if expiresAt := parseClaudeBatchTime(upstream.ExpiresAt); expiresAt > 0 {
+		batch.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (claudeBatchProvider) fetch(ctx context.Context, channel *model.Channel, batch *model.Batch) (*batchUpstreamState, error) {
+	var upstream dto.ClaudeMessageBatch
+	if err := doClaudeBatchJSON(ctx, channel, batch.KeyIndex, http.MethodGet, "/"+batch.UpstreamBatchId, nil, &upstream); err != nil {
+		return nil, err
+	}
+	return claudeBatchState(&upstream), nil
+}
+
+func (claudeBatchProvider) cancel(ctx context.Context, channel *model.Channel, batch *model.Batch) error {
+	var upstream dto.ClaudeMessageBatch
+	return doClaudeBatchJSON(ctx, channel, batch.KeyIndex, http.MethodPost, "/"+batch.UpstreamBatchId+"/cancel", nil, &upstream)
+}
+
+func (claudeBatchProvider) collect(ctx context.Context, channel *model.Channel, batch *model.Batch, _ *batchUpstreamState) (*batchCollectResult, error) {
+	result := &batchCollectResult{Accumulator: newBatchQuotaAccumulator(batch.PrivateData.BillingContext)}
+	resp, err := doClaudeBatchRequest(ctx, channel, batch.KeyIndex, http.MethodGet, "/"+batch.UpstreamBatchId+"/results", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		// 没有任何结果（例如提交后立即取消）
+		return result, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, readUpstreamError(resp)
+	}
+
+	outputFile := newBatchOutputFile(batch, "output")
+	size, err := common.SaveStoredFile(outputFile.FileId, resp.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	outputFile.Stored = true
+	outputFile.Bytes = size
+	result.OutputFile = outputFile
+	if err := accumulateClaudeBatchResults(outputFile.FileId, result.Accumulator); err != nil {
+		_ = common.RemoveStoredFile(outputFile.FileId)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (claudeBatchProvider) cleanup(context.Context, *model.Channel, *model.Batch) {}
+
+// accumulateClaudeBatchResults 逐行读取本地保存的结果文件，累计成功请求的额度
+func accumulateClaudeBatchResults(fileId string, acc *batchQuotaAccumulator) error {
+	reader, err := common.OpenStoredFile(fileId)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), batchMaxLineBytes)
+	for scanner.Scan() {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		var line dto.ClaudeMessageBatchResult
+		if err := common.Unmarshal(raw, &line); err != nil {
+			continue
+		}
+		if line.Result.Type != "succeeded" {
+			continue
+		}
+		acc.add(claudeBatchLineUsage(line.Result.Message))
+	}
+	return scanner.Err()
+}
+
+// doClaudeBatchRequest 使用渠道的指定 key 请求 Message Batches 接口，path 为 /v1/messages/batches 之后的部分
+func doClaudeBatchRequest(ctx context.Context, channel *model.Channel, keyIndex int, method string, path string, body []byte) (*http.Response, error) {
+	key := channelKeyAt(channel, keyIndex)
+	if key == "" {
+		return nil, fmt.Errorf("channel #%d has no key at index %d", channel.Id, keyIndex)
+	}
+	baseURL := strings.TrimSuffix(channel.GetBaseURL(), "/")
+	if baseURL == "" {
+		baseURL = constant.ChannelBaseURLs[channel.Type]
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+"/v1/messages/batches"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("x-api-key", key)
+	req.Header.Set("anthropic-version", claudeBatchAnthropicVersion)
+	client, err := GetHttpClientWithProxy(channel.GetSetting().Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+func doClaudeBatchJSON(ctx context.Context, channel *model.Channel, keyIndex int, method string, path string, body []byte, v any) error {
+	resp, err := doClaudeBatchRequest(ctx, channel, keyIndex, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readUpstreamError(resp)
+	}
+	return common.DecodeJson(resp.Body, v)
+}
+
+func parseClaudeBatchTime(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// claudeBatchState 将 Claude 批处理状态映射为 OpenAI 批处理状态
+func claudeBatchState(upstream *dto.ClaudeMessageBatch) *batchUpstreamState {
+	counts := upstream.RequestCounts
+	state := &batchUpstreamState{
+		Total:        counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+		Completed:    counts.Succeeded,
+		Failed:       counts.Errored + counts.Canceled + counts.Expired,
+		InProgressAt: parseClaudeBatchTime(upstream.CreatedAt),
+		CancellingAt: parseClaudeBatchTime(upstream.CancelInitiatedAt),
+	}
+	endedAt := parseClaudeBatchTime(upstream.EndedAt)
+	switch upstream.ProcessingStatus {
+	case "canceling":
+		state.Status = dto.BatchStatusCancelling
+	case "ended":
+		switch {
+		case state.CancellingAt > 0:
+			state.Status = dto.BatchStatusCancelled
+			state.CancelledAt = endedAt
+		case counts.Expired > 0 && counts.Succeeded+counts.Errored == 0:
+			state.Status = dto.BatchStatusExpired
+			state.ExpiredAt = endedAt
+		default:
+			state.Status = dto.BatchStatusCompleted
+			state.CompletedAt = endedAt
+		}
+	default:
+		state.Status = dto.BatchStatusInProgress
+	}
+	return state
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+)
+
+// ---------------------------------------------------------------------------
+// 本地批处理：没有原生批处理接口的渠道由 new-api 逐行执行（执行器见 controller/batch_local.go），
+// 每行按普通请求计费，结果逐段追加写入本地输出/错误文件。
+// ---------------------------------------------------------------------------
+
+// BatchLocalLineResult 本地执行单行请求的结果
+type BatchLocalLineResult struct {
+	CustomID   string
+	StatusCode int
+	RequestID  string
+	Body       []byte
+	Error      *dto.OpenAIBatchError // 请求未执行（例如批处理过期）时的错误
+}
+
+// LoadBatchInput 重新读取并解析批处理的输入文件
+func LoadBatchInput(ctx context.Context, batch *model.Batch) (*BatchInput, error) {
+	file, exists, err := model.GetTokenFile(batch.UserId, batch.TokenId, batch.InputFileId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("input file %s not found", batch.InputFileId)
+	}
+	reader, err := OpenFileContent(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ParseBatchInput(reader, batch.Endpoint, 0)
+}
+
+func newBatchRequestID() string {
+	key, _ := common.GenerateRandomCharsKey(24)
+	return "batch_req_" + key
+}
+
+// PrepareBatchLocalFiles 在执行任何一行之前分配并保存输出/错误文件 ID，
+// 保证崩溃后仍能根据结果文件恢复进度
+func PrepareBatchLocalFiles(batch *model.Batch) error {
+	private := &batch.PrivateData
+	if private.LocalOutputFileId != "" && private.LocalErrorFileId != "" {
+		return nil
+	}
+	if private.LocalOutputFileId == "" {
+		private.LocalOutputFileId = model.GenerateFileID()
+	}
+	if private.LocalErrorFileId == "" {
+		private.LocalErrorFileId = model.GenerateFileID()
+	}
+	return batch.UpdateLocalProgress()
+}
+
+// RecoverBatchLocalResults 读取已写入的输出/错误文件，返回已有结果的 custom_id，并按文件内容修正计数。
+// 结果写入文件与保存进度不是原子操作，进程在两者之间退出时，重启后需要跳过这些行，避免重复执行与计费
+func RecoverBatchLocalResults(batch *model.Batch) (map[string]struct{}, error) {
+	done := make(map[string]struct{})
+	completed, err := readBatchLocalResultFile(batch.PrivateData.LocalOutputFileId, done)
+	if err != nil {
+		return nil, err
+	}
+	failed, err := readBatchLocalResultFile(batch.PrivateData.LocalErrorFileId, done)
+	if err != nil {
+		return nil, err
+	}
+	batch.RequestCompleted = completed
+	batch.RequestFailed = failed
+	return done, nil
+}
+
+// readBatchLocalResultFile 收集结果文件中的 custom_id 并返回完整行数，末尾写了一半的行会被截掉
+func readBatchLocalResultFile(fileId string, done map[string]struct{}) (int, error) {
+	if fileId == "" {
+		return 0, nil
+	}
+	file, err := common.OpenStoredFile(fileId)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var offset int64
+	count := 0
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(raw) > 0 {
+				return count, common.TruncateStoredFile(fileId, offset)
+			}
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += int64(len(raw))
+		var line dto.OpenAIBatchOutputLine
+		if err := common.Unmarshal(raw, &line); err != nil {
+			return 0, fmt.Errorf("invalid result line in file %s: %w", fileId, err)
+		}
+		done[line.CustomID] = struct{}{}
+		count++
+	}
+}
+
+// AppendBatchLocalResults 将一组执行结果追加写入输出/错误文件，并将游标推进 processed 行、更新计数（调用方负责保存）。
+// processed 可以大于结果数，多出的是恢复时跳过的已有结果的行
+func AppendBatchLocalResults(batch *model.Batch, processed int, results []BatchLocalLineResult) error {
+	var output, errorOutput bytes.Buffer
+	completed, failed := 0, 0
+	for _, result := range results {
+		line := dto.OpenAIBatchOutputLine{
+			ID:       newBatchRequestID(),
+			CustomID: result.CustomID,
+			Error:    result.Error,
+		}
+		if result.Error == nil {
+			body := result.Body
+			if !json.Valid(body) {
+				body, _ = common.Marshal(string(result.Body))
+			}
+			line.Response = &dto.OpenAIBatchOutputResponse{
+				StatusCode: result.StatusCode,
+				RequestID:  result.RequestID,
+				Body:       body,
+			}
+		}
+		data, err := common.Marshal(line)
+		if err != nil {
+			return err
+		}
+		if result.Error == nil && result.StatusCode == http.StatusOK {
+			output.Write(data)
+			output.WriteByte('\n')
+			completed++
+		} else {
+			errorOutput.Write(data)
+			errorOutput.WriteByte('\n')
+			failed++
+		}
+	}
+	private := &batch.PrivateData
+	if output.Len() > 0 {
+		if private.LocalOutputFileId == "" {
+			private.LocalOutputFileId = model.GenerateFileID()
+		}
+		if _, err := common.AppendStoredFile(private.LocalOutputFileId, output.Bytes()); err != nil {
+			return err
+		}
+	}
+	if errorOutput.Len() > 0 {
+		if private.LocalErrorFileId == "" {
+			private.LocalErrorFileId = model.GenerateFileID()
+		}
+		if _, err := common.AppendStoredFile(private.LocalErrorFileId, errorOutput.Bytes()); err != nil {
+			return err
+		}
+	}
+	private.LocalCursor += processed
+	batch.RequestCompleted += completed
+	batch.RequestFailed += failed
+	return nil
+}
+
+// newBatchLocalOutputFile 为本地执行过程中写入的结果文件创建文件记录，文件为空时返回 nil
+func newBatchLocalOutputFile(batch *model.Batch, fileId string, suffix string) (*model.File, error) {
+	size, err := common.AppendStoredFile(fileId, nil)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		_ = common.RemoveStoredFile(fileId)
+		return nil, nil
+	}
+	file := newBatchOutputFile(batch, suffix)
+	file.FileId = fileId
+	file.Stored = true
+	file.Bytes = size
+	return file, nil
+}
+
+// FinalizeLocalBatch 以 fromStatus 为条件将本地批处理更新为终态，并登记输出/错误文件
+func FinalizeLocalBatch(ctx context.Context, batch *model.Batch, fromStatus string, status string) (bool, error) {
+	result := &batchCollectResult{}
+	var err error
+	if fileId := batch.PrivateData.LocalOutputFileId; fileId != "" {
+		if result.OutputFile, err = newBatchLocalOutputFile(batch, fileId, "output"); err != nil {
+			return false, err
+		}
+		if result.OutputFile != nil {
+			batch.OutputFileId = fileId
+		}
+	}
+	if fileId := batch.PrivateData.LocalErrorFileId; fileId != "" {
+		if result.ErrorFile, err = newBatchLocalOutputFile(batch, fileId, "error"); err != nil {
+			return false, err
+		}
+		if result.ErrorFile != nil {
+			batch.ErrorFileId = fileId
+		}
+	}
+
+	now := common.GetTimestamp()
+	batch.Status = status
+	switch status {
+	case dto.BatchStatusCompleted:
+		batch.FinalizingAt = now
+		batch.CompletedAt = now
+	case dto.BatchStatusExpired:
+		batch.ExpiredAt = now
+	case dto.BatchStatusCancelled:
+		batch.CancelledAt = now
+	case dto.BatchStatusFailed:
+		batch.FailedAt = now
+	}
+	won, err := batch.UpdateWithStatus(fromStatus)
+	if err != nil || !won {
+		return won, err
+	}
+	for _, file := range []*model.File{result.OutputFile, result.ErrorFile} {
+		if file == nil {
+			continue
+		}
+		if err := file.Insert(); err != nil {
+			logger.LogError(ctx, fmt.Sprintf("failed to insert output file %s of batch %s: %s", file.FileId, batch.BatchId, err.Error()))
+		}
+	}
+	logger.LogInfo(ctx, fmt.Sprintf("local batch %s finished: status=%s completed=%d failed=%d", batch.BatchId, status, batch.RequestCompleted, batch.RequestFailed))
+	return true, nil
+}
+
+// FailLocalBatch 本地批处理无法执行时标记为失败
+func FailLocalBatch(ctx context.Context, batch *model.Batch, code string, message string) error {
+	batch.SetErrors([]dto.OpenAIBatchError{{Code: code, Message: message}})
+	_, err := FinalizeLocalBatch(ctx, batch, batch.Status, dto.BatchStatusFailed)
+	return err
+}
+
+// ExpireLocalBatch 本地批处理超过完成时间窗口：未执行的请求写入错误文件后标记为过期
+func ExpireLocalBatch(ctx context.Context, batch *model.Batch) error {
+	input, err := LoadBatchInput(ctx, batch)
+	if err == nil && batch.PrivateData.LocalCursor < len(input.Lines) {
+		done, err := RecoverBatchLocalResults(batch)
+		if err != nil {
+			return err
+		}
+		remaining := input.Lines[batch.PrivateData.LocalCursor:]
+		results := make([]BatchLocalLineResult, 0, len(remaining))
+		for _, line := range remaining {
+			if _, ok := done[line.CustomID]; ok {
+				continue
+			}
+			results = append(results, BatchLocalLineResult{
+				CustomID: line.CustomID,
+				Error: &dto.OpenAIBatchError{
+					Code:    "batch_expired",
+					Message: "This request could not be executed before the completion window expired.",
+				},
+			})
+		}
+		if err := AppendBatchLocalResults(batch, len(remaining), results); err != nil {
+			return err
+		}
+	}
+	_, err = FinalizeLocalBatch(ctx, batch, batch.Status, dto.BatchStatusExpired)
+	return err
+}
+
+// cancelLocalBatch 本地批处理的取消：尚未开始执行时直接取消，否则交由执行器在当前分段完成后结束
+func cancelLocalBatch(ctx context.Context, batch *model.Batch) error {
+	if batch.Status == dto.BatchStatusValidating {
+		won, err := FinalizeLocalBatch(ctx, batch, dto.BatchStatusValidating, dto.BatchStatusCancelled)
+		if err == nil && !won {
+			err = errors.New("batch status changed concurrently")
+		}
+		return err
+	}
+	oldStatus := batch.Status
+	batch.Status = dto.BatchStatusCancelling
+	batch.CancellingAt = common.GetTimestamp()
+	won, err := batch.UpdateColumnsWithStatus(oldStatus, "status", "cancelling_at")
+	if err == nil && !won {
+		err = fmt.Errorf("batch %s status changed concurrently", batch.BatchId)
+	}
+	return err
+}
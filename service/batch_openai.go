@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+)
+
+// openAIBatchProvider OpenAI / Azure OpenAI Batch API
+// docs: https://platform.openai.com/docs/api-reference/batch
+type openAIBatchProvider struct{}
+
+func (openAIBatchProvider) submit(ctx context.Context, channel *model.Channel, batch *model.Batch, file *model.File, input *BatchInput) error {
+	// Azure 的请求路径不带 /v1 前缀
+	url := batch.Endpoint
+	if channel.Type == constant.ChannelTypeAzure {
+		url = strings.TrimPrefix(url, "/v1")
+	}
+	upstreamModel := batch.ModelName
+	if bc := batch.PrivateData.BillingContext; bc != nil && bc.UpstreamModelName != "" {
+		upstreamModel = bc.UpstreamModelName
+	}
+
+	var upstreamFileId string
+	var keyIndex int
+	var err error
+	if upstreamModel != batch.ModelName || url != batch.Endpoint {
+		// 需要改写模型名或路径时，重新生成输入文件上传到上游
+		data, encodeErr := encodeBatchLines(input.Lines, upstreamModel, url)
+		if encodeErr != nil {
+			return encodeErr
+		}
+		upstreamFileId, keyIndex, err = UploadFileToChannel(ctx, channel, batch.BatchId+"_input.jsonl", BatchFilePurpose, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		batch.PrivateData.UpstreamInputFileId = upstreamFileId
+	} else {
+		upstreamFileId, keyIndex, err = EnsureFileOnChannel(ctx, file, channel)
+		if err != nil {
+			return err
+		}
+	}
+
+	reqBody, err := common.Marshal(map[string]any{
+		"input_file_id":     upstreamFileId,
+		"endpoint":          url,
+		"completion_window": batch.CompletionWindow,
+	})
+	if err != nil {
+		return err
+	}
+	batch.KeyIndex = keyIndex
+	var upstream dto.OpenAIBatch
+	err = doOpenAIBatchJSON(ctx, channel, keyIndex, http.MethodPost, "/batches", reqBody, &upstream)
+	if err == nil && upstream.ID == "" {
+		err = errors.New("upstream returned empty batch id")
+	}
+	if err != nil {
+		openAIBatchProvider{}.cleanup(ctx, channel, batch)
+		return err
+	}
+	batch.UpstreamBatchId = upstream.ID
+	applyBatchState(batch, openAIBatchState(&upstream))
+	if upstream.ExpiresAt > 0 {
+		batch.ExpiresAt = upstream.ExpiresAt
+	}
+	return nil
+}
+
+func (openAIBatchProvider) fetch(ctx context.Context, channel *model.Channel, batch *model.Batch) (*batchUpstreamState, error) {
+	var upstream dto.OpenAIBatch
+	if err := doOpenAIBatchJSON(ctx, channel, batch.KeyIndex, http.MethodGet, "/batches/"+batch.UpstreamBatchId, nil, &upstream); err != nil {
+		return nil, err
+	}
+	return openAIBatchState(&upstream), nil
+}
+
+func (openAIBatchProvider) cancel(ctx context.Context, channel *model.Channel, batch *model.Batch) error {
+	var upstream dto.OpenAIBatch
+	return doOpenAIBatchJSON(ctx, channel, batch.KeyIndex, http.MethodPost, "/batches/"+batch.UpstreamBatchId+"/cancel", nil, &upstream)
+}
+
+func (openAIBatchProvider) collect(ctx context.Context, channel *model.Channel, batch *model.Batch, state *batchUpstreamState) (*batchCollectResult, error) {
+	result := &batchCollectResult{Accumulator: newBatchQuotaAccumulator(batch.PrivateData.BillingContext)}
+	if state.OutputFileId != "" {
+		outputFile := newBatchOutputFile(batch, "output")
+		outputFile.ChannelId = channel.Id
+		outputFile.UpstreamFileId = state.OutputFileId
+		outputFile.KeyIndex = batch.KeyIndex
+		size, err := accumulateOpenAIBatchOutput(ctx, channel, outputFile, result.Accumulator)
+		if err != nil {
+			return nil, err
+		}
+		outputFile.Bytes = size
+		result.OutputFile = outputFile
+	}
+	if state.ErrorFileId != "" {
+		errorFile := newBatchOutputFile(batch, "error")
+		errorFile.ChannelId = channel.Id
+		errorFile.UpstreamFileId = state.ErrorFileId
+		errorFile.KeyIndex = batch.KeyIndex
+		var upstreamFile dto.OpenAIFile
+		if err := doOpenAIBatchJSON(ctx, channel, batch.KeyIndex, http.MethodGet, "/files/"+state.ErrorFileId, nil, &upstreamFile); err == nil {
+			errorFile.Bytes = upstreamFile.Bytes
+		}
+		result.ErrorFile = errorFile
+	}
+	return result, nil
+}
+
+func (openAIBatchProvider) cleanup(ctx context.Context, channel *model.Channel, batch *model.Batch) {
+	if batch.PrivateData.UpstreamInputFileId == "" {
+		return
+	}
+	tmp := &model.File{ChannelId: channel.Id, UpstreamFileId: batch.PrivateData.UpstreamInputFileId, KeyIndex: batch.KeyIndex}
+	if err := deleteChannelFile(ctx, tmp); err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("failed to delete upstream input file of batch %s: %s", batch.BatchId, err.Error()))
+	}
+}
+
+// accumulateOpenAIBatchOutput 逐行读取上游输出文件，累计成功请求的额度，返回文件大小
+func accumulateOpenAIBatchOutput(ctx context.Context, channel *model.Channel, outputFile *model.File, acc *batchQuotaAccumulator) (int64, error) {
+	resp, err := DoChannelOpenAIRequest(ctx, channel, outputFile.KeyIndex, http.MethodGet, "/files/"+outputFile.UpstreamFileId+"/content", nil, "")
+	if err != nil {
+		return 0, err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, readUpstreamError(resp)
+	}
+	var size int64
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), batchMaxLineBytes)
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		size += int64(len(raw)) + 1
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var line dto.OpenAIBatchOutputLine
+		if err := common.Unmarshal(raw, &line); err != nil {
+			continue
+		}
+		if line.Response == nil || line.Response.StatusCode != http.StatusOK {
+			continue
+		}
+		acc.add(openAIBatchLineUsage(line.Response.Body))
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// doOpenAIBatchJSON 发送 JSON 请求并解析响应
+func doOpenAIBatchJSON(ctx context.Context, channel *model.Channel, keyIndex int, method string, path string, body []byte, v any) error {
+	var resp *http.Response
+	var err error
+	if body != nil {
+		resp, err = DoChannelOpenAIRequest(ctx, channel, keyIndex, method, path, bytes.NewReader(body), "application/json")
+	} else {
+		resp, err = DoChannelOpenAIRequest(ctx, channel, keyIndex, method, path, nil, "")
+	}
+	if err != nil {
+		return err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readUpstreamError(resp)
+	}
+	return common.DecodeJson(resp.Body, v)
+}
+
+func openAIBatchState(upstream *dto.OpenAIBatch) *batchUpstreamState {
+	state := &batchUpstreamState{
+		Status:       upstream.Status,
+		Total:        upstream.RequestCounts.Total,
+		Completed:    upstream.RequestCounts.Completed,
+		Failed:       upstream.RequestCounts.Failed,
+		InProgressAt: upstream.InProgressAt,
+		FinalizingAt: upstream.FinalizingAt,
+		CompletedAt:  upstream.CompletedAt,
+		FailedAt:     upstream.FailedAt,
+		ExpiredAt:    upstream.ExpiredAt,
+		CancellingAt: upstream.CancellingAt,
+		CancelledAt:  upstream.CancelledAt,
+		OutputFileId: upstream.OutputFileID,
+		ErrorFileId:  upstream.ErrorFileID,
+	}
+	if upstream.Errors != nil {
+		state.Errors = upstream.Errors.Data
+	}
+	return state
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ===========================================================================
+// ParseBatchInput tests
+// ===========================================================================
+
+func TestParseBatchInput_Valid(t *testing.T) {
+	data := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hi"}],"max_tokens":10}}`,
+		``,
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hello"}]}}`,
+	}, "\n")
+	input, err := ParseBatchInput(strings.NewReader(data), "/v1/chat/completions", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini", input.Model)
+	assert.Len(t, input.Lines, 2)
+	assert.Greater(t, input.EstimatedTokens, 10)
+}
+
+func TestParseBatchInput_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "duplicate custom_id",
+			data: `{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m","input":"x"}}` + "\n" +
+				`{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m","input":"y"}}`,
+			want: "duplicate custom_id",
+		},
+		{
+			name: "url mismatch",
+			data: `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"m"}}`,
+			want: "does not match",
+		},
+		{
+			name: "mixed models",
+			data: `{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m1","input":"x"}}` + "\n" +
+				`{"custom_id":"b","method":"POST","url":"/v1/embeddings","body":{"model":"m2","input":"y"}}`,
+			want: "same model",
+		},
+		{
+			name: "stream",
+			data: `{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m","stream":true}}`,
+			want: "stream",
+		},
+		{
+			name: "empty",
+			data: "\n\n",
+			want: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBatchInput(strings.NewReader(tt.data), "/v1/embeddings", 0)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestParseBatchInput_MaxRequests(t *testing.T) {
+	line := `{"custom_id":"%s","method":"POST","url":"/v1/embeddings","body":{"model":"m","input":"x"}}`
+	data := strings.Replace(line, "%s", "a", 1) + "\n" + strings.Replace(line, "%s", "b", 1)
+	_, err := ParseBatchInput(strings.NewReader(data), "/v1/embeddings", 1)
+	require.Error(t, err)
+}
+
+// ===========================================================================
+// Batch quota tests
+// ===========================================================================
+
+func TestBatchQuotaAccumulator_Ratio(t *testing.T) {
+	acc := newBatchQuotaAccumulator(&model.BatchBillingContext{
+		ModelRatio:      2,
+		CompletionRatio: 4,
+		CacheRatio:      0.1,
+		GroupRatio:      1,
+		BatchRatio:      0.5,
+	})
+	acc.add(openAIBatchLineUsage([]byte(`{"usage":{"prompt_tokens":100,"completion_tokens":10,"prompt_tokens_details":{"cached_tokens":50}}}`)))
+	acc.add(openAIBatchLineUsage([]byte(`{"usage":{"input_tokens":20,"output_tokens":5}}`)))
+
+	// (50 + 50*0.1 + 10*4) * 2 * 0.5 = 95; (20 + 5*4) * 2 * 0.5 = 40
+	assert.Equal(t, 135, acc.Quota())
+	assert.Equal(t, 2, acc.Succeeded)
+	assert.Equal(t, 120, acc.PromptTokens)
+	assert.Equal(t, 15, acc.CompletionTokens)
+}
+
+func TestBatchQuotaAccumulator_Price(t *testing.T) {
+	acc := newBatchQuotaAccumulator(&model.BatchBillingContext{
+		ModelPrice: 0.01,
+		UsePrice:   true,
+		GroupRatio: 1,
+		BatchRatio: 0.5,
+	})
+	acc.add(claudeBatchLineUsage([]byte(`{"usage":{"input_tokens":10,"output_tokens":10}}`)))
+	acc.add(batchLineUsage{})
+	assert.Equal(t, int(0.01*common.QuotaPerUnit*0.5*2), acc.Quota())
+}
+
+func makeBatch(userId, channelId, quota, tokenId int, billingSource string, subscriptionId int) *model.Batch {
+	return &model.Batch{
+		BatchId:   model.GenerateBatchID(),
+		UserId:    userId,
+		TokenId:   tokenId,
+		ChannelId: channelId,
+		Group:     "default",
+		ModelName: "test-model",
+		Status:    dto.BatchStatusInProgress,
+		Quota:     quota,
+		PrivateData: model.BatchPrivateData{
+			BillingSource:  billingSource,
+			SubscriptionId: subscriptionId,
+			BillingContext: &model.BatchBillingContext{ModelRatio: 1, GroupRatio: 1, BatchRatio: 0.5},
+		},
+	}
+}
+
+func TestRecalculateBatchQuota_Refund(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 1, 1, 1
+	seedUser(t, userID, 10000)
+	seedToken(t, tokenID, userID, "sk-batch-key", 5000)
+	seedChannel(t, channelID)
+
+	batch := makeBatch(userID, channelID, 3000, tokenID, BillingSourceWallet, 0)
+	RecalculateBatchQuota(ctx, batch, 1000, "batch completed")
+
+	assert.Equal(t, 12000, getUserQuota(t, userID))
+	assert.Equal(t, 7000, getTokenRemainQuota(t, tokenID))
+	assert.Equal(t, 1000, batch.Quota)
+	log := getLastLog(t)
+	require.NotNil(t, log)
+	assert.Equal(t, model.LogTypeRefund, log.Type)
+	assert.Equal(t, 2000, log.Quota)
+}
+
+func TestRecalculateBatchQuota_ExtraCharge(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 1, 1, 1
+	seedUser(t, userID, 10000)
+	seedToken(t, tokenID, userID, "sk-batch-key", 5000)
+	seedChannel(t, channelID)
+
+	batch := makeBatch(userID, channelID, 1000, tokenID, BillingSourceWallet, 0)
+	RecalculateBatchQuota(ctx, batch, 1500, "batch completed")
+
+	assert.Equal(t, 9500, getUserQuota(t, userID))
+	assert.Equal(t, 4500, getTokenRemainQuota(t, tokenID))
+	log := getLastLog(t)
+	require.NotNil(t, log)
+	assert.Equal(t, model.LogTypeConsume, log.Type)
+	assert.Equal(t, 500, log.Quota)
+}
+
+func TestRecalculateBatchQuota_NothingSucceeded(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, subID, channelID = 1, 1, 1
+	seedUser(t, userID, 10000)
+	seedSubscription(t, subID, userID, 100000, 2000)
+	seedChannel(t, channelID)
+
+	batch := makeBatch(userID, channelID, 2000, 0, BillingSourceSubscription, subID)
+	RecalculateBatchQuota(ctx, batch, 0, "batch failed")
+
+	assert.Equal(t, int64(0), getSubscriptionUsed(t, subID))
+	assert.Equal(t, 10000, getUserQuota(t, userID))
+	assert.Equal(t, 0, batch.Quota)
+}
+
+// ===========================================================================
+// Local batch tests
+// ===========================================================================
+
+func useTempFileStorage(t *testing.T) {
+	t.Helper()
+	original := common.FileStoragePath
+	common.FileStoragePath = t.TempDir()
+	t.Cleanup(func() { common.FileStoragePath = original })
+}
+
+func TestLocalBatch_AppendAndFinalize(t *testing.T) {
+	truncate(t)
+	useTempFileStorage(t)
+	ctx := context.Background()
+
+	batch := makeBatch(1, 0, 0, 1, "", 0)
+	batch.IsLocal = true
+	batch.RequestTotal = 3
+	require.NoError(t, batch.Insert())
+
+	require.NoError(t, AppendBatchLocalResults(batch, 2, []BatchLocalLineResult{
+		{CustomID: "a", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-1"}`)},
+		{CustomID: "b", StatusCode: 429, Body: []byte(`rate limited`)},
+	}))
+	require.NoError(t, AppendBatchLocalResults(batch, 1, []BatchLocalLineResult{
+		{CustomID: "c", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-3"}`)},
+	}))
+	require.NoError(t, batch.UpdateLocalProgress())
+	assert.Equal(t, 3, batch.PrivateData.LocalCursor)
+	assert.Equal(t, 2, batch.RequestCompleted)
+	assert.Equal(t, 1, batch.RequestFailed)
+
+	won, err := FinalizeLocalBatch(ctx, batch, dto.BatchStatusInProgress, dto.BatchStatusCompleted)
+	require.NoError(t, err)
+	require.True(t, won)
+
+	output, exists, err := model.GetTokenFile(1, 1, batch.OutputFileId)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, BatchOutputPurpose, output.Purpose)
+	reader, err := common.OpenStoredFile(output.FileId)
+	require.NoError(t, err)
+	defer reader.Close()
+	var lines []dto.OpenAIBatchOutputLine
+	for _, raw := range strings.Split(strings.TrimSpace(readAll(t, reader)), "\n") {
+		var line dto.OpenAIBatchOutputLine
+		require.NoError(t, common.UnmarshalJsonStr(raw, &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "a", lines[0].CustomID)
+	assert.Equal(t, "c", lines[1].CustomID)
+
+	errorFile, exists, err := model.GetTokenFile(1, 1, batch.ErrorFileId)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Greater(t, errorFile.Bytes, int64(0))
+
+	// 已完成的批处理不会再被更新
+	won, err = FinalizeLocalBatch(ctx, batch, dto.BatchStatusInProgress, dto.BatchStatusCancelled)
+	require.NoError(t, err)
+	assert.False(t, won)
+}
+
+func TestLocalBatch_RecoverAfterCrash(t *testing.T) {
+	truncate(t)
+	useTempFileStorage(t)
+
+	batch := makeBatch(1, 0, 0, 1, "", 0)
+	batch.IsLocal = true
+	batch.RequestTotal = 4
+	require.NoError(t, batch.Insert())
+	require.NoError(t, PrepareBatchLocalFiles(batch))
+	fileIds := batch.PrivateData
+
+	// 第一段结果已保存进度，第二段只写入了结果文件，进程随后退出
+	require.NoError(t, AppendBatchLocalResults(batch, 1, []BatchLocalLineResult{
+		{CustomID: "a", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-1"}`)},
+	}))
+	require.NoError(t, batch.UpdateLocalProgress())
+	require.NoError(t, AppendBatchLocalResults(batch, 2, []BatchLocalLineResult{
+		{CustomID: "b", StatusCode: 500, Body: []byte(`upstream error`)},
+		{CustomID: "c", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-3"}`)},
+	}))
+	_, err := common.AppendStoredFile(fileIds.LocalOutputFileId, []byte(`{"custom_id":"d"`))
+	require.NoError(t, err)
+
+	reloaded, exists, err := model.GetTokenBatch(1, 1, batch.BatchId)
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, 1, reloaded.PrivateData.LocalCursor)
+	assert.Equal(t, fileIds.LocalOutputFileId, reloaded.PrivateData.LocalOutputFileId)
+
+	done, err := RecoverBatchLocalResults(reloaded)
+	require.NoError(t, err)
+	assert.Len(t, done, 3)
+	assert.Contains(t, done, "c")
+	assert.NotContains(t, done, "d")
+	assert.Equal(t, 2, reloaded.RequestCompleted)
+	assert.Equal(t, 1, reloaded.RequestFailed)
+
+	// 写了一半的行被截掉，之后追加的结果仍是合法的 JSONL
+	require.NoError(t, AppendBatchLocalResults(reloaded, 3, []BatchLocalLineResult{
+		{CustomID: "d", StatusCode: 200, Body: []byte(`{"id":"chatcmpl-4"}`)},
+	}))
+	done, err = RecoverBatchLocalResults(reloaded)
+	require.NoError(t, err)
+	assert.Len(t, done, 4)
+	assert.Equal(t, 3, reloaded.RequestCompleted)
+}
+
+func TestCancelBatch_LocalValidating(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	batch := makeBatch(1, 0, 0, 1, "", 0)
+	batch.IsLocal = true
+	batch.Status = dto.BatchStatusValidating
+	require.NoError(t, batch.Insert())
+
+	require.NoError(t, CancelBatch(ctx, batch))
+	status, err := model.GetBatchStatus(batch.Id)
+	require.NoError(t, err)
+	assert.Equal(t, dto.BatchStatusCancelled, status)
+}
+
+func readAll(t *testing.T, r io.Reader) string {
+	t.Helper()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
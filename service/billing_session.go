@@ -1,12 +1,15 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/model"
 	relaycommon "github.com/QuantumNous/new-api/relay/common"
@@ -33,6 +36,10 @@ type BillingSession struct {
 	settled          bool // Settle 全部完成（资金 + 令牌）
 	refunded         bool // Refund 已调用
 	mu               sync.Mutex
+	// 周期预算：budgetAt 为预留时间，结算与退款按同一时间定位预算窗口
+	budgets        []*model.Budget
+	budgetAt       time.Time
+	budgetReserved int
 }
 
 // Settle 根据实际消耗额度进行结算。
@@ -47,6 +54,7 @@ func (s *BillingSession) Settle(actualQuota int) error {
 	delta := actualQuota - s.preConsumedQuota
 	if delta == 0 {
 		s.settled = true
+		checkAndSendBudgetNotify(s.relayInfo, s.budgets, s.budgetAt)
 		return nil
 	}
 	// 1) 调整资金来源（仅在尚未提交时执行，防止重复调用）
@@ -70,11 +78,19 @@ func (s *BillingSession) Settle(actualQuota int) error {
 				s.relayInfo.UserId, s.relayInfo.TokenId, delta, tokenErr.Error()))
 		}
 	}
-	// 3) 更新 relayInfo 上的订阅 PostDelta（用于日志）
+	// 3) 调整周期预算已用额度（结算可能超出硬上限，下一次请求将被拒绝）
+	if err := model.AdjustBudgets(s.budgets, s.budgetAt, delta); err != nil {
+		common.SysLog(fmt.Sprintf("error adjusting budget usage (userId=%d, tokenId=%d, delta=%d): %s",
+			s.relayInfo.UserId, s.relayInfo.TokenId, delta, err.Error()))
+	} else {
+		s.budgetReserved += delta
+	}
+	// 4) 更新 relayInfo 上的订阅 PostDelta（用于日志）
 	if s.funding.Source() == BillingSourceSubscription {
 		s.relayInfo.SubscriptionPostDelta += int64(delta)
 	}
 	s.settled = true
+	checkAndSendBudgetNotify(s.relayInfo, s.budgets, s.budgetAt)
 	return tokenErr
 }
 
@@ -102,6 +118,9 @@ func (s *BillingSession) Refund(c *gin.Context) {
 	extraReserved := s.extraReserved
 	subscriptionId := s.relayInfo.SubscriptionId
 	funding := s.funding
+	budgets := s.budgets
+	budgetAt := s.budgetAt
+	budgetReserved := s.budgetReserved
 
 	gopool.Go(func() {
 		// 1) 退还资金来源
@@ -119,6 +138,12 @@ func (s *BillingSession) Refund(c *gin.Context) {
 				common.SysLog("error refunding token quota: " + err.Error())
 			}
 		}
+		// 3) 释放周期预算
+		if budgetReserved > 0 {
+			if err := model.AdjustBudgets(budgets, budgetAt, -budgetReserved); err != nil {
+				common.SysLog("error releasing budget usage: " + err.Error())
+			}
+		}
 	})
 }
 
@@ -134,7 +159,7 @@ func (s *BillingSession) needsRefundLocked() bool {
 		// fundingSettled 时资金来源已提交结算，不能再退预扣费
 		return false
 	}
-	if s.tokenConsumed > 0 {
+	if s.tokenConsumed > 0 || s.budgetReserved > 0 {
 		return true
 	}
 	// 订阅可能在 tokenConsumed=0 时仍预扣了额度
@@ -162,17 +187,23 @@ func (s *BillingSession) Reserve(targetQuota int) error {
 		return nil
 	}
 
+	if err := model.ReserveBudgets(s.budgets, s.budgetAt, delta); err != nil {
+		return newBudgetExceededError(err)
+	}
 	if err := s.reserveFunding(delta); err != nil {
+		s.releaseBudgets(delta)
 		return err
 	}
 	if err := s.reserveToken(delta); err != nil {
 		s.rollbackFundingReserve(delta)
+		s.releaseBudgets(delta)
 		return err
 	}
 
 	s.preConsumedQuota += delta
 	s.tokenConsumed += delta
 	s.extraReserved += delta
+	s.budgetReserved += delta
 	s.syncRelayInfo()
 	return nil
 }
@@ -181,11 +212,18 @@ func (s *BillingSession) Reserve(targetQuota int) error {
 // PreConsume — 统一预扣费入口（含信任额度旁路）
 // ---------------------------------------------------------------------------
 
-// preConsume 执行预扣费：信任检查 -> 令牌预扣 -> 资金来源预扣。
+// preConsume 执行预扣费：信任检查 -> 周期预算预留 -> 令牌预扣 -> 资金来源预扣。
 // 任一步骤失败时原子回滚已完成的步骤。
 func (s *BillingSession) preConsume(c *gin.Context, quota int) *types.NewAPIError {
 	effectiveQuota := quota
 
+	budgets, err := loadActiveBudgets(s.relayInfo.UserId, s.relayInfo.TokenId)
+	if err != nil {
+		return types.NewError(err, types.ErrorCodeQueryDataError, types.ErrOptionWithSkipRetry())
+	}
+	s.budgets = budgets
+	s.budgetAt = time.Now()
+
 	// ---- 信任额度旁路 ----
 	if s.shouldTrust(c) {
 		s.trusted = true
@@ -195,16 +233,24 @@ func (s *BillingSession) preConsume(c *gin.Context, quota int) *types.NewAPIErro
 		logger.LogInfo(c, fmt.Sprintf("用户 %d 需要预扣费 %s (funding=%s)", s.relayInfo.UserId, logger.FormatQuota(effectiveQuota), s.funding.Source()))
 	}
 
-	// ---- 1) 预扣令牌额度 ----
+	// ---- 1) 预留周期预算 ----
+	if err := model.ReserveBudgets(s.budgets, s.budgetAt, effectiveQuota); err != nil {
+		return newBudgetExceededError(err)
+	}
+	s.budgetReserved = effectiveQuota
+
+	// ---- 2) 预扣令牌额度 ----
 	if effectiveQuota > 0 {
 		if err := PreConsumeTokenQuota(s.relayInfo, effectiveQuota); err != nil {
+			s.releaseBudgets(s.budgetReserved)
 			return types.NewErrorWithStatusCode(err, types.ErrorCodePreConsumeTokenQuotaFailed, http.StatusForbidden, types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
 		}
 		s.tokenConsumed = effectiveQuota
 	}
 
-	// ---- 2) 预扣资金来源 ----
+	// ---- 3) 预扣资金来源 ----
 	if err := s.funding.PreConsume(effectiveQuota); err != nil {
+		s.releaseBudgets(s.budgetReserved)
 		// 预扣费失败，回滚令牌额度
 		if s.tokenConsumed > 0 && !s.relayInfo.IsPlayground {
 			if rollbackErr := model.IncreaseTokenQuota(s.relayInfo.TokenId, s.relayInfo.TokenKey, s.tokenConsumed); rollbackErr != nil {
@@ -218,6 +264,9 @@ func (s *BillingSession) preConsume(c *gin.Context, quota int) *types.NewAPIErro
 		if strings.Contains(errMsg, "no active subscription") || strings.Contains(errMsg, "subscription quota insufficient") {
 			return types.NewErrorWithStatusCode(fmt.Errorf("订阅额度不足或未配置订阅: %s", errMsg), types.ErrorCodeInsufficientUserQuota, http.StatusForbidden, types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
 		}
+		if isOrganizationQuotaError(err) {
+			return newOrganizationQuotaError(err)
+		}
 		return types.NewError(err, types.ErrorCodeUpdateDataError, types.ErrOptionWithSkipRetry())
 	}
 
@@ -225,6 +274,8 @@ func (s *BillingSession) preConsume(c *gin.Context, quota int) *types.NewAPIErro
 
 	// ---- 同步 RelayInfo 兼容字段 ----
 	s.syncRelayInfo()
+	// 日志按实际扣费的资金来源归属组织，组织令牌走订阅计费时不计入组织消费
+	common.SetContextKey(c, constant.ContextKeyBillingOrganizationId, s.relayInfo.BillingOrganizationId())
 
 	return nil
 }
@@ -248,6 +299,15 @@ func (s *BillingSession) reserveFunding(delta int) error {
 			)
 		}
 		return nil
+	case *OrganizationFunding:
+		if err := model.PreConsumeOrganizationQuota(funding.organizationId, funding.userId, delta); err != nil {
+			if isOrganizationQuotaError(err) {
+				return newOrganizationQuotaError(err)
+			}
+			return types.NewError(err, types.ErrorCodeUpdateDataError, types.ErrOptionWithSkipRetry())
+		}
+		funding.consumed += delta
+		return nil
 	default:
 		return types.NewError(fmt.Errorf("unsupported funding source: %s", s.funding.Source()), types.ErrorCodeUpdateDataError, types.ErrOptionWithSkipRetry())
 	}
@@ -265,7 +325,46 @@ func (s *BillingSession) rollbackFundingReserve(delta int) {
 		if err := model.PostConsumeUserSubscriptionDelta(funding.subscriptionId, -int64(delta)); err != nil {
 			common.SysLog("error rolling back subscription funding reserve: " + err.Error())
 		}
+	case *OrganizationFunding:
+		if err := model.AdjustOrganizationQuota(funding.organizationId, funding.userId, -delta); err != nil {
+			common.SysLog("error rolling back organization funding reserve: " + err.Error())
+		} else {
+			funding.consumed -= delta
+		}
+	}
+}
+
+// isOrganizationQuotaError 判断是否为组织余额不足、成员超出消费上限或组织被禁用等可回退的错误
+func isOrganizationQuotaError(err error) bool {
+	return errors.Is(err, model.ErrOrganizationQuotaInsufficient) ||
+		errors.Is(err, model.ErrOrganizationMemberCapExceeded) ||
+		errors.Is(err, model.ErrOrganizationDisabled)
+}
+
+func newOrganizationQuotaError(err error) *types.NewAPIError {
+	var msg string
+	switch {
+	case errors.Is(err, model.ErrOrganizationMemberCapExceeded):
+		msg = "已达到组织成员消费上限"
+	case errors.Is(err, model.ErrOrganizationDisabled):
+		msg = "所属组织已被禁用"
+	default:
+		msg = "组织额度不足"
+	}
+	return types.NewErrorWithStatusCode(errors.New(msg), types.ErrorCodeInsufficientUserQuota, http.StatusForbidden,
+		types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+}
+
+// releaseBudgets 回滚预留的周期预算，用于预扣失败的场景
+func (s *BillingSession) releaseBudgets(amount int) {
+	if amount <= 0 || len(s.budgets) == 0 {
+		return
+	}
+	if err := model.AdjustBudgets(s.budgets, s.budgetAt, -amount); err != nil {
+		common.SysLog(fmt.Sprintf("error releasing budget reserve (userId=%d, amount=%d): %s", s.relayInfo.UserId, amount, err.Error()))
+		return
 	}
+	s.budgetReserved -= amount
 }
 
 func (s *BillingSession) reserveToken(delta int) error {
@@ -290,6 +389,11 @@ func (s *BillingSession) shouldTrust(c *gin.Context) bool {
 		return false
 	}
 
+	// 配置了周期预算时必须按实际额度预留，否则无法在请求前拒绝超出预算的调用
+	if len(s.budgets) > 0 {
+		return false
+	}
+
 	// 检查令牌是否充足
 	tokenTrusted := s.relayInfo.TokenUnlimited
 	if !tokenTrusted {
@@ -309,6 +413,9 @@ func (s *BillingSession) shouldTrust(c *gin.Context) bool {
 		// 2. SubscriptionFunding.PreConsume 忽略参数，始终用 s.amount 预扣
 		// 3. 若信任旁路将 effectiveQuota 设为 0，会导致 preConsumedQuota 与实际订阅预扣不一致
 		return false
+	case BillingSourceOrganization:
+		// 组织钱包需要在预扣时校验成员消费上限，不能启用信任旁路
+		return false
 	default:
 		return false
 	}
@@ -346,8 +453,52 @@ func NewBillingSession(c *gin.Context, relayInfo *relaycommon.RelayInfo, preCons
 
 	pref := common.NormalizeBillingPreference(relayInfo.UserSetting.BillingPreference)
 
-	// 钱包路径需要先检查用户额度
+	// 组织令牌的钱包计费从组织共享钱包扣除，预先检查成员关系、组织状态、余额与成员消费上限
+	tryOrganization := func() (*BillingSession, *types.NewAPIError) {
+		org, err := model.GetOrganizationById(relayInfo.OrganizationId)
+		if err != nil {
+			return nil, types.NewError(err, types.ErrorCodeQueryDataError, types.ErrOptionWithSkipRetry())
+		}
+		if org.Status != model.OrganizationStatusEnabled {
+			return nil, newOrganizationQuotaError(model.ErrOrganizationDisabled)
+		}
+		if org.Quota <= 0 || org.Quota-preConsumedQuota < 0 {
+			return nil, types.NewErrorWithStatusCode(
+				fmt.Errorf("组织额度不足, 剩余额度: %s, 需要预扣费额度: %s", logger.FormatQuota(org.Quota), logger.FormatQuota(preConsumedQuota)),
+				types.ErrorCodeInsufficientUserQuota, http.StatusForbidden,
+				types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+		}
+		member, err := model.GetOrganizationMemberByUserId(relayInfo.UserId)
+		if err != nil && !errors.Is(err, model.ErrOrganizationMemberNotFound) {
+			return nil, types.NewError(err, types.ErrorCodeQueryDataError, types.ErrOptionWithSkipRetry())
+		}
+		if member == nil || member.OrganizationId != relayInfo.OrganizationId {
+			return nil, types.NewErrorWithStatusCode(errors.New("令牌绑定的组织已不包含当前用户"),
+				types.ErrorCodeAccessDenied, http.StatusForbidden,
+				types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+		}
+		if member.QuotaLimit > 0 && member.UsedQuota+preConsumedQuota > member.QuotaLimit {
+			return nil, newOrganizationQuotaError(model.ErrOrganizationMemberCapExceeded)
+		}
+
+		session := &BillingSession{
+			relayInfo: relayInfo,
+			funding: &OrganizationFunding{
+				organizationId: relayInfo.OrganizationId,
+				userId:         relayInfo.UserId,
+			},
+		}
+		if apiErr := session.preConsume(c, preConsumedQuota); apiErr != nil {
+			return nil, apiErr
+		}
+		return session, nil
+	}
+
+	// 钱包路径需要先检查用户额度；只有绑定组织的令牌才从组织钱包扣费，其余请求始终使用个人钱包
 	tryWallet := func() (*BillingSession, *types.NewAPIError) {
+		if relayInfo.OrganizationId != 0 {
+			return tryOrganization()
+		}
 		userQuota, err := model.GetUserQuota(relayInfo.UserId, false)
 		if err != nil {
 			return nil, types.NewError(err, types.ErrorCodeQueryDataError, types.ErrOptionWithSkipRetry())
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+// loadActiveBudgets 获取对本次请求生效的周期预算，未启用预算功能时返回空
+func loadActiveBudgets(userId int, tokenId int) ([]*model.Budget, error) {
+	if !operation_setting.GetBudgetSetting().Enabled {
+		return nil, nil
+	}
+	return model.GetActiveBudgets(userId, tokenId)
+}
+
+func budgetPeriodName(period string) string {
+	switch period {
+	case model.BudgetPeriodWeekly:
+		return "每周"
+	case model.BudgetPeriodMonthly:
+		return "每月"
+	default:
+		return "每日"
+	}
+}
+
+func budgetScopeName(budget *model.Budget) string {
+	if budget.TokenId > 0 {
+		return fmt.Sprintf("令牌 #%d", budget.TokenId)
+	}
+	return "账户"
+}
+
+// newBudgetExceededError 将预算超限转换为对客户端可见的错误，其他错误按数据更新失败处理
+func newBudgetExceededError(err error) *types.NewAPIError {
+	var exceeded *model.BudgetExceededError
+	if !errors.As(err, &exceeded) {
+		return types.NewError(err, types.ErrorCodeUpdateDataError, types.ErrOptionWithSkipRetry())
+	}
+	budget := exceeded.Budget
+	_, windowEnd := model.BudgetWindow(budget.PeriodType, time.Now())
+	resetAt := time.Unix(windowEnd, 0).In(operation_setting.GetBudgetLocation()).Format("2006-01-02 15:04:05 MST")
+	return types.NewErrorWithStatusCode(
+		fmt.Errorf("已超出%s%s预算，已用额度: %s，预算上限: %s，将于 %s 重置",
+			budgetScopeName(budget), budgetPeriodName(budget.PeriodType),
+			logger.FormatQuota(exceeded.Used), logger.FormatQuota(budget.QuotaLimit), resetAt),
+		types.ErrorCodeBudgetExceeded, http.StatusForbidden,
+		types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+}
+
+// checkAndSendBudgetNotify 检查预算是否达到软阈值，每个预算窗口最多提醒一次
+func checkAndSendBudgetNotify(relayInfo *relaycommon.RelayInfo, budgets []*model.Budget, at time.Time) {
+	if len(budgets) == 0 {
+		return
+	}
+	userId := relayInfo.UserId
+	userEmail := relayInfo.UserEmail
+	userSetting := relayInfo.UserSetting
+	gopool.Go(func() {
+		for _, budget := range budgets {
+			notified, used, err := model.MarkBudgetSoftNotified(budget, at)
+			if err != nil {
+				common.SysError(fmt.Sprintf("failed to check budget %d soft threshold: %s", budget.Id, err.Error()))
+				continue
+			}
+			if !notified {
+				continue
+			}
+			sendBudgetNotify(userId, userEmail, userSetting, budget, used)
+		}
+	})
+}
+
+func sendBudgetNotify(userId int, userEmail string, userSetting dto.UserSetting, budget *model.Budget, used int) {
+	prompt := fmt.Sprintf("您的%s%s预算即将用尽", budgetScopeName(budget), budgetPeriodName(budget.PeriodType))
+
+	values := []interface{}{prompt, logger.FormatQuota(used), logger.FormatQuota(budget.QuotaLimit)}
+	// Bark / Gotify 推送使用简短文本
+	content := "{{value}}，本周期已用额度为 {{value}}，预算上限为 {{value}}。达到上限后请求将被拒绝，直到下一个周期开始。"
+	if userSetting.NotifyType == dto.NotifyTypeBark || userSetting.NotifyType == dto.NotifyTypeGotify {
+		content = "{{value}}，已用：{{value}}，上限：{{value}}"
+	}
+
+	err := NotifyUser(userId, userEmail, userSetting, dto.NewNotify(dto.NotifyTypeBudgetWarning, prompt, content, values))
+	if err != nil {
+		common.SysError(fmt.Sprintf("failed to send budget notify to user %d: %s", userId, err.Error()))
+	}
+}
+
+// adjustAsyncBudgets 异步任务差额结算或退款时调整预算已用额度，at 为任务提交时间，保证与预扣时落在同一窗口
+func adjustAsyncBudgets(ctx context.Context, userId int, tokenId int, at int64, delta int) {
+	if delta == 0 {
+		return
+	}
+	budgets, err := loadActiveBudgets(userId, tokenId)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("获取预算失败 (userId=%d, tokenId=%d): %s", userId, tokenId, err.Error()))
+		return
+	}
+	if at <= 0 {
+		at = time.Now().Unix()
+	}
+	if err := model.AdjustBudgets(budgets, time.Unix(at, 0), delta); err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("调整预算已用额度失败 (userId=%d, delta=%d): %s", userId, delta, err.Error()))
+	}
+}
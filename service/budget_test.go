@@ -0,0 +1,105 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateBudgets(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		model.DB.Exec("DELETE FROM budgets")
+		model.DB.Exec("DELETE FROM budget_usages")
+	})
+}
+
+func getBudgetUsed(t *testing.T, userId int, tokenId int) int {
+	t.Helper()
+	budgets, err := model.GetBudgets(userId, tokenId)
+	require.NoError(t, err)
+	require.Len(t, budgets, 1)
+	return budgets[0].UsedQuota
+}
+
+// newBudgetRelayInfo 构造钱包计费的 RelayInfo。测试库未初始化 token key 列名，
+// 因此以 playground 模式跳过令牌额度扣减，令牌预算仍按 TokenId 生效。
+func newBudgetRelayInfo(userId int, tokenId int) *relaycommon.RelayInfo {
+	return &relaycommon.RelayInfo{
+		UserId:          userId,
+		TokenId:         tokenId,
+		IsPlayground:    true,
+		ForcePreConsume: true,
+		UserSetting:     dto.UserSetting{BillingPreference: "wallet_only"},
+	}
+}
+
+func TestBillingSession_TokenBudget(t *testing.T) {
+	truncate(t)
+	truncateBudgets(t)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	const userID, tokenID = 1, 1
+	seedUser(t, userID, 100000)
+	require.NoError(t, model.UpsertBudget(&model.Budget{
+		UserId:     userID,
+		TokenId:    tokenID,
+		PeriodType: model.BudgetPeriodDaily,
+		QuotaLimit: 1000,
+	}))
+
+	session, apiErr := NewBillingSession(c, newBudgetRelayInfo(userID, tokenID), 600)
+	require.Nil(t, apiErr)
+	assert.Equal(t, 600, getBudgetUsed(t, userID, tokenID))
+
+	// 实际消耗 700，预算已用 700
+	require.NoError(t, session.Settle(700))
+	assert.Equal(t, 700, getBudgetUsed(t, userID, tokenID))
+
+	// 剩余 300，预扣 500 被硬上限拒绝，且不扣减钱包与令牌
+	quotaBefore := getUserQuota(t, userID)
+	_, apiErr = NewBillingSession(c, newBudgetRelayInfo(userID, tokenID), 500)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, types.ErrorCodeBudgetExceeded, apiErr.GetErrorCode())
+	assert.Equal(t, quotaBefore, getUserQuota(t, userID))
+	assert.Equal(t, 700, getBudgetUsed(t, userID, tokenID))
+
+	// 补充预扣同样受预算约束
+	session, apiErr = NewBillingSession(c, newBudgetRelayInfo(userID, tokenID), 200)
+	require.Nil(t, apiErr)
+	assert.Error(t, session.Reserve(400))
+	assert.Equal(t, 900, getBudgetUsed(t, userID, tokenID))
+}
+
+func TestBillingSession_BudgetRefund(t *testing.T) {
+	truncate(t)
+	truncateBudgets(t)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	const userID, tokenID = 1, 1
+	seedUser(t, userID, 100000)
+	require.NoError(t, model.UpsertBudget(&model.Budget{
+		UserId:     userID,
+		PeriodType: model.BudgetPeriodMonthly,
+		QuotaLimit: 5000,
+	}))
+
+	session, apiErr := NewBillingSession(c, newBudgetRelayInfo(userID, tokenID), 800)
+	require.Nil(t, apiErr)
+	assert.Equal(t, 800, getBudgetUsed(t, userID, 0))
+
+	session.Refund(c)
+	require.Eventually(t, func() bool {
+		return getBudgetUsed(t, userID, 0) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordChannelResult 将一次上游调用的结果计入渠道（及多 Key 渠道中对应 Key）的熔断器。
+// 请求参数错误、本地跳过重试等与渠道健康无关的错误不计入。
+func RecordChannelResult(c *gin.Context, info *relaycommon.RelayInfo, channelId int, attemptStart time.Time, err *types.NewAPIError) {
+	keyIndex := circuitbreaker.ChannelLevel
+	if common.GetContextKeyBool(c, constant.ContextKeyChannelIsMultiKey) {
+		keyIndex = common.GetContextKeyInt(c, constant.ContextKeyChannelMultiKeyIndex)
+	}
+	if err == nil {
+		circuitbreaker.Record(channelId, keyIndex, circuitbreaker.OutcomeSuccess, channelLatency(info, attemptStart))
+		return
+	}
+	if isChannelHealthFailure(err) {
+		circuitbreaker.Record(channelId, keyIndex, circuitbreaker.OutcomeFailure, time.Since(attemptStart))
+	}
+}
+
+// channelLatency 流式请求使用首字耗时，避免长输出被误判为慢请求；Realtime 为长连接会话，不统计延迟
+func channelLatency(info *relaycommon.RelayInfo, attemptStart time.Time) time.Duration {
+	if info != nil && info.RelayFormat == types.RelayFormatOpenAIRealtime {
+		return 0
+	}
+	if info != nil && info.IsStream && info.FirstResponseTime.After(attemptStart) {
+		return info.FirstResponseTime.Sub(attemptStart)
+	}
+	return time.Since(attemptStart)
+}
+
+func isChannelHealthFailure(err *types.NewAPIError) bool {
+	if types.IsChannelError(err) {
+		return true
+	}
+	if types.IsSkipRetryError(err) {
+		return false
+	}
+	code := err.StatusCode
+	if code < 100 || code > 599 {
+		return true
+	}
+	if code >= 500 || code == 429 || code == 408 {
+		return true
+	}
+	return operation_setting.ShouldDisableByStatusCode(code)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/QuantumNous/new-api/logger"
 	"github.com/QuantumNous/new-api/model"
 	"github.com/QuantumNous/new-api/setting"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,7 +18,9 @@ type RetryParam struct {
 	ModelName    string
 	EndpointType constant.EndpointType
 	Retry        *int
-	resetNextTry bool
+	// ModelFallback 当前模型没有可用渠道时是否按降级链切换模型，切换后 ModelName 为降级模型
+	ModelFallback bool
+	resetNextTry  bool
 }
 
 func (p *RetryParam) GetRetry() int {
@@ -81,7 +84,19 @@ func (p *RetryParam) ResetRetryNextTry() {
 //
 //	Retry=3: GroupB, priority1 (startRetryIndex=2, priorityRetry=1)
 //	         分组B, 优先级1
+//
+// When ModelFallback is enabled and no channel is available for the model, the next model
+// in the fallback chain is tried and param.ModelName is switched to it.
+// 开启 ModelFallback 且当前模型没有可用渠道时，依次尝试降级链中的模型，并将 param.ModelName 切换为该模型。
 func CacheGetRandomSatisfiedChannel(param *RetryParam) (*model.Channel, string, error) {
+	channel, selectGroup, err := cacheGetRandomSatisfiedChannel(param)
+	for err == nil && channel == nil && param.ModelFallback && param.NextFallbackModel() {
+		channel, selectGroup, err = cacheGetRandomSatisfiedChannel(param)
+	}
+	return channel, selectGroup, err
+}
+
+func cacheGetRandomSatisfiedChannel(param *RetryParam) (*model.Channel, string, error) {
 	var channel *model.Channel
 	var err error
 	selectGroup := param.TokenGroup
@@ -159,5 +174,8 @@ func CacheGetRandomSatisfiedChannel(param *RetryParam) (*model.Channel, string,
 			return nil, param.TokenGroup, err
 		}
 	}
+	if channel != nil {
+		common.SetContextKey(param.Ctx, constant.ContextKeyRoutingStrategy, operation_setting.GetRoutingStrategy(selectGroup, param.ModelName))
+	}
 	return channel, selectGroup, nil
 }
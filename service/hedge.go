@@ -0,0 +1,56 @@
+package service
+
+import (
+	"time"
+
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HedgeLoserQuota 返回对冲请求未被采用时的计费额度：按次计费或阶梯计费使用预扣额度，按量计费按输入预估 token 计算。
+// 未开启未采用请求计费或免费模型时返回 0。
+func HedgeLoserQuota(relayInfo *relaycommon.RelayInfo) int {
+	if !operation_setting.GetHedgeSetting().BillLoser || relayInfo.PriceData.FreeModel {
+		return 0
+	}
+	if relayInfo.PriceData.UsePrice || relayInfo.TieredBillingSnapshot != nil {
+		return relayInfo.PriceData.QuotaToPreConsume
+	}
+	quota := float64(relayInfo.GetEstimatePromptTokens()) * relayInfo.PriceData.ModelRatio * relayInfo.PriceData.GroupRatioInfo.GroupRatio
+	if quota > 0 && quota < 1 {
+		return 1
+	}
+	return int(quota)
+}
+
+// RecordHedgeLoserConsume 记录未被采用的对冲请求的消费日志，额度已随被采用请求一并结算
+func RecordHedgeLoserConsume(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, channelId int, quota int, hedgeInfo map[string]interface{}) {
+	if quota <= 0 {
+		return
+	}
+	model.UpdateUserUsedQuotaAndRequestCount(relayInfo.UserId, quota)
+	model.UpdateChannelUsedQuota(channelId, quota)
+
+	useTimeSeconds := time.Now().Unix() - relayInfo.StartTime.Unix()
+	other := map[string]interface{}{
+		"hedge_loser": true,
+		"admin_info": map[string]interface{}{
+			"hedge": hedgeInfo,
+		},
+	}
+	model.RecordConsumeLog(ctx, relayInfo.UserId, model.RecordConsumeLogParams{
+		ChannelId:      channelId,
+		ModelName:      relayInfo.OriginModelName,
+		TokenName:      ctx.GetString("token_name"),
+		Quota:          quota,
+		Content:        "对冲请求未被采用，按输入预估计费",
+		TokenId:        relayInfo.TokenId,
+		UseTimeSeconds: int(useTimeSeconds),
+		IsStream:       relayInfo.IsStream,
+		Group:          relayInfo.UsingGroup,
+		Other:          other,
+	})
+}
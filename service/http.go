@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
 	"github.com/QuantumNous/new-api/logger"
 
 	"github.com/gin-gonic/gin"
@@ -46,6 +47,17 @@ func IOCopyBytesGracefully(c *gin.Context, src *http.Response, data []byte) {
 		return
 	}
 
+	if src == nil || src.StatusCode < http.StatusMultipleChoices {
+		filtered, apiErr := FilterSensitiveOutput(c, data)
+		if apiErr != nil {
+			// 用户没有收到响应，结算时不计费
+			common.SetContextKey(c, constant.ContextKeySensitiveOutputBlocked, true)
+			writeRelayFormatError(c, apiErr)
+			return
+		}
+		data = filtered
+	}
+
 	body := io.NopCloser(bytes.NewBuffer(data))
 
 	// We shouldn't set the header before we parse the response body, because the parse part may fail.
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+)
+
+var ErrInvalidStatementMonth = errors.New("invalid statement month, expected YYYY-MM")
+
+// RenderInvoiceHTML 输出可打印的发票页面，浏览器中可直接打印或另存为 PDF
+func RenderInvoiceHTML(w io.Writer, invoice *model.Invoice) error {
+	return htmlTemplates.ExecuteTemplate(w, "invoice.html", map[string]any{
+		"Invoice": invoice,
+		"Seller":  operation_setting.GetInvoiceSetting(),
+	})
+}
+
+// MonthlyStatement 用户某个自然月的账单，消费按模型与令牌汇总
+type MonthlyStatement struct {
+	UserId      int
+	BuyerName   string
+	TaxId       string
+	Email       string
+	Month       string
+	Timezone    string
+	PeriodStart string
+	PeriodEnd   string
+	Items       []*model.StatementItem
+	Total       model.StatementItem
+	TopUps      []*model.TopUp
+	Seller      *operation_setting.InvoiceSetting
+}
+
+// BuildMonthlyStatement 生成用户的月度账单，month 形如 2026-09，按 invoice_setting.timezone 的自然月统计
+func BuildMonthlyStatement(userId int, month string) (*MonthlyStatement, error) {
+	loc := operation_setting.GetInvoiceLocation()
+	start, err := time.ParseInLocation("2006-01", month, loc)
+	if err != nil {
+		return nil, ErrInvalidStatementMonth
+	}
+	end := start.AddDate(0, 1, 0)
+	user, err := model.GetUserById(userId, false)
+	if err != nil {
+		return nil, err
+	}
+	items, err := model.GetUserStatementItems(userId, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	topUps, err := model.GetUserPaidTopUps(userId, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &MonthlyStatement{
+		UserId:      user.Id,
+		BuyerName:   user.CompanyName,
+		TaxId:       user.TaxId,
+		Email:       user.Email,
+		Month:       start.Format("2006-01"),
+		Timezone:    loc.String(),
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Items:       items,
+		TopUps:      topUps,
+		Seller:      operation_setting.GetInvoiceSetting(),
+	}
+	if statement.BuyerName == "" {
+		statement.BuyerName = user.Username
+	}
+	for _, item := range items {
+		statement.Total.Requests += item.Requests
+		statement.Total.PromptTokens += item.PromptTokens
+		statement.Total.CompletionTokens += item.CompletionTokens
+		statement.Total.Quota += item.Quota
+	}
+	return statement, nil
+}
+
+// RenderStatementHTML 输出可打印的月度账单页面
+func RenderStatementHTML(w io.Writer, statement *MonthlyStatement) error {
+	return htmlTemplates.ExecuteTemplate(w, "statement.html", statement)
+}
+
+// WriteStatementCSV 以 CSV 输出月度账单的消费明细，最后一行为合计
+func WriteStatementCSV(w io.Writer, statement *MonthlyStatement) error {
+	writer := csv.NewWriter(w)
+	writeRow := func(modelName string, tokenName string, item *model.StatementItem) {
+		_ = writer.Write([]string{
+			statement.Month,
+			modelName,
+			tokenName,
+			strconv.FormatInt(item.Requests, 10),
+			strconv.FormatInt(item.PromptTokens, 10),
+			strconv.FormatInt(item.CompletionTokens, 10),
+			strconv.FormatInt(item.Quota, 10),
+			logger.FormatQuota(int(item.Quota)),
+		})
+	}
+	_ = writer.Write([]string{"month", "model_name", "token_name", "requests", "prompt_tokens", "completion_tokens", "quota", "cost"})
+	for _, item := range statement.Items {
+		writeRow(csvSafeCell(item.ModelName), csvSafeCell(item.TokenName), item)
+	}
+	writeRow("TOTAL", "", &statement.Total)
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafeCell 防止表格软件将用户输入（如令牌名称）解析为公式
+func csvSafeCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// StatementFileName 账单下载文件名，例如 statement-1-2026-09.csv
+func StatementFileName(statement *MonthlyStatement, ext string) string {
+	return fmt.Sprintf("statement-%d-%s.%s", statement.UserId, statement.Month, ext)
+}
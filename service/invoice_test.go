@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateInvoices(t *testing.T) {
+	t.Helper()
+	truncate(t)
+	t.Cleanup(func() {
+		model.DB.Exec("DELETE FROM invoices")
+		model.DB.Exec("DELETE FROM subscription_orders")
+		model.DB.Exec("DELETE FROM subscription_plans")
+	})
+}
+
+func seedTopUp(t *testing.T, userId int, tradeNo string, money float64, status string, completeTime int64) *model.TopUp {
+	t.Helper()
+	topUp := &model.TopUp{
+		UserId:        userId,
+		Amount:        10,
+		Money:         money,
+		TradeNo:       tradeNo,
+		PaymentMethod: "alipay",
+		CreateTime:    completeTime - 60,
+		CompleteTime:  completeTime,
+		Status:        status,
+	}
+	require.NoError(t, topUp.Insert())
+	return topUp
+}
+
+func TestGetOrCreateInvoice(t *testing.T) {
+	truncateInvoices(t)
+	seedUser(t, 1, 0)
+	require.NoError(t, model.UpdateUserBillingProfile(1, "ACME Ltd.", "91310000MA1FL0000X"))
+
+	paidAt := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC).Unix()
+	topUp := seedTopUp(t, 1, "trade_topup", 72.5, common.TopUpStatusSuccess, paidAt)
+
+	invoice, err := model.GetOrCreateInvoice(topUp)
+	require.NoError(t, err)
+	assert.Equal(t, model.InvoiceKindTopUp, invoice.Kind)
+	assert.Equal(t, fmt.Sprintf("INV-202609-%06d", invoice.Id), invoice.InvoiceNo)
+	assert.Equal(t, "ACME Ltd.", invoice.BuyerName)
+	assert.Equal(t, "91310000MA1FL0000X", invoice.BuyerTaxId)
+	assert.Equal(t, "CNY", invoice.Currency)
+
+	// 已开具的发票不随抬头变更
+	require.NoError(t, model.UpdateUserBillingProfile(1, "Other Co.", ""))
+	again, err := model.GetOrCreateInvoice(topUp)
+	require.NoError(t, err)
+	assert.Equal(t, invoice.Id, again.Id)
+	assert.Equal(t, "ACME Ltd.", again.BuyerName)
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderInvoiceHTML(&buf, again))
+	assert.Contains(t, buf.String(), invoice.InvoiceNo)
+	assert.Contains(t, buf.String(), "72.50")
+
+	pending := seedTopUp(t, 1, "trade_pending", 10, common.TopUpStatusPending, paidAt)
+	_, err = model.GetOrCreateInvoice(pending)
+	assert.ErrorIs(t, err, model.ErrInvoiceNotAvailable)
+}
+
+func TestGetOrCreateInvoiceSubscription(t *testing.T) {
+	truncateInvoices(t)
+	seedUser(t, 1, 0)
+	plan := &model.SubscriptionPlan{Title: "Pro Monthly", PriceAmount: 20, Currency: "USD"}
+	require.NoError(t, model.DB.Create(plan).Error)
+	require.NoError(t, model.DB.Create(&model.SubscriptionOrder{
+		UserId:  1,
+		PlanId:  plan.Id,
+		Money:   20,
+		TradeNo: "trade_sub",
+		Status:  common.TopUpStatusSuccess,
+	}).Error)
+	topUp := seedTopUp(t, 1, "trade_sub", 20, common.TopUpStatusSuccess, time.Now().Unix())
+
+	invoice, err := model.GetOrCreateInvoice(topUp)
+	require.NoError(t, err)
+	assert.Equal(t, model.InvoiceKindSubscription, invoice.Kind)
+	assert.Equal(t, "Subscription: Pro Monthly", invoice.Description)
+	assert.Equal(t, "USD", invoice.Currency)
+	assert.Equal(t, "test_user", invoice.BuyerName)
+}
+
+func TestBuildMonthlyStatement(t *testing.T) {
+	truncateInvoices(t)
+	seedUser(t, 1, 0)
+
+	september := time.Date(2026, 9, 10, 8, 0, 0, 0, time.UTC).Unix()
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).Unix()
+	logs := []*model.Log{
+		{UserId: 1, Type: model.LogTypeConsume, CreatedAt: september, ModelName: "gpt-4o", TokenName: "prod", Quota: 3000, PromptTokens: 100, CompletionTokens: 50},
+		{UserId: 1, Type: model.LogTypeConsume, CreatedAt: september, ModelName: "gpt-4o", TokenName: "prod", Quota: 2000, PromptTokens: 80, CompletionTokens: 20},
+		{UserId: 1, Type: model.LogTypeRefund, CreatedAt: september, ModelName: "gpt-4o", TokenName: "prod", Quota: 500},
+		{UserId: 1, Type: model.LogTypeConsume, CreatedAt: september, ModelName: "sora-2", TokenName: "=cmd", Quota: 1000},
+		{UserId: 1, Type: model.LogTypeTopup, CreatedAt: september, Quota: 500000},
+		{UserId: 1, Type: model.LogTypeConsume, CreatedAt: october, ModelName: "gpt-4o", TokenName: "prod", Quota: 9999},
+		{UserId: 2, Type: model.LogTypeConsume, CreatedAt: september, ModelName: "gpt-4o", TokenName: "prod", Quota: 7777},
+	}
+	require.NoError(t, model.LOG_DB.Create(&logs).Error)
+	seedTopUp(t, 1, "trade_sep", 50, common.TopUpStatusSuccess, september)
+	seedTopUp(t, 1, "trade_oct", 50, common.TopUpStatusSuccess, october)
+
+	statement, err := BuildMonthlyStatement(1, "2026-09")
+	require.NoError(t, err)
+	require.Len(t, statement.Items, 2)
+	assert.Equal(t, "gpt-4o", statement.Items[0].ModelName)
+	assert.Equal(t, int64(2), statement.Items[0].Requests)
+	assert.Equal(t, int64(180), statement.Items[0].PromptTokens)
+	assert.Equal(t, int64(4500), statement.Items[0].Quota)
+	assert.Equal(t, int64(3), statement.Total.Requests)
+	assert.Equal(t, int64(5500), statement.Total.Quota)
+	require.Len(t, statement.TopUps, 1)
+	assert.Equal(t, "trade_sep", statement.TopUps[0].TradeNo)
+	assert.Equal(t, "2026-09-30", statement.PeriodEnd)
+
+	var csvBuf bytes.Buffer
+	require.NoError(t, WriteStatementCSV(&csvBuf, statement))
+	lines := strings.Split(strings.TrimSpace(csvBuf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[1], "2026-09,gpt-4o,prod,2,180,70,4500,"))
+	assert.True(t, strings.HasPrefix(lines[2], "2026-09,sora-2,'=cmd,1,"))
+	assert.True(t, strings.HasPrefix(lines[3], "2026-09,TOTAL,,3,"))
+
+	var htmlBuf bytes.Buffer
+	require.NoError(t, RenderStatementHTML(&htmlBuf, statement))
+	assert.Contains(t, htmlBuf.String(), "trade_sep")
+	assert.NotContains(t, htmlBuf.String(), "trade_oct")
+
+	_, err = BuildMonthlyStatement(1, "2026/09")
+	assert.ErrorIs(t, err, ErrInvalidStatementMonth)
+}
+
+func TestBuildMonthlyStatementTimezone(t *testing.T) {
+	truncateInvoices(t)
+	seedUser(t, 1, 0)
+	setting := operation_setting.GetInvoiceSetting()
+	original := setting.Timezone
+	setting.Timezone = "Asia/Shanghai"
+	t.Cleanup(func() { setting.Timezone = original })
+
+	// 2026-09-30 20:00 UTC 为上海时间 10 月 1 日
+	createdAt := time.Date(2026, 9, 30, 20, 0, 0, 0, time.UTC).Unix()
+	require.NoError(t, model.LOG_DB.Create(&model.Log{UserId: 1, Type: model.LogTypeConsume, CreatedAt: createdAt, ModelName: "gpt-4o", Quota: 100}).Error)
+
+	september, err := BuildMonthlyStatement(1, "2026-09")
+	require.NoError(t, err)
+	assert.Empty(t, september.Items)
+	october, err := BuildMonthlyStatement(1, "2026-10")
+	require.NoError(t, err)
+	assert.Len(t, october.Items, 1)
+}
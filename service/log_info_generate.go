@@ -51,11 +51,20 @@ func GenerateTextOtherInfo(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, m
 		other["is_model_mapped"] = true
 		other["upstream_model_name"] = relayInfo.UpstreamModelName
 	}
+	if fallbackFrom := GetModelFallbackFrom(ctx); fallbackFrom != "" {
+		other["model_fallback_from"] = fallbackFrom
+	}
 
 	isSystemPromptOverwritten := common.GetContextKeyBool(ctx, constant.ContextKeySystemPromptOverride)
 	if isSystemPromptOverwritten {
 		other["is_system_prompt_overwritten"] = true
 	}
+	if batchId := common.GetContextKeyString(ctx, constant.ContextKeyBatchId); batchId != "" {
+		other["batch_id"] = batchId
+	}
+	if moderation := GetModerationResult(ctx); moderation != nil {
+		other["moderation"] = moderation
+	}
 
 	adminInfo := make(map[string]interface{})
 	adminInfo["use_channel"] = ctx.GetStringSlice("use_channel")
@@ -64,6 +73,19 @@ func GenerateTextOtherInfo(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, m
 		adminInfo["is_multi_key"] = true
 		adminInfo["multi_key_index"] = common.GetContextKeyInt(ctx, constant.ContextKeyChannelMultiKeyIndex)
 	}
+	if routingStrategy := common.GetContextKeyString(ctx, constant.ContextKeyRoutingStrategy); routingStrategy != "" {
+		adminInfo["routing_strategy"] = routingStrategy
+	}
+	if hedgeAttempt := relaycommon.GetHedgeAttempt(ctx); hedgeAttempt != nil {
+		if hedgeInfo := hedgeAttempt.Race().LogInfo(); hedgeInfo != nil {
+			adminInfo["hedge"] = hedgeInfo
+		}
+	}
+	if streamFailover := relaycommon.GetStreamFailover(ctx); streamFailover != nil {
+		if failoverInfo := streamFailover.LogInfo(); failoverInfo != nil {
+			adminInfo["stream_failover"] = failoverInfo
+		}
+	}
 
 	isLocalCountTokens := common.GetContextKeyBool(ctx, constant.ContextKeyLocalCountTokens)
 	if isLocalCountTokens {
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/ratio_setting"
+	"github.com/gin-gonic/gin"
+)
+
+// NextFallbackModel 将 ModelName 切换为降级链中的下一个模型，并从该模型的最高优先级重新选择渠道。
+// 降级链始终按请求的原始模型计算，令牌无权使用的模型会被跳过；没有可切换的模型时返回 false。
+func (p *RetryParam) NextFallbackModel() bool {
+	originModel := GetModelFallbackFrom(p.Ctx)
+	if originModel == "" {
+		originModel = p.ModelName
+	}
+	chain := getModelFallbackChain(p.Ctx, originModel)
+	start := 0
+	if p.ModelName != originModel {
+		start = slices.Index(chain, p.ModelName) + 1
+		if start == 0 {
+			return false
+		}
+	}
+	for _, fallbackModel := range chain[start:] {
+		if fallbackModel == originModel || !tokenAllowsModel(p.Ctx, fallbackModel) {
+			continue
+		}
+		logger.LogInfo(p.Ctx, fmt.Sprintf("model %s has no available channel, falling back to %s", p.ModelName, fallbackModel))
+		common.SetContextKey(p.Ctx, constant.ContextKeyModelFallbackFrom, originModel)
+		// 降级模型从第一个自动分组、最高优先级开始选择
+		common.SetContextKey(p.Ctx, constant.ContextKeyAutoGroupIndex, 0)
+		common.SetContextKey(p.Ctx, constant.ContextKeyAutoGroupRetryIndex, 0)
+		p.ModelName = fallbackModel
+		p.SetRetry(0)
+		return true
+	}
+	return false
+}
+
+// GetModelFallbackFrom 返回发生模型降级时请求的原始模型，未降级时返回空字符串
+func GetModelFallbackFrom(c *gin.Context) string {
+	return common.GetContextKeyString(c, constant.ContextKeyModelFallbackFrom)
+}
+
+func getModelFallbackChain(c *gin.Context, modelName string) []string {
+	var tokenChains map[string][]string
+	if value, ok := common.GetContextKey(c, constant.ContextKeyTokenModelFallbacks); ok {
+		tokenChains, _ = value.(map[string][]string)
+	}
+	return operation_setting.GetModelFallbackChain(modelName, tokenChains)
+}
+
+// tokenAllowsModel 与 middleware.CheckTokenModelLimit 一致，判断令牌的模型限制是否允许使用该模型
+func tokenAllowsModel(c *gin.Context, modelName string) bool {
+	if !common.GetContextKeyBool(c, constant.ContextKeyTokenModelLimitEnabled) {
+		return true
+	}
+	value, ok := common.GetContextKey(c, constant.ContextKeyTokenModelLimit)
+	if !ok {
+		return false
+	}
+	tokenModelLimit, _ := value.(map[string]bool)
+	return tokenModelLimit[ratio_setting.FormatMatchingModelName(modelName)]
+}
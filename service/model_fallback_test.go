@@ -0,0 +1,57 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func withModelFallbackChains(t *testing.T, chains map[string][]string) {
+	t.Helper()
+	setting := operation_setting.GetModelFallbackSetting()
+	original := *setting
+	t.Cleanup(func() { *setting = original })
+	setting.Enabled = true
+	setting.Chains = chains
+}
+
+func TestNextFallbackModelWalksChain(t *testing.T) {
+	withModelFallbackChains(t, map[string][]string{"gpt-4o": {"gpt-4.1", "claude-sonnet-4"}})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	param := &RetryParam{Ctx: c, ModelName: "gpt-4o", Retry: common.GetPointer(2)}
+
+	require.True(t, param.NextFallbackModel())
+	require.Equal(t, "gpt-4.1", param.ModelName)
+	require.Equal(t, 0, param.GetRetry())
+	require.Equal(t, "gpt-4o", GetModelFallbackFrom(c))
+
+	require.True(t, param.NextFallbackModel())
+	require.Equal(t, "claude-sonnet-4", param.ModelName)
+	require.False(t, param.NextFallbackModel())
+	require.Equal(t, "claude-sonnet-4", param.ModelName)
+}
+
+func TestNextFallbackModelTokenOverrideAndLimits(t *testing.T) {
+	withModelFallbackChains(t, map[string][]string{"gpt-4o": {"gpt-4.1"}})
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	common.SetContextKey(c, constant.ContextKeyTokenModelFallbacks, map[string][]string{"gpt-4o": {"gemini-2.5-pro", "claude-sonnet-4"}})
+	// 令牌的模型限制不允许的降级模型会被跳过
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimitEnabled, true)
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimit, map[string]bool{"gpt-4o": true, "claude-sonnet-4": true})
+	param := &RetryParam{Ctx: c, ModelName: "gpt-4o"}
+
+	require.True(t, param.NextFallbackModel())
+	require.Equal(t, "claude-sonnet-4", param.ModelName)
+	require.False(t, param.NextFallbackModel())
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	common.SetContextKey(c, constant.ContextKeyTokenModelFallbacks, map[string][]string{"gpt-4o": {}})
+	param = &RetryParam{Ctx: c, ModelName: "gpt-4o"}
+	require.False(t, param.NextFallbackModel())
+	require.Equal(t, "gpt-4o", param.ModelName)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 分组 × 模型的 TPM/TPD 限流（setting.ModelTokenRateLimitGroup）。
+// 请求进入时按预估输入 token 预占额度，结算时按实际用量（输入 + 输出）补扣或归还，失败时归还预占。
+// 单次预占不超过限制值本身，避免超大请求永远无法通过；超出部分在结算时计入，余量可为负。
+// ---------------------------------------------------------------------------
+
+// modelTokenRateLimitWindow 一个限流维度：TPM 或 TPD
+type modelTokenRateLimitWindow struct {
+	name     string // 用于 key 与提示信息：min / day
+	window   int64  // 窗口秒数
+	limit    int
+	reserved int // 本次请求在该维度预占的 token 数
+}
+
+// modelTokenRateLimitReservation 当前请求的预占记录，保存在 gin context 中
+type modelTokenRateLimitReservation struct {
+	group   string
+	model   string
+	windows []modelTokenRateLimitWindow
+	settled bool
+}
+
+func modelTokenRateLimitKey(group string, modelName string, window string) string {
+	return fmt.Sprintf("modelTokenRateLimit:%s:%s:%s", window, group, modelName)
+}
+
+func getModelTokenRateLimitWindows(group string, modelName string) []modelTokenRateLimitWindow {
+	if !setting.ModelTokenRateLimitEnabled {
+		return nil
+	}
+	tpm, tpd, found := setting.GetModelTokenRateLimit(group, modelName)
+	if !found {
+		return nil
+	}
+	windows := make([]modelTokenRateLimitWindow, 0, 2)
+	if tpm > 0 {
+		windows = append(windows, modelTokenRateLimitWindow{name: "min", window: 60, limit: tpm})
+	}
+	if tpd > 0 {
+		windows = append(windows, modelTokenRateLimitWindow{name: "day", window: 24 * 60 * 60, limit: tpd})
+	}
+	return windows
+}
+
+// HasModelTokenRateLimit 当前请求的分组与模型是否配置了 token 限流
+func HasModelTokenRateLimit(info *relaycommon.RelayInfo) bool {
+	return len(getModelTokenRateLimitWindows(info.UsingGroup, info.OriginModelName)) > 0
+}
+
+// ReserveModelTokenRateLimit 按预估输入 token 预占分组 × 模型的 TPM/TPD 额度，超出时返回 429 并写入限流响应头。
+// promptTokens 为 0（未开启 token 统计）时使用 meta 的文本按模型估算。
+func ReserveModelTokenRateLimit(c *gin.Context, info *relaycommon.RelayInfo, promptTokens int, meta *types.TokenCountMeta) *types.NewAPIError {
+	windows := getModelTokenRateLimitWindows(info.UsingGroup, info.OriginModelName)
+	if len(windows) == 0 {
+		return nil
+	}
+	if promptTokens <= 0 && meta != nil {
+		promptTokens = EstimateTokenByModel(info.OriginModelName, meta.CombineText)
+	}
+	promptTokens = max(promptTokens, 1)
+
+	reservation := &modelTokenRateLimitReservation{group: info.UsingGroup, model: info.OriginModelName}
+	for _, w := range windows {
+		key := modelTokenRateLimitKey(reservation.group, reservation.model, w.name)
+		w.reserved = min(promptTokens, w.limit)
+		allowed, err := allowBucket(c, key, bucketOptions(w.limit, w.window, int64(w.reserved))...)
+		if err != nil {
+			reservation.release(c)
+			return types.NewError(fmt.Errorf("check model token rate limit failed: %w", err), types.ErrorCodeRateLimitExceeded, types.ErrOptionWithSkipRetry())
+		}
+		if !allowed {
+			reservation.release(c)
+			SetRateLimitHeaders(c, info.RelayFormat, modelTokenRateLimitHeaderInfo(c, key, w, true))
+			scope := fmt.Sprintf("%s in group %s", reservation.model, reservation.group)
+			return NewRateLimitError(info.RelayFormat, formatTokenRateLimitMessage(scope, w.limit, w.name, promptTokens))
+		}
+		reservation.windows = append(reservation.windows, w)
+	}
+	common.SetContextKey(c, constant.ContextKeyModelTokenRateLimit, reservation)
+	for _, w := range reservation.windows {
+		key := modelTokenRateLimitKey(reservation.group, reservation.model, w.name)
+		SetRateLimitHeaders(c, info.RelayFormat, modelTokenRateLimitHeaderInfo(c, key, w, false))
+	}
+	return nil
+}
+
+// modelTokenRateLimitHeaderInfo 查询某一维度的余量，rejected 为 true 时计算补足本次预占所需的等待时间
+func modelTokenRateLimitHeaderInfo(c *gin.Context, key string, w modelTokenRateLimitWindow, rejected bool) RateLimitHeaderInfo {
+	remaining, err := consumeBucket(c, key, bucketOptions(w.limit, w.window, 0)...)
+	if err != nil {
+		remaining = 0
+	}
+	info := RateLimitHeaderInfo{
+		TokensLimit:     w.limit,
+		TokensRemaining: int(remaining / w.window),
+		TokensReset:     bucketWaitTime(remaining, int64(w.limit)*w.window, w.limit),
+	}
+	if rejected {
+		info.RetryAfter = bucketWaitTime(remaining, int64(w.reserved)*w.window, w.limit)
+	}
+	return info
+}
+
+// adjust 按 delta(w) 调整各维度余量，delta 为负时归还
+func (r *modelTokenRateLimitReservation) adjust(c *gin.Context, delta func(w modelTokenRateLimitWindow) int) {
+	for _, w := range r.windows {
+		tokens := delta(w)
+		if tokens == 0 {
+			continue
+		}
+		key := modelTokenRateLimitKey(r.group, r.model, w.name)
+		if _, err := consumeBucket(c, key, bucketOptions(w.limit, w.window, int64(tokens))...); err != nil {
+			logger.LogError(c, fmt.Sprintf("consume model token rate limit failed: key=%s, tokens=%d, error=%s", key, tokens, err.Error()))
+		}
+	}
+}
+
+// release 归还已预占的额度
+func (r *modelTokenRateLimitReservation) release(c *gin.Context) {
+	r.adjust(c, func(w modelTokenRateLimitWindow) int { return -w.reserved })
+}
+
+func getModelTokenRateLimitReservation(c *gin.Context) *modelTokenRateLimitReservation {
+	reservation, ok := common.GetContextKeyType[*modelTokenRateLimitReservation](c, constant.ContextKeyModelTokenRateLimit)
+	if !ok || reservation == nil || reservation.settled {
+		return nil
+	}
+	return reservation
+}
+
+// SettleModelTokenRateLimit 请求结算时按实际用量（输入 + 输出）与预占量的差额补扣或归还
+func SettleModelTokenRateLimit(c *gin.Context, actualTokens int) {
+	reservation := getModelTokenRateLimitReservation(c)
+	if reservation == nil {
+		return
+	}
+	reservation.settled = true
+	reservation.adjust(c, func(w modelTokenRateLimitWindow) int { return actualTokens - w.reserved })
+}
+
+// ReleaseModelTokenRateLimit 请求失败时归还预占的额度
+func ReleaseModelTokenRateLimit(c *gin.Context) {
+	reservation := getModelTokenRateLimitReservation(c)
+	if reservation == nil {
+		return
+	}
+	reservation.settled = true
+	reservation.release(c)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	"github.com/QuantumNous/new-api/setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func useModelTokenRateLimit(t *testing.T, config string) {
+	t.Helper()
+	enabled := setting.ModelTokenRateLimitEnabled
+	setting.ModelTokenRateLimitEnabled = true
+	require.NoError(t, setting.UpdateModelTokenRateLimitGroupByJSONString(config))
+	t.Cleanup(func() {
+		setting.ModelTokenRateLimitEnabled = enabled
+		_ = setting.UpdateModelTokenRateLimitGroupByJSONString("{}")
+	})
+}
+
+func newModelTokenRateLimitContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	return c, w
+}
+
+func TestReserveModelTokenRateLimit_RejectsWithHeaders(t *testing.T) {
+	useModelTokenRateLimit(t, `{"tpm-reject": {"gpt-4o": [100, 0]}}`)
+	info := &relaycommon.RelayInfo{UsingGroup: "tpm-reject", OriginModelName: "gpt-4o", RelayFormat: types.RelayFormatOpenAI}
+
+	c1, _ := newModelTokenRateLimitContext()
+	require.Nil(t, ReserveModelTokenRateLimit(c1, info, 80, nil))
+
+	c2, w2 := newModelTokenRateLimitContext()
+	apiErr := ReserveModelTokenRateLimit(c2, info, 80, nil)
+	require.NotNil(t, apiErr)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	require.True(t, types.IsSkipRetryError(apiErr))
+	require.Equal(t, "rate_limit_exceeded", apiErr.ToOpenAIError().Code)
+	require.Equal(t, "100", w2.Header().Get("x-ratelimit-limit-tokens"))
+	require.NotEmpty(t, w2.Header().Get("x-ratelimit-remaining-tokens"))
+	require.NotEmpty(t, w2.Header().Get("Retry-After"))
+
+	// 结算时实际用量少于预占，归还差额后可以再次通过
+	SettleModelTokenRateLimit(c1, 10)
+	c3, _ := newModelTokenRateLimitContext()
+	require.Nil(t, ReserveModelTokenRateLimit(c3, info, 80, nil))
+}
+
+func TestReserveModelTokenRateLimit_ReleaseOnFailure(t *testing.T) {
+	useModelTokenRateLimit(t, `{"tpd-release": {"*": [0, 100]}}`)
+	info := &relaycommon.RelayInfo{UsingGroup: "tpd-release", OriginModelName: "claude-sonnet-4", RelayFormat: types.RelayFormatClaude}
+
+	c1, _ := newModelTokenRateLimitContext()
+	require.Nil(t, ReserveModelTokenRateLimit(c1, info, 500, nil))
+
+	c2, w2 := newModelTokenRateLimitContext()
+	apiErr := ReserveModelTokenRateLimit(c2, info, 10, nil)
+	require.NotNil(t, apiErr)
+	require.Equal(t, "rate_limit_error", apiErr.ToClaudeError().Type)
+	require.Equal(t, "100", w2.Header().Get("anthropic-ratelimit-tokens-limit"))
+	require.NotEmpty(t, w2.Header().Get("anthropic-ratelimit-tokens-reset"))
+
+	ReleaseModelTokenRateLimit(c1)
+	// 重复归还不生效
+	ReleaseModelTokenRateLimit(c1)
+	c3, _ := newModelTokenRateLimitContext()
+	require.Nil(t, ReserveModelTokenRateLimit(c3, info, 10, nil))
+}
+
+func TestReserveModelTokenRateLimit_Unconfigured(t *testing.T) {
+	useModelTokenRateLimit(t, `{"limited": {"gpt-4o": [1, 1]}}`)
+	info := &relaycommon.RelayInfo{UsingGroup: "default", OriginModelName: "gpt-4o", RelayFormat: types.RelayFormatOpenAI}
+	require.False(t, HasModelTokenRateLimit(info))
+
+	c, w := newModelTokenRateLimitContext()
+	for i := 0; i < 3; i++ {
+		require.Nil(t, ReserveModelTokenRateLimit(c, info, 1000, nil))
+	}
+	require.Empty(t, w.Header().Get("x-ratelimit-limit-tokens"))
+}
+
+func TestNewRateLimitError_Formats(t *testing.T) {
+	geminiErr := NewRateLimitError(types.RelayFormatGemini, "slow down")
+	require.Equal(t, types.ErrorTypeGeminiError, geminiErr.GetErrorType())
+	require.Equal(t, types.GeminiError{Code: http.StatusTooManyRequests, Message: "slow down", Status: "RESOURCE_EXHAUSTED"}, geminiErr.ToGeminiError())
+
+	claudeErr := NewRateLimitError(types.RelayFormatClaude, "slow down")
+	require.Equal(t, "rate_limit_error", claudeErr.ToClaudeError().Type)
+
+	// 其他错误不是 Gemini 类型，Gemini 路由上仍按 OpenAI 格式返回
+	openAIErr := NewRateLimitError(types.RelayFormatOpenAI, "slow down")
+	require.NotEqual(t, types.ErrorTypeGeminiError, openAIErr.GetErrorType())
+	require.Equal(t, string(types.ErrorCodeRateLimitExceeded), openAIErr.ToOpenAIError().Code)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 转发前的内容审核（operation_setting.ModerationSetting）。
+// 审核方式为 model 时，选择一个提供审核模型的渠道，经由渠道适配器调用 /v1/moderations（RelayModeModerations）；
+// 为 webhook 时，POST 到配置的地址，响应需与 /v1/moderations 格式一致。
+// 各分组按策略 allow / flag / block 处理命中结果，flag 的结果记录到消费日志的 other.moderation。
+// ---------------------------------------------------------------------------
+
+const moderationResponseLimit = 1 << 20
+
+// ChannelRequestFunc 由 main 包注入（避免 service -> relay 的循环引用），通过渠道适配器向上游发送内部请求并返回响应体
+var ChannelRequestFunc func(ctx context.Context, channel *model.Channel, path string, request dto.Request) ([]byte, error)
+
+// moderationRequest 与 OpenAI /v1/moderations 请求格式一致，webhook 额外携带请求上下文
+type moderationRequest struct {
+	Model     string `json:"model,omitempty"`
+	Input     string `json:"input"`
+	Group     string `json:"group,omitempty"`
+	UserId    int    `json:"user_id,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+type moderationResponse struct {
+	Results []struct {
+		Flagged        bool               `json:"flagged"`
+		Categories     map[string]bool    `json:"categories"`
+		CategoryScores map[string]float64 `json:"category_scores"`
+	} `json:"results"`
+}
+
+// ModerationResult 一次审核的结果，命中时写入消费日志
+type ModerationResult struct {
+	Provider   string   `json:"provider"`
+	Model      string   `json:"model,omitempty"`
+	Action     string   `json:"action"`
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// NeedModeration 当前分组是否需要审核请求内容
+func NeedModeration(group string) bool {
+	return operation_setting.GetModerationAction(group) != operation_setting.ModerationActionAllow
+}
+
+// ModerateRequest 按分组策略审核请求文本：block 策略命中时返回错误，flag 策略命中时记录结果后放行。
+// 审核服务出错时按 FailOpen 决定放行或拒绝。
+func ModerateRequest(c *gin.Context, group string, text string) *types.NewAPIError {
+	action := operation_setting.GetModerationAction(group)
+	if action == operation_setting.ModerationActionAllow || strings.TrimSpace(text) == "" {
+		return nil
+	}
+	moderationSetting := operation_setting.GetModerationSetting()
+	result, err := requestModeration(c, group, text)
+	if err != nil {
+		if moderationSetting.FailOpen {
+			logger.LogError(c, fmt.Sprintf("content moderation failed, request allowed: %s", err.Error()))
+			return nil
+		}
+		return types.NewErrorWithStatusCode(fmt.Errorf("content moderation failed: %w", err), types.ErrorCodeContentModerationBlock, http.StatusServiceUnavailable, types.ErrOptionWithSkipRetry())
+	}
+	if !result.Flagged {
+		return nil
+	}
+	result.Action = action
+	logger.LogWarn(c, fmt.Sprintf("content moderation flagged, action: %s, categories: %s", action, strings.Join(result.Categories, ", ")))
+	if action == operation_setting.ModerationActionBlock {
+		return types.NewErrorWithStatusCode(fmt.Errorf("request blocked by content moderation: %s", strings.Join(result.Categories, ", ")), types.ErrorCodeContentModerationBlock, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
+	}
+	common.SetContextKey(c, constant.ContextKeyModerationResult, result)
+	return nil
+}
+
+// GetModerationResult 获取当前请求被标记的审核结果
+func GetModerationResult(c *gin.Context) *ModerationResult {
+	result, ok := common.GetContextKeyType[*ModerationResult](c, constant.ContextKeyModerationResult)
+	if !ok {
+		return nil
+	}
+	return result
+}
+
+func requestModeration(c *gin.Context, group string, text string) (*ModerationResult, error) {
+	moderationSetting := operation_setting.GetModerationSetting()
+	timeout := time.Duration(moderationSetting.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	body := moderationRequest{Input: text}
+	switch moderationSetting.Provider {
+	case operation_setting.ModerationProviderWebhook:
+		if moderationSetting.WebhookURL == "" {
+			return nil, errors.New("moderation webhook url is not configured")
+		}
+		body.Model = moderationSetting.Model
+		body.Group = group
+		body.UserId = c.GetInt("id")
+		body.RequestId = c.GetString(common.RequestIdKey)
+		result, err := doModerationRequest(ctx, GetHttpClient(), moderationSetting.WebhookURL, moderationSetting.WebhookSecret, body)
+		if err != nil {
+			return nil, err
+		}
+		result.Provider = operation_setting.ModerationProviderWebhook
+		return result, nil
+	default:
+		if moderationSetting.Group != "" {
+			group = moderationSetting.Group
+		}
+		if ChannelRequestFunc == nil {
+			return nil, errors.New("channel request is not available")
+		}
+		channel, err := model.GetRandomSatisfiedChannelForEndpoint(group, moderationSetting.Model, 0, "")
+		if err != nil {
+			return nil, err
+		}
+		if channel == nil {
+			return nil, fmt.Errorf("no available channel for moderation model %s in group %s", moderationSetting.Model, group)
+		}
+		respBody, err := ChannelRequestFunc(ctx, channel, "/v1/moderations", &dto.GeneralOpenAIRequest{Model: moderationSetting.Model, Input: text})
+		if err != nil {
+			return nil, fmt.Errorf("channel #%d: %w", channel.Id, err)
+		}
+		result, err := parseModerationResponse(respBody)
+		if err != nil {
+			return nil, fmt.Errorf("channel #%d: %w", channel.Id, err)
+		}
+		result.Provider = operation_setting.ModerationProviderModel
+		result.Model = moderationSetting.Model
+		return result, nil
+	}
+}
+
+func doModerationRequest(ctx context.Context, client *http.Client, url string, key string, body moderationRequest) (*ModerationResult, error) {
+	data, err := common.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, moderationResponseLimit))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation request failed: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return parseModerationResponse(respBody)
+}
+
+func parseModerationResponse(respBody []byte) (*ModerationResult, error) {
+	var moderation moderationResponse
+	if err := common.Unmarshal(respBody, &moderation); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+	if len(moderation.Results) == 0 {
+		return nil, errors.New("invalid moderation response: empty results")
+	}
+
+	result := &ModerationResult{}
+	categories := make(map[string]struct{})
+	for _, r := range moderation.Results {
+		if !r.Flagged {
+			continue
+		}
+		result.Flagged = true
+		for category, hit := range r.Categories {
+			if hit {
+				categories[category] = struct{}{}
+			}
+		}
+	}
+	for category := range categories {
+		result.Categories = append(result.Categories, category)
+	}
+	sort.Strings(result.Categories)
+	return result, nil
+}
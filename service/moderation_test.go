@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func useModerationWebhook(t *testing.T, handler http.HandlerFunc) *operation_setting.ModerationSetting {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	if GetHttpClient() == nil {
+		InitHttpClient()
+	}
+
+	moderationSetting := operation_setting.GetModerationSetting()
+	original := *moderationSetting
+	t.Cleanup(func() { *moderationSetting = original })
+	moderationSetting.Enabled = true
+	moderationSetting.Provider = operation_setting.ModerationProviderWebhook
+	moderationSetting.WebhookURL = server.URL
+	moderationSetting.WebhookSecret = "secret"
+	moderationSetting.DefaultAction = operation_setting.ModerationActionFlag
+	moderationSetting.GroupActions = map[string]string{"strict": "block", "trusted": "allow"}
+	moderationSetting.FailOpen = true
+	return moderationSetting
+}
+
+func newModerationContext() *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set(common.RequestIdKey, "test-request")
+	return c
+}
+
+func flaggedModerationHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var req moderationRequest
+		require.NoError(t, common.DecodeJson(r.Body, &req))
+		flagged := req.Input == "bad prompt"
+		_, _ = fmt.Fprintf(w, `{"results":[{"flagged":%t,"categories":{"violence":%t,"hate":false}}]}`, flagged, flagged)
+	}
+}
+
+func TestModerateRequest_GroupActions(t *testing.T) {
+	useModerationWebhook(t, flaggedModerationHandler(t))
+
+	require.True(t, NeedModeration("default"))
+	require.False(t, NeedModeration("trusted"))
+
+	// block 策略命中时拒绝
+	c := newModerationContext()
+	apiErr := ModerateRequest(c, "strict", "bad prompt")
+	require.NotNil(t, apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	require.Equal(t, types.ErrorCodeContentModerationBlock, apiErr.GetErrorCode())
+	require.True(t, types.IsSkipRetryError(apiErr))
+
+	// flag 策略命中时放行并记录结果
+	c = newModerationContext()
+	require.Nil(t, ModerateRequest(c, "default", "bad prompt"))
+	result := GetModerationResult(c)
+	require.NotNil(t, result)
+	require.Equal(t, operation_setting.ModerationActionFlag, result.Action)
+	require.Equal(t, []string{"violence"}, result.Categories)
+
+	// 未命中时不记录
+	c = newModerationContext()
+	require.Nil(t, ModerateRequest(c, "strict", "hello"))
+	require.Nil(t, GetModerationResult(c))
+}
+
+func TestModerateRequest_FailOpen(t *testing.T) {
+	moderationSetting := useModerationWebhook(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	c := newModerationContext()
+	require.Nil(t, ModerateRequest(c, "strict", "bad prompt"))
+
+	moderationSetting.FailOpen = false
+	apiErr := ModerateRequest(c, "strict", "bad prompt")
+	require.NotNil(t, apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	circuitbreaker "github.com/QuantumNous/new-api/pkg/circuit_breaker"
+	keyusage "github.com/QuantumNous/new-api/pkg/key_usage"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const multiKeyMaintenanceTickInterval = 1 * time.Minute
+
+var multiKeyMaintenanceOnce sync.Once
+
+// RecordChannelKeyTokens 多 Key 渠道结算时记录所用 Key 的 token 用量，用于单 Key TPM 上限与用量统计
+func RecordChannelKeyTokens(relayInfo *relaycommon.RelayInfo, tokens int) {
+	if relayInfo == nil || relayInfo.ChannelMeta == nil || !relayInfo.ChannelIsMultiKey {
+		return
+	}
+	keyusage.RecordTokens(relayInfo.ChannelId, relayInfo.ChannelMultiKeyIndex, tokens)
+}
+
+// StartMultiKeyMaintenanceTask 定期将多 Key 渠道的 Key 用量落库，并在主节点恢复已过冷却时间的自动禁用 Key
+func StartMultiKeyMaintenanceTask() {
+	multiKeyMaintenanceOnce.Do(func() {
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("multi-key maintenance task started: tick=%s", multiKeyMaintenanceTickInterval))
+			ticker := time.NewTicker(multiKeyMaintenanceTickInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				runMultiKeyMaintenanceOnce()
+			}
+		})
+	})
+}
+
+func runMultiKeyMaintenanceOnce() {
+	// 各节点只持有自己的内存计数，均需落库
+	model.SyncMultiKeyUsage()
+	if !common.IsMasterNode {
+		return
+	}
+	channels, err := model.ReenableCooledDownKeys()
+	if err != nil {
+		logger.LogWarn(context.Background(), fmt.Sprintf("multi-key cooldown task failed: %v", err))
+		return
+	}
+	for _, channel := range channels {
+		circuitbreaker.Reset(channel.Id)
+		subject := fmt.Sprintf("通道「%s」（#%d）已被启用", channel.Name, channel.Id)
+		content := fmt.Sprintf("通道「%s」（#%d）的 Key 冷却结束，已自动恢复启用", channel.Name, channel.Id)
+		NotifyRootUser(formatNotifyType(channel.Id, common.ChannelStatusEnabled), subject, content)
+	}
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	fileCleanupTickInterval = 10 * time.Minute
+	fileCleanupBatchSize    = 200
+)
+
+var fileCleanupOnce sync.Once
+
+// IsFileChannelSupported 判断渠道类型是否支持 OpenAI Files API
+func IsFileChannelSupported(channelType int) bool {
+	return channelType == constant.ChannelTypeOpenAI || channelType == constant.ChannelTypeAzure
+}
+
+// channelKeyAt 获取渠道指定索引的 key，非多 Key 渠道直接返回完整 key
+func channelKeyAt(channel *model.Channel, index int) string {
+	if !channel.ChannelInfo.IsMultiKey {
+		return channel.Key
+	}
+	keys := channel.GetKeys()
+	if index < 0 || index >= len(keys) {
+		return ""
+	}
+	return keys[index]
+}
+
+// BuildChannelOpenAIURL 构建 OpenAI/Azure 渠道上非模型类接口（files、batches）的请求地址
+// path 不带 /v1 前缀，例如 "/files" 或 "/batches/batch_xxx/cancel"
+func BuildChannelOpenAIURL(channel *model.Channel, path string) string {
+	baseURL := strings.TrimSuffix(channel.GetBaseURL(), "/")
+	if baseURL == "" {
+		baseURL = constant.ChannelBaseURLs[channel.Type]
+	}
+	if channel.Type == constant.ChannelTypeAzure {
+		apiVersion := channel.Other
+		if apiVersion == "" {
+			apiVersion = constant.AzureDefaultAPIVersion
+		}
+		return fmt.Sprintf("%s/openai%s?api-version=%s", baseURL, path, apiVersion)
+	}
+	return fmt.Sprintf("%s/v1%s", baseURL, path)
+}
+
+// DoChannelOpenAIRequest 使用渠道的指定 key 向上游发送请求
+func DoChannelOpenAIRequest(ctx context.Context, channel *model.Channel, keyIndex int, method string, path string, body io.Reader, contentType string) (*http.Response, error) {
+	key := channelKeyAt(channel, keyIndex)
+	if key == "" {
+		return nil, fmt.Errorf("channel #%d has no key at index %d", channel.Id, keyIndex)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, BuildChannelOpenAIURL(channel, path), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if channel.Type == constant.ChannelTypeAzure {
+		req.Header.Set("api-key", key)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+key)
+		if channel.OpenAIOrganization != nil && *channel.OpenAIOrganization != "" {
+			req.Header.Set("OpenAI-Organization", *channel.OpenAIOrganization)
+		}
+	}
+	client, err := GetHttpClientWithProxy(channel.GetSetting().Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// readUpstreamError 读取上游错误响应并转换为 error
+func readUpstreamError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var errResp dto.GeneralErrorResponse
+	if err := common.Unmarshal(body, &errResp); err == nil {
+		if openaiErr := errResp.TryToOpenAIError(); openaiErr != nil && openaiErr.Message != "" {
+			return fmt.Errorf("upstream status %d: %s", resp.StatusCode, openaiErr.Message)
+		}
+	}
+	return fmt.Errorf("upstream status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// UploadFileToChannel 以 multipart 流式上传文件到上游渠道，返回上游文件 ID 与使用的 key 索引
+func UploadFileToChannel(ctx context.Context, channel *model.Channel, filename string, purpose string, r io.Reader) (string, int, error) {
+	if !IsFileChannelSupported(channel.Type) {
+		return "", 0, fmt.Errorf("channel #%d does not support files api", channel.Id)
+	}
+	_, keyIndex, apiErr := channel.GetNextEnabledKey()
+	if apiErr != nil {
+		return "", 0, apiErr.Err
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	gopool.Go(func() {
+		err := writer.WriteField("purpose", purpose)
+		if err == nil {
+			var part io.Writer
+			part, err = writer.CreateFormFile("file", filename)
+			if err == nil {
+				_, err = io.Copy(part, r)
+			}
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		_ = pw.CloseWithError(err)
+	})
+
+	resp, err := DoChannelOpenAIRequest(ctx, channel, keyIndex, http.MethodPost, "/files", pr, writer.FormDataContentType())
+	if err != nil {
+		_ = pr.CloseWithError(err)
+		return "", 0, err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", 0, readUpstreamError(resp)
+	}
+	var upstreamFile dto.OpenAIFile
+	if err := common.DecodeJson(resp.Body, &upstreamFile); err != nil {
+		return "", 0, fmt.Errorf("failed to decode upstream file response: %w", err)
+	}
+	if upstreamFile.ID == "" {
+		return "", 0, errors.New("upstream returned empty file id")
+	}
+	return upstreamFile.ID, keyIndex, nil
+}
+
+// deleteChannelFile 删除上游渠道上的文件副本
+func deleteChannelFile(ctx context.Context, file *model.File) error {
+	channel, err := model.CacheGetChannel(file.ChannelId)
+	if err != nil {
+		return err
+	}
+	resp, err := DoChannelOpenAIRequest(ctx, channel, file.KeyIndex, http.MethodDelete, "/files/"+file.UpstreamFileId, nil, "")
+	if err != nil {
+		return err
+	}
+	defer CloseResponseBodyGracefully(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return readUpstreamError(resp)
+	}
+	return nil
+}
+
+// OpenFileContent 打开文件内容，优先读取本地副本，否则从上游渠道获取
+func OpenFileContent(ctx context.Context, file *model.File) (io.ReadCloser, error) {
+	if file.IsLocal() {
+		return common.OpenStoredFile(file.FileId)
+	}
+	if !file.IsUpstream() {
+		return nil, errors.New("file content is not available")
+	}
+	channel, err := model.CacheGetChannel(file.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := DoChannelOpenAIRequest(ctx, channel, file.KeyIndex, http.MethodGet, "/files/"+file.UpstreamFileId+"/content", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer CloseResponseBodyGracefully(resp)
+		return nil, readUpstreamError(resp)
+	}
+	return resp.Body, nil
+}
+
+// fileExpiresAt 根据保留天数计算过期时间，0 表示永不过期
+func fileExpiresAt(now int64) int64 {
+	days := operation_setting.GetFileSetting().RetentionDays
+	if days <= 0 {
+		return 0
+	}
+	return now + int64(days)*24*3600
+}
+
+// CreateLocalFile 将文件保存到本地存储并写入文件记录
+func CreateLocalFile(userId int, tokenId int, purpose string, filename string, r io.Reader) (*model.File, error) {
+	file := &model.File{
+		FileId:    model.GenerateFileID(),
+		UserId:    userId,
+		TokenId:   tokenId,
+		Purpose:   purpose,
+		Filename:  filename,
+		Status:    dto.FileStatusProcessed,
+		CreatedAt: common.GetTimestamp(),
+	}
+	size, err := common.SaveStoredFile(file.FileId, r, operation_setting.GetMaxFileSizeBytes())
+	if err != nil {
+		return nil, err
+	}
+	file.Stored = true
+	file.Bytes = size
+	file.ExpiresAt = fileExpiresAt(file.CreatedAt)
+	if err := file.Insert(); err != nil {
+		_ = common.RemoveStoredFile(file.FileId)
+		return nil, err
+	}
+	return file, nil
+}
+
+// CreateUpstreamFile 将文件上传到上游渠道并写入文件记录
+func CreateUpstreamFile(ctx context.Context, channel *model.Channel, userId int, tokenId int, purpose string, filename string, size int64, r io.Reader) (*model.File, error) {
+	upstreamFileId, keyIndex, err := UploadFileToChannel(ctx, channel, filename, purpose, r)
+	if err != nil {
+		return nil, err
+	}
+	file := &model.File{
+		FileId:         model.GenerateFileID(),
+		UserId:         userId,
+		TokenId:        tokenId,
+		Purpose:        purpose,
+		Filename:       filename,
+		Bytes:          size,
+		Status:         dto.FileStatusProcessed,
+		ChannelId:      channel.Id,
+		UpstreamFileId: upstreamFileId,
+		KeyIndex:       keyIndex,
+		CreatedAt:      common.GetTimestamp(),
+	}
+	file.ExpiresAt = fileExpiresAt(file.CreatedAt)
+	if err := file.Insert(); err != nil {
+		if delErr := deleteChannelFile(ctx, file); delErr != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to rollback upstream file %s on channel #%d: %s", upstreamFileId, channel.Id, delErr.Error()))
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// EnsureFileOnChannel 确保文件在指定渠道上存在，必要时把本地副本上传到该渠道，返回上游文件 ID 与 key 索引
+func EnsureFileOnChannel(ctx context.Context, file *model.File, channel *model.Channel) (string, int, error) {
+	if file.IsUpstream() && file.ChannelId == channel.Id {
+		return file.UpstreamFileId, file.KeyIndex, nil
+	}
+	if !file.IsLocal() {
+		return "", 0, fmt.Errorf("file %s is stored on channel #%d and cannot be used on channel #%d", file.FileId, file.ChannelId, channel.Id)
+	}
+	reader, err := common.OpenStoredFile(file.FileId)
+	if err != nil {
+		return "", 0, err
+	}
+	defer reader.Close()
+	upstreamFileId, keyIndex, err := UploadFileToChannel(ctx, channel, file.Filename, file.Purpose, reader)
+	if err != nil {
+		return "", 0, err
+	}
+	if file.IsUpstream() {
+		// 旧渠道上的副本不再被引用
+		if delErr := deleteChannelFile(ctx, file); delErr != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to delete stale upstream copy of file %s: %s", file.FileId, delErr.Error()))
+		}
+	}
+	if err := file.UpdateUpstream(channel.Id, upstreamFileId, keyIndex); err != nil {
+		return "", 0, err
+	}
+	return upstreamFileId, keyIndex, nil
+}
+
+// DeleteFile 删除文件的本地副本、上游副本以及文件记录
+func DeleteFile(ctx context.Context, file *model.File) error {
+	if file.IsUpstream() {
+		if err := deleteChannelFile(ctx, file); err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to delete upstream file %s on channel #%d: %s", file.UpstreamFileId, file.ChannelId, err.Error()))
+		}
+	}
+	if file.IsLocal() {
+		if err := common.RemoveStoredFile(file.FileId); err != nil {
+			return err
+		}
+	}
+	return file.Delete()
+}
+
+// StartFileCleanupTask 定期清理已过期的文件
+func StartFileCleanupTask() {
+	fileCleanupOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("file cleanup task started: tick=%s", fileCleanupTickInterval))
+			ticker := time.NewTicker(fileCleanupTickInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runFileCleanupOnce()
+			}
+		})
+	})
+}
+
+func runFileCleanupOnce() {
+	ctx := context.Background()
+	files, err := model.GetExpiredFiles(common.GetTimestamp(), fileCleanupBatchSize)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("file cleanup task failed: %v", err))
+		return
+	}
+	for _, file := range files {
+		if err := DeleteFile(ctx, file); err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to delete expired file %s: %v", file.FileId, err))
+		}
+	}
+	if len(files) > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("file cleanup task: deleted %d expired files", len(files)))
+	}
+}
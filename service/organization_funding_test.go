@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func truncateOrganizations(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		model.DB.Exec("DELETE FROM organizations")
+		model.DB.Exec("DELETE FROM organization_members")
+		model.DB.Exec("DELETE FROM organization_invitations")
+	})
+}
+
+func seedOrganization(t *testing.T, id int, ownerId int, quota int) {
+	t.Helper()
+	org := &model.Organization{Id: id, Name: "test_org", Quota: quota}
+	require.NoError(t, model.CreateOrganization(org, ownerId))
+}
+
+func getOrganizationQuota(t *testing.T, id int) (quota int, used int) {
+	t.Helper()
+	org, err := model.GetOrganizationById(id)
+	require.NoError(t, err)
+	return org.Quota, org.UsedQuota
+}
+
+func getOrganizationMemberUsed(t *testing.T, userId int) int {
+	t.Helper()
+	member, err := model.GetOrganizationMemberByUserId(userId)
+	require.NoError(t, err)
+	return member.UsedQuota
+}
+
+func TestOrganizationFunding_PreConsumeSettleRefund(t *testing.T) {
+	truncate(t)
+	truncateOrganizations(t)
+
+	const userID, orgID = 1, 1
+	seedUser(t, userID, 500)
+	seedOrganization(t, orgID, userID, 10000)
+
+	funding := &OrganizationFunding{organizationId: orgID, userId: userID}
+	require.NoError(t, funding.PreConsume(3000))
+	quota, used := getOrganizationQuota(t, orgID)
+	assert.Equal(t, 7000, quota)
+	assert.Equal(t, 3000, used)
+	assert.Equal(t, 3000, getOrganizationMemberUsed(t, userID))
+
+	// 实际消耗 2000，退还 1000
+	require.NoError(t, funding.Settle(-1000))
+	quota, used = getOrganizationQuota(t, orgID)
+	assert.Equal(t, 8000, quota)
+	assert.Equal(t, 2000, used)
+	assert.Equal(t, 2000, getOrganizationMemberUsed(t, userID))
+
+	// 个人钱包不受影响
+	assert.Equal(t, 500, getUserQuota(t, userID))
+
+	refund := &OrganizationFunding{organizationId: orgID, userId: userID}
+	require.NoError(t, refund.PreConsume(500))
+	require.NoError(t, refund.Refund())
+	quota, _ = getOrganizationQuota(t, orgID)
+	assert.Equal(t, 8000, quota)
+	assert.Equal(t, 2000, getOrganizationMemberUsed(t, userID))
+}
+
+func TestOrganizationFunding_MemberCapExceeded(t *testing.T) {
+	truncate(t)
+	truncateOrganizations(t)
+
+	const ownerID, memberID, orgID = 1, 2, 1
+	seedUser(t, ownerID, 0)
+	require.NoError(t, model.DB.Create(&model.User{Id: memberID, Username: "member_user", AffCode: "member"}).Error)
+	seedOrganization(t, orgID, ownerID, 10000)
+	require.NoError(t, model.AddOrganizationMember(&model.OrganizationMember{
+		OrganizationId: orgID,
+		UserId:         memberID,
+		Role:           model.OrganizationRoleMember,
+		QuotaLimit:     1000,
+	}))
+
+	funding := &OrganizationFunding{organizationId: orgID, userId: memberID}
+	require.NoError(t, funding.PreConsume(800))
+
+	next := &OrganizationFunding{organizationId: orgID, userId: memberID}
+	assert.ErrorIs(t, next.PreConsume(300), model.ErrOrganizationMemberCapExceeded)
+
+	// 所有者不受该成员上限影响
+	owner := &OrganizationFunding{organizationId: orgID, userId: ownerID}
+	require.NoError(t, owner.PreConsume(300))
+
+	quota, used := getOrganizationQuota(t, orgID)
+	assert.Equal(t, 8900, quota)
+	assert.Equal(t, 1100, used)
+}
+
+func TestOrganizationFunding_InsufficientAndDisabled(t *testing.T) {
+	truncate(t)
+	truncateOrganizations(t)
+
+	const userID, orgID = 1, 1
+	seedUser(t, userID, 0)
+	seedOrganization(t, orgID, userID, 1000)
+
+	funding := &OrganizationFunding{organizationId: orgID, userId: userID}
+	assert.ErrorIs(t, funding.PreConsume(2000), model.ErrOrganizationQuotaInsufficient)
+
+	org, err := model.GetOrganizationById(orgID)
+	require.NoError(t, err)
+	org.Status = model.OrganizationStatusDisabled
+	require.NoError(t, org.Update())
+	assert.ErrorIs(t, funding.PreConsume(100), model.ErrOrganizationDisabled)
+
+	quota, used := getOrganizationQuota(t, orgID)
+	assert.Equal(t, 1000, quota)
+	assert.Equal(t, 0, used)
+}
+
+func TestRefundTaskQuota_Organization(t *testing.T) {
+	truncate(t)
+	truncateOrganizations(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID, orgID = 1, 1, 1, 1
+	const preConsumed = 3000
+
+	seedUser(t, userID, 500)
+	seedToken(t, tokenID, userID, "sk-test-key", 5000)
+	seedChannel(t, channelID)
+	seedOrganization(t, orgID, userID, 10000)
+	require.NoError(t, model.AdjustOrganizationQuota(orgID, userID, preConsumed))
+
+	task := makeTask(userID, channelID, preConsumed, tokenID, BillingSourceOrganization, 0)
+	task.PrivateData.OrganizationId = orgID
+
+	RefundTaskQuota(ctx, task, "task failed: upstream error")
+
+	quota, used := getOrganizationQuota(t, orgID)
+	assert.Equal(t, 10000, quota)
+	assert.Equal(t, 0, used)
+	assert.Equal(t, 0, getOrganizationMemberUsed(t, userID))
+	assert.Equal(t, 500, getUserQuota(t, userID))
+
+	log := getLastLog(t)
+	require.NotNil(t, log)
+	assert.Equal(t, model.LogTypeRefund, log.Type)
+	assert.Equal(t, orgID, log.OrganizationId)
+}
+
+func TestNewBillingSession_OrganizationOnlyForBoundToken(t *testing.T) {
+	truncate(t)
+	truncateOrganizations(t)
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	const ownerID, memberID, orgID = 1, 2, 1
+	seedUser(t, ownerID, 0)
+	require.NoError(t, model.DB.Create(&model.User{Id: memberID, Username: "member_user", AffCode: "member", Quota: 5000}).Error)
+	seedOrganization(t, orgID, ownerID, 10000)
+
+	// 成员需要接受邀请才会加入组织
+	invitation := &model.OrganizationInvitation{OrganizationId: orgID, UserId: memberID, InviterId: ownerID}
+	require.NoError(t, model.CreateOrganizationInvitation(invitation))
+	_, err := model.GetOrganizationMemberByUserId(memberID)
+	assert.ErrorIs(t, err, model.ErrOrganizationMemberNotFound)
+	_, err = model.AcceptOrganizationInvitation(invitation.Id, memberID)
+	require.NoError(t, err)
+
+	// 普通令牌仍从个人钱包扣费
+	session, apiErr := NewBillingSession(c, newBudgetRelayInfo(memberID, 1), 600)
+	require.Nil(t, apiErr)
+	assert.Equal(t, BillingSourceWallet, session.funding.Source())
+	assert.Equal(t, 4400, getUserQuota(t, memberID))
+	assert.Equal(t, 0, common.GetContextKeyInt(c, constant.ContextKeyBillingOrganizationId))
+	quota, _ := getOrganizationQuota(t, orgID)
+	assert.Equal(t, 10000, quota)
+
+	// 绑定组织的令牌从组织钱包扣费
+	orgInfo := newBudgetRelayInfo(memberID, 1)
+	orgInfo.OrganizationId = orgID
+	session, apiErr = NewBillingSession(c, orgInfo, 600)
+	require.Nil(t, apiErr)
+	assert.Equal(t, BillingSourceOrganization, session.funding.Source())
+	assert.Equal(t, orgID, common.GetContextKeyInt(c, constant.ContextKeyBillingOrganizationId))
+	quota, _ = getOrganizationQuota(t, orgID)
+	assert.Equal(t, 9400, quota)
+	assert.Equal(t, 4400, getUserQuota(t, memberID))
+
+	// 退出组织后组织令牌被拒绝，不会改用个人钱包
+	assert.ErrorIs(t, model.LeaveOrganization(ownerID), model.ErrOrganizationOwnerCannotLeave)
+	require.NoError(t, model.LeaveOrganization(memberID))
+	orgInfo = newBudgetRelayInfo(memberID, 1)
+	orgInfo.OrganizationId = orgID
+	_, apiErr = NewBillingSession(c, orgInfo, 600)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, 4400, getUserQuota(t, memberID))
+}
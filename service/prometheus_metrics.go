@@ -0,0 +1,27 @@
+package service
+
+import (
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	prommetrics "github.com/QuantumNous/new-api/pkg/prom_metrics"
+)
+
+// InitPrometheusMetrics 注册 /metrics 抓取时采集的渠道状态、任务积压以及数据库与 Redis 连接池指标，
+// 需在数据库与 Redis 初始化之后调用
+func InitPrometheusMetrics() {
+	prommetrics.RegisterStateSources(prommetrics.StateSources{
+		Channels:    model.GetChannelMetricStates,
+		TaskBacklog: model.CountUnfinishedTasksByPlatform,
+	})
+	if sqlDB, err := model.DB.DB(); err == nil {
+		prommetrics.RegisterDB("main", sqlDB)
+	}
+	if model.LOG_DB != model.DB {
+		if sqlDB, err := model.LOG_DB.DB(); err == nil {
+			prommetrics.RegisterDB("log", sqlDB)
+		}
+	}
+	if common.RedisEnabled {
+		prommetrics.RegisterRedis(common.RDB)
+	}
+}
@@ -229,6 +229,9 @@ func PostWssConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, mod
 	if err := SettleBilling(ctx, relayInfo, quota); err != nil {
 		logger.LogError(ctx, "error settling billing: "+err.Error())
 	}
+	ConsumeTokenTpm(ctx, relayInfo.TokenId, usage.InputTokens+usage.OutputTokens)
+	SettleModelTokenRateLimit(ctx, usage.InputTokens+usage.OutputTokens)
+	RecordChannelKeyTokens(relayInfo, usage.InputTokens+usage.OutputTokens)
 
 	logModel := modelName
 	if extraContent != "" {
@@ -350,6 +353,9 @@ func PostAudioConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, u
 	if err := SettleBilling(ctx, relayInfo, quota); err != nil {
 		logger.LogError(ctx, "error settling billing: "+err.Error())
 	}
+	ConsumeTokenTpm(ctx, relayInfo.TokenId, usage.PromptTokens+usage.CompletionTokens)
+	SettleModelTokenRateLimit(ctx, usage.PromptTokens+usage.CompletionTokens)
+	RecordChannelKeyTokens(relayInfo, usage.PromptTokens+usage.CompletionTokens)
 
 	logModel := relayInfo.OriginModelName
 	if extraContent != "" {
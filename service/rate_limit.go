@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/common/limiter"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 令牌桶公共方法：启用 Redis 时使用 common/limiter 的 Lua 脚本，否则使用进程内实现。
+// 与 ModelRequestRateLimit 一致，桶以 1/window 为单位计数：容量 limit*window，每秒补充 limit，
+// 因此每个请求/token 需要 window 个单位。
+// ---------------------------------------------------------------------------
+
+func bucketOptions(limit int, window int64, requested int64) []limiter.Option {
+	return []limiter.Option{
+		limiter.WithCapacity(int64(limit) * window),
+		limiter.WithRate(int64(limit)),
+		limiter.WithRequested(requested * window),
+	}
+}
+
+// allowBucket 余量充足时扣减并放行
+func allowBucket(ctx context.Context, key string, opts ...limiter.Option) (bool, error) {
+	if common.RedisEnabled {
+		return limiter.New(ctx, common.RDB).Allow(ctx, key, opts...)
+	}
+	return limiter.NewMemory().Allow(key, opts...), nil
+}
+
+// consumeBucket 无条件扣减（requested 为负时归还），返回扣减后的余量
+func consumeBucket(ctx context.Context, key string, opts ...limiter.Option) (int64, error) {
+	if common.RedisEnabled {
+		return limiter.New(ctx, common.RDB).Consume(ctx, key, opts...)
+	}
+	return limiter.NewMemory().Consume(key, opts...), nil
+}
+
+// bucketWaitTime 余量 remaining（桶单位）补足到 needed（桶单位）所需的时间
+func bucketWaitTime(remaining int64, needed int64, limit int) time.Duration {
+	if remaining >= needed || limit <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(float64(needed-remaining)/float64(limit))) * time.Second
+}
+
+// RateLimitHeaderInfo 需要通过响应头告知客户端的限流状态，Limit 为 0 的项不输出
+type RateLimitHeaderInfo struct {
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Duration
+	TokensLimit       int
+	TokensRemaining   int
+	TokensReset       time.Duration
+	// QuotaRemaining 令牌剩余额度，nil 表示无限额度或未知
+	QuotaRemaining *int
+	RetryAfter     time.Duration
+}
+
+// merge 同一请求可能经过多个限流器（全局、分组、令牌、分组 × 模型），每一项保留余量最少的那个
+func (info *RateLimitHeaderInfo) merge(other RateLimitHeaderInfo) {
+	if other.RequestsLimit > 0 && (info.RequestsLimit <= 0 || other.RequestsRemaining < info.RequestsRemaining) {
+		info.RequestsLimit = other.RequestsLimit
+		info.RequestsRemaining = other.RequestsRemaining
+		info.RequestsReset = other.RequestsReset
+	}
+	if other.TokensLimit > 0 && (info.TokensLimit <= 0 || other.TokensRemaining < info.TokensRemaining) {
+		info.TokensLimit = other.TokensLimit
+		info.TokensRemaining = other.TokensRemaining
+		info.TokensReset = other.TokensReset
+	}
+	if other.QuotaRemaining != nil && (info.QuotaRemaining == nil || *other.QuotaRemaining < *info.QuotaRemaining) {
+		info.QuotaRemaining = other.QuotaRemaining
+	}
+	info.RetryAfter = max(info.RetryAfter, other.RetryAfter)
+}
+
+// InboundRelayFormat 在中间件中按请求路径推断入站格式，用于选择限流响应头
+func InboundRelayFormat(c *gin.Context) types.RelayFormat {
+	path := c.Request.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v1/messages"):
+		return types.RelayFormatClaude
+	case strings.HasPrefix(path, "/v1beta/"):
+		return types.RelayFormatGemini
+	default:
+		return types.RelayFormatOpenAI
+	}
+}
+
+// SetRateLimitHeaders 与本次请求已记录的限流状态合并后，按入站请求格式写入限流响应头：
+// OpenAI/Gemini 使用 x-ratelimit-*，Claude 使用 anthropic-ratelimit-*，被拒绝时附带 Retry-After。
+// 需在响应写出前调用。
+func SetRateLimitHeaders(c *gin.Context, format types.RelayFormat, info RateLimitHeaderInfo) {
+	if current, ok := common.GetContextKeyType[*RateLimitHeaderInfo](c, constant.ContextKeyRateLimitHeaders); ok && current != nil {
+		current.merge(info)
+		info = *current
+	} else {
+		merged := info
+		common.SetContextKey(c, constant.ContextKeyRateLimitHeaders, &merged)
+	}
+
+	if info.RequestsLimit > 0 {
+		remaining := strconv.Itoa(max(info.RequestsRemaining, 0))
+		if format == types.RelayFormatClaude {
+			c.Header("anthropic-ratelimit-requests-limit", strconv.Itoa(info.RequestsLimit))
+			c.Header("anthropic-ratelimit-requests-remaining", remaining)
+			c.Header("anthropic-ratelimit-requests-reset", time.Now().Add(info.RequestsReset).UTC().Format(time.RFC3339))
+		} else {
+			c.Header("x-ratelimit-limit-requests", strconv.Itoa(info.RequestsLimit))
+			c.Header("x-ratelimit-remaining-requests", remaining)
+			c.Header("x-ratelimit-reset-requests", formatRateLimitReset(info.RequestsReset))
+		}
+	}
+	if info.TokensLimit > 0 {
+		remaining := strconv.Itoa(max(info.TokensRemaining, 0))
+		if format == types.RelayFormatClaude {
+			c.Header("anthropic-ratelimit-tokens-limit", strconv.Itoa(info.TokensLimit))
+			c.Header("anthropic-ratelimit-tokens-remaining", remaining)
+			c.Header("anthropic-ratelimit-tokens-reset", time.Now().Add(info.TokensReset).UTC().Format(time.RFC3339))
+		} else {
+			c.Header("x-ratelimit-limit-tokens", strconv.Itoa(info.TokensLimit))
+			c.Header("x-ratelimit-remaining-tokens", remaining)
+			c.Header("x-ratelimit-reset-tokens", formatRateLimitReset(info.TokensReset))
+		}
+	}
+	if info.QuotaRemaining != nil {
+		remaining := strconv.Itoa(max(*info.QuotaRemaining, 0))
+		if format == types.RelayFormatClaude {
+			c.Header("anthropic-ratelimit-quota-remaining", remaining)
+		} else {
+			c.Header("x-ratelimit-remaining-quota", remaining)
+		}
+	}
+	if info.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(info.RetryAfter.Seconds())), 10))
+	}
+}
+
+// formatRateLimitReset 与 OpenAI 一致的重置时间格式，例如 "6s"、"1m30s"
+func formatRateLimitReset(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	return d.Round(time.Second).String()
+}
+
+// NewRateLimitError 按入站请求格式构造 429 错误，不触发渠道重试
+func NewRateLimitError(format types.RelayFormat, message string) *types.NewAPIError {
+	switch format {
+	case types.RelayFormatClaude:
+		return types.WithClaudeError(types.ClaudeError{
+			Type:    "rate_limit_error",
+			Message: message,
+		}, http.StatusTooManyRequests, types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+	case types.RelayFormatGemini:
+		return types.WithGeminiError(types.GeminiError{
+			Message: message,
+		}, http.StatusTooManyRequests, types.ErrorCodeRateLimitExceeded, types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+	}
+	return types.WithOpenAIError(types.OpenAIError{
+		Message: message,
+		Type:    "tokens",
+		Code:    string(types.ErrorCodeRateLimitExceeded),
+	}, http.StatusTooManyRequests, types.ErrOptionWithSkipRetry(), types.ErrOptionWithNoRecordErrorLog())
+}
+
+func formatTokenRateLimitMessage(scope string, limit int, window string, requested int) string {
+	return fmt.Sprintf("Rate limit reached for %s on tokens per %s: Limit %d, Requested %d. Please try again later.", scope, window, limit, requested)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRateLimitHeaders_KeepsTightestLimit(t *testing.T) {
+	c, w := newModelTokenRateLimitContext()
+	quota := 500
+
+	SetRateLimitHeaders(c, types.RelayFormatOpenAI, RateLimitHeaderInfo{RequestsLimit: 100, RequestsRemaining: 80, RequestsReset: 12 * time.Second})
+	SetRateLimitHeaders(c, types.RelayFormatOpenAI, RateLimitHeaderInfo{RequestsLimit: 10, RequestsRemaining: 3, RequestsReset: time.Minute, QuotaRemaining: &quota})
+	SetRateLimitHeaders(c, types.RelayFormatOpenAI, RateLimitHeaderInfo{RequestsLimit: 60, RequestsRemaining: 59, TokensLimit: 1000, TokensRemaining: 400})
+
+	require.Equal(t, "10", w.Header().Get("x-ratelimit-limit-requests"))
+	require.Equal(t, "3", w.Header().Get("x-ratelimit-remaining-requests"))
+	require.Equal(t, "1m0s", w.Header().Get("x-ratelimit-reset-requests"))
+	require.Equal(t, "400", w.Header().Get("x-ratelimit-remaining-tokens"))
+	require.Equal(t, "500", w.Header().Get("x-ratelimit-remaining-quota"))
+	require.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestSetRateLimitHeaders_ClaudeFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	require.EqualValues(t, types.RelayFormatClaude, InboundRelayFormat(c))
+
+	SetRateLimitHeaders(c, InboundRelayFormat(c), RateLimitHeaderInfo{RequestsLimit: 5, RequestsRemaining: -1, RetryAfter: 1500 * time.Millisecond})
+
+	require.Equal(t, "5", w.Header().Get("anthropic-ratelimit-requests-limit"))
+	require.Equal(t, "0", w.Header().Get("anthropic-ratelimit-requests-remaining"))
+	require.NotEmpty(t, w.Header().Get("anthropic-ratelimit-requests-reset"))
+	require.Empty(t, w.Header().Get("x-ratelimit-limit-requests"))
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
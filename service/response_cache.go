@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/cachex"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/hot"
+	"github.com/shopspring/decimal"
+	"github.com/tidwall/gjson"
+)
+
+// ---------------------------------------------------------------------------
+// 精确匹配的响应缓存（operation_setting.ResponseCacheSetting）。
+// 以分组、模型与规范化后的请求体为 key，由 middleware.ResponseCache 在 Distribute 选择渠道前查询：
+// 命中时不选择渠道（不占用渠道 Key 的限额，没有可用渠道时也能返回），由 Relay 在预扣费后直接返回；
+// 未命中时记录本次返回给客户端的原始响应（流式为 SSE 原文），请求成功结算后写入缓存。
+// 精确匹配未命中时再查询语义缓存（semantic_cache.go），两者共用响应记录与命中计费逻辑。
+// ---------------------------------------------------------------------------
+
+const responseCacheNamespace = "new-api:response_cache:v1"
+
+var (
+	responseCacheOnce sync.Once
+	responseCache     *cachex.HybridCache[ResponseCacheEntry]
+)
+
+// ResponseCacheEntry 一条缓存的响应及其原始计费信息
+type ResponseCacheEntry struct {
+	ContentType      string `json:"content_type"`
+	Body             string `json:"body"`
+	IsStream         bool   `json:"is_stream"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	Quota            int    `json:"quota"`
+	CreatedAt        int64  `json:"created_at"`
+	// Similarity 语义缓存命中时的相似度，精确匹配命中时为 0
+	Similarity float64 `json:"-"`
+}
+
+func getResponseCache() *cachex.HybridCache[ResponseCacheEntry] {
+	responseCacheOnce.Do(func() {
+		capacity := common.GetEnvOrDefault("RESPONSE_CACHE_CAP", 10000)
+		if capacity <= 0 {
+			capacity = 10000
+		}
+		responseCache = cachex.NewHybridCache[ResponseCacheEntry](cachex.HybridCacheConfig[ResponseCacheEntry]{
+			Namespace: cachex.Namespace(responseCacheNamespace),
+			Redis:     common.RDB,
+			RedisEnabled: func() bool {
+				return common.RedisEnabled && common.RDB != nil
+			},
+			RedisCodec: cachex.JSONCodec[ResponseCacheEntry]{},
+			Memory: func() *hot.HotCache[string, ResponseCacheEntry] {
+				// 写入时由 SetWithTTL 按当前配置指定过期时间，此处仅作为默认值
+				return hot.NewHotCache[string, ResponseCacheEntry](hot.LRU, capacity).
+					WithTTL(time.Hour).
+					WithJanitor().
+					Build()
+			},
+		})
+	})
+	return responseCache
+}
+
+// responseCacheRequest 查询缓存所用的请求信息，在选择渠道前从上下文与请求体中获取
+type responseCacheRequest struct {
+	group     string
+	modelName string
+	relayMode int
+	userId    int
+}
+
+// responseCacheLookup 一次缓存查询的结果：命中时 entry 不为空，否则记录写入缓存所需的 key
+type responseCacheLookup struct {
+	entry    *ResponseCacheEntry
+	key      string
+	semantic *semanticCacheCandidate
+}
+
+// responseCacheCapture 未命中时记录返回给客户端的响应，结算时补充计费信息
+type responseCacheCapture struct {
+	gin.ResponseWriter
+	key      string                  // 精确匹配缓存 key，为空时不写入
+	semantic *semanticCacheCandidate // 语义缓存的请求信息，为 nil 时不写入
+	limit    int
+	buf      bytes.Buffer
+	overflow bool
+	settled  bool
+	entry    ResponseCacheEntry
+}
+
+func (w *responseCacheCapture) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCacheCapture) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseCacheCapture) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.buf.Len()+len(data) > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return
+	}
+	w.buf.Write(data)
+}
+
+// LookupResponseCache 在选择渠道前依次查询精确匹配缓存与语义缓存，结果记录在上下文中：
+// 命中时由 GetResponseCacheHit 取得，未命中但可缓存时由 StartResponseCacheCapture 开始记录本次响应。
+// 客户端可通过 Cache-Control: no-cache / no-store 跳过缓存。
+func LookupResponseCache(c *gin.Context) *ResponseCacheEntry {
+	cacheControl := strings.ToLower(c.GetHeader("Cache-Control"))
+	if strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store") {
+		return nil
+	}
+	request := responseCacheRequest{
+		group:     common.GetContextKeyString(c, constant.ContextKeyUsingGroup),
+		relayMode: relayconstant.Path2RelayMode(c.Request.URL.Path),
+		userId:    common.GetContextKeyInt(c, constant.ContextKeyUserId),
+	}
+	if request.relayMode != relayconstant.RelayModeChatCompletions && request.relayMode != relayconstant.RelayModeEmbeddings {
+		return nil
+	}
+	if !operation_setting.GetResponseCacheSetting().Enabled && !operation_setting.GetSemanticCacheSetting().Enabled {
+		return nil
+	}
+	storage, err := common.GetBodyStorage(c)
+	if err != nil {
+		return nil
+	}
+	body, err := storage.Bytes()
+	if err != nil {
+		return nil
+	}
+	request.modelName = gjson.GetBytes(body, "model").String()
+	if request.modelName == "" {
+		return nil
+	}
+	// 令牌不允许使用该模型时由 Distribute 拒绝请求，不查询缓存，也不为语义缓存产生向量化消耗
+	if _, pinned := common.GetContextKey(c, constant.ContextKeyTokenSpecificChannelId); !pinned && !tokenAllowsModel(c, request.modelName) {
+		return nil
+	}
+
+	lookup := &responseCacheLookup{}
+	key, cacheable := responseCacheKey(request, body)
+	if cacheable {
+		if entry, hit := GetResponseCache(key); hit {
+			lookup.entry = entry
+			common.SetContextKey(c, constant.ContextKeyResponseCacheLookup, lookup)
+			return entry
+		}
+		lookup.key = key
+	}
+	lookup.entry, lookup.semantic = lookupSemanticCache(c, request, body)
+	if lookup.entry != nil || lookup.key != "" || lookup.semantic != nil {
+		common.SetContextKey(c, constant.ContextKeyResponseCacheLookup, lookup)
+	}
+	return lookup.entry
+}
+
+// GetResponseCacheHit 返回选择渠道前命中的缓存响应，未命中时返回 nil
+func GetResponseCacheHit(c *gin.Context) *ResponseCacheEntry {
+	lookup, ok := common.GetContextKeyType[*responseCacheLookup](c, constant.ContextKeyResponseCacheLookup)
+	if !ok || lookup == nil {
+		return nil
+	}
+	return lookup.entry
+}
+
+// StartResponseCacheCapture 缓存未命中但请求可缓存时，开始记录返回给客户端的响应
+func StartResponseCacheCapture(c *gin.Context) {
+	lookup, ok := common.GetContextKeyType[*responseCacheLookup](c, constant.ContextKeyResponseCacheLookup)
+	if !ok || lookup == nil || lookup.entry != nil {
+		return
+	}
+	startResponseCacheCapture(c, lookup.key, lookup.semantic)
+}
+
+// responseCacheKey 计算请求的缓存 key，请求不可缓存时返回 ok=false。
+// 仅缓存对话与 embedding 请求；开启 DeterministicOnly 时对话请求必须显式指定 temperature 为 0。
+func responseCacheKey(request responseCacheRequest, body []byte) (string, bool) {
+	setting := operation_setting.GetResponseCacheSetting()
+	if !setting.Enabled {
+		return "", false
+	}
+	if request.relayMode != relayconstant.RelayModeChatCompletions && request.relayMode != relayconstant.RelayModeEmbeddings {
+		return "", false
+	}
+	normalized, ok := normalizeResponseCacheBody(body, request.relayMode == relayconstant.RelayModeChatCompletions && setting.DeterministicOnly)
+	if !ok {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(request.group + "\n" + request.modelName + "\n" + normalized))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// normalizeResponseCacheBody 将请求体重新序列化为键名有序、无多余空白的 JSON，使语义相同的请求得到相同的 key
+func normalizeResponseCacheBody(body []byte, requireZeroTemperature bool) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var request map[string]any
+	if err := decoder.Decode(&request); err != nil {
+		return "", false
+	}
+	if requireZeroTemperature {
+		temperature, ok := request["temperature"].(json.Number)
+		if !ok {
+			return "", false
+		}
+		if value, err := temperature.Float64(); err != nil || value != 0 {
+			return "", false
+		}
+	}
+	normalized, err := common.Marshal(request)
+	if err != nil {
+		return "", false
+	}
+	return string(normalized), true
+}
+
+// GetResponseCache 查询缓存的响应
+func GetResponseCache(key string) (*ResponseCacheEntry, bool) {
+	entry, found, err := getResponseCache().Get(key)
+	if err != nil {
+		common.SysError(fmt.Sprintf("get response cache failed: %s", err.Error()))
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// startResponseCacheCapture 开始记录当前请求返回给客户端的响应
+func startResponseCacheCapture(c *gin.Context, key string, semantic *semanticCacheCandidate) {
+	limit := 0
+	if key != "" {
+		limit = operation_setting.GetResponseCacheSetting().MaxEntryKB << 10
+	}
+	if semantic != nil {
+		limit = max(limit, operation_setting.GetSemanticCacheSetting().MaxEntryKB<<10)
+	}
+	if limit <= 0 {
+		return
+	}
+	w := &responseCacheCapture{ResponseWriter: c.Writer, key: key, semantic: semantic, limit: limit}
+	c.Writer = w
+	common.SetContextKey(c, constant.ContextKeyResponseCacheCapture, w)
+}
+
+// recordResponseCacheUsage 结算时记录本次请求的计费信息，命中缓存时按该额度的倍率计费
+func recordResponseCacheUsage(c *gin.Context, quota int, promptTokens int, completionTokens int) {
+	w, ok := common.GetContextKeyType[*responseCacheCapture](c, constant.ContextKeyResponseCacheCapture)
+	if !ok || w == nil {
+		return
+	}
+	w.settled = true
+	w.entry.Quota = quota
+	w.entry.PromptTokens = promptTokens
+	w.entry.CompletionTokens = completionTokens
+}
+
+// FinishResponseCacheCapture 请求成功且已结算时写入缓存
+func FinishResponseCacheCapture(c *gin.Context, info *relaycommon.RelayInfo, success bool) {
+	w, ok := common.GetContextKeyType[*responseCacheCapture](c, constant.ContextKeyResponseCacheCapture)
+	if !ok || w == nil {
+		return
+	}
+	c.Writer = w.ResponseWriter
+	common.SetContextKey(c, constant.ContextKeyResponseCacheCapture, nil)
+	if !success || !w.settled || w.overflow || w.buf.Len() == 0 || w.Status() != http.StatusOK {
+		return
+	}
+	if info.StreamStatus != nil && (!info.StreamStatus.IsNormalEnd() || info.StreamStatus.HasErrors()) {
+		return
+	}
+	// 降级模型的响应不作为原模型请求的缓存
+	if GetModelFallbackFrom(c) != "" {
+		return
+	}
+	entry := w.entry
+	entry.ContentType = w.Header().Get("Content-Type")
+	entry.Body = w.buf.String()
+	entry.IsStream = info.IsStream
+	entry.CreatedAt = common.GetTimestamp()
+	if w.semantic != nil {
+		storeSemanticCache(c, w.semantic, entry)
+	}
+	if w.key == "" {
+		return
+	}
+	setting := operation_setting.GetResponseCacheSetting()
+	ttl := time.Duration(setting.TTLSeconds) * time.Second
+	if ttl <= 0 || len(entry.Body) > setting.MaxEntryKB<<10 {
+		return
+	}
+	if err := getResponseCache().SetWithTTL(w.key, entry, ttl); err != nil {
+		logger.LogError(c, fmt.Sprintf("set response cache failed: %s", err.Error()))
+	}
+}
+
+// SettleResponseCacheHit 命中缓存时按 BillingRatio 计费并记录消费日志，日志 other 中带有 response_cache_hit 标记，
+// 语义缓存命中时额外记录 semantic_cache_similarity
+func SettleResponseCacheHit(c *gin.Context, info *relaycommon.RelayInfo, entry *ResponseCacheEntry) {
+	ratio := operation_setting.GetResponseCacheSetting().BillingRatio
+	content := "响应缓存命中"
+	if entry.Similarity > 0 {
+		ratio = operation_setting.GetSemanticCacheSetting().BillingRatio
+		content = fmt.Sprintf("语义缓存命中，相似度 %.4f", entry.Similarity)
+	}
+	if ratio < 0 {
+		ratio = 0
+	}
+	quota := int(decimal.NewFromInt(int64(entry.Quota)).Mul(decimal.NewFromFloat(ratio)).Round(0).IntPart())
+	if info.PriceData.FreeModel {
+		quota = 0
+	}
+	info.SetFirstResponseTime()
+	// 命中时 Distribute 跳过了渠道选择，ChannelMeta 未初始化；补一个空的，日志中不带渠道信息
+	if info.ChannelMeta == nil {
+		info.ChannelMeta = &relaycommon.ChannelMeta{}
+	}
+
+	if err := SettleBilling(c, info, quota); err != nil {
+		logger.LogError(c, "error settling billing: "+err.Error())
+	}
+	if quota > 0 {
+		model.UpdateUserUsedQuotaAndRequestCount(info.UserId, quota)
+	}
+	// 缓存命中不消耗上游 token，归还分组 × 模型 TPM/TPD 预占
+	ReleaseModelTokenRateLimit(c)
+
+	priceData := info.PriceData
+	other := GenerateTextOtherInfo(c, info, priceData.ModelRatio, priceData.GroupRatioInfo.GroupRatio, priceData.CompletionRatio,
+		0, 0, priceData.ModelPrice, priceData.GroupRatioInfo.GroupSpecialRatio)
+	other["response_cache_hit"] = true
+	other["response_cache_ratio"] = ratio
+	other["response_cache_origin_quota"] = entry.Quota
+	if entry.Similarity > 0 {
+		other["semantic_cache_similarity"] = entry.Similarity
+	}
+	model.RecordConsumeLog(c, info.UserId, model.RecordConsumeLogParams{
+		PromptTokens:     entry.PromptTokens,
+		CompletionTokens: entry.CompletionTokens,
+		ModelName:        info.OriginModelName,
+		TokenName:        c.GetString("token_name"),
+		Quota:            quota,
+		Content:          content,
+		TokenId:          info.TokenId,
+		UseTimeSeconds:   int(time.Since(info.StartTime).Seconds()),
+		IsStream:         info.IsStream,
+		Group:            info.UsingGroup,
+		Other:            other,
+	})
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	relaycommon "github.com/QuantumNous/new-api/relay/common"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func enableResponseCache(t *testing.T) *operation_setting.ResponseCacheSetting {
+	t.Helper()
+	setting := operation_setting.GetResponseCacheSetting()
+	original := *setting
+	t.Cleanup(func() { *setting = original })
+	setting.Enabled = true
+	setting.DeterministicOnly = true
+	setting.TTLSeconds = 60
+	setting.MaxEntryKB = 1
+	return setting
+}
+
+func newResponseCacheContext(body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	common.SetContextKey(c, constant.ContextKeyUsingGroup, "default")
+	return c, recorder
+}
+
+func newResponseCacheRelayInfo() *relaycommon.RelayInfo {
+	return &relaycommon.RelayInfo{
+		RelayMode:       relayconstant.RelayModeChatCompletions,
+		UsingGroup:      "default",
+		OriginModelName: "gpt-4o",
+	}
+}
+
+func newResponseCacheRequest() responseCacheRequest {
+	return responseCacheRequest{group: "default", modelName: "gpt-4o", relayMode: relayconstant.RelayModeChatCompletions}
+}
+
+// lookupResponseCache 模拟 middleware.ResponseCache 与 Relay：选择渠道前查询，未命中时开始记录响应
+func lookupResponseCache(c *gin.Context) *ResponseCacheEntry {
+	if entry := LookupResponseCache(c); entry != nil {
+		return entry
+	}
+	StartResponseCacheCapture(c)
+	return nil
+}
+
+func TestResponseCacheKey_Normalize(t *testing.T) {
+	enableResponseCache(t)
+	info := newResponseCacheRequest()
+
+	key1, ok := responseCacheKey(info, []byte(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	require.True(t, ok)
+
+	// 键顺序与空白不同，语义相同
+	key2, ok := responseCacheKey(info, []byte(`{ "messages": [{"content": "hi", "role": "user"}], "temperature": 0, "model": "gpt-4o" }`))
+	require.True(t, ok)
+	require.Equal(t, key1, key2)
+
+	key3, ok := responseCacheKey(info, []byte(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hello"}]}`))
+	require.True(t, ok)
+	require.NotEqual(t, key1, key3)
+
+	// 不同分组不共享缓存
+	other := newResponseCacheRequest()
+	other.group = "vip"
+	key4, ok := responseCacheKey(other, []byte(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	require.True(t, ok)
+	require.NotEqual(t, key1, key4)
+}
+
+func TestResponseCacheKey_Cacheable(t *testing.T) {
+	setting := enableResponseCache(t)
+	info := newResponseCacheRequest()
+
+	_, ok := responseCacheKey(info, []byte(`{"model":"gpt-4o","messages":[]}`))
+	require.False(t, ok, "temperature is required when deterministic only")
+
+	_, ok = responseCacheKey(info, []byte(`{"model":"gpt-4o","temperature":0.7,"messages":[]}`))
+	require.False(t, ok)
+
+	embedding := newResponseCacheRequest()
+	embedding.relayMode = relayconstant.RelayModeEmbeddings
+	_, ok = responseCacheKey(embedding, []byte(`{"model":"text-embedding-3-small","input":"hi"}`))
+	require.True(t, ok)
+
+	setting.DeterministicOnly = false
+	_, ok = responseCacheKey(info, []byte(`{"model":"gpt-4o","temperature":0.7,"messages":[]}`))
+	require.True(t, ok)
+
+	setting.Enabled = false
+	_, ok = responseCacheKey(info, []byte(`{"model":"gpt-4o","temperature":0,"messages":[]}`))
+	require.False(t, ok)
+}
+
+func TestResponseCacheCapture(t *testing.T) {
+	enableResponseCache(t)
+	info := newResponseCacheRelayInfo()
+	body := `{"id":"chatcmpl-1","choices":[{"message":{"content":"hello"}}]}`
+
+	// 未结算的请求不写入缓存
+	c, _ := newResponseCacheContext("")
+	startResponseCacheCapture(c, "test-unsettled", nil)
+	c.Data(http.StatusOK, "application/json", []byte(body))
+	FinishResponseCacheCapture(c, info, true)
+	_, hit := GetResponseCache("test-unsettled")
+	require.False(t, hit)
+
+	// 超出大小上限的响应不写入缓存
+	c, _ = newResponseCacheContext("")
+	startResponseCacheCapture(c, "test-overflow", nil)
+	c.Data(http.StatusOK, "application/json", []byte(strings.Repeat("a", 2048)))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+	_, hit = GetResponseCache("test-overflow")
+	require.False(t, hit)
+
+	c, recorder := newResponseCacheContext("")
+	original := c.Writer
+	startResponseCacheCapture(c, "test-hit", nil)
+	c.Data(http.StatusOK, "application/json", []byte(body))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+	require.Equal(t, original, c.Writer)
+	require.Equal(t, body, recorder.Body.String())
+
+	entry, hit := GetResponseCache("test-hit")
+	require.True(t, hit)
+	require.Equal(t, body, entry.Body)
+	require.Equal(t, "application/json", entry.ContentType)
+	require.Equal(t, 100, entry.Quota)
+	require.Equal(t, 10, entry.PromptTokens)
+	require.Equal(t, 20, entry.CompletionTokens)
+}
+
+func TestLookupResponseCache(t *testing.T) {
+	enableResponseCache(t)
+	info := newResponseCacheRelayInfo()
+	body := `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"lookup"}]}`
+
+	// 未命中时开始记录响应
+	c, _ := newResponseCacheContext(body)
+	require.Nil(t, lookupResponseCache(c))
+	_, capturing := c.Writer.(*responseCacheCapture)
+	require.True(t, capturing)
+	c.Data(http.StatusOK, "application/json", []byte(`{"id":"chatcmpl-2"}`))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+
+	c, _ = newResponseCacheContext(body)
+	entry := lookupResponseCache(c)
+	require.NotNil(t, entry)
+	require.Equal(t, `{"id":"chatcmpl-2"}`, entry.Body)
+	require.Zero(t, entry.Similarity)
+
+	// Cache-Control: no-cache 跳过缓存
+	c, _ = newResponseCacheContext(body)
+	c.Request.Header.Set("Cache-Control", "no-cache")
+	require.Nil(t, lookupResponseCache(c))
+	_, capturing = c.Writer.(*responseCacheCapture)
+	require.False(t, capturing)
+}
+
+func TestSettleResponseCacheHit(t *testing.T) {
+	truncate(t)
+	setting := enableResponseCache(t)
+	setting.BillingRatio = 0.5
+
+	const userID, tokenID = 1, 1
+	seedUser(t, userID, 10000)
+
+	c, _ := newResponseCacheContext(`{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"hit"}]}`)
+	c.Set("token_name", "cache")
+	// 与命中缓存时一样，Distribute 未选择渠道，ChannelMeta 为空
+	info := newResponseCacheRelayInfo()
+	info.UserId = userID
+	info.TokenId = tokenID
+	info.IsPlayground = true
+	info.ForcePreConsume = true
+	info.UserSetting = dto.UserSetting{BillingPreference: "wallet_only"}
+	info.StartTime = time.Now()
+	info.PriceData.ModelRatio = 1
+	require.Nil(t, PreConsumeBilling(c, 300, info))
+
+	SettleResponseCacheHit(c, info, &ResponseCacheEntry{Body: `{"id":"chatcmpl-3"}`, Quota: 400, PromptTokens: 10, CompletionTokens: 20})
+
+	require.Equal(t, 10000-200, getUserQuota(t, userID))
+	var log model.Log
+	require.NoError(t, model.LOG_DB.Where("user_id = ? AND type = ?", userID, model.LogTypeConsume).First(&log).Error)
+	require.Equal(t, 200, log.Quota)
+	require.Equal(t, "响应缓存命中", log.Content)
+	other, err := common.StrToMap(log.Other)
+	require.NoError(t, err)
+	require.Equal(t, true, other["response_cache_hit"])
+	require.EqualValues(t, 400, other["response_cache_origin_quota"])
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	relayconstant "github.com/QuantumNous/new-api/relay/constant"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 对话请求的语义缓存（operation_setting.SemanticCacheSetting）。
+// 以请求的用户与令牌身份向量化最后一条用户消息（与普通 embedding 请求一样计费），在内存向量索引中查找同一分区内最相似的条目，
+// 相似度达到模型阈值时直接返回缓存的响应。分区由分组、模型与其余请求内容（系统提示词、历史消息、参数等）决定，
+// 除 SharedGroups 中的分组外还按用户隔离，不同用户之间不共享缓存的回答。
+// 条目持久化在 semantic_cache_entries 表中（提示词只保存哈希），各实例定期增量加载新条目。
+// ---------------------------------------------------------------------------
+
+const (
+	semanticCacheSyncInterval  = time.Minute
+	semanticCacheLoadBatchSize = 1000
+)
+
+// SemanticCacheEmbeddingFunc 由 main 包注入（避免 service -> controller 的循环引用），
+// 以当前请求的用户与令牌身份经普通中继流程请求 embedding 并返回响应体
+var SemanticCacheEmbeddingFunc func(ctx context.Context, c *gin.Context, group string, request *dto.EmbeddingRequest) ([]byte, error)
+
+// semanticCacheEmbed 向量化文本，返回归一化后的向量
+var semanticCacheEmbed = requestSemanticCacheEmbedding
+
+var semanticIndex = newSemanticCacheIndex()
+
+// semanticCacheCandidate 未命中时记录的请求信息，请求成功后连同响应写入语义缓存
+type semanticCacheCandidate struct {
+	group        string
+	modelName    string
+	userId       int
+	partitionKey string
+	promptHash   string
+	vector       []float32
+}
+
+type semanticCacheItem struct {
+	id        int
+	vector    []float32
+	expiresAt int64
+}
+
+// semanticCacheIndex 内存中的向量索引，同一分区内按余弦相似度线性查找
+type semanticCacheIndex struct {
+	mu         sync.RWMutex
+	partitions map[string][]semanticCacheItem
+	ids        map[int]struct{}
+	lastId     int // 已从数据库加载的最大 id
+
+	syncMu   sync.Mutex
+	syncedAt time.Time
+}
+
+func newSemanticCacheIndex() *semanticCacheIndex {
+	return &semanticCacheIndex{
+		partitions: make(map[string][]semanticCacheItem),
+		ids:        make(map[int]struct{}),
+	}
+}
+
+// sync 增量加载其他实例写入的条目，并清理过期条目
+func (idx *semanticCacheIndex) sync() {
+	idx.syncMu.Lock()
+	defer idx.syncMu.Unlock()
+	if time.Since(idx.syncedAt) < semanticCacheSyncInterval {
+		return
+	}
+	idx.syncedAt = time.Now()
+
+	now := common.GetTimestamp()
+	if _, err := model.DeleteExpiredSemanticCacheEntries(now); err != nil {
+		common.SysError("delete expired semantic cache entries failed: " + err.Error())
+	}
+	idx.mu.Lock()
+	idx.filterLocked(func(item semanticCacheItem) bool { return item.expiresAt > now })
+	lastId := idx.lastId
+	idx.mu.Unlock()
+
+	for {
+		entries, err := model.GetSemanticCacheEntriesAfter(lastId, now, semanticCacheLoadBatchSize)
+		if err != nil {
+			common.SysError("load semantic cache entries failed: " + err.Error())
+			return
+		}
+		for _, entry := range entries {
+			lastId = entry.Id
+			vector, err := decodeSemanticCacheVector(entry.Embedding)
+			if err != nil {
+				continue
+			}
+			idx.add(entry.Id, entry.PartitionKey, vector, entry.ExpiresAt)
+		}
+		idx.mu.Lock()
+		idx.lastId = lastId
+		idx.mu.Unlock()
+		if len(entries) < semanticCacheLoadBatchSize {
+			return
+		}
+	}
+}
+
+// add 加入一个条目，返回因超出 MaxEntries 被淘汰的条目 id
+func (idx *semanticCacheIndex) add(id int, partitionKey string, vector []float32, expiresAt int64) []int {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.ids[id]; ok {
+		return nil
+	}
+	idx.partitions[partitionKey] = append(idx.partitions[partitionKey], semanticCacheItem{id: id, vector: vector, expiresAt: expiresAt})
+	idx.ids[id] = struct{}{}
+
+	maxEntries := operation_setting.GetSemanticCacheSetting().MaxEntries
+	if maxEntries <= 0 || len(idx.ids) <= maxEntries {
+		return nil
+	}
+	// 一次淘汰到上限的 90%，避免每次写入都重新排序
+	ids := make([]int, 0, len(idx.ids))
+	for itemId := range idx.ids {
+		ids = append(ids, itemId)
+	}
+	sort.Ints(ids)
+	evicted := ids[:len(ids)-maxEntries*9/10]
+	threshold := evicted[len(evicted)-1]
+	idx.filterLocked(func(item semanticCacheItem) bool { return item.id > threshold })
+	return evicted
+}
+
+func (idx *semanticCacheIndex) remove(id int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.ids[id]; ok {
+		idx.filterLocked(func(item semanticCacheItem) bool { return item.id != id })
+	}
+}
+
+// filterLocked 仅保留 keep 返回 true 的条目
+func (idx *semanticCacheIndex) filterLocked(keep func(item semanticCacheItem) bool) {
+	for partitionKey, items := range idx.partitions {
+		alive := items[:0]
+		for _, item := range items {
+			if keep(item) {
+				alive = append(alive, item)
+			} else {
+				delete(idx.ids, item.id)
+			}
+		}
+		if len(alive) == 0 {
+			delete(idx.partitions, partitionKey)
+		} else {
+			idx.partitions[partitionKey] = alive
+		}
+	}
+}
+
+// search 查找分区内相似度不低于 threshold 的最相似条目，未找到时返回 id 0
+func (idx *semanticCacheIndex) search(partitionKey string, vector []float32, threshold float64, now int64) (int, float64) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	bestId, bestSimilarity := 0, threshold
+	for _, item := range idx.partitions[partitionKey] {
+		if item.expiresAt <= now || len(item.vector) != len(vector) {
+			continue
+		}
+		var dot float64
+		for i := range vector {
+			dot += float64(vector[i]) * float64(item.vector[i])
+		}
+		if dot >= bestSimilarity {
+			bestId, bestSimilarity = item.id, dot
+		}
+	}
+	if bestId == 0 {
+		return 0, 0
+	}
+	return bestId, bestSimilarity
+}
+
+// lookupSemanticCache 查询语义缓存，未命中但可缓存时返回待写入的请求信息
+func lookupSemanticCache(c *gin.Context, request responseCacheRequest, body []byte) (*ResponseCacheEntry, *semanticCacheCandidate) {
+	setting := operation_setting.GetSemanticCacheSetting()
+	if !setting.Enabled || request.relayMode != relayconstant.RelayModeChatCompletions {
+		return nil, nil
+	}
+	if common.GetContextKeyBool(c, constant.ContextKeyTokenSemanticCacheDisabled) {
+		return nil, nil
+	}
+	prompt, rest, ok := splitSemanticCachePrompt(body)
+	if !ok {
+		return nil, nil
+	}
+	vector, err := semanticCacheEmbed(c, request.group, prompt)
+	if err != nil {
+		logger.LogWarn(c, "semantic cache embedding failed: "+err.Error())
+		return nil, nil
+	}
+	partition := request.group + "\n" + request.modelName + "\n" + rest
+	if !operation_setting.IsSemanticCacheSharedGroup(request.group) {
+		partition = fmt.Sprintf("user:%d\n%s", request.userId, partition)
+	}
+	sum := sha256.Sum256([]byte(partition))
+	promptSum := sha256.Sum256([]byte(prompt))
+	candidate := &semanticCacheCandidate{
+		group:        request.group,
+		modelName:    request.modelName,
+		userId:       request.userId,
+		partitionKey: hex.EncodeToString(sum[:]),
+		promptHash:   hex.EncodeToString(promptSum[:]),
+		vector:       vector,
+	}
+
+	semanticIndex.sync()
+	threshold := operation_setting.GetSemanticCacheThreshold(request.modelName)
+	id, similarity := semanticIndex.search(candidate.partitionKey, vector, threshold, common.GetTimestamp())
+	if id == 0 {
+		return nil, candidate
+	}
+	stored, err := model.GetSemanticCacheEntryById(id)
+	if err != nil {
+		// 条目已被其他实例淘汰
+		semanticIndex.remove(id)
+		return nil, candidate
+	}
+	return &ResponseCacheEntry{
+		ContentType:      stored.ContentType,
+		Body:             stored.Body,
+		IsStream:         stored.IsStream,
+		PromptTokens:     stored.PromptTokens,
+		CompletionTokens: stored.CompletionTokens,
+		Quota:            stored.Quota,
+		CreatedAt:        stored.CreatedAt,
+		Similarity:       similarity,
+	}, nil
+}
+
+// storeSemanticCache 将成功的响应写入语义缓存
+func storeSemanticCache(c *gin.Context, candidate *semanticCacheCandidate, entry ResponseCacheEntry) {
+	setting := operation_setting.GetSemanticCacheSetting()
+	if setting.TTLSeconds <= 0 || len(entry.Body) > setting.MaxEntryKB<<10 {
+		return
+	}
+	record := &model.SemanticCacheEntry{
+		PartitionKey:     candidate.partitionKey,
+		Group:            candidate.group,
+		ModelName:        candidate.modelName,
+		UserId:           candidate.userId,
+		PromptHash:       candidate.promptHash,
+		Embedding:        encodeSemanticCacheVector(candidate.vector),
+		ContentType:      entry.ContentType,
+		Body:             entry.Body,
+		IsStream:         entry.IsStream,
+		PromptTokens:     entry.PromptTokens,
+		CompletionTokens: entry.CompletionTokens,
+		Quota:            entry.Quota,
+		CreatedAt:        entry.CreatedAt,
+		ExpiresAt:        entry.CreatedAt + int64(setting.TTLSeconds),
+	}
+	if err := model.CreateSemanticCacheEntry(record); err != nil {
+		logger.LogError(c, "create semantic cache entry failed: "+err.Error())
+		return
+	}
+	evicted := semanticIndex.add(record.Id, record.PartitionKey, candidate.vector, record.ExpiresAt)
+	if err := model.DeleteSemanticCacheEntries(evicted); err != nil {
+		logger.LogError(c, "delete evicted semantic cache entries failed: "+err.Error())
+	}
+}
+
+// splitSemanticCachePrompt 取出最后一条用户消息的文本，返回去掉该文本后的请求体用于计算分区。
+// 最后一条用户消息包含图片、音频等非文本内容时不缓存。
+func splitSemanticCachePrompt(body []byte) (string, string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var request map[string]any
+	if err := decoder.Decode(&request); err != nil {
+		return "", "", false
+	}
+	messages, _ := request["messages"].([]any)
+	for i := len(messages) - 1; i >= 0; i-- {
+		message, ok := messages[i].(map[string]any)
+		if !ok || message["role"] != "user" {
+			continue
+		}
+		prompt, ok := semanticCacheMessageText(message["content"])
+		if !ok || strings.TrimSpace(prompt) == "" {
+			return "", "", false
+		}
+		message["content"] = ""
+		rest, err := common.Marshal(request)
+		if err != nil {
+			return "", "", false
+		}
+		return prompt, string(rest), true
+	}
+	return "", "", false
+}
+
+func semanticCacheMessageText(content any) (string, bool) {
+	switch content := content.(type) {
+	case string:
+		return content, true
+	case []any:
+		texts := make([]string, 0, len(content))
+		for _, part := range content {
+			part, ok := part.(map[string]any)
+			if !ok || part["type"] != "text" {
+				return "", false
+			}
+			text, _ := part["text"].(string)
+			texts = append(texts, text)
+		}
+		return strings.Join(texts, "\n"), true
+	default:
+		return "", false
+	}
+}
+
+type semanticCacheEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// requestSemanticCacheEmbedding 以当前请求的用户与令牌身份请求 embedding，
+// 与普通 /v1/embeddings 请求一样经由渠道适配器转发，并按 embedding 模型计费、记录消费日志
+func requestSemanticCacheEmbedding(c *gin.Context, group string, text string) ([]float32, error) {
+	setting := operation_setting.GetSemanticCacheSetting()
+	if SemanticCacheEmbeddingFunc == nil {
+		return nil, errors.New("semantic cache embedding is not available")
+	}
+	timeout := time.Duration(setting.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+
+	if setting.EmbeddingGroup != "" {
+		group = setting.EmbeddingGroup
+	}
+	respBody, err := SemanticCacheEmbeddingFunc(ctx, c, group, &dto.EmbeddingRequest{Model: setting.EmbeddingModel, Input: text})
+	if err != nil {
+		return nil, err
+	}
+	var embedding semanticCacheEmbeddingResponse
+	if err := common.Unmarshal(respBody, &embedding); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+	if len(embedding.Data) == 0 || len(embedding.Data[0].Embedding) == 0 {
+		return nil, errors.New("invalid embedding response: empty embedding")
+	}
+	return normalizeSemanticCacheVector(embedding.Data[0].Embedding)
+}
+
+// normalizeSemanticCacheVector 归一化向量，使余弦相似度等于点积
+func normalizeSemanticCacheVector(vector []float32) ([]float32, error) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil, errors.New("invalid embedding: zero vector")
+	}
+	norm = math.Sqrt(norm)
+	normalized := make([]float32, len(vector))
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized, nil
+}
+
+func encodeSemanticCacheVector(vector []float32) string {
+	data := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func decodeSemanticCacheVector(encoded string) ([]float32, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 || len(data)%4 != 0 {
+		return nil, errors.New("invalid semantic cache vector")
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, nil
+}
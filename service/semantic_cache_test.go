@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func enableSemanticCache(t *testing.T, vectors map[string][]float32) *operation_setting.SemanticCacheSetting {
+	t.Helper()
+	setting := operation_setting.GetSemanticCacheSetting()
+	original := *setting
+	originalEmbed := semanticCacheEmbed
+	originalIndex := semanticIndex
+	t.Cleanup(func() {
+		*setting = original
+		semanticCacheEmbed = originalEmbed
+		semanticIndex = originalIndex
+		model.DB.Exec("DELETE FROM semantic_cache_entries")
+	})
+	setting.Enabled = true
+	setting.DefaultThreshold = 0.9
+	setting.ModelThresholds = map[string]float64{}
+	setting.TTLSeconds = 60
+	setting.MaxEntries = 100
+	setting.MaxEntryKB = 1
+	semanticIndex = newSemanticCacheIndex()
+	semanticCacheEmbed = func(c *gin.Context, group string, text string) ([]float32, error) {
+		vector, ok := vectors[text]
+		if !ok {
+			return nil, errors.New("unknown text")
+		}
+		return normalizeSemanticCacheVector(vector)
+	}
+	return setting
+}
+
+func semanticCacheRequest(prompt string) string {
+	return `{"model":"gpt-4o","messages":[{"role":"system","content":"You are a FAQ bot."},{"role":"user","content":"` + prompt + `"}]}`
+}
+
+func TestSplitSemanticCachePrompt(t *testing.T) {
+	prompt, rest, ok := splitSemanticCachePrompt([]byte(semanticCacheRequest("how do I reset my password")))
+	require.True(t, ok)
+	require.Equal(t, "how do I reset my password", prompt)
+
+	// 只有最后一条用户消息不同的请求属于同一分区
+	_, other, ok := splitSemanticCachePrompt([]byte(semanticCacheRequest("password reset")))
+	require.True(t, ok)
+	require.Equal(t, rest, other)
+
+	prompt, _, ok = splitSemanticCachePrompt([]byte(`{"messages":[{"role":"user","content":[{"type":"text","text":"hello"},{"type":"text","text":"world"}]},{"role":"assistant","content":"hi"}]}`))
+	require.True(t, ok)
+	require.Equal(t, "hello\nworld", prompt)
+
+	_, _, ok = splitSemanticCachePrompt([]byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`))
+	require.False(t, ok)
+
+	_, _, ok = splitSemanticCachePrompt([]byte(`{"messages":[{"role":"system","content":"hi"}]}`))
+	require.False(t, ok)
+}
+
+func TestSemanticCacheVectorEncoding(t *testing.T) {
+	vector, err := normalizeSemanticCacheVector([]float32{3, 4})
+	require.NoError(t, err)
+	require.InDelta(t, 0.6, vector[0], 1e-6)
+	require.InDelta(t, 0.8, vector[1], 1e-6)
+
+	decoded, err := decodeSemanticCacheVector(encodeSemanticCacheVector(vector))
+	require.NoError(t, err)
+	require.Equal(t, vector, decoded)
+
+	_, err = normalizeSemanticCacheVector([]float32{0, 0})
+	require.Error(t, err)
+}
+
+func TestLookupSemanticCache(t *testing.T) {
+	setting := enableSemanticCache(t, map[string][]float32{
+		"how do I reset my password": {1, 0, 0},
+		"password reset":             {0.98, 0.2, 0},
+		"what is the refund policy":  {0, 1, 0},
+	})
+	info := newResponseCacheRelayInfo()
+	answer := `{"id":"chatcmpl-3","choices":[{"message":{"content":"Use the reset link."}}]}`
+
+	c, _ := newResponseCacheContext(semanticCacheRequest("how do I reset my password"))
+	require.Nil(t, lookupResponseCache(c))
+	c.Data(http.StatusOK, "application/json", []byte(answer))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+
+	var count int64
+	require.NoError(t, model.DB.Model(&model.SemanticCacheEntry{}).Count(&count).Error)
+	require.EqualValues(t, 1, count)
+
+	// 相似的问题命中缓存
+	c, _ = newResponseCacheContext(semanticCacheRequest("password reset"))
+	entry := lookupResponseCache(c)
+	require.NotNil(t, entry)
+	require.Equal(t, answer, entry.Body)
+	require.Equal(t, 100, entry.Quota)
+	require.Greater(t, entry.Similarity, 0.9)
+
+	// 不相似的问题未命中
+	c, _ = newResponseCacheContext(semanticCacheRequest("what is the refund policy"))
+	require.Nil(t, lookupResponseCache(c))
+
+	// 模型阈值高于相似度时未命中
+	setting.ModelThresholds = map[string]float64{"gpt-4o": 0.999}
+	c, _ = newResponseCacheContext(semanticCacheRequest("password reset"))
+	require.Nil(t, lookupResponseCache(c))
+	setting.ModelThresholds = map[string]float64{}
+
+	// 令牌关闭语义缓存
+	c, _ = newResponseCacheContext(semanticCacheRequest("password reset"))
+	common.SetContextKey(c, constant.ContextKeyTokenSemanticCacheDisabled, true)
+	require.Nil(t, lookupResponseCache(c))
+	_, capturing := c.Writer.(*responseCacheCapture)
+	require.False(t, capturing)
+
+	// 令牌不允许使用该模型时不查询缓存，也不请求 embedding
+	embedded := false
+	embed := semanticCacheEmbed
+	semanticCacheEmbed = func(c *gin.Context, group string, text string) ([]float32, error) {
+		embedded = true
+		return embed(c, group, text)
+	}
+	c, _ = newResponseCacheContext(semanticCacheRequest("password reset"))
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimitEnabled, true)
+	common.SetContextKey(c, constant.ContextKeyTokenModelLimit, map[string]bool{"gpt-4o-mini": true})
+	require.Nil(t, lookupResponseCache(c))
+	require.False(t, embedded)
+	semanticCacheEmbed = embed
+
+	// 重启后从数据库重新加载索引
+	semanticIndex = newSemanticCacheIndex()
+	c, _ = newResponseCacheContext(semanticCacheRequest("password reset"))
+	require.NotNil(t, lookupResponseCache(c))
+}
+
+func TestSemanticCacheIndexEvict(t *testing.T) {
+	setting := enableSemanticCache(t, nil)
+	setting.MaxEntries = 10
+
+	index := newSemanticCacheIndex()
+	var evicted []int
+	for id := 1; id <= 11; id++ {
+		evicted = append(evicted, index.add(id, "p", []float32{1}, common.GetTimestamp()+60)...)
+	}
+	// 超出上限时淘汰到上限的 90%
+	require.Equal(t, []int{1, 2}, evicted)
+	require.Len(t, index.ids, 9)
+	id, _ := index.search("p", []float32{1}, 0.5, common.GetTimestamp())
+	require.NotZero(t, id)
+}
+
+func TestLookupSemanticCache_UserIsolation(t *testing.T) {
+	setting := enableSemanticCache(t, map[string][]float32{
+		"how do I reset my password": {1, 0, 0},
+		"password reset":             {0.98, 0.2, 0},
+	})
+	info := newResponseCacheRelayInfo()
+	answer := `{"id":"chatcmpl-4","choices":[{"message":{"content":"Your account id is 42."}}]}`
+
+	newUserContext := func(userId int, prompt string) *gin.Context {
+		c, _ := newResponseCacheContext(semanticCacheRequest(prompt))
+		common.SetContextKey(c, constant.ContextKeyUserId, userId)
+		return c
+	}
+
+	c := newUserContext(1, "how do I reset my password")
+	require.Nil(t, lookupResponseCache(c))
+	c.Data(http.StatusOK, "application/json", []byte(answer))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+
+	// 提示词原文不落库
+	var stored model.SemanticCacheEntry
+	require.NoError(t, model.DB.First(&stored).Error)
+	require.Equal(t, 1, stored.UserId)
+	require.Len(t, stored.PromptHash, 64)
+	require.NotContains(t, stored.PromptHash, "password")
+
+	// 其他用户不命中
+	require.Nil(t, lookupResponseCache(newUserContext(2, "password reset")))
+	require.NotNil(t, lookupResponseCache(newUserContext(1, "password reset")))
+
+	// 开启跨用户共享的分组按分组共享缓存
+	setting.SharedGroups = "vip, default"
+	c = newUserContext(1, "how do I reset my password")
+	require.Nil(t, lookupResponseCache(c))
+	c.Data(http.StatusOK, "application/json", []byte(answer))
+	recordResponseCacheUsage(c, 100, 10, 20)
+	FinishResponseCacheCapture(c, info, true)
+	require.NotNil(t, lookupResponseCache(newUserContext(2, "password reset")))
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/setting"
+	"github.com/QuantumNous/new-api/types"
+
+	goahocorasick "github.com/anknown/ahocorasick"
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 模型输出的敏感词过滤（setting.ShouldCheckCompletionSensitive）。
+// 只检查响应 JSON 中的文本字段；流式输出保留最近的文本，用于发现被拆分到多个分片中的敏感词。
+// ---------------------------------------------------------------------------
+
+// sensitiveTextFields 需要检查的文本字段，覆盖 OpenAI / Claude / Gemini / Responses 的正文、推理与增量文本
+var sensitiveTextFields = map[string]struct{}{
+	"content":           {},
+	"text":              {},
+	"reasoning_content": {},
+	"reasoning":         {},
+	"thinking":          {},
+	"refusal":           {},
+	"delta":             {},
+}
+
+const sensitiveWordMask = "**###**"
+
+// sensitiveSegment 响应中的一个文本字段，masked 标记需要替换的字符
+type sensitiveSegment struct {
+	runes  []rune
+	lower  []rune
+	masked []bool
+	set    func(string)
+}
+
+func newSensitiveSegment(text string, set func(string)) *sensitiveSegment {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return &sensitiveSegment{runes: runes, lower: lower, masked: make([]bool, len(runes)), set: set}
+}
+
+// maskedText 将连续的命中字符替换为一个 sensitiveWordMask，未命中时返回 ok=false
+func (s *sensitiveSegment) maskedText() (string, bool) {
+	var builder strings.Builder
+	changed := false
+	for i, r := range s.runes {
+		if !s.masked[i] {
+			builder.WriteRune(r)
+			continue
+		}
+		changed = true
+		if i == 0 || !s.masked[i-1] {
+			builder.WriteString(sensitiveWordMask)
+		}
+	}
+	return builder.String(), changed
+}
+
+// SensitiveOutputChunk 一个已检查的响应分片（流式的一个 data 或非流式的完整响应体）
+type SensitiveOutputChunk struct {
+	raw      string
+	root     any
+	segments []*sensitiveSegment
+}
+
+// String 返回替换敏感词后的内容，没有需要替换的文本时原样返回。
+// 流式分片在缓存期间仍可能被后续分片中的命中标记，因此需要在发送前调用。
+func (c *SensitiveOutputChunk) String() string {
+	changed := false
+	for _, segment := range c.segments {
+		if text, ok := segment.maskedText(); ok {
+			segment.set(text)
+			changed = true
+		}
+	}
+	if !changed {
+		return c.raw
+	}
+	data, err := common.Marshal(c.root)
+	if err != nil {
+		return c.raw
+	}
+	return string(data)
+}
+
+// TextLen 返回分片中参与检查的文本字符数
+func (c *SensitiveOutputChunk) TextLen() int {
+	n := 0
+	for _, segment := range c.segments {
+		n += len(segment.runes)
+	}
+	return n
+}
+
+// SensitiveOutputFilter 对一次请求的模型输出做敏感词检测，跨分片保留不少于最长敏感词长度的文本窗口
+type SensitiveOutputFilter struct {
+	machine *goahocorasick.Machine
+	maxLen  int
+	window  []*sensitiveSegment
+}
+
+// NewSensitiveOutputFilter 未开启输出检查或敏感词为空时返回 nil
+func NewSensitiveOutputFilter() *SensitiveOutputFilter {
+	if !setting.ShouldCheckCompletionSensitive() || len(setting.SensitiveWords) == 0 {
+		return nil
+	}
+	machine := getOrBuildAC(setting.SensitiveWords)
+	if machine == nil {
+		return nil
+	}
+	maxLen := 0
+	for _, word := range setting.SensitiveWords {
+		maxLen = max(maxLen, len([]rune(strings.TrimSpace(word))))
+	}
+	return &SensitiveOutputFilter{machine: machine, maxLen: maxLen}
+}
+
+// HoldbackLen 返回流式发送前至少需要在后续分片中积累的字符数（最长敏感词长度 - 1），
+// 此前的分片仍可能被跨分片的命中标记
+func (f *SensitiveOutputFilter) HoldbackLen() int {
+	return max(f.maxLen-1, 0)
+}
+
+// Check 解析并检查一个响应分片，返回分片与命中的敏感词。非 JSON 内容不检查，原样返回。
+func (f *SensitiveOutputFilter) Check(data string) (*SensitiveOutputChunk, []string) {
+	chunk := &SensitiveOutputChunk{raw: data}
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&chunk.root); err != nil {
+		return chunk, nil
+	}
+	collectSensitiveSegments(chunk.root, &chunk.segments)
+
+	var words []string
+	for _, segment := range chunk.segments {
+		words = append(words, f.search(segment)...)
+	}
+	return chunk, words
+}
+
+// search 在窗口文本 + segment 中查找结束于 segment 内的命中，并标记需要替换的字符
+func (f *SensitiveOutputFilter) search(segment *sensitiveSegment) []string {
+	offset := 0
+	for _, s := range f.window {
+		offset += len(s.lower)
+	}
+	combined := make([]rune, 0, offset+len(segment.lower))
+	for _, s := range f.window {
+		combined = append(combined, s.lower...)
+	}
+	combined = append(combined, segment.lower...)
+	segments := append(f.window, segment)
+
+	var words []string
+	if len(segment.lower) > 0 {
+		for _, hit := range f.machine.MultiPatternSearch(combined, false) {
+			start, end := hit.Pos, hit.Pos+len(hit.Word)
+			if end <= offset {
+				// 完全位于窗口内的命中已在之前的分片中处理
+				continue
+			}
+			words = append(words, string(hit.Word))
+			markSensitiveRange(segments, start, end)
+		}
+	}
+
+	// 窗口只需保留最长敏感词长度 - 1 个字符
+	f.window = segments
+	total := offset + len(segment.lower)
+	for len(f.window) > 0 && total-len(f.window[0].lower) >= f.maxLen-1 {
+		total -= len(f.window[0].lower)
+		f.window = f.window[1:]
+	}
+	return words
+}
+
+func markSensitiveRange(segments []*sensitiveSegment, start int, end int) {
+	offset := 0
+	for _, s := range segments {
+		for i := range s.masked {
+			if pos := offset + i; pos >= start && pos < end {
+				s.masked[i] = true
+			}
+		}
+		offset += len(s.masked)
+	}
+}
+
+// collectSensitiveSegments 按固定顺序（键名排序）收集 sensitiveTextFields 中的字符串字段
+func collectSensitiveSegments(node any, segments *[]*sensitiveSegment) {
+	switch v := node.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if text, ok := v[key].(string); ok {
+				if _, isText := sensitiveTextFields[key]; isText && text != "" {
+					*segments = append(*segments, newSensitiveSegment(text, func(masked string) { v[key] = masked }))
+				}
+				continue
+			}
+			collectSensitiveSegments(v[key], segments)
+		}
+	case []any:
+		for _, item := range v {
+			collectSensitiveSegments(item, segments)
+		}
+	}
+}
+
+// LogSensitiveOutput 记录输出中命中的敏感词，日志带有请求 ID
+func LogSensitiveOutput(c *gin.Context, words []string) {
+	logger.LogWarn(c, fmt.Sprintf("sensitive words detected in completion: %s", strings.Join(words, ", ")))
+}
+
+// NewSensitiveOutputError 输出中检测到敏感词并中断时返回给客户端的错误
+func NewSensitiveOutputError(c *gin.Context) *types.NewAPIError {
+	apiErr := types.NewErrorWithStatusCode(errors.New("sensitive words detected in completion"), types.ErrorCodeSensitiveWordsDetected, http.StatusBadRequest, types.ErrOptionWithSkipRetry())
+	apiErr.SetMessage(common.MessageWithRequestId(apiErr.Error(), c.GetString(common.RequestIdKey)))
+	return apiErr
+}
+
+// FilterSensitiveOutput 检查非流式响应体并返回替换敏感词后的内容；开启 StopOnSensitiveEnabled 且命中时返回错误
+func FilterSensitiveOutput(c *gin.Context, data []byte) ([]byte, *types.NewAPIError) {
+	filter := NewSensitiveOutputFilter()
+	if filter == nil {
+		return data, nil
+	}
+	chunk, words := filter.Check(string(data))
+	if len(words) == 0 {
+		return data, nil
+	}
+	LogSensitiveOutput(c, words)
+	if setting.StopOnSensitiveEnabled {
+		return nil, NewSensitiveOutputError(c)
+	}
+	return []byte(chunk.String()), nil
+}
+
+// writeRelayFormatError 非流式响应按入站格式输出错误，与 controller.Relay 的错误格式一致
+func writeRelayFormatError(c *gin.Context, apiErr *types.NewAPIError) {
+	switch InboundRelayFormat(c) {
+	case types.RelayFormatClaude:
+		c.JSON(apiErr.StatusCode, gin.H{
+			"type":  "error",
+			"error": apiErr.ToClaudeError(),
+		})
+	case types.RelayFormatGemini:
+		c.JSON(apiErr.StatusCode, gin.H{
+			"error": apiErr.ToGeminiError(),
+		})
+	default:
+		c.JSON(apiErr.StatusCode, gin.H{
+			"error": apiErr.ToOpenAIError(),
+		})
+	}
+}
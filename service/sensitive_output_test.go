@@ -0,0 +1,77 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/setting"
+
+	"github.com/stretchr/testify/require"
+)
+
+func useCompletionSensitiveWords(t *testing.T, stop bool, words ...string) {
+	t.Helper()
+	enabled, onCompletion, stopOnSensitive, sensitiveWords := setting.CheckSensitiveEnabled, setting.CheckSensitiveOnCompletionEnabled, setting.StopOnSensitiveEnabled, setting.SensitiveWords
+	setting.CheckSensitiveEnabled = true
+	setting.CheckSensitiveOnCompletionEnabled = true
+	setting.StopOnSensitiveEnabled = stop
+	setting.SensitiveWords = words
+	t.Cleanup(func() {
+		setting.CheckSensitiveEnabled = enabled
+		setting.CheckSensitiveOnCompletionEnabled = onCompletion
+		setting.StopOnSensitiveEnabled = stopOnSensitive
+		setting.SensitiveWords = sensitiveWords
+	})
+}
+
+func TestSensitiveOutputFilter_MatchAcrossChunks(t *testing.T) {
+	useCompletionSensitiveWords(t, false, "Secret Plan")
+	filter := NewSensitiveOutputFilter()
+	require.NotNil(t, filter)
+
+	first, words := filter.Check(`{"id":123456789012345678,"choices":[{"delta":{"content":"the sec"}}]}`)
+	require.Empty(t, words)
+	second, words := filter.Check(`{"id":123456789012345678,"choices":[{"delta":{"content":"RET plan is"}}]}`)
+	require.Equal(t, []string{"secret plan"}, words)
+
+	// 缓存中的前一个分片在发送前同样被替换，数字保持原样
+	require.Equal(t, `{"choices":[{"delta":{"content":"the **###**"}}],"id":123456789012345678}`, first.String())
+	require.Equal(t, `{"choices":[{"delta":{"content":"**###** is"}}],"id":123456789012345678}`, second.String())
+
+	third, words := filter.Check(`{"choices":[{"delta":{"content":" safe"}}]}`)
+	require.Empty(t, words)
+	require.Equal(t, `{"choices":[{"delta":{"content":" safe"}}]}`, third.String())
+}
+
+func TestFilterSensitiveOutput(t *testing.T) {
+	body := []byte(`{"type":"message","content":[{"type":"text","text":"a forbidden word"}]}`)
+
+	useCompletionSensitiveWords(t, false, "forbidden")
+	c, _ := newModelTokenRateLimitContext()
+	filtered, apiErr := FilterSensitiveOutput(c, body)
+	require.Nil(t, apiErr)
+	require.JSONEq(t, `{"type":"message","content":[{"type":"text","text":"a **###** word"}]}`, string(filtered))
+
+	setting.StopOnSensitiveEnabled = true
+	_, apiErr = FilterSensitiveOutput(c, body)
+	require.NotNil(t, apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+
+	setting.CheckSensitiveOnCompletionEnabled = false
+	filtered, apiErr = FilterSensitiveOutput(c, body)
+	require.Nil(t, apiErr)
+	require.Equal(t, body, filtered)
+}
+
+func TestIOCopyBytesGracefully_SensitiveBlockedSkipsBilling(t *testing.T) {
+	body := []byte(`{"choices":[{"message":{"content":"a forbidden word"}}]}`)
+	useCompletionSensitiveWords(t, true, "forbidden")
+	c, w := newModelTokenRateLimitContext()
+
+	IOCopyBytesGracefully(c, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, body)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.NotContains(t, w.Body.String(), "forbidden word")
+	require.True(t, common.GetContextKeyBool(c, constant.ContextKeySensitiveOutputBlocked))
+}
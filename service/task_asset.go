@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	assetstorage "github.com/QuantumNous/new-api/pkg/asset_storage"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	assetCleanupTickInterval = 10 * time.Minute
+	assetCleanupBatchSize    = 200
+)
+
+var (
+	assetCleanupOnce sync.Once
+
+	errAssetQuotaExceeded = errors.New("user asset storage quota exceeded")
+)
+
+// GetAssetStorage 按存储方式创建存储后端，S3 配置在每次调用时读取，修改设置后立即生效
+func GetAssetStorage(mode string) (assetstorage.Storage, error) {
+	switch mode {
+	case operation_setting.AssetStorageModeLocal:
+		return assetstorage.NewLocal(common.GetAssetStorageDir()), nil
+	case operation_setting.AssetStorageModeS3:
+		setting := operation_setting.GetAssetSetting()
+		return assetstorage.NewS3(assetstorage.S3Config{
+			Endpoint:        setting.S3Endpoint,
+			Region:          setting.S3Region,
+			Bucket:          setting.S3Bucket,
+			AccessKeyId:     setting.S3AccessKeyId,
+			SecretAccessKey: setting.S3AccessSecret,
+			PathStyle:       setting.S3PathStyle,
+		}, nil)
+	}
+	return nil, fmt.Errorf("unsupported asset storage mode: %s", mode)
+}
+
+// shouldPersistTaskAsset 仅转存上游直链；data: URI 与代理地址需要渠道密钥，由 VideoProxy 处理
+func shouldPersistTaskAsset(task *model.Task) bool {
+	if !operation_setting.GetAssetSetting().Enabled {
+		return false
+	}
+	if task.Status != model.TaskStatusSuccess || task.PrivateData.AssetId != "" {
+		return false
+	}
+	resultURL := task.PrivateData.ResultURL
+	return strings.HasPrefix(resultURL, "http://") || strings.HasPrefix(resultURL, "https://")
+}
+
+// completeTaskWithAsset 任务成功时先转存结果再推送回调，使回调中的 result_url 指向 new-api
+func completeTaskWithAsset(ctx context.Context, task *model.Task) {
+	if !shouldPersistTaskAsset(task) {
+		NotifyTaskCompletion(ctx, task)
+		return
+	}
+	gopool.Go(func() {
+		if err := PersistTaskAsset(ctx, task); err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to persist asset of task %s: %s", task.TaskID, err.Error()))
+		}
+		NotifyTaskCompletion(ctx, task)
+	})
+}
+
+// PersistTaskAsset 下载任务结果并写入存储后端，成功后将任务结果地址改写为签名下载地址。
+// 超过单文件大小或用户存储配额时保留上游地址。
+func PersistTaskAsset(ctx context.Context, task *model.Task) error {
+	setting := operation_setting.GetAssetSetting()
+	maxBytesPerUser := setting.GetMaxStorageBytesPerUser()
+	if maxBytesPerUser > 0 {
+		used, err := model.GetUserTaskAssetBytes(task.UserId)
+		if err != nil {
+			return err
+		}
+		if used >= maxBytesPerUser {
+			return errAssetQuotaExceeded
+		}
+	}
+
+	sourceURL := task.PrivateData.ResultURL
+	resp, err := DoDownloadRequest(sourceURL, "persist task asset")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download task asset failed: status %d", resp.StatusCode)
+	}
+	maxFileSize := setting.GetMaxFileSizeBytes()
+	if resp.ContentLength > maxFileSize {
+		return common.ErrStoredFileTooLarge
+	}
+
+	// 先落盘得到准确大小，S3 上传需要 Content-Length
+	tmpPath, tmpFile, err := common.CreateDiskCacheFile(common.DiskCacheTypeFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+	}()
+	size, err := io.Copy(tmpFile, io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return err
+	}
+	if size > maxFileSize {
+		return common.ErrStoredFileTooLarge
+	}
+	if maxBytesPerUser > 0 {
+		used, err := model.GetUserTaskAssetBytes(task.UserId)
+		if err != nil {
+			return err
+		}
+		if used+size > maxBytesPerUser {
+			return errAssetQuotaExceeded
+		}
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
+	if contentType == "" || contentType == "application/octet-stream" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(tmpFile, head)
+		contentType = http.DetectContentType(head[:n])
+		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	assetKey, _ := common.GenerateRandomCharsKey(32)
+	asset := &model.TaskAsset{
+		AssetId:     "asset_" + assetKey,
+		TaskId:      task.TaskID,
+		UserId:      task.UserId,
+		StorageMode: setting.StorageMode,
+		ContentType: contentType,
+		Bytes:       size,
+		SourceUrl:   sourceURL,
+		CreatedAt:   now.Unix(),
+	}
+	asset.StorageKey = now.UTC().Format("2006/01/02") + "/" + asset.AssetId + assetExtension(sourceURL)
+	if setting.RetentionDays > 0 {
+		asset.ExpiresAt = now.Add(time.Duration(setting.RetentionDays) * 24 * time.Hour).Unix()
+	}
+
+	storage, err := GetAssetStorage(asset.StorageMode)
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(ctx, asset.StorageKey, tmpFile, size, contentType); err != nil {
+		return err
+	}
+	if err := asset.Insert(); err != nil {
+		_ = storage.Delete(ctx, asset.StorageKey)
+		return err
+	}
+	task.PrivateData.AssetId = asset.AssetId
+	task.PrivateData.ResultURL = model.BuildTaskAssetURL(asset.AssetId)
+	return task.UpdateTaskPrivateData()
+}
+
+// assetExtension 从上游地址中取扩展名，便于在存储桶中识别文件类型
+func assetExtension(sourceURL string) string {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if len(ext) < 2 || len(ext) > 6 {
+		return ""
+	}
+	for _, r := range ext[1:] {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// OpenTaskAsset 从写入时使用的存储后端读取转存结果
+func OpenTaskAsset(ctx context.Context, asset *model.TaskAsset) (io.ReadCloser, error) {
+	storage, err := GetAssetStorage(asset.StorageMode)
+	if err != nil {
+		return nil, err
+	}
+	return storage.Open(ctx, asset.StorageKey)
+}
+
+// DeleteTaskAsset 删除转存结果，并将任务结果地址恢复为上游地址
+func DeleteTaskAsset(ctx context.Context, asset *model.TaskAsset) error {
+	storage, err := GetAssetStorage(asset.StorageMode)
+	if err != nil {
+		return err
+	}
+	if err := storage.Delete(ctx, asset.StorageKey); err != nil {
+		return err
+	}
+	if err := asset.Delete(); err != nil {
+		return err
+	}
+	task, exist, err := model.GetByTaskId(asset.UserId, asset.TaskId)
+	if err != nil || !exist || task.PrivateData.AssetId != asset.AssetId {
+		return err
+	}
+	task.PrivateData.AssetId = ""
+	task.PrivateData.ResultURL = asset.SourceUrl
+	return task.UpdateTaskPrivateData()
+}
+
+// StartAssetCleanupTask 定期清理超过保留天数的任务结果
+func StartAssetCleanupTask() {
+	assetCleanupOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("asset cleanup task started: tick=%s", assetCleanupTickInterval))
+			ticker := time.NewTicker(assetCleanupTickInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runAssetCleanupOnce()
+			}
+		})
+	})
+}
+
+func runAssetCleanupOnce() {
+	ctx := context.Background()
+	assets, err := model.GetExpiredTaskAssets(common.GetTimestamp(), assetCleanupBatchSize)
+	if err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("asset cleanup task failed: %v", err))
+		return
+	}
+	for _, asset := range assets {
+		if err := DeleteTaskAsset(ctx, asset); err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("failed to delete expired asset %s: %v", asset.AssetId, err))
+		}
+	}
+	if len(assets) > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("asset cleanup task: deleted %d expired assets", len(assets)))
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/system_setting"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testAssetBody = []byte("\x00\x00\x00\x18ftypmp42 fake video bytes")
+
+// useTaskAssetStorage 使用临时目录作为本地存储，并提供一个模拟上游结果地址的服务
+func useTaskAssetStorage(t *testing.T, body []byte) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	if GetHttpClient() == nil {
+		InitHttpClient()
+	}
+	fetchSetting := system_setting.GetFetchSetting()
+	originalFetch := *fetchSetting
+	t.Cleanup(func() { *fetchSetting = originalFetch })
+	fetchSetting.EnableSSRFProtection = false
+
+	originalStoragePath := common.FileStoragePath
+	t.Cleanup(func() { common.FileStoragePath = originalStoragePath })
+	common.FileStoragePath = t.TempDir()
+
+	setting := operation_setting.GetAssetSetting()
+	originalSetting := *setting
+	t.Cleanup(func() { *setting = originalSetting })
+	setting.Enabled = true
+	setting.StorageMode = operation_setting.AssetStorageModeLocal
+	setting.RetentionDays = 7
+
+	t.Cleanup(func() { model.DB.Exec("DELETE FROM task_assets") })
+	return server.URL
+}
+
+func insertSucceededTask(t *testing.T, resultURL string) *model.Task {
+	t.Helper()
+	task := makeTask(1, 1, 0, 0, BillingSourceWallet, 0)
+	task.Status = model.TaskStatusSuccess
+	task.PrivateData.ResultURL = resultURL
+	require.NoError(t, model.DB.Create(task).Error)
+	return task
+}
+
+func TestPersistTaskAsset_RewritesResultURL(t *testing.T) {
+	truncate(t)
+	sourceURL := useTaskAssetStorage(t, testAssetBody) + "/output/video.mp4?token=abc"
+	task := insertSucceededTask(t, sourceURL)
+
+	require.NoError(t, PersistTaskAsset(context.Background(), task))
+	require.NotEmpty(t, task.PrivateData.AssetId)
+
+	reloaded, exist, err := model.GetByTaskId(1, task.TaskID)
+	require.NoError(t, err)
+	require.True(t, exist)
+	require.Equal(t, task.PrivateData.AssetId, reloaded.PrivateData.AssetId)
+
+	resultURL, err := url.Parse(reloaded.GetResultURL())
+	require.NoError(t, err)
+	require.Equal(t, "/v1/assets/"+task.PrivateData.AssetId, resultURL.Path)
+	query := resultURL.Query()
+	require.True(t, model.VerifyTaskAssetSignature(path.Base(resultURL.Path), query.Get("expires"), query.Get("signature")))
+	require.False(t, model.VerifyTaskAssetSignature("asset_other", query.Get("expires"), query.Get("signature")))
+	require.False(t, model.VerifyTaskAssetSignature(path.Base(resultURL.Path), "1", query.Get("signature")))
+
+	asset, err := model.GetTaskAssetByAssetId(task.PrivateData.AssetId)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(testAssetBody)), asset.Bytes)
+	require.Equal(t, "video/mp4", asset.ContentType)
+	require.True(t, strings.HasSuffix(asset.StorageKey, ".mp4"))
+	require.Greater(t, asset.ExpiresAt, asset.CreatedAt)
+
+	reader, err := OpenTaskAsset(context.Background(), asset)
+	require.NoError(t, err)
+	stored, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, testAssetBody, stored)
+}
+
+func TestPersistTaskAsset_UserQuotaExceeded(t *testing.T) {
+	truncate(t)
+	sourceURL := useTaskAssetStorage(t, testAssetBody) + "/video.mp4"
+	operation_setting.GetAssetSetting().MaxStorageMBPerUser = 1
+	require.NoError(t, (&model.TaskAsset{AssetId: "asset_existing", UserId: 1, Bytes: 1 << 20}).Insert())
+	task := insertSucceededTask(t, sourceURL)
+
+	require.ErrorIs(t, PersistTaskAsset(context.Background(), task), errAssetQuotaExceeded)
+	require.Empty(t, task.PrivateData.AssetId)
+	require.Equal(t, sourceURL, task.GetResultURL())
+}
+
+func TestPersistTaskAsset_FileTooLarge(t *testing.T) {
+	truncate(t)
+	sourceURL := useTaskAssetStorage(t, make([]byte, 1<<20+1)) + "/video.mp4"
+	task := insertSucceededTask(t, sourceURL)
+	operation_setting.GetAssetSetting().MaxFileSizeMB = 1
+
+	require.ErrorIs(t, PersistTaskAsset(context.Background(), task), common.ErrStoredFileTooLarge)
+	used, err := model.GetUserTaskAssetBytes(1)
+	require.NoError(t, err)
+	require.Zero(t, used)
+}
+
+func TestRunAssetCleanupOnce_RestoresSourceURL(t *testing.T) {
+	truncate(t)
+	sourceURL := useTaskAssetStorage(t, testAssetBody) + "/video.mp4"
+	task := insertSucceededTask(t, sourceURL)
+	require.NoError(t, PersistTaskAsset(context.Background(), task))
+	asset, err := model.GetTaskAssetByAssetId(task.PrivateData.AssetId)
+	require.NoError(t, err)
+	require.NoError(t, model.DB.Model(asset).Update("expires_at", 1).Error)
+
+	runAssetCleanupOnce()
+
+	_, err = model.GetTaskAssetByAssetId(asset.AssetId)
+	require.Error(t, err)
+	_, err = OpenTaskAsset(context.Background(), asset)
+	require.Error(t, err)
+	reloaded, _, err := model.GetByTaskId(1, task.TaskID)
+	require.NoError(t, err)
+	require.Empty(t, reloaded.PrivateData.AssetId)
+	require.Equal(t, sourceURL, reloaded.GetResultURL())
+}
+
+func TestShouldPersistTaskAsset(t *testing.T) {
+	setting := operation_setting.GetAssetSetting()
+	original := *setting
+	t.Cleanup(func() { *setting = original })
+	setting.Enabled = true
+
+	task := &model.Task{Status: model.TaskStatusSuccess}
+	task.PrivateData.ResultURL = "https://cdn.example.com/a.mp4"
+	require.True(t, shouldPersistTaskAsset(task))
+
+	task.PrivateData.ResultURL = "data:video/mp4;base64,AAAA"
+	require.False(t, shouldPersistTaskAsset(task))
+
+	task.PrivateData.ResultURL = "https://cdn.example.com/a.mp4"
+	task.Status = model.TaskStatusFailure
+	require.False(t, shouldPersistTaskAsset(task))
+
+	task.Status = model.TaskStatusSuccess
+	setting.Enabled = false
+	require.False(t, shouldPersistTaskAsset(task))
+}
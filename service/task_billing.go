@@ -79,40 +79,158 @@ func resolveTokenKey(ctx context.Context, tokenId int, taskID string) string {
 	return token.Key
 }
 
-// taskIsSubscription 判断任务是否通过订阅计费。
-func taskIsSubscription(task *model.Task) bool {
-	return task.PrivateData.BillingSource == BillingSourceSubscription && task.PrivateData.SubscriptionId > 0
+// asyncBillingAccount 异步计费对象（任务、批处理）的资金来源快照，用于轮询阶段的退款与差额结算。
+type asyncBillingAccount struct {
+	UserId         int
+	TokenId        int
+	BillingSource  string
+	SubscriptionId int
+	OrganizationId int
+	CreatedAt      int64  // 提交时间，用于定位预扣时的周期预算窗口
+	Kind           string // "任务" 或 "批处理"，仅用于日志
+	RefId          string // 任务或批处理的公开 ID，仅用于日志
+	ChannelId      int
+	ModelName      string
+	Group          string
 }
 
-// taskAdjustFunding 调整任务的资金来源（钱包或订阅），delta > 0 表示扣费，delta < 0 表示退还。
-func taskAdjustFunding(task *model.Task, delta int) error {
-	if taskIsSubscription(task) {
-		return model.PostConsumeUserSubscriptionDelta(task.PrivateData.SubscriptionId, int64(delta))
+func (a asyncBillingAccount) isSubscription() bool {
+	return a.BillingSource == BillingSourceSubscription && a.SubscriptionId > 0
+}
+
+func (a asyncBillingAccount) isOrganization() bool {
+	return a.BillingSource == BillingSourceOrganization && a.OrganizationId > 0
+}
+
+// adjustFunding 调整资金来源（钱包、订阅或组织钱包），delta > 0 表示扣费，delta < 0 表示退还。
+func (a asyncBillingAccount) adjustFunding(delta int) error {
+	if a.isSubscription() {
+		return model.PostConsumeUserSubscriptionDelta(a.SubscriptionId, int64(delta))
+	}
+	if a.isOrganization() {
+		return model.AdjustOrganizationQuota(a.OrganizationId, a.UserId, delta)
 	}
 	if delta > 0 {
-		return model.DecreaseUserQuota(task.UserId, delta, false)
+		return model.DecreaseUserQuota(a.UserId, delta, false)
 	}
-	return model.IncreaseUserQuota(task.UserId, -delta, false)
+	return model.IncreaseUserQuota(a.UserId, -delta, false)
 }
 
-// taskAdjustTokenQuota 调整任务的令牌额度，delta > 0 表示扣费，delta < 0 表示退还。
+// adjustTokenQuota 调整令牌额度，delta > 0 表示扣费，delta < 0 表示退还。
 // 需要通过 resolveTokenKey 运行时获取 key（不从 PrivateData 中读取）。
-func taskAdjustTokenQuota(ctx context.Context, task *model.Task, delta int) {
-	if task.PrivateData.TokenId <= 0 || delta == 0 {
+func (a asyncBillingAccount) adjustTokenQuota(ctx context.Context, delta int) {
+	if a.TokenId <= 0 || delta == 0 {
 		return
 	}
-	tokenKey := resolveTokenKey(ctx, task.PrivateData.TokenId, task.TaskID)
+	tokenKey := resolveTokenKey(ctx, a.TokenId, a.RefId)
 	if tokenKey == "" {
 		return
 	}
 	var err error
 	if delta > 0 {
-		err = model.DecreaseTokenQuota(task.PrivateData.TokenId, tokenKey, delta)
+		err = model.DecreaseTokenQuota(a.TokenId, tokenKey, delta)
 	} else {
-		err = model.IncreaseTokenQuota(task.PrivateData.TokenId, tokenKey, -delta)
+		err = model.IncreaseTokenQuota(a.TokenId, tokenKey, -delta)
 	}
 	if err != nil {
-		logger.LogWarn(ctx, fmt.Sprintf("调整令牌额度失败 (delta=%d, task=%s): %s", delta, task.TaskID, err.Error()))
+		logger.LogWarn(ctx, fmt.Sprintf("调整令牌额度失败 (delta=%d, task=%s): %s", delta, a.RefId, err.Error()))
+	}
+}
+
+// adjustBudgets 调整周期预算已用额度，delta > 0 表示扣费，delta < 0 表示退还。
+func (a asyncBillingAccount) adjustBudgets(ctx context.Context, delta int) {
+	adjustAsyncBudgets(ctx, a.UserId, a.TokenId, a.CreatedAt, delta)
+}
+
+// adjust 调整资金来源、令牌额度与周期预算，资金来源调整失败时不调整其余部分并返回错误。
+func (a asyncBillingAccount) adjust(ctx context.Context, delta int) error {
+	if err := a.adjustFunding(delta); err != nil {
+		return err
+	}
+	a.adjustTokenQuota(ctx, delta)
+	a.adjustBudgets(ctx, delta)
+	return nil
+}
+
+// recordLog 记录退款或差额结算日志
+func (a asyncBillingAccount) recordLog(logType int, content string, quota int, other map[string]interface{}) {
+	model.RecordTaskBillingLog(model.RecordTaskBillingLogParams{
+		UserId:         a.UserId,
+		LogType:        logType,
+		Content:        content,
+		ChannelId:      a.ChannelId,
+		ModelName:      a.ModelName,
+		Quota:          quota,
+		TokenId:        a.TokenId,
+		Group:          a.Group,
+		Other:          other,
+		OrganizationId: a.OrganizationId,
+	})
+}
+
+// refund 退还全部预扣额度并记录退款日志，退还失败时返回 false。
+func (a asyncBillingAccount) refund(ctx context.Context, quota int, reason string, other map[string]interface{}) bool {
+	if err := a.adjust(ctx, -quota); err != nil {
+		logger.LogWarn(ctx, fmt.Sprintf("退还资金来源失败 %s %s: %s", a.Kind, a.RefId, err.Error()))
+		return false
+	}
+	other["reason"] = reason
+	a.recordLog(model.LogTypeRefund, "", quota, other)
+	return true
+}
+
+// recalculate 按实际额度与预扣额度的差额补扣或退还并记录日志，未发生调整时返回 false。
+func (a asyncBillingAccount) recalculate(ctx context.Context, preConsumedQuota int, actualQuota int, reason string, other map[string]interface{}) bool {
+	quotaDelta := actualQuota - preConsumedQuota
+	if quotaDelta == 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("%s %s 预扣费准确（%s，%s）", a.Kind, a.RefId, logger.LogQuota(actualQuota), reason))
+		return false
+	}
+
+	logger.LogInfo(ctx, fmt.Sprintf("%s %s 差额结算：delta=%s（实际：%s，预扣：%s，%s）",
+		a.Kind,
+		a.RefId,
+		logger.LogQuota(quotaDelta),
+		logger.LogQuota(actualQuota),
+		logger.LogQuota(preConsumedQuota),
+		reason,
+	))
+
+	if err := a.adjust(ctx, quotaDelta); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("差额结算资金调整失败 %s %s: %s", a.Kind, a.RefId, err.Error()))
+		return false
+	}
+
+	var logType int
+	var logQuota int
+	if quotaDelta > 0 {
+		logType = model.LogTypeConsume
+		logQuota = quotaDelta
+		model.UpdateUserUsedQuotaAndRequestCount(a.UserId, quotaDelta)
+		model.UpdateChannelUsedQuota(a.ChannelId, quotaDelta)
+	} else {
+		logType = model.LogTypeRefund
+		logQuota = -quotaDelta
+	}
+	other["pre_consumed_quota"] = preConsumedQuota
+	other["actual_quota"] = actualQuota
+	a.recordLog(logType, reason, logQuota, other)
+	return true
+}
+
+func taskBillingAccount(task *model.Task) asyncBillingAccount {
+	return asyncBillingAccount{
+		UserId:         task.UserId,
+		TokenId:        task.PrivateData.TokenId,
+		BillingSource:  task.PrivateData.BillingSource,
+		SubscriptionId: task.PrivateData.SubscriptionId,
+		OrganizationId: task.PrivateData.OrganizationId,
+		CreatedAt:      task.CreatedAt,
+		Kind:           "任务",
+		RefId:          task.TaskID,
+		ChannelId:      task.ChannelId,
+		ModelName:      taskModelName(task),
+		Group:          task.Group,
 	}
 }
 
@@ -154,31 +272,9 @@ func RefundTaskQuota(ctx context.Context, task *model.Task, reason string) {
 	if quota == 0 {
 		return
 	}
-
-	// 1. 退还资金来源（钱包或订阅）
-	if err := taskAdjustFunding(task, -quota); err != nil {
-		logger.LogWarn(ctx, fmt.Sprintf("退还资金来源失败 task %s: %s", task.TaskID, err.Error()))
-		return
-	}
-
-	// 2. 退还令牌额度
-	taskAdjustTokenQuota(ctx, task, -quota)
-
-	// 3. 记录日志
 	other := taskBillingOther(task)
 	other["task_id"] = task.TaskID
-	other["reason"] = reason
-	model.RecordTaskBillingLog(model.RecordTaskBillingLogParams{
-		UserId:    task.UserId,
-		LogType:   model.LogTypeRefund,
-		Content:   "",
-		ChannelId: task.ChannelId,
-		ModelName: taskModelName(task),
-		Quota:     quota,
-		TokenId:   task.PrivateData.TokenId,
-		Group:     task.Group,
-		Other:     other,
-	})
+	taskBillingAccount(task).refund(ctx, quota, reason, other)
 }
 
 // RecalculateTaskQuota 通用的异步差额结算。
@@ -188,60 +284,11 @@ func RecalculateTaskQuota(ctx context.Context, task *model.Task, actualQuota int
 	if actualQuota <= 0 {
 		return
 	}
-	preConsumedQuota := task.Quota
-	quotaDelta := actualQuota - preConsumedQuota
-
-	if quotaDelta == 0 {
-		logger.LogInfo(ctx, fmt.Sprintf("任务 %s 预扣费准确（%s，%s）",
-			task.TaskID, logger.LogQuota(actualQuota), reason))
-		return
-	}
-
-	logger.LogInfo(ctx, fmt.Sprintf("任务 %s 差额结算：delta=%s（实际：%s，预扣：%s，%s）",
-		task.TaskID,
-		logger.LogQuota(quotaDelta),
-		logger.LogQuota(actualQuota),
-		logger.LogQuota(preConsumedQuota),
-		reason,
-	))
-
-	// 调整资金来源
-	if err := taskAdjustFunding(task, quotaDelta); err != nil {
-		logger.LogError(ctx, fmt.Sprintf("差额结算资金调整失败 task %s: %s", task.TaskID, err.Error()))
-		return
-	}
-
-	// 调整令牌额度
-	taskAdjustTokenQuota(ctx, task, quotaDelta)
-
-	task.Quota = actualQuota
-
-	var logType int
-	var logQuota int
-	if quotaDelta > 0 {
-		logType = model.LogTypeConsume
-		logQuota = quotaDelta
-		model.UpdateUserUsedQuotaAndRequestCount(task.UserId, quotaDelta)
-		model.UpdateChannelUsedQuota(task.ChannelId, quotaDelta)
-	} else {
-		logType = model.LogTypeRefund
-		logQuota = -quotaDelta
-	}
 	other := taskBillingOther(task)
 	other["task_id"] = task.TaskID
-	other["pre_consumed_quota"] = preConsumedQuota
-	other["actual_quota"] = actualQuota
-	model.RecordTaskBillingLog(model.RecordTaskBillingLogParams{
-		UserId:    task.UserId,
-		LogType:   logType,
-		Content:   reason,
-		ChannelId: task.ChannelId,
-		ModelName: taskModelName(task),
-		Quota:     logQuota,
-		TokenId:   task.PrivateData.TokenId,
-		Group:     task.Group,
-		Other:     other,
-	})
+	if taskBillingAccount(task).recalculate(ctx, task.Quota, actualQuota, reason, other) {
+		task.Quota = actualQuota
+	}
 }
 
 // RecalculateTaskQuotaByTokens 根据实际 token 消耗重新计费（异步差额结算）。
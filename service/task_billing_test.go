@@ -44,6 +44,20 @@ func TestMain(m *testing.M) {
 		&model.Channel{},
 		&model.TopUp{},
 		&model.UserSubscription{},
+		&model.Batch{},
+		&model.File{},
+		&model.SemanticCacheEntry{},
+		&model.Organization{},
+		&model.OrganizationMember{},
+		&model.OrganizationInvitation{},
+		&model.Budget{},
+		&model.BudgetUsage{},
+		&model.TaskWebhookDelivery{},
+		&model.TaskAsset{},
+		&model.Invoice{},
+		&model.SubscriptionOrder{},
+		&model.SubscriptionPlan{},
+		&model.UsageReportDelivery{},
 	); err != nil {
 		panic("failed to migrate: " + err.Error())
 	}
@@ -65,6 +79,8 @@ func truncate(t *testing.T) {
 		model.DB.Exec("DELETE FROM channels")
 		model.DB.Exec("DELETE FROM top_ups")
 		model.DB.Exec("DELETE FROM user_subscriptions")
+		model.DB.Exec("DELETE FROM batches")
+		model.DB.Exec("DELETE FROM files")
 	})
 }
 
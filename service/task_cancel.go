@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/relay/channel/task/taskcommon"
+)
+
+const (
+	taskCancelledReason = "任务已被用户取消"
+	// taskCancelMaxAttempts CAS 因轮询推进了未完成任务的状态而失败时的最大尝试次数
+	taskCancelMaxAttempts = 3
+)
+
+// CancelTaskAndRefund 将上游已接受取消的任务标记为失败并退还预扣额度，
+// 任务进入终态后轮询不再处理该任务。
+// 使用 CAS 更新状态，失败时重新读取任务：仍未完成则按最新状态重试，
+// 已被轮询推进到终态时返回 false，此时不退款，task 被替换为数据库中的实际状态。
+func CancelTaskAndRefund(ctx context.Context, task *model.Task) (bool, error) {
+	for attempt := 0; attempt < taskCancelMaxAttempts; attempt++ {
+		oldStatus := task.Status
+		task.Status = model.TaskStatusFailure
+		task.Progress = taskcommon.ProgressComplete
+		task.FinishTime = time.Now().Unix()
+		task.FailReason = taskCancelledReason
+		won, err := task.UpdateWithStatus(oldStatus)
+		if err != nil {
+			return false, err
+		}
+		if won {
+			RefundTaskQuota(ctx, task, taskCancelledReason)
+			NotifyTaskCompletion(ctx, task)
+			return true, nil
+		}
+
+		fresh, exist, err := model.GetByOnlyTaskId(task.TaskID)
+		if err != nil {
+			return false, err
+		}
+		if !exist {
+			return false, errors.New("task not found")
+		}
+		*task = *fresh
+		if task.Status == model.TaskStatusSuccess || task.Status == model.TaskStatusFailure {
+			return false, nil
+		}
+	}
+	return false, errors.New("task status keeps changing, please retry")
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelTaskAndRefund(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 1, 1, 1
+	const initQuota, preConsumed, tokenRemain = 10000, 3000, 5000
+	seedUser(t, userID, initQuota)
+	seedToken(t, tokenID, userID, "sk-test-key", tokenRemain)
+	seedChannel(t, channelID)
+
+	task := makeTask(userID, channelID, preConsumed, tokenID, BillingSourceWallet, 0)
+	require.NoError(t, model.DB.Create(task).Error)
+
+	won, err := CancelTaskAndRefund(ctx, task)
+	require.NoError(t, err)
+	require.True(t, won)
+
+	assert.Equal(t, initQuota+preConsumed, getUserQuota(t, userID))
+	assert.Equal(t, tokenRemain+preConsumed, getTokenRemainQuota(t, tokenID))
+
+	var stored model.Task
+	require.NoError(t, model.DB.First(&stored, task.ID).Error)
+	assert.Equal(t, model.TaskStatus(model.TaskStatusFailure), stored.Status)
+	assert.Equal(t, "100%", stored.Progress)
+	assert.Equal(t, taskCancelledReason, stored.FailReason)
+}
+
+func TestCancelTaskAndRefund_AlreadyFinished(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 1, 1, 1
+	const initQuota, preConsumed = 10000, 3000
+	seedUser(t, userID, initQuota)
+	seedToken(t, tokenID, userID, "sk-test-key", 5000)
+	seedChannel(t, channelID)
+
+	task := makeTask(userID, channelID, preConsumed, tokenID, BillingSourceWallet, 0)
+	require.NoError(t, model.DB.Create(task).Error)
+	// 轮询先一步将任务推进到成功
+	require.NoError(t, model.DB.Model(&model.Task{}).Where("id = ?", task.ID).Update("status", model.TaskStatusSuccess).Error)
+
+	won, err := CancelTaskAndRefund(ctx, task)
+	require.NoError(t, err)
+	assert.False(t, won)
+	assert.Equal(t, initQuota, getUserQuota(t, userID))
+	// task 被替换为数据库中的实际状态
+	assert.Equal(t, model.TaskStatus(model.TaskStatusSuccess), task.Status)
+}
+
+func TestCancelTaskAndRefund_RetriesWhenStillRunning(t *testing.T) {
+	truncate(t)
+	ctx := context.Background()
+
+	const userID, tokenID, channelID = 1, 1, 1
+	const initQuota, preConsumed = 10000, 3000
+	seedUser(t, userID, initQuota)
+	seedToken(t, tokenID, userID, "sk-test-key", 5000)
+	seedChannel(t, channelID)
+
+	task := makeTask(userID, channelID, preConsumed, tokenID, BillingSourceWallet, 0)
+	task.Status = model.TaskStatusQueued
+	require.NoError(t, model.DB.Create(task).Error)
+	// 轮询只把任务从排队推进到执行中，取消仍应生效
+	require.NoError(t, model.DB.Model(&model.Task{}).Where("id = ?", task.ID).Update("status", model.TaskStatusInProgress).Error)
+
+	won, err := CancelTaskAndRefund(ctx, task)
+	require.NoError(t, err)
+	assert.True(t, won)
+	assert.Equal(t, initQuota+preConsumed, getUserQuota(t, userID))
+
+	var stored model.Task
+	require.NoError(t, model.DB.First(&stored, task.ID).Error)
+	assert.Equal(t, model.TaskStatus(model.TaskStatusFailure), stored.Status)
+	assert.Equal(t, taskCancelledReason, stored.FailReason)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	TaskWebhookEventSucceeded = "task.succeeded"
+	TaskWebhookEventFailed    = "task.failed"
+)
+
+// taskWebhookRetryDelays 每次投递前的等待时间，长度即最大尝试次数。
+// 重试在进程内进行，服务重启时尚未完成的重试会丢失，可通过投递记录排查。
+var taskWebhookRetryDelays = []time.Duration{0, 10 * time.Second, time.Minute, 5 * time.Minute}
+
+// TaskWebhookPayload 异步任务完成回调的负载，签名方式与用户 webhook 通知一致（X-Webhook-Signature）
+type TaskWebhookPayload struct {
+	Event      string `json:"event"`
+	TaskId     string `json:"task_id"`
+	Platform   string `json:"platform"`
+	Action     string `json:"action"`
+	Model      string `json:"model,omitempty"`
+	Status     string `json:"status"`
+	Progress   string `json:"progress"`
+	FailReason string `json:"fail_reason,omitempty"`
+	ResultUrl  string `json:"result_url,omitempty"`
+	Data       any    `json:"data,omitempty"`
+	SubmitTime int64  `json:"submit_time"`
+	FinishTime int64  `json:"finish_time"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// CheckTaskCallbackURL 校验任务回调地址格式，空字符串表示不回调。
+// 投递时还会按 SSRF 防护配置再次校验目标地址。
+func CheckTaskCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	if len(callbackURL) > 1024 {
+		return errors.New("callback_url is too long")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid callback_url: %s", callbackURL)
+	}
+	return nil
+}
+
+func buildTaskWebhookPayload(task *model.Task) TaskWebhookPayload {
+	event := TaskWebhookEventSucceeded
+	if task.Status == model.TaskStatusFailure {
+		event = TaskWebhookEventFailed
+	}
+	payload := TaskWebhookPayload{
+		Event:      event,
+		TaskId:     task.TaskID,
+		Platform:   string(task.Platform),
+		Action:     task.Action,
+		Model:      task.Properties.OriginModelName,
+		Status:     string(task.Status),
+		Progress:   task.Progress,
+		FailReason: task.FailReason,
+		ResultUrl:  task.GetResultURL(),
+		SubmitTime: task.SubmitTime,
+		FinishTime: task.FinishTime,
+		Timestamp:  time.Now().Unix(),
+	}
+	if len(task.Data) > 0 {
+		payload.Data = task.Data
+	}
+	return payload
+}
+
+// NotifyTaskCompletion 在任务进入 SUCCESS / FAILURE 后异步推送回调，未配置回调地址时不做任何事。
+// 调用方需保证每个任务只在状态切换成功时调用一次。
+func NotifyTaskCompletion(ctx context.Context, task *model.Task) {
+	if task == nil || task.PrivateData.CallbackURL == "" {
+		return
+	}
+	if task.Status != model.TaskStatusSuccess && task.Status != model.TaskStatusFailure {
+		return
+	}
+	payloadBytes, err := common.Marshal(buildTaskWebhookPayload(task))
+	if err != nil {
+		logger.LogError(ctx, fmt.Sprintf("failed to marshal task webhook payload for task %s: %s", task.TaskID, err.Error()))
+		return
+	}
+	taskId := task.TaskID
+	userId := task.UserId
+	status := string(task.Status)
+	callbackURL := task.PrivateData.CallbackURL
+	gopool.Go(func() {
+		deliverTaskWebhook(ctx, taskId, userId, status, callbackURL, payloadBytes)
+	})
+}
+
+func deliverTaskWebhook(ctx context.Context, taskId string, userId int, status string, callbackURL string, payloadBytes []byte) {
+	secret := ""
+	if userSetting, err := model.GetUserSetting(userId, false); err == nil {
+		secret = userSetting.WebhookSecret
+	}
+	for i, delay := range taskWebhookRetryDelays {
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		statusCode, err := postSignedWebhook(callbackURL, secret, payloadBytes)
+		delivery := &model.TaskWebhookDelivery{
+			TaskId:     taskId,
+			UserId:     userId,
+			Url:        callbackURL,
+			Status:     status,
+			Attempt:    i + 1,
+			StatusCode: statusCode,
+			Success:    err == nil,
+			CreatedAt:  time.Now().Unix(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+		if insertErr := delivery.Insert(); insertErr != nil {
+			logger.LogError(ctx, fmt.Sprintf("failed to record task webhook delivery for task %s: %s", taskId, insertErr.Error()))
+		}
+		if err == nil {
+			return
+		}
+		logger.LogWarn(ctx, fmt.Sprintf("task %s webhook attempt %d failed: %s", taskId, i+1, err.Error()))
+		if !isRetryableWebhookError(statusCode, err) {
+			return
+		}
+	}
+}
+
+// isRetryableWebhookError 网络错误、429 与 5xx 可重试；地址被拒绝或其他 4xx 重试也不会成功
+func isRetryableWebhookError(statusCode int, err error) bool {
+	if errors.Is(err, errWebhookURLRejected) {
+		return false
+	}
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/system_setting"
+
+	"github.com/stretchr/testify/require"
+)
+
+func useTaskWebhookServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	if GetHttpClient() == nil {
+		InitHttpClient()
+	}
+	fetchSetting := system_setting.GetFetchSetting()
+	original := *fetchSetting
+	t.Cleanup(func() { *fetchSetting = original })
+	fetchSetting.EnableSSRFProtection = false
+
+	originalDelays := taskWebhookRetryDelays
+	t.Cleanup(func() { taskWebhookRetryDelays = originalDelays })
+	taskWebhookRetryDelays = []time.Duration{0, time.Millisecond, time.Millisecond}
+
+	t.Cleanup(func() { model.DB.Exec("DELETE FROM task_webhook_deliveries") })
+	return server.URL
+}
+
+func TestDeliverTaskWebhook_SignedPayload(t *testing.T) {
+	truncate(t)
+	var signature, body string
+	url := useTaskWebhookServer(t, func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Webhook-Signature")
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+	})
+	user := &model.User{Id: 1, Username: "webhook_user", Status: common.UserStatusEnabled}
+	user.SetSetting(dto.UserSetting{WebhookSecret: "secret"})
+	require.NoError(t, model.DB.Create(user).Error)
+
+	task := makeTask(1, 1, 0, 0, BillingSourceWallet, 0)
+	task.Status = model.TaskStatusSuccess
+	task.PrivateData.ResultURL = "https://cdn.example.com/video.mp4"
+	payloadBytes, err := common.Marshal(buildTaskWebhookPayload(task))
+	require.NoError(t, err)
+
+	deliverTaskWebhook(context.Background(), task.TaskID, task.UserId, string(task.Status), url, payloadBytes)
+
+	require.Equal(t, string(payloadBytes), body)
+	require.Equal(t, generateSignature("secret", payloadBytes), signature)
+	var payload TaskWebhookPayload
+	require.NoError(t, common.Unmarshal([]byte(body), &payload))
+	require.Equal(t, TaskWebhookEventSucceeded, payload.Event)
+	require.Equal(t, "https://cdn.example.com/video.mp4", payload.ResultUrl)
+
+	deliveries, err := model.GetTaskWebhookDeliveries(1, task.TaskID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.True(t, deliveries[0].Success)
+	require.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+}
+
+func TestDeliverTaskWebhook_Retries(t *testing.T) {
+	truncate(t)
+	var calls atomic.Int32
+	url := useTaskWebhookServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	deliverTaskWebhook(context.Background(), "task_retry", 2, string(model.TaskStatusFailure), url, []byte(`{}`))
+
+	deliveries, err := model.GetTaskWebhookDeliveries(2, "task_retry")
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+	require.Equal(t, http.StatusBadGateway, deliveries[0].StatusCode)
+	require.False(t, deliveries[1].Success)
+	require.Equal(t, 3, deliveries[2].Attempt)
+	require.True(t, deliveries[2].Success)
+}
+
+func TestDeliverTaskWebhook_ClientErrorNotRetried(t *testing.T) {
+	truncate(t)
+	var calls atomic.Int32
+	url := useTaskWebhookServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	deliverTaskWebhook(context.Background(), "task_404", 3, string(model.TaskStatusSuccess), url, []byte(`{}`))
+
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestCheckTaskCallbackURL(t *testing.T) {
+	require.NoError(t, CheckTaskCallbackURL(""))
+	require.NoError(t, CheckTaskCallbackURL("https://example.com/hook"))
+	require.Error(t, CheckTaskCallbackURL("ftp://example.com/hook"))
+	require.Error(t, CheckTaskCallbackURL("example.com/hook"))
+}
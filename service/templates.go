@@ -0,0 +1,32 @@
+package service
+
+import (
+	"embed"
+	"html/template"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// htmlTemplates 发票、月度账单与用量报告邮件共用的 HTML 模板
+var htmlTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"formatTime": formatInvoiceTime,
+	"formatMoney": func(money float64) string {
+		return strconv.FormatFloat(money, 'f', 2, 64)
+	},
+	"formatQuota": func(quota int64) string {
+		return logger.FormatQuota(int(quota))
+	},
+}).ParseFS(templateFS, "templates/*.html"))
+
+func formatInvoiceTime(unix int64) string {
+	if unix <= 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).In(operation_setting.GetInvoiceLocation()).Format("2006-01-02 15:04")
+}
@@ -362,6 +362,11 @@ func PostTextConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, us
 		extraContent = append(extraContent, fmt.Sprintf("Image Generation Call 花费 %s", decimal.NewFromFloat(summary.ImageGenerationCallPrice).Mul(decimal.NewFromFloat(summary.GroupRatio)).Mul(decimal.NewFromFloat(common.QuotaPerUnit)).String()))
 	}
 
+	if common.GetContextKeyBool(ctx, constant.ContextKeySensitiveOutputBlocked) {
+		summary.Quota = 0
+		extraContent = append(extraContent, "输出命中敏感词已拦截，不计费")
+	}
+
 	if summary.TotalTokens == 0 {
 		extraContent = append(extraContent, "上游没有返回计费信息，无法扣费（可能是上游超时）")
 		logger.LogError(ctx, fmt.Sprintf("total tokens is 0, cannot consume quota, userId %d, channelId %d, tokenId %d, model %s， pre-consumed quota %d", relayInfo.UserId, relayInfo.ChannelId, relayInfo.TokenId, summary.ModelName, relayInfo.FinalPreConsumedQuota))
@@ -373,6 +378,12 @@ func PostTextConsumeQuota(ctx *gin.Context, relayInfo *relaycommon.RelayInfo, us
 	if err := SettleBilling(ctx, relayInfo, summary.Quota); err != nil {
 		logger.LogError(ctx, "error settling billing: "+err.Error())
 	}
+	ConsumeTokenTpm(ctx, relayInfo.TokenId, summary.PromptTokens+summary.CompletionTokens)
+	SettleModelTokenRateLimit(ctx, summary.PromptTokens+summary.CompletionTokens)
+	RecordChannelKeyTokens(relayInfo, summary.PromptTokens+summary.CompletionTokens)
+	if summary.TotalTokens > 0 {
+		recordResponseCacheUsage(ctx, summary.Quota, summary.PromptTokens, summary.CompletionTokens)
+	}
 
 	logModel := summary.ModelName
 	if strings.HasPrefix(logModel, "gpt-4-gizmo") {
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/common/limiter"
+	"github.com/QuantumNous/new-api/constant"
+	"github.com/QuantumNous/new-api/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------------------------------------------------------------
+// 令牌级限流：RPM / TPM / 并发数，限制值保存在 model.Token 上（0 表示不限制）。
+// ---------------------------------------------------------------------------
+
+const (
+	tokenRateLimitWindow = 60
+	// tokenConcurrencyTTL 并发计数的过期时间，防止进程异常退出后名额无法释放
+	tokenConcurrencyTTL = 30 * time.Minute
+)
+
+func tokenRpmKey(tokenId int) string {
+	return fmt.Sprintf("tokenRateLimit:rpm:%d", tokenId)
+}
+
+func tokenTpmKey(tokenId int) string {
+	return fmt.Sprintf("tokenRateLimit:tpm:%d", tokenId)
+}
+
+func tokenConcurrencyKey(tokenId int) string {
+	return fmt.Sprintf("tokenRateLimit:concurrency:%d", tokenId)
+}
+
+// AllowTokenRequest 检查并记录一次请求是否超出令牌的 RPM 限制
+func AllowTokenRequest(ctx context.Context, tokenId int, rpmLimit int) (bool, error) {
+	if rpmLimit <= 0 {
+		return true, nil
+	}
+	return allowBucket(ctx, tokenRpmKey(tokenId), bucketOptions(rpmLimit, tokenRateLimitWindow, 1)...)
+}
+
+// IsTokenTpmAvailable 检查令牌的 TPM 余量是否为正。
+// TPM 按实际用量事后扣减（见 ConsumeTokenTpm），因此只要余量为正即放行，超出部分由后续请求等待补齐。
+func IsTokenTpmAvailable(ctx context.Context, tokenId int, tpmLimit int) (bool, error) {
+	if tpmLimit <= 0 {
+		return true, nil
+	}
+	remaining, err := consumeTokenTpm(ctx, tokenId, tpmLimit, 0)
+	if err != nil {
+		return false, err
+	}
+	return remaining > 0, nil
+}
+
+func consumeTokenTpm(ctx context.Context, tokenId int, tpmLimit int, tokens int) (int64, error) {
+	return consumeBucket(ctx, tokenTpmKey(tokenId), bucketOptions(tpmLimit, tokenRateLimitWindow, int64(tokens))...)
+}
+
+// ConsumeTokenTpm 请求结算时按实际使用的 token 数扣减当前令牌的 TPM 余量
+func ConsumeTokenTpm(c *gin.Context, tokenId int, tokens int) {
+	tpmLimit := common.GetContextKeyInt(c, constant.ContextKeyTokenTpmLimit)
+	if tpmLimit <= 0 || tokenId <= 0 || tokens <= 0 {
+		return
+	}
+	if _, err := consumeTokenTpm(c, tokenId, tpmLimit, tokens); err != nil {
+		logger.LogError(c, fmt.Sprintf("consume token tpm failed: token_id=%d, tokens=%d, error=%s", tokenId, tokens, err.Error()))
+	}
+}
+
+// SetTokenRateLimitHeaders 查询令牌 RPM/TPM 余量，连同令牌剩余额度写入限流响应头。
+// rejected 为 true 时按尚未满足的维度计算 Retry-After。
+func SetTokenRateLimitHeaders(c *gin.Context, tokenId int, rpmLimit int, tpmLimit int, rejected bool) {
+	info := RateLimitHeaderInfo{}
+	if quota, ok := c.Get("token_quota"); ok && !common.GetContextKeyBool(c, constant.ContextKeyTokenUnlimited) {
+		if remainQuota, ok := quota.(int); ok {
+			info.QuotaRemaining = &remainQuota
+		}
+	}
+	window := int64(tokenRateLimitWindow)
+	if rpmLimit > 0 {
+		remaining, err := consumeBucket(c, tokenRpmKey(tokenId), bucketOptions(rpmLimit, window, 0)...)
+		if err == nil {
+			info.RequestsLimit = rpmLimit
+			info.RequestsRemaining = int(remaining / window)
+			info.RequestsReset = bucketWaitTime(remaining, int64(rpmLimit)*window, rpmLimit)
+			if rejected {
+				info.RetryAfter = max(info.RetryAfter, bucketWaitTime(remaining, window, rpmLimit))
+			}
+		}
+	}
+	if tpmLimit > 0 {
+		remaining, err := consumeTokenTpm(c, tokenId, tpmLimit, 0)
+		if err == nil {
+			info.TokensLimit = tpmLimit
+			info.TokensRemaining = int(remaining / window)
+			info.TokensReset = bucketWaitTime(remaining, int64(tpmLimit)*window, tpmLimit)
+			if rejected {
+				// TPM 余量为正即可放行
+				info.RetryAfter = max(info.RetryAfter, bucketWaitTime(remaining, 1, tpmLimit))
+			}
+		}
+	}
+	SetRateLimitHeaders(c, InboundRelayFormat(c), info)
+}
+
+// AcquireTokenConcurrency 获取令牌的一个并发名额，成功时返回的 release 必须被调用
+func AcquireTokenConcurrency(ctx context.Context, tokenId int, concurrencyLimit int) (release func(), ok bool, err error) {
+	if concurrencyLimit <= 0 {
+		return func() {}, true, nil
+	}
+	key := tokenConcurrencyKey(tokenId)
+	if common.RedisEnabled {
+		rl := limiter.New(ctx, common.RDB)
+		ok, err = rl.Acquire(ctx, key, int64(concurrencyLimit), tokenConcurrencyTTL)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		return func() {
+			if err := rl.Release(context.Background(), key); err != nil {
+				common.SysLog(fmt.Sprintf("release token concurrency failed: token_id=%d, error=%s", tokenId, err.Error()))
+			}
+		}, true, nil
+	}
+	ml := limiter.NewMemory()
+	if !ml.Acquire(key, int64(concurrencyLimit)) {
+		return nil, false, nil
+	}
+	return func() { ml.Release(key) }, true, nil
+}
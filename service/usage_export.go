@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/pkg/parquet"
+)
+
+const (
+	UsageExportFormatCSV     = "csv"
+	UsageExportFormatParquet = "parquet"
+
+	usageExportBatchSize = 1000
+)
+
+var ErrInvalidExportFormat = errors.New("unsupported export format, expected csv or parquet")
+
+// tableWriter CSV 与 Parquet 导出共用的按行写入接口，parquet.Writer 直接满足该接口
+type tableWriter interface {
+	Write(values ...any) error
+	Close() error
+}
+
+// csvTableWriter 按列类型格式化单元格，时间列输出为 UTC 的 RFC 3339
+type csvTableWriter struct {
+	writer  *csv.Writer
+	columns []parquet.Column
+}
+
+func (w *csvTableWriter) Write(values ...any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case string:
+			record[i] = csvSafeCell(v)
+		case int:
+			record[i] = strconv.Itoa(v)
+		case int64:
+			if w.columns[i].Type == parquet.Timestamp {
+				record[i] = time.Unix(v, 0).UTC().Format(time.RFC3339)
+			} else {
+				record[i] = strconv.FormatInt(v, 10)
+			}
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvTableWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func newTableWriter(w io.Writer, format string, columns []parquet.Column) (tableWriter, error) {
+	switch format {
+	case UsageExportFormatCSV:
+		writer := csv.NewWriter(w)
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = column.Name
+		}
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvTableWriter{writer: writer, columns: columns}, nil
+	case UsageExportFormatParquet:
+		return parquet.NewWriter(w, columns), nil
+	}
+	return nil, ErrInvalidExportFormat
+}
+
+// UsageExportContentType 返回对外开放的导出格式对应的 Content-Type，不支持的格式返回 false。
+// pkg/parquet 的输出尚未经独立的 Parquet 实现（pyarrow、DuckDB 等）校验，校验前 Parquet 不对外开放，
+// 见 pkg/parquet/testdata/README.md
+func UsageExportContentType(format string) (string, bool) {
+	switch format {
+	case UsageExportFormatCSV:
+		return "text/csv; charset=utf-8", true
+	}
+	return "", false
+}
+
+// UsageExportFileName 导出文件名，例如 logs-20261016-150405.csv
+func UsageExportFileName(kind string, format string) string {
+	return fmt.Sprintf("%s-%s.%s", kind, time.Now().Format("20060102-150405"), format)
+}
+
+func quotaToUSD(quota int) float64 {
+	return float64(quota) / common.QuotaPerUnit
+}
+
+func logExportColumns(admin bool) []parquet.Column {
+	columns := []parquet.Column{
+		{Name: "id", Type: parquet.Int64},
+		{Name: "created_at", Type: parquet.Timestamp},
+		{Name: "type", Type: parquet.Int64},
+		{Name: "username", Type: parquet.String},
+		{Name: "token_name", Type: parquet.String},
+		{Name: "model_name", Type: parquet.String},
+		{Name: "group", Type: parquet.String},
+		{Name: "quota", Type: parquet.Int64},
+		{Name: "cost_usd", Type: parquet.Double},
+		{Name: "prompt_tokens", Type: parquet.Int64},
+		{Name: "completion_tokens", Type: parquet.Int64},
+		{Name: "use_time", Type: parquet.Int64},
+		{Name: "is_stream", Type: parquet.Boolean},
+		{Name: "ip", Type: parquet.String},
+		{Name: "request_id", Type: parquet.String},
+		{Name: "content", Type: parquet.String},
+		{Name: "other", Type: parquet.String},
+	}
+	if admin {
+		columns = append(columns,
+			parquet.Column{Name: "channel_id", Type: parquet.Int64},
+			parquet.Column{Name: "channel_name", Type: parquet.String},
+		)
+	}
+	return columns
+}
+
+// ExportLogs 以 CSV 或 Parquet 流式导出日志，filter.UserId 为 0 时为管理员导出并附带渠道信息
+func ExportLogs(w io.Writer, format string, filter model.LogExportFilter) error {
+	admin := filter.UserId == 0
+	writer, err := newTableWriter(w, format, logExportColumns(admin))
+	if err != nil {
+		return err
+	}
+	err = model.StreamLogs(filter, usageExportBatchSize, func(logs []*model.Log) error {
+		for _, log := range logs {
+			values := []any{
+				log.Id, log.CreatedAt, log.Type, log.Username, log.TokenName, log.ModelName, log.Group,
+				log.Quota, quotaToUSD(log.Quota), log.PromptTokens, log.CompletionTokens, log.UseTime,
+				log.IsStream, log.Ip, log.RequestId, log.Content, log.Other,
+			}
+			if admin {
+				values = append(values, log.ChannelId, log.ChannelName)
+			}
+			if err := writer.Write(values...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+var usageDataExportColumns = []parquet.Column{
+	{Name: "created_at", Type: parquet.Timestamp},
+	{Name: "user_id", Type: parquet.Int64},
+	{Name: "username", Type: parquet.String},
+	{Name: "model_name", Type: parquet.String},
+	{Name: "count", Type: parquet.Int64},
+	{Name: "token_used", Type: parquet.Int64},
+	{Name: "quota", Type: parquet.Int64},
+	{Name: "cost_usd", Type: parquet.Double},
+}
+
+// ExportUsageData 以 CSV 或 Parquet 导出按小时、用户与模型汇总的用量（与数据看板的 QuotaData 一致）
+func ExportUsageData(w io.Writer, format string, filter model.LogExportFilter) error {
+	writer, err := newTableWriter(w, format, usageDataExportColumns)
+	if err != nil {
+		return err
+	}
+	err = model.StreamUsageData(filter, func(data *model.QuotaData) error {
+		return writer.Write(data.CreatedAt, data.UserID, data.Username, data.ModelName,
+			data.Count, data.TokenUsed, data.Quota, quotaToUSD(data.Quota))
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
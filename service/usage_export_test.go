@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedUsageLogs(t *testing.T) int64 {
+	t.Helper()
+	truncate(t)
+	t.Cleanup(func() { model.DB.Exec("DELETE FROM usage_report_deliveries") })
+	createdAt := time.Date(2026, 10, 6, 9, 30, 0, 0, time.UTC).Unix()
+	logs := []*model.Log{
+		{UserId: 1, Username: "test_user", Type: model.LogTypeConsume, CreatedAt: createdAt, ModelName: "gpt-4o", TokenName: "=HYPERLINK()", Quota: 250000, PromptTokens: 100, CompletionTokens: 50, IsStream: true},
+		{UserId: 1, Username: "test_user", Type: model.LogTypeConsume, CreatedAt: createdAt + 60, ModelName: "gpt-4o", TokenName: "prod", Quota: 500000, PromptTokens: 200, CompletionTokens: 80},
+		{UserId: 2, Username: "other", Type: model.LogTypeConsume, CreatedAt: createdAt, ModelName: "gpt-4o", TokenName: "prod", Quota: 999},
+	}
+	require.NoError(t, model.LOG_DB.Create(&logs).Error)
+	return createdAt
+}
+
+func TestExportLogsCSV(t *testing.T) {
+	seedUsageLogs(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportLogs(&buf, UsageExportFormatCSV, model.LogExportFilter{UserId: 1}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,created_at,type,username,token_name,"))
+	assert.NotContains(t, lines[0], "channel_id")
+	assert.True(t, strings.HasPrefix(lines[1], "1,2026-10-06T09:30:00Z,2,test_user,'=HYPERLINK(),gpt-4o,,250000,0.5,100,50,0,true,"))
+
+	buf.Reset()
+	require.NoError(t, ExportLogs(&buf, UsageExportFormatCSV, model.LogExportFilter{TokenName: "prod"}))
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasSuffix(lines[0], ",channel_id,channel_name"))
+
+	assert.ErrorIs(t, ExportLogs(&buf, "xlsx", model.LogExportFilter{}), ErrInvalidExportFormat)
+}
+
+func TestExportUsageDataParquet(t *testing.T) {
+	seedUsageLogs(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportUsageData(&buf, UsageExportFormatParquet, model.LogExportFilter{UserId: 1}))
+	data := buf.Bytes()
+	require.Greater(t, len(data), 12)
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	assert.Less(t, footerLength, len(data))
+	// 经独立实现校验前接口不开放 Parquet
+	_, ok := UsageExportContentType(UsageExportFormatParquet)
+	assert.False(t, ok)
+
+	buf.Reset()
+	require.NoError(t, ExportUsageData(&buf, UsageExportFormatCSV, model.LogExportFilter{UserId: 1}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "2026-10-06T09:00:00Z,1,test_user,gpt-4o,2,430,750000,1.5", lines[1])
+}
+
+func TestUsageReportPeriod(t *testing.T) {
+	setting := operation_setting.GetUsageReportSetting()
+	original := setting.Timezone
+	t.Cleanup(func() { setting.Timezone = original })
+
+	// 2026-10-12 为周一
+	at := time.Date(2026, 10, 14, 3, 0, 0, 0, time.UTC)
+	key, start, end := UsageReportPeriod(dto.UsageReportWeekly, at)
+	assert.Equal(t, "weekly:2026-10-05", key)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), end)
+	assert.Equal(t, time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), start)
+
+	key, start, end = UsageReportPeriod(dto.UsageReportMonthly, at)
+	assert.Equal(t, "monthly:2026-09", key)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), end)
+
+	// 周一 UTC 17:00 已是上海时间周二
+	setting.Timezone = "Asia/Shanghai"
+	key, _, end = UsageReportPeriod(dto.UsageReportWeekly, time.Date(2026, 10, 11, 17, 0, 0, 0, time.UTC))
+	assert.Equal(t, "weekly:2026-10-05", key)
+	assert.Equal(t, "2026-10-12T00:00:00+08:00", end.Format(time.RFC3339))
+}
+
+func TestBuildUsageReport(t *testing.T) {
+	createdAt := seedUsageLogs(t)
+	seedUser(t, 1, 0)
+	user, err := model.GetUserById(1, false)
+	require.NoError(t, err)
+
+	start := time.Unix(createdAt, 0).UTC().Truncate(24 * time.Hour)
+	report, err := BuildUsageReport(user, start, start.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, report.Items, 2)
+	assert.Equal(t, int64(2), report.Total.Requests)
+	assert.Equal(t, int64(430), report.TotalTokens)
+	assert.Equal(t, int64(750000), report.Total.Quota)
+	assert.Equal(t, "2026-10-12", report.PeriodEnd)
+
+	var content bytes.Buffer
+	require.NoError(t, htmlTemplates.ExecuteTemplate(&content, "usage_report.html", report))
+	assert.Contains(t, content.String(), "=HYPERLINK()")
+	assert.Contains(t, content.String(), "2026-10-06 – 2026-10-12")
+}
+
+func TestSendUsageReportSkipsEmptyPeriod(t *testing.T) {
+	seedUsageLogs(t)
+	user := &model.User{Id: 3, Username: "idle", Email: "idle@example.com", Status: common.UserStatusEnabled}
+	user.SetSetting(dto.UserSetting{UsageReportFrequency: dto.UsageReportWeekly})
+	require.NoError(t, model.DB.Create(user).Error)
+
+	subscribers, err := model.GetUsageReportSubscribers(0, 10)
+	require.NoError(t, err)
+	require.Len(t, subscribers, 1)
+
+	sent, err := sendUsageReport(subscribers[0], time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, sent)
+	_, claimed, err := model.ClaimUsageReport(3, "weekly:2026-10-05")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/dto"
+	"github.com/QuantumNous/new-api/logger"
+	"github.com/QuantumNous/new-api/model"
+	"github.com/QuantumNous/new-api/setting/operation_setting"
+	"github.com/QuantumNous/new-api/setting/system_setting"
+
+	"github.com/bytedance/gopkg/util/gopool"
+)
+
+const (
+	usageReportTickInterval = 15 * time.Minute
+	usageReportBatchSize    = 200
+)
+
+var (
+	usageReportOnce    sync.Once
+	usageReportRunning atomic.Bool
+)
+
+// UsageReport 用户一个统计周期的用量汇总，用于渲染报告邮件
+type UsageReport struct {
+	SystemName  string
+	SiteUrl     string
+	Username    string
+	PeriodStart string
+	PeriodEnd   string
+	Timezone    string
+	Items       []*model.StatementItem
+	Total       model.StatementItem
+	TotalTokens int64
+}
+
+// StartUsageReportTask 在主节点定期检查并发送已结束周期的用量报告
+func StartUsageReportTask() {
+	usageReportOnce.Do(func() {
+		if !common.IsMasterNode {
+			return
+		}
+		gopool.Go(func() {
+			logger.LogInfo(context.Background(), fmt.Sprintf("usage report task started: tick=%s", usageReportTickInterval))
+			ticker := time.NewTicker(usageReportTickInterval)
+			defer ticker.Stop()
+
+			runUsageReportOnce(time.Now())
+			for range ticker.C {
+				runUsageReportOnce(time.Now())
+			}
+		})
+	})
+}
+
+// UsageReportPeriod 返回 at 之前最近一个已结束的自然周（周一开始）或自然月，key 用于发送去重
+func UsageReportPeriod(frequency string, at time.Time) (key string, start time.Time, end time.Time) {
+	now := at.In(operation_setting.GetUsageReportLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if frequency == dto.UsageReportMonthly {
+		end = today.AddDate(0, 0, 1-today.Day())
+		start = end.AddDate(0, -1, 0)
+		return "monthly:" + start.Format("2006-01"), start, end
+	}
+	end = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	start = end.AddDate(0, 0, -7)
+	return "weekly:" + start.Format("2006-01-02"), start, end
+}
+
+func runUsageReportOnce(at time.Time) {
+	if !operation_setting.GetUsageReportSetting().Enabled || common.SMTPServer == "" {
+		return
+	}
+	if !usageReportRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer usageReportRunning.Store(false)
+
+	ctx := context.Background()
+	sent := 0
+	afterId := 0
+	for {
+		users, err := model.GetUsageReportSubscribers(afterId, usageReportBatchSize)
+		if err != nil {
+			logger.LogWarn(ctx, fmt.Sprintf("usage report task failed to query users: %v", err))
+			return
+		}
+		for _, user := range users {
+			ok, err := sendUsageReport(user, at)
+			if err != nil {
+				logger.LogWarn(ctx, fmt.Sprintf("failed to send usage report to user %d: %v", user.Id, err))
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+		if len(users) < usageReportBatchSize {
+			break
+		}
+		afterId = users[len(users)-1].Id
+	}
+	if sent > 0 {
+		logger.LogInfo(ctx, fmt.Sprintf("usage reports sent: %d", sent))
+	}
+}
+
+// sendUsageReport 为订阅了报告的用户发送上一周期的报告，每个周期只发送一次；周期内无消费时不发送邮件
+func sendUsageReport(user *model.User, at time.Time) (bool, error) {
+	userSetting := user.GetSetting()
+	frequency := userSetting.UsageReportFrequency
+	if frequency != dto.UsageReportWeekly && frequency != dto.UsageReportMonthly {
+		return false, nil
+	}
+	email := userSetting.NotificationEmail
+	if email == "" {
+		email = user.Email
+	}
+	if email == "" {
+		return false, nil
+	}
+	period, start, end := UsageReportPeriod(frequency, at)
+	delivery, claimed, err := model.ClaimUsageReport(user.Id, period)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	report, err := BuildUsageReport(user, start, end)
+	if err != nil {
+		_ = model.FinishUsageReport(delivery, err)
+		return false, err
+	}
+	if len(report.Items) == 0 {
+		return false, model.FinishUsageReport(delivery, nil)
+	}
+	var content bytes.Buffer
+	if err := htmlTemplates.ExecuteTemplate(&content, "usage_report.html", report); err != nil {
+		_ = model.FinishUsageReport(delivery, err)
+		return false, err
+	}
+	subject := fmt.Sprintf("%s 用量报告 / Usage report %s – %s", common.SystemName, report.PeriodStart, report.PeriodEnd)
+	err = sendEmailNotify(email, dto.NewNotify(dto.NotifyTypeUsageReport, subject, content.String(), nil))
+	if finishErr := model.FinishUsageReport(delivery, err); finishErr != nil && err == nil {
+		err = finishErr
+	}
+	return err == nil, err
+}
+
+// BuildUsageReport 汇总用户在 [start, end) 内按模型与令牌的消费
+func BuildUsageReport(user *model.User, start time.Time, end time.Time) (*UsageReport, error) {
+	items, err := model.GetUserStatementItems(user.Id, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	report := &UsageReport{
+		SystemName:  common.SystemName,
+		SiteUrl:     strings.TrimRight(system_setting.ServerAddress, "/"),
+		Username:    user.Username,
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Timezone:    start.Location().String(),
+		Items:       items,
+	}
+	for _, item := range items {
+		report.Total.Requests += item.Requests
+		report.Total.PromptTokens += item.PromptTokens
+		report.Total.CompletionTokens += item.CompletionTokens
+		report.Total.Quota += item.Quota
+	}
+	report.TotalTokens = report.Total.PromptTokens + report.Total.CompletionTokens
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package operation_setting
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+const (
+	AssetStorageModeLocal = "local" // 保存在本地磁盘
+	AssetStorageModeS3    = "s3"    // 保存在 S3 兼容的对象存储（AWS S3、MinIO、R2 等）
+)
+
+// AssetSetting 异步任务生成结果（视频、图片）持久化配置
+type AssetSetting struct {
+	Enabled     bool   `json:"enabled"`      // 任务成功后是否将结果转存并改写结果地址
+	StorageMode string `json:"storage_mode"` // local 或 s3
+	// S3 兼容存储配置，Endpoint 形如 https://s3.us-east-1.amazonaws.com 或 http://minio:9000
+	S3Endpoint     string `json:"s3_endpoint"`
+	S3Region       string `json:"s3_region"`
+	S3Bucket       string `json:"s3_bucket"`
+	S3AccessKeyId  string `json:"s3_access_key_id"`
+	S3AccessSecret string `json:"s3_access_secret"`
+	S3PathStyle    bool   `json:"s3_path_style"` // 使用 endpoint/bucket/key 形式访问，MinIO 通常需要开启
+	// RetentionDays 结果保留天数，到期后删除，0 表示永久保留
+	RetentionDays int `json:"retention_days"`
+	// MaxFileSizeMB 单个结果最大大小（MB），超过时保留上游地址
+	MaxFileSizeMB int `json:"max_file_size_mb"`
+	// MaxStorageMBPerUser 每个用户可占用的存储空间（MB），超出后不再转存，0 表示不限制
+	MaxStorageMBPerUser int `json:"max_storage_mb_per_user"`
+	// SignedURLTTLMinutes 签名下载地址的有效期（分钟），每次查询任务时重新签发
+	SignedURLTTLMinutes int `json:"signed_url_ttl_minutes"`
+}
+
+// 默认配置
+var assetSetting = AssetSetting{
+	Enabled:             false,
+	StorageMode:         AssetStorageModeLocal,
+	S3Region:            "us-east-1",
+	S3PathStyle:         true,
+	RetentionDays:       7,
+	MaxFileSizeMB:       512,
+	MaxStorageMBPerUser: 0,
+	SignedURLTTLMinutes: 60,
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("asset_setting", &assetSetting)
+}
+
+func GetAssetSetting() *AssetSetting {
+	return &assetSetting
+}
+
+func (s *AssetSetting) GetMaxFileSizeBytes() int64 {
+	if s.MaxFileSizeMB <= 0 {
+		return 512 << 20
+	}
+	return int64(s.MaxFileSizeMB) << 20
+}
+
+// GetMaxStorageBytesPerUser 返回每个用户的存储配额，0 表示不限制
+func (s *AssetSetting) GetMaxStorageBytesPerUser() int64 {
+	if s.MaxStorageMBPerUser <= 0 {
+		return 0
+	}
+	return int64(s.MaxStorageMBPerUser) << 20
+}
+
+func (s *AssetSetting) GetSignedURLTTLSeconds() int64 {
+	if s.SignedURLTTLMinutes <= 0 {
+		return 3600
+	}
+	return int64(s.SignedURLTTLMinutes) * 60
+}
+
+// CheckAssetStorageMode 校验存储方式
+func CheckAssetStorageMode(mode string) error {
+	switch mode {
+	case AssetStorageModeLocal, AssetStorageModeS3:
+		return nil
+	}
+	return fmt.Errorf("无效的存储方式: %s", mode)
+}
+
+// CheckAssetS3Endpoint 校验 S3 endpoint，留空表示未配置
+func CheckAssetS3Endpoint(endpoint string) error {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("无效的 S3 Endpoint: %s", endpoint)
+	}
+	return nil
+}
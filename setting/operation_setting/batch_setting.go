@@ -0,0 +1,62 @@
+package operation_setting
+
+import (
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// BatchSetting OpenAI Batch API 配置
+type BatchSetting struct {
+	Enabled             bool `json:"enabled"`                // 是否启用 /v1/batches
+	MaxRequestsPerBatch int  `json:"max_requests_per_batch"` // 单个批处理最多包含的请求数
+	// 本地执行：没有支持原生批处理接口的渠道时，由 new-api 逐行调用普通接口执行
+	LocalEnabled     bool   `json:"local_enabled"`      // 是否启用本地执行
+	LocalConcurrency int    `json:"local_concurrency"`  // 本地执行的并发请求数
+	LocalWindowStart string `json:"local_window_start"` // 执行时间窗口开始，格式 HH:MM，为空表示不限制
+	LocalWindowEnd   string `json:"local_window_end"`   // 执行时间窗口结束，格式 HH:MM，可跨越零点
+}
+
+var batchSetting = BatchSetting{
+	Enabled:             true,
+	MaxRequestsPerBatch: 50000,
+	LocalEnabled:        true,
+	LocalConcurrency:    4,
+}
+
+func init() {
+	config.GlobalConfig.Register("batch_setting", &batchSetting)
+}
+
+func GetBatchSetting() *BatchSetting {
+	return &batchSetting
+}
+
+// GetBatchLocalConcurrency 获取本地执行并发数，至少为 1
+func GetBatchLocalConcurrency() int {
+	if batchSetting.LocalConcurrency < 1 {
+		return 1
+	}
+	return batchSetting.LocalConcurrency
+}
+
+// IsInBatchLocalWindow 判断当前（服务器本地时间）是否处于本地执行时间窗口内，
+// 未配置或配置无效时不限制
+func IsInBatchLocalWindow(now time.Time) bool {
+	start, err1 := time.Parse("15:04", batchSetting.LocalWindowStart)
+	end, err2 := time.Parse("15:04", batchSetting.LocalWindowEnd)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	minute := now.Hour()*60 + now.Minute()
+	if startMinute == endMinute {
+		return true
+	}
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	// 跨越零点，例如 22:00 - 06:00
+	return minute >= startMinute || minute < endMinute
+}
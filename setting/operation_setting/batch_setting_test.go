@@ -0,0 +1,40 @@
+package operation_setting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsInBatchLocalWindow(t *testing.T) {
+	original := batchSetting
+	t.Cleanup(func() { batchSetting = original })
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		now   time.Time
+		want  bool
+	}{
+		{name: "not configured", start: "", end: "", now: at(12, 0), want: true},
+		{name: "invalid", start: "25:00", end: "06:00", now: at(12, 0), want: true},
+		{name: "inside same day", start: "01:00", end: "06:00", now: at(3, 30), want: true},
+		{name: "end is exclusive", start: "01:00", end: "06:00", now: at(6, 0), want: false},
+		{name: "outside same day", start: "01:00", end: "06:00", now: at(12, 0), want: false},
+		{name: "overnight before midnight", start: "22:00", end: "06:00", now: at(23, 15), want: true},
+		{name: "overnight after midnight", start: "22:00", end: "06:00", now: at(5, 59), want: true},
+		{name: "overnight outside", start: "22:00", end: "06:00", now: at(12, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batchSetting.LocalWindowStart = tt.start
+			batchSetting.LocalWindowEnd = tt.end
+			require.Equal(t, tt.want, IsInBatchLocalWindow(tt.now))
+		})
+	}
+}
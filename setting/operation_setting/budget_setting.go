@@ -0,0 +1,59 @@
+package operation_setting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// BudgetSetting 用户/令牌周期预算配置
+type BudgetSetting struct {
+	Enabled bool `json:"enabled"` // 是否启用周期预算检查
+	// Timezone 预算窗口按该时区的自然日/周/月对齐，留空使用 UTC
+	Timezone string `json:"timezone"`
+	// DefaultSoftPercent 预算未单独配置软阈值时使用的默认百分比，0 表示不发送提醒
+	DefaultSoftPercent int `json:"default_soft_percent"`
+}
+
+// 默认配置
+var budgetSetting = BudgetSetting{
+	Enabled:            true,
+	Timezone:           "",
+	DefaultSoftPercent: 80,
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("budget_setting", &budgetSetting)
+}
+
+func GetBudgetSetting() *BudgetSetting {
+	return &budgetSetting
+}
+
+// GetBudgetLocation 返回预算窗口对齐使用的时区，配置无效时回退到 UTC
+func GetBudgetLocation() *time.Location {
+	tz := strings.TrimSpace(budgetSetting.Timezone)
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// CheckBudgetTimezone 校验时区名称是否可被加载
+func CheckBudgetTimezone(tz string) error {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return fmt.Errorf("无效的时区: %s", tz)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// CircuitBreakerSetting 渠道熔断与健康评分配置
+type CircuitBreakerSetting struct {
+	Enabled bool `json:"enabled"` // 是否根据渠道健康状况调整选择权重并熔断
+	// WindowSeconds 统计错误率与延迟的滚动窗口长度
+	WindowSeconds int `json:"window_seconds"`
+	// MinRequests 窗口内请求数达到该值后才会评分或熔断，避免少量样本误判
+	MinRequests int `json:"min_requests"`
+	// FailureRatePercent 窗口内失败率达到该百分比时熔断
+	FailureRatePercent int `json:"failure_rate_percent"`
+	// SlowCallMs 超过该耗时（流式请求为首字耗时）的成功请求视为慢请求，0 表示不统计延迟
+	SlowCallMs int `json:"slow_call_ms"`
+	// SlowCallRatePercent 窗口内慢请求比例达到该百分比时熔断
+	SlowCallRatePercent int `json:"slow_call_rate_percent"`
+	// OpenSeconds 熔断后等待多久进入半开状态
+	OpenSeconds int `json:"open_seconds"`
+	// ProbeIntervalSeconds 半开状态下两次探测请求之间的最小间隔
+	ProbeIntervalSeconds int `json:"probe_interval_seconds"`
+	// HalfOpenSuccesses 半开状态下连续成功多少次后恢复
+	HalfOpenSuccesses int `json:"half_open_successes"`
+}
+
+// 默认配置
+var circuitBreakerSetting = CircuitBreakerSetting{
+	Enabled:              true,
+	WindowSeconds:        60,
+	MinRequests:          10,
+	FailureRatePercent:   50,
+	SlowCallMs:           60000,
+	SlowCallRatePercent:  80,
+	OpenSeconds:          30,
+	ProbeIntervalSeconds: 5,
+	HalfOpenSuccesses:    3,
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("circuit_breaker_setting", &circuitBreakerSetting)
+}
+
+func GetCircuitBreakerSetting() *CircuitBreakerSetting {
+	return &circuitBreakerSetting
+}
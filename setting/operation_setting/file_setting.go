@@ -0,0 +1,53 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+const (
+	FileStorageModeLocal    = "local"    // 文件保存在本地磁盘
+	FileStorageModeUpstream = "upstream" // 文件转发到上游 OpenAI/Azure 渠道
+)
+
+// FileSetting OpenAI Files API 配置
+type FileSetting struct {
+	Enabled           bool     `json:"enabled"`             // 是否启用 /v1/files
+	StorageMode       string   `json:"storage_mode"`        // local 或 upstream
+	UpstreamChannelId int      `json:"upstream_channel_id"` // upstream 模式下使用的渠道 ID
+	MaxFileSizeMB     int      `json:"max_file_size_mb"`    // 单个文件最大大小（MB）
+	MaxFilesPerUser   int      `json:"max_files_per_user"`  // 每个用户最多保存的文件数，0 表示不限制
+	RetentionDays     int      `json:"retention_days"`      // 文件保留天数，0 表示永久保留
+	AllowedPurposes   []string `json:"allowed_purposes"`    // 允许的 purpose 列表
+}
+
+var fileSetting = FileSetting{
+	Enabled:           true,
+	StorageMode:       FileStorageModeLocal,
+	UpstreamChannelId: 0,
+	MaxFileSizeMB:     512,
+	MaxFilesPerUser:   0,
+	RetentionDays:     30,
+	AllowedPurposes:   []string{"assistants", "batch", "fine-tune", "vision", "user_data", "evals"},
+}
+
+func init() {
+	config.GlobalConfig.Register("file_setting", &fileSetting)
+}
+
+func GetFileSetting() *FileSetting {
+	return &fileSetting
+}
+
+func IsFilePurposeAllowed(purpose string) bool {
+	for _, p := range fileSetting.AllowedPurposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
+func GetMaxFileSizeBytes() int64 {
+	if fileSetting.MaxFileSizeMB <= 0 {
+		return 512 << 20
+	}
+	return int64(fileSetting.MaxFileSizeMB) << 20
+}
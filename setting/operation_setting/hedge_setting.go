@@ -0,0 +1,66 @@
+package operation_setting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// HedgeSetting 对冲请求配置：首个渠道在指定时间内未返回首字节时，向另一个渠道发起相同请求并采用先响应的结果
+type HedgeSetting struct {
+	Enabled bool `json:"enabled"`
+	// GroupDelays 分组 -> 触发对冲的等待时间（毫秒），未配置的分组不对冲
+	GroupDelays map[string]int `json:"group_delays"`
+	// ModelDelays 模型 -> 触发对冲的等待时间（毫秒），对所有分组生效且优先于分组配置
+	ModelDelays map[string]int `json:"model_delays"`
+	// BillLoser 是否对未被采用的请求按输入预估额度计费，默认只对被采用的请求计费
+	BillLoser bool `json:"bill_loser"`
+}
+
+// 默认配置
+var hedgeSetting = HedgeSetting{
+	Enabled:     false,
+	GroupDelays: map[string]int{},
+	ModelDelays: map[string]int{},
+	BillLoser:   false,
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("hedge_setting", &hedgeSetting)
+}
+
+func GetHedgeSetting() *HedgeSetting {
+	return &hedgeSetting
+}
+
+// GetHedgeDelay 返回分组与模型触发对冲的等待时间，未开启或未配置时返回 false
+func GetHedgeDelay(group string, modelName string) (time.Duration, bool) {
+	if !hedgeSetting.Enabled {
+		return 0, false
+	}
+	delayMs, ok := hedgeSetting.ModelDelays[modelName]
+	if !ok {
+		delayMs, ok = hedgeSetting.GroupDelays[group]
+	}
+	if !ok || delayMs <= 0 {
+		return 0, false
+	}
+	return time.Duration(delayMs) * time.Millisecond, true
+}
+
+// CheckHedgeDelays 校验 JSON 格式的对冲等待时间映射（分组或模型 -> 毫秒）
+func CheckHedgeDelays(jsonStr string) error {
+	delays := make(map[string]int)
+	if err := common.UnmarshalJsonStr(jsonStr, &delays); err != nil {
+		return err
+	}
+	for name, delayMs := range delays {
+		if delayMs <= 0 {
+			return fmt.Errorf("%s 的对冲等待时间必须大于 0", name)
+		}
+	}
+	return nil
+}
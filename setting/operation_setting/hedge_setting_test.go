@@ -0,0 +1,34 @@
+package operation_setting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHedgeDelay(t *testing.T) {
+	original := hedgeSetting
+	t.Cleanup(func() { hedgeSetting = original })
+
+	hedgeSetting.GroupDelays = map[string]int{"vip": 1500}
+	hedgeSetting.ModelDelays = map[string]int{"gpt-4o-mini": 800}
+	_, ok := GetHedgeDelay("vip", "gpt-4o-mini")
+	require.False(t, ok)
+
+	hedgeSetting.Enabled = true
+	delay, ok := GetHedgeDelay("vip", "gpt-4o-mini")
+	require.True(t, ok)
+	require.Equal(t, 800*time.Millisecond, delay)
+	delay, ok = GetHedgeDelay("vip", "gpt-4o")
+	require.True(t, ok)
+	require.Equal(t, 1500*time.Millisecond, delay)
+	_, ok = GetHedgeDelay("default", "gpt-4o")
+	require.False(t, ok)
+}
+
+func TestCheckHedgeDelays(t *testing.T) {
+	require.NoError(t, CheckHedgeDelays(`{"vip": 1500}`))
+	require.Error(t, CheckHedgeDelays(`{"vip": 0}`))
+	require.Error(t, CheckHedgeDelays(`{"vip": "fast"}`))
+}
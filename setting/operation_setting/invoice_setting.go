@@ -0,0 +1,72 @@
+package operation_setting
+
+import (
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// InvoiceSetting 充值/订阅发票与月度账单配置
+type InvoiceSetting struct {
+	Enabled bool `json:"enabled"` // 是否允许用户下载发票与月度账单
+	// 开票方信息，显示在发票与账单抬头
+	SellerName    string `json:"seller_name"`
+	SellerAddress string `json:"seller_address"`
+	SellerTaxId   string `json:"seller_tax_id"`
+	SellerEmail   string `json:"seller_email"`
+	// NumberPrefix 发票编号前缀，编号形如 INV-202610-000001
+	NumberPrefix string `json:"number_prefix"`
+	// Currency 钱包充值的结算币种，订阅订单使用套餐自身的币种
+	Currency string `json:"currency"`
+	// Footer 发票底部备注，例如开户行信息或说明文字
+	Footer string `json:"footer"`
+	// Timezone 月度账单按该时区的自然月统计，留空使用 UTC
+	Timezone string `json:"timezone"`
+}
+
+// 默认配置
+var invoiceSetting = InvoiceSetting{
+	Enabled:      false,
+	NumberPrefix: "INV",
+	Currency:     "CNY",
+	Timezone:     "",
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("invoice_setting", &invoiceSetting)
+}
+
+func GetInvoiceSetting() *InvoiceSetting {
+	return &invoiceSetting
+}
+
+// GetInvoiceLocation 返回月度账单使用的时区，配置无效时回退到 UTC
+func GetInvoiceLocation() *time.Location {
+	tz := strings.TrimSpace(invoiceSetting.Timezone)
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (s *InvoiceSetting) GetNumberPrefix() string {
+	prefix := strings.TrimSpace(s.NumberPrefix)
+	if prefix == "" {
+		return "INV"
+	}
+	return prefix
+}
+
+func (s *InvoiceSetting) GetCurrency() string {
+	currency := strings.ToUpper(strings.TrimSpace(s.Currency))
+	if currency == "" {
+		return "CNY"
+	}
+	return currency
+}
@@ -0,0 +1,75 @@
+package operation_setting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// ModelFallbackSetting 模型降级配置：某个模型在所有渠道上均不可用或重试次数用完时，
+// 依次改用降级链中的模型，计费与日志均按实际使用的模型
+type ModelFallbackSetting struct {
+	Enabled bool `json:"enabled"`
+	// Chains 模型 -> 按顺序尝试的降级模型列表，令牌可按模型覆盖
+	Chains map[string][]string `json:"chains"`
+}
+
+// 默认配置
+var modelFallbackSetting = ModelFallbackSetting{
+	Enabled: false,
+	Chains:  map[string][]string{},
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("model_fallback_setting", &modelFallbackSetting)
+}
+
+func GetModelFallbackSetting() *ModelFallbackSetting {
+	return &modelFallbackSetting
+}
+
+// GetModelFallbackChain 返回模型的降级链，tokenChains 中配置了该模型时优先使用（空列表表示不降级），
+// 未开启时返回 nil
+func GetModelFallbackChain(modelName string, tokenChains map[string][]string) []string {
+	if !modelFallbackSetting.Enabled {
+		return nil
+	}
+	if chain, ok := tokenChains[modelName]; ok {
+		return chain
+	}
+	return modelFallbackSetting.Chains[modelName]
+}
+
+// ParseModelFallbackChains 解析并校验 JSON 格式的降级链（模型 -> 降级模型数组）
+func ParseModelFallbackChains(jsonStr string) (map[string][]string, error) {
+	chains := make(map[string][]string)
+	if strings.TrimSpace(jsonStr) == "" {
+		return chains, nil
+	}
+	if err := common.UnmarshalJsonStr(jsonStr, &chains); err != nil {
+		return nil, err
+	}
+	for modelName, chain := range chains {
+		if strings.TrimSpace(modelName) == "" {
+			return nil, fmt.Errorf("降级链的模型名称不能为空")
+		}
+		for _, fallbackModel := range chain {
+			if strings.TrimSpace(fallbackModel) == "" {
+				return nil, fmt.Errorf("%s 的降级模型名称不能为空", modelName)
+			}
+			if fallbackModel == modelName {
+				return nil, fmt.Errorf("%s 的降级链不能包含自身", modelName)
+			}
+		}
+	}
+	return chains, nil
+}
+
+// CheckModelFallbackChains 校验 JSON 格式的降级链
+func CheckModelFallbackChains(jsonStr string) error {
+	_, err := ParseModelFallbackChains(jsonStr)
+	return err
+}
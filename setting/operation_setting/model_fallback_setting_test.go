@@ -0,0 +1,32 @@
+package operation_setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModelFallbackChain(t *testing.T) {
+	original := modelFallbackSetting
+	t.Cleanup(func() { modelFallbackSetting = original })
+
+	modelFallbackSetting.Chains = map[string][]string{"gpt-4o": {"gpt-4.1", "claude-sonnet-4"}}
+	require.Nil(t, GetModelFallbackChain("gpt-4o", nil))
+
+	modelFallbackSetting.Enabled = true
+	require.Equal(t, []string{"gpt-4.1", "claude-sonnet-4"}, GetModelFallbackChain("gpt-4o", nil))
+	require.Nil(t, GetModelFallbackChain("gpt-4.1", nil))
+
+	// 令牌配置覆盖全局降级链，空列表表示不降级
+	require.Equal(t, []string{"gemini-2.5-pro"}, GetModelFallbackChain("gpt-4o", map[string][]string{"gpt-4o": {"gemini-2.5-pro"}}))
+	require.Empty(t, GetModelFallbackChain("gpt-4o", map[string][]string{"gpt-4o": {}}))
+}
+
+func TestCheckModelFallbackChains(t *testing.T) {
+	require.NoError(t, CheckModelFallbackChains(`{"gpt-4o": ["gpt-4.1"]}`))
+	require.NoError(t, CheckModelFallbackChains(`{}`))
+	require.NoError(t, CheckModelFallbackChains(``))
+	require.Error(t, CheckModelFallbackChains(`{"gpt-4o": [" "]}`))
+	require.Error(t, CheckModelFallbackChains(`{"gpt-4o": ["gpt-4o"]}`))
+	require.Error(t, CheckModelFallbackChains(`["gpt-4.1"]`))
+}
@@ -0,0 +1,86 @@
+package operation_setting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+const (
+	ModerationProviderModel   = "model"   // 通过提供 /v1/moderations 的渠道调用审核模型
+	ModerationProviderWebhook = "webhook" // 调用通用 HTTP webhook
+
+	ModerationActionAllow = "allow" // 不审核
+	ModerationActionFlag  = "flag"  // 审核，命中时放行并记录到日志
+	ModerationActionBlock = "block" // 审核，命中时拒绝请求
+)
+
+// ModerationSetting 转发前的内容审核配置
+type ModerationSetting struct {
+	Enabled        bool   `json:"enabled"`         // 是否启用内容审核
+	Provider       string `json:"provider"`        // 审核方式：model / webhook
+	Model          string `json:"model"`           // provider 为 model 时使用的审核模型，例如 omni-moderation-latest
+	Group          string `json:"group"`           // 选择审核模型渠道时使用的分组，为空时使用请求的分组
+	WebhookURL     string `json:"webhook_url"`     // provider 为 webhook 时的请求地址
+	WebhookSecret  string `json:"webhook_secret"`  // webhook 请求的 Bearer 密钥，可为空
+	TimeoutSeconds int    `json:"timeout_seconds"` // 单次审核请求超时时间
+	// FailOpen 审核服务出错时是否放行请求
+	FailOpen bool `json:"fail_open"`
+	// DefaultAction 未单独配置的分组使用的策略：allow / flag / block
+	DefaultAction string `json:"default_action"`
+	// GroupActions 按分组覆盖策略，例如 {"vip": "flag", "free": "block"}
+	GroupActions map[string]string `json:"group_actions"`
+}
+
+var moderationSetting = ModerationSetting{
+	Enabled:        false,
+	Provider:       ModerationProviderModel,
+	Model:          "omni-moderation-latest",
+	TimeoutSeconds: 10,
+	FailOpen:       true,
+	DefaultAction:  ModerationActionFlag,
+	GroupActions:   map[string]string{},
+}
+
+func init() {
+	config.GlobalConfig.Register("moderation_setting", &moderationSetting)
+}
+
+func GetModerationSetting() *ModerationSetting {
+	return &moderationSetting
+}
+
+// GetModerationAction 获取分组的审核策略，未启用审核或配置无效时返回 allow
+func GetModerationAction(group string) string {
+	if !moderationSetting.Enabled {
+		return ModerationActionAllow
+	}
+	action, ok := moderationSetting.GroupActions[group]
+	if !ok {
+		action = moderationSetting.DefaultAction
+	}
+	switch action = strings.ToLower(strings.TrimSpace(action)); action {
+	case ModerationActionFlag, ModerationActionBlock:
+		return action
+	default:
+		return ModerationActionAllow
+	}
+}
+
+// CheckModerationGroupActions 校验分组审核策略 JSON，策略只能是 allow / flag / block
+func CheckModerationGroupActions(jsonStr string) error {
+	groupActions := make(map[string]string)
+	if err := json.Unmarshal([]byte(jsonStr), &groupActions); err != nil {
+		return err
+	}
+	for group, action := range groupActions {
+		switch strings.ToLower(strings.TrimSpace(action)) {
+		case ModerationActionAllow, ModerationActionFlag, ModerationActionBlock:
+		default:
+			return fmt.Errorf("group %s has invalid moderation action: %s", group, action)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package operation_setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModerationAction(t *testing.T) {
+	original := moderationSetting
+	t.Cleanup(func() { moderationSetting = original })
+
+	moderationSetting.GroupActions = map[string]string{"vip": "allow", "free": " Block ", "bad": "unknown"}
+	moderationSetting.DefaultAction = ModerationActionFlag
+
+	moderationSetting.Enabled = false
+	require.Equal(t, ModerationActionAllow, GetModerationAction("free"))
+
+	moderationSetting.Enabled = true
+	require.Equal(t, ModerationActionAllow, GetModerationAction("vip"))
+	require.Equal(t, ModerationActionBlock, GetModerationAction("free"))
+	require.Equal(t, ModerationActionAllow, GetModerationAction("bad"))
+	require.Equal(t, ModerationActionFlag, GetModerationAction("default"))
+}
+
+func TestCheckModerationGroupActions(t *testing.T) {
+	require.NoError(t, CheckModerationGroupActions(`{"vip": "allow", "free": "block", "default": "flag"}`))
+	require.Error(t, CheckModerationGroupActions(`{"vip": "deny"}`))
+	require.Error(t, CheckModerationGroupActions(`["block"]`))
+}
@@ -0,0 +1,30 @@
+package operation_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// ResponseCacheSetting 精确匹配的响应缓存配置
+type ResponseCacheSetting struct {
+	Enabled    bool `json:"enabled"`     // 是否启用响应缓存
+	TTLSeconds int  `json:"ttl_seconds"` // 缓存有效期
+	// BillingRatio 命中缓存时按原始消耗额度的倍率计费，0 表示免费
+	BillingRatio float64 `json:"billing_ratio"`
+	// DeterministicOnly 仅缓存 temperature 为 0 的对话请求（embedding 请求总是可缓存）
+	DeterministicOnly bool `json:"deterministic_only"`
+	MaxEntryKB        int  `json:"max_entry_kb"` // 单条响应的最大缓存大小，超出时不缓存
+}
+
+var responseCacheSetting = ResponseCacheSetting{
+	Enabled:           false,
+	TTLSeconds:        3600,
+	BillingRatio:      0,
+	DeterministicOnly: true,
+	MaxEntryKB:        1024,
+}
+
+func init() {
+	config.GlobalConfig.Register("response_cache_setting", &responseCacheSetting)
+}
+
+func GetResponseCacheSetting() *ResponseCacheSetting {
+	return &responseCacheSetting
+}
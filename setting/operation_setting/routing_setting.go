@@ -0,0 +1,78 @@
+package operation_setting
+
+import (
+	"fmt"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// 同一优先级内选择渠道的策略
+const (
+	RoutingStrategyWeightedRandom   = "weighted_random"   // 按权重随机（默认）
+	RoutingStrategyLeastLatency     = "least_latency"     // 首字延迟最低
+	RoutingStrategyLeastCost        = "least_cost"        // 模型重定向后的模型倍率最低
+	RoutingStrategyLeastOutstanding = "least_outstanding" // 在途请求数最少
+)
+
+// RoutingSetting 渠道路由策略配置，优先级：模型 > 分组 > 默认
+type RoutingSetting struct {
+	DefaultStrategy string `json:"default_strategy"`
+	// GroupStrategies 分组 -> 策略
+	GroupStrategies map[string]string `json:"group_strategies"`
+	// ModelStrategies 模型 -> 策略，对所有分组生效
+	ModelStrategies map[string]string `json:"model_strategies"`
+}
+
+// 默认配置
+var routingSetting = RoutingSetting{
+	DefaultStrategy: RoutingStrategyWeightedRandom,
+	GroupStrategies: map[string]string{},
+	ModelStrategies: map[string]string{},
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("routing_setting", &routingSetting)
+}
+
+func GetRoutingSetting() *RoutingSetting {
+	return &routingSetting
+}
+
+func IsValidRoutingStrategy(strategy string) bool {
+	switch strategy {
+	case RoutingStrategyWeightedRandom, RoutingStrategyLeastLatency, RoutingStrategyLeastCost, RoutingStrategyLeastOutstanding:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetRoutingStrategy 返回分组与模型对应的路由策略，未配置或配置无效时使用按权重随机
+func GetRoutingStrategy(group string, modelName string) string {
+	if strategy, ok := routingSetting.ModelStrategies[modelName]; ok && IsValidRoutingStrategy(strategy) {
+		return strategy
+	}
+	if strategy, ok := routingSetting.GroupStrategies[group]; ok && IsValidRoutingStrategy(strategy) {
+		return strategy
+	}
+	if IsValidRoutingStrategy(routingSetting.DefaultStrategy) {
+		return routingSetting.DefaultStrategy
+	}
+	return RoutingStrategyWeightedRandom
+}
+
+// CheckRoutingStrategies 校验 JSON 格式的策略映射（分组或模型 -> 策略）
+func CheckRoutingStrategies(jsonStr string) error {
+	strategies := make(map[string]string)
+	if err := common.UnmarshalJsonStr(jsonStr, &strategies); err != nil {
+		return err
+	}
+	for name, strategy := range strategies {
+		if !IsValidRoutingStrategy(strategy) {
+			return fmt.Errorf("%s 的路由策略 %s 无效", name, strategy)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package operation_setting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// SemanticCacheSetting 对话请求的语义缓存配置
+type SemanticCacheSetting struct {
+	Enabled bool `json:"enabled"` // 是否启用语义缓存
+	// EmbeddingModel 用于向量化最后一条用户消息的模型，需要有渠道提供该模型的 /v1/embeddings 接口
+	EmbeddingModel string `json:"embedding_model"`
+	EmbeddingGroup string `json:"embedding_group"` // 选择 embedding 渠道时使用的分组，为空时使用请求的分组
+	TimeoutSeconds int    `json:"timeout_seconds"` // 单次 embedding 请求超时时间
+	// SharedGroups 允许不同用户共享缓存的分组，逗号分隔；其他分组的缓存按用户隔离
+	SharedGroups string `json:"shared_groups"`
+	// DefaultThreshold 命中缓存所需的最小余弦相似度
+	DefaultThreshold float64 `json:"default_threshold"`
+	// ModelThresholds 按模型覆盖相似度阈值，例如 {"gpt-4o-mini": 0.92}
+	ModelThresholds map[string]float64 `json:"model_thresholds"`
+	TTLSeconds      int                `json:"ttl_seconds"`  // 缓存有效期
+	MaxEntries      int                `json:"max_entries"`  // 最大缓存条目数，超出时淘汰最早的条目
+	MaxEntryKB      int                `json:"max_entry_kb"` // 单条响应的最大缓存大小，超出时不缓存
+	// BillingRatio 命中缓存时按原始消耗额度的倍率计费，0 表示免费
+	BillingRatio float64 `json:"billing_ratio"`
+}
+
+var semanticCacheSetting = SemanticCacheSetting{
+	Enabled:          false,
+	EmbeddingModel:   "text-embedding-3-small",
+	TimeoutSeconds:   10,
+	SharedGroups:     "",
+	DefaultThreshold: 0.95,
+	ModelThresholds:  map[string]float64{},
+	TTLSeconds:       86400,
+	MaxEntries:       10000,
+	MaxEntryKB:       48,
+	BillingRatio:     0,
+}
+
+func init() {
+	config.GlobalConfig.Register("semantic_cache_setting", &semanticCacheSetting)
+}
+
+func GetSemanticCacheSetting() *SemanticCacheSetting {
+	return &semanticCacheSetting
+}
+
+// IsSemanticCacheSharedGroup 分组是否开启了跨用户共享语义缓存
+func IsSemanticCacheSharedGroup(group string) bool {
+	for _, shared := range strings.Split(semanticCacheSetting.SharedGroups, ",") {
+		if shared = strings.TrimSpace(shared); shared != "" && shared == group {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSemanticCacheThreshold 获取模型的相似度阈值，未单独配置时使用默认阈值
+func GetSemanticCacheThreshold(modelName string) float64 {
+	if threshold, ok := semanticCacheSetting.ModelThresholds[modelName]; ok {
+		return threshold
+	}
+	return semanticCacheSetting.DefaultThreshold
+}
+
+// CheckSemanticCacheModelThresholds 校验模型相似度阈值 JSON，阈值范围为 (0, 1]
+func CheckSemanticCacheModelThresholds(jsonStr string) error {
+	thresholds := make(map[string]float64)
+	if err := json.Unmarshal([]byte(jsonStr), &thresholds); err != nil {
+		return err
+	}
+	for modelName, threshold := range thresholds {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("model %s has invalid similarity threshold: %v", modelName, threshold)
+		}
+	}
+	return nil
+}
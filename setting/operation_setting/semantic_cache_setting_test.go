@@ -0,0 +1,24 @@
+package operation_setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSemanticCacheThreshold(t *testing.T) {
+	original := semanticCacheSetting
+	t.Cleanup(func() { semanticCacheSetting = original })
+
+	semanticCacheSetting.DefaultThreshold = 0.95
+	semanticCacheSetting.ModelThresholds = map[string]float64{"gpt-4o-mini": 0.9}
+	require.Equal(t, 0.9, GetSemanticCacheThreshold("gpt-4o-mini"))
+	require.Equal(t, 0.95, GetSemanticCacheThreshold("gpt-4o"))
+}
+
+func TestCheckSemanticCacheModelThresholds(t *testing.T) {
+	require.NoError(t, CheckSemanticCacheModelThresholds(`{"gpt-4o": 0.92, "gpt-4o-mini": 1}`))
+	require.Error(t, CheckSemanticCacheModelThresholds(`{"gpt-4o": 0}`))
+	require.Error(t, CheckSemanticCacheModelThresholds(`{"gpt-4o": 1.2}`))
+	require.Error(t, CheckSemanticCacheModelThresholds(`{"gpt-4o": "high"}`))
+}
@@ -0,0 +1,58 @@
+package operation_setting
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// StreamFailoverSetting 流式响应中途断开时的续写配置：保留已输出给客户端的内容，
+// 将其作为 assistant 预填充换渠道重新请求，并把续写的内容接在原响应之后
+type StreamFailoverSetting struct {
+	Enabled bool `json:"enabled"`
+	// MaxFailovers 单个请求最多续写的次数，同时受重试次数限制
+	MaxFailovers int `json:"max_failovers"`
+	// Models 开启续写的模型，为空时对所有模型生效
+	Models []string `json:"models"`
+}
+
+// 默认配置
+var streamFailoverSetting = StreamFailoverSetting{
+	Enabled:      false,
+	MaxFailovers: 1,
+	Models:       []string{},
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("stream_failover_setting", &streamFailoverSetting)
+}
+
+func GetStreamFailoverSetting() *StreamFailoverSetting {
+	return &streamFailoverSetting
+}
+
+// IsStreamFailoverEnabled 返回模型是否开启流式续写
+func IsStreamFailoverEnabled(modelName string) bool {
+	if !streamFailoverSetting.Enabled || streamFailoverSetting.MaxFailovers <= 0 {
+		return false
+	}
+	return len(streamFailoverSetting.Models) == 0 || slices.Contains(streamFailoverSetting.Models, modelName)
+}
+
+// CheckStreamFailoverModels 校验 JSON 格式的续写模型列表
+func CheckStreamFailoverModels(jsonStr string) error {
+	var models []string
+	if err := common.UnmarshalJsonStr(jsonStr, &models); err != nil {
+		return err
+	}
+	for _, modelName := range models {
+		if strings.TrimSpace(modelName) == "" {
+			return fmt.Errorf("续写模型名称不能为空")
+		}
+	}
+	return nil
+}
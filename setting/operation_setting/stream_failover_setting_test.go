@@ -0,0 +1,31 @@
+package operation_setting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStreamFailoverEnabled(t *testing.T) {
+	original := streamFailoverSetting
+	t.Cleanup(func() { streamFailoverSetting = original })
+
+	require.False(t, IsStreamFailoverEnabled("claude-sonnet-4"))
+
+	streamFailoverSetting.Enabled = true
+	require.True(t, IsStreamFailoverEnabled("claude-sonnet-4"))
+
+	streamFailoverSetting.Models = []string{"claude-sonnet-4"}
+	require.True(t, IsStreamFailoverEnabled("claude-sonnet-4"))
+	require.False(t, IsStreamFailoverEnabled("gpt-4o"))
+
+	streamFailoverSetting.MaxFailovers = 0
+	require.False(t, IsStreamFailoverEnabled("claude-sonnet-4"))
+}
+
+func TestCheckStreamFailoverModels(t *testing.T) {
+	require.NoError(t, CheckStreamFailoverModels(`["claude-sonnet-4"]`))
+	require.NoError(t, CheckStreamFailoverModels(`[]`))
+	require.Error(t, CheckStreamFailoverModels(`[" "]`))
+	require.Error(t, CheckStreamFailoverModels(`{"claude-sonnet-4": true}`))
+}
@@ -0,0 +1,43 @@
+package operation_setting
+
+import (
+	"strings"
+	"time"
+
+	"github.com/QuantumNous/new-api/setting/config"
+)
+
+// UsageReportSetting 周/月用量报告邮件配置，用户在通知设置中选择是否订阅
+type UsageReportSetting struct {
+	Enabled bool `json:"enabled"` // 是否发送定期用量报告
+	// Timezone 报告按该时区的自然周（周一开始）/自然月统计，留空使用 UTC
+	Timezone string `json:"timezone"`
+}
+
+// 默认配置
+var usageReportSetting = UsageReportSetting{
+	Enabled:  false,
+	Timezone: "",
+}
+
+func init() {
+	// 注册到全局配置管理器
+	config.GlobalConfig.Register("usage_report_setting", &usageReportSetting)
+}
+
+func GetUsageReportSetting() *UsageReportSetting {
+	return &usageReportSetting
+}
+
+// GetUsageReportLocation 返回用量报告使用的时区，配置无效时回退到 UTC
+func GetUsageReportLocation() *time.Location {
+	tz := strings.TrimSpace(usageReportSetting.Timezone)
+	if tz == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -67,3 +67,68 @@ func CheckModelRequestRateLimitGroup(jsonStr string) error {
 
 	return nil
 }
+
+// 分组 × 模型的 token 速率限制：按预估输入 token 预占，请求结束后按实际用量结算
+var ModelTokenRateLimitEnabled = false
+
+// ModelTokenRateLimitGroup {"组名": {"模型名": [TPM, TPD]}}，0 表示不限制；
+// 模型名为 "*" 时作为该分组下未单独配置的模型的默认值（每个模型仍单独计数）
+var ModelTokenRateLimitGroup = map[string]map[string][2]int{}
+var ModelTokenRateLimitMutex sync.RWMutex
+
+func ModelTokenRateLimitGroup2JSONString() string {
+	ModelTokenRateLimitMutex.RLock()
+	defer ModelTokenRateLimitMutex.RUnlock()
+
+	jsonBytes, err := json.Marshal(ModelTokenRateLimitGroup)
+	if err != nil {
+		common.SysLog("error marshalling model token rate limit: " + err.Error())
+	}
+	return string(jsonBytes)
+}
+
+func UpdateModelTokenRateLimitGroupByJSONString(jsonStr string) error {
+	ModelTokenRateLimitMutex.Lock()
+	defer ModelTokenRateLimitMutex.Unlock()
+
+	ModelTokenRateLimitGroup = make(map[string]map[string][2]int)
+	return json.Unmarshal([]byte(jsonStr), &ModelTokenRateLimitGroup)
+}
+
+// GetModelTokenRateLimit 获取分组下模型的 TPM/TPD 限制，优先使用模型的单独配置，其次使用 "*"
+func GetModelTokenRateLimit(group string, modelName string) (tpm, tpd int, found bool) {
+	ModelTokenRateLimitMutex.RLock()
+	defer ModelTokenRateLimitMutex.RUnlock()
+
+	models, ok := ModelTokenRateLimitGroup[group]
+	if !ok {
+		return 0, 0, false
+	}
+	limits, ok := models[modelName]
+	if !ok {
+		limits, ok = models["*"]
+		if !ok {
+			return 0, 0, false
+		}
+	}
+	return limits[0], limits[1], limits[0] > 0 || limits[1] > 0
+}
+
+func CheckModelTokenRateLimitGroup(jsonStr string) error {
+	checkModelTokenRateLimitGroup := make(map[string]map[string][2]int)
+	err := json.Unmarshal([]byte(jsonStr), &checkModelTokenRateLimitGroup)
+	if err != nil {
+		return err
+	}
+	for group, models := range checkModelTokenRateLimitGroup {
+		for modelName, limits := range models {
+			if limits[0] < 0 || limits[1] < 0 {
+				return fmt.Errorf("group %s model %s has negative token rate limit values: [%d, %d]", group, modelName, limits[0], limits[1])
+			}
+			if limits[0] > math.MaxInt32 || limits[1] > math.MaxInt32 {
+				return fmt.Errorf("group %s model %s [%d, %d] has max token rate limits value 2147483647", group, modelName, limits[0], limits[1])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package ratio_setting
+
+import "github.com/QuantumNous/new-api/setting/config"
+
+// BatchRatioSetting 批处理（/v1/batches）计费折扣
+// 最终额度 = 正常按量计费额度 * 批处理倍率
+type BatchRatioSetting struct {
+	DefaultRatio float64            `json:"default_ratio"` // 默认批处理倍率
+	ModelRatio   map[string]float64 `json:"model_ratio"`   // 按模型覆盖的批处理倍率
+}
+
+var batchRatioSetting = BatchRatioSetting{
+	DefaultRatio: 0.5,
+	ModelRatio:   map[string]float64{},
+}
+
+func init() {
+	config.GlobalConfig.Register("batch_ratio_setting", &batchRatioSetting)
+}
+
+func GetBatchRatioSetting() *BatchRatioSetting {
+	return &batchRatioSetting
+}
+
+// GetBatchRatio 获取模型的批处理倍率，未单独配置时使用默认倍率
+func GetBatchRatio(modelName string) float64 {
+	if ratio, ok := batchRatioSetting.ModelRatio[FormatMatchingModelName(modelName)]; ok && ratio >= 0 {
+		return ratio
+	}
+	if batchRatioSetting.DefaultRatio < 0 {
+		return 1
+	}
+	return batchRatioSetting.DefaultRatio
+}
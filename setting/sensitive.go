@@ -5,12 +5,14 @@ import "strings"
 var CheckSensitiveEnabled = true
 var CheckSensitiveOnPromptEnabled = true
 
-//var CheckSensitiveOnCompletionEnabled = true
+// CheckSensitiveOnCompletionEnabled 是否检查模型输出（流式与非流式）中的敏感词
+var CheckSensitiveOnCompletionEnabled = false
 
-// StopOnSensitiveEnabled 如果检测到敏感词，是否立刻停止生成，否则替换敏感词
+// StopOnSensitiveEnabled 输出中检测到敏感词时，是否立刻停止生成（流式输出以错误事件结束），否则替换敏感词
 var StopOnSensitiveEnabled = true
 
-// StreamCacheQueueLength 流模式缓存队列长度，0表示无缓存
+// StreamCacheQueueLength 流模式缓存队列长度，0表示无缓存。
+// 缓存的分片延后发送，跨分片的敏感词可以在发送前被整体替换或丢弃
 var StreamCacheQueueLength = 0
 
 // SensitiveWords 敏感词
@@ -38,6 +40,6 @@ func ShouldCheckPromptSensitive() bool {
 	return CheckSensitiveEnabled && CheckSensitiveOnPromptEnabled
 }
 
-//func ShouldCheckCompletionSensitive() bool {
-//	return CheckSensitiveEnabled && CheckSensitiveOnCompletionEnabled
-//}
+func ShouldCheckCompletionSensitive() bool {
+	return CheckSensitiveEnabled && CheckSensitiveOnCompletionEnabled
+}
@@ -23,6 +23,13 @@ type ClaudeError struct {
 	Message string `json:"message,omitempty"`
 }
 
+// GeminiError Gemini 原生错误格式（google.rpc.Status）
+type GeminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
 type ErrorType string
 
 const (
@@ -40,6 +47,7 @@ type ErrorCode string
 const (
 	ErrorCodeInvalidRequest         ErrorCode = "invalid_request"
 	ErrorCodeSensitiveWordsDetected ErrorCode = "sensitive_words_detected"
+	ErrorCodeContentModerationBlock ErrorCode = "content_moderation_blocked"
 	ErrorCodeViolationFeeGrokCSAM   ErrorCode = "violation_fee.grok.csam"
 
 	// new api error
@@ -53,6 +61,7 @@ const (
 
 	// channel error
 	ErrorCodeChannelNoAvailableKey        ErrorCode = "channel:no_available_key"
+	ErrorCodeChannelKeyRateLimited        ErrorCode = "channel:key_rate_limited"
 	ErrorCodeChannelParamOverrideInvalid  ErrorCode = "channel:param_override_invalid"
 	ErrorCodeChannelHeaderOverrideInvalid ErrorCode = "channel:header_override_invalid"
 	ErrorCodeChannelModelMappedError      ErrorCode = "channel:model_mapped_error"
@@ -74,6 +83,7 @@ const (
 	ErrorCodeBadResponse            ErrorCode = "bad_response"
 	ErrorCodeBadResponseBody        ErrorCode = "bad_response_body"
 	ErrorCodeEmptyResponse          ErrorCode = "empty_response"
+	ErrorCodeStreamInterrupted      ErrorCode = "stream_interrupted"
 	ErrorCodeAwsInvokeError         ErrorCode = "aws_invoke_error"
 	ErrorCodeModelNotFound          ErrorCode = "model_not_found"
 	ErrorCodePromptBlocked          ErrorCode = "prompt_blocked"
@@ -85,6 +95,10 @@ const (
 	// quota error
 	ErrorCodeInsufficientUserQuota      ErrorCode = "insufficient_user_quota"
 	ErrorCodePreConsumeTokenQuotaFailed ErrorCode = "pre_consume_token_quota_failed"
+	ErrorCodeBudgetExceeded             ErrorCode = "budget_exceeded"
+
+	// rate limit error
+	ErrorCodeRateLimitExceeded ErrorCode = "rate_limit_exceeded"
 )
 
 type NewAPIError struct {
@@ -239,6 +253,44 @@ func (e *NewAPIError) ToClaudeError() ClaudeError {
 	return result
 }
 
+// geminiErrorStatus HTTP 状态码对应的 google.rpc.Code 名称
+func geminiErrorStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusRequestEntityTooLarge:
+		return "INVALID_ARGUMENT"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return "INTERNAL"
+	}
+	return "UNKNOWN"
+}
+
+func (e *NewAPIError) ToGeminiError() GeminiError {
+	if geminiError, ok := e.RelayError.(GeminiError); ok && e.errorType == ErrorTypeGeminiError {
+		geminiError.Message = common.MaskSensitiveInfo(geminiError.Message)
+		return geminiError
+	}
+	return GeminiError{
+		Code:    e.StatusCode,
+		Message: e.ToOpenAIError().Message,
+		Status:  geminiErrorStatus(e.StatusCode),
+	}
+}
+
 type NewAPIErrorOptions func(*NewAPIError)
 
 func NewError(err error, errorCode ErrorCode, ops ...NewAPIErrorOptions) *NewAPIError {
@@ -363,6 +415,25 @@ func WithClaudeError(claudeError ClaudeError, statusCode int, ops ...NewAPIError
 	return e
 }
 
+// WithGeminiError 构造以 Gemini 原生格式返回给客户端的错误，Status 为空时按状态码推断
+func WithGeminiError(geminiError GeminiError, statusCode int, errorCode ErrorCode, ops ...NewAPIErrorOptions) *NewAPIError {
+	geminiError.Code = statusCode
+	if geminiError.Status == "" {
+		geminiError.Status = geminiErrorStatus(statusCode)
+	}
+	e := &NewAPIError{
+		RelayError: geminiError,
+		errorType:  ErrorTypeGeminiError,
+		StatusCode: statusCode,
+		Err:        errors.New(geminiError.Message),
+		errorCode:  errorCode,
+	}
+	for _, op := range ops {
+		op(e)
+	}
+	return e
+}
+
 func IsChannelError(err *NewAPIError) bool {
 	if err == nil {
 		return false
@@ -42,7 +42,8 @@ const SearchModal = ({
     <Component {...FORM_FIELD_PROPS} {...props} />
   );
 
-  const { start_timestamp, end_timestamp, username } = inputs;
+  const { start_timestamp, end_timestamp, username, organization_id } =
+    inputs;
 
   return (
     <Modal
@@ -95,6 +96,16 @@ const SearchModal = ({
             name: 'username',
             onChange: (value) => handleInputChange(value, 'username'),
           })}
+
+        {isAdminUser &&
+          createFormField(Form.Input, {
+            field: 'organization_id',
+            label: t('组织 ID'),
+            value: organization_id,
+            placeholder: t('可选值'),
+            name: 'organization_id',
+            onChange: (value) => handleInputChange(value, 'organization_id'),
+          })}
       </Form>
     </Modal>
   );
@@ -23,10 +23,22 @@ import SettingsGeneral from '../../pages/Setting/Operation/SettingsGeneral';
 import SettingsHeaderNavModules from '../../pages/Setting/Operation/SettingsHeaderNavModules';
 import SettingsSidebarModulesAdmin from '../../pages/Setting/Operation/SettingsSidebarModulesAdmin';
 import SettingsSensitiveWords from '../../pages/Setting/Operation/SettingsSensitiveWords';
+import SettingsModeration from '../../pages/Setting/Operation/SettingsModeration';
+import SettingsResponseCache from '../../pages/Setting/Operation/SettingsResponseCache';
+import SettingsSemanticCache from '../../pages/Setting/Operation/SettingsSemanticCache';
 import SettingsLog from '../../pages/Setting/Operation/SettingsLog';
 import SettingsMonitoring from '../../pages/Setting/Operation/SettingsMonitoring';
+import SettingsCircuitBreaker from '../../pages/Setting/Operation/SettingsCircuitBreaker';
+import SettingsRouting from '../../pages/Setting/Operation/SettingsRouting';
+import SettingsHedge from '../../pages/Setting/Operation/SettingsHedge';
+import SettingsStreamFailover from '../../pages/Setting/Operation/SettingsStreamFailover';
+import SettingsModelFallback from '../../pages/Setting/Operation/SettingsModelFallback';
 import SettingsCreditLimit from '../../pages/Setting/Operation/SettingsCreditLimit';
 import SettingsCheckin from '../../pages/Setting/Operation/SettingsCheckin';
+import SettingsBudget from '../../pages/Setting/Operation/SettingsBudget';
+import SettingsTaskAsset from '../../pages/Setting/Operation/SettingsTaskAsset';
+import SettingsInvoice from '../../pages/Setting/Operation/SettingsInvoice';
+import SettingsUsageReport from '../../pages/Setting/Operation/SettingsUsageReport';
 import { API, showError, toBoolean } from '../../helpers';
 
 const OperationSetting = () => {
@@ -59,8 +71,43 @@ const OperationSetting = () => {
     /* 敏感词设置 */
     CheckSensitiveEnabled: false,
     CheckSensitiveOnPromptEnabled: false,
+    CheckSensitiveOnCompletionEnabled: false,
+    StopOnSensitiveEnabled: true,
+    StreamCacheQueueLength: 0,
     SensitiveWords: '',
 
+    /* 内容审核设置 */
+    'moderation_setting.enabled': false,
+    'moderation_setting.provider': 'model',
+    'moderation_setting.model': 'omni-moderation-latest',
+    'moderation_setting.group': '',
+    'moderation_setting.webhook_url': '',
+    'moderation_setting.webhook_secret': '',
+    'moderation_setting.timeout_seconds': 10,
+    'moderation_setting.fail_open': true,
+    'moderation_setting.default_action': 'flag',
+    'moderation_setting.group_actions': '{}',
+
+    /* 响应缓存设置 */
+    'response_cache_setting.enabled': false,
+    'response_cache_setting.deterministic_only': true,
+    'response_cache_setting.ttl_seconds': 3600,
+    'response_cache_setting.billing_ratio': 0,
+    'response_cache_setting.max_entry_kb': 1024,
+
+    /* 语义缓存设置 */
+    'semantic_cache_setting.enabled': false,
+    'semantic_cache_setting.embedding_model': 'text-embedding-3-small',
+    'semantic_cache_setting.embedding_group': '',
+    'semantic_cache_setting.shared_groups': '',
+    'semantic_cache_setting.timeout_seconds': 10,
+    'semantic_cache_setting.default_threshold': 0.95,
+    'semantic_cache_setting.model_thresholds': '{}',
+    'semantic_cache_setting.ttl_seconds': 86400,
+    'semantic_cache_setting.max_entries': 10000,
+    'semantic_cache_setting.max_entry_kb': 48,
+    'semantic_cache_setting.billing_ratio': 0,
+
     /* 日志设置 */
     LogConsumeEnabled: false,
 
@@ -81,6 +128,60 @@ const OperationSetting = () => {
 
     /* 令牌设置 */
     'token_setting.max_user_tokens': 1000,
+
+    /* 周期预算设置 */
+    'budget_setting.enabled': true,
+    'budget_setting.timezone': '',
+    'budget_setting.default_soft_percent': 80,
+    'circuit_breaker_setting.enabled': true,
+    'circuit_breaker_setting.window_seconds': 60,
+    'circuit_breaker_setting.min_requests': 10,
+    'circuit_breaker_setting.failure_rate_percent': 50,
+    'circuit_breaker_setting.slow_call_ms': 60000,
+    'circuit_breaker_setting.slow_call_rate_percent': 80,
+    'circuit_breaker_setting.open_seconds': 30,
+    'circuit_breaker_setting.probe_interval_seconds': 5,
+    'circuit_breaker_setting.half_open_successes': 3,
+    'routing_setting.default_strategy': 'weighted_random',
+    'routing_setting.group_strategies': '{}',
+    'routing_setting.model_strategies': '{}',
+    'hedge_setting.enabled': false,
+    'hedge_setting.group_delays': '{}',
+    'hedge_setting.model_delays': '{}',
+    'hedge_setting.bill_loser': false,
+    'stream_failover_setting.enabled': false,
+    'stream_failover_setting.max_failovers': 1,
+    'stream_failover_setting.models': '[]',
+    'model_fallback_setting.enabled': false,
+    'model_fallback_setting.chains': '{}',
+
+    /* 生成结果转存设置 */
+    'asset_setting.enabled': false,
+    'asset_setting.storage_mode': 'local',
+    'asset_setting.s3_endpoint': '',
+    'asset_setting.s3_region': 'us-east-1',
+    'asset_setting.s3_bucket': '',
+    'asset_setting.s3_access_key_id': '',
+    'asset_setting.s3_path_style': true,
+    'asset_setting.retention_days': 7,
+    'asset_setting.max_file_size_mb': 512,
+    'asset_setting.max_storage_mb_per_user': 0,
+    'asset_setting.signed_url_ttl_minutes': 60,
+
+    /* 发票与账单设置 */
+    'invoice_setting.enabled': false,
+    'invoice_setting.seller_name': '',
+    'invoice_setting.seller_address': '',
+    'invoice_setting.seller_tax_id': '',
+    'invoice_setting.seller_email': '',
+    'invoice_setting.number_prefix': 'INV',
+    'invoice_setting.currency': 'CNY',
+    'invoice_setting.footer': '',
+    'invoice_setting.timezone': '',
+
+    /* 用量报告设置 */
+    'usage_report_setting.enabled': false,
+    'usage_report_setting.timezone': '',
   });
 
   let [loading, setLoading] = useState(false);
@@ -138,6 +239,18 @@ const OperationSetting = () => {
         <Card style={{ marginTop: '10px' }}>
           <SettingsSensitiveWords options={inputs} refresh={onRefresh} />
         </Card>
+        {/* 内容审核设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsModeration options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 响应缓存设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsResponseCache options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 语义缓存设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsSemanticCache options={inputs} refresh={onRefresh} />
+        </Card>
         {/* 日志设置 */}
         <Card style={{ marginTop: '10px' }}>
           <SettingsLog options={inputs} refresh={onRefresh} />
@@ -146,6 +259,26 @@ const OperationSetting = () => {
         <Card style={{ marginTop: '10px' }}>
           <SettingsMonitoring options={inputs} refresh={onRefresh} />
         </Card>
+        {/* 渠道熔断设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsCircuitBreaker options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 渠道路由设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsRouting options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 对冲请求设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsHedge options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 流式续写设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsStreamFailover options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 模型降级设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsModelFallback options={inputs} refresh={onRefresh} />
+        </Card>
         {/* 额度设置 */}
         <Card style={{ marginTop: '10px' }}>
           <SettingsCreditLimit options={inputs} refresh={onRefresh} />
@@ -154,6 +287,22 @@ const OperationSetting = () => {
         <Card style={{ marginTop: '10px' }}>
           <SettingsCheckin options={inputs} refresh={onRefresh} />
         </Card>
+        {/* 周期预算设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsBudget options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 生成结果转存设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsTaskAsset options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 发票与账单设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsInvoice options={inputs} refresh={onRefresh} />
+        </Card>
+        {/* 用量报告设置 */}
+        <Card style={{ marginTop: '10px' }}>
+          <SettingsUsageReport options={inputs} refresh={onRefresh} />
+        </Card>
       </Spin>
     </>
   );
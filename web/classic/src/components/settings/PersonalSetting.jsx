@@ -40,6 +40,7 @@ import UserInfoHeader from './personal/components/UserInfoHeader';
 import AccountManagement from './personal/cards/AccountManagement';
 import NotificationSettings from './personal/cards/NotificationSettings';
 import PreferencesSettings from './personal/cards/PreferencesSettings';
+import BillingProfileSettings from './personal/cards/BillingProfileSettings';
 import CheckinCalendar from './personal/cards/CheckinCalendar';
 import EmailBindModal from './personal/modals/EmailBindModal';
 import WeChatBindModal from './personal/modals/WeChatBindModal';
@@ -95,6 +96,7 @@ const PersonalSetting = () => {
     upstreamModelUpdateNotifyEnabled: false,
     acceptUnsetModelRatioModel: false,
     recordIpLog: false,
+    usageReportFrequency: '',
   });
 
   const {
@@ -198,6 +200,7 @@ const PersonalSetting = () => {
         acceptUnsetModelRatioModel:
           settings.accept_unset_model_ratio_model || false,
         recordIpLog: settings.record_ip_log || false,
+        usageReportFrequency: settings.usage_report_frequency || '',
       });
     }
   }, [userState?.user?.setting]);
@@ -528,6 +531,7 @@ const PersonalSetting = () => {
         accept_unset_model_ratio_model:
           notificationSettings.acceptUnsetModelRatioModel,
         record_ip_log: notificationSettings.recordIpLog,
+        usage_report_frequency: notificationSettings.usageReportFrequency,
       });
 
       if (res.data.success) {
@@ -585,6 +589,9 @@ const PersonalSetting = () => {
 
               {/* 偏好设置（语言等） */}
               <PreferencesSettings t={t} />
+
+              {/* 发票抬头 - 仅在启用发票时显示 */}
+              {status?.invoice_enabled && <BillingProfileSettings t={t} />}
             </div>
 
             {/* 右侧：其他设置 */}
@@ -23,6 +23,7 @@ import { Card, Spin } from '@douyinfe/semi-ui';
 import { API, showError, toBoolean } from '../../helpers';
 import { useTranslation } from 'react-i18next';
 import RequestRateLimit from '../../pages/Setting/RateLimit/SettingsRequestRateLimit';
+import TokenRateLimit from '../../pages/Setting/RateLimit/SettingsTokenRateLimit';
 
 const RateLimitSetting = () => {
   const { t } = useTranslation();
@@ -32,6 +33,8 @@ const RateLimitSetting = () => {
     ModelRequestRateLimitSuccessCount: 1000,
     ModelRequestRateLimitDurationMinutes: 1,
     ModelRequestRateLimitGroup: '',
+    ModelTokenRateLimitEnabled: false,
+    ModelTokenRateLimitGroup: '',
   });
 
   let [loading, setLoading] = useState(false);
@@ -42,7 +45,10 @@ const RateLimitSetting = () => {
     if (success) {
       let newInputs = {};
       data.forEach((item) => {
-        if (item.key === 'ModelRequestRateLimitGroup') {
+        if (
+          item.key === 'ModelRequestRateLimitGroup' ||
+          item.key === 'ModelTokenRateLimitGroup'
+        ) {
           item.value = JSON.stringify(JSON.parse(item.value), null, 2);
         }
 
@@ -81,6 +87,9 @@ const RateLimitSetting = () => {
         <Card style={{ marginTop: '10px' }}>
           <RequestRateLimit options={inputs} refresh={onRefresh} />
         </Card>
+        <Card style={{ marginTop: '10px' }}>
+          <TokenRateLimit options={inputs} refresh={onRefresh} />
+        </Card>
       </Spin>
     </>
   );
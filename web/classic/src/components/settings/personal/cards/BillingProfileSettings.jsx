@@ -0,0 +1,106 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import React, { useContext, useEffect, useState } from 'react';
+import { Avatar, Button, Card, Input, Typography } from '@douyinfe/semi-ui';
+import { Receipt } from 'lucide-react';
+import { API, showError, showSuccess } from '../../../../helpers';
+import { UserContext } from '../../../../context/User';
+
+const BillingProfileSettings = ({ t }) => {
+  const [userState, userDispatch] = useContext(UserContext);
+  const [companyName, setCompanyName] = useState('');
+  const [taxId, setTaxId] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    setCompanyName(userState?.user?.company_name || '');
+    setTaxId(userState?.user?.tax_id || '');
+  }, [userState?.user?.company_name, userState?.user?.tax_id]);
+
+  const saveBillingProfile = async () => {
+    setLoading(true);
+    try {
+      const res = await API.put('/api/user/billing_profile', {
+        company_name: companyName,
+        tax_id: taxId,
+      });
+      const { success, message, data } = res.data;
+      if (success) {
+        showSuccess(t('发票抬头已保存'));
+        userDispatch({
+          type: 'login',
+          payload: { ...userState.user, ...data },
+        });
+      } else {
+        showError(message);
+      }
+    } catch (error) {
+      showError(t('保存失败，请重试'));
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <Card className='!rounded-2xl shadow-sm border-0'>
+      <div className='flex items-center mb-4'>
+        <Avatar size='small' color='orange' className='mr-3 shadow-md'>
+          <Receipt size={16} />
+        </Avatar>
+        <div>
+          <Typography.Text className='text-lg font-medium'>
+            {t('发票抬头')}
+          </Typography.Text>
+          <div className='text-xs text-gray-600 dark:text-gray-400'>
+            {t('显示在充值发票与月度账单上，仅对之后开具的发票生效')}
+          </div>
+        </div>
+      </div>
+      <div className='flex flex-col gap-3'>
+        <div>
+          <Typography.Text strong>{t('公司名称')}</Typography.Text>
+          <Input
+            className='mt-1'
+            value={companyName}
+            onChange={setCompanyName}
+            maxLength={128}
+            placeholder={t('留空时使用用户名')}
+          />
+        </div>
+        <div>
+          <Typography.Text strong>{t('税号')}</Typography.Text>
+          <Input
+            className='mt-1'
+            value={taxId}
+            onChange={setTaxId}
+            maxLength={64}
+            placeholder={t('纳税人识别号 / VAT ID')}
+          />
+        </div>
+        <div className='flex justify-end'>
+          <Button type='primary' loading={loading} onClick={saveBillingProfile}>
+            {t('保存')}
+          </Button>
+        </div>
+      </div>
+    </Card>
+  );
+};
+
+export default BillingProfileSettings;
@@ -486,6 +486,24 @@ const NotificationSettings = ({
                   />
                 )}
 
+                {statusState?.status?.usage_report_enabled && (
+                  <Form.RadioGroup
+                    field='usageReportFrequency'
+                    label={t('用量报告邮件')}
+                    initValue={notificationSettings.usageReportFrequency}
+                    onChange={(e) =>
+                      handleFormChange('usageReportFrequency', e.target.value)
+                    }
+                    extraText={t(
+                      '按模型与令牌汇总上一自然周或自然月的用量，发送到通知邮箱（未设置时使用账号绑定的邮箱），与通知方式无关；周期内无消费时不发送',
+                    )}
+                  >
+                    <Radio value=''>{t('不发送')}</Radio>
+                    <Radio value='weekly'>{t('每周')}</Radio>
+                    <Radio value='monthly'>{t('每月')}</Radio>
+                  </Form.RadioGroup>
+                )}
+
                 {/* 邮件通知设置 */}
                 {notificationSettings.warningType === 'email' && (
                   <Form.Input
@@ -927,6 +927,12 @@ const EditChannelModal = (props) => {
           )
             ? parsedSettings.upstream_model_update_ignored_models.join(',')
             : '';
+          data.multi_key_rpm_limit =
+            Number(parsedSettings.multi_key_rpm_limit) || 0;
+          data.multi_key_tpm_limit =
+            Number(parsedSettings.multi_key_tpm_limit) || 0;
+          data.multi_key_cooldown_seconds =
+            Number(parsedSettings.multi_key_cooldown_seconds) || 0;
         } catch (error) {
           console.error('解析其他设置失败:', error);
           data.azure_responses_version = '';
@@ -946,6 +952,9 @@ const EditChannelModal = (props) => {
           data.upstream_model_update_last_check_time = 0;
           data.upstream_model_update_last_detected_models = [];
           data.upstream_model_update_ignored_models = '';
+          data.multi_key_rpm_limit = 0;
+          data.multi_key_tpm_limit = 0;
+          data.multi_key_cooldown_seconds = 0;
         }
       } else {
         // 兼容历史数据：老渠道没有 settings 时，默认按 json 展示
@@ -964,6 +973,9 @@ const EditChannelModal = (props) => {
         data.upstream_model_update_last_check_time = 0;
         data.upstream_model_update_last_detected_models = [];
         data.upstream_model_update_ignored_models = '';
+        data.multi_key_rpm_limit = 0;
+        data.multi_key_tpm_limit = 0;
+        data.multi_key_cooldown_seconds = 0;
       }
 
       if (
@@ -1853,6 +1865,10 @@ const EditChannelModal = (props) => {
     delete localInputs.upstream_model_update_last_check_time;
     delete localInputs.upstream_model_update_last_detected_models;
     delete localInputs.upstream_model_update_ignored_models;
+    // 单密钥限额已在 handleChannelOtherSettingsChange 中写入 settings
+    delete localInputs.multi_key_rpm_limit;
+    delete localInputs.multi_key_tpm_limit;
+    delete localInputs.multi_key_cooldown_seconds;
 
     let res;
     localInputs.auto_ban = localInputs.auto_ban ? 1 : 0;
@@ -3138,6 +3154,8 @@ const EditChannelModal = (props) => {
                           optionList={[
                             { label: t('随机'), value: 'random' },
                             { label: t('轮询'), value: 'polling' },
+                            { label: t('最少使用'), value: 'least-used' },
+                            { label: t('加权'), value: 'weighted' },
                           ]}
                           style={{ width: '100%' }}
                           value={inputs.multi_key_mode || 'random'}
@@ -3155,6 +3173,64 @@ const EditChannelModal = (props) => {
                             className='!rounded-lg mt-2'
                           />
                         )}
+                        {inputs.multi_key_mode === 'weighted' && (
+                          <Banner
+                            type='info'
+                            description={t(
+                              '加权模式按密钥权重随机选择，可在多密钥管理中设置每个密钥的权重',
+                            )}
+                            className='!rounded-lg mt-2'
+                          />
+                        )}
+                        <Row gutter={12}>
+                          <Col span={8}>
+                            <Form.InputNumber
+                              field='multi_key_rpm_limit'
+                              label={t('单密钥 RPM 上限')}
+                              min={0}
+                              onNumberChange={(value) =>
+                                handleChannelOtherSettingsChange(
+                                  'multi_key_rpm_limit',
+                                  value || 0,
+                                )
+                              }
+                              style={{ width: '100%' }}
+                            />
+                          </Col>
+                          <Col span={8}>
+                            <Form.InputNumber
+                              field='multi_key_tpm_limit'
+                              label={t('单密钥 TPM 上限')}
+                              min={0}
+                              onNumberChange={(value) =>
+                                handleChannelOtherSettingsChange(
+                                  'multi_key_tpm_limit',
+                                  value || 0,
+                                )
+                              }
+                              style={{ width: '100%' }}
+                            />
+                          </Col>
+                          <Col span={8}>
+                            <Form.InputNumber
+                              field='multi_key_cooldown_seconds'
+                              label={t('密钥冷却时间（秒）')}
+                              min={0}
+                              onNumberChange={(value) =>
+                                handleChannelOtherSettingsChange(
+                                  'multi_key_cooldown_seconds',
+                                  value || 0,
+                                )
+                              }
+                              style={{ width: '100%' }}
+                            />
+                          </Col>
+                        </Row>
+                        <Text type='tertiary' size='small'>
+                          {t(
+                            '最近一分钟超过 RPM/TPM 上限的密钥会被跳过；自动禁用的密钥在冷却时间后自动恢复启用。填 0 表示不限制或不自动恢复。',
+                          )}
+                        </Text>
                       </>
                     )}
 
@@ -31,6 +31,7 @@ import {
   Empty,
   Spin,
   Select,
+  InputNumber,
   Row,
   Col,
   Badge,
@@ -143,6 +144,32 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
     }
   };
 
+  // Set weight of a specific key (weighted mode)
+  const handleSetKeyWeight = async (keyIndex, weight) => {
+    const operationId = `weight_${keyIndex}`;
+    setOperationLoading((prev) => ({ ...prev, [operationId]: true }));
+
+    try {
+      const res = await API.post('/api/channel/multi_key/manage', {
+        channel_id: channel.id,
+        action: 'set_key_weight',
+        key_index: keyIndex,
+        weight,
+      });
+
+      if (res.data.success) {
+        showSuccess(t('密钥权重已更新'));
+        await loadKeyStatus(currentPage, pageSize); // Reload current page
+      } else {
+        showError(res.data.message);
+      }
+    } catch (error) {
+      showError(t('更新密钥权重失败'));
+    } finally {
+      setOperationLoading((prev) => ({ ...prev, [operationId]: false }));
+    }
+  };
+
   // Enable a specific key
   const handleEnableKey = async (keyIndex) => {
     const operationId = `enable_${keyIndex}`;
@@ -355,6 +382,14 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
     }
   };
 
+  const multiKeyMode = channel?.channel_info?.multi_key_mode;
+  const multiKeyModeLabels = {
+    random: t('随机模式'),
+    polling: t('轮询模式'),
+    'least-used': t('最少使用模式'),
+    weighted: t('加权模式'),
+  };
+
   // Table columns definition
   const columns = [
     {
@@ -406,6 +441,60 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
         );
       },
     },
+    {
+      title: t('用量'),
+      dataIndex: 'request_count',
+      render: (_, record) => (
+        <Tooltip
+          content={`${t('最近一分钟')}: ${record.minute_requests || 0} ${t(
+            '次请求',
+          )} / ${record.minute_tokens || 0} tokens`}
+        >
+          <Text style={{ fontSize: '12px' }}>
+            {record.request_count || 0} {t('次请求')} /{' '}
+            {record.token_count || 0} tokens
+          </Text>
+        </Tooltip>
+      ),
+    },
+    {
+      title: t('最后使用时间'),
+      dataIndex: 'last_used_time',
+      render: (time) => {
+        if (!time) {
+          return <Text type='quaternary'>-</Text>;
+        }
+        return (
+          <Text style={{ fontSize: '12px' }}>{timestamp2string(time)}</Text>
+        );
+      },
+    },
+    ...(multiKeyMode === 'weighted'
+      ? [
+          {
+            title: t('权重'),
+            dataIndex: 'weight',
+            width: 110,
+            render: (weight, record) => (
+              <InputNumber
+                size='small'
+                min={0}
+                precision={0}
+                defaultValue={weight ?? 1}
+                disabled={operationLoading[`weight_${record.index}`]}
+                onBlur={(e) => {
+                  const value = parseInt(e.target.value, 10);
+                  if (Number.isNaN(value) || value === (weight ?? 1)) {
+                    return;
+                  }
+                  handleSetKeyWeight(record.index, value);
+                }}
+                style={{ width: 80 }}
+              />
+            ),
+          },
+        ]
+      : []),
     {
       title: t('操作'),
       key: 'action',
@@ -465,18 +554,16 @@ const MultiKeyManageModal = ({ visible, onCancel, channel, onRefresh }) => {
           <Tag size='small' shape='circle' color='white'>
             {t('总密钥数')}: {total}
           </Tag>
-          {channel?.channel_info?.multi_key_mode && (
+          {multiKeyMode && (
             <Tag size='small' shape='circle' color='white'>
-              {channel.channel_info.multi_key_mode === 'random'
-                ? t('随机模式')
-                : t('轮询模式')}
+              {multiKeyModeLabels[multiKeyMode] || multiKeyMode}
             </Tag>
           )}
         </Space>
       }
       visible={visible}
       onCancel={onCancel}
-      width={900}
+      width={1100}
       footer={null}
     >
       <div className='flex flex-col mb-5'>
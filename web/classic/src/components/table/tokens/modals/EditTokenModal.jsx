@@ -27,6 +27,7 @@ import {
   getCurrencyConfig,
   getModelCategories,
   selectFilter,
+  verifyJSON,
 } from '../../../../helpers';
 import {
   quotaToDisplayAmount,
@@ -67,6 +68,7 @@ const EditTokenModal = (props) => {
   const formApiRef = useRef(null);
   const [models, setModels] = useState([]);
   const [groups, setGroups] = useState([]);
+  const [organization, setOrganization] = useState(null);
   const [showQuotaInput, setShowQuotaInput] = useState(false);
   const isEdit = props.editingToken.id !== undefined;
 
@@ -81,6 +83,13 @@ const EditTokenModal = (props) => {
     allow_ips: '',
     group: statusState?.status?.default_use_auto_group ? 'auto' : '',
     cross_group_retry: false,
+    rpm_limit: 0,
+    tpm_limit: 0,
+    concurrency_limit: 0,
+    semantic_cache_disabled: false,
+    model_fallbacks: '',
+    task_callback_url: '',
+    organization_id: 0,
     tokenCount: 1,
   });
 
@@ -160,6 +169,14 @@ const EditTokenModal = (props) => {
     }
   };
 
+  const loadOrganization = async () => {
+    let res = await API.get(`/api/organization/self`);
+    const { success, data } = res.data;
+    if (success) {
+      setOrganization(data?.organization || null);
+    }
+  };
+
   const loadToken = async () => {
     setLoading(true);
     let res = await API.get(`/api/token/${props.editingToken.id}`);
@@ -193,6 +210,7 @@ const EditTokenModal = (props) => {
     }
     loadModels();
     loadGroups();
+    loadOrganization();
   }, [props.editingToken.id]);
 
   useEffect(() => {
@@ -644,6 +662,92 @@ const EditTokenModal = (props) => {
                       style={{ width: '100%' }}
                     />
                   </Col>
+                  <Col span={8}>
+                    <Form.InputNumber
+                      field='rpm_limit'
+                      label={t('每分钟请求数 (RPM)')}
+                      min={0}
+                      precision={0}
+                      extraText={t('0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  <Col span={8}>
+                    <Form.InputNumber
+                      field='tpm_limit'
+                      label={t('每分钟 Token 数 (TPM)')}
+                      min={0}
+                      precision={0}
+                      extraText={t('0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  <Col span={8}>
+                    <Form.InputNumber
+                      field='concurrency_limit'
+                      label={t('最大并发请求数')}
+                      min={0}
+                      precision={0}
+                      extraText={t('0 表示不限制')}
+                      style={{ width: '100%' }}
+                    />
+                  </Col>
+                  {organization && (
+                    <Col span={24}>
+                      <Form.Select
+                        field='organization_id'
+                        label={t('计费钱包')}
+                        optionList={[
+                          { label: t('个人钱包'), value: 0 },
+                          {
+                            label: `${t('组织钱包')}: ${organization.name}`,
+                            value: organization.id,
+                          },
+                        ]}
+                        extraText={t(
+                          '选择组织钱包后，该令牌的消费从组织共享钱包扣除；退出组织后该令牌将无法使用',
+                        )}
+                        style={{ width: '100%' }}
+                      />
+                    </Col>
+                  )}
+                  <Col span={24}>
+                    <Form.Switch
+                      field='semantic_cache_disabled'
+                      label={t('不使用语义缓存')}
+                      size='default'
+                      extraText={t('开启后，该令牌的请求不会读取或写入语义缓存')}
+                    />
+                  </Col>
+                  <Col span={24}>
+                    <Form.TextArea
+                      field='model_fallbacks'
+                      label={t('模型降级链')}
+                      placeholder={'{\n  "gpt-4o": ["gpt-4.1"]\n}'}
+                      autosize={{ minRows: 2, maxRows: 8 }}
+                      extraText={t(
+                        '按模型覆盖全局降级链，留空数组表示该模型不降级',
+                      )}
+                      rules={[
+                        {
+                          validator: (rule, value) =>
+                            !value || verifyJSON(value),
+                          message: t('不是合法的 JSON 字符串'),
+                        },
+                      ]}
+                    />
+                  </Col>
+                  <Col span={24}>
+                    <Form.Input
+                      field='task_callback_url'
+                      label={t('任务完成回调地址')}
+                      placeholder='https://example.com/callback'
+                      showClear
+                      extraText={t(
+                        '视频、音乐等异步任务完成后推送结果，请求中的 callback_url 优先；使用个人设置中的 Webhook 密钥签名',
+                      )}
+                    />
+                  </Col>
                 </Row>
               </Card>
             </div>
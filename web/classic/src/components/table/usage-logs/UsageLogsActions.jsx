@@ -18,7 +18,8 @@ For commercial licensing, please contact support@quantumnous.com
 */
 
 import React from 'react';
-import { Tag, Space, Skeleton } from '@douyinfe/semi-ui';
+import { Tag, Space, Skeleton, Button, Dropdown } from '@douyinfe/semi-ui';
+import { IconDownload } from '@douyinfe/semi-icons';
 import { renderQuota } from '../../../helpers';
 import CompactModeToggle from '../../common/ui/CompactModeToggle';
 import { useMinimumLoadingTime } from '../../../hooks/common/useMinimumLoadingTime';
@@ -29,6 +30,8 @@ const LogsActions = ({
   showStat,
   compactMode,
   setCompactMode,
+  exporting,
+  exportLogs,
   t,
 }) => {
   const showSkeleton = useMinimumLoadingTime(loadingStat);
@@ -83,11 +86,36 @@ const LogsActions = ({
         </Space>
       </Skeleton>
 
-      <CompactModeToggle
-        compactMode={compactMode}
-        setCompactMode={setCompactMode}
-        t={t}
-      />
+      <Space>
+        <Dropdown
+          trigger='click'
+          position='bottomRight'
+          render={
+            <Dropdown.Menu>
+              <Dropdown.Item onClick={() => exportLogs('logs', 'csv')}>
+                {t('导出日志明细')} (CSV)
+              </Dropdown.Item>
+              <Dropdown.Item onClick={() => exportLogs('usage', 'csv')}>
+                {t('导出每小时用量汇总')} (CSV)
+              </Dropdown.Item>
+            </Dropdown.Menu>
+          }
+        >
+          <Button
+            type='tertiary'
+            size='small'
+            icon={<IconDownload />}
+            loading={exporting}
+          >
+            {t('导出')}
+          </Button>
+        </Dropdown>
+        <CompactModeToggle
+          compactMode={compactMode}
+          setCompactMode={setCompactMode}
+          t={t}
+        />
+      </Space>
     </div>
   );
 };
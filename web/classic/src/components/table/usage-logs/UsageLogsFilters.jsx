@@ -120,6 +120,14 @@ const LogsFilters = ({
                 pure
                 size='small'
               />
+              <Form.Input
+                field='organization_id'
+                prefix={<IconSearch />}
+                placeholder={t('组织 ID')}
+                showClear
+                pure
+                size='small'
+              />
             </>
           )}
         </div>
@@ -16,7 +16,7 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 For commercial licensing, please contact support@quantumnous.com
 */
-import React, { useState, useEffect, useMemo } from 'react';
+import React, { useState, useEffect, useMemo, useContext } from 'react';
 import {
   Modal,
   Table,
@@ -27,6 +27,7 @@ import {
   Button,
   Input,
   Tag,
+  DatePicker,
 } from '@douyinfe/semi-ui';
 import {
   IllustrationNoResult,
@@ -34,9 +35,10 @@ import {
 } from '@douyinfe/semi-illustrations';
 import { Coins } from 'lucide-react';
 import { IconSearch } from '@douyinfe/semi-icons';
-import { API, timestamp2string } from '../../../helpers';
+import { API, downloadApiFile, timestamp2string } from '../../../helpers';
 import { isAdmin } from '../../../helpers/utils';
 import { useIsMobile } from '../../../hooks/common/useIsMobile';
+import { StatusContext } from '../../../context/Status';
 const { Text } = Typography;
 
 // 状态映射配置
@@ -63,7 +65,10 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
   const [page, setPage] = useState(1);
   const [pageSize, setPageSize] = useState(10);
   const [keyword, setKeyword] = useState('');
+  const [statementMonth, setStatementMonth] = useState(new Date());
   const isMobile = useIsMobile();
+  const [statusState] = useContext(StatusContext);
+  const invoiceEnabled = !!statusState?.status?.invoice_enabled;
 
   const loadTopups = async (currentPage, currentPageSize) => {
     setLoading(true);
@@ -126,6 +131,32 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
     }
   };
 
+  // 下载发票（可打印 HTML）
+  const handleDownloadInvoice = async (record) => {
+    try {
+      await downloadApiFile(
+        `/api/user/topup/${record.id}/invoice`,
+        `invoice-${record.trade_no}.html`,
+      );
+    } catch (e) {
+      Toast.error({ content: e.message || t('下载失败') });
+    }
+  };
+
+  // 下载月度账单
+  const handleDownloadStatement = async (format) => {
+    const date = statementMonth || new Date();
+    const month = `${date.getFullYear()}-${String(date.getMonth() + 1).padStart(2, '0')}`;
+    try {
+      await downloadApiFile(
+        `/api/user/statement?month=${month}&format=${format}`,
+        `statement-${month}.${format}`,
+      );
+    } catch (e) {
+      Toast.error({ content: e.message || t('下载失败') });
+    }
+  };
+
   const confirmAdminComplete = (tradeNo) => {
     Modal.confirm({
       title: t('确认补单'),
@@ -217,14 +248,14 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
       },
     ];
 
-    // 管理员才显示操作列
-    if (userIsAdmin) {
+    // 管理员或启用发票时显示操作列
+    if (userIsAdmin || invoiceEnabled) {
       baseColumns.push({
         title: t('操作'),
         key: 'action',
         render: (_, record) => {
           const actions = [];
-          if (record.status === 'pending') {
+          if (userIsAdmin && record.status === 'pending') {
             actions.push(
               <Button
                 key="complete"
@@ -237,6 +268,18 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
               </Button>
             );
           }
+          if (record.status === 'success') {
+            actions.push(
+              <Button
+                key='invoice'
+                size='small'
+                theme='borderless'
+                onClick={() => handleDownloadInvoice(record)}
+              >
+                {t('发票')}
+              </Button>,
+            );
+          }
           return actions.length > 0 ? <>{actions}</> : null;
         },
       });
@@ -250,7 +293,7 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
     });
 
     return baseColumns;
-  }, [t, userIsAdmin]);
+  }, [t, userIsAdmin, invoiceEnabled]);
 
   return (
     <Modal
@@ -260,6 +303,23 @@ const TopupHistoryModal = ({ visible, onCancel, t }) => {
       footer={null}
       size={isMobile ? 'full-width' : 'large'}
     >
+      {invoiceEnabled && !userIsAdmin && (
+        <div className='mb-3 flex flex-wrap items-center gap-2'>
+          <Text>{t('月度账单')}</Text>
+          <DatePicker
+            type='month'
+            value={statementMonth}
+            onChange={(date) => setStatementMonth(date)}
+            style={{ width: 140 }}
+          />
+          <Button size='small' onClick={() => handleDownloadStatement('html')}>
+            {t('下载账单')}
+          </Button>
+          <Button size='small' onClick={() => handleDownloadStatement('csv')}>
+            CSV
+          </Button>
+        </div>
+      )}
       <div className='mb-3'>
         <Input
           prefix={<IconSearch />}
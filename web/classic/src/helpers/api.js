@@ -367,6 +367,27 @@ export async function onCustomOAuthClicked(provider, options = {}) {
   }
 }
 
+// 以登录态下载接口返回的文件（发票、账单等），接口返回 JSON 时视为错误并抛出其中的 message
+export async function downloadApiFile(url, fallbackFilename) {
+  const res = await API.get(url, {
+    responseType: 'blob',
+    disableDuplicate: true,
+  });
+  const contentType = res.headers['content-type'] || '';
+  if (contentType.includes('application/json')) {
+    const { message } = JSON.parse(await res.data.text());
+    throw new Error(message);
+  }
+  const disposition = res.headers['content-disposition'] || '';
+  const matched = disposition.match(/filename="?([^";]+)"?/);
+  const objectUrl = URL.createObjectURL(res.data);
+  const a = document.createElement('a');
+  a.href = objectUrl;
+  a.download = matched ? matched[1] : fallbackFilename;
+  a.click();
+  setTimeout(() => URL.revokeObjectURL(objectUrl), 1000);
+}
+
 let channelModels = undefined;
 export async function loadChannelModels() {
   const res = await API.get('/api/models');
@@ -22,6 +22,7 @@ import { useTranslation } from 'react-i18next';
 import { Modal } from '@douyinfe/semi-ui';
 import {
   API,
+  downloadApiFile,
   getTodayStartTimestamp,
   isAdmin,
   showError,
@@ -69,6 +70,7 @@ export const useLogsData = () => {
   const [expandData, setExpandData] = useState({});
   const [showStat, setShowStat] = useState(false);
   const [loading, setLoading] = useState(false);
+  const [exporting, setExporting] = useState(false);
   const [loadingStat, setLoadingStat] = useState(false);
   const [activePage, setActivePage] = useState(1);
   const [logCount, setLogCount] = useState(0);
@@ -99,6 +101,7 @@ export const useLogsData = () => {
     token_name: '',
     model_name: '',
     channel: '',
+    organization_id: '',
     group: '',
     request_id: '',
     dateRange: [
@@ -255,6 +258,7 @@ export const useLogsData = () => {
       start_timestamp,
       end_timestamp,
       channel: formValues.channel || '',
+      organization_id: formValues.organization_id || '',
       group: formValues.group || '',
       request_id: formValues.request_id || '',
       logType: formValues.logType ? parseInt(formValues.logType) : 0,
@@ -293,13 +297,14 @@ export const useLogsData = () => {
       start_timestamp,
       end_timestamp,
       channel,
+      organization_id,
       group,
       logType: formLogType,
     } = getFormValues();
     const currentLogType = formLogType !== undefined ? formLogType : logType;
     let localStartTimestamp = Date.parse(start_timestamp) / 1000;
     let localEndTimestamp = Date.parse(end_timestamp) / 1000;
-    let url = `/api/log/stat?type=${currentLogType}&username=${username}&token_name=${token_name}&model_name=${model_name}&start_timestamp=${localStartTimestamp}&end_timestamp=${localEndTimestamp}&channel=${channel}&group=${group}`;
+    let url = `/api/log/stat?type=${currentLogType}&username=${username}&token_name=${token_name}&model_name=${model_name}&start_timestamp=${localStartTimestamp}&end_timestamp=${localEndTimestamp}&channel=${channel}&organization_id=${organization_id}&group=${group}`;
     url = encodeURI(url);
     let res = await API.get(url);
     const { success, message, data } = res.data;
@@ -462,6 +467,12 @@ export const useLogsData = () => {
             value: other.upstream_model_name,
           });
         }
+        if (other?.model_fallback_from) {
+          expandDataLocal.push({
+            key: t('模型降级'),
+            value: `${other.model_fallback_from} → ${logs[i].model_name}`,
+          });
+        }
 
         const isViolationFeeLog =
           other?.violation_fee === true ||
@@ -497,6 +508,20 @@ export const useLogsData = () => {
             value: other.reasoning_effort,
           });
         }
+        if (other?.response_cache_hit) {
+          expandDataLocal.push({
+            key: t('响应缓存'),
+            value: t('命中缓存，按原始额度的 {{ratio}} 倍计费', {
+              ratio: other.response_cache_ratio ?? 0,
+            }),
+          });
+        }
+        if (other?.semantic_cache_similarity) {
+          expandDataLocal.push({
+            key: t('语义相似度'),
+            value: other.semantic_cache_similarity.toFixed(4),
+          });
+        }
         if (other?.billing_mode === 'tiered_expr' && other?.expr_b64) {
           expandDataLocal.push({
             key: t('计费过程'),
@@ -641,6 +666,58 @@ export const useLogsData = () => {
           value: localCountMode,
         });
       }
+      if (isAdminUser && other?.admin_info?.routing_strategy) {
+        const routingStrategyLabels = {
+          weighted_random: t('按权重随机'),
+          least_latency: t('最低延迟'),
+          least_cost: t('最低成本'),
+          least_outstanding: t('最少在途请求'),
+        };
+        const routingStrategy = other.admin_info.routing_strategy;
+        expandDataLocal.push({
+          key: t('路由策略'),
+          value: routingStrategyLabels[routingStrategy] || routingStrategy,
+        });
+      }
+      if (isAdminUser && other?.admin_info?.hedge) {
+        const hedge = other.admin_info.hedge;
+        expandDataLocal.push({
+          key: t('对冲请求'),
+          value: hedge.winner_channel
+            ? t(
+                '#{{primary}} 与 #{{hedge}}（{{delay}}ms 后对冲），#{{winner}} 胜出',
+                {
+                  primary: hedge.primary_channel,
+                  hedge: hedge.hedge_channel,
+                  delay: hedge.delay_ms,
+                  winner: hedge.winner_channel,
+                },
+              )
+            : t(
+                '#{{primary}} 与 #{{hedge}}（{{delay}}ms 后对冲），均未成功',
+                {
+                  primary: hedge.primary_channel,
+                  hedge: hedge.hedge_channel,
+                  delay: hedge.delay_ms,
+                },
+              ),
+        });
+      }
+      if (isAdminUser && other?.admin_info?.stream_failover) {
+        const streamFailover = other.admin_info.stream_failover;
+        const channels = streamFailover.interrupted_channels
+          .map((id) => `#${id}`)
+          .join(', ');
+        expandDataLocal.push({
+          key: t('流式续写'),
+          value: streamFailover.resumed
+            ? t('在 {{channels}} 中断，于 #{{channel}} 续写', {
+                channels,
+                channel: logs[i].channel,
+              })
+            : t('在 {{channels}} 中断，未能续写', { channels }),
+        });
+      }
       if (isAdminUser && logs[i].type === 1) {
         const adminInfo = other?.admin_info;
         if (adminInfo) {
@@ -737,6 +814,7 @@ export const useLogsData = () => {
       start_timestamp,
       end_timestamp,
       channel,
+      organization_id,
       group,
       request_id,
       logType: formLogType,
@@ -752,7 +830,7 @@ export const useLogsData = () => {
     let localStartTimestamp = Date.parse(start_timestamp) / 1000;
     let localEndTimestamp = Date.parse(end_timestamp) / 1000;
     if (isAdminUser) {
-      url = `/api/log/?p=${startIdx}&page_size=${pageSize}&type=${currentLogType}&username=${username}&token_name=${token_name}&model_name=${model_name}&start_timestamp=${localStartTimestamp}&end_timestamp=${localEndTimestamp}&channel=${channel}&group=${group}&request_id=${request_id}`;
+      url = `/api/log/?p=${startIdx}&page_size=${pageSize}&type=${currentLogType}&username=${username}&token_name=${token_name}&model_name=${model_name}&start_timestamp=${localStartTimestamp}&end_timestamp=${localEndTimestamp}&channel=${channel}&organization_id=${organization_id}&group=${group}&request_id=${request_id}`;
     } else {
       url = `/api/log/self/?p=${startIdx}&page_size=${pageSize}&type=${currentLogType}&token_name=${token_name}&model_name=${model_name}&start_timestamp=${localStartTimestamp}&end_timestamp=${localEndTimestamp}&group=${group}&request_id=${request_id}`;
     }
@@ -772,6 +850,45 @@ export const useLogsData = () => {
     setLoading(false);
   };
 
+  // 按当前筛选条件导出日志（logs）或按小时汇总的用量（usage），format 目前仅支持 csv
+  const exportLogs = async (kind, format) => {
+    const {
+      username,
+      token_name,
+      model_name,
+      start_timestamp,
+      end_timestamp,
+      channel,
+      organization_id,
+      group,
+      logType: formLogType,
+    } = getFormValues();
+    const params = new URLSearchParams({
+      format,
+      type: String(formLogType),
+      token_name,
+      model_name,
+      start_timestamp: String(Date.parse(start_timestamp) / 1000),
+      end_timestamp: String(Date.parse(end_timestamp) / 1000),
+      group,
+    });
+    if (isAdminUser) {
+      params.set('username', username);
+      params.set('channel', channel);
+      params.set('organization_id', organization_id);
+    }
+    const base = kind === 'usage' ? '/api/data' : '/api/log';
+    const url = `${base}/${isAdminUser ? '' : 'self/'}export?${params.toString()}`;
+    setExporting(true);
+    try {
+      await downloadApiFile(url, `${kind}.${format}`);
+    } catch (error) {
+      showError(error.message || t('导出失败'));
+    } finally {
+      setExporting(false);
+    }
+  };
+
   // Page handlers
   const handlePageChange = (page) => {
     setActivePage(page);
@@ -839,6 +956,7 @@ export const useLogsData = () => {
     showStat,
     loading,
     loadingStat,
+    exporting,
     activePage,
     logCount,
     pageSize,
@@ -893,6 +1011,7 @@ export const useLogsData = () => {
     hasExpandableRows,
     setLogType,
     openParamOverrideModal,
+    exportLogs,
 
     // Translation
     t,
@@ -0,0 +1,230 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin, Typography } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+export default function SettingsCircuitBreaker(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'circuit_breaker_setting.enabled': true,
+    'circuit_breaker_setting.window_seconds': 60,
+    'circuit_breaker_setting.min_requests': 10,
+    'circuit_breaker_setting.failure_rate_percent': 50,
+    'circuit_breaker_setting.slow_call_ms': 60000,
+    'circuit_breaker_setting.slow_call_rate_percent': 80,
+    'circuit_breaker_setting.open_seconds': 30,
+    'circuit_breaker_setting.probe_interval_seconds': 5,
+    'circuit_breaker_setting.half_open_successes': 3,
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function handleFieldChange(fieldName) {
+    return (value) => {
+      setInputs((inputs) => ({ ...inputs, [fieldName]: value }));
+    };
+  }
+
+  function onSubmit() {
+    const updateArray = compareObjects(inputs, inputsRow);
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    const requestQueue = updateArray.map((item) => {
+      return API.put('/api/option/', {
+        key: item.key,
+        value: String(inputs[item.key]),
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = {};
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  const disabled = !inputs['circuit_breaker_setting.enabled'];
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('渠道熔断设置')}>
+            <Typography.Text
+              type='tertiary'
+              style={{ marginBottom: 16, display: 'block' }}
+            >
+              {t(
+                '根据近期错误率与延迟降低渠道及多 Key 的选择权重，熔断的渠道在探测成功前不再被选择',
+              )}
+            </Typography.Text>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'circuit_breaker_setting.enabled'}
+                  label={t('启用渠道熔断')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.enabled',
+                  )}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.window_seconds'}
+                  label={t('统计窗口（秒）')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.window_seconds',
+                  )}
+                  min={10}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.min_requests'}
+                  label={t('最少请求数')}
+                  extraText={t('窗口内请求数达到该值后才会评分或熔断')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.min_requests',
+                  )}
+                  min={1}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.failure_rate_percent'}
+                  label={t('失败率阈值（%）')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.failure_rate_percent',
+                  )}
+                  min={0}
+                  max={100}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.slow_call_ms'}
+                  label={t('慢请求阈值（毫秒）')}
+                  extraText={t('流式请求按首字耗时计算，0 表示不统计延迟')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.slow_call_ms',
+                  )}
+                  min={0}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.slow_call_rate_percent'}
+                  label={t('慢请求比例阈值（%）')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.slow_call_rate_percent',
+                  )}
+                  min={0}
+                  max={100}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.open_seconds'}
+                  label={t('熔断时长（秒）')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.open_seconds',
+                  )}
+                  min={1}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.probe_interval_seconds'}
+                  label={t('探测间隔（秒）')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.probe_interval_seconds',
+                  )}
+                  min={1}
+                  disabled={disabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'circuit_breaker_setting.half_open_successes'}
+                  label={t('恢复所需探测成功次数')}
+                  onChange={handleFieldChange(
+                    'circuit_breaker_setting.half_open_successes',
+                  )}
+                  min={1}
+                  disabled={disabled}
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存渠道熔断设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
@@ -0,0 +1,211 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin, Typography } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+export default function SettingsInvoice(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'invoice_setting.enabled': false,
+    'invoice_setting.seller_name': '',
+    'invoice_setting.seller_address': '',
+    'invoice_setting.seller_tax_id': '',
+    'invoice_setting.seller_email': '',
+    'invoice_setting.number_prefix': 'INV',
+    'invoice_setting.currency': 'CNY',
+    'invoice_setting.footer': '',
+    'invoice_setting.timezone': '',
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function handleFieldChange(fieldName) {
+    return (value) => {
+      setInputs((inputs) => ({ ...inputs, [fieldName]: value }));
+    };
+  }
+
+  function onSubmit() {
+    const updateArray = compareObjects(inputs, inputsRow);
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    const requestQueue = updateArray.map((item) => {
+      return API.put('/api/option/', {
+        key: item.key,
+        value: String(inputs[item.key]),
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = {};
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  const enabled = inputs['invoice_setting.enabled'];
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('发票与账单设置')}>
+            <Typography.Text
+              type='tertiary'
+              style={{ marginBottom: 16, display: 'block' }}
+            >
+              {t(
+                '用户可在充值账单中下载已完成订单的发票与按模型、令牌汇总的月度账单（可打印 HTML 或 CSV），发票首次下载时开具，开具后内容不再变化',
+              )}
+            </Typography.Text>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'invoice_setting.enabled'}
+                  label={t('启用发票与月度账单')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={handleFieldChange('invoice_setting.enabled')}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.number_prefix'}
+                  label={t('发票编号前缀')}
+                  extraText={t('编号形如 INV-202610-000001')}
+                  onChange={handleFieldChange('invoice_setting.number_prefix')}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.currency'}
+                  label={t('充值币种')}
+                  extraText={t('钱包充值发票使用的币种，订阅订单使用套餐币种')}
+                  onChange={handleFieldChange('invoice_setting.currency')}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.seller_name'}
+                  label={t('开票方名称')}
+                  onChange={handleFieldChange('invoice_setting.seller_name')}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.seller_tax_id'}
+                  label={t('开票方税号')}
+                  onChange={handleFieldChange('invoice_setting.seller_tax_id')}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.seller_email'}
+                  label={t('开票方邮箱')}
+                  onChange={handleFieldChange('invoice_setting.seller_email')}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={16} lg={16} xl={16}>
+                <Form.Input
+                  field={'invoice_setting.seller_address'}
+                  label={t('开票方地址')}
+                  onChange={handleFieldChange('invoice_setting.seller_address')}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'invoice_setting.timezone'}
+                  label={t('账单时区')}
+                  placeholder='Asia/Shanghai'
+                  extraText={t('月度账单按该时区的自然月统计，留空使用 UTC')}
+                  onChange={handleFieldChange('invoice_setting.timezone')}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col span={24}>
+                <Form.TextArea
+                  field={'invoice_setting.footer'}
+                  label={t('发票备注')}
+                  placeholder={t('例如开户行信息或说明文字')}
+                  autosize={{ minRows: 2, maxRows: 6 }}
+                  onChange={handleFieldChange('invoice_setting.footer')}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存发票设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
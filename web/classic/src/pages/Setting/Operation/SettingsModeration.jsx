@@ -0,0 +1,301 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+  verifyJSON,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+export default function SettingsModeration(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'moderation_setting.enabled': false,
+    'moderation_setting.provider': 'model',
+    'moderation_setting.model': 'omni-moderation-latest',
+    'moderation_setting.group': '',
+    'moderation_setting.webhook_url': '',
+    'moderation_setting.webhook_secret': '',
+    'moderation_setting.timeout_seconds': 10,
+    'moderation_setting.fail_open': true,
+    'moderation_setting.default_action': 'flag',
+    'moderation_setting.group_actions': '{}',
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function onSubmit() {
+    const updateArray = compareObjects(inputs, inputsRow);
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    if (!verifyJSON(inputs['moderation_setting.group_actions'])) {
+      return showError(t('不是合法的 JSON 字符串'));
+    }
+    const requestQueue = updateArray.map((item) => {
+      let value = '';
+      if (typeof inputs[item.key] === 'boolean') {
+        value = String(inputs[item.key]);
+      } else {
+        value = inputs[item.key];
+      }
+      return API.put('/api/option/', {
+        key: item.key,
+        value,
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = {};
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  const isWebhook = inputs['moderation_setting.provider'] === 'webhook';
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('内容审核设置')}>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'moderation_setting.enabled'}
+                  label={t('启用转发前内容审核')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'moderation_setting.fail_open'}
+                  label={t('审核服务异常时放行')}
+                  extraText={t('关闭时审核服务异常将拒绝请求')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.fail_open': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'moderation_setting.timeout_seconds'}
+                  label={t('审核超时时间（秒）')}
+                  min={1}
+                  step={1}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.timeout_seconds': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Select
+                  field={'moderation_setting.provider'}
+                  label={t('审核方式')}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.provider': value,
+                    })
+                  }
+                >
+                  <Form.Select.Option value='model'>
+                    {t('审核模型')}
+                  </Form.Select.Option>
+                  <Form.Select.Option value='webhook'>
+                    Webhook
+                  </Form.Select.Option>
+                </Form.Select>
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'moderation_setting.model'}
+                  label={t('审核模型')}
+                  extraText={t('需要有渠道提供该模型的 /v1/moderations 接口')}
+                  placeholder='omni-moderation-latest'
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.model': value,
+                    })
+                  }
+                />
+              </Col>
+              {!isWebhook && (
+                <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                  <Form.Input
+                    field={'moderation_setting.group'}
+                    label={t('审核模型渠道分组')}
+                    extraText={t('留空时使用请求的分组选择渠道')}
+                    onChange={(value) =>
+                      setInputs({
+                        ...inputs,
+                        'moderation_setting.group': value,
+                      })
+                    }
+                  />
+                </Col>
+              )}
+            </Row>
+            {isWebhook && (
+              <Row gutter={16}>
+                <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                  <Form.Input
+                    field={'moderation_setting.webhook_url'}
+                    label={t('Webhook 地址')}
+                    extraText={t('响应格式需与 /v1/moderations 一致')}
+                    placeholder='https://example.com/moderate'
+                    onChange={(value) =>
+                      setInputs({
+                        ...inputs,
+                        'moderation_setting.webhook_url': value,
+                      })
+                    }
+                  />
+                </Col>
+                <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                  <Form.Input
+                    field={'moderation_setting.webhook_secret'}
+                    label={t('Webhook 密钥')}
+                    extraText={t('以 Bearer Token 形式发送，可留空')}
+                    mode='password'
+                    onChange={(value) =>
+                      setInputs({
+                        ...inputs,
+                        'moderation_setting.webhook_secret': value,
+                      })
+                    }
+                  />
+                </Col>
+              </Row>
+            )}
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Select
+                  field={'moderation_setting.default_action'}
+                  label={t('默认审核策略')}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.default_action': value,
+                    })
+                  }
+                >
+                  <Form.Select.Option value='allow'>
+                    {t('不审核')}
+                  </Form.Select.Option>
+                  <Form.Select.Option value='flag'>
+                    {t('标记并记录日志')}
+                  </Form.Select.Option>
+                  <Form.Select.Option value='block'>
+                    {t('拒绝请求')}
+                  </Form.Select.Option>
+                </Form.Select>
+              </Col>
+            </Row>
+            <Row>
+              <Col xs={24} sm={16}>
+                <Form.TextArea
+                  label={t('分组审核策略')}
+                  placeholder={'{\n  "vip": "allow",\n  "default": "block"\n}'}
+                  field={'moderation_setting.group_actions'}
+                  autosize={{ minRows: 4, maxRows: 12 }}
+                  trigger='blur'
+                  stopValidateWithError
+                  rules={[
+                    {
+                      validator: (rule, value) => verifyJSON(value),
+                      message: t('不是合法的 JSON 字符串'),
+                    },
+                  ]}
+                  extraText={t(
+                    '格式为 {"分组": "策略"}，策略可选 allow（不审核）、flag（标记并记录日志）、block（拒绝请求），未配置的分组使用默认审核策略',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'moderation_setting.group_actions': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存内容审核设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin, Typography } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+  verifyJSON,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+const ROUTING_STRATEGY_OPTIONS = [
+  { value: 'weighted_random', label: '按权重随机' },
+  { value: 'least_latency', label: '最低延迟' },
+  { value: 'least_cost', label: '最低成本' },
+  { value: 'least_outstanding', label: '最少在途请求' },
+];
+
+export default function SettingsRouting(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'routing_setting.default_strategy': 'weighted_random',
+    'routing_setting.group_strategies': '{}',
+    'routing_setting.model_strategies': '{}',
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function handleFieldChange(fieldName) {
+    return (value) => {
+      setInputs((inputs) => ({ ...inputs, [fieldName]: value }));
+    };
+  }
+
+  function onSubmit() {
+    if (
+      !verifyJSON(inputs['routing_setting.group_strategies']) ||
+      !verifyJSON(inputs['routing_setting.model_strategies'])
+    ) {
+      return showError(t('不是合法的 JSON 字符串'));
+    }
+    const updateArray = compareObjects(inputs, inputsRow);
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    const requestQueue = updateArray.map((item) => {
+      return API.put('/api/option/', {
+        key: item.key,
+        value: String(inputs[item.key]),
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+
+        for (let i = 0; i < res.length; i++) {
+          if (!res[i].data.success) {
+            return showError(res[i].data.message);
+          }
+        }
+
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = {};
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('渠道路由设置')}>
+            <Typography.Text
+              type='tertiary'
+              style={{ marginBottom: 16, display: 'block' }}
+            >
+              {t(
+                '决定同一优先级的渠道之间如何选择：最低延迟优先选择近期首字延迟最低的渠道，最低成本优先选择模型重定向后价格最低的渠道，最少在途请求优先选择最空闲的渠道，条件相同时按权重随机',
+              )}
+            </Typography.Text>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Select
+                  field={'routing_setting.default_strategy'}
+                  label={t('默认路由策略')}
+                  optionList={ROUTING_STRATEGY_OPTIONS.map((item) => ({
+                    value: item.value,
+                    label: t(item.label),
+                  }))}
+                  onChange={handleFieldChange(
+                    'routing_setting.default_strategy',
+                  )}
+                  style={{ width: '100%' }}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={24} md={12} lg={12} xl={12}>
+                <Form.TextArea
+                  field={'routing_setting.group_strategies'}
+                  label={t('分组路由策略')}
+                  placeholder={'{\n  "default": "least_latency"\n}'}
+                  extraText={t('分组到策略的 JSON 映射，优先于默认策略')}
+                  autosize={{ minRows: 4, maxRows: 12 }}
+                  trigger='blur'
+                  stopValidateWithError
+                  rules={[
+                    {
+                      validator: (rule, value) => verifyJSON(value),
+                      message: t('不是合法的 JSON 字符串'),
+                    },
+                  ]}
+                  onChange={handleFieldChange(
+                    'routing_setting.group_strategies',
+                  )}
+                />
+              </Col>
+              <Col xs={24} sm={24} md={12} lg={12} xl={12}>
+                <Form.TextArea
+                  field={'routing_setting.model_strategies'}
+                  label={t('模型路由策略')}
+                  placeholder={'{\n  "gpt-4o": "least_cost"\n}'}
+                  extraText={t(
+                    '模型到策略的 JSON 映射，对所有分组生效，优先于分组策略',
+                  )}
+                  autosize={{ minRows: 4, maxRows: 12 }}
+                  trigger='blur'
+                  stopValidateWithError
+                  rules={[
+                    {
+                      validator: (rule, value) => verifyJSON(value),
+                      message: t('不是合法的 JSON 字符串'),
+                    },
+                  ]}
+                  onChange={handleFieldChange(
+                    'routing_setting.model_strategies',
+                  )}
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存路由设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
@@ -0,0 +1,307 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+  verifyJSON,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+export default function SettingsSemanticCache(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'semantic_cache_setting.enabled': false,
+    'semantic_cache_setting.embedding_model': 'text-embedding-3-small',
+    'semantic_cache_setting.embedding_group': '',
+    'semantic_cache_setting.shared_groups': '',
+    'semantic_cache_setting.timeout_seconds': 10,
+    'semantic_cache_setting.default_threshold': 0.95,
+    'semantic_cache_setting.model_thresholds': '{}',
+    'semantic_cache_setting.ttl_seconds': 86400,
+    'semantic_cache_setting.max_entries': 10000,
+    'semantic_cache_setting.max_entry_kb': 48,
+    'semantic_cache_setting.billing_ratio': 0,
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function onSubmit() {
+    const updateArray = compareObjects(inputs, inputsRow);
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    if (!verifyJSON(inputs['semantic_cache_setting.model_thresholds'])) {
+      return showError(t('不是合法的 JSON 字符串'));
+    }
+    const requestQueue = updateArray.map((item) => {
+      let value = '';
+      if (typeof inputs[item.key] === 'boolean') {
+        value = String(inputs[item.key]);
+      } else {
+        value = inputs[item.key];
+      }
+      return API.put('/api/option/', {
+        key: item.key,
+        value,
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = {};
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('语义缓存设置')}>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'semantic_cache_setting.enabled'}
+                  label={t('启用语义缓存')}
+                  extraText={t(
+                    '对最后一条用户消息做向量化，与分组、模型及其余上下文相同的已缓存请求比较相似度',
+                  )}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.enabled': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'semantic_cache_setting.embedding_model'}
+                  label={t('Embedding 模型')}
+                  placeholder='text-embedding-3-small'
+                  extraText={t('需要有渠道提供该模型的 /v1/embeddings 接口')}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.embedding_model': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Input
+                  field={'semantic_cache_setting.embedding_group'}
+                  label={t('Embedding 渠道分组')}
+                  extraText={t('留空则使用请求的分组选择渠道')}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.embedding_group': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.timeout_seconds'}
+                  label={t('Embedding 请求超时（秒）')}
+                  min={1}
+                  step={1}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.timeout_seconds': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={24} md={16} lg={16} xl={16}>
+                <Form.Input
+                  field={'semantic_cache_setting.shared_groups'}
+                  label={t('跨用户共享缓存的分组')}
+                  placeholder='default,vip'
+                  extraText={t(
+                    '逗号分隔；其他分组的缓存按用户隔离，不同用户之间不共享回答',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.shared_groups': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.default_threshold'}
+                  label={t('默认相似度阈值')}
+                  extraText={t('命中缓存所需的最小余弦相似度')}
+                  min={0}
+                  max={1}
+                  step={0.01}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.default_threshold': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.ttl_seconds'}
+                  label={t('缓存有效期（秒）')}
+                  min={1}
+                  step={1}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.ttl_seconds': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.billing_ratio'}
+                  label={t('命中缓存计费倍率')}
+                  extraText={t('按原始消耗额度的倍率计费，0 表示免费')}
+                  min={0}
+                  step={0.01}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.billing_ratio': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.max_entries'}
+                  label={t('最大缓存条目数')}
+                  extraText={t('超出时淘汰最早的条目')}
+                  min={1}
+                  step={1}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.max_entries': value,
+                    })
+                  }
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'semantic_cache_setting.max_entry_kb'}
+                  label={t('单条响应最大缓存大小（KB）')}
+                  extraText={t('超出大小的响应不会被缓存')}
+                  min={1}
+                  step={1}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.max_entry_kb': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Col xs={24} sm={16}>
+                <Form.TextArea
+                  label={t('模型相似度阈值')}
+                  placeholder={'{\n  "gpt-4o-mini": 0.92\n}'}
+                  field={'semantic_cache_setting.model_thresholds'}
+                  autosize={{ minRows: 4, maxRows: 12 }}
+                  trigger='blur'
+                  stopValidateWithError
+                  rules={[
+                    {
+                      validator: (rule, value) => verifyJSON(value),
+                      message: t('不是合法的 JSON 字符串'),
+                    },
+                  ]}
+                  extraText={t(
+                    '格式为 {"模型": 阈值}，阈值范围为 0 到 1，未配置的模型使用默认相似度阈值',
+                  )}
+                  onChange={(value) =>
+                    setInputs({
+                      ...inputs,
+                      'semantic_cache_setting.model_thresholds': value,
+                    })
+                  }
+                />
+              </Col>
+            </Row>
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存语义缓存设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
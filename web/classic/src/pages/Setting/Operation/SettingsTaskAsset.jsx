@@ -0,0 +1,274 @@
+/*
+Copyright (C) 2025 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+
+import React, { useEffect, useState, useRef } from 'react';
+import { Button, Col, Form, Row, Spin, Typography } from '@douyinfe/semi-ui';
+import {
+  compareObjects,
+  API,
+  showError,
+  showSuccess,
+  showWarning,
+} from '../../../helpers';
+import { useTranslation } from 'react-i18next';
+
+// 密钥不会随选项列表返回，留空表示不修改
+const SECRET_KEY = 'asset_setting.s3_access_secret';
+
+export default function SettingsTaskAsset(props) {
+  const { t } = useTranslation();
+  const [loading, setLoading] = useState(false);
+  const [inputs, setInputs] = useState({
+    'asset_setting.enabled': false,
+    'asset_setting.storage_mode': 'local',
+    'asset_setting.s3_endpoint': '',
+    'asset_setting.s3_region': 'us-east-1',
+    'asset_setting.s3_bucket': '',
+    'asset_setting.s3_access_key_id': '',
+    [SECRET_KEY]: '',
+    'asset_setting.s3_path_style': true,
+    'asset_setting.retention_days': 7,
+    'asset_setting.max_file_size_mb': 512,
+    'asset_setting.max_storage_mb_per_user': 0,
+    'asset_setting.signed_url_ttl_minutes': 60,
+  });
+  const refForm = useRef();
+  const [inputsRow, setInputsRow] = useState(inputs);
+
+  function handleFieldChange(fieldName) {
+    return (value) => {
+      setInputs((inputs) => ({ ...inputs, [fieldName]: value }));
+    };
+  }
+
+  function onSubmit() {
+    const updateArray = compareObjects(inputs, inputsRow).filter(
+      (item) =>
+        item.key !== SECRET_KEY || inputs[SECRET_KEY] !== '',
+    );
+    if (!updateArray.length) return showWarning(t('你似乎并没有修改什么'));
+    const requestQueue = updateArray.map((item) => {
+      return API.put('/api/option/', {
+        key: item.key,
+        value: String(inputs[item.key]),
+      });
+    });
+    setLoading(true);
+    Promise.all(requestQueue)
+      .then((res) => {
+        if (requestQueue.length === 1) {
+          if (res.includes(undefined)) return;
+        } else if (requestQueue.length > 1) {
+          if (res.includes(undefined))
+            return showError(t('部分保存失败，请重试'));
+        }
+        showSuccess(t('保存成功'));
+        props.refresh();
+      })
+      .catch(() => {
+        showError(t('保存失败，请重试'));
+      })
+      .finally(() => {
+        setLoading(false);
+      });
+  }
+
+  useEffect(() => {
+    const currentInputs = { [SECRET_KEY]: '' };
+    for (let key in props.options) {
+      if (Object.keys(inputs).includes(key)) {
+        currentInputs[key] = props.options[key];
+      }
+    }
+    setInputs(currentInputs);
+    setInputsRow(structuredClone(currentInputs));
+    refForm.current.setValues(currentInputs);
+  }, [props.options]);
+
+  const enabled = inputs['asset_setting.enabled'];
+  const isS3 = inputs['asset_setting.storage_mode'] === 's3';
+
+  return (
+    <>
+      <Spin spinning={loading}>
+        <Form
+          values={inputs}
+          getFormApi={(formAPI) => (refForm.current = formAPI)}
+          style={{ marginBottom: 15 }}
+        >
+          <Form.Section text={t('生成结果转存设置')}>
+            <Typography.Text
+              type='tertiary'
+              style={{ marginBottom: 16, display: 'block' }}
+            >
+              {t(
+                '视频、图片任务成功后将上游结果下载到本地或 S3 兼容存储，并将结果地址改写为带签名、会过期的本站地址，避免上游链接失效',
+              )}
+            </Typography.Text>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Switch
+                  field={'asset_setting.enabled'}
+                  label={t('启用结果转存')}
+                  size='default'
+                  checkedText='｜'
+                  uncheckedText='〇'
+                  onChange={handleFieldChange('asset_setting.enabled')}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.Select
+                  field={'asset_setting.storage_mode'}
+                  label={t('存储方式')}
+                  optionList={[
+                    { label: t('本地磁盘'), value: 'local' },
+                    { label: t('S3 兼容存储'), value: 's3' },
+                  ]}
+                  onChange={handleFieldChange('asset_setting.storage_mode')}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'asset_setting.retention_days'}
+                  label={t('保留天数')}
+                  extraText={t('到期后删除转存文件，0 表示永久保留')}
+                  onChange={handleFieldChange('asset_setting.retention_days')}
+                  min={0}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            <Row gutter={16}>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'asset_setting.max_file_size_mb'}
+                  label={t('单个文件上限（MB）')}
+                  extraText={t('超过上限时保留上游地址')}
+                  onChange={handleFieldChange(
+                    'asset_setting.max_file_size_mb',
+                  )}
+                  min={1}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'asset_setting.max_storage_mb_per_user'}
+                  label={t('每个用户存储上限（MB）')}
+                  extraText={t('超出后不再转存，0 表示不限制')}
+                  onChange={handleFieldChange(
+                    'asset_setting.max_storage_mb_per_user',
+                  )}
+                  min={0}
+                  disabled={!enabled}
+                />
+              </Col>
+              <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                <Form.InputNumber
+                  field={'asset_setting.signed_url_ttl_minutes'}
+                  label={t('下载地址有效期（分钟）')}
+                  extraText={t('每次查询任务时重新签发')}
+                  onChange={handleFieldChange(
+                    'asset_setting.signed_url_ttl_minutes',
+                  )}
+                  min={1}
+                  disabled={!enabled}
+                />
+              </Col>
+            </Row>
+            {isS3 && (
+              <>
+                <Row gutter={16}>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Input
+                      field={'asset_setting.s3_endpoint'}
+                      label='S3 Endpoint'
+                      placeholder='http://minio:9000'
+                      onChange={handleFieldChange('asset_setting.s3_endpoint')}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Input
+                      field={'asset_setting.s3_region'}
+                      label={t('区域')}
+                      placeholder='us-east-1'
+                      onChange={handleFieldChange('asset_setting.s3_region')}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Input
+                      field={'asset_setting.s3_bucket'}
+                      label={t('存储桶')}
+                      onChange={handleFieldChange('asset_setting.s3_bucket')}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                </Row>
+                <Row gutter={16}>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Input
+                      field={'asset_setting.s3_access_key_id'}
+                      label='Access Key ID'
+                      onChange={handleFieldChange(
+                        'asset_setting.s3_access_key_id',
+                      )}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Input
+                      field={SECRET_KEY}
+                      label='Secret Access Key'
+                      mode='password'
+                      placeholder={t('敏感信息不会发送到前端显示')}
+                      onChange={handleFieldChange(SECRET_KEY)}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                  <Col xs={24} sm={12} md={8} lg={8} xl={8}>
+                    <Form.Switch
+                      field={'asset_setting.s3_path_style'}
+                      label={t('Path-Style 访问')}
+                      extraText={t('MinIO 等自建存储通常需要开启')}
+                      size='default'
+                      checkedText='｜'
+                      uncheckedText='〇'
+                      onChange={handleFieldChange(
+                        'asset_setting.s3_path_style',
+                      )}
+                      disabled={!enabled}
+                    />
+                  </Col>
+                </Row>
+              </>
+            )}
+            <Row>
+              <Button size='default' onClick={onSubmit}>
+                {t('保存结果转存设置')}
+              </Button>
+            </Row>
+          </Form.Section>
+        </Form>
+      </Spin>
+    </>
+  );
+}
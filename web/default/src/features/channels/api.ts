@@ -395,6 +395,22 @@ export async function disableAllMultiKeys(
   }) as Promise<{ success: boolean; message?: string }>
 }
 
+/**
+ * Set the weight of a key used by the weighted multi-key strategy
+ */
+export async function setMultiKeyWeight(
+  channelId: number,
+  keyIndex: number,
+  weight: number
+): Promise<{ success: boolean; message?: string }> {
+  return manageMultiKeys({
+    channel_id: channelId,
+    action: 'set_key_weight',
+    key_index: keyIndex,
+    weight,
+  }) as Promise<{ success: boolean; message?: string }>
+}
+
 /**
  * Delete all disabled keys in multi-key channel
  */
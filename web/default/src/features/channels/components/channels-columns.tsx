@@ -24,7 +24,9 @@ import {
   AlertTriangle,
   ChevronDown,
   ChevronRight,
+  Gauge,
   ListOrdered,
+  Scale,
   Shuffle,
 } from 'lucide-react'
 import { useTranslation } from 'react-i18next'
@@ -638,12 +640,18 @@ export function useChannelsColumns(): ColumnDef<Channel>[] {
         const channel = row.original as Channel
         const isMultiKey = isMultiKeyChannel(channel)
         const multiKeyMode = channel.channel_info?.multi_key_mode ?? 'random'
-        const MultiKeyModeIcon =
-          multiKeyMode === 'random' ? Shuffle : ListOrdered
-        const multiKeyTooltip =
-          multiKeyMode === 'random'
-            ? t('Multi-key: Random rotation')
-            : t('Multi-key: Polling rotation')
+        const MultiKeyModeIcon = {
+          random: Shuffle,
+          polling: ListOrdered,
+          'least-used': Gauge,
+          weighted: Scale,
+        }[multiKeyMode]
+        const multiKeyTooltip = {
+          random: t('Multi-key: Random rotation'),
+          polling: t('Multi-key: Polling rotation'),
+          'least-used': t('Multi-key: Least used first'),
+          weighted: t('Multi-key: Weighted rotation'),
+        }[multiKeyMode]
 
         const ionetMeta = parseIonetMeta(channel.other_info)
         const isIonet = ionetMeta?.source === 'ionet'
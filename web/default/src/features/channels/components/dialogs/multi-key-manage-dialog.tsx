@@ -22,6 +22,7 @@ import { Loader2, RefreshCw, Trash2, Power, PowerOff } from 'lucide-react'
 import { useTranslation } from 'react-i18next'
 import { toast } from 'sonner'
 import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
 import {
   Dialog,
   DialogContent,
@@ -56,8 +57,9 @@ import {
   enableAllMultiKeys,
   disableAllMultiKeys,
   deleteDisabledMultiKeys,
+  setMultiKeyWeight,
 } from '../../api'
-import { MULTI_KEY_FILTER_OPTIONS } from '../../constants'
+import { MULTI_KEY_FILTER_OPTIONS, MULTI_KEY_MODES } from '../../constants'
 import {
   channelsQueryKeys,
   formatTimestamp,
@@ -222,8 +224,41 @@ export function MultiKeyManageDialog({
     return formatTimestamp(timestamp)
   }
 
+  const handleWeightChange = async (key: KeyStatus, value: string) => {
+    if (!currentRow) return
+    const weight = Number(value)
+    if (!Number.isInteger(weight) || weight < 0) {
+      toast.error(t('Weight must be a non-negative integer'))
+      return
+    }
+    if (weight === (key.weight ?? 1)) return
+    try {
+      const response = await setMultiKeyWeight(
+        currentRow.id,
+        key.index,
+        weight
+      )
+      if (response.success) {
+        setKeys((prev) =>
+          prev.map((k) => (k.index === key.index ? { ...k, weight } : k))
+        )
+      } else {
+        toast.error(response.message || t('Operation failed'))
+      }
+    } catch (error: unknown) {
+      toast.error(
+        error instanceof Error ? error.message : t('Operation failed')
+      )
+    }
+  }
+
   if (!currentRow) return null
 
+  const multiKeyModeLabel = MULTI_KEY_MODES.find(
+    (mode) => mode.value === currentRow.channel_info?.multi_key_mode
+  )?.label
+  const isWeighted = currentRow.channel_info?.multi_key_mode === 'weighted'
+
   return (
     <>
       <Dialog open={open} onOpenChange={onOpenChange}>
@@ -236,13 +271,9 @@ export function MultiKeyManageDialog({
                 variant='neutral'
                 copyable={false}
               />
-              {currentRow.channel_info?.multi_key_mode && (
+              {multiKeyModeLabel && (
                 <StatusBadge
-                  label={
-                    currentRow.channel_info.multi_key_mode === 'random'
-                      ? t('Random')
-                      : t('Polling')
-                  }
+                  label={t(multiKeyModeLabel)}
                   variant='neutral'
                   copyable={false}
                 />
@@ -359,7 +390,7 @@ export function MultiKeyManageDialog({
                   {t('No keys found')}
                 </div>
               ) : (
-                <div className='min-w-[800px]'>
+                <div className='min-w-[1100px]'>
                   <Table>
                     <TableHeader>
                       <TableRow>
@@ -371,6 +402,11 @@ export function MultiKeyManageDialog({
                         <TableHead className='w-44'>
                           {t('Disabled Time')}
                         </TableHead>
+                        <TableHead className='w-40'>{t('Usage')}</TableHead>
+                        <TableHead className='w-44'>{t('Last Used')}</TableHead>
+                        {isWeighted && (
+                          <TableHead className='w-24'>{t('Weight')}</TableHead>
+                        )}
                         <TableHead className='w-44 text-right'>
                           {t('Actions')}
                         </TableHead>
@@ -389,6 +425,36 @@ export function MultiKeyManageDialog({
                           <TableCell className='text-muted-foreground text-sm'>
                             {formatKeyTimestamp(key.disabled_time)}
                           </TableCell>
+                          <TableCell className='text-sm'>
+                            <div>
+                              {t('{{requests}} requests / {{tokens}} tokens', {
+                                requests: key.request_count ?? 0,
+                                tokens: key.token_count ?? 0,
+                              })}
+                            </div>
+                            <div className='text-muted-foreground text-xs'>
+                              {t('Last minute: {{rpm}} RPM / {{tpm}} TPM', {
+                                rpm: key.minute_requests ?? 0,
+                                tpm: key.minute_tokens ?? 0,
+                              })}
+                            </div>
+                          </TableCell>
+                          <TableCell className='text-muted-foreground text-sm'>
+                            {formatKeyTimestamp(key.last_used_time)}
+                          </TableCell>
+                          {isWeighted && (
+                            <TableCell>
+                              <Input
+                                type='number'
+                                min={0}
+                                className='h-8 w-20'
+                                defaultValue={key.weight ?? 1}
+                                onBlur={(e) =>
+                                  handleWeightChange(key, e.target.value)
+                                }
+                              />
+                            </TableCell>
+                          )}
                           <TableCell>
                             <MultiKeyTableRowActions
                               keyIndex={key.index}
@@ -124,6 +124,7 @@ import {
   FIELD_DESCRIPTIONS,
   FIELD_PLACEHOLDERS,
   MODEL_FETCHABLE_TYPES,
+  MULTI_KEY_MODES,
   SUCCESS_MESSAGES,
 } from '../../constants'
 import {
@@ -1018,13 +1019,13 @@ export function ChannelMutateDrawer({
         if (isEditing && currentRow) {
           // Update existing channel
           const payload = transformFormDataToUpdatePayload(data, currentRow.id)
-          const payloadWithKeyMode =
-            isMultiKeyChannel && data.key_mode
-              ? {
-                  ...payload,
-                  key_mode: data.key_mode,
-                }
-              : payload
+          const payloadWithKeyMode = isMultiKeyChannel
+            ? {
+                ...payload,
+                key_mode: data.key_mode,
+                multi_key_mode: data.multi_key_type,
+              }
+            : payload
 
           const response = await updateChannel(
             currentRow.id,
@@ -2118,54 +2119,138 @@ export function ChannelMutateDrawer({
                   />
                 )}
 
-                {!isEditing && multiKeyMode === 'multi_to_single' && (
-                  <FormField
-                    control={form.control}
-                    name='multi_key_type'
-                    render={({ field }) => (
-                      <FormItem>
-                        <FormLabel>{t('Multi-Key Strategy')}</FormLabel>
-                        <Select
-                          items={[
-                            { value: 'random', label: t('Random') },
-                            { value: 'polling', label: t('Polling') },
-                          ]}
-                          onValueChange={field.onChange}
-                          value={field.value}
-                        >
-                          <FormControl>
-                            <SelectTrigger>
-                              <SelectValue />
-                            </SelectTrigger>
-                          </FormControl>
-                          <SelectContent alignItemWithTrigger={false}>
-                            <SelectGroup>
-                              <SelectItem value='random'>
-                                {t('Random')}
-                              </SelectItem>
-                              <SelectItem value='polling'>
-                                {t('Polling')}
-                              </SelectItem>
-                            </SelectGroup>
-                          </SelectContent>
-                        </Select>
-                        <FormDescription>
-                          {multiKeyType === 'polling' ? (
-                            <span className='text-warning'>
-                              {t(
-                                'Polling mode requires Redis and memory cache, otherwise performance will be significantly degraded'
-                              )}
-                            </span>
-                          ) : (
-                            t(
-                              'Randomly select a key from the pool for each request'
-                            )
-                          )}
-                        </FormDescription>
-                        <FormMessage />
-                      </FormItem>
-                    )}
-                  />
+                {((!isEditing && multiKeyMode === 'multi_to_single') ||
+                  isMultiKeyChannel) && (
+                  <>
+                    <FormField
+                      control={form.control}
+                      name='multi_key_type'
+                      render={({ field }) => (
+                        <FormItem>
+                          <FormLabel>{t('Multi-Key Strategy')}</FormLabel>
+                          <Select
+                            items={MULTI_KEY_MODES.map((mode) => ({
+                              value: mode.value,
+                              label: t(mode.label),
+                            }))}
+                            onValueChange={field.onChange}
+                            value={field.value}
+                          >
+                            <FormControl>
+                              <SelectTrigger>
+                                <SelectValue />
+                              </SelectTrigger>
+                            </FormControl>
+                            <SelectContent alignItemWithTrigger={false}>
+                              <SelectGroup>
+                                {MULTI_KEY_MODES.map((mode) => (
+                                  <SelectItem
+                                    key={mode.value}
+                                    value={mode.value}
+                                  >
+                                    {t(mode.label)}
+                                  </SelectItem>
+                                ))}
+                              </SelectGroup>
+                            </SelectContent>
+                          </Select>
+                          <FormDescription>
+                            {multiKeyType === 'polling' ? (
+                              <span className='text-warning'>
+                                {t(
+                                  'Polling mode requires Redis and memory cache, otherwise performance will be significantly degraded'
+                                )}
+                              </span>
+                            ) : multiKeyType === 'least-used' ? (
+                              t(
+                                'Pick the key with the fewest requests in the last minute'
+                              )
+                            ) : multiKeyType === 'weighted' ? (
+                              t(
+                                'Pick keys at random in proportion to their weight. Set per-key weights in Multi-Key Management.'
+                              )
+                            ) : (
+                              t(
+                                'Randomly select a key from the pool for each request'
+                              )
+                            )}
+                          </FormDescription>
+                          <FormMessage />
+                        </FormItem>
+                      )}
+                    />
+
+                    <div className='grid gap-4 sm:grid-cols-3'>
+                      <FormField
+                        control={form.control}
+                        name='multi_key_rpm_limit'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Per-key RPM limit')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                type='number'
+                                min={0}
+                                placeholder='0'
+                                {...field}
+                                onChange={(e) =>
+                                  field.onChange(Number(e.target.value))
+                                }
+                              />
+                            </FormControl>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                      <FormField
+                        control={form.control}
+                        name='multi_key_tpm_limit'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Per-key TPM limit')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                type='number'
+                                min={0}
+                                placeholder='0'
+                                {...field}
+                                onChange={(e) =>
+                                  field.onChange(Number(e.target.value))
+                                }
+                              />
+                            </FormControl>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                      <FormField
+                        control={form.control}
+                        name='multi_key_cooldown_seconds'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Key cooldown (seconds)')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                type='number'
+                                min={0}
+                                placeholder='0'
+                                {...field}
+                                onChange={(e) =>
+                                  field.onChange(Number(e.target.value))
+                                }
+                              />
+                            </FormControl>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                    </div>
+                    <p className='text-muted-foreground text-sm'>
+                      {t(
+                        'Keys over their RPM/TPM limit in the last minute are skipped. Automatically disabled keys are re-enabled after the cooldown. 0 means no limit or no automatic re-enable.'
+                      )}
+                    </p>
+                  </>
                 )}
               </div>
 
@@ -188,6 +188,8 @@ export const MULTI_KEY_STATUS_CONFIG = {
 export const MULTI_KEY_MODES = [
   { value: 'random', label: 'Random' },
   { value: 'polling', label: 'Polling' },
+  { value: 'least-used', label: 'Least Used' },
+  { value: 'weighted', label: 'Weighted' },
 ] as const
 
 export const ADD_MODE_OPTIONS = [
@@ -18,7 +18,11 @@ For commercial licensing, please contact support@quantumnous.com
 */
 import { z } from 'zod'
 import { CHANNEL_STATUS, MODEL_FETCHABLE_TYPES } from '../constants'
-import type { Channel } from '../types'
+import {
+  multiKeyStrategySchema,
+  type Channel,
+  type MultiKeyStrategy,
+} from '../types'
 
 // ============================================================================
 // Form Validation Schema
@@ -51,7 +55,7 @@ export const channelFormSchema = z.object({
   other: z.string().optional(),
   // Multi-key options (not sent to backend directly)
   multi_key_mode: z.enum(['single', 'batch', 'multi_to_single']).optional(),
-  multi_key_type: z.enum(['random', 'polling']).optional(),
+  multi_key_type: multiKeyStrategySchema.optional(),
   batch_add_set_key_prefix_2_name: z.boolean().optional(),
   key_mode: z.enum(['append', 'replace']).optional(), // For editing multi-key channels
   // Channel extra settings (stored in setting JSON, not sent directly)
@@ -78,6 +82,10 @@ export const channelFormSchema = z.object({
   upstream_model_update_check_enabled: z.boolean().optional(),
   upstream_model_update_auto_sync_enabled: z.boolean().optional(),
   upstream_model_update_ignored_models: z.string().optional(),
+  // Multi-key per-key limits (stored in settings JSON)
+  multi_key_rpm_limit: z.number().int().min(0).optional(),
+  multi_key_tpm_limit: z.number().int().min(0).optional(),
+  multi_key_cooldown_seconds: z.number().int().min(0).optional(),
 })
 
 export type ChannelFormValues = z.infer<typeof channelFormSchema>
@@ -135,6 +143,9 @@ export const CHANNEL_FORM_DEFAULT_VALUES: ChannelFormValues = {
   upstream_model_update_check_enabled: false,
   upstream_model_update_auto_sync_enabled: false,
   upstream_model_update_ignored_models: '',
+  multi_key_rpm_limit: 0,
+  multi_key_tpm_limit: 0,
+  multi_key_cooldown_seconds: 0,
 }
 
 // ============================================================================
@@ -189,6 +200,9 @@ export function transformChannelToFormDefaults(
   let upstreamModelUpdateCheckEnabled = false
   let upstreamModelUpdateAutoSyncEnabled = false
   let upstreamModelUpdateIgnoredModels = ''
+  let multiKeyRpmLimit = 0
+  let multiKeyTpmLimit = 0
+  let multiKeyCooldownSeconds = 0
 
   if (channel.settings) {
     try {
@@ -213,6 +227,9 @@ export function transformChannelToFormDefaults(
       )
         ? parsed.upstream_model_update_ignored_models.join(',')
         : ''
+      multiKeyRpmLimit = Number(parsed.multi_key_rpm_limit) || 0
+      multiKeyTpmLimit = Number(parsed.multi_key_tpm_limit) || 0
+      multiKeyCooldownSeconds = Number(parsed.multi_key_cooldown_seconds) || 0
     } catch (error) {
       // eslint-disable-next-line no-console
       console.error('Failed to parse channel settings:', error)
@@ -262,6 +279,9 @@ export function transformChannelToFormDefaults(
     upstream_model_update_check_enabled: upstreamModelUpdateCheckEnabled,
     upstream_model_update_auto_sync_enabled: upstreamModelUpdateAutoSyncEnabled,
     upstream_model_update_ignored_models: upstreamModelUpdateIgnoredModels,
+    multi_key_rpm_limit: multiKeyRpmLimit,
+    multi_key_tpm_limit: multiKeyTpmLimit,
+    multi_key_cooldown_seconds: multiKeyCooldownSeconds,
   }
 }
 
@@ -386,6 +406,20 @@ function buildSettingsJSON(formData: ChannelFormValues): string {
     }
   }
 
+  // Multi-key per-key limits, 0 means unlimited / never re-enable
+  const multiKeyLimits = {
+    multi_key_rpm_limit: formData.multi_key_rpm_limit,
+    multi_key_tpm_limit: formData.multi_key_tpm_limit,
+    multi_key_cooldown_seconds: formData.multi_key_cooldown_seconds,
+  }
+  Object.entries(multiKeyLimits).forEach(([key, value]) => {
+    if (value && value > 0) {
+      settingsObj[key] = value
+    } else {
+      delete settingsObj[key]
+    }
+  })
+
   return JSON.stringify(settingsObj)
 }
 
@@ -394,7 +428,7 @@ function buildSettingsJSON(formData: ChannelFormValues): string {
  */
 export function transformFormDataToCreatePayload(formData: ChannelFormValues): {
   mode: 'single' | 'batch' | 'multi_to_single'
-  multi_key_mode?: 'random' | 'polling'
+  multi_key_mode?: MultiKeyStrategy
   batch_add_set_key_prefix_2_name?: boolean
   channel: Partial<Channel>
 } {
@@ -22,6 +22,15 @@ import { z } from 'zod'
 // Channel Schema & Types
 // ============================================================================
 
+export const multiKeyStrategySchema = z.enum([
+  'random',
+  'polling',
+  'least-used',
+  'weighted',
+])
+
+export type MultiKeyStrategy = z.infer<typeof multiKeyStrategySchema>
+
 export const channelInfoSchema = z.object({
   is_multi_key: z.boolean().default(false),
   multi_key_size: z.number().default(0),
@@ -29,7 +38,8 @@ export const channelInfoSchema = z.object({
   multi_key_disabled_reason: z.record(z.string(), z.string()).optional(),
   multi_key_disabled_time: z.record(z.string(), z.number()).optional(),
   multi_key_polling_index: z.number().default(0),
-  multi_key_mode: z.enum(['random', 'polling']).default('random'),
+  multi_key_mode: multiKeyStrategySchema.default('random'),
+  multi_key_weights: z.record(z.string(), z.number()).optional(),
 })
 
 export type ChannelInfo = z.infer<typeof channelInfoSchema>
@@ -180,6 +190,12 @@ export interface KeyStatus {
   disabled_time?: number
   reason?: string
   key_preview?: string
+  weight?: number
+  request_count?: number
+  token_count?: number
+  last_used_time?: number
+  minute_requests?: number
+  minute_tokens?: number
 }
 
 export type MultiKeyConfirmAction = {
@@ -267,7 +283,9 @@ export interface MultiKeyManageParams {
     | 'disable_all_keys'
     | 'delete_key'
     | 'delete_disabled_keys'
+    | 'set_key_weight'
   key_index?: number
+  weight?: number
   page?: number
   page_size?: number
   status?: number // 1=enabled, 2=manual_disabled, 3=auto_disabled
@@ -320,7 +338,7 @@ export interface ChannelFormData {
   other?: string
   // Multi-key specific
   multi_key_mode?: 'single' | 'batch' | 'multi_to_single'
-  multi_key_type?: 'random' | 'polling'
+  multi_key_type?: MultiKeyStrategy
   batch_add_set_key_prefix_2_name?: boolean
 }
 
@@ -330,7 +348,7 @@ export interface ChannelFormData {
 
 export interface AddChannelRequest {
   mode: 'single' | 'batch' | 'multi_to_single'
-  multi_key_mode?: 'random' | 'polling'
+  multi_key_mode?: MultiKeyStrategy
   batch_add_set_key_prefix_2_name?: boolean
   channel: Partial<Channel>
 }
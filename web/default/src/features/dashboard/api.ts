@@ -35,6 +35,7 @@ export async function getUserQuotaDates(
     end_timestamp: number
     default_time?: string
     username?: string
+    organization_id?: string
   },
   isAdmin = false
 ) {
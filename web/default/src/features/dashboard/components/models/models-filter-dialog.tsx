@@ -261,6 +261,20 @@ export function ModelsFilter(props: ModelsFilterProps) {
                     onChange={(e) => handleChange('username', e.target.value)}
                   />
                 </div>
+
+                <div className='grid gap-2'>
+                  <Label htmlFor='organization_id'>
+                    {t('Organization ID')}
+                  </Label>
+                  <Input
+                    id='organization_id'
+                    placeholder={t('Filter by organization ID')}
+                    value={filters.organization_id}
+                    onChange={(e) =>
+                      handleChange('organization_id', e.target.value)
+                    }
+                  />
+                </div>
               </>
             )}
           </div>
@@ -58,6 +58,7 @@ export interface DashboardFilters {
   end_timestamp?: Date
   time_granularity?: TimeGranularity
   username?: string
+  organization_id?: string
 }
 
 export type ConsumptionDistributionChartType = 'bar' | 'area'
@@ -75,6 +75,14 @@ export async function updateApiKey(
   return res.data
 }
 
+// Get the organization of the current user, data is null when not a member
+export async function getSelfOrganization(): Promise<
+  ApiResponse<{ organization: { id: number; name: string } } | null>
+> {
+  const res = await api.get('/api/organization/self')
+  return res.data
+}
+
 // Delete a single API key
 export async function deleteApiKey(id: number): Promise<ApiResponse> {
   const res = await api.delete(`/api/token/${id}/`)
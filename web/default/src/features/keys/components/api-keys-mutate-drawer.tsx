@@ -49,6 +49,14 @@ import {
   FormMessage,
 } from '@/components/ui/form'
 import { Input } from '@/components/ui/input'
+import {
+  Select,
+  SelectContent,
+  SelectGroup,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
 import {
   Sheet,
   SheetClose,
@@ -62,7 +70,12 @@ import { Switch } from '@/components/ui/switch'
 import { Textarea } from '@/components/ui/textarea'
 import { DateTimePicker } from '@/components/datetime-picker'
 import { MultiSelect } from '@/components/multi-select'
-import { createApiKey, updateApiKey, getApiKey } from '../api'
+import {
+  createApiKey,
+  updateApiKey,
+  getApiKey,
+  getSelfOrganization,
+} from '../api'
 import { ERROR_MESSAGES, SUCCESS_MESSAGES } from '../constants'
 import {
   getApiKeyFormSchema,
@@ -141,6 +154,14 @@ export function ApiKeysMutateDrawer({
     staleTime: 5 * 60 * 1000,
   })
 
+  // Fetch the user's organization for org-billed keys
+  const { data: organizationData } = useQuery({
+    queryKey: ['self-organization'],
+    queryFn: getSelfOrganization,
+    staleTime: 5 * 60 * 1000,
+  })
+
+  const organization = organizationData?.data?.organization
   const models = modelsData?.data || []
   const groupsRaw = groupsData?.data || {}
   const groups: ApiKeyGroupOption[] = Object.entries(groupsRaw).map(
@@ -597,6 +618,208 @@ export function ApiKeysMutateDrawer({
                         </FormItem>
                       )}
                     />
+
+                    <div className='grid gap-3 sm:grid-cols-3'>
+                      <FormField
+                        control={form.control}
+                        name='rpm_limit'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Requests per minute (RPM)')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                {...field}
+                                type='number'
+                                min='0'
+                                step={1}
+                                onChange={(e) =>
+                                  field.onChange(
+                                    parseInt(e.target.value, 10) || 0
+                                  )
+                                }
+                              />
+                            </FormControl>
+                            <FormDescription>
+                              {t('0 means unlimited')}
+                            </FormDescription>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                      <FormField
+                        control={form.control}
+                        name='tpm_limit'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Tokens per minute (TPM)')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                {...field}
+                                type='number'
+                                min='0'
+                                step={1}
+                                onChange={(e) =>
+                                  field.onChange(
+                                    parseInt(e.target.value, 10) || 0
+                                  )
+                                }
+                              />
+                            </FormControl>
+                            <FormDescription>
+                              {t('0 means unlimited')}
+                            </FormDescription>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                      <FormField
+                        control={form.control}
+                        name='concurrency_limit'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Max concurrent requests')}</FormLabel>
+                            <FormControl>
+                              <Input
+                                {...field}
+                                type='number'
+                                min='0'
+                                step={1}
+                                onChange={(e) =>
+                                  field.onChange(
+                                    parseInt(e.target.value, 10) || 0
+                                  )
+                                }
+                              />
+                            </FormControl>
+                            <FormDescription>
+                              {t('0 means unlimited')}
+                            </FormDescription>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                    </div>
+
+                    {organization && (
+                      <FormField
+                        control={form.control}
+                        name='organization_id'
+                        render={({ field }) => (
+                          <FormItem>
+                            <FormLabel>{t('Billing wallet')}</FormLabel>
+                            <Select
+                              items={[
+                                { value: '0', label: t('Personal wallet') },
+                                {
+                                  value: String(organization.id),
+                                  label: `${t('Organization wallet')}: ${organization.name}`,
+                                },
+                              ]}
+                              onValueChange={(value) =>
+                                value !== null &&
+                                field.onChange(parseInt(value))
+                              }
+                              value={String(field.value || 0)}
+                            >
+                              <FormControl>
+                                <SelectTrigger>
+                                  <SelectValue />
+                                </SelectTrigger>
+                              </FormControl>
+                              <SelectContent alignItemWithTrigger={false}>
+                                <SelectGroup>
+                                  <SelectItem value='0'>
+                                    {t('Personal wallet')}
+                                  </SelectItem>
+                                  <SelectItem value={String(organization.id)}>
+                                    {`${t('Organization wallet')}: ${organization.name}`}
+                                  </SelectItem>
+                                </SelectGroup>
+                              </SelectContent>
+                            </Select>
+                            <FormDescription>
+                              {t(
+                                'Usage of this key is charged to the shared organization wallet when selected. The key stops working if you leave the organization.'
+                              )}
+                            </FormDescription>
+                            <FormMessage />
+                          </FormItem>
+                        )}
+                      />
+                    )}
+
+                    <FormField
+                      control={form.control}
+                      name='semantic_cache_disabled'
+                      render={({ field }) => (
+                        <FormItem className='flex flex-row items-center justify-between gap-3 rounded-lg border px-3 py-2.5 sm:gap-4 sm:px-4 sm:py-3'>
+                          <div className='space-y-0.5'>
+                            <FormLabel className='text-sm'>
+                              {t('Disable semantic cache')}
+                            </FormLabel>
+                            <FormDescription className='text-xs'>
+                              {t(
+                                'Requests with this key never read from or write to the semantic cache.'
+                              )}
+                            </FormDescription>
+                          </div>
+                          <FormControl>
+                            <Switch
+                              checked={!!field.value}
+                              onCheckedChange={field.onChange}
+                            />
+                          </FormControl>
+                        </FormItem>
+                      )}
+                    />
+
+                    <FormField
+                      control={form.control}
+                      name='model_fallbacks'
+                      render={({ field }) => (
+                        <FormItem>
+                          <FormLabel>{t('Model fallback chains')}</FormLabel>
+                          <FormControl>
+                            <Textarea
+                              {...field}
+                              className='min-h-20 resize-none font-mono'
+                              placeholder={'{\n  "gpt-4o": ["gpt-4.1"]\n}'}
+                              rows={3}
+                            />
+                          </FormControl>
+                          <FormDescription>
+                            {t(
+                              'Overrides the global fallback chain for the listed models. Use an empty array to disable fallback for a model.'
+                            )}
+                          </FormDescription>
+                          <FormMessage />
+                        </FormItem>
+                      )}
+                    />
+
+                    <FormField
+                      control={form.control}
+                      name='task_callback_url'
+                      render={({ field }) => (
+                        <FormItem>
+                          <FormLabel>
+                            {t('Task completion callback URL')}
+                          </FormLabel>
+                          <FormControl>
+                            <Input
+                              {...field}
+                              placeholder='https://example.com/callback'
+                            />
+                          </FormControl>
+                          <FormDescription>
+                            {t(
+                              'Receives the result when async tasks such as video or music finish. A callback_url in the request takes precedence.'
+                            )}
+                          </FormDescription>
+                          <FormMessage />
+                        </FormItem>
+                      )}
+                    />
                   </div>
                 </CollapsibleContent>
               </section>
@@ -37,9 +37,44 @@ export function getApiKeyFormSchema(t: TFunction) {
       allow_ips: z.string().optional(),
       group: z.string().optional(),
       cross_group_retry: z.boolean().optional(),
+      rpm_limit: z.number().min(0).optional(),
+      tpm_limit: z.number().min(0).optional(),
+      concurrency_limit: z.number().min(0).optional(),
+      semantic_cache_disabled: z.boolean().optional(),
+      model_fallbacks: z.string().optional(),
+      task_callback_url: z
+        .string()
+        .trim()
+        .max(1024)
+        .refine((v) => !v || /^https?:\/\/[^/]+/.test(v), {
+          message: t('Must be an http or https URL'),
+        })
+        .optional(),
+      organization_id: z.number().min(0).optional(),
       tokenCount: z.number().min(1).optional(),
     })
     .superRefine((data, ctx) => {
+      if (data.model_fallbacks?.trim()) {
+        let valid = false
+        try {
+          const parsed = JSON.parse(data.model_fallbacks)
+          valid =
+            typeof parsed === 'object' &&
+            parsed !== null &&
+            !Array.isArray(parsed) &&
+            Object.values(parsed).every(Array.isArray)
+        } catch {
+          valid = false
+        }
+        if (!valid) {
+          ctx.addIssue({
+            code: 'custom',
+            path: ['model_fallbacks'],
+            message: t('Must be a JSON object mapping models to arrays'),
+          })
+        }
+      }
+
       if (data.unlimited_quota) {
         return
       }
@@ -74,6 +109,13 @@ export const API_KEY_FORM_DEFAULT_VALUES: ApiKeyFormValues = {
   allow_ips: '',
   group: DEFAULT_GROUP,
   cross_group_retry: true,
+  rpm_limit: 0,
+  tpm_limit: 0,
+  concurrency_limit: 0,
+  semantic_cache_disabled: false,
+  model_fallbacks: '',
+  task_callback_url: '',
+  organization_id: 0,
   tokenCount: 1,
 }
 
@@ -111,6 +153,13 @@ export function transformFormDataToPayload(
     allow_ips: data.allow_ips || '',
     group: data.group || '',
     cross_group_retry: data.group === 'auto' ? !!data.cross_group_retry : false,
+    rpm_limit: data.rpm_limit || 0,
+    tpm_limit: data.tpm_limit || 0,
+    concurrency_limit: data.concurrency_limit || 0,
+    semantic_cache_disabled: !!data.semantic_cache_disabled,
+    model_fallbacks: data.model_fallbacks?.trim() || '',
+    task_callback_url: data.task_callback_url?.trim() || '',
+    organization_id: data.organization_id || 0,
   }
 }
 
@@ -136,6 +185,13 @@ export function transformApiKeyToFormDefaults(
     allow_ips: apiKey.allow_ips || '',
     group: apiKey.group || DEFAULT_GROUP,
     cross_group_retry: !!apiKey.cross_group_retry,
+    rpm_limit: apiKey.rpm_limit || 0,
+    tpm_limit: apiKey.tpm_limit || 0,
+    concurrency_limit: apiKey.concurrency_limit || 0,
+    semantic_cache_disabled: !!apiKey.semantic_cache_disabled,
+    model_fallbacks: apiKey.model_fallbacks || '',
+    task_callback_url: apiKey.task_callback_url || '',
+    organization_id: apiKey.organization_id || 0,
     tokenCount: 1,
   }
 }
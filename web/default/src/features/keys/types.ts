@@ -45,6 +45,13 @@ export const apiKeySchema = z.object({
   model_limits_enabled: z.boolean(),
   model_limits: z.string().nullish().default(''),
   allow_ips: z.string().nullish().default(''),
+  rpm_limit: z.number().optional().default(0),
+  tpm_limit: z.number().optional().default(0),
+  concurrency_limit: z.number().optional().default(0),
+  semantic_cache_disabled: z.boolean().optional().default(false),
+  model_fallbacks: z.string().nullish().default(''),
+  task_callback_url: z.string().nullish().default(''),
+  organization_id: z.number().optional().default(0),
 })
 
 export type ApiKey = z.infer<typeof apiKeySchema>
@@ -92,6 +99,13 @@ export interface ApiKeyFormData {
   allow_ips: string
   group: string
   cross_group_retry: boolean
+  rpm_limit: number
+  tpm_limit: number
+  concurrency_limit: number
+  semantic_cache_disabled: boolean
+  model_fallbacks: string
+  task_callback_url: string
+  organization_id: number
 }
 
 // ============================================================================
@@ -22,6 +22,7 @@ import type {
   UserProfile,
   UpdateUserRequest,
   UpdateUserSettingsRequest,
+  UpdateBillingProfileRequest,
   DeleteAccountRequest,
   CheckinStatusResponse,
   CheckinResponse,
@@ -69,6 +70,16 @@ export async function updateUserLanguage(
   return res.data
 }
 
+/**
+ * Update company name and tax ID printed on invoices
+ */
+export async function updateBillingProfile(
+  data: UpdateBillingProfileRequest
+): Promise<ApiResponse> {
+  const res = await api.put('/api/user/billing_profile', data)
+  return res.data
+}
+
 /**
  * Delete user account
  */
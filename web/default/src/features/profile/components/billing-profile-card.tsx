@@ -0,0 +1,104 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { useEffect, useState } from 'react'
+import { Loader2, Receipt } from 'lucide-react'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
+import { Label } from '@/components/ui/label'
+import { TitledCard } from '@/components/ui/titled-card'
+import { updateBillingProfile } from '../api'
+import type { UserProfile } from '../types'
+
+type BillingProfileCardProps = {
+  profile: UserProfile | null
+  onProfileUpdate: () => void
+}
+
+export function BillingProfileCard(props: BillingProfileCardProps) {
+  const { t } = useTranslation()
+  const [companyName, setCompanyName] = useState('')
+  const [taxId, setTaxId] = useState('')
+  const [saving, setSaving] = useState(false)
+
+  useEffect(() => {
+    setCompanyName(props.profile?.company_name ?? '')
+    setTaxId(props.profile?.tax_id ?? '')
+  }, [props.profile?.company_name, props.profile?.tax_id])
+
+  const handleSave = async () => {
+    setSaving(true)
+    try {
+      const response = await updateBillingProfile({
+        company_name: companyName,
+        tax_id: taxId,
+      })
+      if (!response.success) {
+        toast.error(response.message || t('Failed to update settings'))
+        return
+      }
+      props.onProfileUpdate()
+      toast.success(t('Billing details saved'))
+    } catch (_error) {
+      toast.error(t('Failed to update settings'))
+    } finally {
+      setSaving(false)
+    }
+  }
+
+  return (
+    <TitledCard
+      title={t('Billing Details')}
+      description={t(
+        'Shown on top-up invoices and monthly statements; only applies to invoices issued afterwards'
+      )}
+      icon={<Receipt className='h-4 w-4' />}
+    >
+      <div className='grid gap-4 sm:grid-cols-2'>
+        <div className='space-y-2'>
+          <Label htmlFor='billing-company-name'>{t('Company Name')}</Label>
+          <Input
+            id='billing-company-name'
+            value={companyName}
+            maxLength={128}
+            placeholder={t('Defaults to your username when empty')}
+            onChange={(e) => setCompanyName(e.target.value)}
+          />
+        </div>
+        <div className='space-y-2'>
+          <Label htmlFor='billing-tax-id'>{t('Tax ID')}</Label>
+          <Input
+            id='billing-tax-id'
+            value={taxId}
+            maxLength={64}
+            placeholder={t('Taxpayer ID / VAT ID')}
+            onChange={(e) => setTaxId(e.target.value)}
+          />
+        </div>
+      </div>
+      <div className='mt-4 flex justify-end'>
+        <Button size='sm' onClick={handleSave} disabled={saving}>
+          {saving && <Loader2 className='mr-2 h-4 w-4 animate-spin' />}
+          {t('Save')}
+        </Button>
+      </div>
+    </TitledCard>
+  )
+}
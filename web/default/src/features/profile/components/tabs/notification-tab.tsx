@@ -21,6 +21,7 @@ import { Bell, Loader2, Mail, Server, Webhook } from 'lucide-react'
 import { useTranslation } from 'react-i18next'
 import { toast } from 'sonner'
 import { ROLE } from '@/lib/roles'
+import { useStatus } from '@/hooks/use-status'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -33,7 +34,12 @@ import {
   NOTIFICATION_METHODS,
 } from '../../constants'
 import { parseUserSettings } from '../../lib'
-import type { UserProfile, UserSettings, NotifyType } from '../../types'
+import type {
+  UserProfile,
+  UserSettings,
+  NotifyType,
+  UsageReportFrequency,
+} from '../../types'
 
 const NOTIFICATION_ICONS: Record<string, typeof Mail> = {
   email: Mail,
@@ -42,6 +48,15 @@ const NOTIFICATION_ICONS: Record<string, typeof Mail> = {
   gotify: Server,
 }
 
+const USAGE_REPORT_FREQUENCIES: Array<{
+  value: UsageReportFrequency
+  label: string
+}> = [
+  { value: '', label: "Don't send" },
+  { value: 'weekly', label: 'Weekly' },
+  { value: 'monthly', label: 'Monthly' },
+]
+
 // ============================================================================
 // Settings Tab Component
 // ============================================================================
@@ -54,6 +69,8 @@ interface NotificationTabProps {
 export function NotificationTab({ profile, onUpdate }: NotificationTabProps) {
   const { t } = useTranslation()
   const isAdmin = (profile?.role ?? 0) >= ROLE.ADMIN
+  const { status } = useStatus()
+  const usageReportEnabled = status?.usage_report_enabled === true
   const [loading, setLoading] = useState(false)
   const [settings, setSettings] = useState<UserSettings>({
     notify_type: 'email',
@@ -68,6 +85,7 @@ export function NotificationTab({ profile, onUpdate }: NotificationTabProps) {
     accept_unset_model_ratio_model: false,
     record_ip_log: false,
     upstream_model_update_notify_enabled: false,
+    usage_report_frequency: '',
   })
 
   // Update form field helper
@@ -97,6 +115,7 @@ export function NotificationTab({ profile, onUpdate }: NotificationTabProps) {
         record_ip_log: parsed.record_ip_log || false,
         upstream_model_update_notify_enabled:
           parsed.upstream_model_update_notify_enabled || false,
+        usage_report_frequency: parsed.usage_report_frequency || '',
       })
     }
   }, [profile])
@@ -345,6 +364,45 @@ export function NotificationTab({ profile, onUpdate }: NotificationTabProps) {
           </div>
         )}
 
+        {/* Scheduled Usage Report Email */}
+        {usageReportEnabled && (
+          <div className='flex flex-col gap-3 rounded-lg border p-3 sm:flex-row sm:items-center sm:justify-between sm:p-4'>
+            <div className='space-y-0.5'>
+              <Label>{t('Usage report email')}</Label>
+              <p className='text-muted-foreground text-xs sm:text-sm'>
+                {t(
+                  'Summarizes usage of the previous calendar week or month by model and token and emails it to your notification email (or your account email if unset), regardless of notification method. Nothing is sent for periods without usage.'
+                )}
+              </p>
+            </div>
+            <RadioGroup
+              value={settings.usage_report_frequency ?? ''}
+              onValueChange={(value) =>
+                updateField(
+                  'usage_report_frequency',
+                  value as UsageReportFrequency
+                )
+              }
+              className='flex shrink-0 gap-4'
+            >
+              {USAGE_REPORT_FREQUENCIES.map((item) => (
+                <div key={item.value} className='flex items-center gap-2'>
+                  <RadioGroupItem
+                    value={item.value}
+                    id={`usageReport-${item.value || 'off'}`}
+                  />
+                  <Label
+                    htmlFor={`usageReport-${item.value || 'off'}`}
+                    className='font-normal'
+                  >
+                    {t(item.label)}
+                  </Label>
+                </div>
+              ))}
+            </RadioGroup>
+          </div>
+        )}
+
         {/* Accept Unset Model Price */}
         <div className='flex items-start justify-between gap-3 rounded-lg border p-3 sm:items-center sm:p-4'>
           <div className='space-y-0.5'>
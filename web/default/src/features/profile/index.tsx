@@ -23,6 +23,7 @@ import {
   CardStaggerContainer,
   CardStaggerItem,
 } from '@/components/page-transition'
+import { BillingProfileCard } from './components/billing-profile-card'
 import { CheckinCalendarCard } from './components/checkin-calendar-card'
 import { LanguagePreferencesCard } from './components/language-preferences-card'
 import { PasskeyCard } from './components/passkey-card'
@@ -39,6 +40,7 @@ export function Profile() {
   const permissions = useAuthStore((s) => s.auth.user?.permissions)
 
   const checkinEnabled = status?.checkin_enabled === true
+  const invoiceEnabled = status?.invoice_enabled === true
   const turnstileEnabled = !!(
     status?.turnstile_check && status?.turnstile_site_key
   )
@@ -65,6 +67,12 @@ export function Profile() {
                   profile={profile}
                   onProfileUpdate={refreshProfile}
                 />
+                {invoiceEnabled && (
+                  <BillingProfileCard
+                    profile={profile}
+                    onProfileUpdate={refreshProfile}
+                  />
+                )}
                 <ProfileSecurityCard profile={profile} loading={loading} />
               </div>
 
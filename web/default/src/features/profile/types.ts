@@ -81,6 +81,10 @@ export interface UserProfile {
   telegram_id?: string
   /** LinuxDO ID (OAuth) */
   linux_do_id?: string
+  /** Company name printed on invoices */
+  company_name?: string
+  /** Taxpayer ID / VAT ID printed on invoices */
+  tax_id?: string
 }
 
 /**
@@ -88,6 +92,8 @@ export interface UserProfile {
  */
 export type NotifyType = 'email' | 'webhook' | 'bark' | 'gotify'
 
+export type UsageReportFrequency = '' | 'weekly' | 'monthly'
+
 /**
  * Parsed user settings
  */
@@ -116,6 +122,8 @@ export interface UserSettings {
   record_ip_log?: boolean
   /** Receive upstream model update notifications (admin only) */
   upstream_model_update_notify_enabled?: boolean
+  /** Scheduled usage report email: '' (off), 'weekly' or 'monthly' */
+  usage_report_frequency?: UsageReportFrequency
   /** Preferred interface/API response language */
   language?: string
 }
@@ -145,11 +153,17 @@ export interface UpdateUserSettingsRequest {
   accept_unset_model_ratio_model?: boolean
   record_ip_log?: boolean
   upstream_model_update_notify_enabled?: boolean
+  usage_report_frequency?: UsageReportFrequency
 }
 
 /**
  * Account deletion request
  */
+export interface UpdateBillingProfileRequest {
+  company_name: string
+  tax_id: string
+}
+
 export interface DeleteAccountRequest {
   password?: string
 }
@@ -104,6 +104,20 @@ const defaultBillingSettings: BillingSettings = {
   'checkin_setting.enabled': false,
   'checkin_setting.min_quota': 1000,
   'checkin_setting.max_quota': 10000,
+  'budget_setting.enabled': true,
+  'budget_setting.timezone': '',
+  'budget_setting.default_soft_percent': 80,
+  'invoice_setting.enabled': false,
+  'invoice_setting.seller_name': '',
+  'invoice_setting.seller_address': '',
+  'invoice_setting.seller_tax_id': '',
+  'invoice_setting.seller_email': '',
+  'invoice_setting.number_prefix': 'INV',
+  'invoice_setting.currency': 'CNY',
+  'invoice_setting.footer': '',
+  'invoice_setting.timezone': '',
+  'usage_report_setting.enabled': false,
+  'usage_report_setting.timezone': '',
 }
 
 export function BillingSettings() {
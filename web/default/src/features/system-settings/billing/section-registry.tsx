@@ -17,7 +17,10 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 For commercial licensing, please contact support@quantumnous.com
 */
 import { parseCurrencyDisplayType } from '@/lib/currency'
+import { BudgetSettingsSection } from '../general/budget-settings-section'
 import { CheckinSettingsSection } from '../general/checkin-settings-section'
+import { InvoiceSettingsSection } from '../general/invoice-settings-section'
+import { UsageReportSettingsSection } from '../general/usage-report-settings-section'
 import { PricingSection } from '../general/pricing-section'
 import { QuotaSettingsSection } from '../general/quota-settings-section'
 import { PaymentSettingsSection } from '../integrations/payment-settings-section'
@@ -207,6 +210,56 @@ const BILLING_SECTIONS = [
       />
     ),
   },
+  {
+    id: 'budget',
+    titleKey: 'Budgets',
+    descriptionKey:
+      'Configure daily, weekly and monthly budgets for users and tokens',
+    build: (settings: BillingSettings) => (
+      <BudgetSettingsSection
+        defaultValues={{
+          enabled: settings['budget_setting.enabled'],
+          timezone: settings['budget_setting.timezone'] ?? '',
+          defaultSoftPercent: settings['budget_setting.default_soft_percent'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'invoice',
+    titleKey: 'Invoices & Statements',
+    descriptionKey:
+      'Let users download invoices for completed orders and monthly usage statements',
+    build: (settings: BillingSettings) => (
+      <InvoiceSettingsSection
+        defaultValues={{
+          enabled: settings['invoice_setting.enabled'],
+          sellerName: settings['invoice_setting.seller_name'] ?? '',
+          sellerAddress: settings['invoice_setting.seller_address'] ?? '',
+          sellerTaxId: settings['invoice_setting.seller_tax_id'] ?? '',
+          sellerEmail: settings['invoice_setting.seller_email'] ?? '',
+          numberPrefix: settings['invoice_setting.number_prefix'] ?? 'INV',
+          currency: settings['invoice_setting.currency'] ?? 'CNY',
+          footer: settings['invoice_setting.footer'] ?? '',
+          timezone: settings['invoice_setting.timezone'] ?? '',
+        }}
+      />
+    ),
+  },
+  {
+    id: 'usage-report',
+    titleKey: 'Usage Reports',
+    descriptionKey:
+      'Email weekly or monthly usage reports to users who subscribe to them',
+    build: (settings: BillingSettings) => (
+      <UsageReportSettingsSection
+        defaultValues={{
+          enabled: settings['usage_report_setting.enabled'],
+          timezone: settings['usage_report_setting.timezone'] ?? '',
+        }}
+      />
+    ),
+  },
 ] as const
 
 export type BillingSectionId = (typeof BILLING_SECTIONS)[number]['id']
@@ -0,0 +1,201 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  timezone: z.string(),
+  defaultSoftPercent: z.coerce.number().int().min(0).max(100),
+})
+
+type Values = z.infer<typeof schema>
+
+export function BudgetSettingsSection({
+  defaultValues,
+}: {
+  defaultValues: {
+    enabled: boolean
+    timezone: string
+    defaultSoftPercent: number
+  }
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues: {
+      enabled: defaultValues.enabled,
+      timezone: defaultValues.timezone,
+      defaultSoftPercent: defaultValues.defaultSoftPercent,
+    },
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates: Array<{ key: string; value: string }> = []
+
+    if (values.enabled !== defaultValues.enabled) {
+      updates.push({
+        key: 'budget_setting.enabled',
+        value: String(values.enabled),
+      })
+    }
+
+    const timezone = values.timezone.trim()
+    if (timezone !== defaultValues.timezone) {
+      updates.push({
+        key: 'budget_setting.timezone',
+        value: timezone,
+      })
+    }
+
+    if (values.defaultSoftPercent !== defaultValues.defaultSoftPercent) {
+      updates.push({
+        key: 'budget_setting.default_soft_percent',
+        value: String(values.defaultSoftPercent),
+      })
+    }
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset({ ...values, timezone })
+  }
+
+  return (
+    <SettingsSection
+      title={t('Budget Settings')}
+      description={t(
+        'Configure daily, weekly and monthly budgets for users and tokens'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable budget limits')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'Reject requests once a user or token budget is exhausted for the current period'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                    disabled={updateOption.isPending || isSubmitting}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <div className='grid gap-6 sm:grid-cols-2'>
+              <FormField
+                control={form.control}
+                name='timezone'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Budget timezone')}</FormLabel>
+                    <FormControl>
+                      <Input placeholder='Asia/Shanghai' {...field} />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'Budget periods reset at midnight in this IANA timezone; leave empty for UTC'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name='defaultSoftPercent'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Default warning threshold (%)')}</FormLabel>
+                    <FormControl>
+                      <Input type='number' min={0} max={100} {...field} />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'Notify users when this percentage of a budget is used; 0 disables warnings'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            </div>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save budget settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
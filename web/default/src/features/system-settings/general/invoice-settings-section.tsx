@@ -0,0 +1,254 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  sellerName: z.string(),
+  sellerAddress: z.string(),
+  sellerTaxId: z.string(),
+  sellerEmail: z.string(),
+  numberPrefix: z.string(),
+  currency: z.string(),
+  footer: z.string(),
+  timezone: z.string(),
+})
+
+type Values = z.infer<typeof schema>
+
+// 表单字段与 invoice_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'invoice_setting.enabled',
+  sellerName: 'invoice_setting.seller_name',
+  sellerAddress: 'invoice_setting.seller_address',
+  sellerTaxId: 'invoice_setting.seller_tax_id',
+  sellerEmail: 'invoice_setting.seller_email',
+  numberPrefix: 'invoice_setting.number_prefix',
+  currency: 'invoice_setting.currency',
+  footer: 'invoice_setting.footer',
+  timezone: 'invoice_setting.timezone',
+}
+
+const TEXT_FIELDS = [
+  { name: 'sellerName', labelKey: 'Seller name' },
+  { name: 'sellerTaxId', labelKey: 'Seller tax ID' },
+  { name: 'sellerEmail', labelKey: 'Seller email' },
+  { name: 'sellerAddress', labelKey: 'Seller address' },
+] as const
+
+export function InvoiceSettingsSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Invoices & Statements')}
+      description={t(
+        'Let users download invoices for completed orders and monthly usage statements'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable invoices and monthly statements')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'An invoice is issued on first download and never changes afterwards. Statements group consumption by model and token and can be printed or exported as CSV.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                    disabled={updateOption.isPending || isSubmitting}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <>
+              <div className='grid gap-6 sm:grid-cols-2'>
+                {TEXT_FIELDS.map((item) => (
+                  <FormField
+                    key={item.name}
+                    control={form.control}
+                    name={item.name}
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t(item.labelKey)}</FormLabel>
+                        <FormControl>
+                          <Input {...field} />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                ))}
+              </div>
+
+              <div className='grid gap-6 sm:grid-cols-3'>
+                <FormField
+                  control={form.control}
+                  name='numberPrefix'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Invoice number prefix')}</FormLabel>
+                      <FormControl>
+                        <Input placeholder='INV' {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t('Numbers look like INV-202610-000001')}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <FormField
+                  control={form.control}
+                  name='currency'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Top-up currency')}</FormLabel>
+                      <FormControl>
+                        <Input placeholder='CNY' {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(
+                          'Currency for wallet top-up invoices; subscription orders use the plan currency'
+                        )}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <FormField
+                  control={form.control}
+                  name='timezone'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Statement timezone')}</FormLabel>
+                      <FormControl>
+                        <Input placeholder='Asia/Shanghai' {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(
+                          'Monthly statements follow calendar months in this timezone; UTC when empty'
+                        )}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+              </div>
+
+              <FormField
+                control={form.control}
+                name='footer'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Invoice footer')}</FormLabel>
+                    <FormControl>
+                      <Textarea
+                        rows={3}
+                        placeholder={t('e.g. bank details or notes')}
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            </>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save invoice settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
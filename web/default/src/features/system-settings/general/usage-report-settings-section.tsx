@@ -0,0 +1,157 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  timezone: z.string(),
+})
+
+type Values = z.infer<typeof schema>
+
+// 表单字段与 usage_report_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'usage_report_setting.enabled',
+  timezone: 'usage_report_setting.timezone',
+}
+
+export function UsageReportSettingsSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Usage Reports')}
+      description={t(
+        'Email weekly or monthly usage reports to users who subscribe to them'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable scheduled usage reports')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'Users can subscribe to weekly or monthly usage reports in their personal settings. Reports are emailed via SMTP after each period ends; the email service must be configured first.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                    disabled={updateOption.isPending || isSubmitting}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <FormField
+              control={form.control}
+              name='timezone'
+              render={({ field }) => (
+                <FormItem className='max-w-sm'>
+                  <FormLabel>{t('Report time zone')}</FormLabel>
+                  <FormControl>
+                    <Input placeholder='Asia/Shanghai' {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      'Weekly reports cover Monday to Sunday and monthly reports cover calendar months in this time zone. Leave empty to use UTC.'
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save usage report settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
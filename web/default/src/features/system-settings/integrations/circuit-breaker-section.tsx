@@ -0,0 +1,228 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  windowSeconds: z.coerce.number().int().min(10),
+  minRequests: z.coerce.number().int().min(1),
+  failureRatePercent: z.coerce.number().int().min(0).max(100),
+  slowCallMs: z.coerce.number().int().min(0),
+  slowCallRatePercent: z.coerce.number().int().min(0).max(100),
+  openSeconds: z.coerce.number().int().min(1),
+  probeIntervalSeconds: z.coerce.number().int().min(1),
+  halfOpenSuccesses: z.coerce.number().int().min(1),
+})
+
+type Values = z.infer<typeof schema>
+
+// 表单字段与 circuit_breaker_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'circuit_breaker_setting.enabled',
+  windowSeconds: 'circuit_breaker_setting.window_seconds',
+  minRequests: 'circuit_breaker_setting.min_requests',
+  failureRatePercent: 'circuit_breaker_setting.failure_rate_percent',
+  slowCallMs: 'circuit_breaker_setting.slow_call_ms',
+  slowCallRatePercent: 'circuit_breaker_setting.slow_call_rate_percent',
+  openSeconds: 'circuit_breaker_setting.open_seconds',
+  probeIntervalSeconds: 'circuit_breaker_setting.probe_interval_seconds',
+  halfOpenSuccesses: 'circuit_breaker_setting.half_open_successes',
+}
+
+type NumberField = Exclude<keyof Values, 'enabled'>
+
+const NUMBER_FIELDS: Array<{
+  name: NumberField
+  labelKey: string
+  descriptionKey: string
+}> = [
+  {
+    name: 'windowSeconds',
+    labelKey: 'Rolling window (seconds)',
+    descriptionKey: 'Error rate and latency are measured over this window.',
+  },
+  {
+    name: 'minRequests',
+    labelKey: 'Minimum requests',
+    descriptionKey:
+      'Channels are not scored or tripped until the window has this many requests.',
+  },
+  {
+    name: 'failureRatePercent',
+    labelKey: 'Failure rate threshold (%)',
+    descriptionKey:
+      'Open the circuit when the failure rate reaches this value.',
+  },
+  {
+    name: 'slowCallMs',
+    labelKey: 'Slow call threshold (ms)',
+    descriptionKey:
+      'Successful calls slower than this (time to first token for streams) count as slow; 0 ignores latency.',
+  },
+  {
+    name: 'slowCallRatePercent',
+    labelKey: 'Slow call rate threshold (%)',
+    descriptionKey:
+      'Open the circuit when the share of slow calls reaches this value.',
+  },
+  {
+    name: 'openSeconds',
+    labelKey: 'Open duration (seconds)',
+    descriptionKey:
+      'How long a tripped channel is skipped before it is probed again.',
+  },
+  {
+    name: 'probeIntervalSeconds',
+    labelKey: 'Probe interval (seconds)',
+    descriptionKey: 'Minimum interval between probe requests while half-open.',
+  },
+  {
+    name: 'halfOpenSuccesses',
+    labelKey: 'Successful probes to close',
+    descriptionKey:
+      'Consecutive successful probes required before the channel takes full traffic again.',
+  },
+]
+
+export function CircuitBreakerSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Circuit Breaker')}
+      description={t(
+        'Shed traffic from degrading channels and probe them automatically before restoring full traffic.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable circuit breaker')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'Channel and multi-key weights are lowered by their recent error rate and latency, and tripped channels are skipped until probes succeed.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <div className='grid gap-6 sm:grid-cols-2'>
+              {NUMBER_FIELDS.map((item) => (
+                <FormField
+                  key={item.name}
+                  control={form.control}
+                  name={item.name}
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t(item.labelKey)}</FormLabel>
+                      <FormControl>
+                        <Input type='number' min={0} {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(item.descriptionKey)}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+              ))}
+            </div>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save circuit breaker settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -0,0 +1,266 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+import {
+  formatJsonForTextarea,
+  normalizeJsonString,
+  validateJsonString,
+} from '../models/utils'
+
+// 分组或模型 -> 触发对冲的等待毫秒数，必须为正整数
+const delayMap = z.string().superRefine((value, ctx) => {
+  const result = validateJsonString(value, {
+    predicate: (parsed) =>
+      typeof parsed === 'object' &&
+      parsed !== null &&
+      !Array.isArray(parsed) &&
+      Object.values(parsed).every(
+        (delay) => Number.isInteger(delay) && (delay as number) > 0
+      ),
+    predicateMessage: 'Each value must be a positive number of milliseconds',
+  })
+  if (!result.valid) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: result.message || 'Invalid JSON',
+    })
+  }
+})
+
+const schema = z.object({
+  enabled: z.boolean(),
+  groupDelays: delayMap,
+  modelDelays: delayMap,
+  billLoser: z.boolean(),
+})
+
+type Values = z.infer<typeof schema>
+
+type HedgeSettingsSectionProps = {
+  defaultValues: {
+    enabled: boolean
+    groupDelays: string
+    modelDelays: string
+    billLoser: boolean
+  }
+}
+
+export function HedgeSettingsSection({
+  defaultValues,
+}: HedgeSettingsSectionProps) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const initialValues: Values = {
+    enabled: defaultValues.enabled,
+    groupDelays: formatJsonForTextarea(defaultValues.groupDelays),
+    modelDelays: formatJsonForTextarea(defaultValues.modelDelays),
+    billLoser: defaultValues.billLoser,
+  }
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema),
+    defaultValues: initialValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = [
+      {
+        key: 'hedge_setting.enabled',
+        value: String(values.enabled),
+        previous: String(defaultValues.enabled),
+      },
+      {
+        key: 'hedge_setting.group_delays',
+        value: normalizeJsonString(values.groupDelays) || '{}',
+        previous: normalizeJsonString(defaultValues.groupDelays) || '{}',
+      },
+      {
+        key: 'hedge_setting.model_delays',
+        value: normalizeJsonString(values.modelDelays) || '{}',
+        previous: normalizeJsonString(defaultValues.modelDelays) || '{}',
+      },
+      {
+        key: 'hedge_setting.bill_loser',
+        value: String(values.billLoser),
+        previous: String(defaultValues.billLoser),
+      },
+    ].filter((update) => update.value !== update.previous)
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync({ key: update.key, value: update.value })
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Hedged Requests')}
+      description={t(
+        'Race a second channel when the first one is slow to respond, and stream whichever answers first.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable hedged requests')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'Only groups and models listed below are hedged. The slower request is cancelled as soon as the other returns its first byte.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <>
+              <FormField
+                control={form.control}
+                name='groupDelays'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Group hedge delays')}</FormLabel>
+                    <FormControl>
+                      <Textarea
+                        rows={5}
+                        className='font-mono text-sm'
+                        placeholder={'{\n  "vip": 1500\n}'}
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'JSON map from group to the milliseconds to wait for the first byte before hedging.'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name='modelDelays'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Model hedge delays')}</FormLabel>
+                    <FormControl>
+                      <Textarea
+                        rows={5}
+                        className='font-mono text-sm'
+                        placeholder={'{\n  "gpt-4o-mini": 800\n}'}
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'JSON map from model to the milliseconds to wait before hedging. Applies to all groups and overrides group delays.'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name='billLoser'
+                render={({ field }) => (
+                  <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                    <div className='space-y-0.5'>
+                      <FormLabel className='text-base'>
+                        {t('Bill the discarded request')}
+                      </FormLabel>
+                      <FormDescription>
+                        {t(
+                          'When enabled, the request that lost the race is also charged for its estimated input. Otherwise only the request that was used is billed.'
+                        )}
+                      </FormDescription>
+                    </div>
+                    <FormControl>
+                      <Switch
+                        checked={field.value}
+                        onCheckedChange={field.onChange}
+                      />
+                    </FormControl>
+                  </FormItem>
+                )}
+              />
+            </>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save hedge settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -0,0 +1,203 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+import {
+  formatJsonForTextarea,
+  normalizeJsonString,
+  validateJsonString,
+} from '../models/utils'
+
+// 模型 -> 按顺序尝试的降级模型数组
+const chainMap = z.string().superRefine((value, ctx) => {
+  const result = validateJsonString(value, {
+    predicate: (parsed) =>
+      typeof parsed === 'object' &&
+      parsed !== null &&
+      !Array.isArray(parsed) &&
+      Object.values(parsed).every(
+        (chain) =>
+          Array.isArray(chain) &&
+          chain.every((model) => typeof model === 'string' && model.trim())
+      ),
+    predicateMessage: 'Each value must be an array of model names',
+  })
+  if (!result.valid) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: result.message || 'Invalid JSON',
+    })
+  }
+})
+
+const schema = z.object({
+  enabled: z.boolean(),
+  chains: chainMap,
+})
+
+type Values = z.infer<typeof schema>
+
+type ModelFallbackSettingsSectionProps = {
+  defaultValues: {
+    enabled: boolean
+    chains: string
+  }
+}
+
+export function ModelFallbackSettingsSection({
+  defaultValues,
+}: ModelFallbackSettingsSectionProps) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const initialValues: Values = {
+    enabled: defaultValues.enabled,
+    chains: formatJsonForTextarea(defaultValues.chains),
+  }
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema),
+    defaultValues: initialValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = [
+      {
+        key: 'model_fallback_setting.enabled',
+        value: String(values.enabled),
+        previous: String(defaultValues.enabled),
+      },
+      {
+        key: 'model_fallback_setting.chains',
+        value: normalizeJsonString(values.chains) || '{}',
+        previous: normalizeJsonString(defaultValues.chains) || '{}',
+      },
+    ].filter((update) => update.value !== update.previous)
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync({ key: update.key, value: update.value })
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Model Fallback')}
+      description={t(
+        'Fall back to another model when a model has no available channel or all retries fail.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable model fallback')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'The request is billed and logged as the model actually used. Tokens can override the chain for each model.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <FormField
+              control={form.control}
+              name='chains'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Fallback chains')}</FormLabel>
+                  <FormControl>
+                    <Textarea
+                      rows={6}
+                      className='font-mono text-sm'
+                      placeholder={
+                        '{\n  "gpt-4o": ["gpt-4.1", "claude-sonnet-4"]\n}'
+                      }
+                      {...field}
+                    />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      'JSON map from model to the fallback models to try in order. Requests in Claude or Gemini format are converted automatically when the fallback model is served by another provider.'
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save model fallback settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
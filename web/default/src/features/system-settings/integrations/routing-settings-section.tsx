@@ -0,0 +1,263 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import {
+  Select,
+  SelectContent,
+  SelectGroup,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+import {
+  formatJsonForTextarea,
+  normalizeJsonString,
+  validateJsonString,
+} from '../models/utils'
+
+const ROUTING_STRATEGIES = [
+  { value: 'weighted_random', labelKey: 'Weighted random' },
+  { value: 'least_latency', labelKey: 'Least latency' },
+  { value: 'least_cost', labelKey: 'Least cost' },
+  { value: 'least_outstanding', labelKey: 'Least outstanding requests' },
+] as const
+
+const STRATEGY_VALUES = ROUTING_STRATEGIES.map((item) => item.value)
+
+// 分组或模型 -> 策略的映射，策略必须是已知值
+const strategyMap = z.string().superRefine((value, ctx) => {
+  const result = validateJsonString(value, {
+    predicate: (parsed) =>
+      typeof parsed === 'object' &&
+      parsed !== null &&
+      !Array.isArray(parsed) &&
+      Object.values(parsed).every((strategy) =>
+        (STRATEGY_VALUES as readonly unknown[]).includes(strategy)
+      ),
+    predicateMessage: 'Each value must be a known routing strategy',
+  })
+  if (!result.valid) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: result.message || 'Invalid JSON',
+    })
+  }
+})
+
+const schema = z.object({
+  defaultStrategy: z.enum(STRATEGY_VALUES as [string, ...string[]]),
+  groupStrategies: strategyMap,
+  modelStrategies: strategyMap,
+})
+
+type Values = z.infer<typeof schema>
+
+type RoutingSettingsSectionProps = {
+  defaultValues: {
+    defaultStrategy: string
+    groupStrategies: string
+    modelStrategies: string
+  }
+}
+
+export function RoutingSettingsSection({
+  defaultValues,
+}: RoutingSettingsSectionProps) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const initialValues: Values = {
+    defaultStrategy: defaultValues.defaultStrategy || 'weighted_random',
+    groupStrategies: formatJsonForTextarea(defaultValues.groupStrategies),
+    modelStrategies: formatJsonForTextarea(defaultValues.modelStrategies),
+  }
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema),
+    defaultValues: initialValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+
+  async function onSubmit(values: Values) {
+    const normalized = {
+      defaultStrategy: values.defaultStrategy,
+      groupStrategies: normalizeJsonString(values.groupStrategies) || '{}',
+      modelStrategies: normalizeJsonString(values.modelStrategies) || '{}',
+    }
+    const updates = [
+      {
+        key: 'routing_setting.default_strategy',
+        value: normalized.defaultStrategy,
+        previous: defaultValues.defaultStrategy,
+      },
+      {
+        key: 'routing_setting.group_strategies',
+        value: normalized.groupStrategies,
+        previous: normalizeJsonString(defaultValues.groupStrategies) || '{}',
+      },
+      {
+        key: 'routing_setting.model_strategies',
+        value: normalized.modelStrategies,
+        previous: normalizeJsonString(defaultValues.modelStrategies) || '{}',
+      },
+    ].filter((update) => update.value !== update.previous)
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync({ key: update.key, value: update.value })
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Channel Routing')}
+      description={t(
+        'Choose how a channel is picked among channels of the same priority.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='defaultStrategy'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Default strategy')}</FormLabel>
+                <Select
+                  items={ROUTING_STRATEGIES.map((item) => ({
+                    value: item.value,
+                    label: t(item.labelKey),
+                  }))}
+                  onValueChange={field.onChange}
+                  value={field.value}
+                >
+                  <FormControl>
+                    <SelectTrigger className='w-full sm:w-72'>
+                      <SelectValue />
+                    </SelectTrigger>
+                  </FormControl>
+                  <SelectContent alignItemWithTrigger={false}>
+                    <SelectGroup>
+                      {ROUTING_STRATEGIES.map((item) => (
+                        <SelectItem key={item.value} value={item.value}>
+                          {t(item.labelKey)}
+                        </SelectItem>
+                      ))}
+                    </SelectGroup>
+                  </SelectContent>
+                </Select>
+                <FormDescription>
+                  {t(
+                    'Least latency prefers the lowest recent time to first token, least cost prefers the cheapest model after model mapping, and least outstanding requests prefers the least busy channel. Ties are broken by weight.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
+          <FormField
+            control={form.control}
+            name='groupStrategies'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Group strategies')}</FormLabel>
+                <FormControl>
+                  <Textarea
+                    rows={5}
+                    className='font-mono text-sm'
+                    placeholder={'{\n  "default": "least_latency"\n}'}
+                    {...field}
+                  />
+                </FormControl>
+                <FormDescription>
+                  {t(
+                    'JSON map from group to strategy. Overrides the default strategy.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
+          <FormField
+            control={form.control}
+            name='modelStrategies'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Model strategies')}</FormLabel>
+                <FormControl>
+                  <Textarea
+                    rows={5}
+                    className='font-mono text-sm'
+                    placeholder={'{\n  "gpt-4o": "least_cost"\n}'}
+                    {...field}
+                  />
+                </FormControl>
+                <FormDescription>
+                  {t(
+                    'JSON map from model to strategy. Applies to all groups and overrides group strategies.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save routing settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -0,0 +1,222 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  maxFailovers: z.coerce.number().int().min(1),
+  models: z.string(),
+})
+
+type Values = z.infer<typeof schema>
+
+type StreamFailoverSettingsSectionProps = {
+  defaultValues: {
+    enabled: boolean
+    maxFailovers: number
+    models: string
+  }
+}
+
+// 配置项为 JSON 数组，表单中每行一个模型
+function modelsToText(value: string): string {
+  try {
+    const parsed = JSON.parse(value || '[]')
+    return Array.isArray(parsed) ? parsed.join('\n') : ''
+  } catch {
+    return ''
+  }
+}
+
+function textToModels(value: string): string {
+  const models = value
+    .split('\n')
+    .map((model) => model.trim())
+    .filter(Boolean)
+  return JSON.stringify(Array.from(new Set(models)))
+}
+
+export function StreamFailoverSettingsSection({
+  defaultValues,
+}: StreamFailoverSettingsSectionProps) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const initialValues: Values = {
+    enabled: defaultValues.enabled,
+    maxFailovers: defaultValues.maxFailovers,
+    models: modelsToText(defaultValues.models),
+  }
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema),
+    defaultValues: initialValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+
+  async function onSubmit(values: Values) {
+    const updates = [
+      {
+        key: 'stream_failover_setting.enabled',
+        value: String(values.enabled),
+        previous: String(defaultValues.enabled),
+      },
+      {
+        key: 'stream_failover_setting.max_failovers',
+        value: String(values.maxFailovers),
+        previous: String(defaultValues.maxFailovers),
+      },
+      {
+        key: 'stream_failover_setting.models',
+        value: textToModels(values.models),
+        previous: textToModels(modelsToText(defaultValues.models)),
+      },
+    ].filter((update) => update.value !== update.previous)
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync({ key: update.key, value: update.value })
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Stream Failover')}
+      description={t(
+        'Continue a streaming response on another channel when the upstream disconnects halfway through.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable stream failover')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'Applies to streaming chat completions. The text already sent is passed to the next channel as an assistant prefill, and the continuation is appended to the same response. Usage from all attempts is billed together.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <>
+              <FormField
+                control={form.control}
+                name='maxFailovers'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Max failovers per request')}</FormLabel>
+                    <FormControl>
+                      <Input type='number' min={1} {...field} />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'Each failover also uses one retry, so the retry count is an upper bound as well.'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              <FormField
+                control={form.control}
+                name='models'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Failover models')}</FormLabel>
+                    <FormControl>
+                      <Textarea
+                        rows={5}
+                        className='font-mono text-sm'
+                        placeholder={'claude-sonnet-4\ngemini-2.5-pro'}
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        'One model per line. Leave empty to enable failover for all models. Models should accept a trailing assistant message as a prefill.'
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            </>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save stream failover settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
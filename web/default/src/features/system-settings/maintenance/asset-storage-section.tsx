@@ -0,0 +1,378 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import {
+  Select,
+  SelectContent,
+  SelectGroup,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import { Switch } from '@/components/ui/switch'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const STORAGE_MODES = [
+  { value: 'local', labelKey: 'Local disk' },
+  { value: 's3', labelKey: 'S3-compatible storage' },
+] as const
+
+const schema = z.object({
+  enabled: z.boolean(),
+  storageMode: z.enum(['local', 's3']),
+  s3Endpoint: z.string(),
+  s3Region: z.string(),
+  s3Bucket: z.string(),
+  s3AccessKeyId: z.string(),
+  s3AccessSecret: z.string(),
+  s3PathStyle: z.boolean(),
+  retentionDays: z.coerce.number().int().min(0),
+  maxFileSizeMb: z.coerce.number().int().min(1),
+  maxStorageMbPerUser: z.coerce.number().int().min(0),
+  signedUrlTtlMinutes: z.coerce.number().int().min(1),
+})
+
+type Values = z.infer<typeof schema>
+
+// 表单字段与 asset_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'asset_setting.enabled',
+  storageMode: 'asset_setting.storage_mode',
+  s3Endpoint: 'asset_setting.s3_endpoint',
+  s3Region: 'asset_setting.s3_region',
+  s3Bucket: 'asset_setting.s3_bucket',
+  s3AccessKeyId: 'asset_setting.s3_access_key_id',
+  s3AccessSecret: 'asset_setting.s3_access_secret',
+  s3PathStyle: 'asset_setting.s3_path_style',
+  retentionDays: 'asset_setting.retention_days',
+  maxFileSizeMb: 'asset_setting.max_file_size_mb',
+  maxStorageMbPerUser: 'asset_setting.max_storage_mb_per_user',
+  signedUrlTtlMinutes: 'asset_setting.signed_url_ttl_minutes',
+}
+
+export function AssetStorageSection({
+  defaultValues,
+}: {
+  // 密钥不会随选项列表返回，留空表示不修改
+  defaultValues: Omit<Values, 's3AccessSecret'>
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const initialValues: Values = { ...defaultValues, s3AccessSecret: '' }
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues: initialValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const enabled = form.watch('enabled')
+  const storageMode = form.watch('storageMode')
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== initialValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset({ ...values, s3AccessSecret: '' })
+  }
+
+  return (
+    <SettingsSection
+      title={t('Asset Storage')}
+      description={t(
+        'Keep generated videos and images after upstream links expire.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable asset storage')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'When a task succeeds, its result is downloaded to the storage backend and the result URL is rewritten to a signed, expiring URL on this site.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          {enabled && (
+            <>
+              <FormField
+                control={form.control}
+                name='storageMode'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Storage backend')}</FormLabel>
+                    <Select
+                      items={STORAGE_MODES.map((item) => ({
+                        value: item.value,
+                        label: t(item.labelKey),
+                      }))}
+                      onValueChange={field.onChange}
+                      value={field.value}
+                    >
+                      <FormControl>
+                        <SelectTrigger className='w-full sm:w-72'>
+                          <SelectValue />
+                        </SelectTrigger>
+                      </FormControl>
+                      <SelectContent alignItemWithTrigger={false}>
+                        <SelectGroup>
+                          {STORAGE_MODES.map((item) => (
+                            <SelectItem key={item.value} value={item.value}>
+                              {t(item.labelKey)}
+                            </SelectItem>
+                          ))}
+                        </SelectGroup>
+                      </SelectContent>
+                    </Select>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+
+              {storageMode === 's3' && (
+                <div className='grid gap-6 sm:grid-cols-3'>
+                  <FormField
+                    control={form.control}
+                    name='s3Endpoint'
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t('S3 endpoint')}</FormLabel>
+                        <FormControl>
+                          <Input placeholder='http://minio:9000' {...field} />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                  <FormField
+                    control={form.control}
+                    name='s3Region'
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t('Region')}</FormLabel>
+                        <FormControl>
+                          <Input placeholder='us-east-1' {...field} />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                  <FormField
+                    control={form.control}
+                    name='s3Bucket'
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t('Bucket')}</FormLabel>
+                        <FormControl>
+                          <Input {...field} />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                  <FormField
+                    control={form.control}
+                    name='s3AccessKeyId'
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t('Access key ID')}</FormLabel>
+                        <FormControl>
+                          <Input autoComplete='off' {...field} />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                  <FormField
+                    control={form.control}
+                    name='s3AccessSecret'
+                    render={({ field }) => (
+                      <FormItem>
+                        <FormLabel>{t('Secret access key')}</FormLabel>
+                        <FormControl>
+                          <Input
+                            autoComplete='off'
+                            type='password'
+                            placeholder={t('Enter new secret to update')}
+                            {...field}
+                          />
+                        </FormControl>
+                        <FormMessage />
+                      </FormItem>
+                    )}
+                  />
+                  <FormField
+                    control={form.control}
+                    name='s3PathStyle'
+                    render={({ field }) => (
+                      <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                        <div className='space-y-0.5'>
+                          <FormLabel>{t('Path-style addressing')}</FormLabel>
+                          <FormDescription>
+                            {t(
+                              'Usually required for self-hosted storage such as MinIO'
+                            )}
+                          </FormDescription>
+                        </div>
+                        <FormControl>
+                          <Switch
+                            checked={field.value}
+                            onCheckedChange={field.onChange}
+                          />
+                        </FormControl>
+                      </FormItem>
+                    )}
+                  />
+                </div>
+              )}
+
+              <div className='grid gap-6 sm:grid-cols-2'>
+                <FormField
+                  control={form.control}
+                  name='retentionDays'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Retention (days)')}</FormLabel>
+                      <FormControl>
+                        <Input type='number' min={0} {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(
+                          'Stored files are deleted after this many days, 0 keeps them forever'
+                        )}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <FormField
+                  control={form.control}
+                  name='signedUrlTtlMinutes'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Download link TTL (minutes)')}</FormLabel>
+                      <FormControl>
+                        <Input type='number' min={1} {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(
+                          'A new link is signed every time the task is fetched'
+                        )}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <FormField
+                  control={form.control}
+                  name='maxFileSizeMb'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Max file size (MB)')}</FormLabel>
+                      <FormControl>
+                        <Input type='number' min={1} {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t('Larger results keep the upstream URL')}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <FormField
+                  control={form.control}
+                  name='maxStorageMbPerUser'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Storage quota per user (MB)')}</FormLabel>
+                      <FormControl>
+                        <Input type='number' min={0} {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t(
+                          'Results are no longer stored once exceeded, 0 means unlimited'
+                        )}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+              </div>
+            </>
+          )}
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save asset storage settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
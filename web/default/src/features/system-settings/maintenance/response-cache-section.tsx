@@ -0,0 +1,219 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const schema = z.object({
+  enabled: z.boolean(),
+  deterministicOnly: z.boolean(),
+  ttlSeconds: z.coerce.number().int().min(1),
+  billingRatio: z.coerce.number().min(0),
+  maxEntryKb: z.coerce.number().int().min(1),
+})
+
+type Values = z.infer<typeof schema>
+
+// 表单字段与 response_cache_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'response_cache_setting.enabled',
+  deterministicOnly: 'response_cache_setting.deterministic_only',
+  ttlSeconds: 'response_cache_setting.ttl_seconds',
+  billingRatio: 'response_cache_setting.billing_ratio',
+  maxEntryKb: 'response_cache_setting.max_entry_kb',
+}
+
+export function ResponseCacheSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(schema) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Response Cache')}
+      description={t(
+        'Serve identical requests from cache without calling upstream channels.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <div className='space-y-4'>
+            <FormField
+              control={form.control}
+              name='enabled'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Enable response cache')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'Chat completion and embedding requests with the same group, model and body reuse the cached response.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='deterministicOnly'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Only cache deterministic requests')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'Chat requests are cached only when temperature is explicitly set to 0.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
+          </div>
+
+          <div className='grid gap-6 sm:grid-cols-3'>
+            <FormField
+              control={form.control}
+              name='ttlSeconds'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Cache TTL (seconds)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='billingRatio'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Cache hit billing ratio')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={0} step={0.01} {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      'Ratio of the original quota charged on a hit, 0 is free.'
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='maxEntryKb'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Max cached response size (KB)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t('Larger responses are not cached.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          </div>
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save response cache settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -0,0 +1,358 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const isValidModelThresholds = (value: string) => {
+  try {
+    const parsed = JSON.parse(value || '{}')
+    if (!parsed || typeof parsed !== 'object' || Array.isArray(parsed)) {
+      return false
+    }
+    return Object.values(parsed).every(
+      (threshold) =>
+        typeof threshold === 'number' && threshold > 0 && threshold <= 1
+    )
+  } catch {
+    return false
+  }
+}
+
+const createSemanticCacheSchema = (t: (key: string) => string) =>
+  z.object({
+    enabled: z.boolean(),
+    embeddingModel: z.string().min(1),
+    embeddingGroup: z.string(),
+    sharedGroups: z.string(),
+    timeoutSeconds: z.coerce.number().int().min(1),
+    defaultThreshold: z.coerce.number().gt(0).max(1),
+    modelThresholds: z.string().refine(isValidModelThresholds, {
+      message: t('Invalid JSON format or values out of allowed range'),
+    }),
+    ttlSeconds: z.coerce.number().int().min(1),
+    maxEntries: z.coerce.number().int().min(1),
+    maxEntryKb: z.coerce.number().int().min(1),
+    billingRatio: z.coerce.number().min(0),
+  })
+
+type Values = z.infer<ReturnType<typeof createSemanticCacheSchema>>
+
+// 表单字段与 semantic_cache_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'semantic_cache_setting.enabled',
+  embeddingModel: 'semantic_cache_setting.embedding_model',
+  embeddingGroup: 'semantic_cache_setting.embedding_group',
+  sharedGroups: 'semantic_cache_setting.shared_groups',
+  timeoutSeconds: 'semantic_cache_setting.timeout_seconds',
+  defaultThreshold: 'semantic_cache_setting.default_threshold',
+  modelThresholds: 'semantic_cache_setting.model_thresholds',
+  ttlSeconds: 'semantic_cache_setting.ttl_seconds',
+  maxEntries: 'semantic_cache_setting.max_entries',
+  maxEntryKb: 'semantic_cache_setting.max_entry_kb',
+  billingRatio: 'semantic_cache_setting.billing_ratio',
+}
+
+export function SemanticCacheSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(
+      createSemanticCacheSchema(t)
+    ) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  return (
+    <SettingsSection
+      title={t('Semantic Cache')}
+      description={t(
+        'Answer similar chat questions from cache using an embedding model.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <FormField
+            control={form.control}
+            name='enabled'
+            render={({ field }) => (
+              <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                <div className='space-y-0.5'>
+                  <FormLabel className='text-base'>
+                    {t('Enable semantic cache')}
+                  </FormLabel>
+                  <FormDescription>
+                    {t(
+                      'The last user message is embedded and compared with cached requests that share the same group, model and remaining context.'
+                    )}
+                  </FormDescription>
+                </div>
+                <FormControl>
+                  <Switch
+                    checked={field.value}
+                    onCheckedChange={field.onChange}
+                  />
+                </FormControl>
+              </FormItem>
+            )}
+          />
+
+          <div className='grid gap-6 sm:grid-cols-3'>
+            <FormField
+              control={form.control}
+              name='embeddingModel'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Embedding model')}</FormLabel>
+                  <FormControl>
+                    <Input placeholder='text-embedding-3-small' {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t('A channel must serve /v1/embeddings for this model.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='embeddingGroup'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Embedding channel group')}</FormLabel>
+                  <FormControl>
+                    <Input {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      "Leave empty to select the channel with the request's group."
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='timeoutSeconds'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Embedding timeout (seconds)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='sharedGroups'
+              render={({ field }) => (
+                <FormItem className='sm:col-span-3'>
+                  <FormLabel>{t('Groups sharing cache across users')}</FormLabel>
+                  <FormControl>
+                    <Input placeholder='default,vip' {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      'Comma-separated. Other groups keep the cache per user, so answers are never shared between users.'
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='defaultThreshold'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Default similarity threshold')}</FormLabel>
+                  <FormControl>
+                    <Input
+                      type='number'
+                      min={0}
+                      max={1}
+                      step={0.01}
+                      {...field}
+                    />
+                  </FormControl>
+                  <FormDescription>
+                    {t('Minimum cosine similarity required for a hit.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='ttlSeconds'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Cache TTL (seconds)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='billingRatio'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Cache hit billing ratio')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={0} step={0.01} {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t(
+                      'Ratio of the original quota charged on a hit, 0 is free.'
+                    )}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='maxEntries'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Max cache entries')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t('The oldest entries are evicted beyond this limit.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='maxEntryKb'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Max cached response size (KB)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t('Larger responses are not cached.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          </div>
+
+          <FormField
+            control={form.control}
+            name='modelThresholds'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Model similarity thresholds')}</FormLabel>
+                <FormControl>
+                  <Textarea
+                    rows={6}
+                    placeholder={'{\n  "gpt-4o-mini": 0.92\n}'}
+                    className='font-mono'
+                    {...field}
+                  />
+                </FormControl>
+                <FormDescription>
+                  {t(
+                    'JSON object mapping model names to similarity thresholds between 0 and 1; models not listed use the default threshold.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save semantic cache settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -42,6 +42,27 @@ const defaultOperationsSettings: OperationsSettings = {
     '100-199,300-399,401-407,409-499,500-503,505-523,525-599',
   'monitor_setting.auto_test_channel_enabled': false,
   'monitor_setting.auto_test_channel_minutes': 10,
+  'circuit_breaker_setting.enabled': true,
+  'circuit_breaker_setting.window_seconds': 60,
+  'circuit_breaker_setting.min_requests': 10,
+  'circuit_breaker_setting.failure_rate_percent': 50,
+  'circuit_breaker_setting.slow_call_ms': 60000,
+  'circuit_breaker_setting.slow_call_rate_percent': 80,
+  'circuit_breaker_setting.open_seconds': 30,
+  'circuit_breaker_setting.probe_interval_seconds': 5,
+  'circuit_breaker_setting.half_open_successes': 3,
+  'routing_setting.default_strategy': 'weighted_random',
+  'routing_setting.group_strategies': '{}',
+  'routing_setting.model_strategies': '{}',
+  'hedge_setting.enabled': false,
+  'hedge_setting.group_delays': '{}',
+  'hedge_setting.model_delays': '{}',
+  'hedge_setting.bill_loser': false,
+  'stream_failover_setting.enabled': false,
+  'stream_failover_setting.max_failovers': 1,
+  'stream_failover_setting.models': '[]',
+  'model_fallback_setting.enabled': false,
+  'model_fallback_setting.chains': '{}',
   SMTPServer: '',
   SMTPPort: '',
   SMTPAccount: '',
@@ -65,6 +86,33 @@ const defaultOperationsSettings: OperationsSettings = {
   'perf_metrics_setting.flush_interval': 5,
   'perf_metrics_setting.bucket_time': 'hour',
   'perf_metrics_setting.retention_days': 0,
+  'response_cache_setting.enabled': false,
+  'response_cache_setting.deterministic_only': true,
+  'response_cache_setting.ttl_seconds': 3600,
+  'response_cache_setting.billing_ratio': 0,
+  'response_cache_setting.max_entry_kb': 1024,
+  'semantic_cache_setting.enabled': false,
+  'semantic_cache_setting.embedding_model': 'text-embedding-3-small',
+  'semantic_cache_setting.embedding_group': '',
+  'semantic_cache_setting.shared_groups': '',
+  'semantic_cache_setting.timeout_seconds': 10,
+  'semantic_cache_setting.default_threshold': 0.95,
+  'semantic_cache_setting.model_thresholds': '{}',
+  'semantic_cache_setting.ttl_seconds': 86400,
+  'semantic_cache_setting.max_entries': 10000,
+  'semantic_cache_setting.max_entry_kb': 48,
+  'semantic_cache_setting.billing_ratio': 0,
+  'asset_setting.enabled': false,
+  'asset_setting.storage_mode': 'local',
+  'asset_setting.s3_endpoint': '',
+  'asset_setting.s3_region': 'us-east-1',
+  'asset_setting.s3_bucket': '',
+  'asset_setting.s3_access_key_id': '',
+  'asset_setting.s3_path_style': true,
+  'asset_setting.retention_days': 7,
+  'asset_setting.max_file_size_mb': 512,
+  'asset_setting.max_storage_mb_per_user': 0,
+  'asset_setting.signed_url_ttl_minutes': 60,
 }
 
 export function OperationsSettings() {
@@ -91,10 +139,18 @@ export function OperationsSettings() {
   const activeSection = (params?.section ?? OPERATIONS_DEFAULT_SECTION) as
     | 'behavior'
     | 'monitoring'
+    | 'circuit-breaker'
+    | 'routing'
+    | 'hedge'
+    | 'stream-failover'
+    | 'model-fallback'
     | 'email'
     | 'worker'
     | 'logs'
     | 'performance'
+    | 'response-cache'
+    | 'semantic-cache'
+    | 'asset-storage'
     | 'update-checker'
   const sectionContent = getOperationsSectionContent(
     activeSection,
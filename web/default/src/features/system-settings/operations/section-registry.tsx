@@ -17,11 +17,19 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 For commercial licensing, please contact support@quantumnous.com
 */
 import { SystemBehaviorSection } from '../general/system-behavior-section'
+import { CircuitBreakerSection } from '../integrations/circuit-breaker-section'
 import { EmailSettingsSection } from '../integrations/email-settings-section'
+import { HedgeSettingsSection } from '../integrations/hedge-settings-section'
+import { ModelFallbackSettingsSection } from '../integrations/model-fallback-settings-section'
 import { MonitoringSettingsSection } from '../integrations/monitoring-settings-section'
+import { RoutingSettingsSection } from '../integrations/routing-settings-section'
+import { StreamFailoverSettingsSection } from '../integrations/stream-failover-settings-section'
 import { WorkerSettingsSection } from '../integrations/worker-settings-section'
+import { AssetStorageSection } from '../maintenance/asset-storage-section'
 import { LogSettingsSection } from '../maintenance/log-settings-section'
 import { PerformanceSection } from '../maintenance/performance-section'
+import { ResponseCacheSection } from '../maintenance/response-cache-section'
+import { SemanticCacheSection } from '../maintenance/semantic-cache-section'
 import { UpdateCheckerSection } from '../maintenance/update-checker-section'
 import type { OperationsSettings } from '../types'
 import { createSectionRegistry } from '../utils/section-registry'
@@ -65,6 +73,86 @@ const OPERATIONS_SECTIONS = [
       />
     ),
   },
+  {
+    id: 'circuit-breaker',
+    titleKey: 'Circuit Breaker',
+    descriptionKey: 'Health scoring and circuit breaking for channel selection',
+    build: (settings: OperationsSettings) => (
+      <CircuitBreakerSection
+        defaultValues={{
+          enabled: settings['circuit_breaker_setting.enabled'],
+          windowSeconds: settings['circuit_breaker_setting.window_seconds'],
+          minRequests: settings['circuit_breaker_setting.min_requests'],
+          failureRatePercent:
+            settings['circuit_breaker_setting.failure_rate_percent'],
+          slowCallMs: settings['circuit_breaker_setting.slow_call_ms'],
+          slowCallRatePercent:
+            settings['circuit_breaker_setting.slow_call_rate_percent'],
+          openSeconds: settings['circuit_breaker_setting.open_seconds'],
+          probeIntervalSeconds:
+            settings['circuit_breaker_setting.probe_interval_seconds'],
+          halfOpenSuccesses:
+            settings['circuit_breaker_setting.half_open_successes'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'routing',
+    titleKey: 'Channel Routing',
+    descriptionKey: 'Strategy for choosing among channels of the same priority',
+    build: (settings: OperationsSettings) => (
+      <RoutingSettingsSection
+        defaultValues={{
+          defaultStrategy: settings['routing_setting.default_strategy'],
+          groupStrategies: settings['routing_setting.group_strategies'],
+          modelStrategies: settings['routing_setting.model_strategies'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'hedge',
+    titleKey: 'Hedged Requests',
+    descriptionKey: 'Race a second channel for latency-critical models',
+    build: (settings: OperationsSettings) => (
+      <HedgeSettingsSection
+        defaultValues={{
+          enabled: settings['hedge_setting.enabled'],
+          groupDelays: settings['hedge_setting.group_delays'],
+          modelDelays: settings['hedge_setting.model_delays'],
+          billLoser: settings['hedge_setting.bill_loser'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'stream-failover',
+    titleKey: 'Stream Failover',
+    descriptionKey: 'Continue interrupted streams on another channel',
+    build: (settings: OperationsSettings) => (
+      <StreamFailoverSettingsSection
+        defaultValues={{
+          enabled: settings['stream_failover_setting.enabled'],
+          maxFailovers: settings['stream_failover_setting.max_failovers'],
+          models: settings['stream_failover_setting.models'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'model-fallback',
+    titleKey: 'Model Fallback',
+    descriptionKey: 'Fall back to other models when a model is unavailable',
+    build: (settings: OperationsSettings) => (
+      <ModelFallbackSettingsSection
+        defaultValues={{
+          enabled: settings['model_fallback_setting.enabled'],
+          chains: settings['model_fallback_setting.chains'],
+        }}
+      />
+    ),
+  },
   {
     id: 'email',
     titleKey: 'SMTP Email',
@@ -143,6 +231,70 @@ const OPERATIONS_SECTIONS = [
       />
     ),
   },
+  {
+    id: 'response-cache',
+    titleKey: 'Response Cache',
+    descriptionKey: 'Cache responses of identical deterministic requests',
+    build: (settings: OperationsSettings) => (
+      <ResponseCacheSection
+        defaultValues={{
+          enabled: settings['response_cache_setting.enabled'],
+          deterministicOnly:
+            settings['response_cache_setting.deterministic_only'],
+          ttlSeconds: settings['response_cache_setting.ttl_seconds'],
+          billingRatio: settings['response_cache_setting.billing_ratio'],
+          maxEntryKb: settings['response_cache_setting.max_entry_kb'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'semantic-cache',
+    titleKey: 'Semantic Cache',
+    descriptionKey: 'Answer similar chat questions from cache',
+    build: (settings: OperationsSettings) => (
+      <SemanticCacheSection
+        defaultValues={{
+          enabled: settings['semantic_cache_setting.enabled'],
+          embeddingModel: settings['semantic_cache_setting.embedding_model'],
+          embeddingGroup: settings['semantic_cache_setting.embedding_group'],
+          sharedGroups: settings['semantic_cache_setting.shared_groups'],
+          timeoutSeconds: settings['semantic_cache_setting.timeout_seconds'],
+          defaultThreshold:
+            settings['semantic_cache_setting.default_threshold'],
+          modelThresholds:
+            settings['semantic_cache_setting.model_thresholds'],
+          ttlSeconds: settings['semantic_cache_setting.ttl_seconds'],
+          maxEntries: settings['semantic_cache_setting.max_entries'],
+          maxEntryKb: settings['semantic_cache_setting.max_entry_kb'],
+          billingRatio: settings['semantic_cache_setting.billing_ratio'],
+        }}
+      />
+    ),
+  },
+  {
+    id: 'asset-storage',
+    titleKey: 'Asset Storage',
+    descriptionKey: 'Persist generated videos and images',
+    build: (settings: OperationsSettings) => (
+      <AssetStorageSection
+        defaultValues={{
+          enabled: settings['asset_setting.enabled'],
+          storageMode: settings['asset_setting.storage_mode'],
+          s3Endpoint: settings['asset_setting.s3_endpoint'],
+          s3Region: settings['asset_setting.s3_region'],
+          s3Bucket: settings['asset_setting.s3_bucket'],
+          s3AccessKeyId: settings['asset_setting.s3_access_key_id'],
+          s3PathStyle: settings['asset_setting.s3_path_style'],
+          retentionDays: settings['asset_setting.retention_days'],
+          maxFileSizeMb: settings['asset_setting.max_file_size_mb'],
+          maxStorageMbPerUser:
+            settings['asset_setting.max_storage_mb_per_user'],
+          signedUrlTtlMinutes: settings['asset_setting.signed_url_ttl_minutes'],
+        }}
+      />
+    ),
+  },
   {
     id: 'update-checker',
     titleKey: 'System maintenance',
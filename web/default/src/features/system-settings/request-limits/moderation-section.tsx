@@ -0,0 +1,398 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { z } from 'zod'
+import { useForm, type Resolver } from 'react-hook-form'
+import { zodResolver } from '@hookform/resolvers/zod'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { Button } from '@/components/ui/button'
+import {
+  Form,
+  FormControl,
+  FormDescription,
+  FormField,
+  FormItem,
+  FormLabel,
+  FormMessage,
+} from '@/components/ui/form'
+import { Input } from '@/components/ui/input'
+import {
+  Select,
+  SelectContent,
+  SelectGroup,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from '@/components/ui/select'
+import { Switch } from '@/components/ui/switch'
+import { Textarea } from '@/components/ui/textarea'
+import { SettingsSection } from '../components/settings-section'
+import { useUpdateOption } from '../hooks/use-update-option'
+
+const MODERATION_ACTIONS = ['allow', 'flag', 'block'] as const
+
+const isValidGroupActions = (value: string) => {
+  try {
+    const parsed = JSON.parse(value || '{}')
+    if (!parsed || typeof parsed !== 'object' || Array.isArray(parsed)) {
+      return false
+    }
+    return Object.values(parsed).every((action) =>
+      MODERATION_ACTIONS.includes(action as (typeof MODERATION_ACTIONS)[number])
+    )
+  } catch {
+    return false
+  }
+}
+
+const createModerationSchema = (t: (key: string) => string) =>
+  z.object({
+    enabled: z.boolean(),
+    failOpen: z.boolean(),
+    timeoutSeconds: z.coerce.number().int().min(1),
+    provider: z.enum(['model', 'webhook']),
+    model: z.string(),
+    group: z.string(),
+    webhookUrl: z.string(),
+    webhookSecret: z.string(),
+    defaultAction: z.enum(MODERATION_ACTIONS),
+    groupActions: z.string().refine(isValidGroupActions, {
+      message: t('Invalid JSON format or values out of allowed range'),
+    }),
+  })
+
+type Values = z.infer<ReturnType<typeof createModerationSchema>>
+
+// 表单字段与 moderation_setting 配置项的对应关系
+const OPTION_KEYS: Record<keyof Values, string> = {
+  enabled: 'moderation_setting.enabled',
+  failOpen: 'moderation_setting.fail_open',
+  timeoutSeconds: 'moderation_setting.timeout_seconds',
+  provider: 'moderation_setting.provider',
+  model: 'moderation_setting.model',
+  group: 'moderation_setting.group',
+  webhookUrl: 'moderation_setting.webhook_url',
+  webhookSecret: 'moderation_setting.webhook_secret',
+  defaultAction: 'moderation_setting.default_action',
+  groupActions: 'moderation_setting.group_actions',
+}
+
+export function ModerationSection({
+  defaultValues,
+}: {
+  defaultValues: Values
+}) {
+  const { t } = useTranslation()
+  const updateOption = useUpdateOption()
+
+  const form = useForm<Values>({
+    resolver: zodResolver(
+      createModerationSchema(t)
+    ) as unknown as Resolver<Values>,
+    defaultValues,
+  })
+
+  const { isDirty, isSubmitting } = form.formState
+  const provider = form.watch('provider')
+
+  async function onSubmit(values: Values) {
+    const updates = (Object.keys(OPTION_KEYS) as Array<keyof Values>)
+      .filter((key) => values[key] !== defaultValues[key])
+      .map((key) => ({ key: OPTION_KEYS[key], value: String(values[key]) }))
+
+    if (updates.length === 0) {
+      toast.info(t('No changes to save'))
+      return
+    }
+
+    for (const update of updates) {
+      await updateOption.mutateAsync(update)
+    }
+
+    form.reset(values)
+  }
+
+  const actionLabels: Record<(typeof MODERATION_ACTIONS)[number], string> = {
+    allow: t('Do not moderate'),
+    flag: t('Flag and log'),
+    block: t('Block request'),
+  }
+
+  return (
+    <SettingsSection
+      title={t('Content Moderation')}
+      description={t(
+        'Check prompts with a moderation model or webhook before relaying.'
+      )}
+    >
+      <Form {...form}>
+        <form
+          onSubmit={form.handleSubmit(onSubmit)}
+          autoComplete='off'
+          className='space-y-6'
+        >
+          <div className='space-y-4'>
+            <FormField
+              control={form.control}
+              name='enabled'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Enable content moderation')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'Prompts are checked before requests are dispatched to upstream channels.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='failOpen'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Allow requests when moderation fails')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'When disabled, requests are rejected if the moderation service fails.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
+          </div>
+
+          <div className='grid gap-6 sm:grid-cols-2'>
+            <FormField
+              control={form.control}
+              name='provider'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Moderation provider')}</FormLabel>
+                  <FormControl>
+                    <Select
+                      items={[
+                        { value: 'model', label: t('Moderation model') },
+                        { value: 'webhook', label: 'Webhook' },
+                      ]}
+                      value={field.value}
+                      onValueChange={field.onChange}
+                    >
+                      <SelectTrigger>
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent alignItemWithTrigger={false}>
+                        <SelectGroup>
+                          <SelectItem value='model'>
+                            {t('Moderation model')}
+                          </SelectItem>
+                          <SelectItem value='webhook'>Webhook</SelectItem>
+                        </SelectGroup>
+                      </SelectContent>
+                    </Select>
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='timeoutSeconds'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Moderation timeout (seconds)')}</FormLabel>
+                  <FormControl>
+                    <Input type='number' min={1} {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='model'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Moderation model')}</FormLabel>
+                  <FormControl>
+                    <Input placeholder='omni-moderation-latest' {...field} />
+                  </FormControl>
+                  <FormDescription>
+                    {t('A channel must serve /v1/moderations for this model.')}
+                  </FormDescription>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+
+            {provider === 'model' ? (
+              <FormField
+                control={form.control}
+                name='group'
+                render={({ field }) => (
+                  <FormItem>
+                    <FormLabel>{t('Moderation channel group')}</FormLabel>
+                    <FormControl>
+                      <Input {...field} />
+                    </FormControl>
+                    <FormDescription>
+                      {t(
+                        "Leave empty to select the channel with the request's group."
+                      )}
+                    </FormDescription>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+            ) : (
+              <>
+                <FormField
+                  control={form.control}
+                  name='webhookUrl'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Webhook URL')}</FormLabel>
+                      <FormControl>
+                        <Input
+                          placeholder='https://example.com/moderate'
+                          {...field}
+                        />
+                      </FormControl>
+                      <FormDescription>
+                        {t('The response must use the /v1/moderations format.')}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+
+                <FormField
+                  control={form.control}
+                  name='webhookSecret'
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormLabel>{t('Webhook Secret')}</FormLabel>
+                      <FormControl>
+                        <Input type='password' {...field} />
+                      </FormControl>
+                      <FormDescription>
+                        {t('Sent as a Bearer token, optional.')}
+                      </FormDescription>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+              </>
+            )}
+
+            <FormField
+              control={form.control}
+              name='defaultAction'
+              render={({ field }) => (
+                <FormItem>
+                  <FormLabel>{t('Default moderation policy')}</FormLabel>
+                  <FormControl>
+                    <Select
+                      items={MODERATION_ACTIONS.map((action) => ({
+                        value: action,
+                        label: actionLabels[action],
+                      }))}
+                      value={field.value}
+                      onValueChange={field.onChange}
+                    >
+                      <SelectTrigger>
+                        <SelectValue />
+                      </SelectTrigger>
+                      <SelectContent alignItemWithTrigger={false}>
+                        <SelectGroup>
+                          {MODERATION_ACTIONS.map((action) => (
+                            <SelectItem key={action} value={action}>
+                              {actionLabels[action]}
+                            </SelectItem>
+                          ))}
+                        </SelectGroup>
+                      </SelectContent>
+                    </Select>
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          </div>
+
+          <FormField
+            control={form.control}
+            name='groupActions'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Group moderation policies')}</FormLabel>
+                <FormControl>
+                  <Textarea
+                    rows={6}
+                    placeholder={'{\n  "vip": "allow",\n  "default": "block"\n}'}
+                    className='font-mono'
+                    {...field}
+                  />
+                </FormControl>
+                <FormDescription>
+                  {t(
+                    'JSON object mapping group names to allow, flag or block. Flagged requests are recorded in the usage log; groups not listed use the default policy.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
+          <Button
+            type='submit'
+            disabled={!isDirty || updateOption.isPending || isSubmitting}
+          >
+            {updateOption.isPending || isSubmitting
+              ? t('Saving...')
+              : t('Save moderation settings')}
+          </Button>
+        </form>
+      </Form>
+    </SettingsSection>
+  )
+}
@@ -22,6 +22,7 @@ import { useForm } from 'react-hook-form'
 import { zodResolver } from '@hookform/resolvers/zod'
 import { useTranslation } from 'react-i18next'
 import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
 import {
   Form,
   FormControl,
@@ -39,6 +40,9 @@ import { useUpdateOption } from '../hooks/use-update-option'
 const sensitiveSchema = z.object({
   CheckSensitiveEnabled: z.boolean(),
   CheckSensitiveOnPromptEnabled: z.boolean(),
+  CheckSensitiveOnCompletionEnabled: z.boolean(),
+  StopOnSensitiveEnabled: z.boolean(),
+  StreamCacheQueueLength: z.number().int().min(0),
   SensitiveWords: z.string().optional(),
 })
 
@@ -130,8 +134,85 @@ export function SensitiveWordsSection({
                 </FormItem>
               )}
             />
+
+            <FormField
+              control={form.control}
+              name='CheckSensitiveOnCompletionEnabled'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Inspect model output')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'When enabled, streamed and non-streamed responses are scanned before reaching clients.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
+
+            <FormField
+              control={form.control}
+              name='StopOnSensitiveEnabled'
+              render={({ field }) => (
+                <FormItem className='flex flex-row items-center justify-between rounded-lg border p-4'>
+                  <div className='space-y-0.5'>
+                    <FormLabel className='text-base'>
+                      {t('Stop output on match')}
+                    </FormLabel>
+                    <FormDescription>
+                      {t(
+                        'Ends the response with an error when output contains a blocked keyword. When disabled, matches are replaced with **###**.'
+                      )}
+                    </FormDescription>
+                  </div>
+                  <FormControl>
+                    <Switch
+                      checked={field.value}
+                      onCheckedChange={field.onChange}
+                    />
+                  </FormControl>
+                </FormItem>
+              )}
+            />
           </div>
 
+          <FormField
+            control={form.control}
+            name='StreamCacheQueueLength'
+            render={({ field }) => (
+              <FormItem>
+                <FormLabel>{t('Stream cache chunks')}</FormLabel>
+                <FormControl>
+                  <Input
+                    type='number'
+                    min={0}
+                    step={1}
+                    value={field.value}
+                    onChange={(e) =>
+                      field.onChange(parseInt(e.target.value) || 0)
+                    }
+                  />
+                </FormControl>
+                <FormDescription>
+                  {t(
+                    'Delays sending this many chunks so keywords split across chunks can be handled before they reach clients. 0 disables the cache.'
+                  )}
+                </FormDescription>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+
           <FormField
             control={form.control}
             name='SensitiveWords'
@@ -31,7 +31,20 @@ const defaultSecuritySettings: SecuritySettings = {
   ModelRequestRateLimitGroup: '',
   CheckSensitiveEnabled: false,
   CheckSensitiveOnPromptEnabled: false,
+  CheckSensitiveOnCompletionEnabled: false,
+  StopOnSensitiveEnabled: true,
+  StreamCacheQueueLength: 0,
   SensitiveWords: '',
+  'moderation_setting.enabled': false,
+  'moderation_setting.provider': 'model',
+  'moderation_setting.model': 'omni-moderation-latest',
+  'moderation_setting.group': '',
+  'moderation_setting.webhook_url': '',
+  'moderation_setting.webhook_secret': '',
+  'moderation_setting.timeout_seconds': 10,
+  'moderation_setting.fail_open': true,
+  'moderation_setting.default_action': 'flag',
+  'moderation_setting.group_actions': '{}',
   'fetch_setting.enable_ssrf_protection': true,
   'fetch_setting.allow_private_ip': false,
   'fetch_setting.domain_filter_mode': false,
@@ -16,6 +16,7 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 For commercial licensing, please contact support@quantumnous.com
 */
+import { ModerationSection } from '../request-limits/moderation-section'
 import { RateLimitSection } from '../request-limits/rate-limit-section'
 import { SensitiveWordsSection } from '../request-limits/sensitive-words-section'
 import { SSRFSection } from '../request-limits/ssrf-section'
@@ -50,11 +51,36 @@ const SECURITY_SECTIONS = [
         defaultValues={{
           CheckSensitiveEnabled: settings.CheckSensitiveEnabled,
           CheckSensitiveOnPromptEnabled: settings.CheckSensitiveOnPromptEnabled,
+          CheckSensitiveOnCompletionEnabled:
+            settings.CheckSensitiveOnCompletionEnabled,
+          StopOnSensitiveEnabled: settings.StopOnSensitiveEnabled,
+          StreamCacheQueueLength: settings.StreamCacheQueueLength,
           SensitiveWords: settings.SensitiveWords,
         }}
       />
     ),
   },
+  {
+    id: 'moderation',
+    titleKey: 'Content Moderation',
+    descriptionKey: 'Configure content moderation before relaying',
+    build: (settings: SecuritySettings) => (
+      <ModerationSection
+        defaultValues={{
+          enabled: settings['moderation_setting.enabled'],
+          failOpen: settings['moderation_setting.fail_open'],
+          timeoutSeconds: settings['moderation_setting.timeout_seconds'],
+          provider: settings['moderation_setting.provider'],
+          model: settings['moderation_setting.model'],
+          group: settings['moderation_setting.group'],
+          webhookUrl: settings['moderation_setting.webhook_url'],
+          webhookSecret: settings['moderation_setting.webhook_secret'],
+          defaultAction: settings['moderation_setting.default_action'],
+          groupActions: settings['moderation_setting.group_actions'],
+        }}
+      />
+    ),
+  },
   {
     id: 'ssrf',
     titleKey: 'SSRF Protection',
@@ -266,6 +266,20 @@ export type BillingSettings = {
   'checkin_setting.enabled': boolean
   'checkin_setting.min_quota': number
   'checkin_setting.max_quota': number
+  'budget_setting.enabled': boolean
+  'budget_setting.timezone': string
+  'budget_setting.default_soft_percent': number
+  'invoice_setting.enabled': boolean
+  'invoice_setting.seller_name': string
+  'invoice_setting.seller_address': string
+  'invoice_setting.seller_tax_id': string
+  'invoice_setting.seller_email': string
+  'invoice_setting.number_prefix': string
+  'invoice_setting.currency': string
+  'invoice_setting.footer': string
+  'invoice_setting.timezone': string
+  'usage_report_setting.enabled': boolean
+  'usage_report_setting.timezone': string
 }
 
 export type OperationsSettings = {
@@ -282,6 +296,27 @@ export type OperationsSettings = {
   AutomaticRetryStatusCodes: string
   'monitor_setting.auto_test_channel_enabled': boolean
   'monitor_setting.auto_test_channel_minutes': number
+  'circuit_breaker_setting.enabled': boolean
+  'circuit_breaker_setting.window_seconds': number
+  'circuit_breaker_setting.min_requests': number
+  'circuit_breaker_setting.failure_rate_percent': number
+  'circuit_breaker_setting.slow_call_ms': number
+  'circuit_breaker_setting.slow_call_rate_percent': number
+  'circuit_breaker_setting.open_seconds': number
+  'circuit_breaker_setting.probe_interval_seconds': number
+  'circuit_breaker_setting.half_open_successes': number
+  'routing_setting.default_strategy': string
+  'routing_setting.group_strategies': string
+  'routing_setting.model_strategies': string
+  'hedge_setting.enabled': boolean
+  'hedge_setting.group_delays': string
+  'hedge_setting.model_delays': string
+  'hedge_setting.bill_loser': boolean
+  'stream_failover_setting.enabled': boolean
+  'stream_failover_setting.max_failovers': number
+  'stream_failover_setting.models': string
+  'model_fallback_setting.enabled': boolean
+  'model_fallback_setting.chains': string
   SMTPServer: string
   SMTPPort: string
   SMTPAccount: string
@@ -305,6 +340,33 @@ export type OperationsSettings = {
   'perf_metrics_setting.flush_interval': number
   'perf_metrics_setting.bucket_time': 'hour' | 'minute' | '5min'
   'perf_metrics_setting.retention_days': number
+  'response_cache_setting.enabled': boolean
+  'response_cache_setting.deterministic_only': boolean
+  'response_cache_setting.ttl_seconds': number
+  'response_cache_setting.billing_ratio': number
+  'response_cache_setting.max_entry_kb': number
+  'semantic_cache_setting.enabled': boolean
+  'semantic_cache_setting.embedding_model': string
+  'semantic_cache_setting.embedding_group': string
+  'semantic_cache_setting.shared_groups': string
+  'semantic_cache_setting.timeout_seconds': number
+  'semantic_cache_setting.default_threshold': number
+  'semantic_cache_setting.model_thresholds': string
+  'semantic_cache_setting.ttl_seconds': number
+  'semantic_cache_setting.max_entries': number
+  'semantic_cache_setting.max_entry_kb': number
+  'semantic_cache_setting.billing_ratio': number
+  'asset_setting.enabled': boolean
+  'asset_setting.storage_mode': 'local' | 's3'
+  'asset_setting.s3_endpoint': string
+  'asset_setting.s3_region': string
+  'asset_setting.s3_bucket': string
+  'asset_setting.s3_access_key_id': string
+  'asset_setting.s3_path_style': boolean
+  'asset_setting.retention_days': number
+  'asset_setting.max_file_size_mb': number
+  'asset_setting.max_storage_mb_per_user': number
+  'asset_setting.signed_url_ttl_minutes': number
 }
 
 export type SecuritySettings = {
@@ -315,7 +377,20 @@ export type SecuritySettings = {
   ModelRequestRateLimitGroup: string
   CheckSensitiveEnabled: boolean
   CheckSensitiveOnPromptEnabled: boolean
+  CheckSensitiveOnCompletionEnabled: boolean
+  StopOnSensitiveEnabled: boolean
+  StreamCacheQueueLength: number
   SensitiveWords: string
+  'moderation_setting.enabled': boolean
+  'moderation_setting.provider': 'model' | 'webhook'
+  'moderation_setting.model': string
+  'moderation_setting.group': string
+  'moderation_setting.webhook_url': string
+  'moderation_setting.webhook_secret': string
+  'moderation_setting.timeout_seconds': number
+  'moderation_setting.fail_open': boolean
+  'moderation_setting.default_action': 'allow' | 'flag' | 'block'
+  'moderation_setting.group_actions': string
   'fetch_setting.enable_ssrf_protection': boolean
   'fetch_setting.allow_private_ip': boolean
   'fetch_setting.domain_filter_mode': boolean
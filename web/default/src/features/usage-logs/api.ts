@@ -73,16 +73,49 @@ export const getAllLogs = (params: GetLogsParams = {}) =>
   fetchLogs('/api/log', params, true)
 
 export const getUserLogs = (
-  params: Omit<GetLogsParams, 'username' | 'channel'> = {}
+  params: Omit<GetLogsParams, 'username' | 'channel' | 'organization_id'> = {}
 ) => fetchLogs('/api/log', params, false)
 
 export const getLogStats = (params: GetLogStatsParams = {}) =>
   fetchLogStats('/api/log', params, true)
 
 export const getUserLogStats = (
-  params: Omit<GetLogStatsParams, 'username' | 'channel'> = {}
+  params: Omit<GetLogStatsParams, 'username' | 'channel' | 'organization_id'> = {}
 ) => fetchLogStats('/api/log', params, false)
 
+/**
+ * Stream an export of the filtered logs ('logs') or of their hourly usage
+ * summary ('usage') as CSV. Failures are returned as JSON, so
+ * rethrow them with the server message.
+ */
+export async function exportLogs(
+  kind: 'logs' | 'usage',
+  format: 'csv',
+  params: GetLogStatsParams,
+  isAdmin: boolean
+) {
+  const queryParams = buildQueryParams({
+    ...(params as unknown as Record<string, unknown>),
+    format,
+  })
+  const endpoint = kind === 'logs' ? '/api/log' : '/api/data'
+  const path = isAdmin ? `${endpoint}/export` : `${endpoint}/self/export`
+  const res = await api.get(`${path}?${queryParams}`, { responseType: 'blob' })
+  const contentType = String(res.headers['content-type'] || '')
+  if (contentType.includes('application/json')) {
+    const { message } = JSON.parse(await (res.data as Blob).text())
+    throw new Error(message)
+  }
+  const disposition = String(res.headers['content-disposition'] || '')
+  const matched = disposition.match(/filename="?([^";]+)"?/)
+  const objectUrl = URL.createObjectURL(res.data as Blob)
+  const a = document.createElement('a')
+  a.href = objectUrl
+  a.download = matched ? matched[1] : `${kind}.${format}`
+  a.click()
+  setTimeout(() => URL.revokeObjectURL(objectUrl), 1000)
+}
+
 export async function getUserInfo(
   userId: number
 ): Promise<{ success: boolean; message?: string; data?: UserInfo }> {
@@ -250,6 +250,20 @@ function buildDetailSegments(
     })
   }
 
+  if (other.response_cache_hit) {
+    segments.push({
+      text: `${t('Response Cache Hit')} ${formatRatioCompact(other.response_cache_ratio ?? 0)}x`,
+      muted: true,
+    })
+  }
+
+  if (other.semantic_cache_similarity) {
+    segments.push({
+      text: `${t('Semantic Similarity')} ${other.semantic_cache_similarity.toFixed(4)}`,
+      muted: true,
+    })
+  }
+
   return segments
 }
 
@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2023-2026 QuantumNous
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as
+published by the Free Software Foundation, either version 3 of the
+License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+For commercial licensing, please contact support@quantumnous.com
+*/
+import { useState } from 'react'
+import { getRouteApi } from '@tanstack/react-router'
+import { Download, Loader2 } from 'lucide-react'
+import { useTranslation } from 'react-i18next'
+import { toast } from 'sonner'
+import { useIsAdmin } from '@/hooks/use-admin'
+import { Button } from '@/components/ui/button'
+import {
+  DropdownMenu,
+  DropdownMenuContent,
+  DropdownMenuItem,
+  DropdownMenuSeparator,
+  DropdownMenuTrigger,
+} from '@/components/ui/dropdown-menu'
+import { exportLogs } from '../api'
+import { buildApiParams } from '../lib/utils'
+
+const route = getRouteApi('/_authenticated/usage-logs/$section')
+
+/**
+ * Export menu for the Common Logs view. Downloads either the log details or
+ * the hourly usage summary as CSV, using the filters currently in
+ * the URL (pagination is ignored so the whole result set is exported).
+ */
+export function CommonLogsExportButton() {
+  const { t } = useTranslation()
+  const isAdmin = useIsAdmin()
+  const searchParams = route.useSearch()
+  const [exporting, setExporting] = useState(false)
+
+  const handleExport = async (
+    kind: 'logs' | 'usage',
+    format: 'csv'
+  ) => {
+    const { p: _p, page_size: _pageSize, ...params } = buildApiParams({
+      page: 1,
+      pageSize: 1,
+      searchParams,
+      columnFilters: [],
+      isAdmin,
+    })
+    setExporting(true)
+    try {
+      await exportLogs(kind, format, params, isAdmin)
+    } catch (error) {
+      toast.error((error as Error).message || t('Export failed'))
+    } finally {
+      setExporting(false)
+    }
+  }
+
+  return (
+    <DropdownMenu modal={false}>
+      <DropdownMenuTrigger
+        render={
+          <Button
+            variant='outline'
+            size='sm'
+            className='h-7'
+            disabled={exporting}
+          />
+        }
+      >
+        {exporting ? <Loader2 className='animate-spin' /> : <Download />}
+        {t('Export')}
+      </DropdownMenuTrigger>
+      <DropdownMenuContent align='end' className='w-[240px]'>
+        <DropdownMenuItem onClick={() => handleExport('logs', 'csv')}>
+          {t('Export log details')} (CSV)
+        </DropdownMenuItem>
+        <DropdownMenuSeparator />
+        <DropdownMenuItem onClick={() => handleExport('usage', 'csv')}>
+          {t('Export hourly usage summary')} (CSV)
+        </DropdownMenuItem>
+      </DropdownMenuContent>
+    </DropdownMenu>
+  )
+}
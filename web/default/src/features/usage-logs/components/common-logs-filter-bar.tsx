@@ -87,6 +87,8 @@ export function CommonLogsFilterBar<TData>(
     if (searchParams.token) next.token = searchParams.token
     if (searchParams.group) next.group = searchParams.group
     if (searchParams.username) next.username = searchParams.username
+    if (searchParams.organization)
+      next.organization = searchParams.organization
     if (searchParams.requestId) next.requestId = searchParams.requestId
     if (searchParams.upstreamRequestId)
       next.upstreamRequestId = searchParams.upstreamRequestId
@@ -107,6 +109,7 @@ export function CommonLogsFilterBar<TData>(
     searchParams.token,
     searchParams.group,
     searchParams.username,
+    searchParams.organization,
     searchParams.requestId,
     searchParams.upstreamRequestId,
     searchParams.type,
@@ -164,6 +167,7 @@ export function CommonLogsFilterBar<TData>(
     !!filters.token ||
     !!filters.username ||
     !!filters.channel ||
+    !!filters.organization ||
     !!filters.requestId ||
     !!filters.upstreamRequestId
 
@@ -287,6 +291,15 @@ export function CommonLogsFilterBar<TData>(
               className={inputClass}
             />
           )}
+          {isAdmin && (
+            <Input
+              placeholder={t('Organization ID')}
+              value={filters.organization || ''}
+              onChange={(e) => handleChange('organization', e.target.value)}
+              onKeyDown={handleKeyDown}
+              className={inputClass}
+            />
+          )}
           <Input
             placeholder={t('Request ID')}
             value={filters.requestId || ''}
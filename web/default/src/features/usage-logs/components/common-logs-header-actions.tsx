@@ -24,15 +24,16 @@ import {
   TooltipContent,
   TooltipTrigger,
 } from '@/components/ui/tooltip'
+import { CommonLogsExportButton } from './common-logs-export-button'
 import { CommonLogsStats } from './common-logs-stats'
 import { useUsageLogsContext } from './usage-logs-provider'
 
 /**
- * Page-header actions for the Common Logs view: live usage stats plus a
- * toggle for masking sensitive values (token names, usernames, group names,
- * and the quota figure shown in stats). Both controls live in the page
- * header so the toolbar below stays focused on filter inputs and form
- * actions only.
+ * Page-header actions for the Common Logs view: live usage stats, the export
+ * menu, and a toggle for masking sensitive values (token names, usernames,
+ * group names, and the quota figure shown in stats). These controls live in
+ * the page header so the toolbar below stays focused on filter inputs and
+ * form actions only.
  */
 export function CommonLogsHeaderActions() {
   const { t } = useTranslation()
@@ -41,6 +42,7 @@ export function CommonLogsHeaderActions() {
   return (
     <div className='flex flex-wrap items-center gap-2'>
       <CommonLogsStats />
+      <CommonLogsExportButton />
       <Tooltip>
         <TooltipTrigger
           render={
@@ -47,6 +47,7 @@ import { Label } from '@/components/ui/label'
 import { ScrollArea } from '@/components/ui/scroll-area'
 import { StatusBadge, type StatusBadgeProps } from '@/components/status-badge'
 import { DynamicPricingBreakdown } from '@/features/pricing/components/dynamic-pricing-breakdown'
+import { ROUTING_STRATEGY_LABELS } from '../../constants'
 import type { UsageLog } from '../../data/schema'
 import {
   parseLogOther,
@@ -483,6 +484,37 @@ export function DetailsDialog(props: DetailsDialogProps) {
   const useChannel = other?.admin_info?.use_channel
   const channelChain =
     useChannel && useChannel.length > 0 ? useChannel.join(' → ') : undefined
+  const routingStrategy = other?.admin_info?.routing_strategy
+  const hedge = other?.admin_info?.hedge
+  const hedgeLabel = hedge
+    ? hedge.winner_channel
+      ? t('#{{primary}} vs #{{hedge}} after {{delay}}ms, #{{winner}} won', {
+          primary: hedge.primary_channel,
+          hedge: hedge.hedge_channel,
+          delay: hedge.delay_ms,
+          winner: hedge.winner_channel,
+        })
+      : t('#{{primary}} vs #{{hedge}} after {{delay}}ms, no winner', {
+          primary: hedge.primary_channel,
+          hedge: hedge.hedge_channel,
+          delay: hedge.delay_ms,
+        })
+    : undefined
+  const streamFailover = other?.admin_info?.stream_failover
+  const streamFailoverLabel = streamFailover
+    ? streamFailover.resumed
+      ? t('Interrupted on {{channels}}, continued on #{{channel}}', {
+          channels: streamFailover.interrupted_channels
+            .map((id) => `#${id}`)
+            .join(', '),
+          channel: props.log.channel,
+        })
+      : t('Interrupted on {{channels}}, not continued', {
+          channels: streamFailover.interrupted_channels
+            .map((id) => `#${id}`)
+            .join(', '),
+        })
+    : undefined
 
   return (
     <Dialog open={props.open} onOpenChange={props.onOpenChange}>
@@ -549,6 +581,31 @@ export function DetailsDialog(props: DetailsDialogProps) {
                 <DetailRow label={t('Retry Chain')} value={channelChain} mono />
               )}
 
+              {routingStrategy && props.isAdmin && (
+                <DetailRow
+                  label={t('Routing Strategy')}
+                  value={t(
+                    ROUTING_STRATEGY_LABELS[routingStrategy] ?? routingStrategy
+                  )}
+                />
+              )}
+
+              {hedgeLabel && props.isAdmin && (
+                <DetailRow
+                  label={t('Hedged Request')}
+                  value={hedgeLabel}
+                  mono
+                />
+              )}
+
+              {streamFailoverLabel && props.isAdmin && (
+                <DetailRow
+                  label={t('Stream Failover')}
+                  value={streamFailoverLabel}
+                  mono
+                />
+              )}
+
               {props.log.token_name && (
                 <DetailRow
                   label={t('Token')}
@@ -845,6 +902,22 @@ export function DetailsDialog(props: DetailsDialogProps) {
               </DetailSection>
             )}
 
+            {/* Model fallback */}
+            {other?.model_fallback_from && (
+              <DetailSection label={t('Model Fallback')}>
+                <DetailRow
+                  label={t('Requested Model')}
+                  value={other.model_fallback_from}
+                  mono
+                />
+                <DetailRow
+                  label={t('Fallback Model')}
+                  value={props.log.model_name}
+                  mono
+                />
+              </DetailSection>
+            )}
+
             {/* Token breakdown (for consume/error types with token data) */}
             {isDisplayableType(props.log.type) && other && (
               <TokenBreakdown log={props.log} other={other} />
@@ -323,6 +323,16 @@ export const LOG_CATEGORY_LABELS: Record<LogCategory, string> = {
   task: 'Task',
 }
 
+/**
+ * Channel routing strategy display labels (admin info)
+ */
+export const ROUTING_STRATEGY_LABELS: Record<string, string> = {
+  weighted_random: 'Weighted random',
+  least_latency: 'Least latency',
+  least_cost: 'Least cost',
+  least_outstanding: 'Least outstanding requests',
+}
+
 // ============================================================================
 // Log Type Checkers (Constants)
 // ============================================================================
@@ -51,6 +51,7 @@ export interface CommonLogFilters extends CommonFilters {
   token?: string
   group?: string
   username?: string
+  organization?: string
   requestId?: string
   upstreamRequestId?: string
 }
@@ -92,6 +93,20 @@ export interface ChannelAffinityInfo {
   using_group?: string
 }
 
+export interface HedgeInfo {
+  primary_channel: number
+  hedge_channel: number
+  winner_channel?: number
+  delay_ms: number
+  loser_billed: boolean
+}
+
+export interface StreamFailoverInfo {
+  interrupted_channels: number[]
+  failovers: number
+  resumed: boolean
+}
+
 export interface LogOtherData {
   admin_info?: {
     is_multi_key?: boolean
@@ -99,6 +114,9 @@ export interface LogOtherData {
     use_channel?: number[]
     local_count_tokens?: boolean
     channel_affinity?: ChannelAffinityInfo
+    routing_strategy?: string
+    hedge?: HedgeInfo
+    stream_failover?: StreamFailoverInfo
     // Top-up audit fields (type=1, admin only)
     payment_method?: string
     callback_payment_method?: string
@@ -134,6 +152,7 @@ export interface LogOtherData {
   cache_creation_ratio_1h?: number
   is_model_mapped?: boolean
   upstream_model_name?: string
+  model_fallback_from?: string
   audio_ratio?: number
   audio_completion_ratio?: number
   frt?: number
@@ -159,6 +178,10 @@ export interface LogOtherData {
   image_generation_call?: boolean
   image_generation_call_price?: number
   is_system_prompt_overwritten?: boolean
+  response_cache_hit?: boolean
+  response_cache_ratio?: number
+  response_cache_origin_quota?: number
+  semantic_cache_similarity?: number
   po?: string[]
   billing_source?: string
   group?: string
@@ -266,6 +289,7 @@ export interface GetLogsParams {
   start_timestamp?: number
   end_timestamp?: number
   channel?: number
+  organization_id?: number
   group?: string
   request_id?: string
   upstream_request_id?: string
@@ -290,6 +314,7 @@ export interface GetLogStatsParams {
   start_timestamp?: number
   end_timestamp?: number
   channel?: number
+  organization_id?: number
   group?: string
   request_id?: string
   upstream_request_id?: string
@@ -233,3 +233,48 @@ export async function completeOrder(
   const res = await api.post('/api/user/topup/complete', request)
   return res.data
 }
+
+/**
+ * Download a file (invoice, statement) from an authenticated endpoint.
+ * Failures are returned as JSON, so rethrow them with the server message.
+ */
+async function downloadFile(url: string, fallbackFilename: string) {
+  const res = await api.get(url, { responseType: 'blob' })
+  const contentType = String(res.headers['content-type'] || '')
+  if (contentType.includes('application/json')) {
+    const { message } = JSON.parse(await (res.data as Blob).text())
+    throw new Error(message)
+  }
+  const disposition = String(res.headers['content-disposition'] || '')
+  const matched = disposition.match(/filename="?([^";]+)"?/)
+  const objectUrl = URL.createObjectURL(res.data as Blob)
+  const a = document.createElement('a')
+  a.href = objectUrl
+  a.download = matched ? matched[1] : fallbackFilename
+  a.click()
+  setTimeout(() => URL.revokeObjectURL(objectUrl), 1000)
+}
+
+/**
+ * Download the printable invoice of a completed topup or subscription order
+ */
+export async function downloadInvoice(topupId: number, tradeNo: string) {
+  await downloadFile(
+    `/api/user/topup/${topupId}/invoice`,
+    `invoice-${tradeNo}.html`
+  )
+}
+
+/**
+ * Download the monthly statement of the current user (month: YYYY-MM)
+ */
+export async function downloadStatement(
+  month: string,
+  format: 'html' | 'csv'
+) {
+  const params = new URLSearchParams({ month, format })
+  await downloadFile(
+    `/api/user/statement?${params.toString()}`,
+    `statement-${month}.${format}`
+  )
+}
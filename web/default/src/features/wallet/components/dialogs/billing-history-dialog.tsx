@@ -17,11 +17,20 @@ along with this program. If not, see <https://www.gnu.org/licenses/>.
 For commercial licensing, please contact support@quantumnous.com
 */
 import { useState } from 'react'
-import { Search, Copy, Check, ChevronLeft, ChevronRight } from 'lucide-react'
+import {
+  Search,
+  Copy,
+  Check,
+  ChevronLeft,
+  ChevronRight,
+  Download,
+  FileText,
+} from 'lucide-react'
 import { useTranslation } from 'react-i18next'
 import { formatCurrencyFromUSD } from '@/lib/currency'
 import { formatNumber } from '@/lib/format'
 import { useCopyToClipboard } from '@/hooks/use-copy-to-clipboard'
+import { useStatus } from '@/hooks/use-status'
 import {
   AlertDialog,
   AlertDialogAction,
@@ -83,7 +92,15 @@ export function BillingHistoryDialog({
     handlePageSizeChange,
     handleSearch,
     handleCompleteOrder,
+    handleDownloadInvoice,
+    handleDownloadStatement,
   } = useBillingHistory()
+  const { status } = useStatus()
+  const invoiceEnabled = status?.invoice_enabled === true
+  const [statementMonth, setStatementMonth] = useState(() => {
+    const now = new Date()
+    return `${now.getFullYear()}-${String(now.getMonth() + 1).padStart(2, '0')}`
+  })
 
   const [confirmTradeNo, setConfirmTradeNo] = useState<string | null>(null)
   const { copyToClipboard, copiedText } = useCopyToClipboard({ notify: false })
@@ -148,6 +165,46 @@ export function BillingHistoryDialog({
               </Select>
             </div>
 
+            {/* Monthly Statement */}
+            {invoiceEnabled && !isAdmin && (
+              <div className='flex flex-wrap items-center gap-2 rounded-lg border p-3'>
+                <Label
+                  htmlFor='statement-month'
+                  className='text-sm font-medium'
+                >
+                  {t('Monthly Statement')}
+                </Label>
+                <Input
+                  id='statement-month'
+                  type='month'
+                  value={statementMonth}
+                  onChange={(e) => setStatementMonth(e.target.value)}
+                  className='h-8 w-40'
+                />
+                <Button
+                  size='sm'
+                  variant='outline'
+                  disabled={!statementMonth}
+                  onClick={() =>
+                    handleDownloadStatement(statementMonth, 'html')
+                  }
+                >
+                  <Download className='mr-1 h-3.5 w-3.5' />
+                  {t('Download Statement')}
+                </Button>
+                <Button
+                  size='sm'
+                  variant='outline'
+                  disabled={!statementMonth}
+                  onClick={() =>
+                    handleDownloadStatement(statementMonth, 'csv')
+                  }
+                >
+                  CSV
+                </Button>
+              </div>
+            )}
+
             {/* Records List */}
             <ScrollArea className='h-[calc(100dvh-15rem)] pr-3 sm:h-[500px] sm:pr-4'>
               {loading ? (
@@ -261,7 +318,7 @@ export function BillingHistoryDialog({
                           </div>
                         </div>
 
-                        {/* Admin Actions */}
+                        {/* Actions */}
                         {isAdmin && record.status === 'pending' && (
                           <div className='mt-4 flex justify-end'>
                             <Button
@@ -274,6 +331,19 @@ export function BillingHistoryDialog({
                             </Button>
                           </div>
                         )}
+                        {(isAdmin || invoiceEnabled) &&
+                          record.status === 'success' && (
+                            <div className='mt-4 flex justify-end'>
+                              <Button
+                                size='sm'
+                                variant='ghost'
+                                onClick={() => handleDownloadInvoice(record)}
+                              >
+                                <FileText className='mr-1 h-3.5 w-3.5' />
+                                {t('Invoice')}
+                              </Button>
+                            </div>
+                          )}
                       </div>
                     )
                   })}
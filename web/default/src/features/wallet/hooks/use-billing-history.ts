@@ -24,6 +24,8 @@ import {
   getUserBillingHistory,
   getAllBillingHistory,
   completeOrder,
+  downloadInvoice,
+  downloadStatement,
   isApiSuccess,
 } from '../api'
 import type { TopupRecord } from '../types'
@@ -116,6 +118,35 @@ export function useBillingHistory(options: UseBillingHistoryOptions = {}) {
     [isAdmin, fetchBillingHistory]
   )
 
+  /**
+   * Download the invoice of a completed order
+   */
+  const handleDownloadInvoice = useCallback(async (record: TopupRecord) => {
+    try {
+      await downloadInvoice(record.id, record.trade_no)
+    } catch (error) {
+      toast.error(
+        (error as Error).message || i18next.t('Failed to download file')
+      )
+    }
+  }, [])
+
+  /**
+   * Download the monthly statement (month: YYYY-MM)
+   */
+  const handleDownloadStatement = useCallback(
+    async (month: string, format: 'html' | 'csv') => {
+      try {
+        await downloadStatement(month, format)
+      } catch (error) {
+        toast.error(
+          (error as Error).message || i18next.t('Failed to download file')
+        )
+      }
+    },
+    []
+  )
+
   /**
    * Change page
    */
@@ -157,6 +188,8 @@ export function useBillingHistory(options: UseBillingHistoryOptions = {}) {
     handlePageSizeChange,
     handleSearch,
     handleCompleteOrder,
+    handleDownloadInvoice,
+    handleDownloadStatement,
     refresh: fetchBillingHistory,
   }
 }